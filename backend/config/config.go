@@ -34,6 +34,7 @@ var (
 	maxNumUsers             = utils.GetEnvVarInt("YEETFILE_MAX_NUM_USERS", -1)
 	password                = []byte(utils.GetEnvVar("YEETFILE_SERVER_PASSWORD", ""))
 	allowInsecureLinks      = utils.GetEnvVarBool("YEETFILE_ALLOW_INSECURE_LINKS", false)
+	usageWarnings           = utils.GetEnvVarIntList("YEETFILE_USAGE_WARNINGS", []int{80, 95})
 
 	// Limiter config
 	limiterSeconds  = utils.GetEnvVarInt("YEETFILE_LIMITER_SECONDS", 30)
@@ -41,6 +42,7 @@ var (
 
 	defaultSecret     = []byte("yeetfile-debug-secret-key-123456")
 	secret            = utils.GetEnvVarBytesB64("YEETFILE_SERVER_SECRET", defaultSecret)
+	previousSecrets   = utils.GetEnvVarBytesB64List("YEETFILE_PREVIOUS_SERVER_SECRETS")
 	fallbackWebSecret = utils.GetEnvVarBytesB64(
 		"YEETFILE_FALLBACK_WEB_SECRET",
 		securecookie.GenerateRandomKey(32))
@@ -53,6 +55,10 @@ var (
 	InstanceAdmin  = utils.GetEnvVar("YEETFILE_INSTANCE_ADMIN", "")
 	InvitesAllowed = utils.GetEnvVarBool("YEETFILE_ALLOW_INVITES", false)
 	Banner         = utils.GetEnvVar("YEETFILE_BANNER", "")
+
+	UserInviteAllowance = utils.GetEnvVarInt("YEETFILE_USER_INVITE_ALLOWANCE", 0)
+
+	SignupApprovalRequired = utils.GetEnvVarBool("YEETFILE_REQUIRE_SIGNUP_APPROVAL", false)
 )
 
 // =============================================================================
@@ -107,6 +113,56 @@ var btcPayBilling = BTCPayBillingConfig{
 	WebhookSecret: os.Getenv("YEETFILE_BTCPAY_WEBHOOK_SECRET"),
 }
 
+// =============================================================================
+// Billing configuration (Manual invoices)
+// =============================================================================
+
+type ManualBillingConfig struct {
+	Configured   bool
+	Instructions string
+}
+
+var manualBilling = ManualBillingConfig{
+	Instructions: os.Getenv("YEETFILE_MANUAL_BILLING_INSTRUCTIONS"),
+}
+
+// =============================================================================
+// OpenID Connect configuration (single sign-on)
+// =============================================================================
+
+type OIDCConfig struct {
+	Configured   bool
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Required     bool
+}
+
+var oidc = OIDCConfig{
+	Issuer:       strings.TrimSuffix(os.Getenv("YEETFILE_OIDC_ISSUER"), "/"),
+	ClientID:     os.Getenv("YEETFILE_OIDC_CLIENT_ID"),
+	ClientSecret: os.Getenv("YEETFILE_OIDC_CLIENT_SECRET"),
+	Required:     utils.GetEnvVarBool("YEETFILE_OIDC_REQUIRED", false),
+}
+
+// =============================================================================
+// Key derivation configuration
+// =============================================================================
+
+// kdf holds the recommended parameters for deriving user keys. New accounts
+// use these parameters, and existing accounts are upgraded to them the next
+// time the user logs in.
+var kdf = shared.KDFParams{
+	Algorithm: constants.KDFArgon2id,
+	Iterations: uint32(utils.GetEnvVarInt(
+		"YEETFILE_KDF_ITERATIONS",
+		int(constants.Argon2Iter))),
+	Memory: uint32(utils.GetEnvVarInt(
+		"YEETFILE_KDF_MEMORY",
+		int(constants.Argon2Mem))),
+}
+
 // =============================================================================
 // Full server config
 // =============================================================================
@@ -124,14 +180,18 @@ type ServerConfig struct {
 	Email               EmailConfig
 	StripeBilling       StripeBillingConfig
 	BTCPayBilling       BTCPayBillingConfig
+	ManualBilling       ManualBillingConfig
+	OIDC                OIDCConfig
+	KDF                 shared.KDFParams
 	BillingEnabled      bool
 	Version             string
 	PasswordHash        []byte
-	ServerSecret        []byte
+	ServerSecrets       [][]byte // Current secret first, followed by previous secrets
 	FallbackWebSecret   []byte
 	AllowInsecureLinks  bool
 	LimiterSeconds      int
 	LimiterAttempts     int
+	UsageWarnings       []int
 }
 
 type TemplateConfig struct {
@@ -142,6 +202,9 @@ type TemplateConfig struct {
 	BillingEnabled   bool
 	StripeEnabled    bool
 	BTCPayEnabled    bool
+	ManualEnabled    bool
+	OIDCEnabled      bool
+	OIDCRequired     bool
 }
 
 var YeetFileConfig ServerConfig
@@ -151,6 +214,21 @@ func init() {
 	email.Configured = !utils.IsStructMissingAnyField(email)
 	stripeBilling.Configured = !utils.IsStructMissingAnyField(stripeBilling)
 	btcPayBilling.Configured = !utils.IsStructMissingAnyField(btcPayBilling)
+	manualBilling.Configured = !utils.IsStructMissingAnyField(manualBilling)
+
+	// The client secret is optional, since PKCE allows public clients
+	oidc.Configured = len(oidc.Issuer) > 0 && len(oidc.ClientID) > 0
+	if oidc.Configured {
+		if len(domain) == 0 {
+			log.Fatal("ERROR: YEETFILE_DOMAIN must be set if " +
+				"YEETFILE_OIDC_ISSUER is set.")
+		}
+
+		oidc.RedirectURL = strings.TrimSuffix(domain, "/") + "/api/oidc/callback"
+	} else if oidc.Required {
+		log.Fatal("ERROR: YEETFILE_OIDC_ISSUER and YEETFILE_OIDC_CLIENT_ID " +
+			"must be set if YEETFILE_OIDC_REQUIRED is enabled.")
+	}
 
 	var passwordHash []byte
 	var err error
@@ -179,6 +257,37 @@ func init() {
 			"bytes are required.", len(secret), constants.KeySize)
 	}
 
+	for i, previousSecret := range previousSecrets {
+		if len(previousSecret) != constants.KeySize {
+			log.Fatalf("ERROR: YEETFILE_PREVIOUS_SERVER_SECRETS value #%d is "+
+				"%d bytes, but %d bytes are required.",
+				i+1, len(previousSecret), constants.KeySize)
+		} else if slices.Equal(previousSecret, secret) {
+			log.Fatal("ERROR: YEETFILE_PREVIOUS_SERVER_SECRETS must not " +
+				"contain the current YEETFILE_SERVER_SECRET.")
+		}
+	}
+
+	if slices.Equal(usageWarnings, []int{-1}) {
+		usageWarnings = nil
+	}
+
+	for _, threshold := range usageWarnings {
+		if threshold < 1 || threshold > 99 {
+			log.Fatalf("ERROR: YEETFILE_USAGE_WARNINGS must only contain " +
+				"percentages between 1-99")
+		}
+	}
+
+	slices.Sort(usageWarnings)
+
+	if kdf.Validate() != nil {
+		log.Fatalf("ERROR: YEETFILE_KDF_ITERATIONS must be between %d-%d "+
+			"and YEETFILE_KDF_MEMORY must be between %d-%d (MB)",
+			constants.Argon2Iter, constants.MaxArgon2Iter,
+			constants.Argon2Mem, constants.MaxArgon2Mem)
+	}
+
 	if maxSendDownloads == 0 || maxSendDownloads < -1 {
 		log.Fatalf("ERROR: YEETFILE_MAX_SEND_DOWNLOADS must be -1 " +
 			"(unlimited) or set to a number greater than 0")
@@ -189,6 +298,10 @@ func init() {
 			"(unlimited) or set to greater than 0 days")
 	}
 
+	billingEnabled := stripeBilling.Configured ||
+		btcPayBilling.Configured ||
+		manualBilling.Configured
+
 	YeetFileConfig = ServerConfig{
 		StorageType:         storageType,
 		Domain:              domain,
@@ -201,14 +314,18 @@ func init() {
 		Email:               email,
 		StripeBilling:       stripeBilling,
 		BTCPayBilling:       btcPayBilling,
-		BillingEnabled:      stripeBilling.Configured || btcPayBilling.Configured,
+		ManualBilling:       manualBilling,
+		OIDC:                oidc,
+		KDF:                 kdf,
+		BillingEnabled:      billingEnabled,
 		Version:             constants.VERSION,
 		PasswordHash:        passwordHash,
-		ServerSecret:        secret,
+		ServerSecrets:       append([][]byte{secret}, previousSecrets...),
 		FallbackWebSecret:   fallbackWebSecret,
 		AllowInsecureLinks:  allowInsecureLinks,
 		LimiterSeconds:      limiterSeconds,
 		LimiterAttempts:     limiterAttempts,
+		UsageWarnings:       usageWarnings,
 	}
 
 	// Subset of main server config to use in HTML templating
@@ -219,15 +336,22 @@ func init() {
 		BillingEnabled: YeetFileConfig.BillingEnabled,
 		StripeEnabled:  YeetFileConfig.StripeBilling.Configured,
 		BTCPayEnabled:  YeetFileConfig.BTCPayBilling.Configured,
+		ManualEnabled:  YeetFileConfig.ManualBilling.Configured,
+		OIDCEnabled:    YeetFileConfig.OIDC.Configured,
+		OIDCRequired:   YeetFileConfig.OIDC.Required,
 	}
 
 	log.Printf("Configuration:\n"+
 		"  Email:            %v\n"+
 		"  Billing (Stripe): %v\n"+
-		"  Billing (BTCPay): %v\n",
+		"  Billing (BTCPay): %v\n"+
+		"  Billing (Manual): %v\n"+
+		"  OIDC:             %v\n",
 		email.Configured,
 		stripeBilling.Configured,
 		btcPayBilling.Configured,
+		manualBilling.Configured,
+		oidc.Configured,
 	)
 
 	if IsDebugMode {
@@ -245,6 +369,13 @@ func logWarning(warnings ...string) {
 	log.Println(strings.Repeat("@", 57))
 }
 
+// SupportedFileEncryption are the file encryption formats that clients can use
+// when uploading new files
+var SupportedFileEncryption = []int{
+	constants.FileEncryptionV1,
+	constants.FileEncryptionV2,
+}
+
 func GetServerInfoStruct() shared.ServerInfo {
 	var storageBackend string
 	if storageType == B2Storage {
@@ -263,10 +394,16 @@ func GetServerInfoStruct() shared.ServerInfo {
 		MaxSendExpiry:      YeetFileConfig.MaxSendExpiry,
 		EmailConfigured:    YeetFileConfig.Email.Configured,
 		BillingEnabled:     YeetFileConfig.BillingEnabled,
-		StripeEnabled:      YeetFileConfig.BTCPayBilling.Configured,
-		BTCPayEnabled:      YeetFileConfig.StripeBilling.Configured,
+		StripeEnabled:      YeetFileConfig.StripeBilling.Configured,
+		BTCPayEnabled:      YeetFileConfig.BTCPayBilling.Configured,
+		ManualEnabled:      YeetFileConfig.ManualBilling.Configured,
+		InvitesAllowed:     InvitesAllowed,
+		ApprovalRequired:   SignupApprovalRequired,
+		OIDCEnabled:        YeetFileConfig.OIDC.Configured,
+		OIDCRequired:       YeetFileConfig.OIDC.Required,
 		DefaultStorage:     YeetFileConfig.DefaultUserStorage,
 		DefaultSend:        YeetFileConfig.DefaultUserSend,
+		FileEncryption:     SupportedFileEncryption,
 
 		Upgrades:      *allUpgrades,
 		MonthUpgrades: upgrades.GetVaultUpgrades(false, allUpgrades.VaultUpgrades),
@@ -20,6 +20,11 @@ const (
 	UpgradeTask    = "upgrade"
 	UpgradeExpTask = "upgrade-expiration"
 	B2AuthTask     = "b2-auth-task"
+	UsageWarnTask  = "usage-warning"
+	StatsTask      = "stats"
+	CatalogTask    = "upgrade-catalog"
+	OIDCTask       = "oidc-cleanup"
+	EmergencyTask  = "emergency-access"
 )
 
 type CronTask struct {
@@ -37,6 +42,11 @@ type CronTask struct {
 // - a bandwidth task for resetting user bandwidth every N days
 // - an upgrade monitoring task for instances with billing enabled
 // - a downloads cleanup task that removes abandoned in-progress downloads
+// - a usage warning task that emails users approaching their usage limits
+// - a stats task that rolls up instance statistics for the admin dashboard
+// - a catalog task that reloads upgrades edited by the admin on any server
+// - an OIDC task that removes single sign-on logins that were never finished
+// - an emergency access task that grants access once the waiting period ends
 var tasks = []CronTask{
 	{
 		Name:           ExpiryTask,
@@ -81,6 +91,47 @@ var tasks = []CronTask{
 		Enabled:        true,
 		TaskFn:         db.CleanUpDownloads,
 	},
+	{
+		// Only enable if email is set up and warnings haven't been disabled
+		Name:           UsageWarnTask,
+		Interval:       time.Hour,
+		IntervalAmount: 1,
+		Enabled: config.YeetFileConfig.Email.Configured &&
+			len(config.YeetFileConfig.UsageWarnings) > 0,
+		TaskFn: db.CheckUsageWarnings,
+	},
+	{
+		Name:           StatsTask,
+		Interval:       time.Hour,
+		IntervalAmount: 1,
+		Enabled:        true,
+		TaskFn:         db.UpdateStats,
+	},
+	{
+		Name:           CatalogTask,
+		Interval:       time.Minute,
+		IntervalAmount: 1,
+		Enabled:        true,
+		TaskFn:         db.RefreshUpgrades,
+
+		// Each server keeps its own copy of the catalog in memory
+		SkipAdvisoryLock: true,
+	},
+	{
+		// Only enable if single sign-on is set up
+		Name:           OIDCTask,
+		Interval:       time.Hour,
+		IntervalAmount: 1,
+		Enabled:        config.YeetFileConfig.OIDC.Configured,
+		TaskFn:         db.CleanUpOIDCRequests,
+	},
+	{
+		Name:           EmergencyTask,
+		Interval:       time.Hour,
+		IntervalAmount: 1,
+		Enabled:        true,
+		TaskFn:         db.CheckEmergencyAccess,
+	},
 	{
 		Name:           B2AuthTask,
 		Interval:       time.Hour,
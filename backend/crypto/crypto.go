@@ -1,50 +1,171 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"io"
 	"yeetfile/backend/config"
 )
 
+// Values encrypted with a server secret are prefixed with a header containing
+// the ID of the key used to encrypt them, which allows the server secret to be
+// rotated without losing access to existing values:
+//
+// "yfs" | version (1 byte) | key ID (4 bytes) | nonce | AES-GCM ciphertext
+//
+// Values encrypted before key IDs were introduced have no header, and are
+// decrypted with the oldest secret in the keyring.
+const (
+	headerVersion = 1
+	keyIDSize     = 4
+)
+
+var headerMagic = []byte("yfs")
+
+var (
+	UnknownKeyErr      = errors.New("value was encrypted with an unknown server secret")
+	ShortCiphertextErr = errors.New("ciphertext too short")
+)
+
+// KeyID returns the ID for a server secret, which is included in the header of
+// each value encrypted with that secret.
+func KeyID(key []byte) []byte {
+	hash := sha256.Sum256(append([]byte("yeetfile-server-secret"), key...))
+	return hash[:keyIDSize]
+}
+
+// FormatKeyID returns a readable version of a key ID
+func FormatKeyID(keyID []byte) string {
+	return hex.EncodeToString(keyID)
+}
+
+// CurrentKeyID returns the ID of the server secret used for new encryptions
+func CurrentKeyID() []byte {
+	return KeyID(config.YeetFileConfig.ServerSecrets[0])
+}
+
+// ValueKeyID returns the ID of the server secret that was used to encrypt the
+// value, or nil if the value was encrypted before key IDs were introduced.
+func ValueKeyID(data []byte) []byte {
+	headerSize := len(headerMagic) + 1 + keyIDSize
+	if len(data) < headerSize ||
+		!bytes.HasPrefix(data, headerMagic) ||
+		data[len(headerMagic)] != headerVersion {
+		return nil
+	}
+
+	return data[len(headerMagic)+1 : headerSize]
+}
+
+// NeedsReEncrypt returns true if the value wasn't encrypted with the current
+// server secret.
+func NeedsReEncrypt(data []byte) bool {
+	return !bytes.Equal(ValueKeyID(data), CurrentKeyID())
+}
+
+// ReEncrypt decrypts the value with whichever server secret was used to
+// encrypt it and encrypts it again with the current server secret.
+func ReEncrypt(data []byte) ([]byte, error) {
+	text, err := Decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encrypt(text)
+}
+
+// Encrypt encrypts text with the current server secret
 func Encrypt(text string) ([]byte, error) {
-	block, err := aes.NewCipher(config.YeetFileConfig.ServerSecret)
+	key := config.YeetFileConfig.ServerSecrets[0]
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
 
-	b64Text := base64.StdEncoding.EncodeToString([]byte(text))
-	ciphertext := make([]byte, aes.BlockSize+len(b64Text))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	header := append(append(append([]byte{}, headerMagic...), headerVersion), KeyID(key)...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, err
 	}
 
-	cfb := cipher.NewCFBEncrypter(block, iv)
-	cfb.XORKeyStream(ciphertext[aes.BlockSize:], []byte(b64Text))
-	return ciphertext, nil
+	ciphertext := append(header, nonce...)
+	return gcm.Seal(ciphertext, nonce, []byte(text), header), nil
 }
 
+// Decrypt decrypts a value using the server secret that was used to encrypt it
 func Decrypt(data []byte) (string, error) {
-	block, err := aes.NewCipher(config.YeetFileConfig.ServerSecret)
+	keyID := ValueKeyID(data)
+	if keyID == nil {
+		return decryptLegacy(data)
+	}
+
+	var key []byte
+	for _, secret := range config.YeetFileConfig.ServerSecrets {
+		if bytes.Equal(KeyID(secret), keyID) {
+			key = secret
+			break
+		}
+	}
+
+	if key == nil {
+		return "", UnknownKeyErr
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	headerSize := len(headerMagic) + 1 + keyIDSize
+	if len(data) < headerSize+gcm.NonceSize() {
+		return "", ShortCiphertextErr
+	}
+
+	header := data[:headerSize]
+	nonce := data[headerSize : headerSize+gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, data[headerSize+gcm.NonceSize():], header)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// decryptLegacy decrypts a value without a key ID header, which was encrypted
+// with AES-CFB using the original (oldest) server secret.
+func decryptLegacy(data []byte) (string, error) {
+	secrets := config.YeetFileConfig.ServerSecrets
+	block, err := aes.NewCipher(secrets[len(secrets)-1])
 	if err != nil {
 		return "", err
 	}
 
 	if len(data) < aes.BlockSize {
-		return "", errors.New("ciphertext too short")
+		return "", ShortCiphertextErr
 	}
 
 	iv := data[:aes.BlockSize]
-	data = data[aes.BlockSize:]
+	plaintext := make([]byte, len(data)-aes.BlockSize)
 	cfb := cipher.NewCFBDecrypter(block, iv)
-	cfb.XORKeyStream(data, data)
-	value, err := base64.StdEncoding.DecodeString(string(data))
+	cfb.XORKeyStream(plaintext, data[aes.BlockSize:])
+	value, err := base64.StdEncoding.DecodeString(string(plaintext))
 	if err != nil {
 		return "", err
 	}
 	return string(value), nil
 }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -1,8 +1,14 @@
 package crypto
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"testing"
+	"yeetfile/backend/config"
 )
 
 func TestEncryptDecrypt(t *testing.T) {
@@ -11,9 +17,76 @@ func TestEncryptDecrypt(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotEqual(t, len(text), len(encryptedVal))
 	assert.NotEqual(t, text, string(encryptedVal))
+	assert.Equal(t, CurrentKeyID(), ValueKeyID(encryptedVal))
+	assert.False(t, NeedsReEncrypt(encryptedVal))
 
 	decryptedVal, err := Decrypt(encryptedVal)
 	assert.Nil(t, err)
 
 	assert.Equal(t, text, decryptedVal)
 }
+
+func TestKeyRotation(t *testing.T) {
+	secrets := config.YeetFileConfig.ServerSecrets
+	defer func() { config.YeetFileConfig.ServerSecrets = secrets }()
+
+	oldKey := []byte("yeetfile-old-secret-key-12345678")
+	newKey := []byte("yeetfile-new-secret-key-12345678")
+
+	config.YeetFileConfig.ServerSecrets = [][]byte{oldKey}
+	encryptedVal, err := Encrypt("yeetfile")
+	assert.Nil(t, err)
+
+	// Values encrypted with a previous secret can still be decrypted
+	config.YeetFileConfig.ServerSecrets = [][]byte{newKey, oldKey}
+	assert.True(t, NeedsReEncrypt(encryptedVal))
+	decryptedVal, err := Decrypt(encryptedVal)
+	assert.Nil(t, err)
+	assert.Equal(t, "yeetfile", decryptedVal)
+
+	reEncryptedVal, err := ReEncrypt(encryptedVal)
+	assert.Nil(t, err)
+	assert.False(t, NeedsReEncrypt(reEncryptedVal))
+	assert.Equal(t, KeyID(newKey), ValueKeyID(reEncryptedVal))
+
+	// Once the previous secret is removed, only re-encrypted values can be
+	// decrypted
+	config.YeetFileConfig.ServerSecrets = [][]byte{newKey}
+	_, err = Decrypt(encryptedVal)
+	assert.ErrorIs(t, err, UnknownKeyErr)
+
+	decryptedVal, err = Decrypt(reEncryptedVal)
+	assert.Nil(t, err)
+	assert.Equal(t, "yeetfile", decryptedVal)
+}
+
+func TestLegacyDecrypt(t *testing.T) {
+	secrets := config.YeetFileConfig.ServerSecrets
+	defer func() { config.YeetFileConfig.ServerSecrets = secrets }()
+
+	oldKey := []byte("yeetfile-old-secret-key-12345678")
+	newKey := []byte("yeetfile-new-secret-key-12345678")
+
+	// Legacy values are AES-CFB encrypted base64 text with no key ID header
+	block, err := aes.NewCipher(oldKey)
+	assert.Nil(t, err)
+
+	b64Text := base64.StdEncoding.EncodeToString([]byte("yeetfile"))
+	legacyVal := make([]byte, aes.BlockSize+len(b64Text))
+	_, err = io.ReadFull(rand.Reader, legacyVal[:aes.BlockSize])
+	assert.Nil(t, err)
+	cfb := cipher.NewCFBEncrypter(block, legacyVal[:aes.BlockSize])
+	cfb.XORKeyStream(legacyVal[aes.BlockSize:], []byte(b64Text))
+
+	config.YeetFileConfig.ServerSecrets = [][]byte{newKey, oldKey}
+	assert.Nil(t, ValueKeyID(legacyVal))
+	assert.True(t, NeedsReEncrypt(legacyVal))
+
+	decryptedVal, err := Decrypt(legacyVal)
+	assert.Nil(t, err)
+	assert.Equal(t, "yeetfile", decryptedVal)
+
+	reEncryptedVal, err := ReEncrypt(legacyVal)
+	assert.Nil(t, err)
+	assert.Equal(t, KeyID(newKey), ValueKeyID(reEncryptedVal))
+}
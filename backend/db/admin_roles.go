@@ -0,0 +1,144 @@
+package db
+
+import (
+	"time"
+	"yeetfile/shared"
+)
+
+const (
+	AdminRoleOwner         = "owner"
+	AdminRoleUserManager   = "user-manager"
+	AdminRoleFileModerator = "file-moderator"
+	AdminRoleBilling       = "billing"
+	AdminRoleAuditor       = "auditor"
+)
+
+// AdminRoles contains every role that can be granted to a user
+var AdminRoles = []string{
+	AdminRoleOwner,
+	AdminRoleUserManager,
+	AdminRoleFileModerator,
+	AdminRoleBilling,
+	AdminRoleAuditor,
+}
+
+const (
+	adminAuditGrant  = "grant"
+	adminAuditRevoke = "revoke"
+)
+
+// GetAdminRole returns the admin role granted to the user. Returns
+// sql.ErrNoRows if the user doesn't have a role.
+func GetAdminRole(userID string) (string, error) {
+	var role string
+	s := `SELECT role FROM admin_roles WHERE user_id=$1`
+	err := db.QueryRow(s, userID).Scan(&role)
+	return role, err
+}
+
+// SetAdminRole grants a role to the user, replacing any role they previously
+// had, and records the change in the admin audit trail.
+func SetAdminRole(userID, role, actorID string) error {
+	now := time.Now().UTC()
+	s := `INSERT INTO admin_roles (user_id, role, granted_by, granted)
+	      VALUES ($1, $2, $3, $4)
+	      ON CONFLICT (user_id) DO UPDATE
+	      SET role=$2, granted_by=$3, granted=$4`
+	_, err := db.Exec(s, userID, role, actorID, now)
+	if err != nil {
+		return err
+	}
+
+	return addAdminAuditEntry(actorID, userID, adminAuditGrant, role, now)
+}
+
+// RemoveAdminRole revokes the user's admin role and records the change in the
+// admin audit trail.
+func RemoveAdminRole(userID, actorID string) error {
+	var role string
+	s := `DELETE FROM admin_roles WHERE user_id=$1 RETURNING role`
+	err := db.QueryRow(s, userID).Scan(&role)
+	if err != nil {
+		return err
+	}
+
+	return addAdminAuditEntry(
+		actorID,
+		userID,
+		adminAuditRevoke,
+		role,
+		time.Now().UTC())
+}
+
+// GetAdminRoleList returns every user that has been granted an admin role
+func GetAdminRoleList() ([]shared.AdminRoleEntry, error) {
+	s := `SELECT r.user_id, COALESCE(u.email, ''), r.role, r.granted_by, r.granted
+	      FROM admin_roles r
+	      LEFT JOIN users u ON u.id = r.user_id
+	      ORDER BY r.granted DESC`
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.AdminRoleEntry{}
+	for rows.Next() {
+		var entry shared.AdminRoleEntry
+		err = rows.Scan(
+			&entry.UserID,
+			&entry.Email,
+			&entry.Role,
+			&entry.GrantedBy,
+			&entry.Granted)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+// GetAdminAuditLog returns the most recent role changes, up to the provided
+// limit, with the newest changes first.
+func GetAdminAuditLog(limit int) ([]shared.AdminAuditEntry, error) {
+	s := `SELECT id, actor_id, target_id, action, role, created
+	      FROM admin_audit
+	      ORDER BY created DESC, id DESC
+	      LIMIT $1`
+	rows, err := db.Query(s, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.AdminAuditEntry{}
+	for rows.Next() {
+		var entry shared.AdminAuditEntry
+		err = rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.TargetID,
+			&entry.Action,
+			&entry.Role,
+			&entry.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+func addAdminAuditEntry(actorID, targetID, action, role string, now time.Time) error {
+	s := `INSERT INTO admin_audit (actor_id, target_id, action, role, created)
+	      VALUES ($1, $2, $3, $4, $5)`
+	_, err := db.Exec(s, actorID, targetID, action, role, now)
+	return err
+}
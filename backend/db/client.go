@@ -119,6 +119,8 @@ func init() {
 
 		version = scriptVersion
 	}
+
+	initUpgradeCatalog()
 }
 
 func getScriptVersion(name string) int {
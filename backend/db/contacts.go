@@ -0,0 +1,42 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+	"yeetfile/shared"
+)
+
+// GetUserContacts returns the user's encrypted list of pinned contacts, or an
+// empty list if the user hasn't pinned any contacts yet
+func GetUserContacts(userID string) (shared.ContactList, error) {
+	var contacts shared.ContactList
+	s := `SELECT protected_key, contacts FROM user_contacts WHERE user_id=$1`
+	err := db.QueryRow(s, userID).Scan(&contacts.ProtectedKey, &contacts.Contacts)
+	if err == sql.ErrNoRows {
+		return shared.ContactList{}, nil
+	}
+
+	return contacts, err
+}
+
+// SetUserContacts replaces the user's encrypted list of pinned contacts
+func SetUserContacts(userID string, contacts shared.ContactList) error {
+	s := `INSERT INTO user_contacts (user_id, protected_key, contacts, updated)
+	      VALUES ($1, $2, $3, $4)
+	      ON CONFLICT (user_id) DO UPDATE
+	      SET protected_key=$2, contacts=$3, updated=$4`
+	_, err := db.Exec(
+		s,
+		userID,
+		contacts.ProtectedKey,
+		contacts.Contacts,
+		time.Now().UTC())
+	return err
+}
+
+// DeleteUserContacts removes the user's list of pinned contacts
+func DeleteUserContacts(userID string) error {
+	s := `DELETE FROM user_contacts WHERE user_id=$1`
+	_, err := db.Exec(s, userID)
+	return err
+}
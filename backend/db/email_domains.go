@@ -0,0 +1,52 @@
+package db
+
+import (
+	"time"
+	"yeetfile/shared"
+)
+
+const (
+	EmailDomainAllow = "allow"
+	EmailDomainDeny  = "deny"
+)
+
+// GetEmailDomainRules returns every email domain rule, ordered by domain
+func GetEmailDomainRules() ([]shared.EmailDomainRule, error) {
+	s := `SELECT domain, rule, created FROM email_domain_rules ORDER BY domain`
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.EmailDomainRule{}
+	for rows.Next() {
+		var rule shared.EmailDomainRule
+		err = rows.Scan(&rule.Domain, &rule.Rule, &rule.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, rule)
+	}
+
+	return result, nil
+}
+
+// SetEmailDomainRule adds an allow or deny rule for the domain, replacing any
+// existing rule for that domain
+func SetEmailDomainRule(domain, rule string) error {
+	s := `INSERT INTO email_domain_rules (domain, rule, created)
+	      VALUES ($1, $2, $3)
+	      ON CONFLICT (domain) DO UPDATE SET rule=$2`
+	_, err := db.Exec(s, domain, rule, time.Now().UTC())
+	return err
+}
+
+// RemoveEmailDomainRule removes the rule for the domain
+func RemoveEmailDomainRule(domain string) error {
+	s := `DELETE FROM email_domain_rules WHERE domain=$1`
+	_, err := db.Exec(s, domain)
+	return err
+}
@@ -0,0 +1,290 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+	"yeetfile/backend/mail"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var EmergencyAccessNotFoundErr = errors.New("emergency access not found")
+
+// SetEmergencyContact adds a user as an emergency contact for the owner, along
+// with the owner's vault root folder key wrapped for the contact. If the user
+// is already an emergency contact, their key and waiting period are replaced
+// and any pending request is cancelled.
+func SetEmergencyContact(
+	ownerID string,
+	contactID string,
+	contact shared.NewEmergencyContact,
+) error {
+	s := `INSERT INTO emergency_access
+	      (owner_id, contact_id, protected_key, wait_days, status, created)
+	      VALUES ($1, $2, $3, $4, $5, $6)
+	      ON CONFLICT (owner_id, contact_id) DO UPDATE
+	      SET protected_key=$3,
+	          wait_days=$4,
+	          status=$5,
+	          requested_at=NULL`
+
+	_, err := db.Exec(
+		s,
+		ownerID,
+		contactID,
+		contact.ProtectedKey,
+		contact.WaitDays,
+		constants.EmergencyStatusIdle,
+		time.Now().UTC())
+	return err
+}
+
+// CountEmergencyContacts returns the number of emergency contacts the owner
+// has added
+func CountEmergencyContacts(ownerID string) (int, error) {
+	var count int
+	s := `SELECT COUNT(*) FROM emergency_access WHERE owner_id=$1`
+	err := db.QueryRow(s, ownerID).Scan(&count)
+	return count, err
+}
+
+// GetEmergencyContacts returns the users that the owner has added as
+// emergency contacts
+func GetEmergencyContacts(ownerID string) ([]shared.EmergencyContact, error) {
+	s := `SELECT e.contact_id, COALESCE(u.email, ''),
+	             e.wait_days, e.status, e.requested_at
+	      FROM emergency_access e
+	      LEFT JOIN users u ON u.id = e.contact_id
+	      WHERE e.owner_id=$1
+	      ORDER BY e.created`
+	return queryEmergencyAccess(s, ownerID)
+}
+
+// GetEmergencyGrantors returns the users who have added the contact as one of
+// their emergency contacts
+func GetEmergencyGrantors(contactID string) ([]shared.EmergencyContact, error) {
+	s := `SELECT e.owner_id, COALESCE(u.email, ''),
+	             e.wait_days, e.status, e.requested_at
+	      FROM emergency_access e
+	      LEFT JOIN users u ON u.id = e.owner_id
+	      WHERE e.contact_id=$1
+	      ORDER BY e.created`
+	return queryEmergencyAccess(s, contactID)
+}
+
+func queryEmergencyAccess(s string, userID string) ([]shared.EmergencyContact, error) {
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.EmergencyContact{}
+	for rows.Next() {
+		var (
+			contact     shared.EmergencyContact
+			requestedAt sql.NullTime
+		)
+
+		err = rows.Scan(
+			&contact.UserID,
+			&contact.Email,
+			&contact.WaitDays,
+			&contact.Status,
+			&requestedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if requestedAt.Valid {
+			contact.RequestedAt = requestedAt.Time
+			contact.GrantDate = requestedAt.Time.AddDate(0, 0, contact.WaitDays)
+		}
+
+		result = append(result, contact)
+	}
+
+	return result, nil
+}
+
+// RequestEmergencyAccess starts the waiting period for a contact's access to
+// the owner's vault, returning the number of days in the waiting period.
+// Returns EmergencyAccessNotFoundErr if the contact can't request access,
+// either because they aren't a contact or because they already have.
+func RequestEmergencyAccess(ownerID, contactID string) (int, error) {
+	var waitDays int
+	s := `UPDATE emergency_access
+	      SET status=$3, requested_at=$4
+	      WHERE owner_id=$1 AND contact_id=$2 AND status=$5
+	      RETURNING wait_days`
+	err := db.QueryRow(
+		s,
+		ownerID,
+		contactID,
+		constants.EmergencyStatusRequested,
+		time.Now().UTC(),
+		constants.EmergencyStatusIdle).Scan(&waitDays)
+	if err == sql.ErrNoRows {
+		return 0, EmergencyAccessNotFoundErr
+	}
+
+	return waitDays, err
+}
+
+// DenyEmergencyAccess cancels a contact's pending request for access to the
+// owner's vault. The contact remains an emergency contact and can request
+// access again later.
+func DenyEmergencyAccess(ownerID, contactID string) error {
+	s := `UPDATE emergency_access
+	      SET status=$3, requested_at=NULL
+	      WHERE owner_id=$1 AND contact_id=$2 AND status=$4`
+	result, err := db.Exec(
+		s,
+		ownerID,
+		contactID,
+		constants.EmergencyStatusIdle,
+		constants.EmergencyStatusRequested)
+	if err != nil {
+		return err
+	} else if count, _ := result.RowsAffected(); count == 0 {
+		return EmergencyAccessNotFoundErr
+	}
+
+	return nil
+}
+
+// DeleteEmergencyAccess removes a contact from the owner's emergency contacts,
+// revoking any access they've been granted
+func DeleteEmergencyAccess(ownerID, contactID string) error {
+	s := `DELETE FROM emergency_access WHERE owner_id=$1 AND contact_id=$2`
+	result, err := db.Exec(s, ownerID, contactID)
+	if err != nil {
+		return err
+	} else if count, _ := result.RowsAffected(); count == 0 {
+		return EmergencyAccessNotFoundErr
+	}
+
+	return nil
+}
+
+// HasEmergencyAccess returns true if the contact has been granted access to
+// the owner's vault
+func HasEmergencyAccess(ownerID, contactID string) bool {
+	var status string
+	s := `SELECT status FROM emergency_access
+	      WHERE owner_id=$1 AND contact_id=$2`
+	err := db.QueryRow(s, ownerID, contactID).Scan(&status)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error checking emergency access: %v\n", err)
+	}
+
+	return status == constants.EmergencyStatusGranted
+}
+
+// GetEmergencyKeys returns the owner's vault root folder key that was wrapped
+// for the contact. The key is only returned once the contact has been granted
+// access.
+func GetEmergencyKeys(ownerID, contactID string) (shared.EmergencyKeyResponse, error) {
+	var keys shared.EmergencyKeyResponse
+	s := `SELECT protected_key
+	      FROM emergency_access
+	      WHERE owner_id=$1 AND contact_id=$2 AND status=$3`
+	err := db.QueryRow(
+		s,
+		ownerID,
+		contactID,
+		constants.EmergencyStatusGranted).Scan(&keys.ProtectedKey)
+	if err == sql.ErrNoRows {
+		return shared.EmergencyKeyResponse{}, EmergencyAccessNotFoundErr
+	}
+
+	return keys, err
+}
+
+// deleteUserEmergencyAccess removes all emergency access entries that the
+// user is either the owner or the contact of
+func deleteUserEmergencyAccess(userID string) error {
+	s := `DELETE FROM emergency_access WHERE owner_id=$1 OR contact_id=$1`
+	_, err := db.Exec(s, userID)
+	return err
+}
+
+// CheckEmergencyAccess grants access to every contact whose waiting period has
+// elapsed without the owner denying their request, and notifies both users.
+func CheckEmergencyAccess() {
+	s := `UPDATE emergency_access
+	      SET status=$1
+	      WHERE status=$2
+	        AND requested_at + wait_days * interval '1 day' <= $3
+	      RETURNING owner_id, contact_id`
+
+	rows, err := db.Query(
+		s,
+		constants.EmergencyStatusGranted,
+		constants.EmergencyStatusRequested,
+		time.Now().UTC())
+	if err != nil {
+		log.Printf("Error granting emergency access: %v\n", err)
+		return
+	}
+
+	defer rows.Close()
+
+	type grant struct {
+		ownerID   string
+		contactID string
+	}
+
+	var grants []grant
+	for rows.Next() {
+		var g grant
+		err = rows.Scan(&g.ownerID, &g.contactID)
+		if err != nil {
+			log.Printf("Error scanning emergency access rows: %v\n", err)
+			return
+		}
+
+		grants = append(grants, g)
+	}
+
+	for _, g := range grants {
+		ownerEmail, err := GetUserEmailByID(g.ownerID)
+		if err != nil {
+			log.Printf("Error fetching emergency access owner: %v\n", err)
+			continue
+		}
+
+		contactEmail, err := GetUserEmailByID(g.contactID)
+		if err != nil {
+			log.Printf("Error fetching emergency contact: %v\n", err)
+			continue
+		}
+
+		owner := ownerEmail
+		if len(owner) == 0 {
+			owner = g.ownerID
+		}
+
+		contact := contactEmail
+		if len(contact) == 0 {
+			contact = g.contactID
+		}
+
+		if len(contactEmail) > 0 {
+			err = mail.SendEmergencyAccessGrantedEmail(contactEmail, owner)
+			if err != nil {
+				log.Printf("Error sending emergency access email: %v\n", err)
+			}
+		}
+
+		if len(ownerEmail) > 0 {
+			err = mail.SendEmergencyAccessReleasedEmail(ownerEmail, contact)
+			if err != nil {
+				log.Printf("Error sending emergency access email: %v\n", err)
+			}
+		}
+	}
+}
@@ -309,12 +309,33 @@ func GetFolderOwnerStorage(folderID string) (int64, int64, error) {
 	return storageUsed, storageAvailable, nil
 }
 
+// UpdateFolderOwnerStorage updates the storage used by the owner of a folder
+// and their organization's pool, leaving both unchanged if the update exceeds
+// the owner's storage limits.
 func UpdateFolderOwnerStorage(folderID string, amount int64) error {
 	var (
 		storageUsed      int64
 		storageAvailable int64
 	)
 
+	ownerID, err := GetFolderOwner(folderID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	pooled, err := updateOrgUsage(tx, ownerID, "storage", amount)
+	if err != nil {
+		return err
+	}
+
 	s := `
 	    WITH folder_owner AS (
 	        SELECT owner_id FROM folders WHERE id = $1
@@ -327,14 +348,15 @@ func UpdateFolderOwnerStorage(folderID string, amount int64) error {
 	      WHERE id = (SELECT owner_id FROM folder_owner) 
 	      RETURNING storage_used, storage_available`
 
-	err := db.QueryRow(s, folderID, amount).Scan(&storageUsed, &storageAvailable)
+	err = tx.QueryRow(s, folderID, amount).Scan(&storageUsed, &storageAvailable)
 	if err != nil {
 		return err
-	} else if storageUsed > storageAvailable && amount > 0 {
+	} else if !pooled && storageUsed > storageAvailable && amount > 0 {
 		return UserStorageExceeded
 	}
 
-	return nil
+	// Limits for pooled owners are enforced by the organization's pool
+	return tx.Commit()
 }
 
 func GetFolderOwnership(
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+	"yeetfile/shared"
+)
+
+// CreateInviteLink stores a new invite link. Only a hash of the link's code is
+// stored, so the code itself can't be retrieved after creation.
+func CreateInviteLink(link shared.InviteLink, codeHash []byte) error {
+	var expiration sql.NullTime
+	if !link.Expiration.IsZero() {
+		expiration = sql.NullTime{Time: link.Expiration.UTC(), Valid: true}
+	}
+
+	s := `INSERT INTO invite_links
+	      (id, code_hash, created_by, max_uses, uses, expiration, created)
+	      VALUES ($1, $2, $3, $4, 0, $5, $6)`
+	_, err := db.Exec(
+		s,
+		link.ID,
+		codeHash,
+		link.CreatedBy,
+		link.MaxUses,
+		expiration,
+		link.Created)
+	return err
+}
+
+// ClaimInviteLink uses up one of the remaining uses of an invite link.
+// Returns sql.ErrNoRows if the link doesn't exist, has expired, or has no
+// remaining uses.
+func ClaimInviteLink(codeHash []byte) error {
+	var id string
+	s := `UPDATE invite_links
+	      SET uses = uses + 1
+	      WHERE code_hash = $1
+	        AND uses < max_uses
+	        AND (expiration IS NULL OR expiration > $2)
+	      RETURNING id`
+	return db.QueryRow(s, codeHash, time.Now().UTC()).Scan(&id)
+}
+
+// IsInviteLinkAvailable returns true if an invite link exists for the code
+// hash, hasn't expired, and has remaining uses.
+func IsInviteLinkAvailable(codeHash []byte) (bool, error) {
+	var available bool
+	s := `SELECT EXISTS(
+	          SELECT 1 FROM invite_links
+	          WHERE code_hash = $1
+	            AND uses < max_uses
+	            AND (expiration IS NULL OR expiration > $2))`
+	err := db.QueryRow(s, codeHash, time.Now().UTC()).Scan(&available)
+	return available, err
+}
+
+// GetInviteLinks returns every invite link, with the newest links first
+func GetInviteLinks() ([]shared.InviteLink, error) {
+	s := `SELECT id, created_by, max_uses, uses, expiration, created
+	      FROM invite_links
+	      ORDER BY created DESC`
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInviteLinks(rows)
+}
+
+// GetUserInviteLinks returns the invite links created by the user
+func GetUserInviteLinks(userID string) ([]shared.InviteLink, error) {
+	s := `SELECT id, created_by, max_uses, uses, expiration, created
+	      FROM invite_links
+	      WHERE created_by = $1
+	      ORDER BY created DESC`
+	rows, err := db.Query(s, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInviteLinks(rows)
+}
+
+func scanInviteLinks(rows *sql.Rows) ([]shared.InviteLink, error) {
+	defer rows.Close()
+
+	result := []shared.InviteLink{}
+	for rows.Next() {
+		var link shared.InviteLink
+		var expiration sql.NullTime
+		err := rows.Scan(
+			&link.ID,
+			&link.CreatedBy,
+			&link.MaxUses,
+			&link.Uses,
+			&expiration,
+			&link.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		if expiration.Valid {
+			link.Expiration = expiration.Time
+		}
+
+		result = append(result, link)
+	}
+
+	return result, nil
+}
+
+// DeleteInviteLink removes an invite link
+func DeleteInviteLink(id string) error {
+	s := `DELETE FROM invite_links WHERE id=$1`
+	_, err := db.Exec(s, id)
+	return err
+}
+
+// DeleteUnusedInviteLink removes an invite link created by the user, as long
+// as the link hasn't been used yet. Returns sql.ErrNoRows if no link was
+// removed.
+func DeleteUnusedInviteLink(id, userID string) error {
+	s := `DELETE FROM invite_links WHERE id=$1 AND created_by=$2 AND uses=0`
+	result, err := db.Exec(s, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetUserInviteAllowance returns the number of invite links the user is
+// allowed to create. The returned bool is false if the user doesn't have an
+// allowance set, in which case the server default should be used.
+func GetUserInviteAllowance(userID string) (int, bool, error) {
+	var allowance sql.NullInt64
+	s := `SELECT invite_allowance FROM users WHERE id=$1`
+	err := db.QueryRow(s, userID).Scan(&allowance)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return int(allowance.Int64), allowance.Valid, nil
+}
+
+// SetUserInviteAllowance sets the number of invite links the user is allowed
+// to create, overriding the server default.
+func SetUserInviteAllowance(userID string, allowance int) error {
+	s := `UPDATE users SET invite_allowance=$2 WHERE id=$1`
+	_, err := db.Exec(s, userID, allowance)
+	return err
+}
+
+// ReleaseInviteLink returns a use to an invite link that was claimed for a
+// signup that didn't succeed.
+func ReleaseInviteLink(codeHash []byte) error {
+	s := `UPDATE invite_links SET uses = uses - 1 WHERE code_hash=$1 AND uses > 0`
+	_, err := db.Exec(s, codeHash)
+	return err
+}
@@ -1,12 +1,81 @@
 package db
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	InvoicePending     = "pending"
+	InvoicePaid        = "paid"
+	InvoiceRefunded    = "refunded"
+	InvoicePartial     = "partially_refunded"
+	InvoiceDisputed    = "disputed"
+	InvoiceDisputeLost = "dispute_lost"
+	InvoiceCanceled    = "canceled"
+)
+
+// Invoice is a completed purchase, along with the upgrade values that were
+// applied to the user's account so that they can be reversed if the payment
+// is refunded or disputed.
+type Invoice struct {
+	InvoiceID  string
+	PaymentID  string
+	Source     string
+	Tag        string
+	Quantities string
+	Amount     int64
+	PaymentRef string
+	Status     string
+	Refunded   int64
+	VaultTag   string
+	VaultBytes int64
+	VaultExp   time.Time
+	SendBytes  int64
+	Date       time.Time
+}
+
+// AddInvoice records a purchase of the upgrade(s) matching the invoice tag,
+// where amount is the total paid in cents. Invoices are recorded as paid
+// unless another status is provided.
+func AddInvoice(invoice Invoice) error {
+	return addInvoice(db, invoice)
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func addInvoice(exec execer, invoice Invoice) error {
+	if len(invoice.Status) == 0 {
+		invoice.Status = InvoicePaid
+	}
+
+	var vaultExp sql.NullTime
+	if !invoice.VaultExp.IsZero() {
+		vaultExp = sql.NullTime{Time: invoice.VaultExp, Valid: true}
+	}
 
-func AddInvoice(invoiceID, paymentID, source string) error {
 	s := `INSERT INTO invoices
-	      (invoice_id, payment_id, source, date)
-	      VALUES ($1, $2, $3, $4)`
-	_, err := db.Exec(s, invoiceID, paymentID, source, time.Now().UTC())
+	      (invoice_id, payment_id, source, date, tag, quantities, amount,
+	       payment_ref, status, vault_tag, vault_bytes, vault_exp, send_bytes)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	_, err := exec.Exec(
+		s,
+		invoice.InvoiceID,
+		invoice.PaymentID,
+		invoice.Source,
+		time.Now().UTC(),
+		invoice.Tag,
+		invoice.Quantities,
+		invoice.Amount,
+		invoice.PaymentRef,
+		invoice.Status,
+		invoice.VaultTag,
+		invoice.VaultBytes,
+		vaultExp,
+		invoice.SendBytes)
 
 	return err
 }
@@ -18,3 +87,226 @@ func HasInvoice(invoiceID string) (bool, error) {
 
 	return exists, err
 }
+
+const invoiceColumns = `invoice_id, payment_id, source, tag, quantities,
+	amount, payment_ref, status, refunded, vault_tag, vault_bytes, vault_exp,
+	send_bytes, date`
+
+// GetInvoiceByPaymentRef returns the invoice matching the payment provider's
+// own reference to the payment (i.e. a Stripe payment intent ID).
+func GetInvoiceByPaymentRef(paymentRef string) (Invoice, error) {
+	s := `SELECT ` + invoiceColumns + `
+	      FROM invoices
+	      WHERE payment_ref = $1 AND payment_ref != ''`
+	return scanInvoice(db.QueryRow(s, paymentRef))
+}
+
+// GetInvoice returns the invoice matching the provided invoice ID.
+func GetInvoice(invoiceID string) (Invoice, error) {
+	s := `SELECT ` + invoiceColumns + `
+	      FROM invoices
+	      WHERE invoice_id = $1`
+	return scanInvoice(db.QueryRow(s, invoiceID))
+}
+
+// GetInvoicesByStatus returns all invoices with the provided status, ordered
+// from oldest to newest.
+func GetInvoicesByStatus(status string) ([]Invoice, error) {
+	s := `SELECT ` + invoiceColumns + `
+	      FROM invoices
+	      WHERE status = $1
+	      ORDER BY date`
+	rows, err := db.Query(s, status)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	return scanInvoices(rows)
+}
+
+// GetUserInvoice returns a single invoice belonging to the provided payment ID.
+func GetUserInvoice(paymentID, invoiceID string) (Invoice, error) {
+	s := `SELECT ` + invoiceColumns + `
+	      FROM invoices
+	      WHERE payment_id = $1 AND invoice_id = $2`
+	return scanInvoice(db.QueryRow(s, paymentID, invoiceID))
+}
+
+// GetUserInvoices returns all invoices belonging to the provided payment ID,
+// ordered from newest to oldest.
+func GetUserInvoices(paymentID string) ([]Invoice, error) {
+	s := `SELECT ` + invoiceColumns + `
+	      FROM invoices
+	      WHERE payment_id = $1
+	      ORDER BY date DESC`
+	rows, err := db.Query(s, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	return scanInvoices(rows)
+}
+
+// scanInvoices reads all remaining invoice rows
+func scanInvoices(rows *sql.Rows) ([]Invoice, error) {
+	invoices := []Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, nil
+}
+
+// scanInvoice reads a single row containing each of the invoiceColumns
+func scanInvoice(row interface{ Scan(...any) error }) (Invoice, error) {
+	var (
+		invoice  Invoice
+		vaultExp sql.NullTime
+	)
+
+	err := row.Scan(
+		&invoice.InvoiceID,
+		&invoice.PaymentID,
+		&invoice.Source,
+		&invoice.Tag,
+		&invoice.Quantities,
+		&invoice.Amount,
+		&invoice.PaymentRef,
+		&invoice.Status,
+		&invoice.Refunded,
+		&invoice.VaultTag,
+		&invoice.VaultBytes,
+		&vaultExp,
+		&invoice.SendBytes,
+		&invoice.Date)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	invoice.VaultExp = vaultExp.Time
+	return invoice, nil
+}
+
+// UpdateInvoiceStatus sets the status of the invoice and the total amount (in
+// cents) that has been refunded to the user.
+func UpdateInvoiceStatus(invoiceID, status string, refunded int64) error {
+	s := `UPDATE invoices SET status=$2, refunded=$3 WHERE invoice_id=$1`
+	_, err := db.Exec(s, invoiceID, status, refunded)
+	return err
+}
+
+// PayPendingInvoice claims a pending invoice from the provided source by
+// marking it as paid, and then passes it to prepare, which sets the upgrade
+// values that the invoice applies. The upgrades are applied to the user's
+// account in the same transaction that claims the invoice, so that an invoice
+// can't be applied twice by concurrent requests or retries, or applied at all
+// after it has been deleted. The invoice is left pending if prepare or any
+// of the updates fail. Returns sql.ErrNoRows if the invoice isn't pending.
+func PayPendingInvoice(
+	invoiceID string,
+	source string,
+	prepare func(invoice *Invoice) error,
+) (Invoice, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	s := `UPDATE invoices
+	      SET status=$3, date=$4
+	      WHERE invoice_id=$1 AND source=$2 AND status=$5
+	      RETURNING ` + invoiceColumns
+	invoice, err := scanInvoice(tx.QueryRow(
+		s,
+		invoiceID,
+		source,
+		InvoicePaid,
+		time.Now().UTC(),
+		InvoicePending))
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	err = prepare(&invoice)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	err = applyInvoiceUpgrades(tx, invoice)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	return invoice, tx.Commit()
+}
+
+// DeletePendingInvoice removes an invoice that hasn't been paid yet.
+func DeletePendingInvoice(invoiceID string) error {
+	s := `DELETE FROM invoices WHERE invoice_id=$1 AND status=$2`
+	_, err := db.Exec(s, invoiceID, InvoicePending)
+	return err
+}
+
+// DeleteUserPendingInvoices removes all unpaid invoices from a particular
+// source for the provided payment ID.
+func DeleteUserPendingInvoices(paymentID, source string) error {
+	s := `DELETE FROM invoices WHERE payment_id=$1 AND source=$2 AND status=$3`
+	_, err := db.Exec(s, paymentID, source, InvoicePending)
+	return err
+}
+
+// applyInvoiceUpgrades applies the upgrade values set in the invoice to the
+// user's account, and records them in the invoice
+func applyInvoiceUpgrades(exec execer, invoice Invoice) error {
+	if invoice.VaultBytes > 0 {
+		err := setUserVaultUpgrade(
+			exec,
+			invoice.PaymentID,
+			invoice.VaultTag,
+			invoice.VaultExp,
+			invoice.VaultBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if invoice.SendBytes > 0 {
+		err := setUserSendUpgrade(exec, invoice.PaymentID, invoice.SendBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return setInvoiceUpgrades(exec, invoice)
+}
+
+// setInvoiceUpgrades records the upgrade values that were applied to the
+// user's account for an invoice
+func setInvoiceUpgrades(exec execer, invoice Invoice) error {
+	var vaultExp sql.NullTime
+	if !invoice.VaultExp.IsZero() {
+		vaultExp = sql.NullTime{Time: invoice.VaultExp, Valid: true}
+	}
+
+	s := `UPDATE invoices
+	      SET vault_tag=$2, vault_bytes=$3, vault_exp=$4, send_bytes=$5
+	      WHERE invoice_id=$1`
+	_, err := exec.Exec(
+		s,
+		invoice.InvoiceID,
+		invoice.VaultTag,
+		invoice.VaultBytes,
+		vaultExp,
+		invoice.SendBytes)
+	return err
+}
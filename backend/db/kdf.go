@@ -0,0 +1,47 @@
+package db
+
+import (
+	"crypto/rand"
+	"yeetfile/shared"
+)
+
+const fakeKDFKeyName = "fake_kdf"
+
+// GetUserKDFParams returns the key derivation parameters for the user with
+// the matching email or account ID. Returns sql.ErrNoRows if no user matches.
+func GetUserKDFParams(identifier string) (shared.KDFParams, error) {
+	var kdf shared.KDFParams
+	s := `SELECT kdf_algorithm, kdf_iterations, kdf_memory
+	      FROM users
+	      WHERE email = $1 OR id = $1`
+	err := db.QueryRow(s, identifier).Scan(
+		&kdf.Algorithm,
+		&kdf.Iterations,
+		&kdf.Memory)
+	return kdf, err
+}
+
+// GetFakeKDFKey returns the random key used for choosing the fake KDF
+// parameters given out for identifiers that don't belong to an account. The
+// key is generated on first use and stored in the database, and never rotates
+// (unlike the server secrets) so that the fake parameters for an identifier
+// always stay the same.
+func GetFakeKDFKey() ([]byte, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s := `INSERT INTO server_keys (name, value)
+	      VALUES ($1, $2)
+	      ON CONFLICT (name) DO NOTHING`
+	_, err = db.Exec(s, fakeKDFKeyName, key)
+	if err != nil {
+		return nil, err
+	}
+
+	s = `SELECT value FROM server_keys WHERE name = $1`
+	err = db.QueryRow(s, fakeKDFKeyName).Scan(&key)
+	return key, err
+}
@@ -0,0 +1,341 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+	"yeetfile/shared"
+)
+
+// GetUserAdditionalKeys returns the key pairs a user has in addition to the
+// RSA key pair stored with their account. Private keys are encrypted with the
+// user's key, the same as the RSA private key.
+func GetUserAdditionalKeys(userID string) ([]shared.UserKey, error) {
+	rows, err := db.Query(`
+		SELECT key_type, public_key, protected_key, recovery_protected_key
+		FROM user_keys
+		WHERE user_id = $1
+		ORDER BY created`, userID)
+	if err != nil {
+		log.Printf("Error querying for user keys: %v\n", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	keys := []shared.UserKey{}
+	for rows.Next() {
+		var key shared.UserKey
+		err = rows.Scan(
+			&key.Type,
+			&key.PublicKey,
+			&key.ProtectedKey,
+			&key.RecoveryProtectedKey)
+		if err != nil {
+			log.Printf("Error scanning user key: %v\n", err)
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// GetUserAdditionalPubKeys returns the public halves of a user's additional
+// key pairs
+func GetUserAdditionalPubKeys(userID string) ([]shared.UserPublicKey, error) {
+	keys, err := GetUserAdditionalKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeys := []shared.UserPublicKey{}
+	for _, key := range keys {
+		pubKeys = append(pubKeys, shared.UserPublicKey{
+			Type:      key.Type,
+			PublicKey: key.PublicKey,
+		})
+	}
+
+	return pubKeys, nil
+}
+
+// UserHasKeyType returns true if the user already has a key pair of the
+// provided type
+func UserHasKeyType(userID, keyType string) (bool, error) {
+	var exists bool
+	s := `SELECT EXISTS(SELECT 1 FROM user_keys WHERE user_id=$1 AND key_type=$2)`
+	err := db.QueryRow(s, userID, keyType).Scan(&exists)
+	return exists, err
+}
+
+// AddUserKey stores a new key pair for the user, along with the copy of the
+// private key encrypted with the user's recovery key (if they have one)
+func AddUserKey(userID string, key shared.UserKey) error {
+	s := `INSERT INTO user_keys
+	      (user_id, key_type, public_key, protected_key, recovery_protected_key, created)
+	      VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.Exec(
+		s,
+		userID,
+		key.Type,
+		key.PublicKey,
+		key.ProtectedKey,
+		key.RecoveryProtectedKey,
+		time.Now().UTC())
+	return err
+}
+
+// SetUserPendingProtectedKeys stores encrypted private keys that will replace
+// the user's current ones once they've verified their new email (see
+// UpdateUser)
+func SetUserPendingProtectedKeys(userID string, keys []shared.UserKey) error {
+	s := `UPDATE user_keys SET pending_protected_key=$3 WHERE user_id=$1 AND key_type=$2`
+	for _, key := range keys {
+		_, err := db.Exec(s, userID, key.Type, key.ProtectedKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteUserKeys removes all of a user's additional key pairs
+func DeleteUserKeys(userID string) error {
+	_, err := db.Exec(`DELETE FROM user_keys WHERE user_id=$1`, userID)
+	return err
+}
+
+// GetUserWrappedKeys returns every key that is wrapped directly with one of the
+// user's public keys. This is the user's root folder key, plus the key of each
+// file or folder that has been shared with the user.
+func GetUserWrappedKeys(userID string) ([]shared.WrappedKey, error) {
+	return getUserWrappedKeys(db, userID, "")
+}
+
+// getUserWrappedKeys returns the user's wrapped keys using the provided query
+// suffix, which is used to lock the rows within a transaction
+func getUserWrappedKeys(
+	q interface {
+		Query(query string, args ...any) (*sql.Rows, error)
+	},
+	userID string,
+	suffix string,
+) ([]shared.WrappedKey, error) {
+	// Folders and files are queried separately, since row locks can't be
+	// used with a UNION
+	queries := []string{`
+		SELECT id, TRUE, protected_key, COALESCE(shared_by, '')
+		FROM folders
+		WHERE owner_id = $1 AND (id = $1 OR (parent_id = $1 AND id != ref_id))`, `
+		SELECT id, FALSE, protected_key, COALESCE(shared_by, '')
+		FROM vault
+		WHERE owner_id = $1 AND folder_id = $1 AND id != ref_id`}
+
+	keys := []shared.WrappedKey{}
+	for _, s := range queries {
+		rows, err := q.Query(s+suffix, userID)
+		if err != nil {
+			log.Printf("Error querying for wrapped keys: %v\n", err)
+			return nil, err
+		}
+
+		for rows.Next() {
+			var key shared.WrappedKey
+			err = rows.Scan(&key.ID, &key.IsFolder, &key.ProtectedKey, &key.SharedBy)
+			if err != nil {
+				rows.Close()
+				log.Printf("Error scanning wrapped key: %v\n", err)
+				return nil, err
+			}
+
+			keys = append(keys, key)
+		}
+
+		rows.Close()
+	}
+
+	return keys, nil
+}
+
+// RotateUserKeys replaces all of a user's key pairs, along with every key that
+// was wrapped with them, in a single transaction. Shared items listed in the
+// rotation's removed keys are removed from the user's vault, and the IDs of the
+// users who had shared them are returned so that they can be asked to share
+// them again.
+//
+// Pending email changes are cancelled, since they contain a copy of the
+// user's old private key. The user's recovery key is removed if the rotation
+// doesn't include a recovery copy of the new private key. Emergency access
+// that the user has given or been given is removed, as are the user's pinned
+// contacts if the rotation doesn't include their re-wrapped key.
+//
+// The user's current wrapped keys are locked and passed to validate before
+// any changes are made, so that the rotation is checked against the same keys
+// it replaces. The transaction is aborted if validate returns an error.
+func RotateUserKeys(
+	userID string,
+	rotation shared.KeyRotation,
+	validate func(current []shared.WrappedKey) error,
+) ([]string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	// Locking the user's row prevents concurrent rotations for the same user
+	var id string
+	err = tx.QueryRow(
+		`SELECT id FROM users WHERE id=$1 FOR UPDATE`,
+		userID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := getUserWrappedKeys(tx, userID, " FOR UPDATE")
+	if err != nil {
+		return nil, err
+	} else if err = validate(current); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE users SET public_key=$2, protected_key=$3 WHERE id=$1`,
+		userID,
+		rotation.PublicKey,
+		rotation.ProtectedKey)
+	if err != nil {
+		log.Printf("Error updating user key pair: %v\n", err)
+		return nil, err
+	}
+
+	if len(rotation.RecoveryProtectedKey) > 0 {
+		_, err = tx.Exec(
+			`UPDATE users SET recovery_protected_key=$2 WHERE id=$1`,
+			userID,
+			rotation.RecoveryProtectedKey)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE users
+			SET recovery_key_hash=NULL,
+			    recovery_public_key=NULL,
+			    recovery_protected_key=NULL
+			WHERE id=$1`, userID)
+	}
+
+	if err != nil {
+		log.Printf("Error updating user recovery key: %v\n", err)
+		return nil, err
+	}
+
+	// The key for the user's pinned contacts is re-wrapped by the client,
+	// otherwise the contacts can no longer be decrypted
+	if len(rotation.ContactsProtectedKey) > 0 {
+		_, err = tx.Exec(
+			`UPDATE user_contacts SET protected_key=$2 WHERE user_id=$1`,
+			userID,
+			rotation.ContactsProtectedKey)
+	} else {
+		_, err = tx.Exec(`DELETE FROM user_contacts WHERE user_id=$1`, userID)
+	}
+
+	if err != nil {
+		log.Printf("Error updating user contacts: %v\n", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM user_keys WHERE user_id=$1`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies of the user's old private keys held for emergency contacts, and
+	// the user's access to other vaults as a contact, can't be carried over
+	// to the new keys
+	_, err = tx.Exec(
+		`DELETE FROM emergency_access WHERE owner_id=$1 OR contact_id=$1`,
+		userID)
+	if err != nil {
+		log.Printf("Error removing emergency access: %v\n", err)
+		return nil, err
+	}
+
+	for _, key := range rotation.Keys {
+		_, err = tx.Exec(`
+			INSERT INTO user_keys
+			(user_id, key_type, public_key, protected_key, recovery_protected_key, created)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			userID,
+			key.Type,
+			key.PublicKey,
+			key.ProtectedKey,
+			key.RecoveryProtectedKey,
+			time.Now().UTC())
+		if err != nil {
+			log.Printf("Error inserting rotated user key: %v\n", err)
+			return nil, err
+		}
+	}
+
+	for _, key := range rotation.WrappedKeys {
+		s := `UPDATE vault SET protected_key=$3 WHERE id=$1 AND owner_id=$2`
+		if key.IsFolder {
+			s = `UPDATE folders SET protected_key=$3 WHERE id=$1 AND owner_id=$2`
+		}
+
+		result, err := tx.Exec(s, key.ID, userID, key.ProtectedKey)
+		if err != nil {
+			log.Printf("Error updating wrapped key: %v\n", err)
+			return nil, err
+		} else if count, _ := result.RowsAffected(); count != 1 {
+			return nil, fmt.Errorf("wrapped key %s not found", key.ID)
+		}
+	}
+
+	var sharerIDs []string
+	for _, key := range rotation.RemovedKeys {
+		s := `DELETE FROM vault WHERE id=$1 AND owner_id=$2 RETURNING ref_id`
+		if key.IsFolder {
+			s = `DELETE FROM folders WHERE id=$1 AND owner_id=$2 RETURNING ref_id`
+		}
+
+		var refID string
+		err = tx.QueryRow(s, key.ID, userID).Scan(&refID)
+		if err != nil {
+			log.Printf("Error removing unreadable shared item: %v\n", err)
+			return nil, err
+		}
+
+		rows, err := tx.Query(`
+			DELETE FROM sharing
+			WHERE recipient_id=$1 AND item_id=$2
+			RETURNING owner_id`, userID, refID)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var ownerID string
+			if err = rows.Scan(&ownerID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			sharerIDs = append(sharerIDs, ownerID)
+		}
+
+		rows.Close()
+	}
+
+	_, err = tx.Exec(`DELETE FROM verify WHERE account_id=$1`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return sharerIDs, tx.Commit()
+}
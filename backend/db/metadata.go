@@ -25,11 +25,20 @@ type FileMetadata struct {
 	ParentFolderOwner string
 	Expiration        time.Time
 	Downloads         int
+	EncryptionVersion int
+	KDF               shared.KDFParams
 }
 
 // InsertMetadata creates a new metadata entry in the db and returns a unique ID for
 // that entry.
-func InsertMetadata(chunks int, ownerID, name string, textOnly bool) (string, error) {
+func InsertMetadata(
+	chunks int,
+	ownerID,
+	name string,
+	textOnly bool,
+	encryptionVersion int,
+	kdf shared.KDFParams,
+) (string, error) {
 	prefix := constants.FileIDPrefix
 	if textOnly {
 		prefix = constants.TextIDPrefix
@@ -43,9 +52,12 @@ func InsertMetadata(chunks int, ownerID, name string, textOnly bool) (string, er
 	}
 
 	s := `INSERT INTO metadata
-	      (id, chunks, filename, b2_id, length, owner_id, modified)
-	      VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := db.Exec(s, id, chunks, name, "", -1, ownerID, time.Now().UTC())
+	      (id, chunks, filename, b2_id, length, owner_id, modified,
+	       encryption_version, kdf_algorithm, kdf_iterations, kdf_memory)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := db.Exec(s,
+		id, chunks, name, "", -1, ownerID, time.Now().UTC(),
+		encryptionVersion, kdf.Algorithm, kdf.Iterations, kdf.Memory)
 	if err != nil {
 		panic(err)
 	}
@@ -70,7 +82,9 @@ func MetadataIDExists(id string) bool {
 }
 
 func RetrieveMetadata(id string) (FileMetadata, error) {
-	s := `SELECT m.id, m.chunks, m.filename, m.b2_id, m.length, e.downloads, e.date
+	s := `SELECT m.id, m.chunks, m.filename, m.b2_id, m.length, e.downloads, e.date,
+	             m.encryption_version, m.kdf_algorithm, m.kdf_iterations,
+	             m.kdf_memory
 	      FROM metadata m
 	      JOIN expiry e on m.id = e.id
 	      WHERE m.id = $1`
@@ -89,6 +103,23 @@ func RetrieveMetadata(id string) (FileMetadata, error) {
 	return FileMetadata{}, errors.New("no metadata found")
 }
 
+// IsSendOwnerSuspended returns true if the owner of the Send file has been
+// suspended, which prevents any further downloads of the file.
+func IsSendOwnerSuspended(id string) bool {
+	var suspended bool
+	s := `SELECT EXISTS(
+	          SELECT 1 FROM metadata m
+	          JOIN users u ON u.id = m.owner_id
+	          WHERE m.id = $1 AND u.suspended)`
+	err := db.QueryRow(s, id).Scan(&suspended)
+	if err != nil {
+		log.Printf("Error checking send owner suspension: %v\n", err)
+		return false
+	}
+
+	return suspended
+}
+
 func UpdateMetadata(id string, b2ID string, length int64) error {
 	s := `UPDATE metadata
 	      SET b2_id=$1, length=$2
@@ -118,21 +149,27 @@ func ParseMetadata(rows *sql.Rows) FileMetadata {
 	var length int64
 	var downloads int
 	var date time.Time
+	var encryptionVersion int
+	var kdf shared.KDFParams
 
-	err := rows.Scan(&id, &chunks, &name, &b2ID, &length, &downloads, &date)
+	err := rows.Scan(
+		&id, &chunks, &name, &b2ID, &length, &downloads, &date,
+		&encryptionVersion, &kdf.Algorithm, &kdf.Iterations, &kdf.Memory)
 
 	if err != nil {
 		return FileMetadata{}
 	}
 
 	return FileMetadata{
-		ID:         id,
-		Chunks:     chunks,
-		Name:       name,
-		B2ID:       b2ID,
-		Length:     length,
-		Downloads:  downloads,
-		Expiration: date,
+		ID:                id,
+		Chunks:            chunks,
+		Name:              name,
+		B2ID:              b2ID,
+		Length:            length,
+		Downloads:         downloads,
+		Expiration:        date,
+		EncryptionVersion: encryptionVersion,
+		KDF:               kdf,
 	}
 }
 
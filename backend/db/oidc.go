@@ -0,0 +1,149 @@
+package db
+
+import (
+	"log"
+	"time"
+	"yeetfile/shared/constants"
+)
+
+// OIDCRequest tracks a single OpenID Connect login attempt, from the moment
+// the user is sent to the identity provider until the client finishes logging
+// in, linking, or signing up.
+type OIDCRequest struct {
+	ID          string
+	TokenHash   []byte
+	State       string
+	Nonce       string
+	Verifier    string
+	Status      string
+	Subject     string
+	Email       string
+	Identifier  string
+	UserID      string
+	Error       string
+	Expiration  time.Time
+	IsCLI       bool
+	BindingHash []byte
+	CodeHash    []byte
+	Confirmed   bool
+}
+
+const oidcRequestColumns = `id, token_hash, state, nonce, verifier, status,
+	subject, email, identifier, user_id, error, expiration, is_cli,
+	binding_hash, code_hash, confirmed`
+
+// CreateOIDCRequest stores a new pending OIDC login request
+func CreateOIDCRequest(request OIDCRequest) error {
+	s := `INSERT INTO oidc_requests
+	      (id, token_hash, state, nonce, verifier, status, expiration, is_cli,
+	       binding_hash, code_hash)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := db.Exec(
+		s,
+		request.ID,
+		request.TokenHash,
+		request.State,
+		request.Nonce,
+		request.Verifier,
+		constants.OIDCStatusPending,
+		request.Expiration.UTC(),
+		request.IsCLI,
+		request.BindingHash,
+		request.CodeHash)
+	return err
+}
+
+// GetPendingOIDCRequest returns the unexpired OIDC request that is still
+// waiting on a response from the identity provider for the provided state.
+// Returns sql.ErrNoRows if there isn't a matching request.
+func GetPendingOIDCRequest(state string) (OIDCRequest, error) {
+	s := `SELECT ` + oidcRequestColumns + `
+	      FROM oidc_requests
+	      WHERE state=$1 AND status=$2 AND expiration > $3`
+	return scanOIDCRequest(s, state, constants.OIDCStatusPending, time.Now().UTC())
+}
+
+// GetOIDCRequest returns the unexpired OIDC request matching the provided ID.
+// Returns sql.ErrNoRows if there isn't a matching request.
+func GetOIDCRequest(id string) (OIDCRequest, error) {
+	s := `SELECT ` + oidcRequestColumns + `
+	      FROM oidc_requests
+	      WHERE id=$1 AND expiration > $2`
+	return scanOIDCRequest(s, id, time.Now().UTC())
+}
+
+func scanOIDCRequest(s string, args ...any) (OIDCRequest, error) {
+	var request OIDCRequest
+	err := db.QueryRow(s, args...).Scan(
+		&request.ID,
+		&request.TokenHash,
+		&request.State,
+		&request.Nonce,
+		&request.Verifier,
+		&request.Status,
+		&request.Subject,
+		&request.Email,
+		&request.Identifier,
+		&request.UserID,
+		&request.Error,
+		&request.Expiration,
+		&request.IsCLI,
+		&request.BindingHash,
+		&request.CodeHash,
+		&request.Confirmed)
+	return request, err
+}
+
+// UpdateOIDCRequest stores the result of the identity provider callback for
+// an OIDC request, along with whether the login has been confirmed by the
+// client that started it
+func UpdateOIDCRequest(request OIDCRequest) error {
+	s := `UPDATE oidc_requests
+	      SET status=$2, subject=$3, email=$4, identifier=$5, user_id=$6,
+	          error=$7, binding_hash=$8, confirmed=$9
+	      WHERE id=$1`
+	_, err := db.Exec(
+		s,
+		request.ID,
+		request.Status,
+		request.Subject,
+		request.Email,
+		request.Identifier,
+		request.UserID,
+		request.Error,
+		request.BindingHash,
+		request.Confirmed)
+	return err
+}
+
+// DeleteOIDCRequest removes an OIDC request once it has been completed
+func DeleteOIDCRequest(id string) error {
+	_, err := db.Exec(`DELETE FROM oidc_requests WHERE id=$1`, id)
+	return err
+}
+
+// CleanUpOIDCRequests removes OIDC requests that were never completed
+func CleanUpOIDCRequests() {
+	s := `DELETE FROM oidc_requests WHERE expiration < $1`
+	_, err := db.Exec(s, time.Now().UTC())
+	if err != nil {
+		log.Printf("Error cleaning up expired OIDC requests: %v\n", err)
+	}
+}
+
+// GetOIDCIdentityUserID returns the ID of the user linked to the identity
+// provider's subject. Returns sql.ErrNoRows if the identity isn't linked.
+func GetOIDCIdentityUserID(issuer, subject string) (string, error) {
+	var userID string
+	s := `SELECT user_id FROM oidc_identities WHERE issuer=$1 AND subject=$2`
+	err := db.QueryRow(s, issuer, subject).Scan(&userID)
+	return userID, err
+}
+
+// AddOIDCIdentity links an identity provider's subject to a user
+func AddOIDCIdentity(issuer, subject, userID string) error {
+	s := `INSERT INTO oidc_identities (issuer, subject, user_id, created)
+	      VALUES ($1, $2, $3, $4)`
+	_, err := db.Exec(s, issuer, subject, userID, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,575 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yeetfile/shared"
+)
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// orgPaymentIDPrefix is prepended to organization payment IDs so that upgrade
+// purchases can be applied to the correct table
+const orgPaymentIDPrefix = "org"
+
+var (
+	OrgMemberExistsErr  = errors.New("user already belongs to an organization")
+	OrgNotFoundErr      = errors.New("organization not found")
+	InvalidOrgInviteErr = errors.New("invalid organization invite")
+)
+
+// OrgLimits contains the pooled usage of an organization alongside the usage
+// and cap of a single member of that organization
+type OrgLimits struct {
+	PoolUsed      int64
+	PoolAvailable int64
+	MemberUsed    int64
+	MemberCap     int64
+}
+
+// Remaining returns the number of bytes a member can still use, taking both
+// the organization's pool and the member's cap (if set) into account.
+func (limits OrgLimits) Remaining() int64 {
+	remaining := limits.PoolAvailable - limits.PoolUsed
+	if limits.MemberCap > 0 && limits.MemberCap-limits.MemberUsed < remaining {
+		remaining = limits.MemberCap - limits.MemberUsed
+	}
+
+	return remaining
+}
+
+// Usage returns the used and available bytes that should be reported to a
+// member, which is their own usage and cap if the cap is the tighter limit, and
+// the organization's pool otherwise.
+func (limits OrgLimits) Usage() (int64, int64) {
+	if limits.MemberCap > 0 &&
+		limits.MemberCap-limits.MemberUsed < limits.PoolAvailable-limits.PoolUsed {
+		return limits.MemberUsed, limits.MemberCap
+	}
+
+	return limits.PoolUsed, limits.PoolAvailable
+}
+
+// IsOrgPaymentID returns true if the payment ID belongs to an organization
+// instead of an individual user
+func IsOrgPaymentID(paymentID string) bool {
+	return strings.HasPrefix(paymentID, orgPaymentIDPrefix+"_")
+}
+
+// paymentTable returns the table that holds the upgrade columns for the
+// provided payment ID
+func paymentTable(paymentID string) string {
+	if IsOrgPaymentID(paymentID) {
+		return "organizations"
+	}
+
+	return "users"
+}
+
+// IsValidOrgRole returns true if the role can be assigned to an invited or
+// existing member. The owner role can't be assigned.
+func IsValidOrgRole(role string) bool {
+	return role == OrgRoleAdmin || role == OrgRoleMember
+}
+
+// CreateOrganization creates a new organization owned by the user, and adds
+// the user (and their current storage usage) as the organization's first
+// member.
+func CreateOrganization(name, ownerID string) (string, error) {
+	_, err := GetOrgMemberRole(ownerID)
+	if err == nil {
+		return "", OrgMemberExistsErr
+	} else if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	orgID := shared.GenRandomString(16)
+	paymentID := shared.GenRandomStringWithPrefix(16, orgPaymentIDPrefix)
+
+	s := `WITH org AS (
+	          INSERT INTO organizations
+	          (id, name, owner_id, payment_id, upgrade_exp, created)
+	          VALUES ($1, $2, $3, $4, $5, $5)
+	          RETURNING id
+	      )
+	      INSERT INTO org_members (org_id, user_id, role, joined, storage_used)
+	      SELECT id, $3, $6, $5,
+	             (SELECT storage_used FROM users WHERE id = $3)
+	      FROM org`
+
+	_, err = db.Exec(
+		s,
+		orgID,
+		name,
+		ownerID,
+		paymentID,
+		time.Now().UTC(),
+		OrgRoleOwner)
+	if err != nil {
+		return "", err
+	}
+
+	return orgID, nil
+}
+
+// GetUserOrganization returns the organization that the user belongs to,
+// including the pooled usage of all members and the user's role.
+func GetUserOrganization(userID string) (shared.Organization, error) {
+	var org shared.Organization
+	s := `SELECT o.id, o.name, m.role,
+	             o.storage_available, o.send_available, o.upgrade_exp,
+	             (SELECT COALESCE(SUM(storage_used), 0)
+	              FROM org_members WHERE org_id = o.id),
+	             (SELECT COALESCE(SUM(send_used), 0)
+	              FROM org_members WHERE org_id = o.id)
+	      FROM organizations o
+	      JOIN org_members m ON m.org_id = o.id
+	      WHERE m.user_id = $1`
+
+	err := db.QueryRow(s, userID).Scan(
+		&org.ID,
+		&org.Name,
+		&org.Role,
+		&org.StorageAvailable,
+		&org.SendAvailable,
+		&org.UpgradeExp,
+		&org.StorageUsed,
+		&org.SendUsed)
+	if err == sql.ErrNoRows {
+		return shared.Organization{}, OrgNotFoundErr
+	}
+
+	return org, err
+}
+
+// GetOrgMemberRole returns the user's role within their organization. Returns
+// sql.ErrNoRows if the user isn't a member of an organization.
+func GetOrgMemberRole(userID string) (string, error) {
+	var role string
+	s := `SELECT role FROM org_members WHERE user_id = $1`
+	err := db.QueryRow(s, userID).Scan(&role)
+	return role, err
+}
+
+// GetOrgPaymentID returns the payment ID of the organization that the user
+// manages. Only owners and admins can make purchases for an organization.
+func GetOrgPaymentID(userID string) (string, error) {
+	var paymentID string
+	s := `SELECT o.payment_id
+	      FROM organizations o
+	      JOIN org_members m ON m.org_id = o.id
+	      WHERE m.user_id = $1 AND m.role = ANY($2)`
+	err := db.QueryRow(
+		s,
+		userID,
+		fmt.Sprintf("{%s,%s}", OrgRoleOwner, OrgRoleAdmin)).Scan(&paymentID)
+	if err == sql.ErrNoRows {
+		return "", OrgNotFoundErr
+	}
+
+	return paymentID, err
+}
+
+// GetOrgMembers returns all members of an organization, with the owner first.
+func GetOrgMembers(orgID string) ([]shared.OrgMember, error) {
+	s := `SELECT m.user_id, COALESCE(u.email, ''), m.role,
+	             m.storage_used, m.storage_cap, m.send_used, m.send_cap,
+	             m.joined
+	      FROM org_members m
+	      LEFT JOIN users u ON u.id = m.user_id
+	      WHERE m.org_id = $1
+	      ORDER BY m.role = $2 DESC, m.joined`
+	rows, err := db.Query(s, orgID, OrgRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.OrgMember{}
+	for rows.Next() {
+		var member shared.OrgMember
+		err = rows.Scan(
+			&member.UserID,
+			&member.Email,
+			&member.Role,
+			&member.StorageUsed,
+			&member.StorageCap,
+			&member.SendUsed,
+			&member.SendCap,
+			&member.Joined)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, member)
+	}
+
+	return result, nil
+}
+
+// UpdateOrgMember updates the role and usage caps of a member. A cap of 0
+// allows the member to use the organization's entire pool.
+func UpdateOrgMember(orgID, userID string, update shared.OrgMemberUpdate) error {
+	s := `UPDATE org_members
+	      SET role=$3, storage_cap=$4, send_cap=$5
+	      WHERE org_id=$1 AND user_id=$2 AND role != $6`
+	result, err := db.Exec(
+		s,
+		orgID,
+		userID,
+		update.Role,
+		update.StorageCap,
+		update.SendCap,
+		OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RemoveOrgMember removes a member from the organization. The organization's
+// owner can't be removed.
+func RemoveOrgMember(orgID, userID string) error {
+	s := `DELETE FROM org_members
+	      WHERE org_id=$1 AND user_id=$2 AND role != $3`
+	result, err := db.Exec(s, orgID, userID, OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteOrganization removes an organization along with all of its members
+// and pending invites.
+func DeleteOrganization(orgID string) error {
+	for _, s := range []string{
+		`DELETE FROM org_invites WHERE org_id=$1`,
+		`DELETE FROM org_members WHERE org_id=$1`,
+		`DELETE FROM organizations WHERE id=$1`,
+	} {
+		_, err := db.Exec(s, orgID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteUserOrgData removes the user's organization membership, and deletes
+// any organization that the user owns.
+func deleteUserOrgData(userID string) error {
+	rows, err := db.Query(`SELECT id FROM organizations WHERE owner_id=$1`, userID)
+	if err != nil {
+		return err
+	}
+
+	var orgIDs []string
+	for rows.Next() {
+		var orgID string
+		err = rows.Scan(&orgID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		orgIDs = append(orgIDs, orgID)
+	}
+
+	rows.Close()
+
+	for _, orgID := range orgIDs {
+		err = DeleteOrganization(orgID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec(`DELETE FROM org_members WHERE user_id=$1`, userID)
+	return err
+}
+
+// CreateOrgInvite creates an invite for an email address to join the
+// organization, replacing any previous invite for the same address.
+func CreateOrgInvite(orgID, email, role string) (string, error) {
+	id := shared.GenRandomString(32)
+	s := `INSERT INTO org_invites (id, org_id, email, role, created)
+	      VALUES ($1, $2, $3, $4, $5)
+	      ON CONFLICT (org_id, email) DO UPDATE
+	      SET id=$1, role=$4, created=$5`
+	_, err := db.Exec(s, id, orgID, email, role, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// GetOrgInvites returns pending invites sent by the organization
+func GetOrgInvites(orgID string) ([]shared.OrgInvite, error) {
+	s := `SELECT i.id, o.name, i.email, i.role, i.created
+	      FROM org_invites i
+	      JOIN organizations o ON o.id = i.org_id
+	      WHERE i.org_id = $1
+	      ORDER BY i.created DESC`
+	return queryOrgInvites(s, orgID)
+}
+
+// GetOrgInvitesByEmail returns pending invites sent to an email address
+func GetOrgInvitesByEmail(email string) ([]shared.OrgInvite, error) {
+	s := `SELECT i.id, o.name, i.email, i.role, i.created
+	      FROM org_invites i
+	      JOIN organizations o ON o.id = i.org_id
+	      WHERE i.email = $1
+	      ORDER BY i.created DESC`
+	return queryOrgInvites(s, email)
+}
+
+func queryOrgInvites(s string, arg string) ([]shared.OrgInvite, error) {
+	rows, err := db.Query(s, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.OrgInvite{}
+	for rows.Next() {
+		var invite shared.OrgInvite
+		err = rows.Scan(
+			&invite.ID,
+			&invite.OrgName,
+			&invite.Email,
+			&invite.Role,
+			&invite.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, invite)
+	}
+
+	return result, nil
+}
+
+// AcceptOrgInvite adds the user to the organization that sent the invite, as
+// long as the invite was sent to the user's email address. The user's current
+// storage usage is added to the organization's pool, and is removed from the
+// pool along with their membership.
+func AcceptOrgInvite(inviteID, userID, email string) error {
+	_, err := GetOrgMemberRole(userID)
+	if err == nil {
+		return OrgMemberExistsErr
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	s := `WITH invite AS (
+	          DELETE FROM org_invites
+	          WHERE id=$1 AND email=$2
+	          RETURNING org_id, role
+	      )
+	      INSERT INTO org_members (org_id, user_id, role, joined, storage_used)
+	      SELECT org_id, $3, role, $4,
+	             (SELECT storage_used FROM users WHERE id = $3)
+	      FROM invite`
+	result, err := db.Exec(s, inviteID, email, userID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return InvalidOrgInviteErr
+	}
+
+	return nil
+}
+
+// DeleteOrgInvite removes an invite. The invite must either belong to the
+// organization (orgID) or have been sent to the email address.
+func DeleteOrgInvite(inviteID, orgID, email string) error {
+	s := `DELETE FROM org_invites WHERE id=$1 AND (org_id=$2 OR email=$3)`
+	result, err := db.Exec(s, inviteID, orgID, email)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := result.RowsAffected(); count == 0 {
+		return InvalidOrgInviteErr
+	}
+
+	return nil
+}
+
+// GetOrgStorageLimits returns the storage pool of the user's organization.
+// The returned bool is false if the user isn't part of an organization with
+// pooled storage, in which case their individual limits should be used.
+func GetOrgStorageLimits(userID string) (OrgLimits, bool, error) {
+	return getOrgLimits(userID, "storage")
+}
+
+// GetOrgSendLimits returns the send pool of the user's organization. The
+// returned bool is false if the user isn't part of an organization with a
+// pooled send limit.
+func GetOrgSendLimits(userID string) (OrgLimits, bool, error) {
+	return getOrgLimits(userID, "send")
+}
+
+func getOrgLimits(userID, column string) (OrgLimits, bool, error) {
+	var limits OrgLimits
+	s := fmt.Sprintf(`
+	    SELECT o.%[1]s_available, m.%[1]s_used, m.%[1]s_cap,
+	           (SELECT COALESCE(SUM(%[1]s_used), 0)
+	            FROM org_members WHERE org_id = o.id)
+	    FROM organizations o
+	    JOIN org_members m ON m.org_id = o.id
+	    WHERE m.user_id = $1 AND o.%[1]s_available > 0`, column)
+
+	err := db.QueryRow(s, userID).Scan(
+		&limits.PoolAvailable,
+		&limits.MemberUsed,
+		&limits.MemberCap,
+		&limits.PoolUsed)
+	if err == sql.ErrNoRows {
+		return OrgLimits{}, false, nil
+	} else if err != nil {
+		return OrgLimits{}, false, err
+	}
+
+	return limits, true, nil
+}
+
+// updateOrgUsage adds an amount of bytes (or removes, if negative) to a
+// member's storage or send usage as part of the caller's transaction. The
+// returned bool is false if the user isn't part of an organization with a pool
+// for that column, otherwise an error is returned if the update exceeds the
+// pool or the member's cap.
+func updateOrgUsage(tx *sql.Tx, userID, column string, amount int64) (bool, error) {
+	s := fmt.Sprintf(`
+	    UPDATE org_members m
+	    SET %[1]s_used = m.%[1]s_used + $2
+	    FROM organizations o
+	    WHERE m.user_id = $1 AND o.id = m.org_id AND o.%[1]s_available > 0
+	    RETURNING o.id, o.%[1]s_available, m.%[1]s_used, m.%[1]s_cap`, column)
+
+	var (
+		orgID  string
+		limits OrgLimits
+	)
+
+	err := tx.QueryRow(s, userID, amount).Scan(
+		&orgID,
+		&limits.PoolAvailable,
+		&limits.MemberUsed,
+		&limits.MemberCap)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil || amount <= 0 {
+		return true, err
+	}
+
+	sum := fmt.Sprintf(`
+	    SELECT COALESCE(SUM(%s_used), 0) FROM org_members WHERE org_id = $1`,
+		column)
+	err = tx.QueryRow(sum, orgID).Scan(&limits.PoolUsed)
+	if err != nil {
+		return true, err
+	} else if limits.Remaining() < 0 {
+		return true, errOrgLimitExceeded(column)
+	}
+
+	return true, nil
+}
+
+func errOrgLimitExceeded(column string) error {
+	if column == "send" {
+		return UserSendExceeded
+	}
+
+	return UserStorageExceeded
+}
+
+// getOrgBandwidth returns the bandwidth of the user's organization, if the
+// user belongs to an organization with pooled storage.
+func getOrgBandwidth(userID string) (int64, bool, error) {
+	var bandwidth int64
+	s := `SELECT o.bandwidth
+	      FROM organizations o
+	      JOIN org_members m ON m.org_id = o.id
+	      WHERE m.user_id = $1 AND o.storage_available > 0`
+	err := db.QueryRow(s, userID).Scan(&bandwidth)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	return bandwidth, true, nil
+}
+
+// updateOrgBandwidth subtracts bandwidth from the user's organization,
+// returning false if the user doesn't belong to an organization with pooled
+// storage.
+func updateOrgBandwidth(userID string, amount int64) (bool, error) {
+	s := `UPDATE organizations o
+	      SET bandwidth = o.bandwidth - $2
+	      FROM org_members m
+	      WHERE m.org_id = o.id AND m.user_id = $1 AND o.storage_available > 0`
+	result, err := db.Exec(s, userID, amount)
+	if err != nil {
+		return false, err
+	}
+
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
+
+// setOrgSendUpgrade resets the send usage of every member of the organization
+// and updates the organization's send pool.
+func setOrgSendUpgrade(exec execer, paymentID string, sendUpgradeBytes int64) error {
+	s := `UPDATE organizations o
+	      SET send_available = GREATEST(o.send_available - (
+	              SELECT COALESCE(SUM(send_used), 0)
+	              FROM org_members WHERE org_id = o.id), 0) + $1
+	      WHERE o.payment_id = $2`
+	_, err := exec.Exec(s, sendUpgradeBytes, paymentID)
+	if err != nil {
+		return err
+	}
+
+	s = `UPDATE org_members
+	     SET send_used = 0
+	     WHERE org_id = (SELECT id FROM organizations WHERE payment_id = $1)`
+	_, err = exec.Exec(s, paymentID)
+	return err
+}
+
+// CheckOrgUpgrades removes the storage pool from organizations with expired
+// upgrades.
+func CheckOrgUpgrades() error {
+	s := `UPDATE organizations
+	      SET storage_available = 0,
+	          upgrade_tag = '',
+	          last_upgraded_month = $1
+	      WHERE upgrade_exp < $2 AND last_upgraded_month > 0`
+	_, err := db.Exec(s, unsubscribedMonth, time.Now().UTC())
+	return err
+}
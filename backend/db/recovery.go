@@ -0,0 +1,108 @@
+package db
+
+import (
+	"log"
+	"yeetfile/shared"
+)
+
+// SetUserRecovery stores the hash of a user's recovery key, along with the
+// recovery public key and the copies of the user's private keys that were
+// encrypted with it. Any previous recovery key is replaced.
+func SetUserRecovery(
+	userID string,
+	keyHash []byte,
+	publicKey []byte,
+	protectedKey []byte,
+	keys []shared.UserKey,
+) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET recovery_key_hash=$2,
+		    recovery_public_key=$3,
+		    recovery_protected_key=$4
+		WHERE id=$1`,
+		userID,
+		keyHash,
+		publicKey,
+		protectedKey)
+	if err != nil {
+		log.Printf("Error setting user recovery key: %v\n", err)
+		return err
+	}
+
+	for _, key := range keys {
+		_, err = tx.Exec(`
+			UPDATE user_keys
+			SET recovery_protected_key=$3
+			WHERE user_id=$1 AND key_type=$2`,
+			userID,
+			key.Type,
+			key.RecoveryProtectedKey)
+		if err != nil {
+			log.Printf("Error setting user key recovery copy: %v\n", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveUserRecovery removes a user's recovery key, along with every copy of
+// their private keys that was encrypted with it
+func RemoveUserRecovery(userID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET recovery_key_hash=NULL,
+		    recovery_public_key=NULL,
+		    recovery_protected_key=NULL
+		WHERE id=$1`, userID)
+	if err != nil {
+		log.Printf("Error removing user recovery key: %v\n", err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_keys
+		SET recovery_protected_key=NULL
+		WHERE user_id=$1`, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUserRecoveryPublicKey returns the public half of a user's recovery key,
+// or an empty slice if the user hasn't set up a recovery key
+func GetUserRecoveryPublicKey(userID string) ([]byte, error) {
+	var publicKey []byte
+	s := `SELECT recovery_public_key FROM users WHERE id=$1`
+	err := db.QueryRow(s, userID).Scan(&publicKey)
+	return publicKey, err
+}
+
+// GetUserRecovery returns the hash of a user's recovery key, and the copy of
+// their private key that was encrypted with it. Both are empty if the user
+// hasn't set up a recovery key.
+func GetUserRecovery(userID string) ([]byte, []byte, error) {
+	var keyHash, protectedKey []byte
+	s := `SELECT recovery_key_hash, recovery_protected_key FROM users WHERE id=$1`
+	err := db.QueryRow(s, userID).Scan(&keyHash, &protectedKey)
+	return keyHash, protectedKey, err
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"time"
+	"yeetfile/shared"
+)
+
+const reportIDLength = 16
+
+// AddReport records a report against a YeetFile Send file, identified by its
+// metadata ID.
+func AddReport(metadataID string, report shared.SendReport) error {
+	s := `INSERT INTO reports (id, metadata_id, reason, details, date)
+	      VALUES ($1, $2, $3, $4, $5)`
+	_, err := db.Exec(
+		s,
+		shared.GenRandomString(reportIDLength),
+		metadataID,
+		report.Reason,
+		report.Details,
+		time.Now().UTC())
+	return err
+}
+
+// GetReports returns all reported Send files, grouped by metadata ID, with the
+// most recently reported files first.
+func GetReports() ([]shared.AdminReportResponse, error) {
+	s := `SELECT r.metadata_id,
+	             COALESCE(m.owner_id, ''), COALESCE(m.length, 0),
+	             r.reason, r.details, r.date
+	      FROM reports r
+	      LEFT JOIN metadata m ON m.id = r.metadata_id
+	      ORDER BY r.date DESC`
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.AdminReportResponse{}
+	reportIndex := make(map[string]int)
+	for rows.Next() {
+		var (
+			metadataID string
+			ownerID    string
+			length     int64
+			entry      shared.AdminReportEntry
+		)
+
+		err = rows.Scan(
+			&metadataID,
+			&ownerID,
+			&length,
+			&entry.Reason,
+			&entry.Details,
+			&entry.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		idx, ok := reportIndex[metadataID]
+		if !ok {
+			idx = len(result)
+			reportIndex[metadataID] = idx
+			result = append(result, shared.AdminReportResponse{
+				MetadataID: metadataID,
+				OwnerID:    ownerID,
+				Size:       length,
+				Exists:     length > 0 || len(ownerID) > 0,
+			})
+		}
+
+		result[idx].Reports = append(result[idx].Reports, entry)
+	}
+
+	return result, nil
+}
+
+// DeleteReports removes all reports for the provided metadata ID, either when
+// the reports have been dismissed or the file has been removed.
+func DeleteReports(metadataID string) error {
+	s := `DELETE FROM reports WHERE metadata_id=$1`
+	_, err := db.Exec(s, metadataID)
+	return err
+}
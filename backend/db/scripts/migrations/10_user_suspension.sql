@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended boolean DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_reason text DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at timestamp;
@@ -0,0 +1,26 @@
+create table if not exists stats
+(
+    date         date not null
+        constraint stats_pk
+            primary key,
+    total_users  integer  default 0,
+    active_users integer  default 0,
+    vault_bytes  bigint   default 0,
+    send_bytes   bigint   default 0,
+    uploaded     bigint   default 0,
+    downloaded   bigint   default 0,
+    size_buckets bigint[] default '{}'::bigint[]
+);
+
+create table if not exists stats_revenue
+(
+    date      date not null,
+    tag       text not null,
+    purchases integer default 0,
+    revenue   bigint  default 0,
+    constraint stats_revenue_pk
+        primary key (date, tag)
+);
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tag text DEFAULT '';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS amount bigint DEFAULT 0;
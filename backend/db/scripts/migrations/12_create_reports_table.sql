@@ -0,0 +1,13 @@
+create table if not exists reports
+(
+    id          text not null
+        constraint reports_pk
+            primary key,
+    metadata_id text not null,
+    reason      text,
+    details     text default ''::text,
+    date        timestamp
+);
+
+create index if not exists reports_metadata_id_index
+    on reports (metadata_id);
@@ -0,0 +1,10 @@
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS payment_ref text DEFAULT '';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS status text DEFAULT 'paid';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS refunded bigint DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS vault_tag text DEFAULT '';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS vault_bytes bigint DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS vault_exp timestamp;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS send_bytes bigint DEFAULT 0;
+
+create index if not exists invoices_payment_ref_index
+    on invoices (payment_ref);
@@ -0,0 +1 @@
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS quantities text DEFAULT '';
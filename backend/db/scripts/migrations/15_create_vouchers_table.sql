@@ -0,0 +1,12 @@
+create table if not exists vouchers
+(
+    code       text not null
+        constraint vouchers_pk
+            primary key,
+    tag        text not null,
+    quantity   integer default 1,
+    max_uses   integer default 1,
+    uses       integer default 0,
+    expiration timestamp,
+    created    timestamp
+);
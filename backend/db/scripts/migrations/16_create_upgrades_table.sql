@@ -0,0 +1,15 @@
+create table if not exists upgrades
+(
+    tag         text not null
+        constraint upgrades_pk
+            primary key,
+    name        text not null,
+    description text default ''::text,
+    price       bigint not null,
+    bytes       bigint not null,
+    annual      boolean default false,
+    is_vault    boolean default false,
+    btcpay_link text default ''::text,
+    stripe_id   text default ''::text,
+    updated     timestamp
+);
@@ -0,0 +1,47 @@
+create table if not exists organizations
+(
+    id                  text not null
+        constraint organizations_pk
+            primary key,
+    name                text not null,
+    owner_id            text not null,
+    payment_id          text not null
+        constraint organizations_payment_id_unique
+            unique,
+    storage_available   bigint default 0,
+    send_available      bigint default 0,
+    upgrade_tag         text default ''::text,
+    upgrade_exp         timestamp default now(),
+    last_upgraded_month integer default -1,
+    bandwidth           bigint default 0,
+    created             timestamp
+);
+
+create table if not exists org_members
+(
+    org_id       text not null,
+    user_id      text not null
+        constraint org_members_pk
+            primary key,
+    role         text not null,
+    storage_cap  bigint default 0,
+    send_cap     bigint default 0,
+    storage_used bigint default 0,
+    send_used    bigint default 0,
+    joined       timestamp
+);
+
+create index if not exists org_members_org_id_idx on org_members (org_id);
+
+create table if not exists org_invites
+(
+    id      text not null
+        constraint org_invites_pk
+            primary key,
+    org_id  text not null,
+    email   text not null,
+    role    text not null,
+    created timestamp,
+    constraint org_invites_org_email_unique
+        unique (org_id, email)
+);
@@ -0,0 +1,21 @@
+create table if not exists admin_roles
+(
+    user_id    text not null
+        constraint admin_roles_pk
+            primary key,
+    role       text not null,
+    granted_by text not null,
+    granted    timestamp
+);
+
+create table if not exists admin_audit
+(
+    id        serial
+        constraint admin_audit_pk
+            primary key,
+    actor_id  text not null,
+    target_id text not null,
+    action    text not null,
+    role      text default ''::text,
+    created   timestamp
+);
@@ -0,0 +1,17 @@
+create table if not exists invite_links
+(
+    id         text  not null
+        constraint invite_links_pk
+            primary key,
+    code_hash  bytea not null
+        constraint invite_links_code_hash_unique
+            unique,
+    created_by text  not null,
+    max_uses   integer default 1,
+    uses       integer default 0,
+    expiration timestamp,
+    created    timestamp
+);
+
+alter table users add column if not exists invite_allowance integer;
+alter table verify add column if not exists invite_link_hash bytea;
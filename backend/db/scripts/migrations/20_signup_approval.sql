@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending boolean DEFAULT false;
+
+create table if not exists email_domain_rules
+(
+    domain  text not null
+        constraint email_domain_rules_pk
+            primary key,
+    rule    text not null,
+    created timestamp
+);
@@ -0,0 +1,29 @@
+create table if not exists oidc_identities
+(
+    issuer  text not null,
+    subject text not null,
+    user_id text not null,
+    created timestamp,
+    constraint oidc_identities_pk
+        primary key (issuer, subject)
+);
+
+create table if not exists oidc_requests
+(
+    id         text  not null
+        constraint oidc_requests_pk
+            primary key,
+    token_hash bytea not null,
+    state      text  not null
+        constraint oidc_requests_state_unique
+            unique,
+    nonce      text  not null,
+    verifier   text  not null,
+    status     text  not null default 'pending',
+    subject    text  default '',
+    email      text  default '',
+    identifier text  default '',
+    user_id    text  default '',
+    error      text  default '',
+    expiration timestamp not null
+);
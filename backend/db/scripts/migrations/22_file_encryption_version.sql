@@ -0,0 +1,2 @@
+ALTER TABLE metadata ADD COLUMN IF NOT EXISTS encryption_version integer DEFAULT 1;
+ALTER TABLE vault ADD COLUMN IF NOT EXISTS encryption_version integer DEFAULT 1;
@@ -0,0 +1,11 @@
+create table if not exists user_keys
+(
+    user_id               text  not null,
+    key_type              text  not null,
+    public_key            bytea not null,
+    protected_key         bytea not null,
+    pending_protected_key bytea,
+    created               timestamp,
+    constraint user_keys_pk
+        primary key (user_id, key_type)
+);
@@ -0,0 +1,19 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS kdf_algorithm text DEFAULT 'argon2id';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS kdf_iterations integer DEFAULT 2;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS kdf_memory integer DEFAULT 64;
+
+ALTER TABLE verify ADD COLUMN IF NOT EXISTS kdf_algorithm text DEFAULT 'argon2id';
+ALTER TABLE verify ADD COLUMN IF NOT EXISTS kdf_iterations integer DEFAULT 2;
+ALTER TABLE verify ADD COLUMN IF NOT EXISTS kdf_memory integer DEFAULT 64;
+
+ALTER TABLE metadata ADD COLUMN IF NOT EXISTS kdf_algorithm text DEFAULT 'pbkdf2-sha256';
+ALTER TABLE metadata ADD COLUMN IF NOT EXISTS kdf_iterations integer DEFAULT 600000;
+ALTER TABLE metadata ADD COLUMN IF NOT EXISTS kdf_memory integer DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS server_keys
+(
+    name  text  not null
+        constraint server_keys_pk
+            primary key,
+    value bytea not null
+);
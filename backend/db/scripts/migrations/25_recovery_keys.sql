@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_key_hash bytea;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_public_key bytea;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_protected_key bytea;
+
+ALTER TABLE user_keys ADD COLUMN IF NOT EXISTS recovery_protected_key bytea;
+
+ALTER TABLE verify ADD COLUMN IF NOT EXISTS recovery_key_hash bytea;
+ALTER TABLE verify ADD COLUMN IF NOT EXISTS recovery_public_key bytea;
+ALTER TABLE verify ADD COLUMN IF NOT EXISTS recovery_protected_key bytea;
@@ -0,0 +1,14 @@
+create table if not exists emergency_access
+(
+    owner_id      text    not null,
+    contact_id    text    not null,
+    protected_key bytea   not null,
+    wait_days     integer not null,
+    status        text    not null,
+    requested_at  timestamp,
+    created       timestamp,
+    constraint emergency_access_pk
+        primary key (owner_id, contact_id)
+);
+
+create index if not exists emergency_access_contact_id_idx on emergency_access (contact_id);
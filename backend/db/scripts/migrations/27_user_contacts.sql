@@ -0,0 +1,9 @@
+create table if not exists user_contacts
+(
+    user_id       text  not null
+        constraint user_contacts_pk
+            primary key,
+    protected_key bytea not null,
+    contacts      bytea not null,
+    updated       timestamp
+);
@@ -0,0 +1,17 @@
+create table if not exists voucher_redemptions
+(
+    user_id text      not null,
+    code    text      not null,
+    created timestamp not null,
+    constraint voucher_redemptions_pk
+        primary key (user_id, code)
+);
+
+insert into voucher_redemptions (user_id, code, created)
+select u.id, i.payment_ref, i.date
+from invoices i
+         join users u on u.payment_id = i.payment_id
+where i.source = 'voucher'
+on conflict do nothing;
+
+drop index if exists invoices_voucher_redemption_index;
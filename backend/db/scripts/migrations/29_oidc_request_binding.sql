@@ -0,0 +1,4 @@
+alter table oidc_requests add column if not exists is_cli boolean default false;
+alter table oidc_requests add column if not exists binding_hash bytea;
+alter table oidc_requests add column if not exists code_hash bytea;
+alter table oidc_requests add column if not exists confirmed boolean default false;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_warning smallint DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS send_warning smallint DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bandwidth_warning smallint DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created timestamp;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login timestamp;
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"yeetfile/backend/crypto"
+)
+
+// serverEncryptedColumn is a column containing values encrypted with the
+// server secret (see crypto.Encrypt)
+type serverEncryptedColumn struct {
+	table    string
+	idColumn string
+	column   string
+}
+
+var serverEncryptedColumns = []serverEncryptedColumn{
+	{table: "users", idColumn: "id", column: "secret"},
+	{table: "users", idColumn: "id", column: "pw_hint"},
+	{table: "verify", idColumn: "identity", column: "pw_hint"},
+}
+
+type serverEncryptedValue struct {
+	id    string
+	value []byte
+}
+
+// GetServerEncryptedValues returns every stored value that was encrypted with
+// a server secret
+func GetServerEncryptedValues() ([][]byte, error) {
+	var values [][]byte
+	for _, column := range serverEncryptedColumns {
+		columnValues, err := getServerEncryptedColumn(column)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range columnValues {
+			values = append(values, value.value)
+		}
+	}
+
+	return values, nil
+}
+
+// ReEncryptServerEncryptedValues re-encrypts every stored value that wasn't
+// encrypted with the current server secret. Values that can't be decrypted
+// are left as-is and counted as failures.
+func ReEncryptServerEncryptedValues() (int, int, error) {
+	var reEncrypted, failed int
+	for _, column := range serverEncryptedColumns {
+		values, err := getServerEncryptedColumn(column)
+		if err != nil {
+			return reEncrypted, failed, err
+		}
+
+		// Only update the value if it hasn't changed since it was read
+		s := fmt.Sprintf(`UPDATE %s SET %s=$3 WHERE %s=$1 AND %s=$2`,
+			column.table, column.column, column.idColumn, column.column)
+		for _, value := range values {
+			if !crypto.NeedsReEncrypt(value.value) {
+				continue
+			}
+
+			newValue, err := crypto.ReEncrypt(value.value)
+			if err != nil {
+				log.Printf("Unable to re-encrypt %s.%s for %s: %v\n",
+					column.table, column.column, value.id, err)
+				failed += 1
+				continue
+			}
+
+			_, err = db.Exec(s, value.id, value.value, newValue)
+			if err != nil {
+				return reEncrypted, failed, err
+			}
+
+			reEncrypted += 1
+		}
+	}
+
+	return reEncrypted, failed, nil
+}
+
+func getServerEncryptedColumn(
+	column serverEncryptedColumn,
+) ([]serverEncryptedValue, error) {
+	s := fmt.Sprintf(`SELECT %s, %s FROM %s WHERE length(%s) > 0`,
+		column.idColumn, column.column, column.table, column.column)
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var values []serverEncryptedValue
+	for rows.Next() {
+		var value serverEncryptedValue
+		err = rows.Scan(&value.id, &value.value)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
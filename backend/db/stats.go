@@ -0,0 +1,275 @@
+package db
+
+import (
+	"github.com/lib/pq"
+	"log"
+	"time"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// sizeBucketThresholds defines the boundaries used for grouping files by size
+// in the daily stats rollup. Files are placed into len(thresholds)+1 buckets.
+var sizeBucketThresholds = []int64{
+	1024 * 1024,             // 1 MB
+	1024 * 1024 * 10,        // 10 MB
+	1024 * 1024 * 100,       // 100 MB
+	1024 * 1024 * 1024,      // 1 GB
+	1024 * 1024 * 1024 * 10, // 10 GB
+}
+
+var sizeBucketLabels = []string{
+	"< 1 MB",
+	"1 MB - 10 MB",
+	"10 MB - 100 MB",
+	"100 MB - 1 GB",
+	"1 GB - 10 GB",
+	"> 10 GB",
+}
+
+// RecordDownloadStats adds the provided number of downloaded bytes to the
+// current day's stats. Completed downloads aren't kept in any table, so the
+// total is incremented as each chunk is downloaded, which keeps the count
+// accurate across server instances and restarts.
+func RecordDownloadStats(downloaded int64) {
+	s := `INSERT INTO stats (date, downloaded)
+	      VALUES ($1, $2)
+	      ON CONFLICT (date) DO UPDATE
+	      SET downloaded = stats.downloaded + excluded.downloaded`
+	_, err := db.Exec(s, getStatsDate(time.Now()), downloaded)
+	if err != nil {
+		log.Printf("Error updating download stats: %v\n", err)
+	}
+}
+
+// UpdateStats is a cron task that rolls up aggregate instance statistics into
+// the stats tables for the current day. Revenue is also recalculated for the
+// previous day in order to include any purchases made after the last rollup.
+func UpdateStats() {
+	now := time.Now().UTC()
+	today := getStatsDate(now)
+
+	var (
+		totalUsers  int
+		activeUsers int
+		vaultBytes  int64
+		sendBytes   int64
+	)
+
+	s := `SELECT COUNT(*),
+	             COUNT(*) FILTER (WHERE last_login > $1),
+	             COALESCE(SUM(storage_used), 0)
+	      FROM users`
+	activeCutoff := now.AddDate(0, 0, -constants.ActiveUserDays)
+	err := db.QueryRow(s, activeCutoff).Scan(&totalUsers, &activeUsers, &vaultBytes)
+	if err != nil {
+		log.Printf("Error fetching user stats: %v\n", err)
+		return
+	}
+
+	s = `SELECT COALESCE(SUM(length), 0) FROM metadata`
+	err = db.QueryRow(s).Scan(&sendBytes)
+	if err != nil {
+		log.Printf("Error fetching send stats: %v\n", err)
+		return
+	}
+
+	buckets, err := getFileSizeBuckets()
+	if err != nil {
+		log.Printf("Error fetching file size stats: %v\n", err)
+		return
+	}
+
+	s = `INSERT INTO stats
+	     (date, total_users, active_users, vault_bytes, send_bytes, size_buckets)
+	     VALUES ($1, $2, $3, $4, $5, $6)
+	     ON CONFLICT (date) DO UPDATE
+	     SET total_users=$2, active_users=$3, vault_bytes=$4,
+	         send_bytes=$5, size_buckets=$6`
+	_, err = db.Exec(
+		s,
+		today,
+		totalUsers,
+		activeUsers,
+		vaultBytes,
+		sendBytes,
+		pq.Array(buckets))
+	if err != nil {
+		log.Printf("Error updating stats: %v\n", err)
+		return
+	}
+
+	for _, date := range []time.Time{today.AddDate(0, 0, -1), today} {
+		err = updateUploadStats(date)
+		if err != nil {
+			log.Printf("Error updating upload stats: %v\n", err)
+		}
+
+		err = updateRevenueStats(date)
+		if err != nil {
+			log.Printf("Error updating revenue stats: %v\n", err)
+		}
+	}
+}
+
+// updateUploadStats recalculates the number of bytes uploaded to the vault and
+// to Send on the provided date. Password entries aren't counted as uploads.
+func updateUploadStats(date time.Time) error {
+	s := `INSERT INTO stats (date, uploaded)
+	      SELECT $1, COALESCE(SUM(length), 0)
+	      FROM (
+	          SELECT length FROM vault
+	          WHERE id = ref_id
+	            AND (pw_data IS NULL OR LENGTH(pw_data) = 0)
+	            AND modified >= $1 AND modified < $2
+	          UNION ALL
+	          SELECT length FROM metadata
+	          WHERE modified >= $1 AND modified < $2
+	      ) files
+	      ON CONFLICT (date) DO UPDATE
+	      SET uploaded=excluded.uploaded`
+	_, err := db.Exec(s, date, date.AddDate(0, 0, 1))
+	return err
+}
+
+// getFileSizeBuckets returns the number of vault and send files within each of
+// the size buckets defined by sizeBucketThresholds.
+func getFileSizeBuckets() ([]int64, error) {
+	s := `SELECT width_bucket(length, $1), COUNT(*)
+	      FROM (
+	          SELECT length FROM vault
+	          WHERE id = ref_id AND (pw_data IS NULL OR LENGTH(pw_data) = 0)
+	          UNION ALL
+	          SELECT length FROM metadata
+	      ) files
+	      GROUP BY 1`
+	rows, err := db.Query(s, pq.Array(sizeBucketThresholds))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	buckets := make([]int64, len(sizeBucketLabels))
+	for rows.Next() {
+		var bucket int
+		var count int64
+		err = rows.Scan(&bucket, &count)
+		if err != nil {
+			return nil, err
+		} else if bucket < 0 || bucket >= len(buckets) {
+			continue
+		}
+
+		buckets[bucket] = count
+	}
+
+	return buckets, nil
+}
+
+// updateRevenueStats recalculates the number of purchases and revenue (minus
+// refunds) per upgrade tag for the provided date. Invoices for multiple
+// upgrades have their revenue split evenly between each of their tags.
+func updateRevenueStats(date time.Time) error {
+	s := `INSERT INTO stats_revenue (date, tag, purchases, revenue)
+	      SELECT $1, t.tag, COUNT(*), COALESCE(SUM((amount - refunded) / t.tags), 0)
+	      FROM invoices i
+	      CROSS JOIN LATERAL (
+	          SELECT unnest(string_to_array(i.tag, ',')) AS tag,
+	                 cardinality(string_to_array(i.tag, ',')) AS tags
+	      ) t
+	      WHERE date >= $1 AND date < $2 AND status != $3 AND LENGTH(i.tag) > 0
+	      GROUP BY t.tag
+	      ON CONFLICT (date, tag) DO UPDATE
+	      SET purchases=excluded.purchases, revenue=excluded.revenue`
+	_, err := db.Exec(s, date, date.AddDate(0, 0, 1), InvoicePending)
+	return err
+}
+
+// GetStats returns the daily stats for the past N days, the most recent file
+// size breakdown, and the revenue per upgrade tag for the same period.
+func GetStats(days int) (shared.AdminStatsResponse, error) {
+	since := getStatsDate(time.Now()).AddDate(0, 0, -days+1)
+	response := shared.AdminStatsResponse{
+		Days:        []shared.AdminStatsDay{},
+		SizeBuckets: []shared.AdminStatsBucket{},
+		Revenue:     []shared.AdminStatsRevenue{},
+	}
+
+	s := `SELECT date, total_users, active_users, vault_bytes, send_bytes,
+	             uploaded, downloaded, size_buckets
+	      FROM stats
+	      WHERE date >= $1
+	      ORDER BY date`
+	rows, err := db.Query(s, since)
+	if err != nil {
+		return shared.AdminStatsResponse{}, err
+	}
+
+	defer rows.Close()
+
+	var buckets []int64
+	for rows.Next() {
+		var day shared.AdminStatsDay
+		var dayBuckets []int64
+		err = rows.Scan(
+			&day.Date,
+			&day.TotalUsers,
+			&day.ActiveUsers,
+			&day.VaultBytes,
+			&day.SendBytes,
+			&day.Uploaded,
+			&day.Downloaded,
+			pq.Array(&dayBuckets))
+		if err != nil {
+			return shared.AdminStatsResponse{}, err
+		}
+
+		if len(dayBuckets) > 0 {
+			buckets = dayBuckets
+		}
+
+		response.Days = append(response.Days, day)
+	}
+
+	for i, label := range sizeBucketLabels {
+		var count int64
+		if i < len(buckets) {
+			count = buckets[i]
+		}
+
+		response.SizeBuckets = append(response.SizeBuckets, shared.AdminStatsBucket{
+			Label: label,
+			Count: count,
+		})
+	}
+
+	s = `SELECT tag, SUM(purchases), SUM(revenue)
+	     FROM stats_revenue
+	     WHERE date >= $1
+	     GROUP BY tag
+	     ORDER BY SUM(revenue) DESC`
+	revenueRows, err := db.Query(s, since)
+	if err != nil {
+		return shared.AdminStatsResponse{}, err
+	}
+
+	defer revenueRows.Close()
+
+	for revenueRows.Next() {
+		var revenue shared.AdminStatsRevenue
+		err = revenueRows.Scan(&revenue.Tag, &revenue.Purchases, &revenue.Revenue)
+		if err != nil {
+			return shared.AdminStatsResponse{}, err
+		}
+
+		response.Revenue = append(response.Revenue, revenue)
+	}
+
+	return response, nil
+}
+
+// getStatsDate truncates a timestamp to the start of its day (UTC)
+func getStatsDate(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"log"
+	"time"
+	"yeetfile/backend/server/upgrades"
+	"yeetfile/shared"
+)
+
+// GetUpgradeCatalog returns all upgrades stored in the db, split into send
+// and vault upgrades.
+func GetUpgradeCatalog() (*shared.Upgrades, error) {
+	s := `SELECT tag, name, description, price, bytes, annual, is_vault,
+	             btcpay_link, stripe_id
+	      FROM upgrades`
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	catalog := &shared.Upgrades{
+		SendUpgrades:  []*shared.Upgrade{},
+		VaultUpgrades: []*shared.Upgrade{},
+	}
+
+	for rows.Next() {
+		var upgrade shared.Upgrade
+		err = rows.Scan(
+			&upgrade.Tag,
+			&upgrade.Name,
+			&upgrade.Description,
+			&upgrade.Price,
+			&upgrade.Bytes,
+			&upgrade.Annual,
+			&upgrade.IsVaultUpgrade,
+			&upgrade.BTCPayLink,
+			&upgrade.StripeID)
+		if err != nil {
+			return nil, err
+		}
+
+		if upgrade.IsVaultUpgrade {
+			catalog.VaultUpgrades = append(catalog.VaultUpgrades, &upgrade)
+		} else {
+			catalog.SendUpgrades = append(catalog.SendUpgrades, &upgrade)
+		}
+	}
+
+	return catalog, nil
+}
+
+// SaveUpgrade adds a new upgrade to the catalog, or updates the existing
+// upgrade with the same tag.
+func SaveUpgrade(upgrade shared.Upgrade) error {
+	s := `INSERT INTO upgrades
+	      (tag, name, description, price, bytes, annual, is_vault,
+	       btcpay_link, stripe_id, updated)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	      ON CONFLICT (tag) DO UPDATE
+	      SET name=$2, description=$3, price=$4, bytes=$5, annual=$6,
+	          is_vault=$7, btcpay_link=$8, stripe_id=$9, updated=$10`
+	_, err := db.Exec(
+		s,
+		upgrade.Tag,
+		upgrade.Name,
+		upgrade.Description,
+		upgrade.Price,
+		upgrade.Bytes,
+		upgrade.Annual,
+		upgrade.IsVaultUpgrade,
+		upgrade.BTCPayLink,
+		upgrade.StripeID,
+		time.Now().UTC())
+	return err
+}
+
+// DeleteUpgrade removes an upgrade from the catalog. Users that have already
+// purchased the upgrade keep their current limits.
+func DeleteUpgrade(tag string) error {
+	s := `DELETE FROM upgrades WHERE tag=$1`
+	_, err := db.Exec(s, tag)
+	return err
+}
+
+// RefreshUpgrades reloads the upgrade catalog from the db, so that changes
+// made on any server are picked up without a restart.
+func RefreshUpgrades() {
+	catalog, err := GetUpgradeCatalog()
+	if err != nil {
+		log.Printf("Error refreshing upgrade catalog: %v\n", err)
+		return
+	}
+
+	upgrades.SetUpgrades(catalog)
+}
+
+// initUpgradeCatalog seeds an empty upgrade catalog with any upgrades defined
+// in YEETFILE_UPGRADES_JSON, and then loads the catalog from the db.
+func initUpgradeCatalog() {
+	var count int
+	s := `SELECT COUNT(*) FROM upgrades`
+	err := db.QueryRow(s).Scan(&count)
+	if err != nil {
+		log.Fatalf("Error reading upgrade catalog: %v\n", err)
+	}
+
+	envUpgrades := upgrades.GetEnvUpgrades()
+	envCount := len(envUpgrades.SendUpgrades) + len(envUpgrades.VaultUpgrades)
+	if count == 0 && envCount > 0 {
+		log.Println("Seeding upgrade catalog from YEETFILE_UPGRADES_JSON")
+		for _, upgrade := range envUpgrades.SendUpgrades {
+			err = SaveUpgrade(*upgrade)
+			if err != nil {
+				log.Fatalf("Error seeding upgrade catalog: %v\n", err)
+			}
+		}
+
+		for _, upgrade := range envUpgrades.VaultUpgrades {
+			err = SaveUpgrade(*upgrade)
+			if err != nil {
+				log.Fatalf("Error seeding upgrade catalog: %v\n", err)
+			}
+		}
+	} else if count > 0 && envCount > 0 {
+		log.Println("Upgrade catalog already exists in the db, " +
+			"ignoring YEETFILE_UPGRADES_JSON")
+	}
+
+	RefreshUpgrades()
+
+	catalog := upgrades.GetAllUpgrades()
+	if len(catalog.SendUpgrades) > 0 || len(catalog.VaultUpgrades) > 0 {
+		log.Printf("-- Loaded %d send upgrades and %d vault upgrades\n",
+			len(catalog.SendUpgrades),
+			len(catalog.VaultUpgrades))
+	}
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"log"
+	"yeetfile/backend/config"
+	"yeetfile/backend/mail"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+type usageWarningLevels struct {
+	Storage   int
+	Send      int
+	Bandwidth int
+}
+
+// getBandwidthUsage returns the total bandwidth available to a user for the
+// current bandwidth monitoring period and the amount that has been used, using
+// the remaining bandwidth stored in the users table.
+func getBandwidthUsage(storageAvailable, bandwidth int64) (int64, int64) {
+	if config.YeetFileConfig.DefaultUserStorage < 0 || storageAvailable <= 0 {
+		return -1, 0
+	}
+
+	total := storageAvailable *
+		constants.TotalBandwidthMultiplier *
+		constants.BandwidthMonitorDuration
+
+	return total, max(total-bandwidth, 0)
+}
+
+// generateUsageWarnings returns a list of usage warnings for each type of limit
+// (storage, send, bandwidth) that has reached one of the configured thresholds.
+func generateUsageWarnings(usage shared.UsageResponse) []shared.UsageWarning {
+	warnings := []shared.UsageWarning{}
+	thresholds := config.YeetFileConfig.UsageWarnings
+
+	addWarning := func(usageType string, used, available int64) {
+		threshold := shared.GetUsageThreshold(used, available, thresholds)
+		if threshold == 0 {
+			return
+		}
+
+		warnings = append(warnings, shared.UsageWarning{
+			Type:      usageType,
+			Threshold: threshold,
+			Used:      used,
+			Available: available,
+		})
+	}
+
+	addWarning(constants.UsageTypeStorage, usage.StorageUsed, usage.StorageAvailable)
+	addWarning(constants.UsageTypeSend, usage.SendUsed, usage.SendAvailable)
+	addWarning(constants.UsageTypeBandwidth, usage.BandwidthUsed, usage.BandwidthAvailable)
+
+	return warnings
+}
+
+// setUserUsageWarningLevels updates the thresholds that a user has already
+// been notified about, preventing repeat notifications for the same threshold.
+func setUserUsageWarningLevels(id string, levels usageWarningLevels) error {
+	s := `UPDATE users
+	      SET storage_warning=$2, send_warning=$3, bandwidth_warning=$4
+	      WHERE id=$1`
+	_, err := db.Exec(s, id, levels.Storage, levels.Send, levels.Bandwidth)
+	return err
+}
+
+// CheckUsageWarnings inspects each user's storage, send, and bandwidth usage
+// and sends an email notification to users who have reached a new usage
+// warning threshold since the last time they were notified. Users who have
+// dropped below a previously notified threshold are reset so that they can be
+// notified again in the future.
+func CheckUsageWarnings() {
+	s := `SELECT id, email,
+	             storage_used, storage_available,
+	             send_used, send_available, bandwidth,
+	             storage_warning, send_warning, bandwidth_warning
+	      FROM users
+	      WHERE storage_available > 0 OR send_available > 0`
+
+	rows, err := db.Query(s)
+	if err != nil {
+		log.Printf("Error retrieving user usage: %v\n", err)
+		return
+	}
+
+	defer rows.Close()
+
+	type userWarnings struct {
+		id       string
+		email    string
+		levels   usageWarningLevels
+		warnings []shared.UsageWarning
+	}
+
+	var updates []userWarnings
+	for rows.Next() {
+		var (
+			id        string
+			email     string
+			usage     shared.UsageResponse
+			bandwidth int64
+			prev      usageWarningLevels
+		)
+
+		err = rows.Scan(
+			&id, &email,
+			&usage.StorageUsed, &usage.StorageAvailable,
+			&usage.SendUsed, &usage.SendAvailable, &bandwidth,
+			&prev.Storage, &prev.Send, &prev.Bandwidth)
+		if err != nil {
+			log.Printf("Error scanning user usage rows: %v\n", err)
+			return
+		}
+
+		usage.BandwidthAvailable, usage.BandwidthUsed = getBandwidthUsage(
+			usage.StorageAvailable,
+			bandwidth)
+
+		var (
+			current     usageWarningLevels
+			newWarnings []shared.UsageWarning
+		)
+
+		for _, warning := range generateUsageWarnings(usage) {
+			var prevLevel int
+			switch warning.Type {
+			case constants.UsageTypeStorage:
+				current.Storage, prevLevel = warning.Threshold, prev.Storage
+			case constants.UsageTypeSend:
+				current.Send, prevLevel = warning.Threshold, prev.Send
+			case constants.UsageTypeBandwidth:
+				current.Bandwidth, prevLevel = warning.Threshold, prev.Bandwidth
+			}
+
+			if warning.Threshold > prevLevel {
+				newWarnings = append(newWarnings, warning)
+			}
+		}
+
+		if current == prev {
+			continue
+		}
+
+		updates = append(updates, userWarnings{
+			id:       id,
+			email:    email,
+			levels:   current,
+			warnings: newWarnings,
+		})
+	}
+
+	for _, update := range updates {
+		err = setUserUsageWarningLevels(update.id, update.levels)
+		if err != nil {
+			log.Printf("Error updating user usage warning levels: %v\n", err)
+			continue
+		}
+
+		if len(update.email) == 0 || len(update.warnings) == 0 {
+			continue
+		}
+
+		var messages []string
+		for _, warning := range update.warnings {
+			messages = append(messages, shared.FormatUsageWarning(warning))
+		}
+
+		err = mail.SendUsageWarningEmail(update.email, messages)
+		if err != nil {
+			log.Printf("Error sending usage warning email: %v\n", err)
+		}
+	}
+}
@@ -29,6 +29,8 @@ type User struct {
 	StorageUsed         int64
 	SendAvailable       int64
 	SendUsed            int64
+	Pending             bool
+	KDF                 shared.KDFParams
 }
 
 type UserStorage struct {
@@ -97,9 +99,16 @@ func NewUser(user User) (string, error) {
                    last_upgraded_month,
                    protected_key,
                    public_key,
-                   bandwidth)
-	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
-
+                   bandwidth,
+                   created,
+                   pending,
+                   kdf_algorithm,
+                   kdf_iterations,
+                   kdf_memory)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+	              $15, $16, $17)`
+
+	kdf := user.KDF.OrDefault(shared.LegacyKDFParams())
 	_, err := db.Exec(
 		s,
 		user.ID,
@@ -115,7 +124,12 @@ func NewUser(user User) (string, error) {
 		user.PublicKey,
 		config.YeetFileConfig.DefaultUserStorage*
 			constants.TotalBandwidthMultiplier*
-			constants.BandwidthMonitorDuration)
+			constants.BandwidthMonitorDuration,
+		time.Now().UTC(),
+		user.Pending,
+		kdf.Algorithm,
+		kdf.Iterations,
+		kdf.Memory)
 	if err != nil {
 		return "", err
 	}
@@ -128,18 +142,46 @@ func NewUser(user User) (string, error) {
 }
 
 // UpdateUser is used to update user values that can change as a result of a
-// user changing their email or password.
+// user changing their email or password. The user's pending protected keys
+// (see SetUserPendingProtectedKeys) are applied in the same transaction, so
+// that their keys are never left encrypted with a key they no longer have.
 func UpdateUser(user User, accountID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
 	s := `UPDATE users 
-	      SET email=$1, pw_hash=$2, protected_key=$3
-	      WHERE id=$4`
-	_, err := db.Exec(
+	      SET email=$1, pw_hash=$2, protected_key=$3,
+	          kdf_algorithm=$4, kdf_iterations=$5, kdf_memory=$6
+	      WHERE id=$7`
+	kdf := user.KDF.OrDefault(shared.LegacyKDFParams())
+	_, err = tx.Exec(
 		s,
 		user.Email,
 		user.PasswordHash,
 		user.ProtectedPrivateKey,
+		kdf.Algorithm,
+		kdf.Iterations,
+		kdf.Memory,
 		accountID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_keys
+		SET protected_key=pending_protected_key, pending_protected_key=NULL
+		WHERE user_id=$1 AND pending_protected_key IS NOT NULL`,
+		accountID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetUserCount returns the total number of users in the table
@@ -251,8 +293,23 @@ func GetUserStorage(id string) (UserStorage, UserSend, error) {
 }
 
 // UpdateStorageUsed updates the amount of storage used by the user. Can be a
-// negative number to remove storage space.
+// negative number to remove storage space. The user's usage and their
+// organization's pool are updated together, and neither is changed if the
+// update exceeds the user's storage limits.
 func UpdateStorageUsed(userID string, amount int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	pooled, err := updateOrgUsage(tx, userID, "storage", amount)
+	if err != nil {
+		return err
+	}
+
 	var storageUsed int
 	var storageAvailable int
 	s := `UPDATE users 
@@ -262,26 +319,33 @@ func UpdateStorageUsed(userID string, amount int64) error {
 	                         END
 	      WHERE id=$2 AND storage_available > 0
 	      RETURNING storage_used, storage_available`
-	err := db.QueryRow(s, amount, userID).Scan(&storageUsed, &storageAvailable)
+	err = tx.QueryRow(s, amount, userID).Scan(&storageUsed, &storageAvailable)
 	if err != nil && err != sql.ErrNoRows {
 		return err
-	} else if storageUsed > storageAvailable && amount > 0 && config.YeetFileConfig.DefaultUserStorage > 0 {
+	} else if !pooled && storageUsed > storageAvailable && amount > 0 && config.YeetFileConfig.DefaultUserStorage > 0 {
 		return UserStorageExceeded
 	}
 
-	return nil
+	// Limits for pooled members are enforced by the organization's pool
+	return tx.Commit()
 }
 
 // UpdateBandwidth subtracts bandwidth from the user's bandwidth column, returning
-// an error if the value goes below 0.
+// an error if the value goes below 0. Members of an organization with pooled
+// storage use the organization's bandwidth instead.
 func UpdateBandwidth(userID string, amount int64) error {
 	// Skip db update if send limits aren't configured
 	if config.YeetFileConfig.DefaultUserStorage < 0 {
 		return nil
 	}
 
+	pooled, err := updateOrgBandwidth(userID, amount)
+	if err != nil || pooled {
+		return err
+	}
+
 	s := `UPDATE users SET bandwidth=bandwidth-$2 WHERE id=$1`
-	_, err := db.Exec(s, userID, amount)
+	_, err = db.Exec(s, userID, amount)
 	return err
 }
 
@@ -566,11 +630,11 @@ func GetUserSubByPaymentID(paymentID string) (string, time.Time, error) {
 		subExp  time.Time
 	)
 
-	err := db.QueryRow(`
+	s := fmt.Sprintf(`
 	        SELECT upgrade_tag, upgrade_exp 
-	        FROM users 
-	        WHERE payment_id=$1`, paymentID).
-		Scan(&subType, &subExp)
+	        FROM %s 
+	        WHERE payment_id=$1`, paymentTable(paymentID))
+	err := db.QueryRow(s, paymentID).Scan(&subType, &subExp)
 
 	if err != nil {
 		return "", time.Time{}, err
@@ -592,34 +656,68 @@ func GetUserPasswordHintByEmail(email string) ([]byte, error) {
 	return hint, nil
 }
 
-// GetUserUsage fetches the storage used/available and send used/available for
-// the user matching the provided ID.
+// GetUserUsage fetches the storage used/available, send used/available, and
+// bandwidth used/available for the user matching the provided ID, as well as
+// any usage warnings that apply to the user.
 func GetUserUsage(id string) (shared.UsageResponse, error) {
 	var (
 		storageUsed      int64
 		storageAvailable int64
 		sendUsed         int64
 		sendAvailable    int64
+		bandwidth        int64
 	)
 
-	s := `SELECT storage_used, storage_available, send_used, send_available
+	s := `SELECT storage_used, storage_available, send_used, send_available, bandwidth
 	      FROM users
 	      WHERE id = $1`
 	err := db.QueryRow(s, id).Scan(
 		&storageUsed,
 		&storageAvailable,
 		&sendUsed,
-		&sendAvailable)
+		&sendAvailable,
+		&bandwidth)
 	if err != nil {
 		return shared.UsageResponse{}, err
 	}
 
-	return shared.UsageResponse{
-		StorageAvailable: storageAvailable,
-		StorageUsed:      storageUsed,
-		SendAvailable:    sendAvailable,
-		SendUsed:         sendUsed,
-	}, nil
+	// Members of an organization with pooled storage are limited by the
+	// organization's pool rather than their own account's limits
+	bandwidthStorage := storageAvailable
+	storageLimits, pooled, err := GetOrgStorageLimits(id)
+	if err != nil {
+		return shared.UsageResponse{}, err
+	} else if pooled {
+		storageUsed, storageAvailable = storageLimits.Usage()
+		bandwidthStorage = storageLimits.PoolAvailable
+	}
+
+	sendLimits, pooled, err := GetOrgSendLimits(id)
+	if err != nil {
+		return shared.UsageResponse{}, err
+	} else if pooled {
+		sendUsed, sendAvailable = sendLimits.Usage()
+	}
+
+	orgBandwidth, pooled, err := getOrgBandwidth(id)
+	if err != nil {
+		return shared.UsageResponse{}, err
+	} else if pooled {
+		bandwidth = orgBandwidth
+	}
+
+	bandwidthAvailable, bandwidthUsed := getBandwidthUsage(bandwidthStorage, bandwidth)
+	usage := shared.UsageResponse{
+		StorageAvailable:   storageAvailable,
+		StorageUsed:        storageUsed,
+		SendAvailable:      sendAvailable,
+		SendUsed:           sendUsed,
+		BandwidthAvailable: bandwidthAvailable,
+		BandwidthUsed:      bandwidthUsed,
+	}
+
+	usage.UsageWarnings = generateUsageWarnings(usage)
+	return usage, nil
 }
 
 // GetUserSendLimits returns the amount of used and available bytes for
@@ -663,9 +761,15 @@ func GetUserStorageLimits(id string) (int64, int64, error) {
 // GetUserBandwidth returns the user's bandwidth, which can be used to determine
 // if a file download can be performed.
 func GetUserBandwidth(id string) (int64, error) {
-	var bandwidth int64
+	bandwidth, pooled, err := getOrgBandwidth(id)
+	if err != nil {
+		return 0, err
+	} else if pooled {
+		return bandwidth, nil
+	}
+
 	s := `SELECT bandwidth FROM users WHERE id=$1`
-	err := db.QueryRow(s, id).Scan(&bandwidth)
+	err = db.QueryRow(s, id).Scan(&bandwidth)
 	if err != nil {
 		return 0, err
 	}
@@ -718,11 +822,20 @@ func SetUserSessionKey(userID, sessionKey string) error {
 	return err
 }
 
+// GetUserEmailByPaymentID returns the email of the user with the payment ID.
+// For organizations, the email of the organization's owner is returned.
 func GetUserEmailByPaymentID(paymentID string) (string, error) {
-	rows, err := db.Query(`
-		SELECT email
-		FROM users
-		WHERE payment_id = $1`, paymentID)
+	s := `SELECT email
+	      FROM users
+	      WHERE payment_id = $1`
+	if IsOrgPaymentID(paymentID) {
+		s = `SELECT u.email
+		     FROM users u
+		     JOIN organizations o ON o.owner_id = u.id
+		     WHERE o.payment_id = $1`
+	}
+
+	rows, err := db.Query(s, paymentID)
 	if err != nil {
 		log.Printf("Error querying for user by payment_id: %s\n", paymentID)
 		return "", err
@@ -750,19 +863,29 @@ func SetUserVaultUpgrade(
 	upgradeTag string,
 	exp time.Time,
 	storage int64,
+) error {
+	return setUserVaultUpgrade(db, paymentID, upgradeTag, exp, storage)
+}
+
+func setUserVaultUpgrade(
+	exec execer,
+	paymentID string,
+	upgradeTag string,
+	exp time.Time,
+	storage int64,
 ) error {
 	totalWeeklyBandwidth := storage *
 		constants.TotalBandwidthMultiplier *
 		constants.BandwidthMonitorDuration
 
-	s := `UPDATE users
+	s := fmt.Sprintf(`UPDATE %s
               SET upgrade_exp=$1,
                   storage_available=$2,
                   upgrade_tag=$3,
                   last_upgraded_month=$4, bandwidth=$5
-              WHERE payment_id=$6`
+              WHERE payment_id=$6`, paymentTable(paymentID))
 
-	_, err := db.Exec(s,
+	_, err := exec.Exec(s,
 		exp,
 		storage,
 		upgradeTag,
@@ -778,12 +901,20 @@ func SetUserVaultUpgrade(
 
 // SetUserSendUpgrade updates a user's available send
 func SetUserSendUpgrade(paymentID string, sendUpgradeBytes int64) error {
+	return setUserSendUpgrade(db, paymentID, sendUpgradeBytes)
+}
+
+func setUserSendUpgrade(exec execer, paymentID string, sendUpgradeBytes int64) error {
+	if IsOrgPaymentID(paymentID) {
+		return setOrgSendUpgrade(exec, paymentID, sendUpgradeBytes)
+	}
+
 	s := `UPDATE users
               SET send_available = send_available - send_used + $1,
                   send_used = 0
               WHERE payment_id=$2`
 
-	_, err := db.Exec(s,
+	_, err := exec.Exec(s,
 		sendUpgradeBytes,
 		paymentID)
 	if err != nil {
@@ -793,9 +924,69 @@ func SetUserSendUpgrade(paymentID string, sendUpgradeBytes int64) error {
 	return nil
 }
 
+// SetUserVaultUpgradeExp updates the expiration of a user's vault upgrade
+// without modifying their current storage.
+func SetUserVaultUpgradeExp(paymentID string, exp time.Time) error {
+	s := fmt.Sprintf(
+		`UPDATE %s SET upgrade_exp=$1 WHERE payment_id=$2`,
+		paymentTable(paymentID))
+	_, err := db.Exec(s, exp, paymentID)
+	return err
+}
+
+// RevokeUserVaultUpgrade immediately reverts a user's vault storage back to
+// the default amount and marks their upgrade as expired. Organizations are
+// reverted to having no pooled storage.
+func RevokeUserVaultUpgrade(paymentID string) error {
+	s := fmt.Sprintf(`UPDATE %s
+	      SET upgrade_exp=$1,
+	          storage_available=$2,
+	          upgrade_tag='',
+	          last_upgraded_month=$3
+	      WHERE payment_id=$4`, paymentTable(paymentID))
+
+	defaultStorage := config.YeetFileConfig.DefaultUserStorage
+	if IsOrgPaymentID(paymentID) {
+		defaultStorage = 0
+	}
+
+	_, err := db.Exec(s,
+		time.Now().UTC(),
+		defaultStorage,
+		unsubscribedMonth,
+		paymentID)
+	return err
+}
+
+// AdjustUserSendUpgrade adds (or removes, if negative) an amount of bytes to
+// a user's available send without dropping below zero.
+func AdjustUserSendUpgrade(paymentID string, sendBytes int64) error {
+	s := fmt.Sprintf(`UPDATE %s
+	      SET send_available = GREATEST(send_available + $1, 0)
+	      WHERE payment_id=$2`, paymentTable(paymentID))
+
+	_, err := db.Exec(s, sendBytes, paymentID)
+	return err
+}
+
 // UpdateUserSendUsed adds an amount of bytes (size) to a user's send_used
 // given their user ID.
 func UpdateUserSendUsed(id string, size int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	pooled, err := updateOrgUsage(tx, id, "send", int64(size))
+	if err != nil {
+		return err
+	} else if pooled {
+		return tx.Commit()
+	}
+
 	s := `UPDATE users 
 	      SET send_used = CASE 
 	                           WHEN send_used + $1 < 0 THEN 0
@@ -806,18 +997,20 @@ func UpdateUserSendUsed(id string, size int) error {
 
 	var sendUsed int
 	var sendAvailable int
-	err := db.QueryRow(s, size, id).Scan(&sendUsed, &sendAvailable)
+	err = tx.QueryRow(s, size, id).Scan(&sendUsed, &sendAvailable)
 	if err != nil && err != sql.ErrNoRows {
 		return err
 	} else if sendUsed > sendAvailable {
 		return UserSendExceeded
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 func CheckBandwidth() {
-	bandwidthUpdate := `UPDATE users SET bandwidth = storage_available * $1 * $2;`
+	bandwidthUpdate := `UPDATE users
+	                    SET bandwidth = storage_available * $1 * $2,
+	                        bandwidth_warning = 0;`
 	_, err := db.Exec(
 		bandwidthUpdate,
 		constants.TotalBandwidthMultiplier,
@@ -825,6 +1018,16 @@ func CheckBandwidth() {
 	if err != nil {
 		log.Printf("Failed to update user bandwidths")
 	}
+
+	orgBandwidthUpdate := `UPDATE organizations
+	                       SET bandwidth = storage_available * $1 * $2;`
+	_, err = db.Exec(
+		orgBandwidthUpdate,
+		constants.TotalBandwidthMultiplier,
+		constants.BandwidthMonitorDuration)
+	if err != nil {
+		log.Printf("Failed to update organization bandwidths")
+	}
 }
 
 // CheckActiveUpgrades inspects each user's upgraded account and updates their
@@ -905,6 +1108,11 @@ func CheckActiveUpgrades() {
 			log.Printf("Error updating user storage/send: %v\n", err)
 		}
 	}
+
+	err = CheckOrgUpgrades()
+	if err != nil {
+		log.Printf("Error resetting expired organization upgrades: %v\n", err)
+	}
 }
 
 // CheckUpgradeExpiration checks for users who are a week away from having their
@@ -984,20 +1192,84 @@ func DeleteUser(id string) error {
 		return err
 	}
 
-	return nil
+	_, err = db.Exec(`DELETE FROM admin_roles WHERE user_id=$1`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM invite_links WHERE created_by=$1`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM oidc_identities WHERE user_id=$1`, id)
+	if err != nil {
+		return err
+	}
+
+	err = DeleteUserKeys(id)
+	if err != nil {
+		return err
+	}
+
+	err = deleteUserEmergencyAccess(id)
+	if err != nil {
+		return err
+	}
+
+	err = DeleteUserContacts(id)
+	if err != nil {
+		return err
+	}
+
+	return deleteUserOrgData(id)
+}
+
+// SetUserLastLogin updates the user's last login timestamp to the current time.
+func SetUserLastLogin(id string) error {
+	s := `UPDATE users SET last_login=$2 WHERE id=$1`
+	_, err := db.Exec(s, id, time.Now().UTC())
+	return err
 }
 
-func UpdateUserLogin(id string, loginKeyHash, protectedKey []byte) error {
+// UpdateUserLogin replaces the user's login credentials along with the
+// encrypted private keys of their additional key pairs, which all change
+// together whenever the user's key changes
+func UpdateUserLogin(
+	id string,
+	loginKeyHash,
+	protectedKey []byte,
+	keys []shared.UserKey,
+	kdf shared.KDFParams,
+) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
 	s := `UPDATE users
-          SET pw_hash=$2, protected_key=$3
+          SET pw_hash=$2, protected_key=$3,
+              kdf_algorithm=$4, kdf_iterations=$5, kdf_memory=$6
           WHERE id=$1`
 
-	_, err := db.Exec(s, id, loginKeyHash, protectedKey)
+	_, err = tx.Exec(s, id, loginKeyHash, protectedKey,
+		kdf.Algorithm, kdf.Iterations, kdf.Memory)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	s = `UPDATE user_keys SET protected_key=$3 WHERE user_id=$1 AND key_type=$2`
+	for _, key := range keys {
+		_, err = tx.Exec(s, id, key.Type, key.ProtectedKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 // OverrideUserStorage sets the user's storage_available to the specified amount,
@@ -1024,6 +1296,192 @@ func OverrideUserSend(userID string, send int64) error {
 	return nil
 }
 
+// SuspendUser marks a user as suspended with the provided reason, and clears
+// their session key to invalidate any existing sessions.
+func SuspendUser(id, reason string) error {
+	s := `UPDATE users
+	      SET suspended=true, suspended_reason=$2, suspended_at=$3, session_key=''
+	      WHERE id=$1`
+	result, err := db.Exec(s, id, reason, time.Now().UTC())
+	if err != nil {
+		return err
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UnsuspendUser lifts a suspension on a user, removing the recorded reason.
+func UnsuspendUser(id string) error {
+	s := `UPDATE users
+	      SET suspended=false, suspended_reason='', suspended_at=NULL
+	      WHERE id=$1`
+	result, err := db.Exec(s, id)
+	if err != nil {
+		return err
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetUserSuspension returns whether the user is suspended and the reason for
+// the suspension (if any).
+func GetUserSuspension(id string) (bool, string, error) {
+	var (
+		suspended bool
+		reason    string
+	)
+
+	s := `SELECT suspended, suspended_reason FROM users WHERE id=$1`
+	err := db.QueryRow(s, id).Scan(&suspended, &reason)
+	return suspended, reason, err
+}
+
+// IsUserSuspended returns true if the user has been suspended by an admin.
+func IsUserSuspended(id string) bool {
+	suspended, _, err := GetUserSuspension(id)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error checking user suspension: %v\n", err)
+	}
+
+	return suspended
+}
+
+// IsUserPending returns true if the user has signed up but hasn't been approved
+// by an admin yet.
+func IsUserPending(id string) bool {
+	var pending bool
+	s := `SELECT pending FROM users WHERE id=$1`
+	err := db.QueryRow(s, id).Scan(&pending)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error checking user approval: %v\n", err)
+	}
+
+	return pending
+}
+
+// GetPendingUsers returns every user waiting for admin approval, with the
+// oldest signups first
+func GetPendingUsers() ([]shared.PendingSignup, error) {
+	s := `SELECT id, email, created FROM users WHERE pending=true ORDER BY created`
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.PendingSignup{}
+	for rows.Next() {
+		var signup shared.PendingSignup
+		err = rows.Scan(&signup.ID, &signup.Email, &signup.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, signup)
+	}
+
+	return result, nil
+}
+
+// ApproveUser removes the pending state from a user's account. Returns
+// sql.ErrNoRows if the user doesn't exist or isn't pending approval.
+func ApproveUser(id string) error {
+	s := `UPDATE users SET pending=false WHERE id=$1 AND pending=true`
+	result, err := db.Exec(s, id)
+	if err != nil {
+		return err
+	} else if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AdminListUsers returns a page of users matching the provided search string
+// (partial match on ID or email), ordered by the provided sort column. Also
+// returns the total number of users matching the search.
+func AdminListUsers(
+	search, sortBy string,
+	desc bool,
+	limit, offset int,
+) ([]shared.AdminUserListItem, int, error) {
+	sortColumn, ok := adminUserSortColumns[sortBy]
+	if !ok {
+		sortColumn = adminUserSortColumns[constants.AdminSortSignup]
+	}
+
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+
+	filter := "%" + escapeLikePattern(search) + "%"
+
+	var total int
+	s := `SELECT COUNT(*) FROM users WHERE id ILIKE $1 OR email ILIKE $1`
+	err := db.QueryRow(s, filter).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s = fmt.Sprintf(`SELECT id, email,
+	                        storage_used, storage_available,
+	                        send_used, send_available,
+	                        length(secret) > 0, created, last_login, suspended
+	                 FROM users
+	                 WHERE id ILIKE $1 OR email ILIKE $1
+	                 ORDER BY %s %s NULLS LAST, id
+	                 LIMIT $2 OFFSET $3`, sortColumn, order)
+	rows, err := db.Query(s, filter, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	users := []shared.AdminUserListItem{}
+	for rows.Next() {
+		var (
+			user      shared.AdminUserListItem
+			created   sql.NullTime
+			lastLogin sql.NullTime
+		)
+
+		err = rows.Scan(
+			&user.ID, &user.Email,
+			&user.StorageUsed, &user.StorageAvailable,
+			&user.SendUsed, &user.SendAvailable,
+			&user.Has2FA, &created, &lastLogin, &user.Suspended)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		user.Created = created.Time
+		user.LastLogin = lastLogin.Time
+		users = append(users, user)
+	}
+
+	return users, total, nil
+}
+
+// escapeLikePattern escapes characters that have special meaning in a
+// LIKE/ILIKE pattern so that they're matched literally.
+func escapeLikePattern(str string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(str)
+}
+
+var adminUserSortColumns = map[string]string{
+	constants.AdminSortStorage:   "storage_used",
+	constants.AdminSortLastLogin: "last_login",
+	constants.AdminSortSignup:    "created",
+}
+
 func init() {
 	var err error
 	defaultExp, err = time.Parse(time.RFC1123, time.RFC1123)
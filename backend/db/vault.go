@@ -210,9 +210,9 @@ func AddVaultItem(userID string, item shared.VaultUpload) (string, error) {
 	      (
 	       id, owner_id, name, length, folder_id, 
 	       chunks, protected_key, modified, pw_data, 
-	       ref_id
+	       ref_id, encryption_version
 	      )
-	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $1)`
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $1, $10)`
 	_, err = db.Exec(
 		s,
 		itemID,
@@ -223,7 +223,8 @@ func AddVaultItem(userID string, item shared.VaultUpload) (string, error) {
 		item.Chunks,
 		item.ProtectedKey,
 		time.Now().UTC(),
-		pwData)
+		pwData,
+		item.EncryptionVersion)
 	if err != nil {
 		return "", err
 	}
@@ -286,14 +287,16 @@ func ShareFile(share shared.NewSharedItem, userID string) (string, error) {
 
 	s1 := `INSERT INTO vault
     	           (id, name, folder_id, owner_id, b2_id, length, chunks,
-                    protected_key, shared_by, modified, can_modify, ref_id, pw_data)
-	       VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+                    protected_key, shared_by, modified, can_modify, ref_id, pw_data,
+                    encryption_version)
+	       VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
 	_, err = db.Exec(s1,
 		itemID, file.Name,
 		share.RecipientID, share.RecipientID,
 		file.B2ID, file.Length, file.Chunks,
 		share.ProtectedKey, sharedByName,
-		time.Now().UTC(), share.CanModify, file.ID, file.PasswordData)
+		time.Now().UTC(), share.CanModify, file.ID, file.PasswordData,
+		file.EncryptionVersion)
 	if err != nil {
 		return "", err
 	}
@@ -514,6 +517,24 @@ func AdminRetrieveMetadata(fileID string) (shared.AdminFileInfoResponse, error)
 	}, err
 }
 
+// IsVaultItemOwnerSuspended returns true if the original owner of a vault
+// item (identified by the item's ref_id) has been suspended, which prevents
+// recipients from accessing items shared by that user.
+func IsVaultItemOwnerSuspended(refID string) bool {
+	var suspended bool
+	s := `SELECT EXISTS(
+	          SELECT 1 FROM vault v
+	          JOIN users u ON u.id = v.owner_id
+	          WHERE v.id = $1 AND u.suspended)`
+	err := db.QueryRow(s, refID).Scan(&suspended)
+	if err != nil {
+		log.Printf("Error checking vault item owner suspension: %v\n", err)
+		return false
+	}
+
+	return suspended
+}
+
 // RetrieveVaultMetadata returns a FileMetadata struct containing a specific
 // file's metadata
 func RetrieveVaultMetadata(id, ownerID string) (FileMetadata, error) {
@@ -533,7 +554,8 @@ func RetrieveVaultMetadata(id, ownerID string) (FileMetadata, error) {
 		}
 	}
 
-	s := `SELECT id, b2_id, ref_id, name, length, chunks, protected_key, pw_data
+	s := `SELECT id, b2_id, ref_id, name, length, chunks, protected_key, pw_data,
+	             encryption_version
 	      FROM vault
 	      WHERE ref_id = $1`
 
@@ -562,9 +584,11 @@ func RetrieveVaultMetadata(id, ownerID string) (FileMetadata, error) {
 		var chunks int
 		var protectedKey []byte
 		var passwordData []byte
+		var encryptionVersion int
 		err = rows.Scan(
 			&itemID, &b2ID, &refID, &name,
-			&length, &chunks, &protectedKey, &passwordData)
+			&length, &chunks, &protectedKey, &passwordData,
+			&encryptionVersion)
 		if err != nil {
 			log.Printf("Error scanning rows: %v\n", err)
 			return FileMetadata{}, err
@@ -582,6 +606,7 @@ func RetrieveVaultMetadata(id, ownerID string) (FileMetadata, error) {
 			PasswordData:      passwordData,
 			OwnsParentFolder:  ownership.IsOwner,
 			ParentFolderOwner: ownership.ID,
+			EncryptionVersion: encryptionVersion,
 		}, nil
 	}
 
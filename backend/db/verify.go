@@ -18,15 +18,24 @@ type VerifiedAccountValues struct {
 	PublicKey               []byte
 	ProtectedVaultFolderKey []byte
 	PasswordHint            []byte
+	KDF                     shared.KDFParams
+	RecoveryKeyHash         []byte
+	RecoveryPublicKey       []byte
+	RecoveryProtectedKey    []byte
+	InviteLinkHash          []byte
 }
 
 // NewVerification creates a new verification entry for a user. Account ID can
 // be left empty for new user verification, otherwise should be provided if
-// an existing user is verifying their new email.
+// an existing user is verifying their new email. The recovery key hash in the
+// signup data (if any) should already be hashed with bcrypt. The invite link
+// hash is set if the signup was authorized with an invite link, which is
+// claimed once the account is created.
 func NewVerification(
 	signupData shared.Signup,
 	pwHash []byte,
 	accountID string,
+	inviteLinkHash []byte,
 ) (string, error) {
 	if config.YeetFileConfig.MaxUserCount > 0 {
 		count, err := GetUserCount()
@@ -60,6 +69,9 @@ func NewVerification(
 		return "", err
 	}
 
+	kdf := signupData.KDF.OrDefault(shared.LegacyKDFParams())
+	recovery := signupData.RecoveryKey
+
 	var pwHintEncrypted []byte
 	if len(signupData.PasswordHint) > 0 {
 		pwHintEncrypted, err = crypto.Encrypt(signupData.PasswordHint)
@@ -84,8 +96,15 @@ func NewVerification(
 			          protected_private_key=$3, 
 			          protected_vault_folder_key=$4, 
 			          pw_hint=$5,
-			          account_id=$6
-			      WHERE identity=$7`
+			          account_id=$6,
+			          kdf_algorithm=$7,
+			          kdf_iterations=$8,
+			          kdf_memory=$9,
+			          recovery_key_hash=$10,
+			          recovery_public_key=$11,
+			          recovery_protected_key=$12,
+			          invite_link_hash=$13
+			      WHERE identity=$14`
 			_, err = db.Exec(s,
 				pwHash,
 				signupData.PublicKey,
@@ -93,6 +112,13 @@ func NewVerification(
 				signupData.ProtectedVaultFolderKey,
 				pwHintEncrypted,
 				accountID,
+				kdf.Algorithm,
+				kdf.Iterations,
+				kdf.Memory,
+				recovery.KeyHash,
+				recovery.PublicKey,
+				recovery.ProtectedKey,
+				inviteLinkHash,
 				signupData.Identifier)
 			if err != nil {
 				return "", err
@@ -111,8 +137,15 @@ func NewVerification(
 			          protected_private_key=$5,
 			          protected_vault_folder_key=$6,
 			          pw_hint=$7,
-			          account_id=$8
-			      WHERE identity=$9`
+			          account_id=$8,
+			          kdf_algorithm=$9,
+			          kdf_iterations=$10,
+			          kdf_memory=$11,
+			          recovery_key_hash=$12,
+			          recovery_public_key=$13,
+			          recovery_protected_key=$14,
+			          invite_link_hash=$15
+			      WHERE identity=$16`
 			_, err = db.Exec(s,
 				code,
 				pwHash,
@@ -122,6 +155,13 @@ func NewVerification(
 				signupData.ProtectedVaultFolderKey,
 				pwHintEncrypted,
 				accountID,
+				kdf.Algorithm,
+				kdf.Iterations,
+				kdf.Memory,
+				recovery.KeyHash,
+				recovery.PublicKey,
+				recovery.ProtectedKey,
+				inviteLinkHash,
 				signupData.Identifier)
 			if err != nil {
 				return "", err
@@ -139,8 +179,16 @@ func NewVerification(
                     protected_private_key,
                     protected_vault_folder_key,
                     account_id,
-                    pw_hint) 
-		      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+                    pw_hint,
+                    kdf_algorithm,
+                    kdf_iterations,
+                    kdf_memory,
+                    recovery_key_hash,
+                    recovery_public_key,
+                    recovery_protected_key,
+                    invite_link_hash) 
+		      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+		              $14, $15, $16)`
 		_, err = db.Exec(
 			s,
 			signupData.Identifier,
@@ -151,7 +199,14 @@ func NewVerification(
 			signupData.ProtectedPrivateKey,
 			signupData.ProtectedVaultFolderKey,
 			accountID,
-			pwHintEncrypted)
+			pwHintEncrypted,
+			kdf.Algorithm,
+			kdf.Iterations,
+			kdf.Memory,
+			recovery.KeyHash,
+			recovery.PublicKey,
+			recovery.ProtectedKey,
+			inviteLinkHash)
 		if err != nil {
 			return "", err
 		}
@@ -171,6 +226,9 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 		protectedPrivateKey     []byte
 		protectedVaultFolderKey []byte
 		encPwHint               []byte
+		kdf                     shared.KDFParams
+		recovery                shared.RecoveryKey
+		inviteLinkHash          []byte
 	)
 
 	s := `SELECT 
@@ -179,7 +237,14 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 	          public_key, 
 	          protected_private_key, 
 	          protected_vault_folder_key, 
-	          pw_hint 
+	          pw_hint,
+	          kdf_algorithm,
+	          kdf_iterations,
+	          kdf_memory,
+	          recovery_key_hash,
+	          recovery_public_key,
+	          recovery_protected_key,
+	          invite_link_hash
 	      FROM verify WHERE identity=$1 AND code=$2`
 
 	row := db.QueryRow(s, identity, code)
@@ -189,7 +254,14 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 		&publicKey,
 		&protectedPrivateKey,
 		&protectedVaultFolderKey,
-		&encPwHint)
+		&encPwHint,
+		&kdf.Algorithm,
+		&kdf.Iterations,
+		&kdf.Memory,
+		&recovery.KeyHash,
+		&recovery.PublicKey,
+		&recovery.ProtectedKey,
+		&inviteLinkHash)
 
 	if err != nil {
 		return VerifiedAccountValues{}, err
@@ -203,6 +275,11 @@ func VerifyUser(identity string, code string) (VerifiedAccountValues, error) {
 		ProtectedPrivateKey:     protectedPrivateKey,
 		ProtectedVaultFolderKey: protectedVaultFolderKey,
 		PasswordHint:            encPwHint,
+		KDF:                     kdf,
+		RecoveryKeyHash:         recovery.KeyHash,
+		RecoveryPublicKey:       recovery.PublicKey,
+		RecoveryProtectedKey:    recovery.ProtectedKey,
+		InviteLinkHash:          inviteLinkHash,
 	}, nil
 }
 
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"strconv"
+	"time"
+	"yeetfile/shared"
+)
+
+const VoucherSource = "voucher"
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+var VoucherRedeemedErr = errors.New("voucher has already been redeemed")
+
+// CreateVoucher adds a new voucher code that can be redeemed for an upgrade.
+// A zero expiration creates a voucher that never expires.
+func CreateVoucher(voucher shared.AdminVoucher) error {
+	var expiration sql.NullTime
+	if !voucher.Expiration.IsZero() {
+		expiration = sql.NullTime{Time: voucher.Expiration.UTC(), Valid: true}
+	}
+
+	s := `INSERT INTO vouchers
+	      (code, tag, quantity, max_uses, uses, expiration, created)
+	      VALUES ($1, $2, $3, $4, 0, $5, $6)`
+	_, err := db.Exec(
+		s,
+		voucher.Code,
+		voucher.Tag,
+		voucher.Quantity,
+		voucher.MaxUses,
+		expiration,
+		time.Now().UTC())
+	return err
+}
+
+// GetVouchers returns all voucher codes, with the most recently created
+// vouchers first.
+func GetVouchers() ([]shared.AdminVoucher, error) {
+	s := `SELECT code, tag, quantity, max_uses, uses, expiration, created
+	      FROM vouchers
+	      ORDER BY created DESC`
+	rows, err := db.Query(s)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	result := []shared.AdminVoucher{}
+	for rows.Next() {
+		var voucher shared.AdminVoucher
+		var expiration sql.NullTime
+		err = rows.Scan(
+			&voucher.Code,
+			&voucher.Tag,
+			&voucher.Quantity,
+			&voucher.MaxUses,
+			&voucher.Uses,
+			&expiration,
+			&voucher.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		voucher.Expiration = expiration.Time
+		result = append(result, voucher)
+	}
+
+	return result, nil
+}
+
+// RedeemVoucher uses up one redemption of a voucher for the user, and records
+// the redemption as the provided invoice (with the voucher's tag and
+// quantity). The invoice is passed to prepare, which sets the upgrade values
+// for the voucher's upgrade, and the upgrade is applied to the account
+// matching the invoice's payment ID in the same transaction. The redemption
+// is undone if prepare or any of the updates fail. Returns sql.ErrNoRows if
+// the voucher doesn't exist, has expired, or has no redemptions remaining,
+// and VoucherRedeemedErr if the user has already redeemed the voucher.
+func RedeemVoucher(
+	userID string,
+	code string,
+	invoice Invoice,
+	prepare func(tag string, quantity int, invoice *Invoice) error,
+) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		tag      string
+		quantity int
+	)
+
+	s := `UPDATE vouchers SET uses = uses + 1
+	      WHERE code=$1 AND uses < max_uses
+	        AND (expiration IS NULL OR expiration > $2)
+	      RETURNING tag, quantity`
+	err = tx.QueryRow(s, code, time.Now().UTC()).Scan(&tag, &quantity)
+	if err != nil {
+		return err
+	}
+
+	// Each user can only redeem a voucher once. Redemptions are tied to the
+	// user's ID rather than their payment ID, which the user can replace.
+	s = `INSERT INTO voucher_redemptions (user_id, code, created)
+	     VALUES ($1, $2, $3)`
+	_, err = tx.Exec(s, userID, code, time.Now().UTC())
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return VoucherRedeemedErr
+	} else if err != nil {
+		return err
+	}
+
+	invoice.Source = VoucherSource
+	invoice.PaymentRef = code
+	invoice.Tag = tag
+	invoice.Quantities = strconv.Itoa(quantity)
+
+	err = prepare(tag, quantity, &invoice)
+	if err != nil {
+		return err
+	}
+
+	err = addInvoice(tx, invoice)
+	if err != nil {
+		return err
+	}
+
+	err = applyInvoiceUpgrades(tx, invoice)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteVoucher removes a voucher so that it can no longer be redeemed.
+// Upgrades from previous redemptions are not affected.
+func DeleteVoucher(code string) error {
+	s := `DELETE FROM vouchers WHERE code=$1`
+	_, err := db.Exec(s, code)
+	return err
+}
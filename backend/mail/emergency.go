@@ -0,0 +1,127 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+)
+
+type EmergencyEmail struct {
+	Domain   string
+	User     string
+	WaitDays int
+}
+
+var emergencyContactSubject = "You've been added as a YeetFile emergency contact"
+var emergencyContactBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\n{{.User}} has added you as an emergency contact for their " +
+		"YeetFile account on {{.Domain}}.\n\n" +
+		"If you ever need access to their vault, you can request it from " +
+		"your account. They will be notified, and access will be granted " +
+		"if they don't deny the request within {{.WaitDays}} day(s).\n\n" +
+		"- YeetFile Support"))
+
+var emergencyRequestSubject = "Emergency access to your YeetFile vault was requested"
+var emergencyRequestBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nYour emergency contact {{.User}} has requested access to " +
+		"your YeetFile vault on {{.Domain}}.\n\n" +
+		"If you don't deny this request from your account within " +
+		"{{.WaitDays}} day(s), they will be able to view all of the " +
+		"files and passwords in your vault.\n\n- YeetFile Support"))
+
+var emergencyDeniedSubject = "Your YeetFile emergency access request was denied"
+var emergencyDeniedBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\n{{.User}} has denied your request for emergency access to " +
+		"their YeetFile vault on {{.Domain}}.\n\n- YeetFile Support"))
+
+var emergencyGrantedSubject = "Your YeetFile emergency access request was granted"
+var emergencyGrantedBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nThe waiting period for your emergency access request has " +
+		"ended, and you now have access to the YeetFile vault of " +
+		"{{.User}} on {{.Domain}}.\n\n- YeetFile Support"))
+
+var emergencyReleasedSubject = "Emergency access to your YeetFile vault was granted"
+var emergencyReleasedBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nThe waiting period for the emergency access request made by " +
+		"{{.User}} has ended, and they now have access to your YeetFile " +
+		"vault on {{.Domain}}.\n\n" +
+		"If this is unexpected, remove them from your emergency contacts " +
+		"and rotate your account keys.\n\n- YeetFile Support"))
+
+// SendEmergencyContactEmail notifies a user that they were added as an
+// emergency contact by the owner of another account.
+func SendEmergencyContactEmail(to, owner string, waitDays int) error {
+	return sendEmergencyEmail(
+		to,
+		emergencyContactSubject,
+		emergencyContactBodyTemplate,
+		owner,
+		waitDays)
+}
+
+// SendEmergencyAccessRequestEmail notifies the owner of an account that one of
+// their emergency contacts has requested access to their vault.
+func SendEmergencyAccessRequestEmail(to, contact string, waitDays int) error {
+	return sendEmergencyEmail(
+		to,
+		emergencyRequestSubject,
+		emergencyRequestBodyTemplate,
+		contact,
+		waitDays)
+}
+
+// SendEmergencyAccessDeniedEmail notifies an emergency contact that their
+// request for access was denied by the owner.
+func SendEmergencyAccessDeniedEmail(to, owner string) error {
+	return sendEmergencyEmail(
+		to,
+		emergencyDeniedSubject,
+		emergencyDeniedBodyTemplate,
+		owner,
+		0)
+}
+
+// SendEmergencyAccessGrantedEmail notifies an emergency contact that the
+// waiting period for their request has ended.
+func SendEmergencyAccessGrantedEmail(to, owner string) error {
+	return sendEmergencyEmail(
+		to,
+		emergencyGrantedSubject,
+		emergencyGrantedBodyTemplate,
+		owner,
+		0)
+}
+
+// SendEmergencyAccessReleasedEmail notifies the owner of an account that one
+// of their emergency contacts now has access to their vault.
+func SendEmergencyAccessReleasedEmail(to, contact string) error {
+	return sendEmergencyEmail(
+		to,
+		emergencyReleasedSubject,
+		emergencyReleasedBodyTemplate,
+		contact,
+		0)
+}
+
+func sendEmergencyEmail(
+	to, subject string,
+	bodyTemplate *template.Template,
+	user string,
+	waitDays int,
+) error {
+	var buf bytes.Buffer
+
+	emergencyEmail := EmergencyEmail{
+		Domain:   smtpConfig.CallbackDomain,
+		User:     user,
+		WaitDays: waitDays,
+	}
+
+	err := bodyTemplate.Execute(&buf, emergencyEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, subject, body)
+	return nil
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+	"yeetfile/shared/endpoints"
+)
+
+type OrgInviteEmail struct {
+	OrgName  string
+	Domain   string
+	Endpoint string
+}
+
+var orgInviteSubject = "YeetFile Organization Invite"
+var orgInviteBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nYou have been invited to join the \"{{.OrgName}}\" " +
+		"organization on YeetFile ({{.Domain}}).\n\n" +
+		"Members of an organization share the organization's storage " +
+		"and send limits.\n\n" +
+		"To accept the invite, log in with this email address and " +
+		"visit your account page:\n\n" +
+		"{{.Domain}}{{.Endpoint}}"))
+
+// SendOrgInviteEmail notifies a user that they have been invited to join an
+// organization
+func SendOrgInviteEmail(orgName string, to string) error {
+	var buf bytes.Buffer
+
+	inviteEmail := OrgInviteEmail{
+		OrgName:  orgName,
+		Domain:   smtpConfig.CallbackDomain,
+		Endpoint: string(endpoints.HTMLAccount),
+	}
+
+	err := orgInviteBodyTemplate.Execute(&buf, inviteEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, orgInviteSubject, body)
+	return nil
+}
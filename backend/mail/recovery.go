@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+)
+
+type RecoveryEmail struct {
+	Domain string
+}
+
+var recoverySubject = "Your YeetFile password was reset"
+var recoveryBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nThe password for your YeetFile account on {{.Domain}} was " +
+		"just reset using your recovery key, and all of your existing " +
+		"sessions have been logged out.\n\n" +
+		"If you did not do this, your recovery key may have been " +
+		"compromised. Please reset your password again using your " +
+		"recovery key, then generate a new recovery key from your " +
+		"account page.\n\n- YeetFile Support"))
+
+// SendRecoveryEmail notifies a user that their password was reset using their
+// account recovery key.
+func SendRecoveryEmail(to string) error {
+	var buf bytes.Buffer
+
+	recoveryEmail := RecoveryEmail{
+		Domain: smtpConfig.CallbackDomain,
+	}
+
+	err := recoveryBodyTemplate.Execute(&buf, recoveryEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, recoverySubject, body)
+	return nil
+}
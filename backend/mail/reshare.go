@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+)
+
+type ReshareEmail struct {
+	Domain    string
+	Recipient string
+}
+
+var reshareSubject = "Please re-share your YeetFile items"
+var reshareBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\n{{.Recipient}} has replaced the encryption keys for their " +
+		"YeetFile account on {{.Domain}}. Some of the files or folders " +
+		"that you shared with them could no longer be unlocked with their " +
+		"new keys, and have been removed from their vault.\n\n" +
+		"If you would still like {{.Recipient}} to have access, please " +
+		"share these items with them again.\n\n- YeetFile Support"))
+
+// SendReshareEmail asks a user to share their items again with a recipient who
+// rotated their account keys and lost access to the items as a result.
+func SendReshareEmail(to, recipient string) error {
+	var buf bytes.Buffer
+
+	reshareEmail := ReshareEmail{
+		Domain:    smtpConfig.CallbackDomain,
+		Recipient: recipient,
+	}
+
+	err := reshareBodyTemplate.Execute(&buf, reshareEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, reshareSubject, body)
+	return nil
+}
@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+	"yeetfile/shared/endpoints"
+)
+
+type SignupApprovalEmail struct {
+	Domain   string
+	Account  string
+	Endpoint string
+}
+
+var pendingSignupSubject = "New YeetFile signup awaiting approval"
+var pendingSignupBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nA new account ({{.Account}}) has signed up on {{.Domain}} " +
+		"and is waiting for approval. You can approve or reject the " +
+		"signup from the admin page:\n\n" +
+		"{{.Domain}}{{.Endpoint}}\n\n- YeetFile Support"))
+
+var signupApprovedSubject = "YeetFile account approved"
+var signupApprovedBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nYour YeetFile account on {{.Domain}} has been approved by " +
+		"the instance administrator. You can now log in and use your " +
+		"account.\n\n- YeetFile Support"))
+
+var signupRejectedSubject = "YeetFile signup declined"
+var signupRejectedBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nYour request for a YeetFile account on {{.Domain}} has " +
+		"been declined by the instance administrator, and the account " +
+		"has been removed.\n\n- YeetFile Support"))
+
+// SendPendingSignupEmail notifies an admin that a new account is waiting for
+// approval.
+func SendPendingSignupEmail(to, account string) error {
+	return sendSignupApprovalEmail(
+		to,
+		pendingSignupSubject,
+		pendingSignupBodyTemplate,
+		account)
+}
+
+// SendSignupApprovedEmail notifies a user that their account has been approved.
+func SendSignupApprovedEmail(to string) error {
+	return sendSignupApprovalEmail(
+		to,
+		signupApprovedSubject,
+		signupApprovedBodyTemplate,
+		to)
+}
+
+// SendSignupRejectedEmail notifies a user that their signup was rejected.
+func SendSignupRejectedEmail(to string) error {
+	return sendSignupApprovalEmail(
+		to,
+		signupRejectedSubject,
+		signupRejectedBodyTemplate,
+		to)
+}
+
+func sendSignupApprovalEmail(
+	to, subject string,
+	bodyTemplate *template.Template,
+	account string,
+) error {
+	var buf bytes.Buffer
+
+	approvalEmail := SignupApprovalEmail{
+		Domain:   smtpConfig.CallbackDomain,
+		Account:  account,
+		Endpoint: string(endpoints.HTMLAdmin),
+	}
+
+	err := bodyTemplate.Execute(&buf, approvalEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, subject, body)
+	return nil
+}
@@ -0,0 +1,65 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+)
+
+type SuspensionEmail struct {
+	Domain string
+	Reason string
+}
+
+var suspensionSubject = "YeetFile account suspended"
+var suspensionBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nYour YeetFile account on {{.Domain}} has been suspended by " +
+		"the instance administrator. While suspended, you will not be " +
+		"able to log in, and any files you have shared or sent will be " +
+		"unavailable to others.\n\n" +
+		"{{if .Reason}}Reason: {{.Reason}}\n\n{{end}}" +
+		"If you believe this was a mistake, please contact the " +
+		"administrator of this YeetFile instance.\n\n- YeetFile Support"))
+
+var suspensionLiftedSubject = "YeetFile account reinstated"
+var suspensionLiftedBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nThe suspension on your YeetFile account on {{.Domain}} has " +
+		"been lifted. You can now log in and use your account as " +
+		"normal.\n\n- YeetFile Support"))
+
+// SendSuspensionEmail notifies a user that their account has been suspended.
+func SendSuspensionEmail(to, reason string) error {
+	var buf bytes.Buffer
+
+	suspensionEmail := SuspensionEmail{
+		Domain: smtpConfig.CallbackDomain,
+		Reason: reason,
+	}
+
+	err := suspensionBodyTemplate.Execute(&buf, suspensionEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, suspensionSubject, body)
+	return nil
+}
+
+// SendSuspensionLiftedEmail notifies a user that their account suspension has
+// been lifted.
+func SendSuspensionLiftedEmail(to string) error {
+	var buf bytes.Buffer
+
+	suspensionEmail := SuspensionEmail{
+		Domain: smtpConfig.CallbackDomain,
+	}
+
+	err := suspensionLiftedBodyTemplate.Execute(&buf, suspensionEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, suspensionLiftedSubject, body)
+	return nil
+}
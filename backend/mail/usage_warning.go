@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+)
+
+type UsageWarningEmail struct {
+	Domain   string
+	Warnings []string
+}
+
+var usageWarningSubject = "YeetFile usage warning"
+var usageWarningBodyTemplate = template.Must(template.New("").Parse(
+	"Hello,\n\nYour YeetFile account is approaching one or more of its " +
+		"usage limits:\n\n" +
+		"{{range .Warnings}}- {{.}}\n{{end}}\n" +
+		"You can review your current usage, remove files, or upgrade your " +
+		"account by logging in to {{.Domain}}.\n\n- YeetFile Support"))
+
+// SendUsageWarningEmail notifies a user that their account has crossed one or
+// more of the configured usage warning thresholds.
+func SendUsageWarningEmail(to string, warnings []string) error {
+	var buf bytes.Buffer
+
+	usageWarningEmail := UsageWarningEmail{
+		Domain:   smtpConfig.CallbackDomain,
+		Warnings: warnings,
+	}
+
+	err := usageWarningBodyTemplate.Execute(&buf, usageWarningEmail)
+	if err != nil {
+		return err
+	}
+
+	body := buf.String()
+	go sendEmail(to, usageWarningSubject, body)
+	return nil
+}
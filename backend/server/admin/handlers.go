@@ -3,14 +3,67 @@ package admin
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"yeetfile/backend/db"
+	"yeetfile/backend/server/auth"
+	"yeetfile/backend/server/invites"
+	"yeetfile/backend/server/payments"
+	"yeetfile/backend/server/payments/manual"
+	"yeetfile/backend/server/payments/vouchers"
+	"yeetfile/backend/server/upgrades"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
+// UsersHandler handles listing all users (GET) with optional search, sort,
+// and pagination query params, as well as performing bulk actions on a set
+// of users (POST).
+func UsersHandler(w http.ResponseWriter, req *http.Request, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		query := req.URL.Query()
+		page, err := strconv.Atoi(query.Get("page"))
+		if err != nil || page < 0 {
+			page = 0
+		}
+
+		users, err := listUsers(
+			query.Get("q"),
+			query.Get("sort"),
+			query.Get("order") != "asc",
+			page)
+		if err != nil {
+			log.Printf("Error listing users: %v\n", err)
+			http.Error(w, "Failed to list users", http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(users)
+	case http.MethodPost:
+		var action shared.AdminBulkUserAction
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&action)
+		if err != nil || len(action.IDs) == 0 {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		failed, err := bulkUserAction(action, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(shared.AdminBulkUserActionResponse{
+			Failed: failed,
+		})
+	}
+}
+
 func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 	segments := strings.Split(req.URL.Path, "/")
 	userID := segments[len(segments)-1]
@@ -22,7 +75,16 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 			return
 		}
 
-		err := deleteUser(userID)
+		user, err := getUserInfo(userID)
+		if err != nil {
+			http.Error(w, "No match found", http.StatusNotFound)
+			return
+		} else if !auth.CanManageUser(id, user.ID) {
+			http.Error(w, ProtectedUserErr.Error(), http.StatusForbidden)
+			return
+		}
+
+		err = deleteUser(user.ID)
 		if err != nil {
 			log.Printf("Error deleting user: %v\n", err)
 			http.Error(w, "Failed to delete user", http.StatusInternalServerError)
@@ -41,6 +103,16 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 			return
 		}
 
+		suspended, suspendedReason, err := db.GetUserSuspension(user.ID)
+		if err != nil {
+			log.Printf("Error fetching user suspension: %v\n", err)
+		}
+
+		inviteAllowance, err := invites.GetAllowance(user.ID)
+		if err != nil {
+			log.Printf("Error fetching user invite allowance: %v\n", err)
+		}
+
 		files := fetchAllFiles(userID)
 		userResponse := shared.AdminUserInfoResponse{
 			ID:               user.ID,
@@ -49,6 +121,9 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 			StorageAvailable: user.StorageAvailable,
 			SendUsed:         user.SendUsed,
 			SendAvailable:    user.SendAvailable,
+			InviteAllowance:  inviteAllowance,
+			Suspended:        suspended,
+			SuspendedReason:  suspendedReason,
 
 			Files: files,
 		}
@@ -57,7 +132,7 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 	case http.MethodPut:
 		var action shared.AdminUserAction
 		err := utils.LimitedJSONReader(w, req.Body).Decode(&action)
-		if err != nil {
+		if err != nil || action.InviteAllowance < 0 {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
@@ -66,6 +141,9 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 		if err != nil {
 			http.Error(w, "No match found", http.StatusNotFound)
 			return
+		} else if !auth.CanManageUser(id, user.ID) {
+			http.Error(w, ProtectedUserErr.Error(), http.StatusForbidden)
+			return
 		}
 
 		sendErr := db.OverrideUserSend(user.ID, action.SendAvailable)
@@ -74,6 +152,58 @@ func UserActionHandler(w http.ResponseWriter, req *http.Request, id string) {
 			http.Error(w, "Error updating user storage/send", http.StatusInternalServerError)
 			return
 		}
+
+		err = db.SetUserInviteAllowance(user.ID, action.InviteAllowance)
+		if err != nil {
+			log.Printf("Error updating user invite allowance: %v\n", err)
+			http.Error(w, "Error updating user invite allowance", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// SuspendUserHandler handles suspending (POST) or lifting a suspension
+// (DELETE) on a user's account.
+func SuspendUserHandler(w http.ResponseWriter, req *http.Request, id string) {
+	segments := strings.Split(req.URL.Path, "/")
+	userID := segments[len(segments)-1]
+
+	user, err := getUserInfo(userID)
+	if err != nil {
+		http.Error(w, "No match found", http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPost:
+		if user.ID == id {
+			http.Error(w, "Cannot suspend yourself", http.StatusBadRequest)
+			return
+		} else if !auth.CanManageUser(id, user.ID) {
+			http.Error(w, ProtectedUserErr.Error(), http.StatusForbidden)
+			return
+		}
+
+		var action shared.AdminSuspendAction
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&action)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = suspendUser(user, action.Reason)
+		if err != nil {
+			log.Printf("Error suspending user: %v\n", err)
+			http.Error(w, "Failed to suspend user", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		err = unsuspendUser(user)
+		if err != nil {
+			log.Printf("Error lifting user suspension: %v\n", err)
+			http.Error(w, "Failed to lift suspension", http.StatusInternalServerError)
+			return
+		}
 	}
 }
 
@@ -126,3 +256,384 @@ func InviteActionsHandler(w http.ResponseWriter, req *http.Request, _ string) {
 		}
 	}
 }
+
+// StatsHandler returns aggregated instance statistics for the past N days,
+// where N is provided by the "days" query param.
+func StatsHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	days, err := strconv.Atoi(req.URL.Query().Get("days"))
+	if err != nil || days <= 0 {
+		days = constants.DefaultAdminStatDays
+	}
+
+	stats, err := db.GetStats(min(days, constants.MaxAdminStatDays))
+	if err != nil {
+		log.Printf("Error fetching stats: %v\n", err)
+		http.Error(w, "Error fetching stats", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+// ReportsHandler returns the queue of reported Send files for admin review.
+func ReportsHandler(w http.ResponseWriter, _ *http.Request, _ string) {
+	reports, err := db.GetReports()
+	if err != nil {
+		log.Printf("Error fetching reports: %v\n", err)
+		http.Error(w, "Error fetching reports", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reports)
+}
+
+// ReportActionsHandler handles taking down a reported Send file (POST),
+// optionally suspending the uploader, or dismissing the reports (DELETE).
+func ReportActionsHandler(w http.ResponseWriter, req *http.Request, id string) {
+	segments := strings.Split(req.URL.Path, "/")
+	metadataID := segments[len(segments)-1]
+
+	switch req.Method {
+	case http.MethodPost:
+		var action shared.AdminReportAction
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&action)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = takeDownReportedFile(metadataID, action, id)
+		if errors.Is(err, ProtectedUserErr) || errors.Is(err, SuspendPermissionErr) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			log.Printf("Error taking down reported file: %v\n", err)
+			http.Error(w, "Error taking down file", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		err := db.DeleteReports(metadataID)
+		if err != nil {
+			log.Printf("Error dismissing reports: %v\n", err)
+			http.Error(w, "Error dismissing reports", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// InvoicesHandler returns the manual invoices that are awaiting payment.
+func InvoicesHandler(w http.ResponseWriter, _ *http.Request, _ string) {
+	invoices, err := getPendingInvoices()
+	if err != nil {
+		log.Printf("Error fetching pending invoices: %v\n", err)
+		http.Error(w, "Error fetching invoices", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(invoices)
+}
+
+// InvoiceActionHandler handles marking a manual invoice as paid (POST),
+// refunding an invoice (PUT), or canceling a pending invoice (DELETE).
+func InvoiceActionHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	segments := strings.Split(req.URL.Path, "/")
+	invoiceID := segments[len(segments)-1]
+
+	switch req.Method {
+	case http.MethodPost:
+		err := payments.Manual.MarkPaid(invoiceID)
+		if err == sql.ErrNoRows || errors.Is(err, manual.NotPendingErr) {
+			http.Error(w, "Invoice is not pending", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error marking invoice as paid: %v\n", err)
+			http.Error(w, "Error marking invoice as paid", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodPut:
+		var action shared.AdminRefundAction
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&action)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = refundInvoice(invoiceID, action.Amount)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Invoice not found", http.StatusNotFound)
+			return
+		} else if errors.Is(err, InvalidRefundErr) {
+			http.Error(w, "Invalid refund amount", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error refunding invoice: %v\n", err)
+			http.Error(w, "Error refunding invoice", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		err := db.DeletePendingInvoice(invoiceID)
+		if err != nil {
+			log.Printf("Error canceling invoice: %v\n", err)
+			http.Error(w, "Error canceling invoice", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// VouchersHandler handles listing all voucher codes (GET) and creating a new
+// voucher (POST).
+func VouchersHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	switch req.Method {
+	case http.MethodGet:
+		result, err := db.GetVouchers()
+		if err != nil {
+			log.Printf("Error fetching vouchers: %v\n", err)
+			http.Error(w, "Error fetching vouchers", http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(result)
+	case http.MethodPost:
+		var voucher shared.AdminVoucher
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&voucher)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		voucher, err = vouchers.Create(voucher)
+		if errors.Is(err, vouchers.InvalidOptionsErr) {
+			http.Error(w, "Invalid voucher options", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error creating voucher: %v\n", err)
+			http.Error(w, "Error creating voucher", http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(voucher)
+	}
+}
+
+// VoucherActionHandler handles deleting a voucher code (DELETE)
+func VoucherActionHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	segments := strings.Split(req.URL.Path, "/")
+	code := vouchers.NormalizeCode(segments[len(segments)-1])
+
+	err := db.DeleteVoucher(code)
+	if err != nil {
+		log.Printf("Error deleting voucher: %v\n", err)
+		http.Error(w, "Error deleting voucher", http.StatusInternalServerError)
+		return
+	}
+}
+
+// UpgradesHandler handles listing the upgrade catalog (GET) and adding or
+// updating an upgrade (POST).
+func UpgradesHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	switch req.Method {
+	case http.MethodGet:
+		_ = json.NewEncoder(w).Encode(upgrades.GetAllUpgrades())
+	case http.MethodPost:
+		var upgrade shared.Upgrade
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&upgrade)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = saveUpgrade(upgrade)
+		if errors.Is(err, InvalidUpgradeErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error saving upgrade: %v\n", err)
+			http.Error(w, "Error saving upgrade", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// UpgradeActionHandler handles removing an upgrade from the catalog (DELETE)
+func UpgradeActionHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	segments := strings.Split(req.URL.Path, "/")
+	tag := segments[len(segments)-1]
+
+	err := deleteUpgrade(tag)
+	if err != nil {
+		log.Printf("Error deleting upgrade: %v\n", err)
+		http.Error(w, "Error deleting upgrade", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RolesHandler handles listing admin roles and the role audit trail (GET) and
+// granting a role to a user (POST).
+func RolesHandler(w http.ResponseWriter, req *http.Request, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		response, err := getAdminRoles()
+		if err != nil {
+			log.Printf("Error fetching admin roles: %v\n", err)
+			http.Error(w, "Error fetching admin roles", http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(response)
+	case http.MethodPost:
+		var action shared.AdminRoleAction
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&action)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = grantRole(id, action)
+		if errors.Is(err, InvalidRoleErr) || errors.Is(err, SelfRoleChangeErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if errors.Is(err, RoleRankErr) || errors.Is(err, ProtectedUserErr) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Error granting admin role: %v\n", err)
+			http.Error(w, "Error granting admin role", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RoleActionHandler handles revoking a user's admin role (DELETE)
+func RoleActionHandler(w http.ResponseWriter, req *http.Request, id string) {
+	segments := strings.Split(req.URL.Path, "/")
+	userID := segments[len(segments)-1]
+
+	err := revokeRole(id, userID)
+	if errors.Is(err, SelfRoleChangeErr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if errors.Is(err, ProtectedUserErr) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Role not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error revoking admin role: %v\n", err)
+		http.Error(w, "Error revoking admin role", http.StatusInternalServerError)
+		return
+	}
+}
+
+// SignupsHandler handles listing every account waiting for approval (GET)
+func SignupsHandler(w http.ResponseWriter, _ *http.Request, _ string) {
+	signups, err := db.GetPendingUsers()
+	if err != nil {
+		log.Printf("Error fetching pending signups: %v\n", err)
+		http.Error(w, "Error fetching pending signups", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(signups)
+}
+
+// SignupActionHandler handles approving (POST) or rejecting (DELETE) an
+// account that is waiting for approval
+func SignupActionHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	segments := strings.Split(req.URL.Path, "/")
+	userID := segments[len(segments)-1]
+
+	var err error
+	switch req.Method {
+	case http.MethodPost:
+		err = approveSignup(userID)
+	case http.MethodDelete:
+		err = rejectSignup(userID)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "No match found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, NotPendingErr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error updating pending signup: %v\n", err)
+		http.Error(w, "Error updating pending signup", http.StatusInternalServerError)
+		return
+	}
+}
+
+// EmailDomainsHandler handles listing email domain rules (GET) and adding or
+// replacing a rule for a domain (POST)
+func EmailDomainsHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	switch req.Method {
+	case http.MethodGet:
+		rules, err := db.GetEmailDomainRules()
+		if err != nil {
+			log.Printf("Error fetching email domain rules: %v\n", err)
+			http.Error(w, "Error fetching email domain rules", http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(rules)
+	case http.MethodPost:
+		var rule shared.EmailDomainRule
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&rule)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = setDomainRule(rule)
+		if errors.Is(err, InvalidDomainRuleErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error saving email domain rule: %v\n", err)
+			http.Error(w, "Error saving email domain rule", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// EmailDomainActionHandler handles removing an email domain rule (DELETE)
+func EmailDomainActionHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	segments := strings.Split(req.URL.Path, "/")
+	domain := segments[len(segments)-1]
+
+	err := db.RemoveEmailDomainRule(domain)
+	if err != nil {
+		log.Printf("Error removing email domain rule: %v\n", err)
+		http.Error(w, "Error removing email domain rule", http.StatusInternalServerError)
+		return
+	}
+}
+
+// SecretsHandler handles fetching the number of stored values encrypted with
+// each server secret (GET) and re-encrypting all stored values with the current
+// server secret (POST).
+func SecretsHandler(w http.ResponseWriter, req *http.Request, _ string) {
+	var (
+		response shared.AdminServerSecretsResponse
+		err      error
+	)
+
+	switch req.Method {
+	case http.MethodGet:
+		response, err = getServerSecretUsage()
+	case http.MethodPost:
+		response, err = reEncryptServerSecrets()
+	}
+
+	if err != nil {
+		log.Printf("Error managing server secrets: %v\n", err)
+		http.Error(w, "Error managing server secrets", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
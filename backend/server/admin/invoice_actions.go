@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"errors"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/payments"
+	"yeetfile/backend/server/payments/billing"
+	"yeetfile/shared"
+)
+
+var InvalidRefundErr = errors.New("invalid refund amount")
+
+// getPendingInvoices returns all manual invoices that are awaiting payment
+func getPendingInvoices() ([]shared.AdminInvoice, error) {
+	invoices, err := db.GetInvoicesByStatus(db.InvoicePending)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []shared.AdminInvoice{}
+	for _, invoice := range invoices {
+		email, _ := db.GetUserEmailByPaymentID(invoice.PaymentID)
+		result = append(result, shared.AdminInvoice{
+			ID:       invoice.InvoiceID,
+			Email:    email,
+			Provider: invoice.Source,
+			Items:    billing.GetInvoiceItems(invoice),
+			Amount:   invoice.Amount,
+			Refunded: invoice.Refunded,
+			Status:   invoice.Status,
+			Date:     invoice.Date,
+		})
+	}
+
+	return result, nil
+}
+
+// refundInvoice returns part or all of an invoice's remaining balance to the
+// user using the provider that the invoice was paid with.
+func refundInvoice(invoiceID string, amount int64) error {
+	invoice, err := db.GetInvoice(invoiceID)
+	if err != nil {
+		return err
+	}
+
+	if amount <= 0 ||
+		amount > invoice.Amount-invoice.Refunded ||
+		invoice.Status == db.InvoicePending {
+		return InvalidRefundErr
+	}
+
+	provider, err := payments.GetProvider(invoice.Source)
+	if err != nil {
+		return err
+	}
+
+	return provider.Refund(invoice, amount)
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"errors"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/auth"
+	"yeetfile/backend/storage"
+	"yeetfile/shared"
+)
+
+// SuspendPermissionErr is returned when an admin without permission to manage
+// users attempts to suspend the uploader of a reported file
+var SuspendPermissionErr = errors.New("missing permission to suspend users")
+
+// takeDownReportedFile removes a reported Send file and clears its reports.
+// If requested, the uploader of the file is suspended as well.
+func takeDownReportedFile(
+	metadataID string,
+	action shared.AdminReportAction,
+	adminID string,
+) error {
+	fileInfo, err := db.AdminRetrieveSendMetadata(metadataID)
+	if err != nil {
+		return err
+	}
+
+	if action.SuspendUploader && len(fileInfo.OwnerID) > 0 {
+		role := auth.GetAdminRole(adminID)
+		if !auth.HasAdminPermission(role, auth.UserAdminPermission, false) {
+			return SuspendPermissionErr
+		} else if fileInfo.OwnerID == adminID {
+			return errors.New("cannot suspend yourself")
+		} else if !auth.CanManageUser(adminID, fileInfo.OwnerID) {
+			return ProtectedUserErr
+		}
+
+		user, err := db.GetUserByID(fileInfo.OwnerID)
+		if err != nil {
+			return err
+		}
+
+		err = suspendUser(user, action.Reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	metadata, err := db.RetrieveMetadata(metadataID)
+	if err != nil {
+		return err
+	}
+
+	storage.DeleteFileByMetadata(metadata)
+	return db.DeleteReports(metadataID)
+}
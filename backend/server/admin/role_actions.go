@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"errors"
+	"slices"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/auth"
+	"yeetfile/shared"
+)
+
+const adminAuditLogLimit = 100
+
+var (
+	InvalidRoleErr    = errors.New("invalid admin role")
+	SelfRoleChangeErr = errors.New("admins can't change their own role")
+	RoleRankErr       = errors.New("admins can't grant a role equal to or above their own")
+)
+
+// getAdminRoles returns every user with an admin role, along with the most
+// recent role changes
+func getAdminRoles() (shared.AdminRolesResponse, error) {
+	roles, err := db.GetAdminRoleList()
+	if err != nil {
+		return shared.AdminRolesResponse{}, err
+	}
+
+	audit, err := db.GetAdminAuditLog(adminAuditLogLimit)
+	if err != nil {
+		return shared.AdminRolesResponse{}, err
+	}
+
+	return shared.AdminRolesResponse{
+		Roles:          roles,
+		Audit:          audit,
+		AvailableRoles: db.AdminRoles,
+	}, nil
+}
+
+// grantRole grants an admin role to a user (by ID or email)
+func grantRole(actorID string, action shared.AdminRoleAction) error {
+	if !slices.Contains(db.AdminRoles, action.Role) {
+		return InvalidRoleErr
+	} else if !auth.CanGrantRole(actorID, action.Role) {
+		return RoleRankErr
+	}
+
+	user, err := getUserInfo(action.User)
+	if err != nil {
+		return err
+	} else if user.ID == actorID {
+		return SelfRoleChangeErr
+	} else if !auth.CanManageUser(actorID, user.ID) {
+		return ProtectedUserErr
+	}
+
+	return db.SetAdminRole(user.ID, action.Role, actorID)
+}
+
+// revokeRole removes a user's admin role
+func revokeRole(actorID, userID string) error {
+	if userID == actorID {
+		return SelfRoleChangeErr
+	} else if !auth.CanManageUser(actorID, userID) {
+		return ProtectedUserErr
+	}
+
+	return db.RemoveAdminRole(userID, actorID)
+}
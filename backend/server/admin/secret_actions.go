@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"bytes"
+	"yeetfile/backend/config"
+	"yeetfile/backend/crypto"
+	"yeetfile/backend/db"
+	"yeetfile/shared"
+)
+
+// getServerSecretUsage returns the number of stored values encrypted with each
+// server secret in the keyring
+func getServerSecretUsage() (shared.AdminServerSecretsResponse, error) {
+	values, err := db.GetServerEncryptedValues()
+	if err != nil {
+		return shared.AdminServerSecretsResponse{}, err
+	}
+
+	var response shared.AdminServerSecretsResponse
+	for i, secret := range config.YeetFileConfig.ServerSecrets {
+		response.Keys = append(response.Keys, shared.ServerSecretUsage{
+			KeyID:   crypto.FormatKeyID(crypto.KeyID(secret)),
+			Current: i == 0,
+		})
+	}
+
+	for _, value := range values {
+		keyID := crypto.ValueKeyID(value)
+		if keyID == nil {
+			response.Legacy += 1
+			continue
+		}
+
+		known := false
+		for i, secret := range config.YeetFileConfig.ServerSecrets {
+			if bytes.Equal(crypto.KeyID(secret), keyID) {
+				response.Keys[i].Values += 1
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			response.Unknown += 1
+		}
+	}
+
+	return response, nil
+}
+
+// reEncryptServerSecrets re-encrypts all stored values with the current server
+// secret and returns the updated usage of each secret
+func reEncryptServerSecrets() (shared.AdminServerSecretsResponse, error) {
+	reEncrypted, failed, err := db.ReEncryptServerEncryptedValues()
+	if err != nil {
+		return shared.AdminServerSecretsResponse{}, err
+	}
+
+	response, err := getServerSecretUsage()
+	if err != nil {
+		return shared.AdminServerSecretsResponse{}, err
+	}
+
+	response.ReEncrypted = reEncrypted
+	response.Failed = failed
+	return response, nil
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/shared"
+)
+
+var (
+	InvalidDomainRuleErr = errors.New("invalid email domain rule")
+	NotPendingErr        = errors.New("user is not pending approval")
+)
+
+// approveSignup approves a pending user's account and notifies them via email
+// (if the user has an email and email is configured for the instance).
+func approveSignup(userID string) error {
+	user, err := db.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = db.ApproveUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if config.YeetFileConfig.Email.Configured && len(user.Email) > 0 {
+		err = mail.SendSignupApprovedEmail(user.Email)
+		if err != nil {
+			log.Printf("Error sending signup approval email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// rejectSignup deletes a pending user's account and notifies them via email
+// (if the user has an email and email is configured for the instance).
+func rejectSignup(userID string) error {
+	user, err := db.GetUserByID(userID)
+	if err != nil {
+		return err
+	} else if !db.IsUserPending(user.ID) {
+		return NotPendingErr
+	}
+
+	err = deleteUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if config.YeetFileConfig.Email.Configured && len(user.Email) > 0 {
+		err = mail.SendSignupRejectedEmail(user.Email)
+		if err != nil {
+			log.Printf("Error sending signup rejection email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// setDomainRule validates and stores an email domain allow/deny rule
+func setDomainRule(rule shared.EmailDomainRule) error {
+	domain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(rule.Domain), "@"))
+	if len(domain) == 0 || strings.ContainsAny(domain, "@ /") {
+		return InvalidDomainRuleErr
+	} else if rule.Rule != db.EmailDomainAllow && rule.Rule != db.EmailDomainDeny {
+		return InvalidDomainRuleErr
+	}
+
+	return db.SetEmailDomainRule(domain, rule.Rule)
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/payments/stripe"
+	"yeetfile/backend/server/upgrades"
+	"yeetfile/shared"
+)
+
+var InvalidUpgradeErr = errors.New("invalid upgrade")
+
+// saveUpgrade validates and stores an upgrade in the catalog, then reloads
+// the catalog on this server. Other servers pick up the change the next time
+// their catalog is refreshed.
+func saveUpgrade(upgrade shared.Upgrade) error {
+	err := upgrades.ValidateUpgrade(upgrade)
+	if err != nil {
+		return fmt.Errorf("%w: %v", InvalidUpgradeErr, err)
+	}
+
+	if len(upgrade.StripeID) > 0 {
+		err = stripe.ValidateProduct(upgrade.StripeID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", InvalidUpgradeErr, err)
+		}
+	}
+
+	err = db.SaveUpgrade(upgrade)
+	if err != nil {
+		return err
+	}
+
+	db.RefreshUpgrades()
+	return nil
+}
+
+// deleteUpgrade removes an upgrade from the catalog and reloads the catalog
+// on this server.
+func deleteUpgrade(tag string) error {
+	err := db.DeleteUpgrade(tag)
+	if err != nil {
+		return err
+	}
+
+	db.RefreshUpgrades()
+	return nil
+}
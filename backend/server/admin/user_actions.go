@@ -1,17 +1,61 @@
 package admin
 
 import (
+	"errors"
 	"log"
 	"strings"
+	"yeetfile/backend/config"
 	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
 	"yeetfile/backend/server/auth"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
+// ProtectedUserErr is returned when an admin attempts to act on a user whose
+// admin role is equal to or higher than their own
+var ProtectedUserErr = errors.New("cannot act on a user with an equal or higher role")
+
 func deleteUser(userID string) error {
 	return auth.DeleteUser(userID, shared.DeleteAccount{Identifier: userID})
 }
 
+// suspendUser suspends the user's account and notifies them via email (if the
+// user has an email and email is configured for the instance).
+func suspendUser(user db.User, reason string) error {
+	err := db.SuspendUser(user.ID, reason)
+	if err != nil {
+		return err
+	}
+
+	if config.YeetFileConfig.Email.Configured && len(user.Email) > 0 {
+		err = mail.SendSuspensionEmail(user.Email, reason)
+		if err != nil {
+			log.Printf("Error sending suspension email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// unsuspendUser lifts a suspension on the user's account and notifies them
+// via email (if the user has an email and email is configured).
+func unsuspendUser(user db.User) error {
+	err := db.UnsuspendUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if config.YeetFileConfig.Email.Configured && len(user.Email) > 0 {
+		err = mail.SendSuspensionLiftedEmail(user.Email)
+		if err != nil {
+			log.Printf("Error sending suspension lifted email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
 func fetchAllFiles(userID string) []shared.AdminFileInfoResponse {
 	if strings.Contains(userID, "@") {
 		userID, _ = db.GetUserIDByEmail(userID)
@@ -50,3 +94,75 @@ func getUserInfo(userID string) (db.User, error) {
 
 	return user, nil
 }
+
+func listUsers(search, sortBy string, desc bool, page int) (shared.AdminUserListResponse, error) {
+	pageSize := constants.AdminUserListPageSize
+	users, total, err := db.AdminListUsers(
+		search,
+		sortBy,
+		desc,
+		pageSize,
+		page*pageSize)
+	if err != nil {
+		return shared.AdminUserListResponse{}, err
+	}
+
+	return shared.AdminUserListResponse{
+		Users:    users,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// bulkUserAction performs the requested action on every user in the action's
+// list of IDs, returning the IDs of any users that the action failed for.
+func bulkUserAction(action shared.AdminBulkUserAction, adminID string) ([]string, error) {
+	var actionFn func(userID string) error
+	switch action.Action {
+	case constants.AdminBulkUpdateQuota:
+		actionFn = func(userID string) error {
+			err := db.OverrideUserStorage(userID, action.StorageAvailable)
+			if err != nil {
+				return err
+			}
+
+			return db.OverrideUserSend(userID, action.SendAvailable)
+		}
+	case constants.AdminBulkDelete:
+		actionFn = func(userID string) error {
+			if userID == adminID {
+				return errors.New("cannot delete yourself")
+			}
+
+			return deleteUser(userID)
+		}
+	case constants.AdminBulkLogout:
+		actionFn = func(userID string) error {
+			// Clearing the session key invalidates all existing sessions
+			return db.SetUserSessionKey(userID, "")
+		}
+	case constants.AdminBulkDisable2FA:
+		actionFn = db.RemoveUser2FA
+	default:
+		return nil, errors.New("invalid action")
+	}
+
+	failed := []string{}
+	for _, userID := range action.IDs {
+		var err error
+		if !auth.CanManageUser(adminID, userID) {
+			err = ProtectedUserErr
+		} else {
+			err = actionFn(userID)
+		}
+
+		if err != nil {
+			log.Printf("Error performing '%s' on user %s: %v\n",
+				action.Action, userID, err)
+			failed = append(failed, userID)
+		}
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"database/sql"
+	"log"
+	"slices"
+	"yeetfile/backend/db"
+)
+
+type AdminPermission string
+
+const (
+	AnyAdminPermission     AdminPermission = ""
+	UserAdminPermission    AdminPermission = "users"
+	FileAdminPermission    AdminPermission = "files"
+	BillingAdminPermission AdminPermission = "billing"
+	StatsAdminPermission   AdminPermission = "stats"
+	RoleAdminPermission    AdminPermission = "roles"
+	SecretAdminPermission  AdminPermission = "secrets"
+)
+
+// rolePermissions maps each limited admin role to the parts of the admin
+// console it can use. Owners can use everything, and auditors can view (but
+// not modify) everything. Server secret management isn't available to any
+// limited role.
+var rolePermissions = map[string][]AdminPermission{
+	db.AdminRoleUserManager:   {UserAdminPermission, StatsAdminPermission},
+	db.AdminRoleFileModerator: {FileAdminPermission},
+	db.AdminRoleBilling:       {BillingAdminPermission, StatsAdminPermission},
+}
+
+// GetAdminRole returns the admin role of the user, or an empty string if the
+// user isn't an admin. The instance admin (YEETFILE_INSTANCE_ADMIN) is always
+// an owner.
+func GetAdminRole(userID string) string {
+	if IsInstanceAdmin(userID) {
+		return db.AdminRoleOwner
+	}
+
+	role, err := db.GetAdminRole(userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error fetching admin role: %v\n", err)
+		}
+
+		return ""
+	}
+
+	return role
+}
+
+// IsAdmin returns true if the user has any admin role
+func IsAdmin(userID string) bool {
+	return len(GetAdminRole(userID)) > 0
+}
+
+// HasAdminPermission returns true if the role is permitted to use a part of
+// the admin console. Read-only access is granted to auditors for every part.
+func HasAdminPermission(role string, permission AdminPermission, readOnly bool) bool {
+	switch role {
+	case "":
+		return false
+	case db.AdminRoleOwner:
+		return true
+	case db.AdminRoleAuditor:
+		return readOnly
+	}
+
+	if permission == AnyAdminPermission {
+		return true
+	}
+
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// adminRoleRank returns the rank of the user's admin role. Users without a
+// role have the lowest rank, followed by every limited role, then owners, and
+// finally the instance admin.
+func adminRoleRank(userID string) int {
+	if IsInstanceAdmin(userID) {
+		return 3
+	}
+
+	return roleRank(GetAdminRole(userID))
+}
+
+// roleRank returns the rank of an admin role, with an empty role (no admin
+// access) ranked lowest
+func roleRank(role string) int {
+	switch role {
+	case "":
+		return 0
+	case db.AdminRoleOwner:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// CanManageUser returns true if the actor's admin role outranks the target
+// user's role, so that admins can't act on users with an equal or higher role.
+func CanManageUser(actorID, targetID string) bool {
+	return adminRoleRank(actorID) > adminRoleRank(targetID)
+}
+
+// CanGrantRole returns true if the actor's admin role outranks the role being
+// granted, so that admins can't grant a role equal to or above their own.
+func CanGrantRole(actorID, role string) bool {
+	return adminRoleRank(actorID) > roleRank(role)
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"yeetfile/backend/config"
 	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/backend/server/invites"
 	"yeetfile/backend/server/transfer/vault"
 	"yeetfile/shared"
 )
@@ -66,6 +68,19 @@ func createNewUser(values db.VerifiedAccountValues) (string, error) {
 	var id string
 	var err error
 
+	// New accounts are held for admin approval when required, except for
+	// the instance admin's own account
+	pending := config.SignupApprovalRequired && !isInstanceAdminAccount(values)
+
+	// Invite links are only used up once the account is actually created,
+	// and are released if creating the account fails
+	if len(values.InviteLinkHash) > 0 {
+		err = invites.ClaimLink(values.InviteLinkHash)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	// Create new user
 	if len(values.AccountID) > 0 {
 		id = values.AccountID
@@ -74,6 +89,8 @@ func createNewUser(values db.VerifiedAccountValues) (string, error) {
 			PublicKey:           values.PublicKey,
 			ProtectedPrivateKey: values.ProtectedPrivateKey,
 			PasswordHash:        values.PasswordHash,
+			Pending:             pending,
+			KDF:                 values.KDF,
 		})
 	} else {
 		id, err = db.NewUser(db.User{
@@ -82,14 +99,36 @@ func createNewUser(values db.VerifiedAccountValues) (string, error) {
 			PublicKey:           values.PublicKey,
 			ProtectedPrivateKey: values.ProtectedPrivateKey,
 			PasswordHint:        values.PasswordHint,
+			Pending:             pending,
+			KDF:                 values.KDF,
 		})
 	}
 
 	if err != nil {
 		log.Printf("Error initializing new account: %v\n", err)
+		if len(values.InviteLinkHash) > 0 {
+			releaseErr := invites.ReleaseLink(values.InviteLinkHash)
+			if releaseErr != nil {
+				log.Printf("Error releasing invite link: %v\n", releaseErr)
+			}
+		}
+
 		return "", err
 	}
 
+	if len(values.RecoveryKeyHash) > 0 {
+		err = db.SetUserRecovery(
+			id,
+			values.RecoveryKeyHash,
+			values.RecoveryPublicKey,
+			values.RecoveryProtectedKey,
+			nil)
+		if err != nil {
+			log.Printf("Error setting up account recovery: %v\n", err)
+			return "", err
+		}
+	}
+
 	// Initialize user's root vault folder
 	err = db.NewRootFolder(id, values.ProtectedVaultFolderKey)
 	if err != nil {
@@ -104,21 +143,52 @@ func createNewUser(values db.VerifiedAccountValues) (string, error) {
 		return "", err
 	}
 
+	if pending {
+		notifyPendingSignup(id, values.Email)
+	}
+
 	return id, nil
 }
 
+// isInstanceAdminAccount returns true if the new account's ID or email matches
+// the configured instance admin
+func isInstanceAdminAccount(values db.VerifiedAccountValues) bool {
+	adminID := config.InstanceAdmin
+	if len(adminID) == 0 {
+		return false
+	} else if len(values.Email) > 0 && strings.EqualFold(adminID, values.Email) {
+		return true
+	}
+
+	return len(values.AccountID) > 0 && adminID == values.AccountID
+}
+
+// notifyPendingSignup emails the instance admin (if they have an email) about
+// a new account that is waiting for approval
+func notifyPendingSignup(id, email string) {
+	adminEmail := config.InstanceAdmin
+	if !config.YeetFileConfig.Email.Configured || !strings.Contains(adminEmail, "@") {
+		return
+	}
+
+	account := id
+	if len(email) > 0 {
+		account = email
+	}
+
+	err := mail.SendPendingSignupEmail(adminEmail, account)
+	if err != nil {
+		log.Printf("Error sending pending signup email: %v\n", err)
+	}
+}
+
 func updateUser(values db.VerifiedAccountValues) error {
-	err := db.UpdateUser(db.User{
+	return db.UpdateUser(db.User{
 		Email:               values.Email,
 		PasswordHash:        values.PasswordHash,
 		ProtectedPrivateKey: values.ProtectedPrivateKey,
+		KDF:                 values.KDF,
 	}, values.AccountID)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
 func IsInstanceAdmin(currentUserID string) bool {
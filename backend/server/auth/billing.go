@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/backend/server/payments/billing"
+	"yeetfile/shared"
+)
+
+var MissingEmailErr = errors.New("no email set for account")
+
+var providerNames = map[string]string{
+	"stripe":  "Stripe",
+	"btcpay":  "BTCPay",
+	"voucher": "Voucher",
+	"manual":  "Invoice",
+}
+
+// GetBillingHistory returns all purchases made using the user's current
+// payment ID, as well as purchases made for an organization that the user
+// manages. Purchases made before recycling the payment ID are excluded.
+func GetBillingHistory(userID string) ([]shared.BillingPurchase, error) {
+	paymentIDs, err := getBillingPaymentIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	purchases := []shared.BillingPurchase{}
+	for _, paymentID := range paymentIDs {
+		invoices, err := db.GetUserInvoices(paymentID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, invoice := range invoices {
+			purchases = append(purchases, getBillingPurchase(invoice))
+		}
+	}
+
+	sort.Slice(purchases, func(i, j int) bool {
+		return purchases[i].Date.After(purchases[j].Date)
+	})
+
+	return purchases, nil
+}
+
+// GetBillingPurchase returns a single purchase made using the user's current
+// payment ID, or the payment ID of an organization that the user manages.
+func GetBillingPurchase(userID, invoiceID string) (shared.BillingPurchase, error) {
+	paymentIDs, err := getBillingPaymentIDs(userID)
+	if err != nil {
+		return shared.BillingPurchase{}, err
+	}
+
+	for _, paymentID := range paymentIDs {
+		invoice, err := db.GetUserInvoice(paymentID, invoiceID)
+		if err == nil {
+			return getBillingPurchase(invoice), nil
+		} else if err != sql.ErrNoRows {
+			return shared.BillingPurchase{}, err
+		}
+	}
+
+	return shared.BillingPurchase{}, sql.ErrNoRows
+}
+
+// getBillingPaymentIDs returns the user's payment ID, followed by the payment
+// ID of the organization the user manages (if any).
+func getBillingPaymentIDs(userID string) ([]string, error) {
+	paymentID, err := db.GetPaymentIDByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentIDs := []string{paymentID}
+	orgPaymentID, err := db.GetOrgPaymentID(userID)
+	if err == nil {
+		paymentIDs = append(paymentIDs, orgPaymentID)
+	} else if !errors.Is(err, db.OrgNotFoundErr) {
+		return nil, err
+	}
+
+	return paymentIDs, nil
+}
+
+// EmailReceipt sends a plain-text receipt for a purchase to the user's email.
+func EmailReceipt(userID, invoiceID string) error {
+	email, err := db.GetUserEmailByID(userID)
+	if err != nil {
+		return err
+	} else if len(email) == 0 {
+		return MissingEmailErr
+	}
+
+	purchase, err := GetBillingPurchase(userID, invoiceID)
+	if err != nil {
+		return err
+	}
+
+	return mail.CreateOrderEmail(GenerateTextReceipt(purchase), email).Send()
+}
+
+// GenerateTextReceipt creates a plain-text summary of a purchase.
+func GenerateTextReceipt(purchase shared.BillingPurchase) string {
+	receipt := fmt.Sprintf(
+		"Receipt: %s\nDate: %s\nPayment: %s\n\n",
+		purchase.ID,
+		purchase.Date.Format(time.DateOnly),
+		purchase.Provider)
+
+	for _, item := range purchase.Items {
+		receipt += fmt.Sprintf("%s (x%d)\n", item.Name, item.Quantity)
+	}
+
+	receipt += fmt.Sprintf("\nTotal: %s", shared.FormatCents(purchase.Amount))
+	if purchase.Refunded > 0 {
+		receipt += fmt.Sprintf(
+			"\nRefunded: %s",
+			shared.FormatCents(purchase.Refunded))
+	}
+
+	if !purchase.Expiration.IsZero() {
+		receipt += fmt.Sprintf(
+			"\nVault upgrade expires: %s",
+			purchase.Expiration.Format(time.DateOnly))
+	}
+
+	return receipt
+}
+
+// getBillingPurchase converts an invoice into a purchase that can be shown to
+// the user.
+func getBillingPurchase(invoice db.Invoice) shared.BillingPurchase {
+	provider, ok := providerNames[invoice.Source]
+	if !ok {
+		provider = invoice.Source
+	}
+
+	return shared.BillingPurchase{
+		ID:         invoice.InvoiceID,
+		Provider:   provider,
+		Items:      billing.GetInvoiceItems(invoice),
+		Amount:     invoice.Amount,
+		Refunded:   invoice.Refunded,
+		Status:     invoice.Status,
+		Date:       invoice.Date,
+		Expiration: invoice.VaultExp,
+	}
+}
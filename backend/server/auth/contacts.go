@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"errors"
+	"yeetfile/backend/db"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var InvalidContactListErr = errors.New("invalid contact list")
+
+// SetContacts replaces the user's list of pinned contacts. The list is
+// encrypted by the client, so the server can only check its size. An empty
+// list removes the user's pinned contacts.
+func SetContacts(userID string, contacts shared.ContactList) error {
+	if len(contacts.ProtectedKey) == 0 && len(contacts.Contacts) == 0 {
+		return db.DeleteUserContacts(userID)
+	} else if len(contacts.ProtectedKey) == 0 ||
+		len(contacts.ProtectedKey) > constants.MaxProtectedKeySize ||
+		len(contacts.Contacts) == 0 ||
+		len(contacts.Contacts) > constants.MaxContactListSize {
+		return InvalidContactListErr
+	}
+
+	return db.SetUserContacts(userID, contacts)
+}
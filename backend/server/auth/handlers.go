@@ -1,19 +1,32 @@
 package auth
 
 import (
+	"database/sql"
 	"encoding/json"
 	"golang.org/x/crypto/bcrypt"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"yeetfile/backend/config"
 	"yeetfile/backend/crypto"
 	"yeetfile/backend/db"
 	"yeetfile/backend/mail"
+	"yeetfile/backend/server/invites"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
+	"yeetfile/shared/endpoints"
+)
+
+// SuspendedMsg is the error returned for requests made by suspended users
+const SuspendedMsg = "This account has been suspended"
+
+const (
+	pendingApprovalMsg = "This account is waiting for approval by an admin"
+	oidcRequiredMsg    = "This instance requires logging in with single sign-on"
+	oidcBindingCookie  = "oidc_binding"
 )
 
 // LoginHandler handles a POST request to /login to log the user in.
@@ -41,16 +54,53 @@ func LoginHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// The instance admin can always log in with a password, so that the
+	// instance can be recovered if the identity provider is unavailable
+	if config.YeetFileConfig.OIDC.Required && !IsInstanceAdmin(userID) {
+		http.Error(w, oidcRequiredMsg, http.StatusUnauthorized)
+		return
+	}
+
+	suspended, reason, err := db.GetUserSuspension(userID)
+	if err != nil {
+		log.Printf("Error checking user suspension: %v\n", err)
+		http.Error(w, "Error logging in", http.StatusInternalServerError)
+		return
+	} else if suspended {
+		msg := SuspendedMsg
+		if len(reason) > 0 {
+			msg += ": " + reason
+		}
+
+		http.Error(w, msg, http.StatusForbidden)
+		return
+	} else if db.IsUserPending(userID) {
+		http.Error(w, pendingApprovalMsg, http.StatusForbidden)
+		return
+	}
+
 	protectedKey, publicKey, err := db.GetUserKeys(userID)
 	if err != nil {
 		http.Error(w, "Error retrieving user keys", http.StatusInternalServerError)
 		return
 	}
 
+	keys, err := db.GetUserAdditionalKeys(userID)
+	if err != nil {
+		http.Error(w, "Error retrieving user keys", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.SetUserLastLogin(userID)
+	if err != nil {
+		log.Printf("Error updating user last login: %v\n", err)
+	}
+
 	_ = session.SetSession(userID, w, req)
 	_ = json.NewEncoder(w).Encode(shared.LoginResponse{
 		PublicKey:    publicKey,
 		ProtectedKey: protectedKey,
+		Keys:         keys,
 	})
 }
 
@@ -64,33 +114,21 @@ func SignupHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// Check if server has a password
-	if config.YeetFileConfig.PasswordHash != nil {
-		err := bcrypt.CompareHashAndPassword(
-			config.YeetFileConfig.PasswordHash,
-			[]byte(signupData.ServerPassword))
-		errMsg := "Missing or invalid server password"
-
-		if err != nil && config.InvitesAllowed && len(signupData.Identifier) > 0 {
-			// Check if the provided password is an invite code
-			codeHash, dbErr := db.GetInviteCodeHash(signupData.Identifier)
-			if dbErr == nil && len(codeHash) > 0 {
-				err = bcrypt.CompareHashAndPassword(
-					codeHash,
-					[]byte(signupData.ServerPassword))
-				errMsg = "Invalid invite code"
-			}
-		}
-
-		if err != nil {
-			w.WriteHeader(http.StatusForbidden)
-			_, _ = w.Write([]byte(errMsg))
-			return
-		} else if err != nil && config.InvitesAllowed {
+	// New accounts are provisioned on first OIDC login instead
+	if config.YeetFileConfig.OIDC.Required {
+		http.Error(w, oidcRequiredMsg, http.StatusBadRequest)
+		return
+	}
 
-		}
-	} else {
-		signupData.ServerPassword = "-"
+	// Check if the server password, an invite code, or an invite link was
+	// provided (if required)
+	inviteLinkHash, err := authorizeSignup(
+		signupData.Identifier,
+		signupData.ServerPassword)
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(err.Error()))
+		return
 	}
 
 	var response shared.SignupResponse
@@ -99,7 +137,7 @@ func SignupHandler(w http.ResponseWriter, req *http.Request) {
 	if len(signupData.Identifier) == 0 {
 		// No email, so this is an account ID only signup
 		isCLI := req.UserAgent() == constants.CLIUserAgent
-		id, captcha, err := SignupAccountIDOnly(isCLI)
+		id, captcha, err := SignupAccountIDOnly(isCLI, inviteLinkHash)
 		if err != nil {
 			status = http.StatusBadRequest
 			response = shared.SignupResponse{
@@ -119,12 +157,17 @@ func SignupHandler(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
-		err := SignupWithEmail(signupData)
+		err := SignupWithEmail(signupData, inviteLinkHash)
 		if err != nil && err != db.VerificationCodeExistsError {
 			log.Printf("Error creating (email) account: %v\n", err)
 			errMsg := "Error creating account"
 			if err == db.UserAlreadyExists {
 				errMsg = "User already exists"
+			} else if err == EmailDomainNotAllowed {
+				errMsg = "Signups from this email domain are not allowed"
+			} else if err == shared.InvalidKDFParamsErr ||
+				err == InvalidRecoveryKeyErr {
+				errMsg = err.Error()
 			}
 			status = http.StatusBadRequest
 			response = shared.SignupResponse{
@@ -179,6 +222,18 @@ func AccountHandler(w http.ResponseWriter, req *http.Request, id string) {
 			return
 		}
 
+		usage, err := db.GetUserUsage(id)
+		if err != nil {
+			log.Printf("Error fetching usage: %v\n", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		recovery, err := GetRecoveryStatus(id)
+		if err != nil {
+			log.Printf("Error fetching recovery status: %v\n", err)
+		}
+
 		obscuredEmail, _ := shared.ObscureEmail(user.Email)
 		_ = json.NewEncoder(w).Encode(shared.AccountResponse{
 			Email:            obscuredEmail,
@@ -190,6 +245,8 @@ func AccountHandler(w http.ResponseWriter, req *http.Request, id string) {
 			UpgradeExp:       user.UpgradeExp,
 			HasPasswordHint:  len(user.PasswordHint) > 0,
 			Has2FA:           len(user.Secret) > 0,
+			HasRecoveryKey:   recovery.Enabled,
+			UsageWarnings:    usage.UsageWarnings,
 		})
 	}
 }
@@ -233,7 +290,10 @@ func VerifyEmailHandler(w http.ResponseWriter, req *http.Request) {
 	var id string
 	if len(accountValues.AccountID) == 0 {
 		id, err = createNewUser(accountValues)
-		if err != nil {
+		if err == invites.InvalidLinkErr {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
 			http.Error(w, "Error creating account", http.StatusInternalServerError)
 			return
 		}
@@ -272,6 +332,12 @@ func VerifyEmailHandler(w http.ResponseWriter, req *http.Request) {
 
 	// Remove verification entry
 	_ = db.DeleteVerification(verifyEmail.Email)
+
+	if db.IsUserPending(id) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
 	_ = session.SetSession(id, w, req)
 }
 
@@ -290,8 +356,24 @@ func VerifyAccountHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	kdf, err := getAccountKDFParams(verify.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recovery, err := getSignupRecoveryKey(verify.RecoveryKey)
+	if err == InvalidRecoveryKeyErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error hashing recovery key: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
 	// Verify user verification code
-	_, err = db.VerifyUser(verify.ID, verify.Code)
+	accountValues, err := db.VerifyUser(verify.ID, verify.Code)
 	if err != nil {
 		log.Printf("Error verifying user: %v\n", err)
 		http.Error(w, "Incorrect verification code", http.StatusUnauthorized)
@@ -312,9 +394,17 @@ func VerifyAccountHandler(w http.ResponseWriter, req *http.Request) {
 		ProtectedPrivateKey:     verify.ProtectedPrivateKey,
 		PublicKey:               verify.PublicKey,
 		ProtectedVaultFolderKey: verify.ProtectedVaultFolderKey,
+		KDF:                     kdf,
+		RecoveryKeyHash:         recovery.KeyHash,
+		RecoveryPublicKey:       recovery.PublicKey,
+		RecoveryProtectedKey:    recovery.ProtectedKey,
+		InviteLinkHash:          accountValues.InviteLinkHash,
 	})
 
-	if err != nil {
+	if err == invites.InvalidLinkErr {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if err != nil {
 		log.Printf("Error creating user: %v\n", err)
 		http.Error(w, "Error creating account", http.StatusInternalServerError)
 		return
@@ -322,9 +412,29 @@ func VerifyAccountHandler(w http.ResponseWriter, req *http.Request) {
 
 	// Remove verification entry
 	_ = db.DeleteVerification(verify.ID)
+
+	if db.IsUserPending(verify.ID) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
 	_ = session.SetSession(verify.ID, w, req)
 }
 
+// KDFHandler returns the key derivation parameters for the account matching
+// the "identifier" query param, and the parameters recommended by the server.
+func KDFHandler(w http.ResponseWriter, req *http.Request) {
+	identifier := req.URL.Query().Get("identifier")
+	response, err := GetKDFParams(identifier)
+	if err != nil {
+		log.Printf("Error fetching KDF params: %v\n", err)
+		http.Error(w, "Error fetching KDF params", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
+
 // LogoutHandler handles a PUT request to /logout to log the user out of their
 // current session.
 func LogoutHandler(w http.ResponseWriter, req *http.Request) {
@@ -422,7 +532,17 @@ func PubKeyHandler(w http.ResponseWriter, req *http.Request, _ string) {
 		return
 	}
 
-	jsonData, _ := json.Marshal(shared.PubKeyResponse{PublicKey: pubKey})
+	keys, err := db.GetUserAdditionalPubKeys(userID)
+	if err != nil {
+		log.Printf("Error fetching additional pub keys: %v\n", err)
+		http.Error(w, "Error fetching public keys", http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, _ := json.Marshal(shared.PubKeyResponse{
+		PublicKey: pubKey,
+		Keys:      keys,
+	})
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(jsonData)
 }
@@ -438,13 +558,146 @@ func ProtectedKeyHandler(w http.ResponseWriter, _ *http.Request, id string) {
 		return
 	}
 
+	keys, err := db.GetUserAdditionalKeys(id)
+	if err != nil {
+		http.Error(w, "Error fetching protected key", http.StatusInternalServerError)
+		return
+	}
+
 	jsonData, _ := json.Marshal(shared.ProtectedKeyResponse{
 		ProtectedKey: protectedKey,
+		Keys:         keys,
 	})
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(jsonData)
 }
 
+// UserKeysHandler adds a new key pair to the user's account, using the
+// shared.UserKey request struct. The private key must already be encrypted
+// with the user's key.
+func UserKeysHandler(w http.ResponseWriter, req *http.Request, id string) {
+	var userKey shared.UserKey
+	if utils.LimitedJSONReader(w, req.Body).Decode(&userKey) != nil {
+		http.Error(w, "Unable to decode request", http.StatusBadRequest)
+		return
+	}
+
+	err := AddUserKey(id, userKey)
+	if err == UnsupportedKeyTypeErr ||
+		err == InvalidUserKeyErr ||
+		err == MissingRecoveryKeysErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err == UserKeyExistsErr {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		log.Printf("Error adding user key: %v\n", err)
+		http.Error(w, "Error adding key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RotateKeysHandler handles replacing a user's key pairs. GET requests return
+// every key that is wrapped with the user's current key pairs, which the
+// client re-wraps with its new key pairs and submits in a PUT request using
+// the shared.KeyRotation struct.
+func RotateKeysHandler(w http.ResponseWriter, req *http.Request, id string) {
+	if req.Method == http.MethodGet {
+		keys, err := db.GetUserWrappedKeys(id)
+		if err != nil {
+			http.Error(w, "Error fetching keys", http.StatusInternalServerError)
+			return
+		}
+
+		jsonData, _ := json.Marshal(shared.WrappedKeysResponse{Keys: keys})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(jsonData)
+		return
+	}
+
+	// Accounts with many shared items can exceed the usual JSON body limit
+	body, err := utils.LimitedChunkReader(w, req.Body)
+	if err != nil {
+		http.Error(w, "Unable to read request", http.StatusBadRequest)
+		return
+	}
+
+	var rotation shared.KeyRotation
+	if json.Unmarshal(body, &rotation) != nil {
+		http.Error(w, "Unable to decode request", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := ValidateCredentials(id, rotation.LoginKeyHash, "", false)
+	if err != nil || id != userID {
+		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+		return
+	}
+
+	removed, err := RotateKeys(id, rotation)
+	if err == InvalidRotationErr ||
+		err == MissingRecoveryKeysErr ||
+		err == UnsupportedKeyTypeErr ||
+		err == InvalidUserKeyErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err == StaleRotationErr {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		log.Printf("Error rotating user keys: %v\n", err)
+		http.Error(w, "Error rotating keys", http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, _ := json.Marshal(shared.KeyRotationResponse{Removed: removed})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(jsonData)
+}
+
+// ContactsHandler manages the user's pinned contacts, which are encrypted by
+// the client. GET requests return the shared.ContactList, and PUT requests
+// replace it.
+func ContactsHandler(w http.ResponseWriter, req *http.Request, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		contacts, err := db.GetUserContacts(id)
+		if err != nil {
+			log.Printf("Error fetching user contacts: %v\n", err)
+			http.Error(w, "Error fetching contacts", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(contacts)
+	case http.MethodPut:
+		body, err := utils.LimitedChunkReader(w, req.Body)
+		if err != nil {
+			http.Error(w, "Unable to read request", http.StatusBadRequest)
+			return
+		}
+
+		var contacts shared.ContactList
+		if json.Unmarshal(body, &contacts) != nil {
+			http.Error(w, "Unable to decode request", http.StatusBadRequest)
+			return
+		}
+
+		err = SetContacts(id, contacts)
+		if err == InvalidContactListErr {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error updating user contacts: %v\n", err)
+			http.Error(w, "Error updating contacts", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
 // ChangeEmailHandler validates the user's old login information, and uses the
 // ChangeEmail request struct to send a verification email to their new email
 // in preparation for updating their login key hash, encrypted protected key, etc
@@ -535,6 +788,22 @@ func finishEmailChangeHandler(w http.ResponseWriter, req *http.Request, id strin
 		return
 	}
 
+	kdf, err := getAccountKDFParams(changeEmail.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateProtectedKeys(id, changeEmail.ProtectedKeys)
+	if err == MissingUserKeysErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error validating protected keys: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
 	bcryptHash, err := bcrypt.GenerateFromPassword(changeEmail.NewLoginKeyHash, 8)
 	if err != nil {
 		log.Printf("Error generating bcrypt hash: %v\n", err)
@@ -545,13 +814,21 @@ func finishEmailChangeHandler(w http.ResponseWriter, req *http.Request, id strin
 	code, err := db.NewVerification(shared.Signup{
 		Identifier:          changeEmail.NewEmail,
 		ProtectedPrivateKey: changeEmail.ProtectedKey,
-	}, bcryptHash, userID)
+		KDF:                 kdf,
+	}, bcryptHash, userID, nil)
 	if err != nil {
 		log.Printf("Error creating email verification entry: %v\n", err)
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
+	err = db.SetUserPendingProtectedKeys(userID, changeEmail.ProtectedKeys)
+	if err != nil {
+		log.Printf("Error storing pending protected keys: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
 	err = mail.SendVerificationEmail(code, changeEmail.NewEmail)
 	if err != nil {
 		log.Printf("Error sending verification email: %v\n", err)
@@ -578,17 +855,137 @@ func ChangePasswordHandler(w http.ResponseWriter, req *http.Request, id string)
 		return
 	}
 
-	bcryptHash, err := bcrypt.GenerateFromPassword(
-		changePassword.NewLoginKeyHash, 8)
+	kdf, err := getAccountKDFParams(changePassword.KDF)
 	if err != nil {
-		log.Printf("Error generating bcrypt hash: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = validateProtectedKeys(id, changePassword.ProtectedKeys)
+	if err == MissingUserKeysErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error validating protected keys: %v\n", err)
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
-	err = db.UpdateUserLogin(id, bcryptHash, changePassword.ProtectedKey)
+	err = updateUserLogin(
+		id,
+		changePassword.NewLoginKeyHash,
+		changePassword.ProtectedKey,
+		changePassword.ProtectedKeys,
+		kdf)
 	if err != nil {
-		log.Printf("Error updating user login credentials: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RecoveryHandler manages the user's account recovery key. GET requests return
+// the status of the user's recovery key, PUT requests set up a new recovery key
+// using the shared.SetRecoveryKey struct, and DELETE requests remove it.
+func RecoveryHandler(w http.ResponseWriter, req *http.Request, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		status, err := GetRecoveryStatus(id)
+		if err != nil {
+			log.Printf("Error fetching recovery status: %v\n", err)
+			http.Error(w, "Error fetching recovery status", http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(status)
+	case http.MethodPut:
+		var setRecovery shared.SetRecoveryKey
+		if utils.LimitedJSONReader(w, req.Body).Decode(&setRecovery) != nil {
+			http.Error(w, "Unable to decode request", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := ValidateCredentials(id, setRecovery.LoginKeyHash, "", false)
+		if err != nil || id != userID {
+			http.Error(w, "Incorrect password", http.StatusUnauthorized)
+			return
+		}
+
+		err = SetRecoveryKey(id, setRecovery.RecoveryKey)
+		if err == InvalidRecoveryKeyErr || err == MissingRecoveryKeysErr {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error setting recovery key: %v\n", err)
+			http.Error(w, "Error setting recovery key", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		var removeRecovery shared.RemoveRecoveryKey
+		if utils.LimitedJSONReader(w, req.Body).Decode(&removeRecovery) != nil {
+			http.Error(w, "Unable to decode request", http.StatusBadRequest)
+			return
+		}
+
+		userID, err := ValidateCredentials(id, removeRecovery.LoginKeyHash, "", false)
+		if err != nil || id != userID {
+			http.Error(w, "Incorrect password", http.StatusUnauthorized)
+			return
+		}
+
+		err = db.RemoveUserRecovery(id)
+		if err != nil {
+			http.Error(w, "Error removing recovery key", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RecoveryStartHandler handles the first step of recovering an account with a
+// recovery key, returning the user's private keys encrypted with the recovery
+// key in exchange for a shared.RecoveryKeysRequest.
+func RecoveryStartHandler(w http.ResponseWriter, req *http.Request) {
+	var request shared.RecoveryKeysRequest
+	if utils.LimitedJSONReader(w, req.Body).Decode(&request) != nil {
+		http.Error(w, "Unable to decode request", http.StatusBadRequest)
+		return
+	}
+
+	response, err := GetRecoveryKeys(request)
+	if err == RecoveryFailedErr {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		log.Printf("Error fetching recovery keys: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(jsonData)
+}
+
+// RecoveryFinishHandler handles the final step of recovering an account with a
+// recovery key, replacing the user's password using the shared.RecoverAccount
+// struct.
+func RecoveryFinishHandler(w http.ResponseWriter, req *http.Request) {
+	var recover shared.RecoverAccount
+	if utils.LimitedJSONReader(w, req.Body).Decode(&recover) != nil {
+		http.Error(w, "Unable to decode request", http.StatusBadRequest)
+		return
+	}
+
+	err := RecoverAccount(recover)
+	if err == RecoveryFailedErr {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	} else if err == InvalidRecoveryErr ||
+		err == MissingUserKeysErr ||
+		err == shared.InvalidKDFParamsErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error recovering account: %v\n", err)
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
@@ -695,3 +1092,329 @@ func RecyclePaymentIDHandler(w http.ResponseWriter, _ *http.Request, userID stri
 		return
 	}
 }
+
+// BillingHandler handles fetching the user's past purchases
+func BillingHandler(w http.ResponseWriter, _ *http.Request, userID string) {
+	purchases, err := GetBillingHistory(userID)
+	if err != nil {
+		log.Printf("Error fetching billing history: %v\n", err)
+		http.Error(w, "Error fetching billing history", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(shared.BillingHistoryResponse{
+		Purchases: purchases,
+	})
+}
+
+// ReceiptHandler handles POST requests to email the receipt for one of the
+// user's purchases to their account email
+func ReceiptHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	invoiceID := segments[len(segments)-1]
+
+	err := EmailReceipt(userID, invoiceID)
+	if err == MissingEmailErr {
+		http.Error(w, "No email set for this account", http.StatusBadRequest)
+		return
+	} else if err == sql.ErrNoRows {
+		http.Error(w, "Purchase not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error emailing receipt: %v\n", err)
+		http.Error(w, "Error sending receipt", http.StatusInternalServerError)
+		return
+	}
+}
+
+// OIDCStartHandler starts a new OpenID Connect login, returning the identity
+// provider URL that the user needs to visit.
+func OIDCStartHandler(w http.ResponseWriter, req *http.Request) {
+	isCLI := req.UserAgent() == constants.CLIUserAgent
+	start, binding, err := StartOIDCLogin(isCLI)
+	if err == OIDCDisabledErr {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error starting OIDC login: %v\n", err)
+		http.Error(w, "Error starting login", http.StatusInternalServerError)
+		return
+	}
+
+	if len(binding) > 0 {
+		setOIDCBindingCookie(w, req, binding)
+	}
+
+	_ = json.NewEncoder(w).Encode(start)
+}
+
+// OIDCCallbackHandler handles the identity provider redirecting the user back
+// to YeetFile after logging in, and sends them on to the page that finishes
+// the login.
+func OIDCCallbackHandler(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	id, cliBinding, err := FinishOIDCCallback(
+		query.Get("state"),
+		query.Get("code"),
+		query.Get("error"),
+		getOIDCBindingCookie(req))
+	if err == OIDCInvalidRequestErr || err == OIDCBindingErr {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error handling OIDC callback: %v\n", err)
+		http.Error(w, "Error logging in", http.StatusInternalServerError)
+		return
+	}
+
+	if len(cliBinding) > 0 {
+		setOIDCBindingCookie(w, req, cliBinding)
+	}
+
+	redirect := string(endpoints.HTMLOIDC) + "?id=" + url.QueryEscape(id)
+	http.Redirect(w, req, redirect, http.StatusSeeOther)
+}
+
+// OIDCConfirmHandler confirms a CLI login using the code shown in the CLI,
+// which must be submitted from the browser that the user logged in with.
+func OIDCConfirmHandler(w http.ResponseWriter, req *http.Request) {
+	var confirm shared.OIDCConfirm
+	if utils.LimitedJSONReader(w, req.Body).Decode(&confirm) != nil {
+		http.Error(w, "Unable to parse request", http.StatusBadRequest)
+		return
+	}
+
+	err := ConfirmOIDCLogin(confirm, getOIDCBindingCookie(req))
+	if err != nil {
+		writeOIDCError(w, err, "confirming login")
+	}
+}
+
+// OIDCStatusHandler returns the current status of an OIDC login. Once the
+// user has logged in with a linked identity (and provided their TOTP code, if
+// they have 2FA enabled), this also creates the user's session and returns
+// their keys.
+func OIDCStatusHandler(w http.ResponseWriter, req *http.Request) {
+	var statusReq shared.OIDCStatusRequest
+	if utils.LimitedJSONReader(w, req.Body).Decode(&statusReq) != nil {
+		http.Error(w, "Unable to parse request", http.StatusBadRequest)
+		return
+	}
+
+	request, err := GetOIDCRequest(statusReq.ID, statusReq.Token)
+	if err == OIDCInvalidRequestErr {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error fetching OIDC request: %v\n", err)
+		http.Error(w, "Error checking login status", http.StatusInternalServerError)
+		return
+	}
+
+	response := shared.OIDCStatusResponse{
+		Status:     request.Status,
+		Identifier: request.Identifier,
+		Error:      request.Error,
+	}
+
+	switch request.Status {
+	case constants.OIDCStatusError:
+		CompleteOIDCRequest(request.ID)
+	case constants.OIDCStatusLink, constants.OIDCStatusSignup:
+		kdf, err := GetKDFParams(request.Identifier)
+		if err != nil {
+			log.Printf("Error fetching user KDF params: %v\n", err)
+			http.Error(w, "Error checking login status", http.StatusInternalServerError)
+			return
+		}
+
+		response.KDF = kdf.KDF
+		response.RecommendedKDF = kdf.RecommendedKDF
+		if request.Status == constants.OIDCStatusLink {
+			response.TwoFactorRequired = hasOIDC2FA(request.UserID)
+		} else {
+			response.ServerPasswordRequired = config.YeetFileConfig.PasswordHash != nil
+		}
+	case constants.OIDCStatusLogin:
+		err = validateOIDC2FA(request.UserID, statusReq.Code)
+		if err == Missing2FAErr {
+			response.TwoFactorRequired = true
+			break
+		} else if err != nil {
+			// The status endpoint isn't rate limited, so the login
+			// can't be retried with a different code
+			CompleteOIDCRequest(request.ID)
+			log.Printf("Error validating OIDC login TOTP: %v\n", err)
+			http.Error(w, "TOTP incorrect", http.StatusForbidden)
+			return
+		}
+
+		CompleteOIDCRequest(request.ID)
+		if !writeOIDCLogin(w, req, request.UserID, &response) {
+			return
+		}
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// OIDCLinkHandler links an OIDC identity to an existing account, once the user
+// has provided their current password, and logs them in.
+func OIDCLinkHandler(w http.ResponseWriter, req *http.Request) {
+	var link shared.OIDCLink
+	if utils.LimitedJSONReader(w, req.Body).Decode(&link) != nil {
+		http.Error(w, "Unable to parse request", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := LinkOIDCAccount(link)
+	if err != nil {
+		writeOIDCError(w, err, "linking account")
+		return
+	}
+
+	response := shared.OIDCStatusResponse{Status: constants.OIDCStatusLogin}
+	if writeOIDCLogin(w, req, userID, &response) {
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
+
+// OIDCSignupHandler creates a new account for an OIDC identity that hasn't
+// been linked to an account yet
+func OIDCSignupHandler(w http.ResponseWriter, req *http.Request) {
+	var signup shared.OIDCSignup
+	if utils.LimitedJSONReader(w, req.Body).Decode(&signup) != nil {
+		http.Error(w, "Unable to parse request", http.StatusBadRequest)
+		return
+	} else if utils.IsAnyByteSliceMissing(
+		signup.LoginKeyHash,
+		signup.PublicKey,
+		signup.ProtectedPrivateKey,
+		signup.ProtectedVaultFolderKey) {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := SignupOIDCAccount(signup)
+	if err != nil {
+		writeOIDCError(w, err, "creating account")
+		return
+	}
+
+	if db.IsUserPending(userID) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	_ = session.SetSession(userID, w, req)
+}
+
+// writeOIDCLogin checks that the user is allowed to log in, and if so, sets
+// the user's session and adds their keys to the response. Returns false if an
+// error response was written instead.
+func writeOIDCLogin(
+	w http.ResponseWriter,
+	req *http.Request,
+	userID string,
+	response *shared.OIDCStatusResponse,
+) bool {
+	suspended, reason, err := db.GetUserSuspension(userID)
+	if err != nil {
+		log.Printf("Error checking user suspension: %v\n", err)
+		http.Error(w, "Error logging in", http.StatusInternalServerError)
+		return false
+	} else if suspended {
+		msg := SuspendedMsg
+		if len(reason) > 0 {
+			msg += ": " + reason
+		}
+
+		http.Error(w, msg, http.StatusForbidden)
+		return false
+	} else if db.IsUserPending(userID) {
+		http.Error(w, pendingApprovalMsg, http.StatusForbidden)
+		return false
+	}
+
+	protectedKey, publicKey, err := db.GetUserKeys(userID)
+	if err != nil {
+		http.Error(w, "Error retrieving user keys", http.StatusInternalServerError)
+		return false
+	}
+
+	keys, err := db.GetUserAdditionalKeys(userID)
+	if err != nil {
+		http.Error(w, "Error retrieving user keys", http.StatusInternalServerError)
+		return false
+	}
+
+	kdf, err := GetKDFParams(userID)
+	if err != nil {
+		log.Printf("Error fetching user KDF params: %v\n", err)
+		http.Error(w, "Error retrieving user keys", http.StatusInternalServerError)
+		return false
+	}
+
+	err = db.SetUserLastLogin(userID)
+	if err != nil {
+		log.Printf("Error updating user last login: %v\n", err)
+	}
+
+	_ = session.SetSession(userID, w, req)
+	response.KDF = kdf.KDF
+	response.RecommendedKDF = kdf.RecommendedKDF
+	response.PublicKey = publicKey
+	response.ProtectedKey = protectedKey
+	response.Keys = keys
+	return true
+}
+
+// setOIDCBindingCookie stores the secret that binds an OIDC login to the
+// browser. Unlike the session cookie, this uses SameSite=Lax so that it's
+// included in the identity provider's redirect back to YeetFile.
+func setOIDCBindingCookie(w http.ResponseWriter, req *http.Request, binding string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcBindingCookie,
+		Value:    binding,
+		Path:     "/api/oidc",
+		MaxAge:   int(oidcRequestLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   req.TLS != nil || strings.HasPrefix(config.YeetFileConfig.Domain, "https"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// getOIDCBindingCookie returns the OIDC binding secret stored in the browser
+func getOIDCBindingCookie(req *http.Request) string {
+	cookie, err := req.Cookie(oidcBindingCookie)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// writeOIDCError maps OIDC errors to the appropriate response status
+func writeOIDCError(w http.ResponseWriter, err error, action string) {
+	switch err {
+	case OIDCInvalidRequestErr:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case OIDCWrongStatusErr:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case OIDCWrongAccountErr:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case OIDCBindingErr, OIDCConfirmCodeErr:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case Missing2FAErr:
+		http.Error(w, "TOTP required", http.StatusForbidden)
+	case Failed2FAErr:
+		http.Error(w, "TOTP incorrect", http.StatusForbidden)
+	case InvalidServerPassword, InvalidInviteCode:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case db.UserAlreadyExists, db.UserLimitReached, shared.InvalidKDFParamsErr:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error %s: %v\n", action, err)
+		http.Error(w, "Error "+action, http.StatusInternalServerError)
+	}
+}
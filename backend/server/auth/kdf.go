@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"sync"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/shared"
+)
+
+const fakeKDFInfo = "yeetfile-fake-kdf-params"
+
+var (
+	fakeKDFKey  []byte
+	fakeKDFLock sync.Mutex
+)
+
+// GetKDFParams returns the key derivation parameters that a client should use
+// to derive the user key for an account, along with the server's recommended
+// parameters. Identifiers that don't belong to an account receive fake
+// parameters, so that the response can't be used to tell whether an account
+// exists. New accounts always use the recommended parameters.
+func GetKDFParams(identifier string) (shared.KDFResponse, error) {
+	recommended := config.YeetFileConfig.KDF
+	response := shared.KDFResponse{
+		KDF:            recommended,
+		RecommendedKDF: recommended,
+	}
+
+	if len(identifier) == 0 {
+		return response, nil
+	}
+
+	kdf, err := db.GetUserKDFParams(identifier)
+	if err == sql.ErrNoRows {
+		response.KDF, err = fakeKDFParams(identifier)
+		if err != nil {
+			return shared.KDFResponse{}, err
+		}
+
+		return response, nil
+	} else if err != nil {
+		return shared.KDFResponse{}, err
+	}
+
+	response.KDF = kdf
+	return response, nil
+}
+
+// fakeKDFParams returns the parameters given out for an identifier that
+// doesn't belong to an account. The parameters are chosen from the ones that
+// real accounts use, using a MAC of the identifier keyed with a dedicated key
+// that never rotates, so that repeated requests for the same identifier always
+// receive the same response.
+func fakeKDFParams(identifier string) (shared.KDFParams, error) {
+	candidates := []shared.KDFParams{
+		shared.LegacyKDFParams(),
+		config.YeetFileConfig.KDF,
+	}
+
+	key, err := getFakeKDFKey()
+	if err != nil {
+		return shared.KDFParams{}, err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(identifier))
+	return candidates[int(mac.Sum(nil)[0])%len(candidates)], nil
+}
+
+// getFakeKDFKey returns the MAC key used for choosing fake KDF parameters,
+// which is derived from the stored fake KDF key on first use.
+func getFakeKDFKey() ([]byte, error) {
+	fakeKDFLock.Lock()
+	defer fakeKDFLock.Unlock()
+
+	if fakeKDFKey != nil {
+		return fakeKDFKey, nil
+	}
+
+	secret, err := db.GetFakeKDFKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	reader := hkdf.New(sha256.New, secret, nil, []byte(fakeKDFInfo))
+	_, err = io.ReadFull(reader, key)
+	if err != nil {
+		return nil, err
+	}
+
+	fakeKDFKey = key
+	return fakeKDFKey, nil
+}
+
+// getAccountKDFParams validates the key derivation parameters that a client
+// used to derive a user key. Older clients don't send any parameters, in which
+// case the original parameters are assumed.
+func getAccountKDFParams(kdf shared.KDFParams) (shared.KDFParams, error) {
+	kdf = kdf.OrDefault(shared.LegacyKDFParams())
+	return kdf, kdf.Validate()
+}
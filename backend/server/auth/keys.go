@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var (
+	UnsupportedKeyTypeErr = errors.New("unsupported key type")
+	InvalidUserKeyErr     = errors.New("invalid public or protected key")
+	UserKeyExistsErr      = errors.New("a key of this type already exists")
+	MissingUserKeysErr    = errors.New("all of the account's keys must be " +
+		"re-encrypted, please update your client")
+	InvalidRotationErr = errors.New("invalid key rotation")
+	StaleRotationErr   = errors.New("your vault has changed since the key " +
+		"rotation started, please try again")
+)
+
+// AddUserKey validates and stores a new key pair for the user. Each account
+// holds at most one key pair of each type, in addition to its RSA key pair.
+// If the user has a recovery key, the key pair must include a copy of the
+// private key encrypted with the recovery key.
+func AddUserKey(userID string, key shared.UserKey) error {
+	err := validateUserKey(key)
+	if err != nil {
+		return err
+	}
+
+	recoveryPublicKey, err := db.GetUserRecoveryPublicKey(userID)
+	if err != nil {
+		return err
+	} else if len(recoveryPublicKey) > 0 && len(key.RecoveryProtectedKey) == 0 {
+		return MissingRecoveryKeysErr
+	} else if len(recoveryPublicKey) == 0 {
+		key.RecoveryProtectedKey = nil
+	}
+
+	exists, err := db.UserHasKeyType(userID, key.Type)
+	if err != nil {
+		return err
+	} else if exists {
+		return UserKeyExistsErr
+	}
+
+	return db.AddUserKey(userID, key)
+}
+
+// validateProtectedKeys ensures that a password or email change includes a
+// newly encrypted private key for each of the user's additional key pairs.
+// Otherwise, clients that don't know about the additional keys would leave
+// them encrypted with a key the user can no longer derive.
+func validateProtectedKeys(userID string, protectedKeys []shared.UserKey) error {
+	keys, err := db.GetUserAdditionalKeys(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		found := false
+		for _, protectedKey := range protectedKeys {
+			if protectedKey.Type == key.Type && len(protectedKey.ProtectedKey) > 0 {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return MissingUserKeysErr
+		}
+	}
+
+	return nil
+}
+
+// RotateKeys replaces the user's key pairs with the new ones in the rotation,
+// along with every key that was wrapped with the old key pairs. Any users that
+// had shared an item the user could no longer read are sent an email asking
+// them to share it again. Returns the number of shared items that were removed.
+func RotateKeys(userID string, rotation shared.KeyRotation) (int, error) {
+	// The rotation is validated within the same transaction that applies
+	// it, so that keys added or removed in the meantime can't be skipped
+	sharerIDs, err := db.RotateUserKeys(
+		userID,
+		rotation,
+		func(current []shared.WrappedKey) error {
+			return validateKeyRotation(userID, current, rotation)
+		})
+	if err != nil {
+		return 0, err
+	}
+
+	notifySharers(userID, sharerIDs)
+	return len(rotation.RemovedKeys), nil
+}
+
+// validateKeyRotation checks that a rotation includes the user's new key
+// pairs, and accounts for each of the user's currently wrapped keys exactly
+// once. Only shared items can be removed, since the user's root folder key
+// can't be recovered by anyone else.
+func validateKeyRotation(
+	userID string,
+	current []shared.WrappedKey,
+	rotation shared.KeyRotation,
+) error {
+	if len(rotation.PublicKey) == 0 || len(rotation.ProtectedKey) == 0 ||
+		len(rotation.ContactsProtectedKey) > constants.MaxProtectedKeySize {
+		return InvalidRotationErr
+	}
+
+	keyTypes := make(map[string]bool)
+	for _, key := range rotation.Keys {
+		if err := validateUserKey(key); err != nil {
+			return err
+		} else if keyTypes[key.Type] {
+			return InvalidRotationErr
+		} else if len(rotation.RecoveryProtectedKey) > 0 &&
+			len(key.RecoveryProtectedKey) == 0 {
+			return MissingRecoveryKeysErr
+		}
+
+		keyTypes[key.Type] = true
+	}
+
+	remaining := make(map[string]shared.WrappedKey)
+	for _, key := range current {
+		remaining[wrappedKeyID(key)] = key
+	}
+
+	for _, key := range rotation.WrappedKeys {
+		if _, ok := remaining[wrappedKeyID(key)]; !ok {
+			return StaleRotationErr
+		} else if len(key.ProtectedKey) == 0 {
+			return InvalidRotationErr
+		}
+
+		delete(remaining, wrappedKeyID(key))
+	}
+
+	for _, key := range rotation.RemovedKeys {
+		existing, ok := remaining[wrappedKeyID(key)]
+		if !ok {
+			return StaleRotationErr
+		} else if existing.ID == userID {
+			return InvalidRotationErr
+		}
+
+		delete(remaining, wrappedKeyID(key))
+	}
+
+	if len(remaining) > 0 {
+		return StaleRotationErr
+	}
+
+	return nil
+}
+
+// notifySharers emails each user that had shared an item that was removed
+// during a key rotation, so that they know to share it again
+func notifySharers(userID string, sharerIDs []string) {
+	if len(sharerIDs) == 0 {
+		return
+	}
+
+	name, err := db.GetUserPublicName(userID)
+	if err != nil {
+		return
+	}
+
+	notified := make(map[string]bool)
+	for _, sharerID := range sharerIDs {
+		if notified[sharerID] {
+			continue
+		}
+
+		notified[sharerID] = true
+		email, err := db.GetUserEmailByID(sharerID)
+		if err != nil || len(email) == 0 {
+			continue
+		}
+
+		err = mail.SendReshareEmail(email, name)
+		if err != nil {
+			log.Printf("Error sending re-share email: %v\n", err)
+		}
+	}
+}
+
+func validateUserKey(key shared.UserKey) error {
+	if key.Type != constants.KeyTypeX25519 {
+		return UnsupportedKeyTypeErr
+	} else if len(key.PublicKey) != constants.X25519KeySize ||
+		len(key.ProtectedKey) == 0 ||
+		len(key.ProtectedKey) > constants.MaxProtectedKeySize ||
+		len(key.RecoveryProtectedKey) > constants.MaxProtectedKeySize {
+		return InvalidUserKeyErr
+	}
+
+	return nil
+}
+
+// wrappedKeyID returns a unique ID for a wrapped key, since files and folders
+// don't share an ID space
+func wrappedKeyID(key shared.WrappedKey) string {
+	if key.IsFolder {
+		return "folder:" + key.ID
+	}
+
+	return "file:" + key.ID
+}
@@ -0,0 +1,488 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/auth/oidc"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const (
+	// oidcRequestLifetime is how long a user has to finish an OIDC login
+	// after starting it, including entering their vault passphrase afterward
+	oidcRequestLifetime = 10 * time.Minute
+
+	// oidcConfirmCodeMax is the upper bound of the confirmation codes shown
+	// for CLI logins (i.e. 8 digits)
+	oidcConfirmCodeMax = 100000000
+)
+
+var (
+	OIDCDisabledErr       = errors.New("OIDC login is not enabled on this instance")
+	OIDCInvalidRequestErr = errors.New("invalid or expired login request")
+	OIDCWrongStatusErr    = errors.New("login request is not ready for this action")
+	OIDCWrongAccountErr   = errors.New("incorrect password for the linked account")
+	OIDCBindingErr        = errors.New("login was started in a different browser")
+	OIDCConfirmCodeErr    = errors.New("incorrect confirmation code")
+)
+
+var (
+	oidcProvider *oidc.Provider
+	oidcLock     sync.Mutex
+)
+
+// getOIDCProvider returns the configured identity provider, fetching its
+// discovery document on first use. Failures aren't cached, so that an
+// unreachable provider at startup doesn't prevent logins later on.
+func getOIDCProvider() (*oidc.Provider, error) {
+	oidcConfig := config.YeetFileConfig.OIDC
+	if !oidcConfig.Configured {
+		return nil, OIDCDisabledErr
+	}
+
+	oidcLock.Lock()
+	defer oidcLock.Unlock()
+
+	if oidcProvider != nil {
+		return oidcProvider, nil
+	}
+
+	provider, err := oidc.NewProvider(
+		oidcConfig.Issuer,
+		oidcConfig.ClientID,
+		oidcConfig.ClientSecret,
+		oidcConfig.RedirectURL,
+		nil)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcProvider = provider
+	return oidcProvider, nil
+}
+
+// StartOIDCLogin creates a new OIDC login request and returns the URL of the
+// identity provider, along with the request ID and a secret token that the
+// client uses to check on the status of the login. Logins started in a
+// browser also return a binding secret, which must be stored in the browser so
+// that the provider's callback can be matched to it. CLI logins return a
+// confirmation code instead, which the user enters in the browser that they
+// log in with.
+func StartOIDCLogin(isCLI bool) (shared.OIDCStartResponse, string, error) {
+	provider, err := getOIDCProvider()
+	if err != nil {
+		return shared.OIDCStartResponse{}, "", err
+	}
+
+	authRequest, err := oidc.NewAuthRequest()
+	if err != nil {
+		return shared.OIDCStartResponse{}, "", err
+	}
+
+	// The token proves that whoever checks the status of the request is the
+	// same client that started it
+	token, err := generateOIDCSecret()
+	if err != nil {
+		return shared.OIDCStartResponse{}, "", err
+	}
+
+	request := db.OIDCRequest{
+		ID:         shared.GenRandomString(16),
+		TokenHash:  hashOIDCToken(token),
+		State:      authRequest.State,
+		Nonce:      authRequest.Nonce,
+		Verifier:   authRequest.Verifier,
+		Expiration: time.Now().Add(oidcRequestLifetime),
+		IsCLI:      isCLI,
+	}
+
+	var binding, code string
+	if isCLI {
+		code, err = generateOIDCConfirmCode()
+		request.CodeHash = hashOIDCToken(code)
+	} else {
+		binding, err = generateOIDCSecret()
+		request.BindingHash = hashOIDCToken(binding)
+	}
+
+	if err != nil {
+		return shared.OIDCStartResponse{}, "", err
+	}
+
+	err = db.CreateOIDCRequest(request)
+	if err != nil {
+		return shared.OIDCStartResponse{}, "", err
+	}
+
+	return shared.OIDCStartResponse{
+		ID:      request.ID,
+		Token:   token,
+		AuthURL: provider.AuthURL(authRequest),
+		Code:    code,
+	}, binding, nil
+}
+
+// FinishOIDCCallback handles the identity provider's redirect back to
+// YeetFile. The authorization code is exchanged for the user's identity, which
+// determines whether the user can log in directly, needs to link an existing
+// account, or can sign up for a new account. Browser logins are only accepted
+// from the browser that started the login (using its binding secret), while
+// CLI logins are bound to the browser that finished the callback, which then
+// needs to confirm the CLI's code. Returns the request ID, which the client
+// can use to continue the login, and the new binding secret for CLI logins.
+func FinishOIDCCallback(state, code, providerErr, binding string) (string, string, error) {
+	request, err := db.GetPendingOIDCRequest(state)
+	if err == sql.ErrNoRows {
+		return "", "", OIDCInvalidRequestErr
+	} else if err != nil {
+		return "", "", err
+	}
+
+	var cliBinding string
+	if request.IsCLI {
+		cliBinding, err = generateOIDCSecret()
+		if err != nil {
+			return "", "", err
+		}
+
+		request.BindingHash = hashOIDCToken(cliBinding)
+	} else if !isOIDCBinding(request, binding) {
+		return "", "", OIDCBindingErr
+	} else {
+		request.Confirmed = true
+	}
+
+	request.Status, request.Error = resolveOIDCRequest(&request, code, providerErr)
+	err = db.UpdateOIDCRequest(request)
+	return request.ID, cliBinding, err
+}
+
+// ConfirmOIDCLogin confirms a CLI login from the browser that finished the
+// identity provider's callback, using the code shown by the CLI. An incorrect
+// code ends the login, so that codes can't be guessed.
+func ConfirmOIDCLogin(confirm shared.OIDCConfirm, binding string) error {
+	request, err := db.GetOIDCRequest(confirm.ID)
+	if err == sql.ErrNoRows {
+		return OIDCInvalidRequestErr
+	} else if err != nil {
+		return err
+	} else if !request.IsCLI ||
+		request.Confirmed ||
+		request.Status == constants.OIDCStatusPending ||
+		request.Status == constants.OIDCStatusError {
+		return OIDCWrongStatusErr
+	} else if !isOIDCBinding(request, binding) {
+		return OIDCBindingErr
+	}
+
+	code := hashOIDCToken(strings.TrimSpace(confirm.Code))
+	if subtle.ConstantTimeCompare(request.CodeHash, code) != 1 {
+		request.Status = constants.OIDCStatusError
+		request.Error = "Incorrect confirmation code"
+		err = db.UpdateOIDCRequest(request)
+		if err != nil {
+			return err
+		}
+
+		return OIDCConfirmCodeErr
+	}
+
+	request.Confirmed = true
+	return db.UpdateOIDCRequest(request)
+}
+
+// resolveOIDCRequest fills in the user's identity for the request, and returns
+// the request's new status along with an error message to show the user
+func resolveOIDCRequest(request *db.OIDCRequest, code, providerErr string) (string, string) {
+	if len(providerErr) > 0 || len(code) == 0 {
+		log.Printf("OIDC provider returned an error: %s\n", providerErr)
+		return constants.OIDCStatusError, "Login was cancelled or denied"
+	}
+
+	provider, err := getOIDCProvider()
+	if err != nil {
+		log.Printf("Error loading OIDC provider: %v\n", err)
+		return constants.OIDCStatusError, "Unable to reach the identity provider"
+	}
+
+	claims, err := provider.Exchange(code, oidc.AuthRequest{
+		State:    request.State,
+		Nonce:    request.Nonce,
+		Verifier: request.Verifier,
+	})
+	if err != nil {
+		log.Printf("Error verifying OIDC login: %v\n", err)
+		return constants.OIDCStatusError, "Unable to verify login"
+	}
+
+	request.Subject = claims.Subject
+	if claims.EmailVerified {
+		request.Email = strings.ToLower(claims.Email)
+	}
+
+	userID, err := db.GetOIDCIdentityUserID(provider.Issuer, claims.Subject)
+	if err == nil {
+		request.UserID = userID
+		request.Identifier = userID
+		if email, _ := db.GetUserEmailByID(userID); len(email) > 0 {
+			request.Identifier = email
+		}
+
+		return constants.OIDCStatusLogin, ""
+	} else if err != sql.ErrNoRows {
+		log.Printf("Error fetching OIDC identity: %v\n", err)
+		return constants.OIDCStatusError, "Error logging in"
+	}
+
+	// Existing accounts are only matched by email if the provider has
+	// verified that the user owns the address
+	if len(request.Email) > 0 {
+		userID, err = db.GetUserIDByEmail(request.Email)
+		if err == nil {
+			request.UserID = userID
+			request.Identifier = request.Email
+			return constants.OIDCStatusLink, ""
+		}
+	}
+
+	// New users are provisioned with their email if the provider has one,
+	// otherwise a new account ID is created for them
+	if len(request.Email) > 0 {
+		allowed, err := isEmailDomainAllowed(request.Email)
+		if err != nil {
+			log.Printf("Error checking email domain rules: %v\n", err)
+			return constants.OIDCStatusError, "Error logging in"
+		} else if !allowed {
+			return constants.OIDCStatusError, EmailDomainNotAllowed.Error()
+		}
+
+		request.Identifier = request.Email
+	} else {
+		request.Identifier = db.CreateUniqueUserID()
+	}
+
+	return constants.OIDCStatusSignup, ""
+}
+
+// GetOIDCRequest returns the OIDC request matching the ID, as long as the
+// provided token matches the one generated when the request was started
+func GetOIDCRequest(id, token string) (db.OIDCRequest, error) {
+	request, err := db.GetOIDCRequest(id)
+	if err == sql.ErrNoRows {
+		return db.OIDCRequest{}, OIDCInvalidRequestErr
+	} else if err != nil {
+		return db.OIDCRequest{}, err
+	}
+
+	if subtle.ConstantTimeCompare(request.TokenHash, hashOIDCToken(token)) != 1 {
+		return db.OIDCRequest{}, OIDCInvalidRequestErr
+	}
+
+	// The result of the login isn't available to the client until the login
+	// has been confirmed
+	if !request.Confirmed && request.Status != constants.OIDCStatusError {
+		request.Status = constants.OIDCStatusPending
+	}
+
+	return request, nil
+}
+
+// validateOIDC2FA checks the TOTP (or recovery) code of a user with two-factor
+// authentication enabled, which is still required after the user has logged
+// in with the identity provider
+func validateOIDC2FA(userID, code string) error {
+	secret, err := db.GetUserSecret(userID)
+	if err != nil {
+		return err
+	} else if len(secret) == 0 {
+		return nil
+	}
+
+	return validateTOTP(secret, code, userID)
+}
+
+// hasOIDC2FA returns true if the user needs to provide a TOTP code to log in
+func hasOIDC2FA(userID string) bool {
+	secret, err := db.GetUserSecret(userID)
+	if err != nil {
+		log.Printf("Error fetching user 2FA secret: %v\n", err)
+	}
+
+	return len(secret) > 0
+}
+
+// LinkOIDCAccount links the identity from an OIDC request to an existing
+// account, after the user has confirmed ownership with their password.
+// Returns the ID of the linked user.
+func LinkOIDCAccount(link shared.OIDCLink) (string, error) {
+	request, err := GetOIDCRequest(link.ID, link.Token)
+	if err != nil {
+		return "", err
+	} else if request.Status != constants.OIDCStatusLink {
+		return "", OIDCWrongStatusErr
+	}
+
+	userID, err := ValidateCredentials(request.Identifier, link.LoginKeyHash, link.Code, true)
+	if err == Missing2FAErr || err == Failed2FAErr {
+		return "", err
+	} else if err != nil || userID != request.UserID {
+		return "", OIDCWrongAccountErr
+	}
+
+	err = addOIDCIdentity(request)
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+// SignupOIDCAccount creates a new account for the identity in an OIDC
+// request, using the keys generated from the user's vault passphrase. The
+// signup requires the server password, an invite code, or an invite link in
+// the same way as other signups. Returns the new user's ID.
+func SignupOIDCAccount(signup shared.OIDCSignup) (string, error) {
+	request, err := GetOIDCRequest(signup.ID, signup.Token)
+	if err != nil {
+		return "", err
+	} else if request.Status != constants.OIDCStatusSignup {
+		return "", OIDCWrongStatusErr
+	}
+
+	var email string
+	if strings.Contains(request.Identifier, "@") {
+		email = request.Identifier
+	}
+
+	inviteLinkHash, err := authorizeSignup(email, signup.ServerPassword)
+	if err != nil {
+		return "", err
+	}
+
+	userID, err := createOIDCAccount(request, signup, inviteLinkHash)
+	if err != nil {
+		return "", err
+	}
+
+	if config.InvitesAllowed && len(email) > 0 {
+		err = db.RemoveInvites([]string{email})
+		if err != nil {
+			log.Printf("Error removing invite: %v\n", err)
+		}
+	}
+
+	return userID, nil
+}
+
+func createOIDCAccount(
+	request db.OIDCRequest,
+	signup shared.OIDCSignup,
+	inviteLinkHash []byte,
+) (string, error) {
+	kdf, err := getAccountKDFParams(signup.KDF)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword(signup.LoginKeyHash, 8)
+	if err != nil {
+		return "", err
+	}
+
+	values := db.VerifiedAccountValues{
+		KDF:                     kdf,
+		PasswordHash:            hash,
+		PublicKey:               signup.PublicKey,
+		ProtectedPrivateKey:     signup.ProtectedPrivateKey,
+		ProtectedVaultFolderKey: signup.ProtectedVaultFolderKey,
+		InviteLinkHash:          inviteLinkHash,
+	}
+
+	if strings.Contains(request.Identifier, "@") {
+		values.Email = request.Identifier
+	} else {
+		values.AccountID = request.Identifier
+	}
+
+	userID, err := createNewUser(values)
+	if err != nil {
+		return "", err
+	}
+
+	request.UserID = userID
+	err = addOIDCIdentity(request)
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+// CompleteOIDCRequest removes a finished OIDC request so that it can't be
+// used again
+func CompleteOIDCRequest(id string) {
+	err := db.DeleteOIDCRequest(id)
+	if err != nil {
+		log.Printf("Error removing OIDC request: %v\n", err)
+	}
+}
+
+func addOIDCIdentity(request db.OIDCRequest) error {
+	provider, err := getOIDCProvider()
+	if err != nil {
+		return err
+	}
+
+	err = db.AddOIDCIdentity(provider.Issuer, request.Subject, request.UserID)
+	if err != nil {
+		return err
+	}
+
+	CompleteOIDCRequest(request.ID)
+	return nil
+}
+
+// isOIDCBinding returns true if the binding secret matches the one stored for
+// the request
+func isOIDCBinding(request db.OIDCRequest, binding string) bool {
+	return len(request.BindingHash) > 0 && len(binding) > 0 &&
+		subtle.ConstantTimeCompare(request.BindingHash, hashOIDCToken(binding)) == 1
+}
+
+func generateOIDCSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func generateOIDCConfirmCode() (string, error) {
+	code, err := rand.Int(rand.Reader, big.NewInt(oidcConfirmCodeMax))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%08d", code), nil
+}
+
+func hashOIDCToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
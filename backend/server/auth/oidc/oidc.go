@@ -0,0 +1,239 @@
+// Package oidc implements the parts of the OpenID Connect authorization code
+// flow (with PKCE) needed to authenticate users against an external identity
+// provider. It intentionally only relies on the standard library.
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// maxResponseSize limits how much of a provider response is read
+	maxResponseSize = 1024 * 1024
+
+	// clockSkew is the amount of leeway given when checking token expiration
+	clockSkew = time.Minute
+
+	// keyRefreshInterval is the minimum amount of time between fetching the
+	// provider's signing keys, so that tokens with unknown key IDs can't be
+	// used to flood the provider with requests
+	keyRefreshInterval = time.Minute
+)
+
+var (
+	IssuerMismatchErr = errors.New("oidc: issuer does not match configuration")
+	MissingTokenErr   = errors.New("oidc: token response is missing id_token")
+)
+
+// Provider is an OpenID Connect identity provider, configured using the
+// provider's discovery document
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	authURL  string
+	tokenURL string
+	jwksURL  string
+
+	client      *http.Client
+	mu          sync.Mutex
+	keys        map[string]any
+	keysFetched time.Time
+}
+
+// AuthRequest contains the random values generated for a single login
+// attempt, which must be kept by the server until the provider redirects the
+// user back to the callback URL.
+type AuthRequest struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// Claims are the user details extracted from a verified ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// NewProvider fetches the issuer's discovery document and returns a Provider
+// that can be used to start logins and verify their results.
+func NewProvider(
+	issuer,
+	clientID,
+	clientSecret,
+	redirectURL string,
+	client *http.Client,
+) (*Provider, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	issuer = strings.TrimSuffix(issuer, "/")
+	var doc discovery
+	err := getJSON(client, issuer+discoveryPath, &doc)
+	if err != nil {
+		return nil, err
+	} else if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+		return nil, IssuerMismatchErr
+	} else if len(doc.AuthorizationEndpoint) == 0 ||
+		len(doc.TokenEndpoint) == 0 ||
+		len(doc.JWKSURI) == 0 {
+		return nil, errors.New("oidc: discovery document is incomplete")
+	}
+
+	return &Provider{
+		Issuer:       doc.Issuer,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		authURL:      doc.AuthorizationEndpoint,
+		tokenURL:     doc.TokenEndpoint,
+		jwksURL:      doc.JWKSURI,
+		client:       client,
+	}, nil
+}
+
+// NewAuthRequest generates the state, nonce, and PKCE verifier for a new
+// login attempt
+func NewAuthRequest() (AuthRequest, error) {
+	var values [3]string
+	for i := range values {
+		value, err := randomString()
+		if err != nil {
+			return AuthRequest{}, err
+		}
+
+		values[i] = value
+	}
+
+	return AuthRequest{
+		State:    values[0],
+		Nonce:    values[1],
+		Verifier: values[2],
+	}, nil
+}
+
+// AuthURL returns the provider URL that the user should be sent to in order to
+// log in
+func (p *Provider) AuthURL(request AuthRequest) string {
+	challenge := sha256.Sum256([]byte(request.Verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {"openid email"},
+		"state":                 {request.State},
+		"nonce":                 {request.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.authURL, "?") {
+		separator = "&"
+	}
+
+	return p.authURL + separator + params.Encode()
+}
+
+// Exchange trades the authorization code returned to the callback URL for an
+// ID token, and returns the token's claims once it has been verified.
+func (p *Provider) Exchange(code string, request AuthRequest) (Claims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"code_verifier": {request.Verifier},
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		p.tokenURL,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if len(p.ClientSecret) > 0 {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token)
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: invalid token response: %w", err)
+	} else if resp.StatusCode != http.StatusOK {
+		return Claims{}, fmt.Errorf(
+			"oidc: token request failed (%d): %s",
+			resp.StatusCode,
+			token.Error)
+	} else if len(token.IDToken) == 0 {
+		return Claims{}, MissingTokenErr
+	}
+
+	return p.VerifyIDToken(token.IDToken, request.Nonce)
+}
+
+func getJSON(client *http.Client, url string, out any) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: unexpected status fetching %s: %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(out)
+}
+
+func randomString() (string, error) {
+	value := make([]byte, 32)
+	_, err := rand.Read(value)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(value), nil
+}
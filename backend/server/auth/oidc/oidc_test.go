@@ -0,0 +1,244 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID    = "yeetfile-test"
+	testRedirectURL = "https://yeetfile.example/api/oidc/callback"
+	testCode        = "test-auth-code"
+	testKeyID       = "test-key"
+)
+
+// mockIssuer is a minimal OIDC provider that issues RS256-signed ID tokens
+type mockIssuer struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	claims    map[string]any
+	kid       string
+	fetches   int
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	issuer := &mockIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(discovery{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		issuer.fetches++
+		e := big.NewInt(int64(key.PublicKey.E)).Bytes()
+		_ = json.NewEncoder(w).Encode(jwks{Keys: []jwk{{
+			Kty: "RSA",
+			Kid: testKeyID,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(e),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		_ = req.ParseForm()
+		verifier := sha256.Sum256([]byte(req.PostForm.Get("code_verifier")))
+		challenge := base64.RawURLEncoding.EncodeToString(verifier[:])
+		if req.PostForm.Get("code") != testCode || challenge != issuer.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(tokenResponse{
+			IDToken: issuer.sign(t, issuer.claims),
+		})
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (m *mockIssuer) sign(t *testing.T, claims map[string]any) string {
+	kid := testKeyID
+	if len(m.kid) > 0 {
+		kid = m.kid
+	}
+
+	header, _ := json.Marshal(tokenHeader{Alg: "RS256", Kid: kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (m *mockIssuer) defaultClaims(nonce string) map[string]any {
+	return map[string]any{
+		"iss":            m.server.URL,
+		"sub":            "user-1234",
+		"aud":            testClientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+}
+
+// startLogin creates a provider and auth request against the mock issuer, and
+// records the PKCE challenge sent in the authorization URL
+func startLogin(t *testing.T, issuer *mockIssuer) (*Provider, AuthRequest) {
+	provider, err := NewProvider(issuer.server.URL, testClientID, "", testRedirectURL, nil)
+	assert.Nil(t, err)
+
+	request, err := NewAuthRequest()
+	assert.Nil(t, err)
+
+	authURL, err := url.Parse(provider.AuthURL(request))
+	assert.Nil(t, err)
+
+	query := authURL.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, request.State, query.Get("state"))
+	assert.Equal(t, request.Nonce, query.Get("nonce"))
+	assert.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+
+	issuer.challenge = query.Get("code_challenge")
+	issuer.claims = issuer.defaultClaims(request.Nonce)
+	return provider, request
+}
+
+func TestExchange(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider, request := startLogin(t, issuer)
+
+	claims, err := provider.Exchange(testCode, request)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1234", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+
+	// Providers may send email_verified as a string
+	issuer.claims["email_verified"] = "false"
+	claims, err = provider.Exchange(testCode, request)
+	assert.Nil(t, err)
+	assert.False(t, claims.EmailVerified)
+}
+
+func TestExchangeWrongVerifier(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider, request := startLogin(t, issuer)
+
+	request.Verifier = "not-the-original-verifier"
+	_, err := provider.Exchange(testCode, request)
+	assert.NotNil(t, err)
+
+	_, err = provider.Exchange("wrong-code", request)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyIDTokenClaims(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider, request := startLogin(t, issuer)
+
+	tests := []struct {
+		name     string
+		key      string
+		value    any
+		expected error
+	}{
+		{"wrong nonce", "nonce", "other-nonce", InvalidClaimsErr},
+		{"wrong audience", "aud", "other-client", InvalidClaimsErr},
+		{"wrong issuer", "iss", "https://evil.example", InvalidClaimsErr},
+		{"missing subject", "sub", "", InvalidClaimsErr},
+		{"expired", "exp", time.Now().Add(-time.Hour).Unix(), ExpiredTokenErr},
+	}
+
+	for _, test := range tests {
+		claims := issuer.defaultClaims(request.Nonce)
+		claims[test.key] = test.value
+
+		_, err := provider.VerifyIDToken(issuer.sign(t, claims), request.Nonce)
+		assert.Equal(t, test.expected, err, test.name)
+	}
+
+	// Multiple audiences are allowed if the client is the authorized party
+	claims := issuer.defaultClaims(request.Nonce)
+	claims["aud"] = []string{testClientID, "other-client"}
+	claims["azp"] = testClientID
+	_, err := provider.VerifyIDToken(issuer.sign(t, claims), request.Nonce)
+	assert.Nil(t, err)
+}
+
+func TestVerifyIDTokenSignature(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider, request := startLogin(t, issuer)
+
+	// Swap the payload while keeping the original signature
+	token := strings.Split(issuer.sign(t, issuer.defaultClaims(request.Nonce)), ".")
+	forged := issuer.defaultClaims(request.Nonce)
+	forged["sub"] = "admin"
+	payload, _ := json.Marshal(forged)
+	token[1] = base64.RawURLEncoding.EncodeToString(payload)
+
+	_, err := provider.VerifyIDToken(strings.Join(token, "."), request.Nonce)
+	assert.Equal(t, InvalidSignatureErr, err)
+
+	// Tokens signed by a key the provider doesn't publish are rejected
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	issuer.key = otherKey
+	_, err = provider.VerifyIDToken(issuer.sign(t, forged), request.Nonce)
+	assert.Equal(t, InvalidSignatureErr, err)
+
+	_, err = provider.VerifyIDToken("not-a-token", request.Nonce)
+	assert.Equal(t, InvalidTokenErr, err)
+}
+
+func TestNewProviderIssuerMismatch(t *testing.T) {
+	issuer := newMockIssuer(t)
+	_, err := NewProvider(issuer.server.URL+"/other", testClientID, "", testRedirectURL, nil)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyIDTokenUnknownKey(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider, request := startLogin(t, issuer)
+
+	_, err := provider.VerifyIDToken(issuer.sign(t, issuer.claims), request.Nonce)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, issuer.fetches)
+
+	// Unknown key IDs don't refresh the keys again until the interval passes
+	issuer.kid = "unknown-key"
+	for i := 0; i < 3; i++ {
+		_, err = provider.VerifyIDToken(issuer.sign(t, issuer.claims), request.Nonce)
+		assert.Equal(t, UnknownKeyErr, err)
+	}
+
+	assert.Equal(t, 1, issuer.fetches)
+}
@@ -0,0 +1,250 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidTokenErr     = errors.New("oidc: malformed id token")
+	InvalidSignatureErr = errors.New("oidc: invalid id token signature")
+	UnknownKeyErr       = errors.New("oidc: id token signed with unknown key")
+	InvalidClaimsErr    = errors.New("oidc: id token claims are invalid")
+	ExpiredTokenErr     = errors.New("oidc: id token has expired")
+)
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type tokenClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	AuthorizedBy  string   `json:"azp"`
+	Expiration    float64  `json:"exp"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified jsonBool `json:"email_verified"`
+}
+
+// audience is either a single string or a list of strings in an ID token
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	err := json.Unmarshal(data, &list)
+	*a = list
+	return err
+}
+
+// jsonBool accepts both booleans and strings, since some providers send
+// email_verified as "true"
+type jsonBool bool
+
+func (b *jsonBool) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	*b = jsonBool(value == "true")
+	return nil
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// VerifyIDToken checks the signature of a raw ID token against the provider's
+// signing keys, validates its claims, and returns the user's details.
+func (p *Provider) VerifyIDToken(rawToken, nonce string) (Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return Claims{}, InvalidTokenErr
+	}
+
+	var header tokenHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return Claims{}, InvalidTokenErr
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, InvalidTokenErr
+	}
+
+	key, err := p.getKey(header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = verifySignature(header.Alg, key, digest[:], signature)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var claims tokenClaims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return Claims{}, InvalidTokenErr
+	}
+
+	if claims.Issuer != p.Issuer ||
+		len(claims.Subject) == 0 ||
+		!slices.Contains(claims.Audience, p.ClientID) ||
+		(len(claims.Audience) > 1 && claims.AuthorizedBy != p.ClientID) ||
+		claims.Nonce != nonce {
+		return Claims{}, InvalidClaimsErr
+	}
+
+	expiration := time.Unix(int64(claims.Expiration), 0)
+	if time.Now().After(expiration.Add(clockSkew)) {
+		return Claims{}, ExpiredTokenErr
+	}
+
+	return Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}, nil
+}
+
+// getKey returns the provider's signing key with the matching key ID. The
+// provider's keys are refreshed if the key isn't known yet (at most once per
+// keyRefreshInterval), so that key rotation on the provider's end doesn't
+// break logins.
+func (p *Provider) getKey(kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	} else if time.Since(p.keysFetched) < keyRefreshInterval {
+		return nil, UnknownKeyErr
+	}
+
+	p.keysFetched = time.Now()
+
+	var set jwks
+	err := getJSON(p.client, p.jwksURL, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = make(map[string]any)
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err == nil {
+			p.keys[k.Kid] = key
+		}
+	}
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, UnknownKeyErr
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.New("oidc: unsupported curve")
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	}
+
+	return nil, errors.New("oidc: unsupported key type")
+}
+
+func verifySignature(alg string, key any, digest, signature []byte) error {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if ok && rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature) == nil {
+			return nil
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if ok && len(signature) == 64 {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			if ecdsa.Verify(ecKey, digest, r, s) {
+				return nil
+			}
+		}
+	}
+
+	return InvalidSignatureErr
+}
+
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"strings"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var (
+	InvalidRecoveryKeyErr  = errors.New("invalid recovery key")
+	MissingRecoveryKeysErr = errors.New("all of the account's keys must be " +
+		"encrypted with the recovery key, please update your client")
+	RecoveryFailedErr  = errors.New("incorrect account or recovery key")
+	InvalidRecoveryErr = errors.New("invalid account recovery request")
+)
+
+// GetRecoveryStatus returns whether the user has set up a recovery key, along
+// with the recovery public key that new private keys should be encrypted with
+func GetRecoveryStatus(userID string) (shared.RecoveryStatus, error) {
+	publicKey, err := db.GetUserRecoveryPublicKey(userID)
+	if err != nil {
+		return shared.RecoveryStatus{}, err
+	}
+
+	return shared.RecoveryStatus{
+		Enabled:   len(publicKey) > 0,
+		PublicKey: publicKey,
+	}, nil
+}
+
+// SetRecoveryKey validates and stores a new recovery key for the user, which
+// replaces any recovery key they had before
+func SetRecoveryKey(userID string, recovery shared.RecoveryKey) error {
+	err := validateRecoveryKey(userID, recovery)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword(recovery.KeyHash, 8)
+	if err != nil {
+		return err
+	}
+
+	return db.SetUserRecovery(
+		userID,
+		hash,
+		recovery.PublicKey,
+		recovery.ProtectedKey,
+		recovery.Keys)
+}
+
+// GetRecoveryKeys returns the copies of a user's private keys that were
+// encrypted with their recovery key, which the client decrypts in order to
+// encrypt them again with a new password. The server's recommended key
+// derivation parameters are included for deriving the new user key.
+func GetRecoveryKeys(request shared.RecoveryKeysRequest) (shared.RecoveryKeysResponse, error) {
+	userID, protectedKey, err := verifyRecoveryKey(
+		request.Identifier,
+		request.KeyHash)
+	if err != nil {
+		return shared.RecoveryKeysResponse{}, err
+	}
+
+	keys, err := db.GetUserAdditionalKeys(userID)
+	if err != nil {
+		return shared.RecoveryKeysResponse{}, err
+	}
+
+	recoveryKeys := []shared.UserKey{}
+	for _, key := range keys {
+		recoveryKeys = append(recoveryKeys, shared.UserKey{
+			Type:                 key.Type,
+			PublicKey:            key.PublicKey,
+			RecoveryProtectedKey: key.RecoveryProtectedKey,
+		})
+	}
+
+	return shared.RecoveryKeysResponse{
+		ProtectedKey: protectedKey,
+		Keys:         recoveryKeys,
+		KDF:          config.YeetFileConfig.KDF,
+	}, nil
+}
+
+// RecoverAccount replaces the login credentials of a user who has forgotten
+// their password, using their private keys re-encrypted with a new user key.
+// All of the user's existing sessions are invalidated, and the user is sent
+// an email about the change (if they have an email). Two-factor
+// authentication is left in place and is still required to log in.
+func RecoverAccount(recover shared.RecoverAccount) error {
+	userID, _, err := verifyRecoveryKey(recover.Identifier, recover.KeyHash)
+	if err != nil {
+		return err
+	} else if len(recover.NewLoginKeyHash) == 0 || len(recover.ProtectedKey) == 0 {
+		return InvalidRecoveryErr
+	}
+
+	kdf, err := getAccountKDFParams(recover.KDF)
+	if err != nil {
+		return err
+	}
+
+	err = validateProtectedKeys(userID, recover.ProtectedKeys)
+	if err != nil {
+		return err
+	}
+
+	err = updateUserLogin(
+		userID,
+		recover.NewLoginKeyHash,
+		recover.ProtectedKey,
+		recover.ProtectedKeys,
+		kdf)
+	if err != nil {
+		return err
+	}
+
+	err = db.SetUserSessionKey(userID, shared.GenRandomString(16))
+	if err != nil {
+		log.Printf("Error invalidating sessions after recovery: %v\n", err)
+	}
+
+	email, err := db.GetUserEmailByID(userID)
+	if err == nil && len(email) > 0 {
+		err = mail.SendRecoveryEmail(email)
+		if err != nil {
+			log.Printf("Error sending recovery email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// updateUserLogin replaces a user's login key hash, protected keys, and key
+// derivation parameters after their user key has changed
+func updateUserLogin(
+	userID string,
+	loginKeyHash []byte,
+	protectedKey []byte,
+	protectedKeys []shared.UserKey,
+	kdf shared.KDFParams,
+) error {
+	bcryptHash, err := bcrypt.GenerateFromPassword(loginKeyHash, 8)
+	if err != nil {
+		log.Printf("Error generating bcrypt hash: %v\n", err)
+		return err
+	}
+
+	err = db.UpdateUserLogin(userID, bcryptHash, protectedKey, protectedKeys, kdf)
+	if err != nil {
+		log.Printf("Error updating user login credentials: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// getSignupRecoveryKey validates the recovery key that a new account was
+// created with (if any), returning it with its key hash hashed with bcrypt
+func getSignupRecoveryKey(recovery shared.RecoveryKey) (shared.RecoveryKey, error) {
+	if len(recovery.KeyHash) == 0 &&
+		len(recovery.PublicKey) == 0 &&
+		len(recovery.ProtectedKey) == 0 {
+		return shared.RecoveryKey{}, nil
+	}
+
+	err := validateRecoveryKey("", recovery)
+	if err != nil {
+		return shared.RecoveryKey{}, err
+	}
+
+	recovery.KeyHash, err = bcrypt.GenerateFromPassword(recovery.KeyHash, 8)
+	return recovery, err
+}
+
+// verifyRecoveryKey checks a recovery key hash against the one stored for the
+// account matching the identifier, returning the user's ID and the copy of
+// their private key encrypted with the recovery key. Unknown accounts and
+// accounts without a recovery key return the same error as an incorrect key.
+func verifyRecoveryKey(identifier string, keyHash []byte) (string, []byte, error) {
+	if len(identifier) == 0 || len(keyHash) == 0 {
+		return "", nil, RecoveryFailedErr
+	}
+
+	userID := identifier
+	if strings.Contains(identifier, "@") {
+		var err error
+		userID, err = db.GetUserIDByEmail(identifier)
+		if err != nil {
+			return "", nil, err
+		} else if len(userID) == 0 {
+			return "", nil, RecoveryFailedErr
+		}
+	}
+
+	hash, protectedKey, err := db.GetUserRecovery(userID)
+	if err == sql.ErrNoRows || (err == nil && len(hash) == 0) {
+		return "", nil, RecoveryFailedErr
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, keyHash) != nil {
+		return "", nil, RecoveryFailedErr
+	}
+
+	return userID, protectedKey, nil
+}
+
+// validateRecoveryKey ensures that a new recovery key includes a recovery copy
+// of each of the user's private keys. The user ID is empty for new accounts,
+// which don't have any additional key pairs yet.
+func validateRecoveryKey(userID string, recovery shared.RecoveryKey) error {
+	if len(recovery.KeyHash) == 0 ||
+		len(recovery.PublicKey) != constants.X25519KeySize ||
+		len(recovery.ProtectedKey) == 0 {
+		return InvalidRecoveryKeyErr
+	} else if len(userID) == 0 {
+		return nil
+	}
+
+	keys, err := db.GetUserAdditionalKeys(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		found := false
+		for _, recoveryKey := range recovery.Keys {
+			if recoveryKey.Type == key.Type &&
+				len(recoveryKey.RecoveryProtectedKey) > 0 {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return MissingRecoveryKeysErr
+		}
+	}
+
+	return nil
+}
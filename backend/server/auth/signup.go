@@ -3,18 +3,70 @@ package auth
 import (
 	"errors"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"yeetfile/backend/config"
 	"yeetfile/backend/db"
 	"yeetfile/backend/mail"
+	"yeetfile/backend/server/invites"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
 )
 
-var MissingField = errors.New("missing required signup fields")
+var (
+	MissingField          = errors.New("missing required signup fields")
+	EmailDomainNotAllowed = errors.New("signups from this email domain are not allowed")
+	InvalidServerPassword = errors.New("missing or invalid server password")
+	InvalidInviteCode     = errors.New("invalid invite code")
+)
+
+// authorizeSignup checks that a new user is allowed to sign up, using either
+// the server password, an invite code sent to their email, or an invite link
+// code (all provided as the password). Returns the invite link's code hash if
+// an invite link was used, which is claimed once the account is created.
+func authorizeSignup(email, password string) ([]byte, error) {
+	// Invite links can be used whether or not the server has a password
+	if config.InvitesAllowed {
+		inviteLinkHash, err := invites.CheckLink(password)
+		if err == nil {
+			return inviteLinkHash, nil
+		} else if err != invites.InvalidLinkErr {
+			return nil, err
+		}
+	}
+
+	if config.YeetFileConfig.PasswordHash == nil {
+		return nil, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword(
+		config.YeetFileConfig.PasswordHash,
+		[]byte(password))
+	if err == nil {
+		return nil, nil
+	}
+
+	authErr := InvalidServerPassword
+	if config.InvitesAllowed && len(email) > 0 {
+		// Check if the provided password is an invite code
+		codeHash, dbErr := db.GetInviteCodeHash(email)
+		if dbErr == nil && len(codeHash) > 0 {
+			err = bcrypt.CompareHashAndPassword(codeHash, []byte(password))
+			if err == nil {
+				return nil, nil
+			}
+
+			authErr = InvalidInviteCode
+		}
+	}
+
+	return nil, authErr
+}
 
 // SignupWithEmail uses values from the Signup struct to complete registration
 // of a new user. A hash is generated from the provided password and entered
-// into the "users" db table.
-func SignupWithEmail(signup shared.Signup) error {
+// into the "users" db table. The invite link hash (if any) is stored with the
+// verification entry until the account is created.
+func SignupWithEmail(signup shared.Signup, inviteLinkHash []byte) error {
 	// When signing up with email, no part of the signup struct can be empty
 	isMissingByteSlices := utils.IsAnyByteSliceMissing(
 		signup.ProtectedPrivateKey,
@@ -26,12 +78,31 @@ func SignupWithEmail(signup shared.Signup) error {
 		return MissingField
 	}
 
+	kdf, err := getAccountKDFParams(signup.KDF)
+	if err != nil {
+		return err
+	}
+
+	signup.KDF = kdf
+
+	signup.RecoveryKey, err = getSignupRecoveryKey(signup.RecoveryKey)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := isEmailDomainAllowed(signup.Identifier)
+	if err != nil {
+		return err
+	} else if !allowed {
+		return EmailDomainNotAllowed
+	}
+
 	hash, err := bcrypt.GenerateFromPassword(signup.LoginKeyHash, 8)
 	if err != nil {
 		return err
 	}
 
-	code, err := db.NewVerification(signup, hash, "")
+	code, err := db.NewVerification(signup, hash, "", inviteLinkHash)
 	if err != nil {
 		return err
 	}
@@ -43,10 +114,14 @@ func SignupWithEmail(signup shared.Signup) error {
 // SignupAccountIDOnly creates a new user with only an account ID as the user's
 // login credential. Returns the user's (temporary) account ID, an image
 // of their captcha code, and an error.
-func SignupAccountIDOnly(isCLI bool) (string, string, error) {
+func SignupAccountIDOnly(isCLI bool, inviteLinkHash []byte) (string, string, error) {
 	id := db.CreateUniqueUserID()
 
-	code, err := db.NewVerification(shared.Signup{Identifier: id}, nil, "")
+	code, err := db.NewVerification(
+		shared.Signup{Identifier: id},
+		nil,
+		"",
+		inviteLinkHash)
 	if err != nil {
 		return "", "", err
 	}
@@ -54,3 +129,36 @@ func SignupAccountIDOnly(isCLI bool) (string, string, error) {
 	captchaBase64, err := GenerateCaptchaImage(code, isCLI)
 	return id, captchaBase64, err
 }
+
+// isEmailDomainAllowed checks the email's domain against the admin-configured
+// domain rules. Deny rules always take precedence, and if any allow rules
+// exist, the domain must match one of them. Rules also match subdomains.
+func isEmailDomainAllowed(email string) (bool, error) {
+	rules, err := db.GetEmailDomainRules()
+	if err != nil {
+		return false, err
+	} else if len(rules) == 0 {
+		return true, nil
+	}
+
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	matches := func(ruleDomain string) bool {
+		return domain == ruleDomain || strings.HasSuffix(domain, "."+ruleDomain)
+	}
+
+	hasAllowRules := false
+	allowed := false
+	for _, rule := range rules {
+		switch rule.Rule {
+		case db.EmailDomainDeny:
+			if matches(rule.Domain) {
+				return false, nil
+			}
+		case db.EmailDomainAllow:
+			hasAllowRules = true
+			allowed = allowed || matches(rule.Domain)
+		}
+	}
+
+	return allowed || !hasAllowRules, nil
+}
@@ -0,0 +1,194 @@
+package emergency
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// maxProtectedKeySize limits the size of the wrapped root folder key stored for
+// a contact, which is largest when wrapped with an RSA public key
+const maxProtectedKeySize = 1024
+
+var (
+	InvalidRequestErr  = errors.New("invalid emergency access request")
+	UserNotFoundErr    = errors.New("user not found")
+	TooManyContactsErr = errors.New("emergency contact limit reached")
+)
+
+// GetEmergencyAccess returns the user's emergency contacts, as well as the
+// users who have added the user as one of their emergency contacts
+func GetEmergencyAccess(userID string) (shared.EmergencyAccessResponse, error) {
+	contacts, err := db.GetEmergencyContacts(userID)
+	if err != nil {
+		return shared.EmergencyAccessResponse{}, err
+	}
+
+	grantors, err := db.GetEmergencyGrantors(userID)
+	if err != nil {
+		return shared.EmergencyAccessResponse{}, err
+	}
+
+	return shared.EmergencyAccessResponse{
+		Contacts: contacts,
+		Grantors: grantors,
+	}, nil
+}
+
+// AddContact adds another user as one of the owner's emergency contacts. The
+// owner's vault root folder key must already be wrapped with the contact's
+// public key, so that only the contact is able to read it once access has
+// been granted.
+func AddContact(ownerID string, contact shared.NewEmergencyContact) error {
+	if contact.WaitDays == 0 {
+		contact.WaitDays = constants.DefaultEmergencyWaitDays
+	}
+
+	if contact.WaitDays < 1 ||
+		contact.WaitDays > constants.MaxEmergencyWaitDays ||
+		len(contact.ProtectedKey) == 0 ||
+		len(contact.ProtectedKey) > maxProtectedKeySize {
+		return InvalidRequestErr
+	}
+
+	contactID, err := getUserID(contact.Identifier)
+	if err != nil {
+		return err
+	} else if contactID == ownerID {
+		return InvalidRequestErr
+	}
+
+	contacts, err := db.GetEmergencyContacts(ownerID)
+	if err != nil {
+		return err
+	}
+
+	exists := false
+	for _, existing := range contacts {
+		if existing.UserID == contactID {
+			exists = true
+			break
+		}
+	}
+
+	if !exists && len(contacts) >= constants.MaxEmergencyContacts {
+		return TooManyContactsErr
+	}
+
+	err = db.SetEmergencyContact(ownerID, contactID, contact)
+	if err != nil {
+		return err
+	}
+
+	email, err := db.GetUserEmailByID(contactID)
+	if err == nil && len(email) > 0 {
+		err = mail.SendEmergencyContactEmail(
+			email,
+			getDisplayName(ownerID),
+			contact.WaitDays)
+		if err != nil {
+			log.Printf("Error sending emergency contact email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// RemoveContact removes one of the owner's emergency contacts
+func RemoveContact(ownerID, contactID string) error {
+	return db.DeleteEmergencyAccess(ownerID, contactID)
+}
+
+// LeaveGrantor removes the contact from the owner's emergency contacts at the
+// contact's request
+func LeaveGrantor(contactID, ownerID string) error {
+	return db.DeleteEmergencyAccess(ownerID, contactID)
+}
+
+// RequestAccess starts the waiting period for the contact's access to the
+// owner's vault, and notifies the owner so that they can deny the request.
+func RequestAccess(contactID, ownerID string) error {
+	waitDays, err := db.RequestEmergencyAccess(ownerID, contactID)
+	if err != nil {
+		return err
+	}
+
+	email, err := db.GetUserEmailByID(ownerID)
+	if err == nil && len(email) > 0 {
+		err = mail.SendEmergencyAccessRequestEmail(
+			email,
+			getDisplayName(contactID),
+			waitDays)
+		if err != nil {
+			log.Printf("Error sending emergency request email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// DenyAccess cancels a contact's pending request for access to the owner's
+// vault, and notifies the contact
+func DenyAccess(ownerID, contactID string) error {
+	err := db.DenyEmergencyAccess(ownerID, contactID)
+	if err != nil {
+		return err
+	}
+
+	email, err := db.GetUserEmailByID(contactID)
+	if err == nil && len(email) > 0 {
+		err = mail.SendEmergencyAccessDeniedEmail(
+			email,
+			getDisplayName(ownerID))
+		if err != nil {
+			log.Printf("Error sending emergency denial email: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// GetKeys returns the owner's vault root folder key that was wrapped for the
+// contact, once the contact has been granted access
+func GetKeys(contactID, ownerID string) (shared.EmergencyKeyResponse, error) {
+	return db.GetEmergencyKeys(ownerID, contactID)
+}
+
+// getUserID returns the ID of the user matching an email or account ID
+func getUserID(identifier string) (string, error) {
+	identifier = strings.TrimSpace(identifier)
+	if len(identifier) == 0 {
+		return "", InvalidRequestErr
+	} else if strings.Contains(identifier, "@") {
+		userID, err := db.GetUserIDByEmail(identifier)
+		if err != nil {
+			return "", err
+		} else if len(userID) == 0 {
+			return "", UserNotFoundErr
+		}
+
+		return userID, nil
+	}
+
+	_, err := db.GetUserByID(identifier)
+	if err != nil {
+		return "", UserNotFoundErr
+	}
+
+	return identifier, nil
+}
+
+// getDisplayName returns the user's email, or their account ID if they
+// signed up without an email
+func getDisplayName(userID string) string {
+	email, err := db.GetUserEmailByID(userID)
+	if err != nil || len(email) == 0 {
+		return userID
+	}
+
+	return email
+}
@@ -0,0 +1,108 @@
+package emergency
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+)
+
+// EmergencyAccessHandler handles fetching the user's emergency contacts and
+// the users who have added them as a contact (GET), and adding a new
+// emergency contact (POST).
+func EmergencyAccessHandler(w http.ResponseWriter, req *http.Request, id string) {
+	var err error
+	switch req.Method {
+	case http.MethodGet:
+		var response shared.EmergencyAccessResponse
+		response, err = GetEmergencyAccess(id)
+		if err == nil {
+			_ = json.NewEncoder(w).Encode(response)
+			return
+		}
+	case http.MethodPost:
+		var contact shared.NewEmergencyContact
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&contact)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = AddContact(id, contact)
+	}
+
+	if err != nil {
+		writeError(w, err, "updating emergency access")
+	}
+}
+
+// ContactHandler handles removing one of the user's emergency contacts
+func ContactHandler(w http.ResponseWriter, req *http.Request, id string) {
+	err := RemoveContact(id, getTrailingID(req))
+	if err != nil {
+		writeError(w, err, "removing emergency contact")
+	}
+}
+
+// DenyHandler handles denying an emergency contact's pending request for
+// access to the user's vault
+func DenyHandler(w http.ResponseWriter, req *http.Request, id string) {
+	err := DenyAccess(id, getTrailingID(req))
+	if err != nil {
+		writeError(w, err, "denying emergency access")
+	}
+}
+
+// GrantorHandler handles fetching the vault keys of a user who granted the
+// current user emergency access (GET), requesting access to their vault
+// (POST), and removing the current user from their emergency contacts
+// (DELETE).
+func GrantorHandler(w http.ResponseWriter, req *http.Request, id string) {
+	ownerID := getTrailingID(req)
+
+	var err error
+	switch req.Method {
+	case http.MethodGet:
+		var keys shared.EmergencyKeyResponse
+		keys, err = GetKeys(id, ownerID)
+		if err == nil {
+			_ = json.NewEncoder(w).Encode(keys)
+			return
+		}
+	case http.MethodPost:
+		err = RequestAccess(id, ownerID)
+	case http.MethodDelete:
+		err = LeaveGrantor(id, ownerID)
+	}
+
+	if err != nil {
+		writeError(w, err, "updating emergency access")
+	}
+}
+
+func getTrailingID(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	return segments[len(segments)-1]
+}
+
+// writeError maps emergency access errors to the appropriate response status
+func writeError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, InvalidRequestErr),
+		errors.Is(err, TooManyContactsErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, UserNotFoundErr),
+		errors.Is(err, db.EmergencyAccessNotFoundErr),
+		errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		log.Printf("Error %s: %v\n", action, err)
+		http.Error(w, fmt.Sprintf("Error %s", action), http.StatusInternalServerError)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"yeetfile/backend/db"
 	"yeetfile/backend/server/auth"
 	"yeetfile/backend/server/html/templates"
+	"yeetfile/backend/server/invites"
+	"yeetfile/backend/server/orgs"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/server/upgrades"
 	"yeetfile/shared"
@@ -177,6 +179,7 @@ func SignupPageHandler(w http.ResponseWriter, req *http.Request) {
 			EmailConfigured:        config.YeetFileConfig.Email.Configured,
 			InviteEmail:            inviteEmail,
 			InviteCode:             inviteCode,
+			ApprovalRequired:       config.SignupApprovalRequired,
 		},
 	)
 }
@@ -199,6 +202,25 @@ func LoginPageHandler(w http.ResponseWriter, _ *http.Request) {
 	)
 }
 
+// OIDCPageHandler returns the HTML page for finishing an OpenID Connect login,
+// where the user enters the passphrase that protects their vault keys
+func OIDCPageHandler(w http.ResponseWriter, _ *http.Request) {
+	_ = templates.ServeTemplate(
+		w,
+		templates.OIDCHTML,
+		templates.Template{
+			Base: templates.BaseTemplate{
+				LoggedIn:   false,
+				Title:      "Single Sign-On",
+				Javascript: []string{"oidc.js"},
+				CSS:        []string{"auth.css"},
+				Config:     config.HTMLConfig,
+				Endpoints:  endpoints.HTMLPageEndpoints,
+			},
+		},
+	)
+}
+
 // AccountPageHandler returns the HTML page for a user managing their account
 func AccountPageHandler(w http.ResponseWriter, req *http.Request, userID string) {
 	user, err := db.GetUserByID(userID)
@@ -212,7 +234,40 @@ func AccountPageHandler(w http.ResponseWriter, req *http.Request, userID string)
 	obscuredEmail, _ := shared.ObscureEmail(user.Email)
 	isPrevUpgraded := user.UpgradeExp.Year() >= 2024
 
-	isAdmin := auth.IsInstanceAdmin(userID)
+	isAdmin := auth.IsAdmin(userID)
+
+	var usageWarnings []string
+	usage, err := db.GetUserUsage(userID)
+	if err == nil {
+		for _, warning := range usage.UsageWarnings {
+			usageWarnings = append(usageWarnings, shared.FormatUsageWarning(warning))
+		}
+	}
+
+	var purchaseSummaries []templates.PurchaseSummary
+	purchases, err := auth.GetBillingHistory(userID)
+	if err != nil {
+		log.Printf("Error fetching billing history: %v\n", err)
+	}
+
+	for _, purchase := range purchases {
+		purchaseSummaries = append(purchaseSummaries, generatePurchaseSummary(purchase))
+	}
+
+	orgSummary, err := generateOrgSummary(userID)
+	if err != nil {
+		log.Printf("Error fetching organization: %v\n", err)
+	}
+
+	inviteSummary, err := generateInviteSummary(userID)
+	if err != nil {
+		log.Printf("Error fetching invite links: %v\n", err)
+	}
+
+	recoveryStatus, err := auth.GetRecoveryStatus(userID)
+	if err != nil {
+		log.Printf("Error fetching recovery status: %v\n", err)
+	}
 
 	_ = templates.ServeTemplate(
 		w,
@@ -240,11 +295,52 @@ func AccountPageHandler(w http.ResponseWriter, req *http.Request, userID string)
 			StorageUsed:      shared.ReadableFileSize(user.StorageUsed),
 			HasPasswordHint:  hasHint,
 			Has2FA:           user.Secret != nil && len(user.Secret) > 0,
+			HasRecoveryKey:   recoveryStatus.Enabled,
 			ErrorMessage:     errorMsg,
 			SuccessMessage:   successMsg,
 			IsAdmin:          isAdmin,
 			MaxSendDownloads: config.YeetFileConfig.MaxSendDownloads,
 			MaxSendExpiry:    config.YeetFileConfig.MaxSendExpiry,
+			UsageWarnings:    usageWarnings,
+			Purchases:        purchaseSummaries,
+			Org:              orgSummary,
+			Invites:          inviteSummary,
+		},
+	)
+}
+
+// ReceiptPageHandler displays a printable receipt for one of the user's
+// previous purchases, or payment instructions for an unpaid invoice.
+func ReceiptPageHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	segments := strings.Split(req.URL.Path, "/")
+	invoiceID := segments[len(segments)-1]
+
+	purchase, err := auth.GetBillingPurchase(userID, invoiceID)
+	if err != nil {
+		handleError(w, "Receipt not found", http.StatusNotFound)
+		return
+	}
+
+	var instructions string
+	if purchase.Status == db.InvoicePending {
+		instructions = config.YeetFileConfig.ManualBilling.Instructions
+	}
+
+	_ = templates.ServeTemplate(
+		w,
+		templates.ReceiptHTML,
+		templates.ReceiptTemplate{
+			Base: templates.BaseTemplate{
+				LoggedIn:  true,
+				Title:     "Receipt",
+				Page:      "account",
+				CSS:       []string{"account.css"},
+				Config:    config.HTMLConfig,
+				Endpoints: endpoints.HTMLPageEndpoints,
+			},
+			Purchase:     generatePurchaseSummary(purchase),
+			Instructions: instructions,
+			SupportEmail: config.YeetFileConfig.Email.Address,
 		},
 	)
 }
@@ -268,6 +364,12 @@ func UpgradePageHandler(w http.ResponseWriter, req *http.Request, userID string)
 		user.UpgradeExp.Year() >= 2024 &&
 		time.Now().Before(user.UpgradeExp)
 
+	var orgName string
+	if _, err = db.GetOrgPaymentID(userID); err == nil {
+		org, _ := db.GetUserOrganization(userID)
+		orgName = org.Name
+	}
+
 	_ = templates.ServeTemplate(
 		w,
 		templates.UpgradeHTML,
@@ -286,6 +388,7 @@ func UpgradePageHandler(w http.ResponseWriter, req *http.Request, userID string)
 			BillingEndpoints: endpoints.BillingPageEndpoints,
 			SendUpgrades:     upgrades.GetAllUpgrades().SendUpgrades,
 			VaultUpgrades:    vaultUpgrades,
+			OrgName:          orgName,
 
 			ShowVaultUpgradeNote: showVaultUpgradeNote,
 		},
@@ -461,6 +564,44 @@ func ForgotPageHandler(w http.ResponseWriter, req *http.Request) {
 	)
 }
 
+// RecoverPageHandler returns the HTML page for resetting a user's password
+// with their account recovery key
+func RecoverPageHandler(w http.ResponseWriter, _ *http.Request) {
+	_ = templates.ServeTemplate(
+		w,
+		templates.RecoverHTML,
+		templates.Template{
+			Base: templates.BaseTemplate{
+				LoggedIn:   false,
+				Title:      "Recover Account",
+				Javascript: []string{"recover.js"},
+				CSS:        []string{"auth.css"},
+				Config:     config.HTMLConfig,
+				Endpoints:  endpoints.HTMLPageEndpoints,
+			},
+		},
+	)
+}
+
+// RecoveryKeyPageHandler returns the HTML page for generating or removing a
+// user's account recovery key
+func RecoveryKeyPageHandler(w http.ResponseWriter, _ *http.Request, _ string) {
+	_ = templates.ServeTemplate(
+		w,
+		templates.RecoveryKeyHTML,
+		templates.Template{
+			Base: templates.BaseTemplate{
+				LoggedIn:   true,
+				Title:      "Recovery Key",
+				Javascript: []string{"recovery_key.js"},
+				CSS:        []string{"change.css"},
+				Config:     config.HTMLConfig,
+				Endpoints:  endpoints.HTMLPageEndpoints,
+			},
+		},
+	)
+}
+
 func CheckoutCompleteHandler(w http.ResponseWriter, req *http.Request) {
 	from := req.URL.Query().Get("from")
 
@@ -500,7 +641,21 @@ func AdminPageHandler(w http.ResponseWriter, _ *http.Request, id string) {
 		pendingInvites []string
 	)
 
-	if config.InvitesAllowed {
+	role := auth.GetAdminRole(id)
+	canView := func(permission auth.AdminPermission) bool {
+		return auth.HasAdminPermission(role, permission, true)
+	}
+
+	permissions := templates.AdminPermissions{
+		Users:   canView(auth.UserAdminPermission),
+		Files:   canView(auth.FileAdminPermission),
+		Billing: canView(auth.BillingAdminPermission),
+		Stats:   canView(auth.StatsAdminPermission),
+		Roles:   canView(auth.RoleAdminPermission),
+		Secrets: canView(auth.SecretAdminPermission),
+	}
+
+	if config.InvitesAllowed && permissions.Users {
 		pendingInvites, err = db.GetInvitesList()
 		if err != nil {
 			log.Printf("Error fetching pending invites: %v\n", err)
@@ -519,8 +674,12 @@ func AdminPageHandler(w http.ResponseWriter, _ *http.Request, id string) {
 				Config:     config.HTMLConfig,
 				Endpoints:  endpoints.HTMLPageEndpoints,
 			},
-			InvitesAllowed: config.InvitesAllowed,
-			PendingInvites: pendingInvites,
+			Role:             role,
+			Permissions:      permissions,
+			InvitesAllowed:   config.InvitesAllowed,
+			PendingInvites:   pendingInvites,
+			ApprovalRequired: config.SignupApprovalRequired,
+			Upgrades:         upgrades.GetAllUpgrades(),
 		},
 	)
 }
@@ -548,6 +707,113 @@ func generateAccountMessages(req *http.Request) (string, string) {
 	return successMsg, errorMsg
 }
 
+// generatePurchaseSummary converts a purchase into readable values for
+// displaying in the account page and receipts.
+func generatePurchaseSummary(purchase shared.BillingPurchase) templates.PurchaseSummary {
+	summary := templates.PurchaseSummary{
+		ID:         purchase.ID,
+		Provider:   purchase.Provider,
+		Items:      purchase.Items,
+		Amount:     shared.FormatCents(purchase.Amount),
+		Status:     strings.ReplaceAll(purchase.Status, "_", " "),
+		Date:       purchase.Date.Format("2 Jan 2006"),
+		ReceiptURL: endpoints.HTMLReceipt.Format("", purchase.ID),
+	}
+
+	if purchase.Refunded > 0 {
+		summary.Refunded = shared.FormatCents(purchase.Refunded)
+	}
+
+	if !purchase.Expiration.IsZero() {
+		summary.Expiration = purchase.Expiration.Format("2 Jan 2006")
+	}
+
+	return summary
+}
+
+// generateOrgSummary converts the user's organization into readable values
+// for displaying in the account page.
+func generateOrgSummary(userID string) (templates.OrgSummary, error) {
+	org, err := orgs.GetOrganization(userID)
+	if err != nil {
+		return templates.OrgSummary{}, err
+	}
+
+	summary := templates.OrgSummary{
+		HasOrg:           org.HasOrg,
+		IsManager:        len(org.Members) > 0,
+		Name:             org.Organization.Name,
+		Role:             org.Organization.Role,
+		StorageUsed:      shared.ReadableFileSize(org.Organization.StorageUsed),
+		StorageAvailable: shared.ReadableFileSize(org.Organization.StorageAvailable),
+		SendUsed:         shared.ReadableFileSize(org.Organization.SendUsed),
+		SendAvailable:    shared.ReadableFileSize(org.Organization.SendAvailable),
+		SentInvites:      org.SentInvites,
+		PendingInvites:   org.PendingInvites,
+	}
+
+	readableCap := func(limit int64) string {
+		if limit == 0 {
+			return "No cap"
+		}
+
+		return shared.ReadableFileSize(limit)
+	}
+
+	for _, member := range org.Members {
+		email := member.Email
+		if len(email) == 0 {
+			email = member.UserID
+		}
+
+		summary.Members = append(summary.Members, templates.OrgMemberSummary{
+			UserID:          member.UserID,
+			Email:           email,
+			Role:            member.Role,
+			IsOwner:         member.Role == db.OrgRoleOwner,
+			StorageUsed:     shared.ReadableFileSize(member.StorageUsed),
+			StorageCap:      readableCap(member.StorageCap),
+			SendUsed:        shared.ReadableFileSize(member.SendUsed),
+			SendCap:         readableCap(member.SendCap),
+			StorageCapBytes: member.StorageCap,
+			SendCapBytes:    member.SendCap,
+		})
+	}
+
+	return summary, nil
+}
+
+// generateInviteSummary converts the user's invite allowance and links into
+// readable values for displaying in the account page.
+func generateInviteSummary(userID string) (templates.InviteSummary, error) {
+	if !config.InvitesAllowed {
+		return templates.InviteSummary{}, nil
+	}
+
+	accountInvites, err := invites.GetAccountInvites(userID)
+	if err != nil {
+		return templates.InviteSummary{}, err
+	}
+
+	summary := templates.InviteSummary{
+		Enabled:   accountInvites.Allowance > 0 || accountInvites.Used > 0,
+		Allowance: accountInvites.Allowance,
+		Used:      accountInvites.Used,
+	}
+
+	for _, link := range accountInvites.Links {
+		summary.Links = append(summary.Links, templates.InviteLinkSummary{
+			ID:         link.ID,
+			Uses:       link.Uses,
+			MaxUses:    link.MaxUses,
+			Expiration: link.Expiration.Format("2 Jan 2006"),
+			Expired:    link.Expiration.Before(time.Now()),
+		})
+	}
+
+	return summary, nil
+}
+
 func handleError(w http.ResponseWriter, msg string, status int) {
 	w.WriteHeader(status)
 	_, _ = w.Write([]byte(msg))
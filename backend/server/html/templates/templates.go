@@ -18,9 +18,12 @@ const (
 	VerificationHTML     = "verify.html"
 	SignupHTML           = "signup.html"
 	LoginHTML            = "login.html"
+	OIDCHTML             = "oidc.html"
 	AccountHTML          = "account.html"
 	UpgradeHTML          = "upgrade.html"
 	ForgotHTML           = "forgot.html"
+	RecoverHTML          = "recover.html"
+	RecoveryKeyHTML      = "recovery_key.html"
 	ChangeEmailHTML      = "change_email.html"
 	ChangePasswordHTML   = "change_password.html"
 	ChangeHintHTML       = "change_hint.html"
@@ -28,6 +31,7 @@ const (
 	ServerInfoHTML       = "server_info.html"
 	CheckoutCompleteHTML = "checkout_complete.html"
 	AdminHTML            = "admin.html"
+	ReceiptHTML          = "receipt.html"
 )
 
 //go:embed *.html
@@ -58,6 +62,7 @@ type SignupTemplate struct {
 	EmailConfigured        bool
 	InviteEmail            string
 	InviteCode             string
+	ApprovalRequired       bool
 }
 
 type LoginTemplate struct {
@@ -109,9 +114,24 @@ type CheckoutCompleteTemplate struct {
 }
 
 type AdminTemplate struct {
-	Base           BaseTemplate
-	InvitesAllowed bool
-	PendingInvites []string
+	Base             BaseTemplate
+	Role             string
+	Permissions      AdminPermissions
+	InvitesAllowed   bool
+	PendingInvites   []string
+	ApprovalRequired bool
+	Upgrades         *shared.Upgrades
+}
+
+// AdminPermissions indicates which sections of the admin page can be viewed
+// by the current admin
+type AdminPermissions struct {
+	Users   bool
+	Files   bool
+	Billing bool
+	Stats   bool
+	Roles   bool
+	Secrets bool
 }
 
 type AccountTemplate struct {
@@ -132,11 +152,79 @@ type AccountTemplate struct {
 	BillingConfigured bool
 	HasPasswordHint   bool
 	Has2FA            bool
+	HasRecoveryKey    bool
 	ErrorMessage      string
 	SuccessMessage    string
 	IsAdmin           bool
 	MaxSendDownloads  int
 	MaxSendExpiry     int
+	UsageWarnings     []string
+	Purchases         []PurchaseSummary
+	Org               OrgSummary
+	Invites           InviteSummary
+}
+
+type OrgSummary struct {
+	HasOrg           bool
+	IsManager        bool
+	Name             string
+	Role             string
+	StorageUsed      string
+	StorageAvailable string
+	SendUsed         string
+	SendAvailable    string
+	Members          []OrgMemberSummary
+	SentInvites      []shared.OrgInvite
+	PendingInvites   []shared.OrgInvite
+}
+
+type OrgMemberSummary struct {
+	UserID      string
+	Email       string
+	Role        string
+	IsOwner     bool
+	StorageUsed string
+	StorageCap  string
+	SendUsed    string
+	SendCap     string
+
+	// Raw values used when editing a member's caps
+	StorageCapBytes int64
+	SendCapBytes    int64
+}
+
+type InviteSummary struct {
+	Enabled   bool
+	Allowance int
+	Used      int
+	Links     []InviteLinkSummary
+}
+
+type InviteLinkSummary struct {
+	ID         string
+	Uses       int
+	MaxUses    int
+	Expiration string
+	Expired    bool
+}
+
+type PurchaseSummary struct {
+	ID         string
+	Provider   string
+	Items      []shared.BillingItem
+	Amount     string
+	Refunded   string
+	Status     string
+	Date       string
+	Expiration string
+	ReceiptURL string
+}
+
+type ReceiptTemplate struct {
+	Base         BaseTemplate
+	Purchase     PurchaseSummary
+	Instructions string
+	SupportEmail string
 }
 
 type UpgradeTemplate struct {
@@ -147,6 +235,7 @@ type UpgradeTemplate struct {
 	BillingEndpoints endpoints.BillingEndpoints
 	SendUpgrades     []*shared.Upgrade
 	VaultUpgrades    []*shared.Upgrade
+	OrgName          string
 
 	ShowVaultUpgradeNote bool
 }
@@ -0,0 +1,103 @@
+package invites
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+)
+
+// AdminLinksHandler handles listing every invite link (GET) and creating a
+// new invite link (POST) for admins
+func AdminLinksHandler(w http.ResponseWriter, req *http.Request, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		links, err := db.GetInviteLinks()
+		if err != nil {
+			writeError(w, err, "fetching invite links")
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(links)
+	case http.MethodPost:
+		var options shared.CreateInviteLink
+		err := utils.LimitedJSONReader(w, req.Body).Decode(&options)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		link, err := CreateLink(id, options)
+		if err != nil {
+			writeError(w, err, "creating invite link")
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(link)
+	}
+}
+
+// AdminLinkActionHandler handles deleting an invite link for admins
+func AdminLinkActionHandler(w http.ResponseWriter, req *http.Request, id string) {
+	segments := strings.Split(req.URL.Path, "/")
+	linkID := segments[len(segments)-1]
+
+	err := DeleteLink(linkID, id, true)
+	if err != nil {
+		writeError(w, err, "deleting invite link")
+	}
+}
+
+// AccountInvitesHandler handles fetching the user's invite allowance and links
+// (GET) and creating a new invite link (POST)
+func AccountInvitesHandler(w http.ResponseWriter, req *http.Request, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		invites, err := GetAccountInvites(id)
+		if err != nil {
+			writeError(w, err, "fetching invites")
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(invites)
+	case http.MethodPost:
+		link, err := CreateUserLink(id)
+		if err != nil {
+			writeError(w, err, "creating invite link")
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(link)
+	}
+}
+
+// AccountInviteActionHandler handles deleting one of the user's unused invite
+// links
+func AccountInviteActionHandler(w http.ResponseWriter, req *http.Request, id string) {
+	segments := strings.Split(req.URL.Path, "/")
+	linkID := segments[len(segments)-1]
+
+	err := DeleteLink(linkID, id, false)
+	if err != nil {
+		writeError(w, err, "deleting invite link")
+	}
+}
+
+// writeError maps invite errors to the appropriate response status
+func writeError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, InvitesDisabledErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, AllowanceExceededErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, InvalidOptionsErr), errors.Is(err, InvalidLinkErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error %s: %v\n", action, err)
+		http.Error(w, "Error "+action, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,207 @@
+// Package invites handles invite links, which allow new users to sign up for
+// an instance without the server password or an email-specific invite.
+package invites
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"time"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/shared"
+)
+
+const (
+	codeLength = 24
+	idLength   = 16
+
+	// maxLinkUses is the highest number of uses an admin can assign to a
+	// single invite link
+	maxLinkUses = 1000
+
+	// userLinkExpiration is how long invite links created by regular users
+	// remain valid
+	userLinkExpiration = time.Hour * 24 * 7
+)
+
+var (
+	InvitesDisabledErr   = errors.New("invites are not enabled on this instance")
+	AllowanceExceededErr = errors.New("no remaining invites")
+	InvalidOptionsErr    = errors.New("invalid invite link options")
+	InvalidLinkErr       = errors.New("invalid or expired invite link")
+)
+
+// CreateLink creates a new invite link on behalf of an admin, using the
+// provided number of uses and (optional) expiration. The returned link
+// contains the code needed to sign up, which is not retrievable later.
+func CreateLink(adminID string, options shared.CreateInviteLink) (shared.InviteLink, error) {
+	if !config.InvitesAllowed {
+		return shared.InviteLink{}, InvitesDisabledErr
+	}
+
+	if options.MaxUses < 1 || options.MaxUses > maxLinkUses {
+		return shared.InviteLink{}, InvalidOptionsErr
+	} else if !options.Expiration.IsZero() && options.Expiration.Before(time.Now()) {
+		return shared.InviteLink{}, InvalidOptionsErr
+	}
+
+	return createLink(adminID, options.MaxUses, options.Expiration)
+}
+
+// CreateUserLink creates a new single-use invite link on behalf of a regular
+// user, as long as the user hasn't used up their invite allowance.
+func CreateUserLink(userID string) (shared.InviteLink, error) {
+	invites, err := GetAccountInvites(userID)
+	if err != nil {
+		return shared.InviteLink{}, err
+	} else if invites.Used >= invites.Allowance {
+		return shared.InviteLink{}, AllowanceExceededErr
+	}
+
+	expiration := time.Now().Add(userLinkExpiration)
+	return createLink(userID, 1, expiration)
+}
+
+// GetAccountInvites returns the user's invite allowance and the invite links
+// that they've created
+func GetAccountInvites(userID string) (shared.AccountInvitesResponse, error) {
+	if !config.InvitesAllowed {
+		return shared.AccountInvitesResponse{}, InvitesDisabledErr
+	}
+
+	allowance, err := GetAllowance(userID)
+	if err != nil {
+		return shared.AccountInvitesResponse{}, err
+	}
+
+	links, err := db.GetUserInviteLinks(userID)
+	if err != nil {
+		return shared.AccountInvitesResponse{}, err
+	}
+
+	used := 0
+	for _, link := range links {
+		// Links that expired without being used don't count against the
+		// user's allowance
+		expired := !link.Expiration.IsZero() && link.Expiration.Before(time.Now())
+		if link.Uses > 0 || !expired {
+			used++
+		}
+	}
+
+	return shared.AccountInvitesResponse{
+		Allowance: allowance,
+		Used:      used,
+		Links:     links,
+	}, nil
+}
+
+// GetAllowance returns the number of invite links the user is allowed to
+// create, which is either set per-user by an admin or uses the
+// YEETFILE_USER_INVITE_ALLOWANCE default.
+func GetAllowance(userID string) (int, error) {
+	allowance, isSet, err := db.GetUserInviteAllowance(userID)
+	if err != nil {
+		return 0, err
+	} else if !isSet {
+		return config.UserInviteAllowance, nil
+	}
+
+	return allowance, nil
+}
+
+// DeleteLink removes an invite link. Admins can remove any link, but regular
+// users can only remove their own links that haven't been used yet.
+func DeleteLink(linkID, userID string, isAdmin bool) error {
+	if isAdmin {
+		return db.DeleteInviteLink(linkID)
+	}
+
+	err := db.DeleteUnusedInviteLink(linkID, userID)
+	if err == sql.ErrNoRows {
+		return InvalidLinkErr
+	}
+
+	return err
+}
+
+// CheckLink returns the hash of the provided invite link code if it matches a
+// link with remaining uses, without using up one of those uses. The hash is
+// stored with the pending signup and claimed with ClaimLink once the account
+// is created. Returns InvalidLinkErr if the code doesn't match a valid link.
+func CheckLink(code string) ([]byte, error) {
+	if !config.InvitesAllowed || len(code) == 0 {
+		return nil, InvalidLinkErr
+	}
+
+	codeHash := hashCode(code)
+	available, err := db.IsInviteLinkAvailable(codeHash)
+	if err != nil {
+		return nil, err
+	} else if !available {
+		return nil, InvalidLinkErr
+	}
+
+	return codeHash, nil
+}
+
+// ClaimLink uses up one of the remaining uses of the invite link matching the
+// code hash returned by CheckLink. Returns InvalidLinkErr if the link has
+// expired or been used up since it was checked.
+func ClaimLink(codeHash []byte) error {
+	err := db.ClaimInviteLink(codeHash)
+	if err == sql.ErrNoRows {
+		return InvalidLinkErr
+	}
+
+	return err
+}
+
+// ReleaseLink returns a use to an invite link previously claimed with
+// ClaimLink, for when the account it was claimed for couldn't be created.
+func ReleaseLink(codeHash []byte) error {
+	return db.ReleaseInviteLink(codeHash)
+}
+
+func createLink(createdBy string, maxUses int, expiration time.Time) (shared.InviteLink, error) {
+	code, err := generateCode()
+	if err != nil {
+		return shared.InviteLink{}, err
+	}
+
+	link := shared.InviteLink{
+		ID:         shared.GenRandomString(idLength),
+		Code:       code,
+		CreatedBy:  createdBy,
+		MaxUses:    maxUses,
+		Expiration: expiration,
+		Created:    time.Now().UTC(),
+	}
+
+	err = db.CreateInviteLink(link, hashCode(code))
+	if err != nil {
+		return shared.InviteLink{}, err
+	}
+
+	return link, nil
+}
+
+// generateCode returns a random url-safe invite code. Codes have enough
+// entropy that a fast hash is sufficient for storing them.
+func generateCode() (string, error) {
+	code := make([]byte, codeLength)
+	_, err := rand.Read(code)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(code), nil
+}
+
+func hashCode(code string) []byte {
+	hash := sha256.Sum256([]byte(code))
+	return hash[:]
+}
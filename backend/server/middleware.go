@@ -8,7 +8,9 @@ import (
 	"sync"
 	"time"
 	"yeetfile/backend/config"
+	"yeetfile/backend/db"
 	"yeetfile/backend/server/auth"
+	"yeetfile/backend/server/payments/billing"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/utils"
 	"yeetfile/shared/endpoints"
@@ -101,6 +103,9 @@ func AuthMiddleware(next session.HandlerFunc) http.HandlerFunc {
 			id, err := session.GetSessionAndUserID(req)
 			if err != nil {
 				return
+			} else if db.IsUserSuspended(id) {
+				http.Error(w, auth.SuspendedMsg, http.StatusForbidden)
+				return
 			}
 
 			next(w, req, id)
@@ -123,9 +128,33 @@ func AuthMiddleware(next session.HandlerFunc) http.HandlerFunc {
 	return handler
 }
 
-// AdminMiddleware enforces that particular requests are only performed by those
-// marked as "admin" in the database.
-func AdminMiddleware(next session.HandlerFunc) http.HandlerFunc {
+// EmergencyAccessMiddleware allows a user who has been granted emergency access
+// to another user's vault to view it, by including the owner's ID in the
+// "owner" query param of a GET request. The request is handled as if it were
+// made by the owner. Requests without the param are handled normally.
+func EmergencyAccessMiddleware(next session.HandlerFunc) session.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, id string) {
+		ownerID := req.URL.Query().Get("owner")
+		if len(ownerID) == 0 {
+			next(w, req, id)
+			return
+		}
+
+		if req.Method != http.MethodGet ||
+			!db.HasEmergencyAccess(ownerID, id) ||
+			db.IsUserSuspended(ownerID) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next(w, req, ownerID)
+	}
+}
+
+// AdminMiddleware enforces that particular requests are only performed by
+// admins whose role grants the required permission. Auditors are only able to
+// perform GET requests.
+func AdminMiddleware(permission auth.AdminPermission, next session.HandlerFunc) http.HandlerFunc {
 	handler := func(w http.ResponseWriter, req *http.Request) {
 		if session.IsValidSession(w, req) {
 			id, err := session.GetSessionAndUserID(req)
@@ -133,11 +162,15 @@ func AdminMiddleware(next session.HandlerFunc) http.HandlerFunc {
 				return
 			}
 
-			isAdmin := auth.IsInstanceAdmin(id)
-			if isAdmin {
+			role := auth.GetAdminRole(id)
+			readOnly := req.Method == http.MethodGet
+			if auth.HasAdminPermission(role, permission, readOnly) {
 				// Call the next handler
 				next(w, req, id)
 				return
+			} else if len(role) > 0 {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
 			}
 		}
 
@@ -201,6 +234,9 @@ func AuthLimiterMiddleware(next session.HandlerFunc) http.HandlerFunc {
 			id, err := session.GetSessionAndUserID(req)
 			if err != nil {
 				return
+			} else if db.IsUserSuspended(id) {
+				http.Error(w, auth.SuspendedMsg, http.StatusForbidden)
+				return
 			}
 
 			limiter := getVisitor(id, req.URL.Path)
@@ -223,26 +259,11 @@ func AuthLimiterMiddleware(next session.HandlerFunc) http.HandlerFunc {
 	return handler
 }
 
-// StripeMiddleware ensures that requests made to Stripe related endpoints are
-// only processed if Stripe has been set up already.
-func StripeMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	handler := func(w http.ResponseWriter, req *http.Request) {
-		if !config.YeetFileConfig.StripeBilling.Configured {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-
-		next(w, req)
-	}
-
-	return handler
-}
-
-// BTCPayMiddleware ensures that requests made to BTCPay related endpoints are
-// only processed if BTCPay has been set up already.
-func BTCPayMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// BillingMiddleware ensures that requests made to a payment provider's
+// endpoints are only processed if the provider has been set up already.
+func BillingMiddleware(provider billing.Provider, next http.HandlerFunc) http.HandlerFunc {
 	handler := func(w http.ResponseWriter, req *http.Request) {
-		if !config.YeetFileConfig.BTCPayBilling.Configured {
+		if !provider.Configured() {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -0,0 +1,122 @@
+package orgs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/utils"
+	"yeetfile/shared"
+)
+
+// OrganizationHandler handles fetching the user's organization (GET),
+// creating a new organization (POST), and leaving the organization (DELETE).
+func OrganizationHandler(w http.ResponseWriter, req *http.Request, id string) {
+	var err error
+	switch req.Method {
+	case http.MethodGet:
+		var response shared.OrgResponse
+		response, err = GetOrganization(id)
+		if err == nil {
+			_ = json.NewEncoder(w).Encode(response)
+			return
+		}
+	case http.MethodPost:
+		var request shared.CreateOrgRequest
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&request)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = Create(id, request)
+	case http.MethodDelete:
+		err = RemoveMember(id, id)
+	}
+
+	if err != nil {
+		writeError(w, err, "updating organization")
+	}
+}
+
+// InvitesHandler handles inviting a new member to the organization by email
+func InvitesHandler(w http.ResponseWriter, req *http.Request, id string) {
+	var request shared.OrgInviteRequest
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err = Invite(id, request)
+	if err != nil {
+		writeError(w, err, "inviting organization member")
+	}
+}
+
+// InviteActionHandler handles accepting (PUT) or declining/cancelling
+// (DELETE) an organization invite
+func InviteActionHandler(w http.ResponseWriter, req *http.Request, id string) {
+	segments := strings.Split(req.URL.Path, "/")
+	inviteID := segments[len(segments)-1]
+
+	var err error
+	switch req.Method {
+	case http.MethodPut:
+		err = AcceptInvite(id, inviteID)
+	case http.MethodDelete:
+		err = DeleteInvite(id, inviteID)
+	}
+
+	if err != nil {
+		writeError(w, err, "updating organization invite")
+	}
+}
+
+// MemberHandler handles updating a member's role and caps (PUT) or removing
+// a member from the organization (DELETE)
+func MemberHandler(w http.ResponseWriter, req *http.Request, id string) {
+	segments := strings.Split(req.URL.Path, "/")
+	memberID := segments[len(segments)-1]
+
+	var err error
+	switch req.Method {
+	case http.MethodPut:
+		var update shared.OrgMemberUpdate
+		err = utils.LimitedJSONReader(w, req.Body).Decode(&update)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		err = UpdateMember(id, memberID, update)
+	case http.MethodDelete:
+		err = RemoveMember(id, memberID)
+	}
+
+	if err != nil {
+		writeError(w, err, "updating organization member")
+	}
+}
+
+// writeError maps organization errors to the appropriate response status
+func writeError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, NotOrgAdminErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, InvalidRequestErr),
+		errors.Is(err, OrgNotEmptyErr),
+		errors.Is(err, db.OrgMemberExistsErr),
+		errors.Is(err, db.InvalidOrgInviteErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, db.OrgNotFoundErr), errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		log.Printf("Error %s: %v\n", action, err)
+		http.Error(w, fmt.Sprintf("Error %s", action), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,245 @@
+package orgs
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/shared"
+)
+
+const maxOrgNameLen = 64
+
+var (
+	NotOrgAdminErr    = errors.New("not permitted to manage this organization")
+	InvalidRequestErr = errors.New("invalid organization request")
+	OrgNotEmptyErr    = errors.New("remove all other members before leaving")
+)
+
+// GetOrganization returns the organization that the user belongs to, as well
+// as any pending invites sent to the user. Owners and admins also receive the
+// list of members and the invites sent by the organization.
+func GetOrganization(userID string) (shared.OrgResponse, error) {
+	response := shared.OrgResponse{
+		Members:        []shared.OrgMember{},
+		SentInvites:    []shared.OrgInvite{},
+		PendingInvites: []shared.OrgInvite{},
+	}
+
+	email, err := db.GetUserEmailByID(userID)
+	if err != nil {
+		return shared.OrgResponse{}, err
+	} else if len(email) > 0 {
+		response.PendingInvites, err = db.GetOrgInvitesByEmail(email)
+		if err != nil {
+			return shared.OrgResponse{}, err
+		}
+	}
+
+	org, err := db.GetUserOrganization(userID)
+	if errors.Is(err, db.OrgNotFoundErr) {
+		return response, nil
+	} else if err != nil {
+		return shared.OrgResponse{}, err
+	}
+
+	response.HasOrg = true
+	response.Organization = org
+
+	if !isOrgAdmin(org.Role) {
+		return response, nil
+	}
+
+	response.Members, err = db.GetOrgMembers(org.ID)
+	if err != nil {
+		return shared.OrgResponse{}, err
+	}
+
+	response.SentInvites, err = db.GetOrgInvites(org.ID)
+	if err != nil {
+		return shared.OrgResponse{}, err
+	}
+
+	return response, nil
+}
+
+// Create creates a new organization owned by the user
+func Create(userID string, request shared.CreateOrgRequest) error {
+	name := strings.TrimSpace(request.Name)
+	if len(name) == 0 || len(name) > maxOrgNameLen {
+		return InvalidRequestErr
+	}
+
+	_, err := db.CreateOrganization(name, userID)
+	return err
+}
+
+// Invite sends an invite to join the user's organization to an email address.
+// Only owners are able to invite new admins.
+func Invite(userID string, request shared.OrgInviteRequest) error {
+	org, err := getManagedOrg(userID)
+	if err != nil {
+		return err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if !strings.Contains(email, "@") {
+		return InvalidRequestErr
+	}
+
+	role := request.Role
+	if len(role) == 0 {
+		role = db.OrgRoleMember
+	}
+
+	if !db.IsValidOrgRole(role) {
+		return InvalidRequestErr
+	} else if role == db.OrgRoleAdmin && org.Role != db.OrgRoleOwner {
+		return NotOrgAdminErr
+	}
+
+	_, err = db.CreateOrgInvite(org.ID, email, role)
+	if err != nil {
+		return err
+	}
+
+	err = mail.SendOrgInviteEmail(org.Name, email)
+	if err != nil {
+		log.Printf("Error sending org invite email: %v\n", err)
+	}
+
+	return nil
+}
+
+// AcceptInvite adds the user to the organization that sent the invite
+func AcceptInvite(userID, inviteID string) error {
+	email, err := db.GetUserEmailByID(userID)
+	if err != nil {
+		return err
+	} else if len(email) == 0 {
+		return db.InvalidOrgInviteErr
+	}
+
+	return db.AcceptOrgInvite(inviteID, userID, email)
+}
+
+// DeleteInvite removes an invite, either because the recipient declined it or
+// because an org owner/admin cancelled it.
+func DeleteInvite(userID, inviteID string) error {
+	var orgID string
+	org, err := getManagedOrg(userID)
+	if err == nil {
+		orgID = org.ID
+	} else if !errors.Is(err, NotOrgAdminErr) {
+		return err
+	}
+
+	email, err := db.GetUserEmailByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return db.DeleteOrgInvite(inviteID, orgID, email)
+}
+
+// UpdateMember updates a member's role and usage caps. Admins are only able to
+// manage regular members.
+func UpdateMember(userID, memberID string, update shared.OrgMemberUpdate) error {
+	if update.StorageCap < 0 || update.SendCap < 0 ||
+		!db.IsValidOrgRole(update.Role) {
+		return InvalidRequestErr
+	}
+
+	org, err := getManagedOrg(userID)
+	if err != nil {
+		return err
+	}
+
+	err = checkCanManage(org, memberID, update.Role)
+	if err != nil {
+		return err
+	}
+
+	return db.UpdateOrgMember(org.ID, memberID, update)
+}
+
+// RemoveMember removes a member from the user's organization. If the member is
+// the user, they leave the organization instead. Owners can only leave once
+// all other members have been removed, which deletes the organization.
+func RemoveMember(userID, memberID string) error {
+	if userID == memberID {
+		return leave(userID)
+	}
+
+	org, err := getManagedOrg(userID)
+	if err != nil {
+		return err
+	}
+
+	err = checkCanManage(org, memberID, "")
+	if err != nil {
+		return err
+	}
+
+	return db.RemoveOrgMember(org.ID, memberID)
+}
+
+func leave(userID string) error {
+	org, err := db.GetUserOrganization(userID)
+	if err != nil {
+		return err
+	}
+
+	if org.Role != db.OrgRoleOwner {
+		return db.RemoveOrgMember(org.ID, userID)
+	}
+
+	members, err := db.GetOrgMembers(org.ID)
+	if err != nil {
+		return err
+	} else if len(members) > 1 {
+		return OrgNotEmptyErr
+	}
+
+	return db.DeleteOrganization(org.ID)
+}
+
+// checkCanManage ensures that org admins can't modify other admins, or promote
+// members to admin. Owners can manage all members except themselves.
+func checkCanManage(org shared.Organization, memberID, newRole string) error {
+	memberRole, err := db.GetOrgMemberRole(memberID)
+	if err == sql.ErrNoRows || memberRole == db.OrgRoleOwner {
+		return NotOrgAdminErr
+	} else if err != nil {
+		return err
+	}
+
+	if org.Role == db.OrgRoleOwner {
+		return nil
+	} else if memberRole == db.OrgRoleAdmin || newRole == db.OrgRoleAdmin {
+		return NotOrgAdminErr
+	}
+
+	return nil
+}
+
+// getManagedOrg returns the user's organization if the user is an owner or
+// admin of that organization
+func getManagedOrg(userID string) (shared.Organization, error) {
+	org, err := db.GetUserOrganization(userID)
+	if errors.Is(err, db.OrgNotFoundErr) {
+		return shared.Organization{}, NotOrgAdminErr
+	} else if err != nil {
+		return shared.Organization{}, err
+	} else if !isOrgAdmin(org.Role) {
+		return shared.Organization{}, NotOrgAdminErr
+	}
+
+	return org, nil
+}
+
+func isOrgAdmin(role string) bool {
+	return role == db.OrgRoleOwner || role == db.OrgRoleAdmin
+}
@@ -0,0 +1,169 @@
+// Package billing defines the interface implemented by each payment provider,
+// along with the upgrade and refund logic that is shared between providers.
+package billing
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/mail"
+	"yeetfile/backend/server/upgrades"
+	"yeetfile/shared"
+)
+
+var (
+	WebhookUnsupportedErr = errors.New("provider does not support webhooks")
+	InvalidCheckoutErr    = errors.New("invalid upgrade selection for provider")
+)
+
+// Provider is a payment method that users can purchase upgrades with.
+type Provider interface {
+	// Name returns the identifier used for the provider's invoices (i.e.
+	// "stripe")
+	Name() string
+
+	// Configured returns true if the provider has been set up for this
+	// instance
+	Configured() bool
+
+	// CheckoutLink returns a link where the user can pay for the selected
+	// upgrades. Each upgrade's Quantity must already be set.
+	CheckoutLink(selected []shared.Upgrade, paymentID, baseURL string) (string, error)
+
+	// ValidateWebhook reads and authenticates an incoming webhook request,
+	// returning the request payload
+	ValidateWebhook(w http.ResponseWriter, req *http.Request) ([]byte, error)
+
+	// ProcessWebhook applies (or reverses) the upgrades described by a
+	// validated webhook payload
+	ProcessWebhook(payload []byte) error
+
+	// Refund returns an amount (in cents) of an invoice to the user. The
+	// user's upgrades are reduced once the refund has been recorded.
+	Refund(invoice db.Invoice, amount int64) error
+}
+
+// ApplyUpgrade updates the user's vault or send limits using the purchased
+// upgrade, and records the applied values in the provided invoice so that
+// they can be reversed later on.
+func ApplyUpgrade(
+	paymentID string,
+	upgrade shared.Upgrade,
+	quantity int,
+	invoice *db.Invoice,
+) error {
+	var (
+		prepared db.Invoice
+		err      error
+	)
+
+	err = SetInvoiceUpgrade(upgrade, quantity, &prepared)
+	if err != nil {
+		return err
+	}
+
+	if upgrade.IsVaultUpgrade {
+		err = db.SetUserVaultUpgrade(
+			paymentID,
+			prepared.VaultTag,
+			prepared.VaultExp,
+			prepared.VaultBytes)
+
+		invoice.VaultTag = prepared.VaultTag
+		invoice.VaultBytes = prepared.VaultBytes
+		invoice.VaultExp = prepared.VaultExp
+	} else {
+		err = db.SetUserSendUpgrade(paymentID, prepared.SendBytes)
+
+		invoice.SendBytes += prepared.SendBytes
+	}
+
+	if err != nil {
+		log.Printf("Error processing user upgrade: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// SetInvoiceUpgrade sets the values that the purchased upgrade will apply to
+// the user's account in the invoice, without applying them. This is used for
+// invoices that are applied within a db transaction.
+func SetInvoiceUpgrade(
+	upgrade shared.Upgrade,
+	quantity int,
+	invoice *db.Invoice,
+) error {
+	if !upgrade.IsVaultUpgrade {
+		invoice.SendBytes += upgrade.Bytes * int64(quantity)
+		return nil
+	}
+
+	exp, err := upgrades.GetUpgradeExpiration(upgrade, quantity)
+	if err != nil {
+		return err
+	}
+
+	invoice.VaultTag = upgrade.Tag
+	invoice.VaultBytes = upgrade.Bytes
+	invoice.VaultExp = exp
+	return nil
+}
+
+// GetCheckoutTotal returns the total price (in cents) of the selected upgrades
+func GetCheckoutTotal(selected []shared.Upgrade) int64 {
+	var total int64
+	for _, upgrade := range selected {
+		total += upgrade.Price * 100 * int64(max(upgrade.Quantity, 1))
+	}
+
+	return total
+}
+
+// GetInvoiceItems splits an invoice's comma-separated tags and quantities into
+// separate items.
+func GetInvoiceItems(invoice db.Invoice) []shared.BillingItem {
+	items := []shared.BillingItem{}
+	quantities := strings.Split(invoice.Quantities, ",")
+	for i, tag := range strings.Split(invoice.Tag, ",") {
+		if len(tag) == 0 {
+			continue
+		}
+
+		quantity := 1
+		if i < len(quantities) {
+			if val, err := strconv.Atoi(quantities[i]); err == nil && val > 0 {
+				quantity = val
+			}
+		}
+
+		name := tag
+		upgrade, err := upgrades.GetUpgradeByTag(tag, upgrades.GetAllUpgrades())
+		if err == nil {
+			name = upgrade.Name
+		}
+
+		items = append(items, shared.BillingItem{
+			Tag:      tag,
+			Name:     name,
+			Quantity: quantity,
+		})
+	}
+
+	return items
+}
+
+// SendOrderEmail sends an order confirmation to the user matching the payment
+// ID, if they have an email set on their account.
+func SendOrderEmail(paymentID, description string) {
+	email, err := db.GetUserEmailByPaymentID(paymentID)
+	if err == nil && len(email) != 0 {
+		err = mail.CreateOrderEmail(description, email).Send()
+		if err != nil {
+			log.Println("Error sending confirmation email")
+		}
+	}
+}
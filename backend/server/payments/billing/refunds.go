@@ -0,0 +1,110 @@
+package billing
+
+import (
+	"log"
+	"time"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/upgrades"
+)
+
+// RecordRefund updates an invoice after the total amount refunded to the user
+// has increased, shortening or removing the upgrades that were purchased with
+// the invoice in proportion to the total refund.
+func RecordRefund(invoice db.Invoice, refunded int64) error {
+	if refunded <= invoice.Refunded {
+		log.Printf("Ignoring refund for %s (already refunded %d)\n",
+			invoice.InvoiceID,
+			invoice.Refunded)
+		return nil
+	}
+
+	status := db.InvoicePartial
+	if refunded >= invoice.Amount {
+		status = db.InvoiceRefunded
+	}
+
+	if invoice.Status != db.InvoiceDisputed && invoice.Status != db.InvoiceDisputeLost {
+		// Upgrades are already revoked while a payment is disputed
+		adjustment := upgrades.ProrateRefund(
+			GetInvoicePurchase(invoice),
+			invoice.Refunded,
+			refunded,
+			time.Now().UTC())
+		err := ApplyRefundAdjustment(invoice, adjustment)
+		if err != nil {
+			return err
+		}
+	} else {
+		status = invoice.Status
+	}
+
+	return db.UpdateInvoiceStatus(invoice.InvoiceID, status, refunded)
+}
+
+// GetInvoicePurchase converts an invoice into the upgrade values needed for
+// calculating refund adjustments.
+func GetInvoicePurchase(invoice db.Invoice) upgrades.Purchase {
+	purchase := upgrades.Purchase{
+		Amount:    invoice.Amount,
+		Date:      invoice.Date,
+		VaultTag:  invoice.VaultTag,
+		VaultExp:  invoice.VaultExp,
+		SendBytes: invoice.SendBytes,
+	}
+
+	if invoice.VaultExp.IsZero() {
+		return purchase
+	}
+
+	for _, item := range GetInvoiceItems(invoice) {
+		if item.Tag != invoice.VaultTag {
+			continue
+		}
+
+		upgrade, err := upgrades.GetUpgradeByTag(item.Tag, upgrades.GetAllUpgrades())
+		if err == nil {
+			purchase.VaultStart = upgrades.GetUpgradePeriodStart(
+				upgrade,
+				item.Quantity,
+				invoice.VaultExp)
+		}
+	}
+
+	return purchase
+}
+
+// ApplyRefundAdjustment updates the user's vault and send upgrades using the
+// adjustment from a refund or dispute. Vault upgrades are only modified if the
+// user hasn't purchased a newer vault upgrade since the invoice.
+func ApplyRefundAdjustment(invoice db.Invoice, adjustment upgrades.RefundAdjustment) error {
+	if invoice.VaultBytes > 0 {
+		subTag, subExp, err := db.GetUserSubByPaymentID(invoice.PaymentID)
+		if err != nil {
+			log.Printf("Error fetching user sub for refund: %v\n", err)
+			return err
+		}
+
+		if GetInvoicePurchase(invoice).IsCurrentVaultUpgrade(subTag, subExp) {
+			if adjustment.RevokeVault {
+				err = db.RevokeUserVaultUpgrade(invoice.PaymentID)
+			} else {
+				err = db.SetUserVaultUpgradeExp(invoice.PaymentID, adjustment.VaultExp)
+			}
+
+			if err != nil {
+				log.Printf("Error adjusting vault upgrade: %v\n", err)
+				return err
+			}
+		}
+	}
+
+	if adjustment.SendBytes > 0 {
+		err := db.AdjustUserSendUpgrade(invoice.PaymentID, -adjustment.SendBytes)
+		if err != nil {
+			log.Printf("Error adjusting send upgrade: %v\n", err)
+			return err
+		}
+	}
+
+	return nil
+}
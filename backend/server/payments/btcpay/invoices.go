@@ -3,8 +3,8 @@ package btcpay
 import (
 	"log"
 	"strconv"
-	"time"
 	"yeetfile/backend/db"
+	"yeetfile/backend/server/payments/billing"
 	"yeetfile/backend/server/upgrades"
 	"yeetfile/backend/utils"
 )
@@ -57,29 +57,22 @@ func FinalizeInvoice(invoice Invoice) error {
 		return err
 	}
 
-	if upgrade.IsVaultUpgrade {
-		var exp time.Time
-		exp, err = upgrades.GetUpgradeExpiration(upgrade, quantity)
-		if err != nil {
-			return err
-		}
-
-		err = db.SetUserVaultUpgrade(
-			invoice.Metadata.OrderID,
-			orderType,
-			exp,
-			upgrade.Bytes)
-	} else {
-		err = db.SetUserSendUpgrade(
-			invoice.Metadata.OrderID,
-			upgrade.Bytes)
+	record := db.Invoice{
+		InvoiceID:  invoice.InvoiceID,
+		PaymentID:  invoice.Metadata.OrderID,
+		Source:     "btcpay",
+		Tag:        upgrade.Tag,
+		Quantities: strconv.Itoa(quantity),
+		Amount:     upgrade.Price * 100 * int64(quantity),
+		PaymentRef: invoice.InvoiceID,
 	}
 
+	err = billing.ApplyUpgrade(invoice.Metadata.OrderID, upgrade, quantity, &record)
 	if err != nil {
 		log.Println("Error processing BTCPay upgrade in database", err)
 		return err
 	}
 
-	err = db.AddInvoice(invoice.InvoiceID, invoice.Metadata.OrderID, "btcpay")
+	err = db.AddInvoice(record)
 	return err
 }
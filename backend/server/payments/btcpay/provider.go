@@ -0,0 +1,70 @@
+package btcpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/payments/billing"
+	"yeetfile/shared"
+)
+
+var InvalidRequestErr = errors.New("invalid BTCPay webhook request")
+
+// Provider implements billing.Provider using BTCPay Server payment links
+type Provider struct{}
+
+func (Provider) Name() string {
+	return "btcpay"
+}
+
+func (Provider) Configured() bool {
+	return config.YeetFileConfig.BTCPayBilling.Configured
+}
+
+// CheckoutLink returns the BTCPay payment link for a single upgrade, since
+// BTCPay links don't support purchasing multiple products at once.
+func (Provider) CheckoutLink(
+	selected []shared.Upgrade,
+	paymentID string,
+	_ string,
+) (string, error) {
+	if len(selected) != 1 || len(selected[0].BTCPayLink) == 0 {
+		return "", billing.InvalidCheckoutErr
+	}
+
+	upgrade := selected[0]
+	return fmt.Sprintf(
+		"%s?orderId=%s&quantity=%d",
+		upgrade.BTCPayLink,
+		paymentID,
+		max(upgrade.Quantity, 1)), nil
+}
+
+func (Provider) ValidateWebhook(w http.ResponseWriter, req *http.Request) ([]byte, error) {
+	payload, isValid := IsValidRequest(w, req)
+	if !isValid {
+		return nil, InvalidRequestErr
+	}
+
+	return payload, nil
+}
+
+func (Provider) ProcessWebhook(payload []byte) error {
+	var settledInvoice Invoice
+	err := json.Unmarshal(payload, &settledInvoice)
+	if err != nil {
+		return err
+	}
+
+	return FinalizeInvoice(settledInvoice)
+}
+
+// Refund records a refund for a BTCPay invoice. BTCPay refunds are sent to the
+// user manually from the BTCPay dashboard, so this only adjusts the user's
+// upgrades to match the amount returned.
+func (Provider) Refund(invoice db.Invoice, amount int64) error {
+	return billing.RecordRefund(invoice, invoice.Refunded+amount)
+}
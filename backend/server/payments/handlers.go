@@ -1,48 +1,66 @@
 package payments
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"yeetfile/backend/db"
+	"yeetfile/backend/server/payments/billing"
 	"yeetfile/backend/server/payments/btcpay"
+	"yeetfile/backend/server/payments/manual"
 	"yeetfile/backend/server/payments/stripe"
+	"yeetfile/backend/server/payments/vouchers"
+	"yeetfile/backend/server/session"
 	"yeetfile/backend/server/upgrades"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
 )
 
-// StripeWebhook handles relevant incoming webhook events from Stripe related
-// to purchasing storage
-func StripeWebhook(w http.ResponseWriter, req *http.Request) {
-	const MaxBodyBytes = int64(65536)
-	req.Body = http.MaxBytesReader(w, req.Body, MaxBodyBytes)
-	payload, err := io.ReadAll(req.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
+var (
+	Stripe = stripe.Provider{}
+	BTCPay = btcpay.Provider{}
+	Manual = manual.Provider{}
+)
 
-	// Validate the incoming event against the signature header
-	signature := req.Header.Get("Stripe-Signature")
-	event, err := stripe.ValidateEvent(payload, signature)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
+var providers = []billing.Provider{Stripe, BTCPay, Manual}
 
-	// Process the event received from stripe
-	err = stripe.ProcessEvent(event)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		return
+var UnknownProviderErr = errors.New("unknown payment provider")
+
+// GetProvider returns the payment provider matching the name stored in an
+// invoice's source (i.e. "stripe")
+func GetProvider(name string) (billing.Provider, error) {
+	for _, provider := range providers {
+		if provider.Name() == name {
+			return provider, nil
+		}
 	}
 
-	w.WriteHeader(http.StatusOK)
+	return nil, UnknownProviderErr
+}
+
+// WebhookHandler handles relevant incoming webhook events from a payment
+// provider related to purchasing upgrades
+func WebhookHandler(provider billing.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		payload, err := provider.ValidateWebhook(w, req)
+		if err != nil {
+			log.Printf("Error validating %s webhook event, ignoring\n", provider.Name())
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = provider.ProcessWebhook(payload)
+		if err != nil {
+			log.Printf("Error processing %s webhook: %v\n", provider.Name(), err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
 }
 
 // StripeCustomerPortal redirects users to the Stripe customer portal, which
@@ -64,121 +82,110 @@ func StripeWebhook(w http.ResponseWriter, req *http.Request) {
 //	http.Redirect(w, req, link, http.StatusTemporaryRedirect)
 //}
 
-// StripeCheckout initiates the process for a user adding to their meter
-// using Stripe Checkout
-func StripeCheckout(w http.ResponseWriter, req *http.Request, id string) {
-	var selectedUpgrades []shared.Upgrade
-
-	paymentID, err := db.GetPaymentIDByUserID(id)
-	if err != nil {
-		w.WriteHeader(http.StatusForbidden)
-		return
-	}
-
-	extractUpgrade := func(paramPrefix string) (shared.Upgrade, error) {
-		upgradeParam := fmt.Sprintf("%s-upgrade", paramPrefix)
-		quantityParam := fmt.Sprintf("%s-quantity", paramPrefix)
-
-		if req.URL.Query().Has(upgradeParam) {
-			upgradeTag := req.URL.Query().Get(upgradeParam)
-			upgrade, err := upgrades.GetUpgradeByTag(
-				upgradeTag,
-				upgrades.GetAllUpgrades())
-			if err != nil {
-				return shared.Upgrade{}, err
-			}
+// CheckoutHandler initiates the process for a user purchasing upgrades using
+// the provided payment provider. If the "org" query param is set, upgrades are
+// purchased for the organization that the user manages.
+func CheckoutHandler(provider billing.Provider) session.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request, id string) {
+		var paymentID string
+		var err error
+		if req.URL.Query().Has("org") {
+			paymentID, err = db.GetOrgPaymentID(id)
+		} else {
+			paymentID, err = db.GetPaymentIDByUserID(id)
+		}
 
-			if req.URL.Query().Has(quantityParam) {
-				quantityStr := req.URL.Query().Get(quantityParam)
-				quantity, err := strconv.Atoi(quantityStr)
-				if err != nil {
-					return shared.Upgrade{}, err
-				}
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
 
-				upgrade.Quantity = quantity
-			} else {
-				upgrade.Quantity = 1
-			}
+		selectedUpgrades, err := getSelectedUpgrades(req)
+		if err != nil {
+			log.Println("Error processing requested upgrades", err)
+			http.Error(w, "Error processing requested upgrades", http.StatusBadRequest)
+			return
+		}
 
-			return upgrade, nil
+		scheme := "http"
+		if utils.IsTLSReq(req) {
+			scheme = "https"
 		}
 
-		return shared.Upgrade{}, nil
-	}
+		baseURL := fmt.Sprintf("%s://%s", scheme, req.Host)
+		checkoutLink, err := provider.CheckoutLink(
+			selectedUpgrades,
+			paymentID,
+			baseURL)
+
+		if errors.Is(err, billing.InvalidCheckoutErr) {
+			http.Error(w, "Invalid upgrade selection", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "Error generating checkout link", http.StatusInternalServerError)
+			return
+		}
 
-	sendUpgrade, err := extractUpgrade("send")
-	if err != nil {
-		log.Println("Error processing requested send upgrade", err)
-		http.Error(w, "Error processing requested send upgrade", http.StatusBadRequest)
-		return
-	} else if len(sendUpgrade.Tag) > 0 {
-		selectedUpgrades = append(selectedUpgrades, sendUpgrade)
+		http.Redirect(w, req, checkoutLink, http.StatusTemporaryRedirect)
 	}
+}
 
-	vaultUpgrade, err := extractUpgrade("vault")
-	if err != nil {
-		log.Println("Error processing requested vault upgrade", err)
-		http.Error(w, "Error processing requested vault upgrade", http.StatusBadRequest)
-		return
-	} else if len(vaultUpgrade.Tag) > 0 {
-		selectedUpgrades = append(selectedUpgrades, vaultUpgrade)
-	}
+// getSelectedUpgrades reads the upgrades and quantities requested by the user
+// from the "send-upgrade", "send-quantity", "vault-upgrade", and
+// "vault-quantity" query params. The older "type" and "quantity" params are
+// still accepted for single upgrade purchases.
+func getSelectedUpgrades(req *http.Request) ([]shared.Upgrade, error) {
+	var selectedUpgrades []shared.Upgrade
 
-	scheme := "http"
-	if utils.IsTLSReq(req) {
-		scheme = "https"
-	}
+	extractUpgrade := func(upgradeParam, quantityParam string) error {
+		if !req.URL.Query().Has(upgradeParam) {
+			return nil
+		}
 
-	baseURL := fmt.Sprintf("%s://%s", scheme, req.Host)
-	checkoutLink, err := stripe.GenerateCheckoutLink(
-		selectedUpgrades,
-		paymentID,
-		baseURL)
+		upgradeTag := req.URL.Query().Get(upgradeParam)
+		upgrade, err := upgrades.GetUpgradeByTag(
+			upgradeTag,
+			upgrades.GetAllUpgrades())
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		http.Error(w, "Error generating checkout link", http.StatusInternalServerError)
-		return
-	}
+		upgrade.Quantity = 1
+		if req.URL.Query().Has(quantityParam) {
+			quantityStr := req.URL.Query().Get(quantityParam)
+			quantity, err := strconv.Atoi(quantityStr)
+			if err != nil || quantity <= 0 {
+				return billing.InvalidCheckoutErr
+			}
 
-	http.Redirect(w, req, checkoutLink, http.StatusTemporaryRedirect)
-}
+			upgrade.Quantity = quantity
+		}
 
-// BTCPayWebhook handles relevant incoming webhook events from BTCPay
-func BTCPayWebhook(w http.ResponseWriter, req *http.Request) {
-	bodyBytes, isValid := btcpay.IsValidRequest(w, req)
-	if !isValid {
-		log.Printf("Error validating BTCPay webhook event, ignoring")
-		w.WriteHeader(http.StatusBadRequest)
-		return
+		selectedUpgrades = append(selectedUpgrades, upgrade)
+		return nil
 	}
 
-	reader := bytes.NewReader(bodyBytes)
-	decoder := json.NewDecoder(reader)
-	var settledInvoice btcpay.Invoice
-	err := decoder.Decode(&settledInvoice)
-	if err != nil {
-		log.Printf("Error decoding BTCPay webhook request body: %v\n", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
+	for _, prefix := range []string{"send", "vault"} {
+		err := extractUpgrade(prefix+"-upgrade", prefix+"-quantity")
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	err = btcpay.FinalizeInvoice(settledInvoice)
+	err := extractUpgrade("type", "quantity")
 	if err != nil {
-		log.Printf("Error finalizing BTCPay invoice: %v\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	w.WriteHeader(http.StatusOK)
+	return selectedUpgrades, nil
 }
 
-// BTCPayCheckout generates an invoice for the requested product/upgrade
-func BTCPayCheckout(w http.ResponseWriter, req *http.Request, id string) {
-	itemType := req.URL.Query().Get("type")
-	quantity := req.URL.Query().Get("quantity")
-	upgrade, err := upgrades.GetUpgradeByTag(itemType, upgrades.GetAllUpgrades())
+// RedeemVoucherHandler handles redeeming a voucher code for an upgrade
+func RedeemVoucherHandler(w http.ResponseWriter, req *http.Request, id string) {
+	var request shared.RedeemVoucherRequest
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&request)
 	if err != nil {
-		http.Error(w, "Invalid upgrade tag", http.StatusBadRequest)
+		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
@@ -188,11 +195,16 @@ func BTCPayCheckout(w http.ResponseWriter, req *http.Request, id string) {
 		return
 	}
 
-	if len(upgrade.BTCPayLink) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
+	response, err := vouchers.Redeem(id, paymentID, request.Code)
+	if errors.Is(err, vouchers.InvalidVoucherErr) ||
+		errors.Is(err, vouchers.AlreadyRedeemedErr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error redeeming voucher: %v\n", err)
+		http.Error(w, "Error redeeming voucher", http.StatusInternalServerError)
 		return
 	}
 
-	checkoutLink := fmt.Sprintf("%s?orderId=%s&quantity=%s", upgrade.BTCPayLink, paymentID, quantity)
-	http.Redirect(w, req, checkoutLink, http.StatusTemporaryRedirect)
+	_ = json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,131 @@
+// Package manual implements an offline payment provider, where users are sent
+// an invoice with payment instructions and an admin marks the invoice as paid
+// once payment has been received.
+package manual
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/payments/billing"
+	"yeetfile/backend/server/upgrades"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+const invoiceIDPrefix = "inv"
+
+var NotPendingErr = errors.New("invoice is not a pending manual invoice")
+
+// Provider implements billing.Provider for manually paid invoices
+type Provider struct{}
+
+func (Provider) Name() string {
+	return "manual"
+}
+
+func (Provider) Configured() bool {
+	return config.YeetFileConfig.ManualBilling.Configured
+}
+
+// CheckoutLink creates a pending invoice for the selected upgrades and returns
+// a link to the invoice's receipt page, which contains payment instructions.
+// Any previous unpaid manual invoices for the user are replaced.
+func (p Provider) CheckoutLink(
+	selected []shared.Upgrade,
+	paymentID string,
+	baseURL string,
+) (string, error) {
+	if len(selected) == 0 {
+		return "", billing.InvalidCheckoutErr
+	}
+
+	var tags, quantities []string
+	for _, upgrade := range selected {
+		tags = append(tags, upgrade.Tag)
+		quantities = append(quantities, strconv.Itoa(max(upgrade.Quantity, 1)))
+	}
+
+	err := db.DeleteUserPendingInvoices(paymentID, p.Name())
+	if err != nil {
+		log.Printf("Error removing pending invoices: %v\n", err)
+		return "", err
+	}
+
+	invoiceID := shared.GenRandomStringWithPrefix(16, invoiceIDPrefix)
+	err = db.AddInvoice(db.Invoice{
+		InvoiceID:  invoiceID,
+		PaymentID:  paymentID,
+		Source:     p.Name(),
+		Tag:        strings.Join(tags, ","),
+		Quantities: strings.Join(quantities, ","),
+		Amount:     billing.GetCheckoutTotal(selected),
+		Status:     db.InvoicePending,
+	})
+	if err != nil {
+		log.Printf("Error creating manual invoice: %v\n", err)
+		return "", err
+	}
+
+	return endpoints.HTMLReceipt.Format(baseURL, invoiceID), nil
+}
+
+func (Provider) ValidateWebhook(_ http.ResponseWriter, _ *http.Request) ([]byte, error) {
+	return nil, billing.WebhookUnsupportedErr
+}
+
+func (Provider) ProcessWebhook(_ []byte) error {
+	return billing.WebhookUnsupportedErr
+}
+
+// Refund records a refund for a paid manual invoice. The payment itself needs
+// to be returned to the user outside of YeetFile.
+func (Provider) Refund(invoice db.Invoice, amount int64) error {
+	return billing.RecordRefund(invoice, invoice.Refunded+amount)
+}
+
+// MarkPaid applies the upgrades from a pending manual invoice to the user's
+// account and marks the invoice as paid. The invoice is claimed and its
+// upgrades are applied in one transaction, so that each invoice is only ever
+// applied once.
+func (p Provider) MarkPaid(invoiceID string) error {
+	var emailDescription string
+	invoice, err := db.PayPendingInvoice(invoiceID, p.Name(), func(invoice *db.Invoice) error {
+		for _, item := range billing.GetInvoiceItems(*invoice) {
+			upgrade, err := upgrades.GetUpgradeByTag(
+				item.Tag,
+				upgrades.GetAllUpgrades())
+			if err != nil {
+				return err
+			}
+
+			err = billing.SetInvoiceUpgrade(upgrade, item.Quantity, invoice)
+			if err != nil {
+				return err
+			}
+
+			if len(emailDescription) > 0 {
+				emailDescription += "\n\n"
+			}
+
+			emailDescription += upgrade.Description
+		}
+
+		return nil
+	})
+
+	if err == sql.ErrNoRows {
+		return NotPendingErr
+	} else if err != nil {
+		log.Printf("Error marking invoice as paid: %v\n", err)
+		return err
+	}
+
+	billing.SendOrderEmail(invoice.PaymentID, emailDescription)
+	return nil
+}
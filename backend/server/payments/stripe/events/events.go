@@ -0,0 +1,71 @@
+// Package events parses the Stripe webhook payloads that can reverse or
+// shorten a previously applied upgrade.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stripe/stripe-go/v78"
+)
+
+var MissingPaymentIntentError = errors.New("payload missing payment intent")
+
+// Refund is a full or partial refund of a charge, where AmountRefunded is the
+// total refunded for the charge so far (not just the most recent refund).
+type Refund struct {
+	PaymentIntentID string
+	Amount          int64
+	AmountRefunded  int64
+}
+
+// Dispute is a chargeback opened against a charge by the customer's bank.
+type Dispute struct {
+	PaymentIntentID string
+	Amount          int64
+	Status          stripe.DisputeStatus
+}
+
+// ParseRefund reads the charge from a "charge.refunded" event.
+func ParseRefund(raw json.RawMessage) (Refund, error) {
+	var charge stripe.Charge
+	err := json.Unmarshal(raw, &charge)
+	if err != nil {
+		return Refund{}, err
+	}
+
+	if charge.PaymentIntent == nil || len(charge.PaymentIntent.ID) == 0 {
+		return Refund{}, MissingPaymentIntentError
+	}
+
+	return Refund{
+		PaymentIntentID: charge.PaymentIntent.ID,
+		Amount:          charge.Amount,
+		AmountRefunded:  charge.AmountRefunded,
+	}, nil
+}
+
+// ParseDispute reads the dispute from a "charge.dispute.*" event.
+func ParseDispute(raw json.RawMessage) (Dispute, error) {
+	var dispute stripe.Dispute
+	err := json.Unmarshal(raw, &dispute)
+	if err != nil {
+		return Dispute{}, err
+	}
+
+	var paymentIntentID string
+	if dispute.PaymentIntent != nil {
+		paymentIntentID = dispute.PaymentIntent.ID
+	} else if dispute.Charge != nil && dispute.Charge.PaymentIntent != nil {
+		paymentIntentID = dispute.Charge.PaymentIntent.ID
+	}
+
+	if len(paymentIntentID) == 0 {
+		return Dispute{}, MissingPaymentIntentError
+	}
+
+	return Dispute{
+		PaymentIntentID: paymentIntentID,
+		Amount:          dispute.Amount,
+		Status:          dispute.Status,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v78"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yeetfile/backend/server/upgrades"
+)
+
+const testPaymentIntent = "pi_3PLxYzAbCdEfGh1K0c4Fs8Lb"
+
+func loadEvent(t *testing.T, name string) stripe.Event {
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	assert.Nil(t, err)
+
+	var event stripe.Event
+	err = json.Unmarshal(payload, &event)
+	assert.Nil(t, err)
+
+	return event
+}
+
+func TestParseRefund(t *testing.T) {
+	event := loadEvent(t, "charge_refunded_partial.json")
+	assert.Equal(t, stripe.EventType("charge.refunded"), event.Type)
+
+	refund, err := ParseRefund(event.Data.Raw)
+	assert.Nil(t, err)
+	assert.Equal(t, testPaymentIntent, refund.PaymentIntentID)
+	assert.Equal(t, int64(1030), refund.Amount)
+	assert.Equal(t, int64(515), refund.AmountRefunded)
+
+	event = loadEvent(t, "charge_refunded_full.json")
+	refund, err = ParseRefund(event.Data.Raw)
+	assert.Nil(t, err)
+	assert.Equal(t, refund.Amount, refund.AmountRefunded)
+}
+
+func TestParseRefundMissingPaymentIntent(t *testing.T) {
+	_, err := ParseRefund(json.RawMessage(`{"id": "ch_1", "amount": 100}`))
+	assert.Equal(t, MissingPaymentIntentError, err)
+}
+
+func TestParseDispute(t *testing.T) {
+	event := loadEvent(t, "charge_dispute_created.json")
+	dispute, err := ParseDispute(event.Data.Raw)
+	assert.Nil(t, err)
+	assert.Equal(t, testPaymentIntent, dispute.PaymentIntentID)
+	assert.Equal(t, stripe.DisputeStatusNeedsResponse, dispute.Status)
+
+	event = loadEvent(t, "charge_dispute_closed_won.json")
+	dispute, err = ParseDispute(event.Data.Raw)
+	assert.Nil(t, err)
+	assert.Equal(t, stripe.DisputeStatusWon, dispute.Status)
+}
+
+func TestProrateRecordedRefunds(t *testing.T) {
+	purchased := time.Date(2024, 5, 3, 16, 0, 0, 0, time.UTC)
+	vaultPurchase := upgrades.Purchase{
+		Amount:   1030,
+		Date:     purchased,
+		VaultExp: purchased.AddDate(1, 0, 0),
+	}
+
+	sendPurchase := upgrades.Purchase{
+		Amount:    1030,
+		Date:      purchased,
+		SendBytes: 100_000_000_000,
+	}
+
+	now := purchased.AddDate(0, 1, 0)
+
+	// Half refunded: vault upgrade is cut in half, half of send is removed
+	partial, err := ParseRefund(loadEvent(t, "charge_refunded_partial.json").Data.Raw)
+	assert.Nil(t, err)
+
+	adjustment := upgrades.ProrateRefund(vaultPurchase, 0, partial.AmountRefunded, now)
+	assert.False(t, adjustment.RevokeVault)
+	assert.Equal(t, vaultPurchase.VaultExp.Sub(purchased)/2, adjustment.VaultExp.Sub(purchased))
+
+	adjustment = upgrades.ProrateRefund(sendPurchase, 0, partial.AmountRefunded, now)
+	assert.True(t, adjustment.VaultExp.IsZero())
+	assert.Equal(t, int64(50_000_000_000), adjustment.SendBytes)
+
+	// Remainder refunded: vault is revoked, remaining send is removed
+	full, err := ParseRefund(loadEvent(t, "charge_refunded_full.json").Data.Raw)
+	assert.Nil(t, err)
+
+	adjustment = upgrades.ProrateRefund(
+		vaultPurchase,
+		partial.AmountRefunded,
+		full.AmountRefunded,
+		now)
+	assert.True(t, adjustment.RevokeVault)
+
+	adjustment = upgrades.ProrateRefund(
+		sendPurchase,
+		partial.AmountRefunded,
+		full.AmountRefunded,
+		now)
+	assert.Equal(t, int64(50_000_000_000), adjustment.SendBytes)
+}
+
+func TestProrateRefundExpiredPortion(t *testing.T) {
+	purchased := time.Date(2024, 5, 3, 16, 0, 0, 0, time.UTC)
+	purchase := upgrades.Purchase{
+		Amount:   1000,
+		Date:     purchased,
+		VaultExp: purchased.AddDate(0, 10, 0),
+	}
+
+	// 90% refunded after more than 10% of the upgrade was already used
+	adjustment := upgrades.ProrateRefund(purchase, 0, 900, purchased.AddDate(0, 2, 0))
+	assert.True(t, adjustment.RevokeVault)
+
+	// Refunds beyond the purchase amount are capped
+	adjustment = upgrades.ProrateRefund(purchase, 0, 5000, purchased)
+	assert.True(t, adjustment.RevokeVault)
+	assert.Equal(t, purchased, adjustment.VaultExp)
+}
+
+func TestProrateRefundExtendedUpgrade(t *testing.T) {
+	purchased := time.Date(2024, 5, 3, 16, 0, 0, 0, time.UTC)
+	start := purchased.AddDate(0, 2, 0)
+	purchase := upgrades.Purchase{
+		Amount:     1000,
+		Date:       purchased,
+		VaultStart: start,
+		VaultExp:   start.AddDate(0, 10, 0),
+	}
+
+	// Only the period bought by the purchase is refunded, so the time that
+	// was already paid for before the purchase is kept
+	adjustment := upgrades.ProrateRefund(purchase, 0, 1000, purchased)
+	assert.False(t, adjustment.RevokeVault)
+	assert.Equal(t, start, adjustment.VaultExp)
+
+	adjustment = upgrades.ProrateRefund(purchase, 0, 500, purchased)
+	assert.Equal(t, purchase.VaultExp.Sub(start)/2, adjustment.VaultExp.Sub(start))
+}
@@ -0,0 +1,97 @@
+package stripe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stripe/stripe-go/v78"
+	"github.com/stripe/stripe-go/v78/product"
+	"github.com/stripe/stripe-go/v78/refund"
+	"io"
+	"log"
+	"net/http"
+	"yeetfile/backend/config"
+	"yeetfile/backend/db"
+	"yeetfile/shared"
+)
+
+// Provider implements billing.Provider using Stripe Checkout
+type Provider struct{}
+
+func (Provider) Name() string {
+	return "stripe"
+}
+
+func (Provider) Configured() bool {
+	return config.YeetFileConfig.StripeBilling.Configured
+}
+
+func (Provider) CheckoutLink(
+	selected []shared.Upgrade,
+	paymentID string,
+	baseURL string,
+) (string, error) {
+	return GenerateCheckoutLink(selected, paymentID, baseURL)
+}
+
+func (Provider) ValidateWebhook(w http.ResponseWriter, req *http.Request) ([]byte, error) {
+	const MaxBodyBytes = int64(65536)
+	req.Body = http.MaxBytesReader(w, req.Body, MaxBodyBytes)
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the incoming event against the signature header
+	signature := req.Header.Get("Stripe-Signature")
+	_, err = ValidateEvent(payload, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (Provider) ProcessWebhook(payload []byte) error {
+	var event stripe.Event
+	err := json.Unmarshal(payload, &event)
+	if err != nil {
+		return err
+	}
+
+	return ProcessEvent(event)
+}
+
+// Refund issues a refund through Stripe for the invoice's payment intent. The
+// user's upgrades are adjusted once Stripe sends the "charge.refunded" event.
+func (Provider) Refund(invoice db.Invoice, amount int64) error {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(invoice.PaymentRef),
+		Amount:        stripe.Int64(amount),
+	}
+
+	_, err := refund.New(params)
+	if err != nil {
+		log.Printf("Error creating Stripe refund: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// ValidateProduct checks that a Stripe product ID used in the upgrade catalog
+// exists and is active.
+func ValidateProduct(productID string) error {
+	if !config.YeetFileConfig.StripeBilling.Configured {
+		return errors.New("stripe billing is not configured")
+	}
+
+	p, err := product.Get(productID, nil)
+	if err != nil {
+		return fmt.Errorf("unable to find stripe product: %w", err)
+	} else if !p.Active {
+		return errors.New("stripe product is not active")
+	}
+
+	return nil
+}
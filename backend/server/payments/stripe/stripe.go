@@ -1,6 +1,7 @@
 package stripe
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,7 +15,8 @@ import (
 	"time"
 	"yeetfile/backend/config"
 	"yeetfile/backend/db"
-	"yeetfile/backend/mail"
+	"yeetfile/backend/server/payments/billing"
+	"yeetfile/backend/server/payments/stripe/events"
 	"yeetfile/backend/server/upgrades"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
@@ -56,6 +58,19 @@ func processCheckoutEvent(event *stripe.EventData) error {
 		return err
 	}
 
+	invoice := db.Invoice{
+		InvoiceID: checkoutSession.ID,
+		PaymentID: userPaymentID,
+		Source:    "stripe",
+		Tag:       upgradeTags,
+		Amount:    checkoutSession.AmountTotal,
+	}
+
+	if checkoutSession.PaymentIntent != nil {
+		invoice.PaymentRef = checkoutSession.PaymentIntent.ID
+	}
+
+	var quantities []string
 	splitTags := strings.Split(upgradeTags, ",")
 	for _, upgradeTag := range splitTags {
 		var upgrade shared.Upgrade
@@ -77,11 +92,13 @@ func processCheckoutEvent(event *stripe.EventData) error {
 			quantity = 1
 		}
 
-		err = setUserSubscription(userPaymentID, upgrade.Tag, quantity)
+		err = billing.ApplyUpgrade(userPaymentID, upgrade, quantity, &invoice)
 		if err != nil {
 			return err
 		}
 
+		quantities = append(quantities, strconv.Itoa(quantity))
+
 		if len(emailDescription) > 0 {
 			emailDescription += "\n\n"
 		}
@@ -89,17 +106,129 @@ func processCheckoutEvent(event *stripe.EventData) error {
 		emailDescription += upgrade.Description
 	}
 
-	// Send email (if applicable)
-	email, err := db.GetUserEmailByPaymentID(userPaymentID)
-	if err == nil && len(email) != 0 {
-		err = mail.CreateOrderEmail(emailDescription, email).Send()
+	billing.SendOrderEmail(userPaymentID, emailDescription)
+
+	invoice.Quantities = strings.Join(quantities, ",")
+	err = db.AddInvoice(invoice)
+	return err
+}
+
+// processRefundEvent receives a refunded charge from Stripe and shortens or
+// removes the upgrades purchased with the charge, in proportion to the total
+// amount that has been refunded.
+func processRefundEvent(event *stripe.EventData) error {
+	log.Println("Incoming 'charge.refunded' event from Stripe")
+	refund, err := events.ParseRefund(event.Raw)
+	if err != nil {
+		log.Printf("Error parsing refund webhook JSON: %v\n", err)
+		return err
+	}
+
+	invoice, err := db.GetInvoiceByPaymentRef(refund.PaymentIntentID)
+	if err == sql.ErrNoRows {
+		log.Printf("No invoice found for refunded payment %s\n", refund.PaymentIntentID)
+		return nil
+	} else if err != nil {
+		log.Printf("Error fetching invoice for refund: %v\n", err)
+		return err
+	}
+
+	return billing.RecordRefund(invoice, refund.AmountRefunded)
+}
+
+// processDisputeEvent receives a new or closed dispute from Stripe. Upgrades
+// are revoked as soon as a dispute is opened, and are restored if the dispute
+// is closed in YeetFile's favor.
+func processDisputeEvent(event *stripe.EventData, closed bool) error {
+	log.Println("Incoming 'charge.dispute' event from Stripe")
+	dispute, err := events.ParseDispute(event.Raw)
+	if err != nil {
+		log.Printf("Error parsing dispute webhook JSON: %v\n", err)
+		return err
+	}
+
+	invoice, err := db.GetInvoiceByPaymentRef(dispute.PaymentIntentID)
+	if err == sql.ErrNoRows {
+		log.Printf("No invoice found for disputed payment %s\n", dispute.PaymentIntentID)
+		return nil
+	} else if err != nil {
+		log.Printf("Error fetching invoice for dispute: %v\n", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	purchase := billing.GetInvoicePurchase(invoice)
+
+	if !closed {
+		if invoice.Status == db.InvoiceDisputed {
+			return nil
+		}
+
+		adjustment := upgrades.ProrateRefund(
+			purchase,
+			invoice.Refunded,
+			invoice.Amount,
+			now)
+		err = billing.ApplyRefundAdjustment(invoice, adjustment)
 		if err != nil {
-			log.Println("Error sending confirmation email")
+			return err
 		}
+
+		return db.UpdateInvoiceStatus(
+			invoice.InvoiceID,
+			db.InvoiceDisputed,
+			invoice.Refunded)
+	} else if invoice.Status != db.InvoiceDisputed {
+		return nil
 	}
 
-	err = db.AddInvoice(checkoutSession.ID, userPaymentID, "stripe")
-	return err
+	if dispute.Status != stripe.DisputeStatusWon &&
+		dispute.Status != stripe.DisputeStatusWarningClosed {
+		return db.UpdateInvoiceStatus(
+			invoice.InvoiceID,
+			db.InvoiceDisputeLost,
+			invoice.Refunded)
+	}
+
+	// Dispute was won, restore whatever portion of the upgrade wasn't
+	// previously refunded, unless the user has purchased a newer vault
+	// upgrade while the dispute was open
+	restored := upgrades.ProrateRefund(purchase, 0, invoice.Refunded, now)
+	if invoice.VaultBytes > 0 && !restored.RevokeVault {
+		subTag, subExp, err := db.GetUserSubByPaymentID(invoice.PaymentID)
+		if err != nil {
+			log.Printf("Error fetching user sub for dispute: %v\n", err)
+			return err
+		}
+
+		if !purchase.IsCurrentVaultUpgrade(subTag, subExp) {
+			log.Printf("Not restoring vault upgrade for %s, user has a "+
+				"newer upgrade\n", invoice.InvoiceID)
+		} else if err = db.SetUserVaultUpgrade(
+			invoice.PaymentID,
+			invoice.VaultTag,
+			restored.VaultExp,
+			invoice.VaultBytes); err != nil {
+			log.Printf("Error restoring vault upgrade: %v\n", err)
+			return err
+		}
+	}
+
+	unrefunded := upgrades.ProrateRefund(purchase, invoice.Refunded, invoice.Amount, now)
+	if unrefunded.SendBytes > 0 {
+		err = db.AdjustUserSendUpgrade(invoice.PaymentID, unrefunded.SendBytes)
+		if err != nil {
+			log.Printf("Error restoring send upgrade: %v\n", err)
+			return err
+		}
+	}
+
+	status := db.InvoicePaid
+	if invoice.Refunded > 0 {
+		status = db.InvoicePartial
+	}
+
+	return db.UpdateInvoiceStatus(invoice.InvoiceID, status, invoice.Refunded)
 }
 
 // ProcessEvent receives an input stripe.Event and determines if/how a
@@ -107,12 +236,22 @@ func processCheckoutEvent(event *stripe.EventData) error {
 func ProcessEvent(event stripe.Event) error {
 	utils.LogStruct(event)
 
-	// Currently only successful checkouts are handled by the webhook
 	log.Println("Incoming ", event.Type)
-	if event.Type == "checkout.session.completed" {
+	switch event.Type {
+	case "checkout.session.completed":
 		return processCheckoutEvent(event.Data)
+	case "charge.refunded":
+		return processRefundEvent(event.Data)
+	case "charge.dispute.created":
+		return processDisputeEvent(event.Data, false)
+	case "charge.dispute.closed":
+		return processDisputeEvent(event.Data, true)
 	}
 
+	// Subscription events aren't handled, since GenerateCheckoutLink only
+	// creates one-time payments and upgrades are renewed by purchasing them
+	// again, so a subscription can never be cancelled
+
 	// Unsupported event, ignore...
 	return nil
 }
@@ -135,41 +274,6 @@ func ValidateEvent(payload []byte, sig string) (stripe.Event, error) {
 	return event, nil
 }
 
-// setUserSubscription retrieves values from storage/send/type maps and uses those
-// to update the user's database entry
-func setUserSubscription(paymentID, productID string, quantity int) error {
-	upgrade, err := upgrades.GetUpgradeByTag(productID, upgrades.GetAllUpgrades())
-	if err != nil {
-		log.Printf("Error getting user upgrade product '%s': %v\n", productID, err)
-		return err
-	}
-
-	if upgrade.IsVaultUpgrade {
-		var exp time.Time
-		exp, err = upgrades.GetUpgradeExpiration(upgrade, quantity)
-		if err != nil {
-			return err
-		}
-
-		err = db.SetUserVaultUpgrade(
-			paymentID,
-			productID,
-			exp,
-			upgrade.Bytes)
-	} else {
-		err = db.SetUserSendUpgrade(
-			paymentID,
-			upgrade.Bytes*int64(quantity))
-	}
-
-	if err != nil {
-		log.Printf("Error processing user upgrade: %v\n", err)
-		return err
-	}
-
-	return nil
-}
-
 // generateProrationAmount uses the user's previous vault upgrade to modify a
 // new upgrade's price based on unused months/years from their previous purchase.
 func generateProrationAmount(paymentID string) (int64, error) {
@@ -243,6 +347,12 @@ func GenerateCheckoutLink(
 			UnitAmount: stripe.Int64(finalPrice / int64(upgrade.Quantity)),
 		}
 
+		if len(upgrade.StripeID) > 0 {
+			// Attribute the purchase to an existing Stripe product
+			priceData.ProductData = nil
+			priceData.Product = stripe.String(upgrade.StripeID)
+		}
+
 		lineItem := stripe.CheckoutSessionLineItemParams{
 			PriceData: priceData,
 			Quantity:  stripe.Int64(int64(upgrade.Quantity)),
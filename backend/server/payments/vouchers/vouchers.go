@@ -0,0 +1,127 @@
+// Package vouchers handles creating and redeeming voucher codes, which grant
+// users an upgrade without going through a payment provider.
+package vouchers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"yeetfile/backend/db"
+	"yeetfile/backend/server/payments/billing"
+	"yeetfile/backend/server/upgrades"
+	"yeetfile/shared"
+)
+
+const (
+	codeGroups      = 3
+	codeGroupLength = 4
+	invoicePrefix   = "vch"
+)
+
+// Ambiguous characters (0/O, 1/I) are excluded to make codes easier to type
+var codeCharacters = []rune("ABCDEFGHJKLMNPQRSTUVWXYZ23456789")
+
+var (
+	InvalidVoucherErr  = errors.New("invalid or expired voucher code")
+	AlreadyRedeemedErr = errors.New("voucher has already been redeemed")
+	InvalidOptionsErr  = errors.New("invalid voucher options")
+)
+
+// Create validates and stores a new voucher. A random code is generated if
+// the voucher doesn't already have one.
+func Create(voucher shared.AdminVoucher) (shared.AdminVoucher, error) {
+	_, err := upgrades.GetUpgradeByTag(voucher.Tag, upgrades.GetAllUpgrades())
+	if err != nil || voucher.Quantity < 1 || voucher.MaxUses < 1 {
+		return shared.AdminVoucher{}, InvalidOptionsErr
+	}
+
+	voucher.Code = NormalizeCode(voucher.Code)
+	if len(voucher.Code) == 0 {
+		voucher.Code, err = GenerateCode()
+		if err != nil {
+			return shared.AdminVoucher{}, err
+		}
+	}
+
+	err = db.CreateVoucher(voucher)
+	if err != nil {
+		return shared.AdminVoucher{}, err
+	}
+
+	return voucher, nil
+}
+
+// Redeem applies the upgrade from a voucher to the user's account, and records
+// the redemption as a zero-amount invoice. Each user can only redeem a
+// particular voucher once.
+func Redeem(userID, paymentID, code string) (shared.RedeemVoucherResponse, error) {
+	code = NormalizeCode(code)
+	if len(code) == 0 {
+		return shared.RedeemVoucherResponse{}, InvalidVoucherErr
+	}
+
+	var (
+		upgrade  shared.Upgrade
+		quantity int
+	)
+
+	err := db.RedeemVoucher(userID, code, db.Invoice{
+		InvoiceID: shared.GenRandomStringWithPrefix(16, invoicePrefix),
+		PaymentID: paymentID,
+	}, func(tag string, voucherQuantity int, invoice *db.Invoice) error {
+		var err error
+		quantity = voucherQuantity
+		upgrade, err = upgrades.GetUpgradeByTag(tag, upgrades.GetAllUpgrades())
+		if err != nil {
+			log.Printf("Voucher %s references unknown upgrade %s\n", code, tag)
+			return InvalidVoucherErr
+		}
+
+		return billing.SetInvoiceUpgrade(upgrade, quantity, invoice)
+	})
+
+	if err == sql.ErrNoRows {
+		return shared.RedeemVoucherResponse{}, InvalidVoucherErr
+	} else if errors.Is(err, db.VoucherRedeemedErr) {
+		return shared.RedeemVoucherResponse{}, AlreadyRedeemedErr
+	} else if err != nil {
+		log.Printf("Error redeeming voucher: %v\n", err)
+		return shared.RedeemVoucherResponse{}, err
+	}
+
+	billing.SendOrderEmail(paymentID, upgrade.Description)
+
+	return shared.RedeemVoucherResponse{
+		Name:     upgrade.Name,
+		Quantity: quantity,
+	}, nil
+}
+
+// GenerateCode creates a random voucher code (i.e. "ABCD-EFGH-JKLM")
+func GenerateCode() (string, error) {
+	max := big.NewInt(int64(len(codeCharacters)))
+	var groups []string
+	for i := 0; i < codeGroups; i++ {
+		group := make([]rune, codeGroupLength)
+		for j := range group {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+
+			group[j] = codeCharacters[n.Int64()]
+		}
+
+		groups = append(groups, string(group))
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// NormalizeCode formats a user-provided voucher code to match stored codes
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
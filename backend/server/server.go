@@ -14,8 +14,11 @@ import (
 	"yeetfile/backend/config"
 	"yeetfile/backend/server/admin"
 	"yeetfile/backend/server/auth"
+	"yeetfile/backend/server/emergency"
 	"yeetfile/backend/server/html"
+	"yeetfile/backend/server/invites"
 	"yeetfile/backend/server/misc"
+	"yeetfile/backend/server/orgs"
 	"yeetfile/backend/server/payments"
 	"yeetfile/backend/server/session"
 	"yeetfile/backend/server/transfer/send"
@@ -56,19 +59,20 @@ func Run(host, port string) {
 		{POST, endpoints.UploadSendText, LimiterMiddleware(LockdownAuthMiddleware(send.UploadTextHandler))},
 		{GET, endpoints.DownloadSendFileMetadata, send.DownloadHandler},
 		{GET, endpoints.DownloadSendFileData, send.DownloadChunkHandler},
+		{POST, endpoints.ReportSendFile, LimiterMiddleware(send.ReportHandler)},
 
 		// YeetFile Vault
-		{ALL, endpoints.VaultFolder, AuthMiddleware(vault.FolderHandler(vault.FileVault))},
+		{ALL, endpoints.VaultFolder, AuthMiddleware(EmergencyAccessMiddleware(vault.FolderHandler(vault.FileVault)))},
 		{GET | PUT | DELETE, endpoints.VaultFile, AuthMiddleware(vault.FileHandler)},
 		{POST, endpoints.UploadVaultFileMetadata, AuthMiddleware(vault.UploadMetadataHandler)},
 		{POST, endpoints.UploadVaultFileData, AuthMiddleware(vault.UploadDataHandler)},
-		{GET, endpoints.DownloadVaultFileMetadata, AuthLimiterMiddleware(vault.DownloadHandler)},
-		{GET, endpoints.DownloadVaultFileData, AuthMiddleware(vault.DownloadChunkHandler)},
+		{GET, endpoints.DownloadVaultFileMetadata, AuthLimiterMiddleware(EmergencyAccessMiddleware(vault.DownloadHandler))},
+		{GET, endpoints.DownloadVaultFileData, AuthMiddleware(EmergencyAccessMiddleware(vault.DownloadChunkHandler))},
 		{ALL, endpoints.ShareFile, AuthMiddleware(vault.ShareHandler(false))},
 		{ALL, endpoints.ShareFolder, AuthMiddleware(vault.ShareHandler(true))},
 
 		// YeetFile Pass (YeetPass)
-		{ALL, endpoints.PassFolder, AuthMiddleware(vault.FolderHandler(vault.PassVault))},
+		{ALL, endpoints.PassFolder, AuthMiddleware(EmergencyAccessMiddleware(vault.FolderHandler(vault.PassVault)))},
 		{POST, endpoints.PassEntry, AuthMiddleware(vault.UploadMetadataHandler)},
 		{DELETE, endpoints.PassEntry, AuthMiddleware(vault.FileHandler)},
 
@@ -79,27 +83,78 @@ func Run(host, port string) {
 		{GET, endpoints.Logout, auth.LogoutHandler},
 		{GET | POST | DELETE, endpoints.TwoFactor, AuthMiddleware(auth.TwoFactorHandler)},
 		{POST, endpoints.Login, LimiterMiddleware(auth.LoginHandler)},
+		{GET, endpoints.KDF, LimiterMiddleware(auth.KDFHandler)},
 		{POST, endpoints.Signup, LimiterMiddleware(auth.SignupHandler)},
+		{POST, endpoints.OIDCStart, LimiterMiddleware(auth.OIDCStartHandler)},
+		{GET, endpoints.OIDCCallback, auth.OIDCCallbackHandler},
+		{POST, endpoints.OIDCStatus, auth.OIDCStatusHandler},
+		{POST, endpoints.OIDCConfirm, LimiterMiddleware(auth.OIDCConfirmHandler)},
+		{POST, endpoints.OIDCLink, LimiterMiddleware(auth.OIDCLinkHandler)},
+		{POST, endpoints.OIDCSignup, LimiterMiddleware(auth.OIDCSignupHandler)},
 		{GET | PUT | DELETE, endpoints.Account, AuthMiddleware(auth.AccountHandler)},
 		{GET, endpoints.AccountUsage, AuthMiddleware(auth.AccountUsageHandler)},
+		{GET, endpoints.AccountBilling, AuthMiddleware(auth.BillingHandler)},
+		{POST, endpoints.AccountReceipt, AuthLimiterMiddleware(auth.ReceiptHandler)},
+		{POST, endpoints.RedeemVoucher, AuthLimiterMiddleware(payments.RedeemVoucherHandler)},
+		{GET | POST, endpoints.AccountInvites, AuthLimiterMiddleware(invites.AccountInvitesHandler)},
+		{DELETE, endpoints.AccountInvite, AuthMiddleware(invites.AccountInviteActionHandler)},
 		{POST, endpoints.Forgot, LimiterMiddleware(auth.ForgotPasswordHandler)},
+		{GET | PUT | DELETE, endpoints.Recovery, AuthMiddleware(auth.RecoveryHandler)},
+		{POST, endpoints.RecoveryStart, LimiterMiddleware(auth.RecoveryStartHandler)},
+		{POST, endpoints.RecoveryFinish, LimiterMiddleware(auth.RecoveryFinishHandler)},
 		{GET, endpoints.PubKey, AuthLimiterMiddleware(auth.PubKeyHandler)},
 		{GET, endpoints.ProtectedKey, AuthMiddleware(auth.ProtectedKeyHandler)},
+		{PUT, endpoints.UserKeys, AuthMiddleware(auth.UserKeysHandler)},
+		{GET | PUT, endpoints.RotateKeys, AuthMiddleware(auth.RotateKeysHandler)},
+		{GET | PUT, endpoints.Contacts, AuthMiddleware(auth.ContactsHandler)},
 		{POST | PUT, endpoints.ChangeEmail, AuthMiddleware(auth.ChangeEmailHandler)},
 		{PUT, endpoints.ChangePassword, AuthMiddleware(auth.ChangePasswordHandler)},
 		{POST, endpoints.ChangeHint, AuthMiddleware(auth.ChangeHintHandler)},
 		{PUT, endpoints.RecyclePaymentID, AuthMiddleware(auth.RecyclePaymentIDHandler)},
 
+		// Organizations
+		{GET | POST | DELETE, endpoints.Organization, AuthMiddleware(orgs.OrganizationHandler)},
+		{POST, endpoints.OrgInvites, AuthLimiterMiddleware(orgs.InvitesHandler)},
+		{PUT | DELETE, endpoints.OrgInviteAction, AuthMiddleware(orgs.InviteActionHandler)},
+		{PUT | DELETE, endpoints.OrgMember, AuthMiddleware(orgs.MemberHandler)},
+
+		// Emergency access
+		{GET | POST, endpoints.EmergencyAccess, AuthMiddleware(emergency.EmergencyAccessHandler)},
+		{DELETE, endpoints.EmergencyContact, AuthMiddleware(emergency.ContactHandler)},
+		{POST, endpoints.EmergencyDeny, AuthMiddleware(emergency.DenyHandler)},
+		{GET | POST | DELETE, endpoints.EmergencyGrantor, AuthLimiterMiddleware(emergency.GrantorHandler)},
+
 		// Admin
-		{GET | PUT | DELETE, endpoints.AdminUserActions, AdminMiddleware(admin.UserActionHandler)},
-		{GET | DELETE, endpoints.AdminFileActions, AdminMiddleware(admin.FileActionHandler)},
-		{POST | DELETE, endpoints.AdminInviteActions, AdminMiddleware(admin.InviteActionsHandler)},
+		{GET | POST, endpoints.AdminUsers, AdminMiddleware(auth.UserAdminPermission, admin.UsersHandler)},
+		{GET | PUT | DELETE, endpoints.AdminUserActions, AdminMiddleware(auth.UserAdminPermission, admin.UserActionHandler)},
+		{POST | DELETE, endpoints.AdminSuspendUser, AdminMiddleware(auth.UserAdminPermission, admin.SuspendUserHandler)},
+		{GET | DELETE, endpoints.AdminFileActions, AdminMiddleware(auth.FileAdminPermission, admin.FileActionHandler)},
+		{POST | DELETE, endpoints.AdminInviteActions, AdminMiddleware(auth.UserAdminPermission, admin.InviteActionsHandler)},
+		{GET | POST, endpoints.AdminInviteLinks, AdminMiddleware(auth.UserAdminPermission, invites.AdminLinksHandler)},
+		{DELETE, endpoints.AdminInviteLink, AdminMiddleware(auth.UserAdminPermission, invites.AdminLinkActionHandler)},
+		{GET, endpoints.AdminSignups, AdminMiddleware(auth.UserAdminPermission, admin.SignupsHandler)},
+		{POST | DELETE, endpoints.AdminSignupAction, AdminMiddleware(auth.UserAdminPermission, admin.SignupActionHandler)},
+		{GET | POST, endpoints.AdminEmailDomains, AdminMiddleware(auth.UserAdminPermission, admin.EmailDomainsHandler)},
+		{DELETE, endpoints.AdminEmailDomain, AdminMiddleware(auth.UserAdminPermission, admin.EmailDomainActionHandler)},
+		{GET, endpoints.AdminStats, AdminMiddleware(auth.StatsAdminPermission, admin.StatsHandler)},
+		{GET, endpoints.AdminReports, AdminMiddleware(auth.FileAdminPermission, admin.ReportsHandler)},
+		{POST | DELETE, endpoints.AdminReportActions, AdminMiddleware(auth.FileAdminPermission, admin.ReportActionsHandler)},
+		{GET, endpoints.AdminInvoices, AdminMiddleware(auth.BillingAdminPermission, admin.InvoicesHandler)},
+		{POST | PUT | DELETE, endpoints.AdminInvoiceAction, AdminMiddleware(auth.BillingAdminPermission, admin.InvoiceActionHandler)},
+		{GET | POST, endpoints.AdminVouchers, AdminMiddleware(auth.BillingAdminPermission, admin.VouchersHandler)},
+		{DELETE, endpoints.AdminVoucherAction, AdminMiddleware(auth.BillingAdminPermission, admin.VoucherActionHandler)},
+		{GET | POST, endpoints.AdminUpgrades, AdminMiddleware(auth.BillingAdminPermission, admin.UpgradesHandler)},
+		{DELETE, endpoints.AdminUpgradeAction, AdminMiddleware(auth.BillingAdminPermission, admin.UpgradeActionHandler)},
+		{GET | POST, endpoints.AdminRoles, AdminMiddleware(auth.RoleAdminPermission, admin.RolesHandler)},
+		{DELETE, endpoints.AdminRoleAction, AdminMiddleware(auth.RoleAdminPermission, admin.RoleActionHandler)},
+		{GET | POST, endpoints.AdminSecrets, AdminMiddleware(auth.SecretAdminPermission, admin.SecretsHandler)},
 
-		// Payments (Stripe, BTCPay)
-		{POST, endpoints.StripeWebhook, payments.StripeWebhook},
-		{GET, endpoints.StripeCheckout, StripeMiddleware(AuthMiddleware(payments.StripeCheckout))},
-		{POST, endpoints.BTCPayWebhook, payments.BTCPayWebhook},
-		{GET, endpoints.BTCPayCheckout, BTCPayMiddleware(AuthMiddleware(payments.BTCPayCheckout))},
+		// Payments (Stripe, BTCPay, Manual)
+		{POST, endpoints.StripeWebhook, BillingMiddleware(payments.Stripe, payments.WebhookHandler(payments.Stripe))},
+		{GET, endpoints.StripeCheckout, BillingMiddleware(payments.Stripe, AuthMiddleware(payments.CheckoutHandler(payments.Stripe)))},
+		{POST, endpoints.BTCPayWebhook, BillingMiddleware(payments.BTCPay, payments.WebhookHandler(payments.BTCPay))},
+		{GET, endpoints.BTCPayCheckout, BillingMiddleware(payments.BTCPay, AuthMiddleware(payments.CheckoutHandler(payments.BTCPay)))},
+		{GET, endpoints.ManualCheckout, BillingMiddleware(payments.Manual, AuthMiddleware(payments.CheckoutHandler(payments.Manual)))},
 
 		// HTML
 		{GET, endpoints.HTMLHome, LockdownAuthMiddleware(html.SendPageHandler)},
@@ -113,17 +168,21 @@ func Run(host, port string) {
 		{GET, endpoints.HTMLSendDownload, html.DownloadPageHandler},
 		{GET, endpoints.HTMLSignup, NoAuthMiddleware(html.SignupPageHandler)},
 		{GET, endpoints.HTMLLogin, NoAuthMiddleware(html.LoginPageHandler)},
+		{GET, endpoints.HTMLOIDC, NoAuthMiddleware(html.OIDCPageHandler)},
 		{GET, endpoints.HTMLForgot, NoAuthMiddleware(html.ForgotPageHandler)},
+		{GET, endpoints.HTMLRecover, NoAuthMiddleware(html.RecoverPageHandler)},
 		{GET, endpoints.HTMLAccount, AuthMiddleware(html.AccountPageHandler)},
+		{GET, endpoints.HTMLReceipt, AuthMiddleware(html.ReceiptPageHandler)},
 		{GET, endpoints.HTMLUpgrade, AuthMiddleware(html.UpgradePageHandler)},
 		{GET, endpoints.HTMLVerifyEmail, html.VerifyPageHandler},
 		{GET, endpoints.HTMLChangeEmail, AuthMiddleware(html.ChangeEmailPageHandler)},
 		{GET, endpoints.HTMLChangePassword, AuthMiddleware(html.ChangePasswordPageHandler)},
 		{GET, endpoints.HTMLChangeHint, AuthMiddleware(html.ChangeHintPageHandler)},
+		{GET, endpoints.HTMLRecoveryKey, AuthMiddleware(html.RecoveryKeyPageHandler)},
 		{GET, endpoints.HTMLTwoFactor, AuthMiddleware(html.TwoFactorPageHandler)},
 		{GET, endpoints.HTMLServerInfo, html.ServerInfoPageHandler},
 		{GET, endpoints.HTMLCheckoutComplete, html.CheckoutCompleteHandler},
-		{GET, endpoints.HTMLAdmin, AdminMiddleware(html.AdminPageHandler)},
+		{GET, endpoints.HTMLAdmin, AdminMiddleware(auth.AnyAdminPermission, html.AdminPageHandler)},
 
 		// Misc
 		{ // Static folder files
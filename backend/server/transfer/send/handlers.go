@@ -12,6 +12,7 @@ import (
 	"yeetfile/backend/cache"
 	"yeetfile/backend/config"
 	"yeetfile/backend/db"
+	"yeetfile/backend/server/auth"
 	"yeetfile/backend/server/transfer"
 	"yeetfile/backend/storage"
 	"yeetfile/backend/utils"
@@ -37,6 +38,18 @@ func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID stri
 		return
 	}
 
+	meta.EncryptionVersion, err = transfer.GetEncryptionVersion(meta.EncryptionVersion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	meta.KDF, err = getSendKDFParams(meta.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	downloadsErr := validateSendDownloads(meta.Downloads)
 	if downloadsErr != nil {
 		http.Error(w, downloadsErr.Error(), http.StatusBadRequest)
@@ -59,7 +72,13 @@ func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID stri
 		return
 	}
 
-	id, _ := db.InsertMetadata(meta.Chunks, userID, meta.Name, false)
+	id, _ := db.InsertMetadata(
+		meta.Chunks,
+		userID,
+		meta.Name,
+		false,
+		meta.EncryptionVersion,
+		meta.KDF)
 	err = db.CreateNewUpload(id, meta.Name)
 	if err != nil {
 		log.Printf("Error initializing new upload: %v\n", err)
@@ -157,7 +176,14 @@ func UploadDataHandler(w http.ResponseWriter, req *http.Request, userID string)
 
 // UploadTextHandler handles uploading encrypted text with a max size of
 // shared.MaxTextLen characters (constants.go).
-func UploadTextHandler(w http.ResponseWriter, req *http.Request, _ string) {
+func UploadTextHandler(w http.ResponseWriter, req *http.Request, userID string) {
+	// Text can be uploaded without an account unless the server is locked
+	// down, but suspended users can't upload anything
+	if len(userID) > 0 && db.IsUserSuspended(userID) {
+		http.Error(w, auth.SuspendedMsg, http.StatusForbidden)
+		return
+	}
+
 	var upload shared.TextUpload
 	err := utils.LimitedJSONReader(w, req.Body).Decode(&upload)
 	if err != nil {
@@ -184,7 +210,19 @@ func UploadTextHandler(w http.ResponseWriter, req *http.Request, _ string) {
 		return
 	}
 
-	id, err := db.InsertMetadata(1, "", upload.Name, true)
+	kdf, err := getSendKDFParams(upload.KDF)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := db.InsertMetadata(
+		1,
+		"",
+		upload.Name,
+		true,
+		constants.FileEncryptionV1,
+		kdf)
 	if err != nil {
 		log.Printf("Error inserting new text-only upload metadata: %v\n", err)
 		http.Error(w, "Unable to init metadata", http.StatusInternalServerError)
@@ -244,15 +282,22 @@ func DownloadHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if db.IsSendOwnerSuspended(id) {
+		http.Error(w, "File unavailable", http.StatusForbidden)
+		return
+	}
+
 	expiry := db.GetFileExpiry(id)
 
 	response := shared.DownloadResponse{
-		Name:       metadata.Name,
-		ID:         metadata.ID,
-		Chunks:     metadata.Chunks,
-		Size:       metadata.Length,
-		Downloads:  expiry.Downloads,
-		Expiration: expiry.Date,
+		Name:              metadata.Name,
+		ID:                metadata.ID,
+		Chunks:            metadata.Chunks,
+		Size:              metadata.Length,
+		Downloads:         expiry.Downloads,
+		Expiration:        expiry.Date,
+		EncryptionVersion: metadata.EncryptionVersion,
+		KDF:               metadata.KDF,
 	}
 
 	jsonData, _ := json.Marshal(response)
@@ -284,6 +329,11 @@ func DownloadChunkHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if db.IsSendOwnerSuspended(id) {
+		http.Error(w, "File unavailable", http.StatusForbidden)
+		return
+	}
+
 	var (
 		eof   bool
 		bytes []byte
@@ -323,5 +373,39 @@ func DownloadChunkHandler(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set("Date", fmt.Sprintf("%s", exp.Date.String()))
 	}
 
+	db.RecordDownloadStats(int64(len(bytes)))
+
 	_, _ = w.Write(bytes)
 }
+
+// ReportHandler handles reports submitted by recipients of a Send link that
+// contains abusive content, which are queued for review by the instance admin.
+func ReportHandler(w http.ResponseWriter, req *http.Request) {
+	segments := strings.Split(req.URL.Path, "/")
+	id := segments[len(segments)-1]
+
+	var report shared.SendReport
+	err := utils.LimitedJSONReader(w, req.Body).Decode(&report)
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	} else if !isValidReportReason(report.Reason) {
+		http.Error(w, "Invalid report reason", http.StatusBadRequest)
+		return
+	} else if len(report.Details) > constants.MaxReportDetailsLen {
+		http.Error(w, "Report details too long", http.StatusBadRequest)
+		return
+	}
+
+	if !db.MetadataIDExists(id) {
+		http.Error(w, "No file found", http.StatusNotFound)
+		return
+	}
+
+	err = db.AddReport(id, report)
+	if err != nil {
+		log.Printf("Error adding send report: %v\n", err)
+		http.Error(w, "Error submitting report", http.StatusInternalServerError)
+		return
+	}
+}
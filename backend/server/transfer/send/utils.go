@@ -9,6 +9,8 @@ import (
 	"yeetfile/backend/config"
 	"yeetfile/backend/db"
 	"yeetfile/backend/server/session"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
 var OutOfSpaceError = errors.New("not enough space to upload")
@@ -28,6 +30,21 @@ func UserCanSend(size int64, req *http.Request) (bool, error) {
 	}
 
 	id := session.GetSessionUserID(s)
+
+	// Members of an organization draw from the organization's pool
+	orgLimits, pooled, err := db.GetOrgSendLimits(id)
+	if err != nil {
+		log.Printf("Error validating ability to upload: %v\n", err)
+		return false, err
+	} else if pooled {
+		if orgLimits.Remaining() < size {
+			log.Printf("[Send] Out of org space: %d < %d", orgLimits.Remaining(), size)
+			return false, OutOfSpaceError
+		}
+
+		return true, nil
+	}
+
 	usedSend, availableSend, err := db.GetUserSendLimits(id)
 	if err != nil {
 		log.Printf("Error validating ability to upload: %v\n", err)
@@ -81,3 +98,25 @@ func validateSendExpiry(expiration time.Duration) error {
 
 	return nil
 }
+
+// getSendKDFParams validates the key derivation parameters used for a Send
+// link's password. Older clients don't send any parameters, in which case the
+// original parameters are assumed.
+func getSendKDFParams(kdf shared.KDFParams) (shared.KDFParams, error) {
+	kdf = kdf.OrDefault(shared.LegacySendKDFParams())
+	return kdf, kdf.Validate()
+}
+
+// isValidReportReason checks that the reason provided in a report matches one
+// of the accepted report reasons.
+func isValidReportReason(reason string) bool {
+	switch reason {
+	case constants.ReportReasonMalware,
+		constants.ReportReasonIllegal,
+		constants.ReportReasonPhishing,
+		constants.ReportReasonOther:
+		return true
+	}
+
+	return false
+}
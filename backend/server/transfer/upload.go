@@ -1,8 +1,12 @@
 package transfer
 
 import (
+	"errors"
+	"slices"
+	"yeetfile/backend/config"
 	db "yeetfile/backend/db"
 	"yeetfile/backend/storage"
+	"yeetfile/shared/constants"
 )
 
 func PrepareUpload(
@@ -21,3 +25,18 @@ func PrepareUpload(
 
 	return fileChunk, uploadValues, nil
 }
+
+var UnsupportedEncryptionErr = errors.New("unsupported file encryption version")
+
+// GetEncryptionVersion validates the file encryption version requested by the
+// client. Older clients don't send a version, in which case the original
+// format is assumed.
+func GetEncryptionVersion(version int) (int, error) {
+	if version == 0 {
+		return constants.FileEncryptionV1, nil
+	} else if !slices.Contains(config.SupportedFileEncryption, version) {
+		return 0, UnsupportedEncryptionErr
+	}
+
+	return version, nil
+}
@@ -252,6 +252,12 @@ func UploadMetadataHandler(w http.ResponseWriter, req *http.Request, userID stri
 		return
 	}
 
+	upload.EncryptionVersion, err = transfer.GetEncryptionVersion(upload.EncryptionVersion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if upload.PasswordData == nil || len(upload.PasswordData) == 0 {
 		err = CanUserUpload(upload.Length, userID, upload.FolderID)
 		if err != nil {
@@ -347,8 +353,10 @@ func UploadDataHandler(w http.ResponseWriter, req *http.Request, userID string)
 	}
 
 	if err != nil {
+		// The chunk's size isn't added to the user's storage if it exceeds
+		// their limit, so only the previous chunks need to be removed
 		if metadata.OwnsParentFolder {
-			abortUpload(metadata, userID, totalSize, chunkNum)
+			abortUpload(metadata, userID, 0, chunkNum)
 		}
 		http.Error(w, "Attempting to upload beyond max storage",
 			http.StatusBadRequest)
@@ -398,6 +406,11 @@ func DownloadHandler(w http.ResponseWriter, req *http.Request, userID string) {
 		return
 	}
 
+	if db.IsVaultItemOwnerSuspended(metadata.RefID) {
+		http.Error(w, "File unavailable", http.StatusForbidden)
+		return
+	}
+
 	// If storage limits are in place, track bandwidth usage to prevent
 	// excessive repeated downloads
 	if config.YeetFileConfig.DefaultUserStorage > 0 {
@@ -425,12 +438,13 @@ func DownloadHandler(w http.ResponseWriter, req *http.Request, userID string) {
 	}
 
 	response := shared.VaultDownloadResponse{
-		Name:         metadata.Name,
-		ID:           downloadID,
-		Chunks:       metadata.Chunks,
-		Size:         metadata.Length,
-		ProtectedKey: metadata.ProtectedKey,
-		PasswordData: metadata.PasswordData,
+		Name:              metadata.Name,
+		ID:                downloadID,
+		Chunks:            metadata.Chunks,
+		Size:              metadata.Length,
+		ProtectedKey:      metadata.ProtectedKey,
+		PasswordData:      metadata.PasswordData,
+		EncryptionVersion: metadata.EncryptionVersion,
 	}
 
 	jsonData, _ := json.Marshal(response)
@@ -497,6 +511,8 @@ func DownloadChunkHandler(w http.ResponseWriter, req *http.Request, userID strin
 		log.Printf("Error updating bandwidth: %v\n", err)
 	}
 
+	db.RecordDownloadStats(int64(len(bytes)))
+
 	_, _ = w.Write(bytes)
 }
 
@@ -23,8 +23,32 @@ func CanUserUpload(size int64, userID, folderID string) error {
 		return nil
 	}
 
+	// Storage is drawn from the folder owner, who may be different from the
+	// user if uploading to a shared folder
+	ownerID := userID
+	if len(folderID) > 0 && folderID != userID {
+		ownerID, err = db.GetFolderOwner(folderID)
+		if err != nil {
+			log.Printf("Error validating ability to upload: %v\n", err)
+			return err
+		}
+	}
+
+	// Members of an organization draw from the organization's pool
+	orgLimits, pooled, err := db.GetOrgStorageLimits(ownerID)
+	if err != nil {
+		log.Printf("Error validating ability to upload: %v\n", err)
+		return err
+	} else if pooled {
+		if orgLimits.Remaining() < size {
+			return OutOfSpaceError
+		}
+
+		return nil
+	}
+
 	// Validate that the user has enough space to upload this file
-	if len(folderID) == 0 || folderID == userID {
+	if ownerID == userID {
 		usedStorage, availableStorage, err = db.GetUserStorageLimits(userID)
 	} else {
 		usedStorage, availableStorage, err = db.GetFolderOwnerStorage(folderID)
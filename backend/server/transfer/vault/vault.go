@@ -192,10 +192,7 @@ func deleteVaultFile(id, userID string, isShared bool) (int64, error) {
 
 func abortUpload(metadata db.FileMetadata, userID string, chunkLen int64, chunkNum int) {
 	storage.DeleteFileByMetadata(metadata)
-	totalSize := chunkLen
-	for chunkNum > 1 {
-		totalSize += int64(constants.ChunkSize)
-	}
+	totalSize := chunkLen + int64(chunkNum-1)*int64(constants.ChunkSize)
 
 	err := db.UpdateStorageUsed(userID, -totalSize)
 	if err != nil {
@@ -0,0 +1,93 @@
+package upgrades
+
+import (
+	"time"
+)
+
+// Purchase is the subset of a completed invoice needed to determine how the
+// upgrades it applied should be reduced after a refund.
+type Purchase struct {
+	Amount int64
+	Date   time.Time
+
+	// VaultStart is the start of the vault upgrade period that was paid for
+	// by the purchase. This is later than Date if the purchase extended time
+	// that the user had already paid for. Defaults to Date if not set.
+	VaultStart time.Time
+	VaultTag   string
+	VaultExp   time.Time
+
+	SendBytes int64
+}
+
+// RefundAdjustment describes the changes that should be made to a user's
+// upgrades after all or part of a purchase was returned to them.
+type RefundAdjustment struct {
+	// VaultExp is the new (earlier) expiration of the vault upgrade, or a
+	// zero time if the purchase didn't include a vault upgrade
+	VaultExp time.Time
+
+	// RevokeVault is true if the vault upgrade should end immediately
+	RevokeVault bool
+
+	// SendBytes is the amount of send that should be removed from the user
+	SendBytes int64
+}
+
+// IsCurrentVaultUpgrade returns true if the vault upgrade from the purchase is
+// still the user's current vault upgrade, given the user's current upgrade tag
+// and expiration. A refund or dispute may have already revoked the upgrade
+// (clearing the tag) or shortened it, but any other tag or a later expiration
+// means that the user has purchased a newer vault upgrade.
+func (p Purchase) IsCurrentVaultUpgrade(subTag string, subExp time.Time) bool {
+	if len(subTag) > 0 && subTag != p.VaultTag {
+		return false
+	}
+
+	return subExp.Sub(p.VaultExp) < time.Second
+}
+
+// ProrateRefund determines how much of a purchase's upgrades should be removed
+// when the total refunded amount (in cents) increases from prevRefunded to
+// refunded. Vault upgrades are shortened in proportion to the total refunded
+// amount (only within the period paid for by the purchase), and send upgrades
+// lose the portion covered by the new refund.
+func ProrateRefund(
+	purchase Purchase,
+	prevRefunded int64,
+	refunded int64,
+	now time.Time,
+) RefundAdjustment {
+	var adjustment RefundAdjustment
+	if purchase.Amount <= 0 {
+		// Nothing was paid, so the entire purchase is reversed
+		adjustment.RevokeVault = !purchase.VaultExp.IsZero()
+		adjustment.SendBytes = purchase.SendBytes
+		return adjustment
+	}
+
+	refunded = min(max(refunded, 0), purchase.Amount)
+	prevRefunded = min(max(prevRefunded, 0), refunded)
+
+	if !purchase.VaultExp.IsZero() {
+		start := purchase.VaultStart
+		if start.IsZero() || start.Before(purchase.Date) {
+			start = purchase.Date
+		}
+
+		duration := purchase.VaultExp.Sub(start)
+		remaining := purchase.Amount - refunded
+		kept := time.Duration(
+			float64(duration) * float64(remaining) / float64(purchase.Amount))
+
+		adjustment.VaultExp = start.Add(kept)
+		adjustment.RevokeVault = !adjustment.VaultExp.After(now)
+	}
+
+	if purchase.SendBytes > 0 {
+		delta := refunded - prevRefunded
+		adjustment.SendBytes = purchase.SendBytes * delta / purchase.Amount
+	}
+
+	return adjustment
+}
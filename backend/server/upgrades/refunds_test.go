@@ -0,0 +1,31 @@
+package upgrades
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIsCurrentVaultUpgrade(t *testing.T) {
+	now := time.Now().UTC()
+	purchase := Purchase{
+		Date:     now,
+		VaultTag: "vault-1tb",
+		VaultExp: now.AddDate(0, 1, 0),
+	}
+
+	// Unchanged since the purchase
+	assert.True(t, purchase.IsCurrentVaultUpgrade(purchase.VaultTag, purchase.VaultExp))
+
+	// Shortened or revoked by an earlier refund or dispute
+	assert.True(t, purchase.IsCurrentVaultUpgrade(purchase.VaultTag, now.AddDate(0, 0, 7)))
+	assert.True(t, purchase.IsCurrentVaultUpgrade("", now))
+
+	// Extended by a newer purchase
+	assert.False(t, purchase.IsCurrentVaultUpgrade(
+		purchase.VaultTag,
+		purchase.VaultExp.AddDate(0, 1, 0)))
+
+	// Replaced by a different upgrade, even one that expires sooner
+	assert.False(t, purchase.IsCurrentVaultUpgrade("vault-100gb", now.AddDate(0, 0, 7)))
+}
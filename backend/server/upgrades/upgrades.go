@@ -4,19 +4,76 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"net/url"
 	"os"
+	"regexp"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 	"yeetfile/backend/utils"
 	"yeetfile/shared"
 )
 
-var upgrades *shared.Upgrades
+var (
+	upgrades     *shared.Upgrades
+	envUpgrades  *shared.Upgrades
+	upgradesLock sync.RWMutex
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
 
 func GetAllUpgrades() *shared.Upgrades {
+	upgradesLock.RLock()
+	defer upgradesLock.RUnlock()
 	return upgrades
 }
 
+// GetEnvUpgrades returns the upgrades defined in the YEETFILE_UPGRADES_JSON
+// environment variable, which are used to seed the upgrade catalog in the db.
+func GetEnvUpgrades() *shared.Upgrades {
+	return envUpgrades
+}
+
+// SetUpgrades replaces the current upgrade catalog. The previous catalog is
+// left unmodified, so that any callers still holding it aren't affected.
+func SetUpgrades(catalog *shared.Upgrades) {
+	finalizeUpgrades(catalog.SendUpgrades, false)
+	finalizeUpgrades(catalog.VaultUpgrades, true)
+
+	upgradesLock.Lock()
+	defer upgradesLock.Unlock()
+	upgrades = catalog
+}
+
+// ValidateUpgrade checks that an upgrade in the catalog has all of the fields
+// needed for it to be purchased.
+func ValidateUpgrade(upgrade shared.Upgrade) error {
+	if !tagPattern.MatchString(upgrade.Tag) {
+		return errors.New("tag must be lowercase letters, numbers, '-', or '_'")
+	} else if len(strings.TrimSpace(upgrade.Name)) == 0 {
+		return errors.New("missing upgrade name")
+	} else if upgrade.Price <= 0 {
+		return errors.New("price must be greater than 0")
+	} else if upgrade.Bytes <= 0 {
+		return errors.New("bytes must be greater than 0")
+	} else if !upgrade.IsVaultUpgrade && upgrade.Annual {
+		return errors.New("send upgrades cannot be annual")
+	}
+
+	if len(upgrade.BTCPayLink) > 0 {
+		link, err := url.Parse(upgrade.BTCPayLink)
+		if err != nil ||
+			(link.Scheme != "https" && link.Scheme != "http") ||
+			len(link.Host) == 0 ||
+			len(link.RawQuery) > 0 {
+			return errors.New("invalid BTCPay link")
+		}
+	}
+
+	return nil
+}
+
 // GetUpgradeExpiration returns a point in time in the future that the user's
 // selected vault upgrade should expire.
 func GetUpgradeExpiration(upgrade shared.Upgrade, quantity int) (time.Time, error) {
@@ -33,6 +90,16 @@ func GetUpgradeExpiration(upgrade shared.Upgrade, quantity int) (time.Time, erro
 	}
 }
 
+// GetUpgradePeriodStart returns the start of the vault upgrade period that
+// ends at exp, for the provided quantity of the upgrade
+func GetUpgradePeriodStart(upgrade shared.Upgrade, quantity int, exp time.Time) time.Time {
+	if upgrade.Annual {
+		return exp.AddDate(-1*quantity, 0, 0)
+	}
+
+	return exp.AddDate(0, -1*quantity, 0)
+}
+
 func GetVaultUpgrades(annual bool, upgrades []*shared.Upgrade) []*shared.Upgrade {
 	var result []*shared.Upgrade
 	for _, upgrade := range upgrades {
@@ -62,43 +129,41 @@ func GetUpgradeByTag(tag string, upgrades *shared.Upgrades) (shared.Upgrade, err
 	return shared.Upgrade{}, errors.New("upgrade not found")
 }
 
+// finalizeUpgrades sets the fields that aren't stored in the catalog, and
+// sorts the upgrades by price.
+func finalizeUpgrades(subUpgrades []*shared.Upgrade, isVaultUpgrade bool) {
+	for _, upgrade := range subUpgrades {
+		upgrade.ReadableBytes = shared.ReadableFileSize(upgrade.Bytes)
+		upgrade.IsVaultUpgrade = isVaultUpgrade
+	}
+
+	sort.Slice(subUpgrades, func(i, j int) bool {
+		return subUpgrades[i].Price < subUpgrades[j].Price
+	})
+}
+
 func init() {
+	envUpgrades = &shared.Upgrades{
+		SendUpgrades:  []*shared.Upgrade{},
+		VaultUpgrades: []*shared.Upgrade{},
+	}
+
 	upgradesJson := os.Getenv("YEETFILE_UPGRADES_JSON")
 	if len(upgradesJson) > 0 {
-		err := json.Unmarshal([]byte(upgradesJson), &upgrades)
+		err := json.Unmarshal([]byte(upgradesJson), &envUpgrades)
 		if err != nil {
 			log.Fatalln("Error reading upgrades json:", err)
 		}
-	} else {
-		upgrades = &shared.Upgrades{
-			SendUpgrades:  []*shared.Upgrade{},
-			VaultUpgrades: []*shared.Upgrade{},
-		}
-		return
-	}
 
-	finalizeUpgrades := func(subUpgrades []*shared.Upgrade, isVaultUpgrade bool) {
-		for _, upgrade := range subUpgrades {
+		for _, upgrade := range append(envUpgrades.SendUpgrades, envUpgrades.VaultUpgrades...) {
 			if len(upgrade.Tag) == 0 {
 				utils.LogStruct(upgrade)
 				log.Fatalln("Missing upgrade tag")
 			}
-
-			upgrade.ReadableBytes = shared.ReadableFileSize(upgrade.Bytes)
-			upgrade.IsVaultUpgrade = isVaultUpgrade
 		}
-
-		sort.Slice(subUpgrades, func(i, j int) bool {
-			return subUpgrades[i].Price < subUpgrades[j].Price
-		})
 	}
 
-	finalizeUpgrades(upgrades.SendUpgrades, false)
-	finalizeUpgrades(upgrades.VaultUpgrades, true)
-
-	if len(upgrades.SendUpgrades) > 0 || len(upgrades.VaultUpgrades) > 0 {
-		log.Printf("-- Loaded %d send upgrades and %d vault upgrades\n",
-			len(upgrades.SendUpgrades),
-			len(upgrades.VaultUpgrades))
-	}
+	// The catalog is replaced with the upgrades stored in the db once the
+	// db has been initialized
+	SetUpgrades(envUpgrades)
 }
@@ -54,6 +54,27 @@ func GetEnvVarBytesB64(key string, fallback []byte) []byte {
 	return decoded
 }
 
+// GetEnvVarBytesB64List retrieves a comma-separated list of base64 strings
+// from the environment and returns each value as a []byte.
+func GetEnvVarBytesB64List(key string) [][]byte {
+	value := GetEnvVar(key, "")
+	if value == "" {
+		return nil
+	}
+
+	var values [][]byte
+	for _, b64Str := range strings.Split(value, ",") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64Str))
+		if err != nil {
+			log.Fatalf("Error decoding %s (this should be a list of base64 values)", key)
+		}
+
+		values = append(values, decoded)
+	}
+
+	return values
+}
+
 // GetEnvVarInt retrieves a string value from the environment and converts it
 // into an integer.
 func GetEnvVarInt(key string, fallback int) int {
@@ -87,6 +108,28 @@ func GetEnvVarInt64(key string, fallback int64) int64 {
 	return num
 }
 
+// GetEnvVarIntList retrieves a comma-separated string value from the
+// environment and converts it into a slice of integers.
+func GetEnvVarIntList(key string, fallback []int) []int {
+	value := GetEnvVar(key, "")
+	if value == "" {
+		return fallback
+	}
+
+	var nums []int
+	for _, numStr := range strings.Split(value, ",") {
+		num, err := strconv.Atoi(strings.TrimSpace(numStr))
+		if err != nil {
+			log.Printf("WARNING: Value for %s is not a valid list of numbers, using fallback...\n", key)
+			return fallback
+		}
+
+		nums = append(nums, num)
+	}
+
+	return nums
+}
+
 // GetEnvVarBool retrieves a value from the environment and interprets it as a
 // bool value -- 0/n/false == false, 1/y/true == true
 func GetEnvVarBool(key string, fallback bool) bool {
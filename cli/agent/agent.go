@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"errors"
+	"time"
+	"yeetfile/cli/crypto"
+)
+
+// The YeetFile agent is a small daemon (similar to ssh-agent) that holds the
+// CLI session key and the user's unlocked key pair in memory, so that they
+// don't need to be exported in the user's environment. The agent listens on a
+// Unix socket that only accepts connections from processes owned by the same
+// user, and forgets everything it holds once it has been idle for too long.
+
+const (
+	DefaultIdleTimeout = 15 * time.Minute
+
+	maxMessageSize = 64 * 1024
+)
+
+type action string
+
+const (
+	statusAction     action = "status"
+	getAction        action = "get"
+	setCLIKeyAction  action = "set-cli-key"
+	setKeyPairAction action = "set-key-pair"
+	forgetAction     action = "forget"
+	lockAction       action = "lock"
+	unlockAction     action = "unlock"
+	stopAction       action = "stop"
+)
+
+var (
+	NotRunningErr          = errors.New("the yeetfile agent is not running")
+	AlreadyRunningErr      = errors.New("the yeetfile agent is already running")
+	LockedErr              = errors.New("the yeetfile agent is locked")
+	NotLockedErr           = errors.New("the yeetfile agent is not locked")
+	EmptyErr               = errors.New("the yeetfile agent isn't holding any keys")
+	IncorrectPassphraseErr = errors.New("incorrect passphrase")
+	UnsupportedPlatformErr = errors.New("the yeetfile agent isn't supported on this platform")
+)
+
+// Keys are the secrets held by the agent
+type Keys struct {
+	CLIKey  []byte         `json:"cliKey"`
+	KeyPair crypto.KeyPair `json:"keyPair"`
+}
+
+// Status describes the current state of the agent
+type Status struct {
+	Locked      bool          `json:"locked"`
+	HasCLIKey   bool          `json:"hasCLIKey"`
+	HasKeyPair  bool          `json:"hasKeyPair"`
+	IdleTimeout time.Duration `json:"idleTimeout"`
+}
+
+type request struct {
+	Action     action `json:"action"`
+	Keys       Keys   `json:"keys"`
+	Passphrase []byte `json:"passphrase,omitempty"`
+}
+
+type response struct {
+	Error  string `json:"error,omitempty"`
+	Keys   Keys   `json:"keys"`
+	Status Status `json:"status"`
+}
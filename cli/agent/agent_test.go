@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"yeetfile/cli/crypto"
+)
+
+func startTestAgent(t *testing.T, idleTimeout time.Duration) *Client {
+	if !peerCredentialsSupported {
+		t.Skip("agent isn't supported on this platform")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	go func() {
+		if err := Serve(socketPath, idleTimeout); err != nil {
+			t.Errorf("Error running agent: %v", err)
+		}
+	}()
+
+	client := NewClient(socketPath)
+	for i := 0; i < 50; i++ {
+		if _, err := client.Status(); err == nil {
+			t.Cleanup(func() { _ = client.Stop() })
+			return client
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("Agent didn't start")
+	return nil
+}
+
+func TestAgentKeys(t *testing.T) {
+	client := startTestAgent(t, 0)
+
+	if _, err := client.CLIKey(); !errors.Is(err, EmptyErr) {
+		t.Fatalf("Expected empty agent, got %v", err)
+	}
+
+	cliKey := []byte("cli-key")
+	kp := crypto.KeyPair{PrivateKey: []byte("private"), PublicKey: []byte("public")}
+	if err := client.SetCLIKey(cliKey); err != nil {
+		t.Fatal(err)
+	} else if err = client.SetKeyPair(kp); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := client.Keys()
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(keys.CLIKey, cliKey) ||
+		!bytes.Equal(keys.KeyPair.PrivateKey, kp.PrivateKey) {
+		t.Fatal("Agent returned unexpected keys")
+	}
+
+	// Setting a new CLI key discards the previous key pair
+	if err = client.SetCLIKey([]byte("new-cli-key")); err != nil {
+		t.Fatal(err)
+	} else if _, err = client.KeyPair(); !errors.Is(err, EmptyErr) {
+		t.Fatalf("Expected key pair to be discarded, got %v", err)
+	}
+
+	if err = client.Forget(); err != nil {
+		t.Fatal(err)
+	} else if _, err = client.CLIKey(); !errors.Is(err, EmptyErr) {
+		t.Fatalf("Expected empty agent, got %v", err)
+	}
+}
+
+func TestAgentLock(t *testing.T) {
+	client := startTestAgent(t, 0)
+
+	cliKey := []byte("cli-key")
+	if err := client.SetCLIKey(cliKey); err != nil {
+		t.Fatal(err)
+	} else if err = client.Lock([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CLIKey(); !errors.Is(err, LockedErr) {
+		t.Fatalf("Expected locked agent, got %v", err)
+	} else if err = client.Unlock([]byte("wrong")); !errors.Is(err, IncorrectPassphraseErr) {
+		t.Fatalf("Expected incorrect passphrase, got %v", err)
+	} else if err = client.Unlock([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := client.CLIKey()
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(key, cliKey) {
+		t.Fatal("Agent returned unexpected key after unlocking")
+	}
+}
+
+func TestAgentIdleTimeout(t *testing.T) {
+	client := startTestAgent(t, 100*time.Millisecond)
+
+	if err := client.SetCLIKey([]byte("cli-key")); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if _, err := client.CLIKey(); !errors.Is(err, EmptyErr) {
+		t.Fatalf("Expected keys to be forgotten, got %v", err)
+	}
+}
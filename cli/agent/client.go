@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"time"
+	"yeetfile/cli/crypto"
+)
+
+// Client sends requests to the agent listening on a Unix socket
+type Client struct {
+	socketPath string
+}
+
+func NewClient(socketPath string) *Client {
+	return &Client{socketPath: socketPath}
+}
+
+// Status returns the current state of the agent, or NotRunningErr if the agent
+// isn't running
+func (c *Client) Status() (Status, error) {
+	resp, err := c.send(request{Action: statusAction})
+	return resp.Status, err
+}
+
+// Keys returns the keys currently held by the agent
+func (c *Client) Keys() (Keys, error) {
+	resp, err := c.send(request{Action: getAction})
+	return resp.Keys, err
+}
+
+// CLIKey returns the CLI session key held by the agent
+func (c *Client) CLIKey() ([]byte, error) {
+	keys, err := c.Keys()
+	if err != nil {
+		return nil, err
+	} else if len(keys.CLIKey) == 0 {
+		return nil, EmptyErr
+	}
+
+	return keys.CLIKey, nil
+}
+
+// KeyPair returns the unlocked key pair held by the agent
+func (c *Client) KeyPair() (crypto.KeyPair, error) {
+	keys, err := c.Keys()
+	if err != nil {
+		return crypto.KeyPair{}, err
+	} else if len(keys.KeyPair.PrivateKey) == 0 {
+		return crypto.KeyPair{}, EmptyErr
+	}
+
+	return keys.KeyPair, nil
+}
+
+// SetCLIKey replaces everything held by the agent with a new CLI session key
+func (c *Client) SetCLIKey(cliKey []byte) error {
+	_, err := c.send(request{Action: setCLIKeyAction, Keys: Keys{CLIKey: cliKey}})
+	return err
+}
+
+// SetKeyPair stores the user's unlocked key pair in the agent
+func (c *Client) SetKeyPair(kp crypto.KeyPair) error {
+	_, err := c.send(request{Action: setKeyPairAction, Keys: Keys{KeyPair: kp}})
+	return err
+}
+
+// Forget discards everything held by the agent
+func (c *Client) Forget() error {
+	_, err := c.send(request{Action: forgetAction})
+	return err
+}
+
+// Lock encrypts the keys held by the agent with a passphrase until the agent
+// is unlocked again
+func (c *Client) Lock(passphrase []byte) error {
+	_, err := c.send(request{Action: lockAction, Passphrase: passphrase})
+	return err
+}
+
+// Unlock decrypts the keys held by a locked agent
+func (c *Client) Unlock(passphrase []byte) error {
+	_, err := c.send(request{Action: unlockAction, Passphrase: passphrase})
+	return err
+}
+
+// Stop shuts down the agent, discarding everything it holds
+func (c *Client) Stop() error {
+	_, err := c.send(request{Action: stopAction})
+	return err
+}
+
+func (c *Client) send(req request) (response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, time.Second)
+	if err != nil {
+		return response{}, NotRunningErr
+	}
+
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	err = json.NewEncoder(conn).Encode(req)
+	if err != nil {
+		return response{}, err
+	}
+
+	var resp response
+	err = json.NewDecoder(io.LimitReader(conn, maxMessageSize)).Decode(&resp)
+	if err != nil {
+		return response{}, err
+	} else if len(resp.Error) > 0 {
+		return resp, responseErr(resp.Error)
+	}
+
+	return resp, nil
+}
+
+// responseErr converts an error message returned by the agent back into one of
+// the agent's errors, so that it can be compared with errors.Is
+func responseErr(msg string) error {
+	for _, err := range []error{
+		LockedErr,
+		NotLockedErr,
+		EmptyErr,
+		IncorrectPassphraseErr,
+	} {
+		if err.Error() == msg {
+			return err
+		}
+	}
+
+	return errors.New(msg)
+}
@@ -0,0 +1,34 @@
+//go:build darwin
+
+package agent
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+const peerCredentialsSupported = true
+
+// peerUID returns the ID of the user that owns the process on the other end of
+// the connection
+func peerUID(conn *net.UnixConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	var cred *unix.Xucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	})
+
+	if err != nil {
+		return -1, err
+	} else if credErr != nil {
+		return -1, credErr
+	}
+
+	return int(cred.Uid), nil
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package agent
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+const peerCredentialsSupported = true
+
+// peerUID returns the ID of the user that owns the process on the other end of
+// the connection
+func peerUID(conn *net.UnixConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+
+	if err != nil {
+		return -1, err
+	} else if credErr != nil {
+		return -1, credErr
+	}
+
+	return int(cred.Uid), nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"net"
+)
+
+const peerCredentialsSupported = false
+
+// peerUID isn't implemented on platforms without a way to check the owner of
+// a Unix socket peer, so the agent refuses to run on them
+func peerUID(_ *net.UnixConn) (int, error) {
+	return -1, UnsupportedPlatformErr
+}
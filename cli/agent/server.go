@@ -0,0 +1,304 @@
+package agent
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared/constants"
+)
+
+type server struct {
+	mu          sync.Mutex
+	keys        Keys
+	locked      []byte
+	lockSalt    []byte
+	idleTimeout time.Duration
+	idleTimer   *time.Timer
+	listener    net.Listener
+
+	// generation is incremented whenever the idle timer is reset or the keys
+	// are cleared, so that a timer that fires after being replaced doesn't
+	// discard keys that were stored after it was started
+	generation uint64
+}
+
+// Serve runs the agent on a Unix socket at socketPath until it's stopped or
+// interrupted. Everything held by the agent is forgotten after it hasn't been
+// used for idleTimeout (or never, if idleTimeout is 0).
+func Serve(socketPath string, idleTimeout time.Duration) error {
+	if !peerCredentialsSupported {
+		return UnsupportedPlatformErr
+	}
+
+	if _, err := NewClient(socketPath).Status(); err == nil {
+		return AlreadyRunningErr
+	}
+
+	// Remove the socket left behind by an agent that didn't exit cleanly
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	listener, err := listen(socketPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = listener.Close()
+		_ = os.Remove(socketPath)
+	}()
+
+	s := &server{idleTimeout: idleTimeout, listener: listener}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			s.forget()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		go s.handleConn(conn.(*net.UnixConn))
+	}
+}
+
+// listen creates the agent's socket inside a new directory that only the
+// current user can access, and then moves it to socketPath. This prevents
+// other users from connecting to the socket before its permissions are set.
+func listen(socketPath string) (*net.UnixListener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(socketPath), ".agent-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	tmpPath := filepath.Join(dir, filepath.Base(socketPath))
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+
+	// The socket is removed by Serve once it has been moved to socketPath
+	listener.SetUnlinkOnClose(false)
+
+	err = os.Chmod(tmpPath, 0600)
+	if err == nil {
+		err = os.Rename(tmpPath, socketPath)
+	}
+
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// handleConn handles a single request from a client, after checking that the
+// client is owned by the same user as the agent
+func (s *server) handleConn(conn *net.UnixConn) {
+	defer conn.Close()
+
+	uid, err := peerUID(conn)
+	if err != nil {
+		log.Printf("Unable to verify agent client: %v\n", err)
+		return
+	} else if uid != os.Getuid() {
+		log.Printf("Rejected agent client owned by uid %d\n", uid)
+		return
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var req request
+	err = json.NewDecoder(io.LimitReader(conn, maxMessageSize)).Decode(&req)
+	if err != nil {
+		return
+	}
+
+	resp := s.handleRequest(req)
+	_ = json.NewEncoder(conn).Encode(resp)
+
+	if req.Action == stopAction {
+		_ = s.listener.Close()
+	}
+}
+
+func (s *server) handleRequest(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	var resp response
+	switch req.Action {
+	case statusAction, stopAction:
+	case getAction:
+		if s.locked != nil {
+			err = LockedErr
+		} else if len(s.keys.CLIKey) == 0 && len(s.keys.KeyPair.PrivateKey) == 0 {
+			err = EmptyErr
+		} else {
+			resp.Keys = s.keys
+			s.resetIdleTimer()
+		}
+	case setCLIKeyAction:
+		// A new CLI key means the user logged in again, so the previously
+		// unlocked key pair (if any) is no longer valid
+		s.locked = nil
+		s.keys = Keys{CLIKey: req.Keys.CLIKey}
+		s.resetIdleTimer()
+	case setKeyPairAction:
+		if s.locked != nil {
+			err = LockedErr
+		} else {
+			s.keys.KeyPair = req.Keys.KeyPair
+			s.resetIdleTimer()
+		}
+	case forgetAction:
+		s.clear()
+	case lockAction:
+		err = s.lock(req.Passphrase)
+	case unlockAction:
+		err = s.unlock(req.Passphrase)
+	default:
+		err = errors.New("invalid agent request")
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	resp.Status = s.status()
+	return resp
+}
+
+// lock encrypts the keys held by the agent with a passphrase, and discards
+// the unencrypted keys until the agent is unlocked with the same passphrase
+func (s *server) lock(passphrase []byte) error {
+	if s.locked != nil {
+		return LockedErr
+	} else if len(passphrase) == 0 {
+		return errors.New("passphrase cannot be blank")
+	}
+
+	keys, err := json.Marshal(s.keys)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, constants.KeySize)
+	if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+
+	locked, err := crypto.EncryptChunk(crypto.DerivePBKDFKey(passphrase, salt), keys)
+	if err != nil {
+		return err
+	}
+
+	s.keys = Keys{}
+	s.locked = locked
+	s.lockSalt = salt
+	return nil
+}
+
+// unlock decrypts the keys that were encrypted when the agent was locked
+func (s *server) unlock(passphrase []byte) error {
+	if s.locked == nil {
+		return NotLockedErr
+	}
+
+	keyBytes, err := crypto.DecryptChunk(
+		crypto.DerivePBKDFKey(passphrase, s.lockSalt),
+		s.locked)
+	if err != nil {
+		return IncorrectPassphraseErr
+	}
+
+	var keys Keys
+	if err = json.Unmarshal(keyBytes, &keys); err != nil {
+		return err
+	}
+
+	s.keys = keys
+	s.locked = nil
+	s.lockSalt = nil
+	s.resetIdleTimer()
+	return nil
+}
+
+// resetIdleTimer restarts the countdown until the agent forgets its keys
+func (s *server) resetIdleTimer() {
+	if s.idleTimeout <= 0 {
+		return
+	}
+
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+
+	s.generation++
+	generation := s.generation
+	s.idleTimer = time.AfterFunc(s.idleTimeout, func() {
+		s.expire(generation)
+	})
+}
+
+// expire discards the agent's keys when the idle timer fires, unless the timer
+// has been reset since it was started
+func (s *server) expire(generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generation == generation {
+		s.clear()
+	}
+}
+
+func (s *server) forget() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clear()
+}
+
+// clear discards all keys held by the agent, including locked keys
+func (s *server) clear() {
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+
+	s.generation++
+	s.keys = Keys{}
+	s.locked = nil
+	s.lockSalt = nil
+}
+
+func (s *server) status() Status {
+	return Status{
+		Locked:      s.locked != nil,
+		HasCLIKey:   len(s.keys.CLIKey) > 0,
+		HasKeyPair:  len(s.keys.KeyPair.PrivateKey) > 0,
+		IdleTimeout: s.idleTimeout,
+	}
+}
@@ -45,7 +45,10 @@ func setupTestUser() TestUser {
 		log.Fatal("Failed to sign up test user")
 	}
 
-	signupKeys, err := crypto.GenerateSignupKeys(signup.Identifier, userPassword)
+	signupKeys, err := crypto.GenerateSignupKeys(
+		signup.Identifier,
+		userPassword,
+		shared.LegacyKDFParams())
 
 	verifyAcct := shared.VerifyAccount{
 		ID:                      signup.Identifier,
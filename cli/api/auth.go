@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"yeetfile/cli/requests"
 	"yeetfile/cli/utils"
@@ -103,7 +104,9 @@ func (ctx *Context) VerifyAccount(account shared.VerifyAccount) error {
 		return err
 	}
 
-	if response.StatusCode != http.StatusOK {
+	// Accepted indicates the account was created but is pending approval
+	if response.StatusCode != http.StatusOK &&
+		response.StatusCode != http.StatusAccepted {
 		if response.StatusCode == http.StatusUnauthorized {
 			return errors.New("incorrect verification code")
 		}
@@ -168,6 +171,33 @@ func (ctx *Context) VerifyEmail(email, code string) error {
 	return nil
 }
 
+// GetKDFParams fetches the key derivation parameters for the account matching
+// the identifier, along with the parameters recommended by the server. Servers
+// that don't store per-account parameters only support the original ones.
+func (ctx *Context) GetKDFParams(identifier string) (shared.KDFResponse, error) {
+	endpoint := endpoints.KDF.Format(ctx.Server) +
+		"?identifier=" + url.QueryEscape(identifier)
+	resp, err := requests.GetRequest(ctx.Session, endpoint)
+	if err != nil {
+		return shared.KDFResponse{}, err
+	} else if resp.StatusCode == http.StatusNotFound {
+		return shared.KDFResponse{
+			KDF:            shared.LegacyKDFParams(),
+			RecommendedKDF: shared.LegacyKDFParams(),
+		}, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.KDFResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var kdf shared.KDFResponse
+	err = json.NewDecoder(resp.Body).Decode(&kdf)
+	if err != nil {
+		return shared.KDFResponse{}, err
+	}
+
+	return kdf.Validated()
+}
+
 // GetSession returns the current session info.
 func (ctx *Context) GetSession() (shared.SessionInfo, error) {
 	url := endpoints.Session.Format(ctx.Server)
@@ -201,24 +231,80 @@ func (ctx *Context) LogOut() error {
 	return nil
 }
 
-// GetUserProtectedKey retrieves the user's private key, which has been
+// GetUserProtectedKey retrieves the user's private keys, which have been
 // encrypted with their unique user key before upload.
-func (ctx *Context) GetUserProtectedKey() ([]byte, error) {
+func (ctx *Context) GetUserProtectedKey() (shared.ProtectedKeyResponse, error) {
 	url := endpoints.ProtectedKey.Format(ctx.Server)
 	resp, err := requests.GetRequest(ctx.Session, url)
 	if err != nil {
-		return nil, err
+		return shared.ProtectedKeyResponse{}, err
 	} else if resp.StatusCode != http.StatusOK {
-		return nil, utils.ParseHTTPError(resp)
+		return shared.ProtectedKeyResponse{}, utils.ParseHTTPError(resp)
 	}
 
 	var protectedKey shared.ProtectedKeyResponse
 	err = json.NewDecoder(resp.Body).Decode(&protectedKey)
 	if err != nil {
-		return nil, err
+		return shared.ProtectedKeyResponse{}, err
+	}
+
+	return protectedKey, err
+}
+
+// AddUserKey adds a new key pair to the user's account. The private key must
+// be encrypted with the user's key.
+func (ctx *Context) AddUserKey(key shared.UserKey) error {
+	reqData, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.UserKeys.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
 	}
 
-	return protectedKey.ProtectedKey, err
+	return nil
+}
+
+// GetWrappedKeys returns every key that is wrapped with the user's current
+// key pairs, which need to be re-wrapped when rotating the user's keys
+func (ctx *Context) GetWrappedKeys() (shared.WrappedKeysResponse, error) {
+	url := endpoints.RotateKeys.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.WrappedKeysResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.WrappedKeysResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var wrappedKeys shared.WrappedKeysResponse
+	err = json.NewDecoder(resp.Body).Decode(&wrappedKeys)
+	return wrappedKeys, err
+}
+
+// RotateKeys replaces the user's key pairs, along with every key that was
+// wrapped with the previous key pairs
+func (ctx *Context) RotateKeys(rotation shared.KeyRotation) (shared.KeyRotationResponse, error) {
+	reqData, err := json.Marshal(rotation)
+	if err != nil {
+		return shared.KeyRotationResponse{}, err
+	}
+
+	url := endpoints.RotateKeys.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.KeyRotationResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.KeyRotationResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var rotationResponse shared.KeyRotationResponse
+	err = json.NewDecoder(resp.Body).Decode(&rotationResponse)
+	return rotationResponse, err
 }
 
 // StartChangeEmail initiates the process for changing a user's email. If the
@@ -339,6 +425,102 @@ func (ctx *Context) ForgotPassword(email string) error {
 	return nil
 }
 
+// GetRecoveryStatus returns whether the current user has set up a recovery
+// key, along with the recovery public key that new private keys should be
+// encrypted with
+func (ctx *Context) GetRecoveryStatus() (shared.RecoveryStatus, error) {
+	url := endpoints.Recovery.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.RecoveryStatus{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.RecoveryStatus{}, utils.ParseHTTPError(resp)
+	}
+
+	var status shared.RecoveryStatus
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// SetRecoveryKey sets up a new recovery key for the current user, replacing
+// any recovery key they had before
+func (ctx *Context) SetRecoveryKey(setRecovery shared.SetRecoveryKey) error {
+	reqData, err := json.Marshal(setRecovery)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.Recovery.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// RemoveRecoveryKey removes the current user's recovery key
+func (ctx *Context) RemoveRecoveryKey(removeRecovery shared.RemoveRecoveryKey) error {
+	reqData, err := json.Marshal(removeRecovery)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.Recovery.Format(ctx.Server)
+	resp, err := requests.DeleteRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// StartRecovery fetches the copies of a user's private keys that are encrypted
+// with their recovery key, using the hash of the recovery key
+func (ctx *Context) StartRecovery(
+	request shared.RecoveryKeysRequest,
+) (shared.RecoveryKeysResponse, error) {
+	reqData, err := json.Marshal(request)
+	if err != nil {
+		return shared.RecoveryKeysResponse{}, err
+	}
+
+	url := endpoints.RecoveryStart.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.RecoveryKeysResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.RecoveryKeysResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var recoveryKeys shared.RecoveryKeysResponse
+	err = json.NewDecoder(resp.Body).Decode(&recoveryKeys)
+	return recoveryKeys, err
+}
+
+// FinishRecovery replaces a user's password using their private keys
+// re-encrypted with a new user key
+func (ctx *Context) FinishRecovery(recover shared.RecoverAccount) error {
+	reqData, err := json.Marshal(recover)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.RecoveryFinish.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
 // Generate2FA requests a TOTP secret from the server. This only succeeds if the
 // user doesn't already have 2FA enabled.
 func (ctx *Context) Generate2FA() (shared.NewTOTP, error) {
@@ -412,3 +594,37 @@ func (ctx *Context) RecyclePaymentID() error {
 
 	return nil
 }
+
+// GetBillingHistory fetches the list of purchases made with the user's
+// current payment ID.
+func (ctx *Context) GetBillingHistory() (shared.BillingHistoryResponse, error) {
+	url := endpoints.AccountBilling.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.BillingHistoryResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.BillingHistoryResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var billingResponse shared.BillingHistoryResponse
+	err = json.NewDecoder(resp.Body).Decode(&billingResponse)
+	if err != nil {
+		return shared.BillingHistoryResponse{}, err
+	}
+
+	return billingResponse, nil
+}
+
+// EmailReceipt requests that the receipt for a purchase is sent to the
+// user's email.
+func (ctx *Context) EmailReceipt(invoiceID string) error {
+	url := endpoints.AccountReceipt.Format(ctx.Server, invoiceID)
+	resp, err := requests.PostRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
@@ -20,4 +20,4 @@ func TestValidSessions(t *testing.T) {
 
 func TestChangeEmail(t *testing.T) {
 
-}
\ No newline at end of file
+}
@@ -0,0 +1,21 @@
+//go:build server_test
+
+package api
+
+import (
+	"testing"
+)
+
+func TestEmptyBillingHistory(t *testing.T) {
+	billing, err := UserA.context.GetBillingHistory()
+	if err != nil {
+		t.Fatalf("Error fetching billing history: %v\n", err)
+	} else if len(billing.Purchases) != 0 {
+		t.Fatalf("Expected no purchases, got %d\n", len(billing.Purchases))
+	}
+
+	err = UserA.context.EmailReceipt("missing-invoice")
+	if err == nil {
+		t.Fatalf("Expected error emailing receipt for missing invoice\n")
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,31 +13,10 @@ import (
 
 // InitStripeCheckout produces a link that the user can use to check out via Stripe
 func (ctx *Context) InitStripeCheckout(upgrade shared.Upgrade, quantity string) (string, error) {
-	var url string
-	if upgrade.IsVaultUpgrade {
-		url = fmt.Sprintf("%s?vault-upgrade=%s&vault-quantity=%s",
-			endpoints.StripeCheckout.Format(ctx.Server),
-			upgrade.Tag,
-			quantity)
-	} else {
-		url = fmt.Sprintf("%s?send-upgrade=%s",
-			endpoints.StripeCheckout.Format(ctx.Server),
-			upgrade.Tag)
-	}
-
-	resp, err := requests.GetRequest(ctx.Session, url)
-	if err != nil {
-		return "", err
-	} else if resp.StatusCode > http.StatusBadRequest {
-		return "", utils.ParseHTTPError(resp)
-	}
-
-	redirect := resp.Header.Get("Location")
-	if len(redirect) == 0 {
-		return "", errors.New("missing checkout link in response")
-	}
-
-	return redirect, nil
+	return ctx.initCheckout(getUpgradeCheckoutURL(
+		endpoints.StripeCheckout.Format(ctx.Server),
+		upgrade,
+		quantity))
 }
 
 // InitBTCPayCheckout produces a link that the user can use to check out via BTCPay
@@ -45,6 +25,21 @@ func (ctx *Context) InitBTCPayCheckout(subType, quantity string) (string, error)
 		endpoints.BTCPayCheckout.Format(ctx.Server),
 		subType,
 		quantity)
+	return ctx.initCheckout(url)
+}
+
+// InitManualCheckout creates an invoice for the upgrade, and returns a link to
+// the invoice containing the server's payment instructions
+func (ctx *Context) InitManualCheckout(upgrade shared.Upgrade, quantity string) (string, error) {
+	return ctx.initCheckout(getUpgradeCheckoutURL(
+		endpoints.ManualCheckout.Format(ctx.Server),
+		upgrade,
+		quantity))
+}
+
+// initCheckout requests a checkout link from the server, returning the
+// location that the server redirected to
+func (ctx *Context) initCheckout(url string) (string, error) {
 	resp, err := requests.GetRequest(ctx.Session, url)
 	if err != nil {
 		return "", err
@@ -59,3 +54,38 @@ func (ctx *Context) InitBTCPayCheckout(subType, quantity string) (string, error)
 
 	return redirect, nil
 }
+
+func getUpgradeCheckoutURL(base string, upgrade shared.Upgrade, quantity string) string {
+	if upgrade.IsVaultUpgrade {
+		return fmt.Sprintf("%s?vault-upgrade=%s&vault-quantity=%s",
+			base,
+			upgrade.Tag,
+			quantity)
+	}
+
+	return fmt.Sprintf("%s?send-upgrade=%s", base, upgrade.Tag)
+}
+
+// RedeemVoucher redeems a voucher code for an upgrade
+func (ctx *Context) RedeemVoucher(code string) (shared.RedeemVoucherResponse, error) {
+	reqData, err := json.Marshal(shared.RedeemVoucherRequest{Code: code})
+	if err != nil {
+		return shared.RedeemVoucherResponse{}, err
+	}
+
+	url := endpoints.RedeemVoucher.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.RedeemVoucherResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.RedeemVoucherResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var redeemResponse shared.RedeemVoucherResponse
+	err = json.NewDecoder(resp.Body).Decode(&redeemResponse)
+	if err != nil {
+		return shared.RedeemVoucherResponse{}, err
+	}
+
+	return redeemResponse, nil
+}
@@ -28,5 +28,17 @@ func TestCheckoutInit(t *testing.T) {
 			assert.Nil(t, err)
 			assert.Contains(t, link, account.PaymentID)
 		}
+
+		if info.ManualEnabled {
+			link, err := UserA.context.InitManualCheckout(
+				*info.Upgrades.VaultUpgrades[0], "1")
+			assert.Nil(t, err)
+			assert.Contains(t, link, "inv_")
+		}
 	}
 }
+
+func TestRedeemInvalidVoucher(t *testing.T) {
+	_, err := UserA.context.RedeemVoucher("NOT-A-REAL-CODE")
+	assert.NotNil(t, err)
+}
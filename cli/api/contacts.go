@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+// GetContacts fetches the user's encrypted list of pinned contacts
+func (ctx *Context) GetContacts() (shared.ContactList, error) {
+	url := endpoints.Contacts.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.ContactList{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.ContactList{}, utils.ParseHTTPError(resp)
+	}
+
+	var contacts shared.ContactList
+	err = json.NewDecoder(resp.Body).Decode(&contacts)
+	if err != nil {
+		return shared.ContactList{}, err
+	}
+
+	return contacts, nil
+}
+
+// SetContacts replaces the user's encrypted list of pinned contacts
+func (ctx *Context) SetContacts(contacts shared.ContactList) error {
+	reqData, err := json.Marshal(contacts)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.Contacts.Format(ctx.Server)
+	resp, err := requests.PutRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared"
+)
+
+func TestContacts(t *testing.T) {
+	contactList, err := UserA.context.GetContacts()
+	assert.Nil(t, err)
+	assert.Empty(t, contactList.Contacts)
+
+	pubKeys, err := UserA.context.FetchUserPubKey(UserB.id)
+	assert.Nil(t, err)
+
+	kp := crypto.IngestKeys(UserA.privKey, UserA.pubKey)
+	contacts := []shared.PinnedContact{{
+		Identifier:  UserB.id,
+		Fingerprint: crypto.Fingerprint(pubKeys),
+		Verified:    time.Now().UTC(),
+	}}
+
+	contactList, err = crypto.CreateContactList(kp, contacts)
+	assert.Nil(t, err)
+	assert.Nil(t, UserA.context.SetContacts(contactList))
+
+	contactList, err = UserA.context.GetContacts()
+	assert.Nil(t, err)
+
+	opened, err := crypto.OpenContactList(kp, contactList)
+	assert.Nil(t, err)
+	assert.Len(t, opened, 1)
+	assert.Equal(t, contacts[0].Fingerprint, opened[0].Fingerprint)
+
+	// Contact lists are only readable by their owner
+	contactList, err = UserB.context.GetContacts()
+	assert.Nil(t, err)
+	assert.Empty(t, contactList.Contacts)
+
+	// An empty list removes the user's pinned contacts
+	assert.Nil(t, UserA.context.SetContacts(shared.ContactList{}))
+	contactList, err = UserA.context.GetContacts()
+	assert.Nil(t, err)
+	assert.Empty(t, contactList.Contacts)
+}
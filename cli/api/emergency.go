@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+// GetEmergencyAccess fetches the user's emergency contacts, as well as the
+// users who have added them as an emergency contact
+func (ctx *Context) GetEmergencyAccess() (shared.EmergencyAccessResponse, error) {
+	url := endpoints.EmergencyAccess.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.EmergencyAccessResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.EmergencyAccessResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var access shared.EmergencyAccessResponse
+	err = json.NewDecoder(resp.Body).Decode(&access)
+	if err != nil {
+		return shared.EmergencyAccessResponse{}, err
+	}
+
+	return access, nil
+}
+
+// AddEmergencyContact adds another user as one of the user's emergency
+// contacts, replacing their keys and waiting period if they already are one
+func (ctx *Context) AddEmergencyContact(contact shared.NewEmergencyContact) error {
+	reqData, err := json.Marshal(contact)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.EmergencyAccess.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// RemoveEmergencyContact removes one of the user's emergency contacts
+func (ctx *Context) RemoveEmergencyContact(contactID string) error {
+	url := endpoints.EmergencyContact.Format(ctx.Server, contactID)
+	resp, err := requests.DeleteRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// DenyEmergencyAccess denies an emergency contact's pending request for
+// access to the user's vault
+func (ctx *Context) DenyEmergencyAccess(contactID string) error {
+	url := endpoints.EmergencyDeny.Format(ctx.Server, contactID)
+	resp, err := requests.PostRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// RequestEmergencyAccess requests access to the vault of a user who added the
+// current user as an emergency contact
+func (ctx *Context) RequestEmergencyAccess(ownerID string) error {
+	url := endpoints.EmergencyGrantor.Format(ctx.Server, ownerID)
+	resp, err := requests.PostRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// LeaveEmergencyContacts removes the current user from another user's
+// emergency contacts
+func (ctx *Context) LeaveEmergencyContacts(ownerID string) error {
+	url := endpoints.EmergencyGrantor.Format(ctx.Server, ownerID)
+	resp, err := requests.DeleteRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
+
+// GetEmergencyKeys fetches the keys for another user's vault, which are only
+// released once the current user has been granted emergency access
+func (ctx *Context) GetEmergencyKeys(ownerID string) (shared.EmergencyKeyResponse, error) {
+	url := endpoints.EmergencyGrantor.Format(ctx.Server, ownerID)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.EmergencyKeyResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.EmergencyKeyResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var keys shared.EmergencyKeyResponse
+	err = json.NewDecoder(resp.Body).Decode(&keys)
+	if err != nil {
+		return shared.EmergencyKeyResponse{}, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,58 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"yeetfile/cli/crypto"
+	"yeetfile/shared/constants"
+)
+
+func TestEmergencyAccess(t *testing.T) {
+	pubKeys, err := UserA.context.FetchUserPubKey(UserB.id)
+	assert.Nil(t, err)
+
+	folder, err := UserA.context.FetchFolderContents("", false)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, folder.KeySequence)
+
+	owner := crypto.IngestKeys(UserA.privKey, UserA.pubKey)
+	contact, err := crypto.CreateEmergencyKey(
+		owner,
+		folder.KeySequence[0],
+		crypto.SelectPublicKey(pubKeys))
+	assert.Nil(t, err)
+
+	contact.Identifier = UserB.id
+	contact.WaitDays = 1
+	assert.Nil(t, UserA.context.AddEmergencyContact(contact))
+
+	// Users can't add themselves as an emergency contact
+	contact.Identifier = UserA.id
+	assert.NotNil(t, UserA.context.AddEmergencyContact(contact))
+
+	access, err := UserB.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.Len(t, access.Grantors, 1)
+	assert.Equal(t, UserA.id, access.Grantors[0].UserID)
+	assert.Equal(t, constants.EmergencyStatusIdle, access.Grantors[0].Status)
+
+	// Keys aren't released until the waiting period has ended
+	_, err = UserB.context.GetEmergencyKeys(UserA.id)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, UserB.context.RequestEmergencyAccess(UserA.id))
+	assert.NotNil(t, UserB.context.RequestEmergencyAccess(UserA.id))
+
+	access, err = UserA.context.GetEmergencyAccess()
+	assert.Nil(t, err)
+	assert.Len(t, access.Contacts, 1)
+	assert.Equal(t, constants.EmergencyStatusRequested, access.Contacts[0].Status)
+
+	assert.Nil(t, UserA.context.DenyEmergencyAccess(UserB.id))
+	assert.NotNil(t, UserA.context.DenyEmergencyAccess(UserB.id))
+
+	assert.Nil(t, UserB.context.LeaveEmergencyContacts(UserA.id))
+	assert.NotNil(t, UserA.context.RemoveEmergencyContact(UserB.id))
+}
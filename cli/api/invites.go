@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+// GetAccountInvites fetches the user's invite allowance and the invite links
+// they've created
+func (ctx *Context) GetAccountInvites() (shared.AccountInvitesResponse, error) {
+	url := endpoints.AccountInvites.Format(ctx.Server)
+	resp, err := requests.GetRequest(ctx.Session, url)
+	if err != nil {
+		return shared.AccountInvitesResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.AccountInvitesResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var invites shared.AccountInvitesResponse
+	err = json.NewDecoder(resp.Body).Decode(&invites)
+	if err != nil {
+		return shared.AccountInvitesResponse{}, err
+	}
+
+	return invites, nil
+}
+
+// CreateInviteLink creates a new single-use invite link. The code in the
+// returned link is only available in this response.
+func (ctx *Context) CreateInviteLink() (shared.InviteLink, error) {
+	url := endpoints.AccountInvites.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, nil)
+	if err != nil {
+		return shared.InviteLink{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.InviteLink{}, utils.ParseHTTPError(resp)
+	}
+
+	var link shared.InviteLink
+	err = json.NewDecoder(resp.Body).Decode(&link)
+	if err != nil {
+		return shared.InviteLink{}, err
+	}
+
+	return link, nil
+}
+
+// DeleteInviteLink removes one of the user's unused invite links
+func (ctx *Context) DeleteInviteLink(id string) error {
+	url := endpoints.AccountInvite.Format(ctx.Server, id)
+	resp, err := requests.DeleteRequest(ctx.Session, url, nil)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != http.StatusOK {
+		return utils.ParseHTTPError(resp)
+	}
+
+	return nil
+}
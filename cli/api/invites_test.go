@@ -0,0 +1,22 @@
+//go:build server_test
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAccountInvites(t *testing.T) {
+	info, err := UserA.context.GetServerInfo()
+	assert.Nil(t, err)
+
+	_, err = UserA.context.GetAccountInvites()
+	if !info.InvitesAllowed {
+		assert.NotNil(t, err)
+		return
+	}
+
+	assert.Nil(t, err)
+	assert.NotNil(t, UserA.context.DeleteInviteLink("not-a-real-link"))
+}
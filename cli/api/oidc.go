@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"yeetfile/cli/requests"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/endpoints"
+)
+
+// PendingApprovalError indicates that a new account was created, but must be
+// approved by an admin before the user can log in
+var PendingApprovalError = errors.New("account is waiting for approval by an admin")
+
+// StartOIDCLogin starts a single sign-on login, returning the identity
+// provider URL that the user needs to visit and the values needed to check on
+// the status of the login afterward.
+func (ctx *Context) StartOIDCLogin() (shared.OIDCStartResponse, error) {
+	url := endpoints.OIDCStart.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, nil)
+	if err != nil {
+		return shared.OIDCStartResponse{}, err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.OIDCStartResponse{}, utils.ParseHTTPError(resp)
+	}
+
+	var start shared.OIDCStartResponse
+	err = json.NewDecoder(resp.Body).Decode(&start)
+	if err != nil {
+		return shared.OIDCStartResponse{}, err
+	}
+
+	return start, nil
+}
+
+// GetOIDCStatus checks the status of a single sign-on login. If the user's
+// identity is already linked to an account, the response contains the user's
+// keys and the user's session is returned.
+func (ctx *Context) GetOIDCStatus(
+	statusReq shared.OIDCStatusRequest,
+) (shared.OIDCStatusResponse, string, error) {
+	reqData, err := json.Marshal(statusReq)
+	if err != nil {
+		return shared.OIDCStatusResponse{}, "", err
+	}
+
+	url := endpoints.OIDCStatus.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.OIDCStatusResponse{}, "", err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.OIDCStatusResponse{}, "", utils.ParseHTTPError(resp)
+	}
+
+	var status shared.OIDCStatusResponse
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return shared.OIDCStatusResponse{}, "", err
+	}
+
+	return status, ctx.setSessionFromResponse(resp), nil
+}
+
+// LinkOIDCAccount links a single sign-on identity to the user's existing
+// account, returning the user's keys and session.
+func (ctx *Context) LinkOIDCAccount(link shared.OIDCLink) (shared.OIDCStatusResponse, string, error) {
+	reqData, err := json.Marshal(link)
+	if err != nil {
+		return shared.OIDCStatusResponse{}, "", err
+	}
+
+	url := endpoints.OIDCLink.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return shared.OIDCStatusResponse{}, "", err
+	} else if resp.StatusCode != http.StatusOK {
+		return shared.OIDCStatusResponse{}, "", utils.ParseHTTPError(resp)
+	}
+
+	var status shared.OIDCStatusResponse
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return shared.OIDCStatusResponse{}, "", err
+	}
+
+	return status, ctx.setSessionFromResponse(resp), nil
+}
+
+// SignupOIDCAccount creates a new account for a single sign-on identity,
+// returning the new user's session. Returns PendingApprovalError if the
+// account needs to be approved before the user can log in, or
+// ServerPasswordError if the server password (or invite) was invalid.
+func (ctx *Context) SignupOIDCAccount(signup shared.OIDCSignup) (string, error) {
+	reqData, err := json.Marshal(signup)
+	if err != nil {
+		return "", err
+	}
+
+	url := endpoints.OIDCSignup.Format(ctx.Server)
+	resp, err := requests.PostRequest(ctx.Session, url, reqData)
+	if err != nil {
+		return "", err
+	} else if resp.StatusCode == http.StatusAccepted {
+		return "", PendingApprovalError
+	} else if resp.StatusCode == http.StatusForbidden {
+		return "", ServerPasswordError
+	} else if resp.StatusCode != http.StatusOK {
+		return "", utils.ParseHTTPError(resp)
+	}
+
+	return ctx.setSessionFromResponse(resp), nil
+}
+
+// setSessionFromResponse updates the context with the session cookie set in
+// the response (if any), and returns the session
+func (ctx *Context) setSessionFromResponse(resp *http.Response) string {
+	cookies := resp.Cookies()
+	if len(cookies) == 0 {
+		return ""
+	}
+
+	ctx.Session = cookies[0].Value
+	return ctx.Session
+}
@@ -26,7 +26,7 @@ func TestSendPastLimit(t *testing.T) {
 	contents := []byte(strings.Repeat(".", int(realSize)))
 	password := []byte("password")
 
-	key, _, err := crypto.DeriveSendingKey(password, nil)
+	key, _, err := crypto.DeriveSendingKey(password, nil, shared.SendKDFParams())
 	assert.Nil(t, err)
 
 	encData, err := crypto.EncryptChunk(key, contents)
@@ -67,7 +67,7 @@ func TestSendFile(t *testing.T) {
 	contents := []byte("testing")
 	password := []byte("password")
 
-	key, salt, err := crypto.DeriveSendingKey(password, nil)
+	key, salt, err := crypto.DeriveSendingKey(password, nil, shared.SendKDFParams())
 	if err != nil {
 		t.Fatalf("Error deriving sending key: %v\n", key)
 	}
@@ -109,7 +109,7 @@ func TestSendFile(t *testing.T) {
 		t.Fatalf("Error downloading send file data: %v\n", err)
 	}
 
-	newKey, _, _ := crypto.DeriveSendingKey(password, salt)
+	newKey, _, _ := crypto.DeriveSendingKey(password, salt, shared.SendKDFParams())
 	downloadedData, err := crypto.DecryptChunk(newKey, encDownloadedData)
 	if err != nil {
 		t.Fatalf("Error decrypting downloaded data")
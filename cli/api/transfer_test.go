@@ -13,7 +13,7 @@ import (
 func TestUploadText(t *testing.T) {
 	text := "top secret text"
 	password := "topsecret"
-	key, salt, err := crypto.DeriveSendingKey([]byte(password), nil)
+	key, salt, err := crypto.DeriveSendingKey([]byte(password), nil, shared.SendKDFParams())
 	if err != nil {
 		t.Fatalf("Error deriving sending keys: %v\n", err)
 	}
@@ -46,7 +46,7 @@ func TestUploadText(t *testing.T) {
 		t.Fatalf("Error downloading encrypted text: %v\n", err)
 	}
 
-	newKey, _, err := crypto.DeriveSendingKey([]byte(password), salt)
+	newKey, _, err := crypto.DeriveSendingKey([]byte(password), salt, shared.SendKDFParams())
 	if err != nil {
 		t.Fatalf("Error deriving new key from previous salt")
 	}
@@ -3,11 +3,13 @@ package account
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/utils"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
 type ChangePasswordForm struct {
@@ -40,32 +42,92 @@ func getStorageString(used, available int64, isSend bool) string {
 	}
 }
 
+// changePassword re-derives the user's keys from their new password, using
+// the server's recommended key derivation parameters
 func changePassword(identifier, password, newPassword string) error {
-	userKey := crypto.GenerateUserKey([]byte(identifier), []byte(password))
-	oldLoginKeyHash := crypto.GenerateLoginKeyHash(userKey, []byte(password))
-
-	newUserKey := crypto.GenerateUserKey([]byte(identifier), []byte(newPassword))
-	newLoginKeyHash := crypto.GenerateLoginKeyHash(newUserKey, []byte(newPassword))
-
-	protectedKey, err := globals.API.GetUserProtectedKey()
+	kdf, err := globals.API.GetKDFParams(identifier)
 	if err != nil {
-		return errors.New("error fetching protected key")
+		return err
 	}
 
-	privateKey, err := crypto.DecryptChunk(userKey, protectedKey)
+	userKey, oldLoginKeyHash := crypto.GenerateUserKeys(
+		identifier,
+		password,
+		kdf.KDF)
+	newUserKey, newLoginKeyHash := crypto.GenerateUserKeys(
+		identifier,
+		newPassword,
+		kdf.RecommendedKDF)
+
+	protectedKeys, err := globals.API.GetUserProtectedKey()
 	if err != nil {
-		return errors.New("error decrypting protected key")
+		return errors.New("error fetching protected key")
 	}
 
-	newProtectedKey, err := crypto.EncryptChunk(newUserKey, privateKey)
+	newProtectedKey, newProtectedKeys, err := crypto.ReencryptProtectedKeys(
+		userKey,
+		newUserKey,
+		protectedKeys)
 	if err != nil {
-		return errors.New("error encrypting private key")
+		return err
 	}
 
 	return globals.API.ChangePassword(shared.ChangePassword{
 		OldLoginKeyHash: oldLoginKeyHash,
 		NewLoginKeyHash: newLoginKeyHash,
 		ProtectedKey:    newProtectedKey,
+		ProtectedKeys:   newProtectedKeys,
+		KDF:             kdf.RecommendedKDF,
+	})
+}
+
+// setRecoveryKey generates a new recovery key for the user and encrypts a copy
+// of each of their private keys with it, returning the recovery key formatted
+// for the user to write down
+func setRecoveryKey(identifier, password string) (string, error) {
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return "", err
+	}
+
+	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf.KDF)
+	protectedKeys, err := globals.API.GetUserProtectedKey()
+	if err != nil {
+		return "", errors.New("error fetching protected key")
+	}
+
+	recoveryKey, err := crypto.GenerateRecoveryKey()
+	if err != nil {
+		return "", err
+	}
+
+	recovery, err := crypto.CreateRecoveryKey(recoveryKey, userKey, protectedKeys)
+	if err != nil {
+		return "", errors.New("incorrect identifier or password")
+	}
+
+	err = globals.API.SetRecoveryKey(shared.SetRecoveryKey{
+		LoginKeyHash: loginKeyHash,
+		RecoveryKey:  recovery,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return crypto.FormatRecoveryKey(recoveryKey), nil
+}
+
+// removeRecoveryKey removes the user's recovery key, which requires their
+// current login
+func removeRecoveryKey(identifier, password string) error {
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return err
+	}
+
+	_, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf.KDF)
+	return globals.API.RemoveRecoveryKey(shared.RemoveRecoveryKey{
+		LoginKeyHash: loginKeyHash,
 	})
 }
 
@@ -74,25 +136,31 @@ func changePasswordHint(passwordHint string) error {
 }
 
 func changeEmail(identifier, password, newEmail, changeID string) error {
-	userKey := crypto.GenerateUserKey([]byte(identifier), []byte(password))
-	oldLoginKeyHash := crypto.GenerateLoginKeyHash(userKey, []byte(password))
-
-	newUserKey := crypto.GenerateUserKey([]byte(newEmail), []byte(password))
-	newLoginKeyHash := crypto.GenerateLoginKeyHash(newUserKey, []byte(password))
-
-	protectedKey, err := globals.API.GetUserProtectedKey()
+	kdf, err := globals.API.GetKDFParams(identifier)
 	if err != nil {
-		return errors.New("error fetching protected key")
+		return err
 	}
 
-	privateKey, err := crypto.DecryptChunk(userKey, protectedKey)
+	userKey, oldLoginKeyHash := crypto.GenerateUserKeys(
+		identifier,
+		password,
+		kdf.KDF)
+	newUserKey, newLoginKeyHash := crypto.GenerateUserKeys(
+		newEmail,
+		password,
+		kdf.RecommendedKDF)
+
+	protectedKeys, err := globals.API.GetUserProtectedKey()
 	if err != nil {
-		return errors.New("error decrypting protected key")
+		return errors.New("error fetching protected key")
 	}
 
-	newProtectedKey, err := crypto.EncryptChunk(newUserKey, privateKey)
+	newProtectedKey, newProtectedKeys, err := crypto.ReencryptProtectedKeys(
+		userKey,
+		newUserKey,
+		protectedKeys)
 	if err != nil {
-		return errors.New("error encrypting private key")
+		return err
 	}
 
 	return globals.API.ChangeEmail(shared.ChangeEmail{
@@ -100,9 +168,306 @@ func changeEmail(identifier, password, newEmail, changeID string) error {
 		OldLoginKeyHash: oldLoginKeyHash,
 		NewLoginKeyHash: newLoginKeyHash,
 		ProtectedKey:    newProtectedKey,
+		ProtectedKeys:   newProtectedKeys,
+		KDF:             kdf.RecommendedKDF,
 	}, changeID)
 }
 
+// hasVaultPassword returns true if the user's locally stored private key is
+// protected by a vault password instead of the CLI key
+func hasVaultPassword() bool {
+	encPrivateKey, _, err := globals.Config.GetKeys()
+	if err != nil {
+		return false
+	}
+
+	_, err = crypto.DecryptChunk(globals.CLIKey, encPrivateKey)
+	return err != nil
+}
+
+// getVaultKey returns the key used to encrypt the private keys stored in the
+// config directory, which is either the CLI key or a key derived from the
+// user's vault password
+func getVaultKey(vaultPassword string) ([]byte, error) {
+	cliKey := globals.CLIKey
+	if !hasVaultPassword() {
+		return cliKey, nil
+	}
+
+	encPrivateKey, _, err := globals.Config.GetKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	vaultKey := crypto.DerivePBKDFKey([]byte(vaultPassword), cliKey)
+	_, err = crypto.DecryptChunk(vaultKey, encPrivateKey)
+	if err != nil {
+		return nil, errors.New("incorrect vault password")
+	}
+
+	return vaultKey, nil
+}
+
+// rotateKeys replaces the user's key pairs with newly generated ones. Every
+// key that was wrapped with the old key pairs is re-wrapped with the new ones
+// before the server swaps them in. Shared items that can't be unwrapped with
+// the old keys are removed, and the users who shared them are asked to share
+// them again. Returns the number of shared items that were removed.
+func rotateKeys(identifier, password, vaultPassword string) (int, error) {
+	vaultKey, err := getVaultKey(vaultPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return 0, err
+	}
+
+	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf.KDF)
+	protectedKeys, err := globals.API.GetUserProtectedKey()
+	if err != nil {
+		return 0, errors.New("error fetching protected key")
+	}
+
+	kp, err := decryptUserKeyPair(userKey, protectedKeys)
+	if err != nil {
+		return 0, err
+	}
+
+	newKP, rotation, err := generateRotationKeys(userKey)
+	if err != nil {
+		return 0, err
+	}
+
+	// The recovery key is removed by the rotation unless the new private
+	// keys are also encrypted with it
+	recovery, err := globals.API.GetRecoveryStatus()
+	if err != nil {
+		return 0, err
+	} else if recovery.Enabled {
+		err = addRotationRecoveryKeys(newKP, &rotation, recovery.PublicKey)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Pinned contacts are removed by the rotation unless their key is
+	// re-wrapped with the new key pair
+	contactList, err := globals.API.GetContacts()
+	if err != nil {
+		return 0, err
+	} else if len(contactList.ProtectedKey) > 0 {
+		contactsKey, err := crypto.UnwrapContactsKey(kp, contactList.ProtectedKey)
+		if err != nil {
+			return 0, errors.New("error decrypting contacts key")
+		}
+
+		rotation.ContactsProtectedKey, err = crypto.WrapContactsKey(newKP, contactsKey)
+		if err != nil {
+			return 0, errors.New("error encrypting contacts key")
+		}
+	}
+
+	wrappedKeys, err := globals.API.GetWrappedKeys()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range wrappedKeys.Keys {
+		itemKey, err := kp.UnwrapKey(key.ProtectedKey)
+		if err != nil && len(key.SharedBy) > 0 {
+			rotation.RemovedKeys = append(rotation.RemovedKeys, key)
+			continue
+		} else if err != nil {
+			return 0, errors.New("error decrypting vault key")
+		}
+
+		key.ProtectedKey, err = newKP.WrapKey(itemKey)
+		if err != nil {
+			return 0, errors.New("error encrypting vault key")
+		}
+
+		rotation.WrappedKeys = append(rotation.WrappedKeys, key)
+	}
+
+	rotation.LoginKeyHash = loginKeyHash
+	response, err := globals.API.RotateKeys(rotation)
+	if err != nil {
+		return 0, err
+	}
+
+	encPrivateKey, _ := crypto.EncryptChunk(vaultKey, newKP.PrivateKey)
+	err = globals.Config.SetKeys(encPrivateKey, newKP.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	encX25519Key, _ := crypto.EncryptChunk(vaultKey, newKP.X25519PrivateKey)
+	err = globals.Config.SetX25519Keys(encX25519Key, newKP.X25519PublicKey)
+	if err != nil {
+		return response.Removed, err
+	}
+
+	// Replace the old key pair if it's being held by the agent
+	_ = globals.Agent.SetKeyPair(newKP)
+	return response.Removed, nil
+}
+
+// generateRotationKeys generates new RSA and X25519 key pairs for the user,
+// returning the key pairs along with a rotation containing their public keys
+// and their private keys encrypted with the user's key
+// decryptUserKeyPair decrypts the user's private keys with their user key
+func decryptUserKeyPair(
+	userKey []byte,
+	protectedKeys shared.ProtectedKeyResponse,
+) (crypto.KeyPair, error) {
+	privateKey, err := crypto.DecryptChunk(userKey, protectedKeys.ProtectedKey)
+	if err != nil {
+		return crypto.KeyPair{}, errors.New("incorrect identifier or password")
+	}
+
+	kp := crypto.IngestKeys(privateKey, nil)
+	for _, key := range protectedKeys.Keys {
+		if key.Type != constants.KeyTypeX25519 {
+			continue
+		}
+
+		kp.X25519PrivateKey, err = crypto.DecryptChunk(userKey, key.ProtectedKey)
+		if err != nil {
+			return crypto.KeyPair{}, errors.New("error decrypting protected key")
+		}
+	}
+
+	return kp, nil
+}
+
+// fetchEmergencyContactKeys decrypts the user's key pair and fetches the
+// public keys of the user being added as an emergency contact, which should be
+// confirmed before they're used
+func fetchEmergencyContactKeys(
+	identifier, password, contactIdentifier string,
+) (crypto.KeyPair, shared.PubKeyResponse, error) {
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return crypto.KeyPair{}, shared.PubKeyResponse{}, err
+	}
+
+	userKey, _ := crypto.GenerateUserKeys(identifier, password, kdf.KDF)
+	protectedKeys, err := globals.API.GetUserProtectedKey()
+	if err != nil {
+		return crypto.KeyPair{}, shared.PubKeyResponse{},
+			errors.New("error fetching protected key")
+	}
+
+	kp, err := decryptUserKeyPair(userKey, protectedKeys)
+	if err != nil {
+		return crypto.KeyPair{}, shared.PubKeyResponse{}, err
+	}
+
+	pubKeys, err := globals.API.FetchUserPubKey(contactIdentifier)
+	if err != nil {
+		return crypto.KeyPair{}, shared.PubKeyResponse{}, err
+	}
+
+	return kp, pubKeys, nil
+}
+
+// addEmergencyContact adds another user as one of the user's emergency
+// contacts, wrapping the user's vault root folder key for the contact
+func addEmergencyContact(
+	kp crypto.KeyPair,
+	pubKeys shared.PubKeyResponse,
+	contactIdentifier string,
+	waitDays int,
+) error {
+	folder, err := globals.API.FetchFolderContents("", false)
+	if err != nil {
+		return err
+	} else if len(folder.KeySequence) == 0 {
+		return errors.New("unable to find vault root folder key")
+	}
+
+	contact, err := crypto.CreateEmergencyKey(
+		kp,
+		folder.KeySequence[0],
+		crypto.SelectPublicKey(pubKeys))
+	if err != nil {
+		return err
+	}
+
+	contact.Identifier = contactIdentifier
+	contact.WaitDays = waitDays
+	return globals.API.AddEmergencyContact(contact)
+}
+
+func generateRotationKeys(userKey []byte) (crypto.KeyPair, shared.KeyRotation, error) {
+	privateKey, publicKey, err := crypto.GenerateRSAKeyPair()
+	if err != nil {
+		return crypto.KeyPair{}, shared.KeyRotation{}, err
+	}
+
+	x25519PrivateKey, x25519PublicKey, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		return crypto.KeyPair{}, shared.KeyRotation{}, err
+	}
+
+	protectedKey, err := crypto.EncryptChunk(userKey, privateKey)
+	if err != nil {
+		return crypto.KeyPair{}, shared.KeyRotation{}, err
+	}
+
+	x25519ProtectedKey, err := crypto.EncryptChunk(userKey, x25519PrivateKey)
+	if err != nil {
+		return crypto.KeyPair{}, shared.KeyRotation{}, err
+	}
+
+	kp := crypto.IngestKeys(privateKey, publicKey)
+	kp.X25519PrivateKey = x25519PrivateKey
+	kp.X25519PublicKey = x25519PublicKey
+
+	return kp, shared.KeyRotation{
+		PublicKey:    publicKey,
+		ProtectedKey: protectedKey,
+		Keys: []shared.UserKey{{
+			Type:         constants.KeyTypeX25519,
+			PublicKey:    x25519PublicKey,
+			ProtectedKey: x25519ProtectedKey,
+		}},
+	}, nil
+}
+
+// addRotationRecoveryKeys encrypts a copy of each of the new private keys in a
+// rotation with the user's recovery public key
+func addRotationRecoveryKeys(
+	kp crypto.KeyPair,
+	rotation *shared.KeyRotation,
+	recoveryPublicKey []byte,
+) error {
+	var err error
+	rotation.RecoveryProtectedKey, err = crypto.EncryptX25519(
+		recoveryPublicKey,
+		kp.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	for i, key := range rotation.Keys {
+		if key.Type != constants.KeyTypeX25519 {
+			continue
+		}
+
+		rotation.Keys[i].RecoveryProtectedKey, err = crypto.EncryptX25519(
+			recoveryPublicKey,
+			kp.X25519PrivateKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func FetchAccountDetails() (shared.AccountResponse, string) {
 	account, err := globals.API.GetAccountInfo()
 	if err != nil {
@@ -129,6 +494,11 @@ func FetchAccountDetails() (shared.AccountResponse, string) {
 		twoFactorStr = "Enabled"
 	}
 
+	recoveryKeyStr := "Not Set"
+	if account.HasRecoveryKey {
+		recoveryKeyStr = "Enabled"
+	}
+
 	accountDetails := fmt.Sprintf(""+
 		"Email: %s\n"+
 		"Vault: %s\n"+
@@ -136,6 +506,7 @@ func FetchAccountDetails() (shared.AccountResponse, string) {
 		"Upgrades:      %s\n"+
 		"Password Hint: %s\n"+
 		"Two-Factor:    %s\n"+
+		"Recovery Key:  %s\n"+
 		"Payment ID:    %s",
 		shared.EscapeString(emailStr),
 		storageStr,
@@ -143,11 +514,119 @@ func FetchAccountDetails() (shared.AccountResponse, string) {
 		upgradeStr,
 		passwordHintStr,
 		twoFactorStr,
+		recoveryKeyStr,
 		shared.EscapeString(account.PaymentID))
 
+	if len(account.UsageWarnings) > 0 {
+		accountDetails += "\n"
+		for _, warning := range account.UsageWarnings {
+			accountDetails += fmt.Sprintf(
+				"\n! %s",
+				shared.EscapeString(shared.FormatUsageWarning(warning)))
+		}
+	}
+
 	return account, accountDetails
 }
 
+func generateBillingHistoryDesc(purchases []shared.BillingPurchase) string {
+	if len(purchases) == 0 {
+		return "No purchases found"
+	}
+
+	var desc []string
+	for _, purchase := range purchases {
+		purchaseStr := fmt.Sprintf("%s -- %s (%s)",
+			utils.LocalTimeFromUTC(purchase.Date).Format(time.DateOnly),
+			shared.FormatCents(purchase.Amount),
+			purchase.Provider)
+
+		for _, item := range purchase.Items {
+			purchaseStr += fmt.Sprintf("\n  %s x%d", item.Name, item.Quantity)
+		}
+
+		if !purchase.Expiration.IsZero() {
+			purchaseStr += "\n  Expires " + utils.LocalTimeFromUTC(
+				purchase.Expiration).Format(time.DateOnly)
+		}
+
+		if purchase.Refunded > 0 {
+			purchaseStr += fmt.Sprintf("\n  Refunded %s",
+				shared.FormatCents(purchase.Refunded))
+		}
+
+		desc = append(desc, purchaseStr)
+	}
+
+	return shared.EscapeString(strings.Join(desc, "\n\n"))
+}
+
+func generateInvitesDesc(invites shared.AccountInvitesResponse) string {
+	desc := fmt.Sprintf("Invite links used: %d / %d",
+		invites.Used,
+		invites.Allowance)
+
+	for _, link := range invites.Links {
+		status := "Expires " + utils.LocalTimeFromUTC(
+			link.Expiration).Format(time.DateOnly)
+		if link.Uses >= link.MaxUses {
+			status = "Used"
+		} else if link.Expiration.Before(time.Now()) {
+			status = "Expired"
+		}
+
+		desc += fmt.Sprintf("\n\n%s\n  %s", link.ID, status)
+	}
+
+	return shared.EscapeString(desc)
+}
+
+func generateEmergencyAccessDesc(access shared.EmergencyAccessResponse) string {
+	desc := "Emergency contacts can request access to your vault. If you " +
+		"don't deny a request within the contact's waiting period, they " +
+		"will be able to view all of your files and passwords."
+
+	getStatus := func(contact shared.EmergencyContact) string {
+		switch contact.Status {
+		case constants.EmergencyStatusRequested:
+			return "Access requested, granted on " + utils.LocalTimeFromUTC(
+				contact.GrantDate).Format(time.DateOnly)
+		case constants.EmergencyStatusGranted:
+			return "Access granted"
+		default:
+			return fmt.Sprintf("Waiting period: %d day(s)", contact.WaitDays)
+		}
+	}
+
+	getName := func(contact shared.EmergencyContact) string {
+		if len(contact.Email) > 0 {
+			return contact.Email
+		}
+
+		return contact.UserID
+	}
+
+	if len(access.Contacts) > 0 {
+		desc += "\n\nYour emergency contacts:"
+		for _, contact := range access.Contacts {
+			desc += fmt.Sprintf("\n\n%s\n  %s",
+				getName(contact),
+				getStatus(contact))
+		}
+	}
+
+	if len(access.Grantors) > 0 {
+		desc += "\n\nYou are an emergency contact for:"
+		for _, grantor := range access.Grantors {
+			desc += fmt.Sprintf("\n\n%s\n  %s",
+				getName(grantor),
+				getStatus(grantor))
+		}
+	}
+
+	return shared.EscapeString(desc)
+}
+
 func generateUpgradeDesc(upgrade shared.Upgrade) string {
 	descStr := fmt.Sprintf(
 		`%s
@@ -7,11 +7,15 @@ import (
 	"github.com/mdp/qrterminal/v3"
 	"strconv"
 	"strings"
+	"time"
+	"yeetfile/cli/contacts"
+	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/styles"
 	"yeetfile/cli/utils"
 	"yeetfile/shared"
 	"yeetfile/shared/constants"
+	"yeetfile/shared/endpoints"
 
 	"github.com/charmbracelet/huh"
 )
@@ -25,9 +29,17 @@ const (
 	SetPasswordHint
 	SetTwoFactor
 	DeleteTwoFactor
+	SetRecoveryKey
+	DeleteRecoveryKey
+	EmergencyAccess
 	PurchaseSendUpgrade
 	PurchaseVaultUpgrade
+	RedeemVoucher
+	Invites
+	BillingHistory
 	RecyclePaymentID
+	ViewFingerprint
+	RotateKeys
 	DeleteAccount
 	Exit
 )
@@ -351,6 +363,234 @@ func showChangePasswordView() {
 	}
 }
 
+func showRotateKeysView() {
+	var identifier string
+	var password string
+	var vaultPassword string
+	var confirmed bool
+
+	var rotated bool
+	var removed int
+	var formErr error
+
+	rotateKeysForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = prevErr.Error()
+		}
+
+		fields := []huh.Field{
+			huh.NewNote().
+				Title(utils.GenerateTitle("Rotate Keys")).
+				Description("This replaces your account's encryption keys, " +
+					"and should be done if you think your private key " +
+					"has been compromised.\n\nShared items that can't be " +
+					"moved to your new keys will be removed, and the " +
+					"users who shared them will be asked to share them " +
+					"again. Your emergency contacts will also be " +
+					"removed, and contacts who verified your keys will " +
+					"need to verify them again.\n\nEnter your current " +
+					"login to continue."),
+			huh.NewInput().
+				Title("Identifier").
+				Placeholder("Email / Account ID").
+				Value(&identifier),
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+		}
+
+		// The vault password is only needed to store the new keys
+		// locally if the user chose one when logging in
+		if hasVaultPassword() {
+			fields = append(fields, huh.NewInput().
+				Title("Vault Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&vaultPassword))
+		}
+
+		fields = append(fields, huh.NewConfirm().
+			Description(styles.ErrStyle.Render(errMsg)).
+			Affirmative("Rotate Keys").
+			Negative("Cancel").
+			Value(&confirmed))
+
+		err := huh.NewForm(huh.NewGroup(fields...)).
+			WithTheme(styles.Theme).
+			Run()
+
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		}
+
+		utils.HandleCLIError("Error showing key rotation form", err)
+
+		_ = spinner.New().Title("Rotating keys...").Action(func() {
+			removed, err = rotateKeys(identifier, password, vaultPassword)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	rotated, formErr = rotateKeysForm(nil)
+	for formErr != nil {
+		rotated, formErr = rotateKeysForm(formErr)
+	}
+
+	if rotated {
+		msg := "Your keys have successfully been rotated."
+		if removed > 0 {
+			msg += fmt.Sprintf("\n\n%d shared item(s) could not be "+
+				"moved to your new keys and were removed. The users "+
+				"who shared them have been asked to share them again.",
+				removed)
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().Title("Rotate Keys").Description(msg),
+			huh.NewConfirm().
+				Affirmative("OK").
+				Negative("")),
+		).WithTheme(styles.Theme).Run()
+		utils.HandleCLIError("Error showing key rotation confirmation", err)
+	}
+
+	ShowAccountModel()
+}
+
+func showRecoveryKeyView() {
+	var (
+		identifier  string
+		password    string
+		recoveryKey string
+		confirmed   bool
+	)
+
+	recoveryKeyForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = prevErr.Error()
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Recovery Key")).
+				Description("A recovery key lets you reset your "+
+					"password if you forget it. It is generated on "+
+					"this device and never sent to the server, so "+
+					"store it somewhere safe.\n\nAnyone with your "+
+					"recovery key can reset your password, but will "+
+					"still need your 2FA code (if enabled) to log "+
+					"in. Any previous recovery key will stop "+
+					"working.\n\nEnter your current login to continue."),
+			huh.NewInput().
+				Title("Identifier").
+				Placeholder("Email / Account ID").
+				Value(&identifier),
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+			huh.NewConfirm().
+				Description(styles.ErrStyle.Render(errMsg)).
+				Affirmative("Generate Recovery Key").
+				Negative("Cancel").
+				Value(&confirmed)),
+		).WithTheme(styles.Theme).Run()
+
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		}
+
+		utils.HandleCLIError("Error showing recovery key form", err)
+
+		_ = spinner.New().Title("Generating recovery key...").Action(func() {
+			recoveryKey, err = setRecoveryKey(identifier, password)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	generated, formErr := recoveryKeyForm(nil)
+	for formErr != nil {
+		generated, formErr = recoveryKeyForm(formErr)
+	}
+
+	if generated {
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Recovery Key")).
+				Description("Your recovery key is:\n\n"+
+					recoveryKey+"\n\n"+
+					"Write this down and store it somewhere safe. "+
+					"It WILL NOT be shown again."),
+			huh.NewConfirm().
+				Affirmative("OK").
+				Negative("")),
+		).WithTheme(styles.Theme).Run()
+		utils.HandleCLIError("Error showing recovery key", err)
+	}
+
+	ShowAccountModel()
+}
+
+func showDeleteRecoveryKeyView() {
+	var (
+		identifier string
+		password   string
+		confirmed  bool
+	)
+
+	removeForm := func(prevErr error) error {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = prevErr.Error()
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Remove Recovery Key")).
+				Description("Are you sure you want to remove your "+
+					"recovery key? You will be unable to reset "+
+					"your password if you forget it.\n\nEnter "+
+					"your current login to continue."),
+			huh.NewInput().
+				Title("Identifier").
+				Placeholder("Email / Account ID").
+				Value(&identifier),
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+			huh.NewConfirm().
+				Description(styles.ErrStyle.Render(errMsg)).
+				Affirmative("Remove Recovery Key").
+				Negative("Cancel").
+				Value(&confirmed)),
+		).WithTheme(styles.Theme).Run()
+
+		if err == huh.ErrUserAborted || !confirmed {
+			return nil
+		}
+
+		utils.HandleCLIError("Error showing recovery key form", err)
+
+		_ = spinner.New().Title("Removing recovery key...").Action(func() {
+			err = removeRecoveryKey(identifier, password)
+		}).Run()
+
+		return err
+	}
+
+	formErr := removeForm(nil)
+	for formErr != nil {
+		formErr = removeForm(formErr)
+	}
+
+	ShowAccountModel()
+}
+
 func showAccountDeletionView() {
 	deletionFunc := func(errMsg string) (bool, string) {
 		var id string
@@ -395,6 +635,7 @@ func showAccountDeletionView() {
 		err = globals.API.DeleteAccount(id)
 	}
 
+	_ = globals.Agent.Forget()
 	_ = globals.Config.Reset()
 	fmt.Println("Your YeetFile account has been deleted.")
 }
@@ -575,6 +816,454 @@ func showRecyclePaymentIDView() {
 	ShowAccountModel()
 }
 
+func showFingerprintView() {
+	_, publicKey, err := globals.Config.GetKeys()
+	utils.HandleCLIError("Error reading public key", err)
+
+	_, x25519PublicKey, err := globals.Config.GetX25519Keys()
+	utils.HandleCLIError("Error reading x25519 public key", err)
+
+	kp := crypto.KeyPair{PublicKey: publicKey, X25519PublicKey: x25519PublicKey}
+	desc := "Users who share with you are shown a fingerprint of your " +
+		"public keys. Ask them to compare it with the fingerprint below " +
+		"in person or over another channel, so that they can verify " +
+		"that the server hasn't replaced your keys."
+
+	_ = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader("Key Fingerprint", desc),
+		huh.NewNote().
+			Title("Your Fingerprint").
+			Description(contacts.FormatFingerprint(crypto.OwnFingerprint(kp))),
+		huh.NewConfirm().Affirmative("OK").Negative(""),
+	)).WithTheme(styles.Theme).Run()
+
+	ShowAccountModel()
+}
+
+func showRedeemVoucherView() {
+	var (
+		code      string
+		submitted bool
+		errMsg    string
+		redeemed  shared.RedeemVoucherResponse
+	)
+
+	for {
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Redeem Voucher")).
+				Description("Enter a voucher code to apply its upgrade to your account."),
+			huh.NewInput().
+				Title("Voucher Code").
+				Placeholder("XXXX-XXXX-XXXX").
+				Value(&code),
+			huh.NewConfirm().
+				Affirmative("Redeem").
+				Negative("Cancel").
+				Description(errMsg).
+				Value(&submitted)),
+		).WithTheme(styles.Theme).Run()
+
+		if err != nil || !submitted {
+			ShowAccountModel()
+			return
+		}
+
+		_ = spinner.New().Title("Redeeming voucher...").Action(func() {
+			redeemed, err = globals.API.RedeemVoucher(code)
+		}).Run()
+
+		if err == nil {
+			break
+		}
+
+		errMsg = styles.ErrStyle.Render(err.Error())
+	}
+
+	_ = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(
+			"Redeem Voucher",
+			fmt.Sprintf("Voucher redeemed: %s (x%d)",
+				redeemed.Name,
+				redeemed.Quantity)),
+		huh.NewConfirm().Affirmative("OK").Negative(""),
+	)).WithTheme(styles.Theme).Run()
+
+	ShowAccountModel()
+}
+
+func showInvitesView() {
+	const (
+		back   = ""
+		create = "+"
+	)
+
+	var (
+		invites  shared.AccountInvitesResponse
+		selected string
+		err      error
+	)
+
+	_ = spinner.New().Title("Fetching invites...").Action(func() {
+		invites, err = globals.API.GetAccountInvites()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(err.Error())
+		ShowAccountModel()
+		return
+	}
+
+	options := []huh.Option[string]{huh.NewOption("Go Back", back)}
+	if invites.Used < invites.Allowance {
+		options = append(options, huh.NewOption("Create Invite Link", create))
+	}
+
+	for _, link := range invites.Links {
+		if link.Uses == 0 {
+			options = append(options, huh.NewOption(
+				fmt.Sprintf("Delete invite link (%s)", link.ID),
+				link.ID))
+		}
+	}
+
+	err = huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title(utils.GenerateTitle("Invites")).
+			Description(generateInvitesDesc(invites)),
+		huh.NewSelect[string]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).Run()
+
+	if err != nil || selected == back {
+		ShowAccountModel()
+		return
+	}
+
+	if selected == create {
+		var link shared.InviteLink
+		_ = spinner.New().Title("Creating invite link...").Action(func() {
+			link, err = globals.API.CreateInviteLink()
+		}).Run()
+
+		if err != nil {
+			utils.ShowErrorForm(err.Error())
+		} else {
+			showInviteLinkModel(link)
+			return
+		}
+	} else {
+		_ = spinner.New().Title("Deleting invite link...").Action(func() {
+			err = globals.API.DeleteInviteLink(selected)
+		}).Run()
+
+		if err != nil {
+			utils.ShowErrorForm(err.Error())
+		}
+	}
+
+	showInvitesView()
+}
+
+func showEmergencyAccessView() {
+	const (
+		back       = ""
+		addContact = "+"
+		deny       = "deny:"
+		remove     = "remove:"
+		request    = "request:"
+		leave      = "leave:"
+	)
+
+	var (
+		access   shared.EmergencyAccessResponse
+		selected string
+		err      error
+	)
+
+	_ = spinner.New().Title("Fetching emergency access...").Action(func() {
+		access, err = globals.API.GetEmergencyAccess()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(err.Error())
+		ShowAccountModel()
+		return
+	}
+
+	getName := func(contact shared.EmergencyContact) string {
+		if len(contact.Email) > 0 {
+			return contact.Email
+		}
+
+		return contact.UserID
+	}
+
+	options := []huh.Option[string]{
+		huh.NewOption("Go Back", back),
+		huh.NewOption("Add Emergency Contact", addContact),
+	}
+
+	for _, contact := range access.Contacts {
+		if contact.Status == constants.EmergencyStatusRequested {
+			options = append(options, huh.NewOption(
+				fmt.Sprintf("Deny request (%s)", getName(contact)),
+				deny+contact.UserID))
+		}
+
+		options = append(options, huh.NewOption(
+			fmt.Sprintf("Remove contact (%s)", getName(contact)),
+			remove+contact.UserID))
+	}
+
+	for _, grantor := range access.Grantors {
+		if grantor.Status == constants.EmergencyStatusIdle {
+			options = append(options, huh.NewOption(
+				fmt.Sprintf("Request access (%s)", getName(grantor)),
+				request+grantor.UserID))
+		}
+
+		options = append(options, huh.NewOption(
+			fmt.Sprintf("Stop being a contact (%s)", getName(grantor)),
+			leave+grantor.UserID))
+	}
+
+	err = huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title(utils.GenerateTitle("Emergency Access")).
+			Description(generateEmergencyAccessDesc(access)),
+		huh.NewSelect[string]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).Run()
+
+	if err != nil || selected == back {
+		ShowAccountModel()
+		return
+	} else if selected == addContact {
+		showAddEmergencyContactView()
+		return
+	}
+
+	var (
+		title    string
+		message  string
+		action   string
+		actionFn func(string) error
+		userID   string
+	)
+
+	switch {
+	case strings.HasPrefix(selected, deny):
+		userID = strings.TrimPrefix(selected, deny)
+		title, action = "Deny Request", "Deny Request"
+		message = "Are you sure you want to deny this contact's " +
+			"request for access to your vault?"
+		actionFn = globals.API.DenyEmergencyAccess
+	case strings.HasPrefix(selected, remove):
+		userID = strings.TrimPrefix(selected, remove)
+		title, action = "Remove Contact", "Remove Contact"
+		message = "Are you sure you want to remove this emergency " +
+			"contact? Any access they've been granted will be revoked."
+		actionFn = globals.API.RemoveEmergencyContact
+	case strings.HasPrefix(selected, request):
+		userID = strings.TrimPrefix(selected, request)
+		title, action = "Request Access", "Request Access"
+		message = "Are you sure you want to request access to this " +
+			"user's vault? They will be notified, and can deny your " +
+			"request until the waiting period ends."
+		actionFn = globals.API.RequestEmergencyAccess
+	case strings.HasPrefix(selected, leave):
+		userID = strings.TrimPrefix(selected, leave)
+		title, action = "Stop Being a Contact", "Confirm"
+		message = "Are you sure you want to stop being an emergency " +
+			"contact for this user?"
+		actionFn = globals.API.LeaveEmergencyContacts
+	}
+
+	var confirmed bool
+	err = huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(title, message),
+		huh.NewConfirm().
+			Affirmative(action).
+			Negative("Cancel").
+			Value(&confirmed),
+	)).WithTheme(styles.Theme).Run()
+
+	if err == nil && confirmed {
+		_ = spinner.New().Title("Updating emergency access...").Action(func() {
+			err = actionFn(userID)
+		}).Run()
+
+		if err != nil {
+			utils.ShowErrorForm(err.Error())
+		}
+	}
+
+	showEmergencyAccessView()
+}
+
+func showAddEmergencyContactView() {
+	var (
+		contactIdentifier string
+		waitDays          = strconv.Itoa(constants.DefaultEmergencyWaitDays)
+		identifier        string
+		password          string
+		confirmed         bool
+	)
+
+	contactForm := func(prevErr error) (bool, error) {
+		var errMsg string
+		if prevErr != nil {
+			errMsg = prevErr.Error()
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Add Emergency Contact")).
+				Description("Your emergency contact will be able to "+
+					"request access to your vault. If you don't deny "+
+					"the request within the waiting period, they will "+
+					"be able to view all of your files and passwords."+
+					"\n\nEnter your current login to continue."),
+			huh.NewInput().
+				Title("Contact").
+				Placeholder("Email / Account ID").
+				Value(&contactIdentifier),
+			huh.NewInput().
+				Title("Waiting Period (Days)").
+				Value(&waitDays).
+				Validate(func(s string) error {
+					days, err := strconv.Atoi(s)
+					if err != nil || days < 1 ||
+						days > constants.MaxEmergencyWaitDays {
+						return fmt.Errorf("must be between 1 and %d",
+							constants.MaxEmergencyWaitDays)
+					}
+
+					return nil
+				}),
+			huh.NewInput().
+				Title("Identifier").
+				Placeholder("Email / Account ID").
+				Value(&identifier),
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password),
+			huh.NewConfirm().
+				Description(styles.ErrStyle.Render(errMsg)).
+				Affirmative("Add Contact").
+				Negative("Cancel").
+				Value(&confirmed)),
+		).WithTheme(styles.Theme).Run()
+
+		if err == huh.ErrUserAborted || !confirmed {
+			return false, nil
+		}
+
+		utils.HandleCLIError("Error showing emergency contact form", err)
+
+		var (
+			kp      crypto.KeyPair
+			pubKeys shared.PubKeyResponse
+			contact = strings.TrimSpace(contactIdentifier)
+		)
+
+		_ = spinner.New().Title("Fetching contact keys...").Action(func() {
+			kp, pubKeys, err = fetchEmergencyContactKeys(
+				identifier,
+				password,
+				contact)
+		}).Run()
+		if err != nil {
+			return false, err
+		}
+
+		keysConfirmed, err := contacts.ConfirmKeys(kp, contact, pubKeys)
+		if err != nil || !keysConfirmed {
+			return false, err
+		}
+
+		days, _ := strconv.Atoi(waitDays)
+		_ = spinner.New().Title("Adding emergency contact...").Action(func() {
+			err = addEmergencyContact(kp, pubKeys, contact, days)
+		}).Run()
+
+		return err == nil, err
+	}
+
+	_, formErr := contactForm(nil)
+	for formErr != nil {
+		_, formErr = contactForm(formErr)
+	}
+
+	showEmergencyAccessView()
+}
+
+func showBillingHistoryView() {
+	const back = -1
+
+	var (
+		history  shared.BillingHistoryResponse
+		selected int
+		err      error
+	)
+
+	_ = spinner.New().Title("Fetching billing history...").Action(func() {
+		history, err = globals.API.GetBillingHistory()
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm("Error fetching billing history")
+		ShowAccountModel()
+		return
+	}
+
+	options := []huh.Option[int]{huh.NewOption("Go Back", back)}
+	if globals.ServerInfo.EmailConfigured {
+		for i, purchase := range history.Purchases {
+			options = append(options, huh.NewOption(
+				fmt.Sprintf("Email receipt (%s, %s)",
+					utils.LocalTimeFromUTC(purchase.Date).Format("2006-01-02"),
+					shared.FormatCents(purchase.Amount)),
+				i))
+		}
+	}
+
+	err = huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title(utils.GenerateTitle("Billing History")).
+			Description(generateBillingHistoryDesc(history.Purchases)),
+		huh.NewSelect[int]().
+			Options(options...).
+			Value(&selected),
+	)).WithTheme(styles.Theme).Run()
+
+	if err != nil || selected == back {
+		ShowAccountModel()
+		return
+	}
+
+	_ = spinner.New().Title("Sending receipt...").Action(func() {
+		err = globals.API.EmailReceipt(history.Purchases[selected].ID)
+	}).Run()
+
+	if err != nil {
+		utils.ShowErrorForm(err.Error())
+	} else {
+		_ = huh.NewForm(huh.NewGroup(
+			utils.CreateHeader(
+				"Billing History",
+				"Your receipt has been sent to your email"),
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+	}
+
+	showBillingHistoryView()
+}
+
 func generateSelectOptions(
 	account shared.AccountResponse,
 ) []huh.Option[Action] {
@@ -621,6 +1310,19 @@ func generateSelectOptions(
 
 	options = append(options, twoFactorOption)
 
+	if account.HasRecoveryKey {
+		options = append(
+			options,
+			huh.NewOption("Replace Recovery Key", SetRecoveryKey),
+			huh.NewOption("Remove Recovery Key", DeleteRecoveryKey))
+	} else {
+		options = append(
+			options,
+			huh.NewOption("Set Up Recovery Key", SetRecoveryKey))
+	}
+
+	options = append(options, huh.NewOption("Emergency Access", EmergencyAccess))
+
 	if globals.ServerInfo.BillingEnabled {
 		if len(globals.ServerInfo.Upgrades.SendUpgrades) > 0 {
 			options = append(
@@ -633,9 +1335,26 @@ func generateSelectOptions(
 				options,
 				huh.NewOption("Upgrade Vault", PurchaseVaultUpgrade))
 		}
+
+		options = append(
+			options,
+			huh.NewOption("Billing History", BillingHistory))
+	}
+
+	if len(globals.ServerInfo.Upgrades.SendUpgrades) > 0 ||
+		len(globals.ServerInfo.Upgrades.VaultUpgrades) > 0 {
+		options = append(
+			options,
+			huh.NewOption("Redeem Voucher", RedeemVoucher))
+	}
+
+	if globals.ServerInfo.InvitesAllowed {
+		options = append(options, huh.NewOption("Invites", Invites))
 	}
 
 	options = append(options, huh.NewOption("Recycle Payment ID", RecyclePaymentID))
+	options = append(options, huh.NewOption("View Key Fingerprint", ViewFingerprint))
+	options = append(options, huh.NewOption("Rotate Keys", RotateKeys))
 	options = append(options, huh.NewOption("Delete Account", DeleteAccount))
 	options = append(options, huh.NewOption("Exit", Exit))
 	return options
@@ -757,6 +1476,7 @@ func showCheckoutModel(upgrade shared.Upgrade, isVaultUpgrade bool) {
 	const (
 		stripe int = iota
 		btcpay
+		manual
 		back
 	)
 
@@ -815,12 +1535,23 @@ func showCheckoutModel(upgrade shared.Upgrade, isVaultUpgrade bool) {
 		fields = append(fields, quantityInput)
 	}
 
-	options := []huh.Option[int]{
-		huh.NewOption("Stripe Checkout (USD, CAD, GBP, etc)", stripe),
-		huh.NewOption("BTCPay Checkout (BTC or XMR)", btcpay),
-		huh.NewOption("Go Back", back),
+	var options []huh.Option[int]
+	if globals.ServerInfo.StripeEnabled {
+		options = append(options,
+			huh.NewOption("Stripe Checkout (USD, CAD, GBP, etc)", stripe))
+	}
+
+	if globals.ServerInfo.BTCPayEnabled {
+		options = append(options,
+			huh.NewOption("BTCPay Checkout (BTC or XMR)", btcpay))
 	}
 
+	if globals.ServerInfo.ManualEnabled {
+		options = append(options, huh.NewOption("Pay by Invoice", manual))
+	}
+
+	options = append(options, huh.NewOption("Go Back", back))
+
 	selectField := huh.NewSelect[int]().Options(options...).Value(&selected)
 	fields = append(fields, selectField)
 
@@ -840,6 +1571,11 @@ func showCheckoutModel(upgrade shared.Upgrade, isVaultUpgrade bool) {
 		if err == nil {
 			showCheckoutLinkModel(link)
 		}
+	} else if selected == manual {
+		link, err = globals.API.InitManualCheckout(upgrade, quantity)
+		if err == nil {
+			showInvoiceLinkModel(link)
+		}
 	}
 
 	if err != nil {
@@ -853,6 +1589,24 @@ func showCheckoutLinkModel(link string) {
 	fmt.Printf("When you are finished checking out, you can return to the CLI.\n\n")
 }
 
+func showInvoiceLinkModel(link string) {
+	fmt.Printf("\nUse link below to view your invoice and payment instructions:\n\n%s\n\n", link)
+	fmt.Printf("Viewing the invoice requires logging into your YeetFile " +
+		"account on the web. Your upgrade will be applied once payment has " +
+		"been received.\n\n")
+}
+
+func showInviteLinkModel(link shared.InviteLink) {
+	url := fmt.Sprintf("%s?code=%s",
+		endpoints.HTMLSignup.Format(globals.Config.Server),
+		link.Code)
+	fmt.Printf("\nShare the link below to invite someone to YeetFile:\n\n%s\n\n", url)
+	fmt.Printf("CLI users can enter this code when prompted for the server "+
+		"password:\n\n%s\n\n", link.Code)
+	fmt.Printf("The link expires on %s and will not be shown again.\n\n",
+		utils.LocalTimeFromUTC(link.Expiration).Format(time.DateOnly))
+}
+
 func exitView() {}
 
 func init() {
@@ -864,8 +1618,16 @@ func init() {
 		SetTwoFactor:         showSetTwoFactorView,
 		PurchaseSendUpgrade:  showSendUpgradeView,
 		PurchaseVaultUpgrade: showVaultUpgradeView,
+		RedeemVoucher:        showRedeemVoucherView,
+		Invites:              showInvitesView,
+		BillingHistory:       showBillingHistoryView,
 		DeleteTwoFactor:      showDeleteTwoFactorView,
+		SetRecoveryKey:       showRecoveryKeyView,
+		DeleteRecoveryKey:    showDeleteRecoveryKeyView,
+		EmergencyAccess:      showEmergencyAccessView,
 		RecyclePaymentID:     showRecyclePaymentIDView,
+		ViewFingerprint:      showFingerprintView,
+		RotateKeys:           showRotateKeysView,
 		DeleteAccount:        showAccountDeletionView,
 		Exit:                 exitView,
 	}
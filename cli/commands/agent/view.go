@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"os"
+	"strings"
+	"yeetfile/cli/agent"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+)
+
+type subcommand string
+
+const (
+	startCmd  subcommand = "start"
+	statusCmd subcommand = "status"
+	lockCmd   subcommand = "lock"
+	unlockCmd subcommand = "unlock"
+	stopCmd   subcommand = "stop"
+)
+
+var agentHelp = `Usage: yeetfile agent [command]
+
+Commands:
+  start [--timeout 15m] | Run the agent (default). Keys are forgotten after
+                          the agent has been idle for the timeout (0 to disable)
+  status                | Show the state of the running agent
+  lock                  | Lock the agent's keys with a passphrase
+  unlock                | Unlock the agent's keys
+  stop                  | Stop the agent and forget its keys`
+
+var startedMsg = `YeetFile agent listening on %s (%s)
+
+Log in with 'yeetfile login' to store your session key in the agent.
+Press Ctrl+C or run 'yeetfile agent stop' to stop the agent.
+`
+
+func ShowAgentModel() {
+	cmd := startCmd
+	var args []string
+	if len(os.Args) > 2 && strings.HasPrefix(os.Args[2], "-") {
+		// Flags for the default "start" command
+		args = os.Args[2:]
+	} else if len(os.Args) > 2 {
+		cmd = subcommand(os.Args[2])
+		args = os.Args[3:]
+	}
+
+	var err error
+	switch cmd {
+	case startCmd:
+		err = startAgent(args)
+	case statusCmd:
+		err = showStatus()
+	case lockCmd:
+		err = lockAgent()
+	case unlockCmd:
+		err = unlockAgent()
+	case stopCmd:
+		err = globals.Agent.Stop()
+		if err == nil {
+			fmt.Println("The YeetFile agent has been stopped")
+		}
+	default:
+		fmt.Println(agentHelp)
+		return
+	}
+
+	utils.HandleCLIError("agent error", err)
+}
+
+func startAgent(args []string) error {
+	flags := flag.NewFlagSet("agent", flag.ContinueOnError)
+	idleTimeout := flags.Duration(
+		"timeout",
+		agent.DefaultIdleTimeout,
+		"forget keys after the agent is idle for this long (0 to disable)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	timeoutMsg := "keys are kept until the agent is stopped"
+	if *idleTimeout > 0 {
+		timeoutMsg = fmt.Sprintf("idle timeout: %s", *idleTimeout)
+	}
+
+	socketPath := globals.Config.AgentSocketPath()
+	fmt.Printf(startedMsg, socketPath, timeoutMsg)
+	return agent.Serve(socketPath, *idleTimeout)
+}
+
+func showStatus() error {
+	status, err := globals.Agent.Status()
+	if err != nil {
+		return err
+	}
+
+	state := "Unlocked"
+	if status.Locked {
+		state = "Locked"
+	}
+
+	timeout := "None"
+	if status.IdleTimeout > 0 {
+		timeout = status.IdleTimeout.String()
+	}
+
+	fmt.Printf("Socket:       %s\n", globals.Config.AgentSocketPath())
+	fmt.Printf("State:        %s\n", state)
+	fmt.Printf("Session key:  %s\n", yesNo(status.HasCLIKey))
+	fmt.Printf("Vault keys:   %s\n", yesNo(status.HasKeyPair))
+	fmt.Printf("Idle timeout: %s\n", timeout)
+	return nil
+}
+
+func lockAgent() error {
+	var passphrase, confirmation string
+	err := huh.NewForm(huh.NewGroup(
+		utils.CreateHeader(
+			"Lock Agent",
+			"Set a passphrase to unlock the agent's keys with"),
+		huh.NewInput().Title("Passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase).
+			Validate(func(s string) error {
+				if len(s) == 0 {
+					return errors.New("passphrase cannot be blank")
+				}
+
+				return nil
+			}),
+		huh.NewInput().Title("Confirm Passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&confirmation).
+			Validate(func(s string) error {
+				if s != passphrase {
+					return errors.New("passphrases don't match")
+				}
+
+				return nil
+			}),
+		huh.NewConfirm().Affirmative("Lock").Negative(""),
+	)).WithTheme(styles.Theme).Run()
+	if err != nil {
+		return err
+	}
+
+	err = globals.Agent.Lock([]byte(passphrase))
+	if err == nil {
+		fmt.Println("The YeetFile agent is locked")
+	}
+
+	return err
+}
+
+func unlockAgent() error {
+	var unlockFunc func(errMsgs ...string) error
+	unlockFunc = func(errMsgs ...string) error {
+		var passphrase string
+		title := huh.NewNote().Title(utils.GenerateTitle("Unlock Agent"))
+		if len(errMsgs) > 0 {
+			title.Description(styles.ErrStyle.Render(errMsgs[0]))
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			title,
+			huh.NewInput().Title("Passphrase").
+				EchoMode(huh.EchoModePassword).
+				Value(&passphrase),
+			huh.NewConfirm().Affirmative("Unlock").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+		if err != nil {
+			return err
+		}
+
+		err = globals.Agent.Unlock([]byte(passphrase))
+		if errors.Is(err, agent.IncorrectPassphraseErr) {
+			return unlockFunc("Incorrect passphrase")
+		}
+
+		return err
+	}
+
+	err := unlockFunc()
+	if err == nil {
+		fmt.Println("The YeetFile agent is unlocked")
+	}
+
+	return err
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "Yes"
+	}
+
+	return "No"
+}
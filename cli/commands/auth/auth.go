@@ -14,6 +14,7 @@ func IsUserAuthenticated() (bool, error) {
 
 		// Ensure keys are removed if the user has an older session
 		if len(globals.API.Session) > 0 {
+			_ = globals.Agent.Forget()
 			resetErr := globals.Config.Reset()
 			if resetErr != nil {
 				return false, resetErr
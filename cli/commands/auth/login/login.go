@@ -1,11 +1,13 @@
 package login
 
 import (
+	"log"
 	"strings"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/utils"
 	"yeetfile/shared"
+	"yeetfile/shared/constants"
 )
 
 // LogIn logs into YeetFile by using the provided identifier and password to
@@ -15,7 +17,12 @@ func LogIn(identifier, password, code string, sessionKey, vaultKey []byte) error
 	identifier = strings.TrimSpace(identifier)
 	password = strings.TrimSpace(password)
 
-	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password)
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return err
+	}
+
+	userKey, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf.KDF)
 
 	login := shared.Login{
 		Identifier:   identifier,
@@ -31,12 +38,146 @@ func LogIn(identifier, password, code string, sessionKey, vaultKey []byte) error
 	privateKey, err := crypto.DecryptChunk(userKey, loginResponse.ProtectedKey)
 	utils.HandleCLIError("failed to decrypt private key", err)
 
-	encPrivateKey, _ := crypto.EncryptChunk(vaultKey, privateKey)
-	err = globals.Config.SetKeys(encPrivateKey, loginResponse.PublicKey)
+	kp, err := loadX25519Keys(
+		crypto.IngestKeys(privateKey, loginResponse.PublicKey),
+		userKey,
+		loginResponse.Keys)
+	if err != nil {
+		return err
+	}
+
+	upgradeKDF(identifier, password, userKey, loginKeyHash, kdf)
+	return storeLogin(kp, session, sessionKey, vaultKey)
+}
+
+// upgradeKDF re-derives the user's keys using the server's recommended key
+// derivation parameters if their account is still using weaker parameters.
+// Failing to upgrade doesn't prevent logging in.
+func upgradeKDF(
+	identifier, password string,
+	userKey, loginKeyHash []byte,
+	kdf shared.KDFResponse,
+) {
+	if !kdf.KDF.IsWeakerThan(kdf.RecommendedKDF) {
+		return
+	}
+
+	protectedKeys, err := globals.API.GetUserProtectedKey()
+	if err != nil {
+		log.Printf("Error fetching protected keys: %v\n", err)
+		return
+	}
+
+	newUserKey, newLoginKeyHash := crypto.GenerateUserKeys(
+		identifier,
+		password,
+		kdf.RecommendedKDF)
+	newProtectedKey, newProtectedKeys, err := crypto.ReencryptProtectedKeys(
+		userKey,
+		newUserKey,
+		protectedKeys)
+	if err != nil {
+		log.Printf("Error re-encrypting protected keys: %v\n", err)
+		return
+	}
+
+	err = globals.API.ChangePassword(shared.ChangePassword{
+		OldLoginKeyHash: loginKeyHash,
+		NewLoginKeyHash: newLoginKeyHash,
+		ProtectedKey:    newProtectedKey,
+		ProtectedKeys:   newProtectedKeys,
+		KDF:             kdf.RecommendedKDF,
+	})
+	if err != nil {
+		log.Printf("Error upgrading key derivation params: %v\n", err)
+	}
+}
+
+// loadX25519Keys decrypts the user's X25519 key pair and adds it to their key
+// pair. If the user doesn't have an X25519 key pair yet, a new one is
+// generated and added to their account, along with a recovery copy of the
+// private key if the user has a recovery key. Failing to add a new key pair
+// doesn't prevent logging in, since older servers don't support additional
+// keys.
+func loadX25519Keys(
+	kp crypto.KeyPair,
+	userKey []byte,
+	keys []shared.UserKey,
+) (crypto.KeyPair, error) {
+	for _, key := range keys {
+		if key.Type != constants.KeyTypeX25519 {
+			continue
+		}
+
+		privateKey, err := crypto.DecryptChunk(userKey, key.ProtectedKey)
+		if err != nil {
+			return kp, err
+		}
+
+		kp.X25519PrivateKey = privateKey
+		kp.X25519PublicKey = key.PublicKey
+		return kp, nil
+	}
+
+	privateKey, publicKey, err := crypto.GenerateX25519KeyPair()
+	if err != nil {
+		log.Printf("Error generating x25519 key pair: %v\n", err)
+		return kp, nil
+	}
+
+	protectedKey, err := crypto.EncryptChunk(userKey, privateKey)
+	if err != nil {
+		log.Printf("Error encrypting x25519 key: %v\n", err)
+		return kp, nil
+	}
+
+	x25519Key := shared.UserKey{
+		Type:         constants.KeyTypeX25519,
+		PublicKey:    publicKey,
+		ProtectedKey: protectedKey,
+	}
+
+	recovery, err := globals.API.GetRecoveryStatus()
+	if err != nil {
+		log.Printf("Error fetching recovery key status: %v\n", err)
+	} else if recovery.Enabled {
+		x25519Key.RecoveryProtectedKey, err = crypto.EncryptX25519(
+			recovery.PublicKey,
+			privateKey)
+		if err != nil {
+			log.Printf("Error encrypting x25519 recovery key: %v\n", err)
+			return kp, nil
+		}
+	}
+
+	err = globals.API.AddUserKey(x25519Key)
+	if err != nil {
+		log.Printf("Error adding x25519 key pair: %v\n", err)
+		return kp, nil
+	}
+
+	kp.X25519PrivateKey = privateKey
+	kp.X25519PublicKey = publicKey
+	return kp, nil
+}
+
+// storeLogin encrypts the user's private keys and session, and stores them
+// along with the user's public keys in their config directory
+func storeLogin(kp crypto.KeyPair, session string, sessionKey, vaultKey []byte) error {
+	encPrivateKey, _ := crypto.EncryptChunk(vaultKey, kp.PrivateKey)
+	err := globals.Config.SetKeys(encPrivateKey, kp.PublicKey)
 	if err != nil {
 		return err
 	}
 
+	if len(kp.X25519PrivateKey) > 0 {
+		encX25519Key, _ := crypto.EncryptChunk(vaultKey, kp.X25519PrivateKey)
+		err = globals.Config.SetX25519Keys(encX25519Key, kp.X25519PublicKey)
+		if err != nil {
+			return err
+		}
+	}
+
 	encSession, err := crypto.EncryptChunk(sessionKey, []byte(session))
 	err = globals.Config.SetSession(string(encSession))
 	if err != nil {
@@ -46,6 +187,51 @@ func LogIn(identifier, password, code string, sessionKey, vaultKey []byte) error
 	return nil
 }
 
+// RecoverAccount resets the password of an account using its recovery key. The
+// copies of the user's private keys that were encrypted with the recovery key
+// are decrypted and re-encrypted with a user key derived from the new password.
+func RecoverAccount(identifier, recoveryKey, password string) error {
+	identifier = strings.TrimSpace(identifier)
+	password = strings.TrimSpace(password)
+
+	key, err := crypto.ParseRecoveryKey(recoveryKey)
+	if err != nil {
+		return err
+	}
+
+	keyHash := crypto.RecoveryKeyHash(key)
+	recoveryKeys, err := globals.API.StartRecovery(shared.RecoveryKeysRequest{
+		Identifier: identifier,
+		KeyHash:    keyHash,
+	})
+	if err != nil {
+		return err
+	} else if err = recoveryKeys.KDF.Validate(); err != nil {
+		return err
+	}
+
+	newUserKey, newLoginKeyHash := crypto.GenerateUserKeys(
+		identifier,
+		password,
+		recoveryKeys.KDF)
+	protectedKey, protectedKeys, err := crypto.RecoverProtectedKeys(
+		key,
+		newUserKey,
+		recoveryKeys)
+	if err != nil {
+		return err
+	}
+
+	return globals.API.FinishRecovery(shared.RecoverAccount{
+		Identifier:      identifier,
+		KeyHash:         keyHash,
+		NewLoginKeyHash: newLoginKeyHash,
+		ProtectedKey:    protectedKey,
+		ProtectedKeys:   protectedKeys,
+		KDF:             recoveryKeys.KDF,
+	})
+}
+
 // RequestPasswordHint sends a request for the password hint set for the account
 // matching the provided email.
 func RequestPasswordHint(email string) error {
@@ -0,0 +1,171 @@
+package login
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+const (
+	oidcPollInterval = 2 * time.Second
+
+	// oidcTimeout matches how long the server keeps a login request
+	oidcTimeout = 10 * time.Minute
+)
+
+var (
+	OIDCTimeoutError         = errors.New("timed out waiting for single sign-on login")
+	IncorrectPassphraseError = errors.New("incorrect vault passphrase")
+)
+
+// WaitForOIDCLogin polls the server until the user has finished logging in
+// with the identity provider, and returns the status of the login along with
+// the user's session (if they were logged in directly).
+func WaitForOIDCLogin(start shared.OIDCStartResponse) (shared.OIDCStatusResponse, string, error) {
+	statusReq := shared.OIDCStatusRequest{ID: start.ID, Token: start.Token}
+	deadline := time.Now().Add(oidcTimeout)
+	for time.Now().Before(deadline) {
+		status, session, err := globals.API.GetOIDCStatus(statusReq)
+		if err != nil {
+			return shared.OIDCStatusResponse{}, "", err
+		} else if status.Status == constants.OIDCStatusError {
+			return shared.OIDCStatusResponse{}, "", errors.New(status.Error)
+		} else if status.Status != constants.OIDCStatusPending {
+			return status, session, nil
+		}
+
+		time.Sleep(oidcPollInterval)
+	}
+
+	return shared.OIDCStatusResponse{}, "", OIDCTimeoutError
+}
+
+// VerifyOIDCLogin2FA submits the user's 2FA code for a single sign-on login to
+// an account with two-factor authentication enabled, and returns the status
+// of the login along with the user's session
+func VerifyOIDCLogin2FA(start shared.OIDCStartResponse, code string) (shared.OIDCStatusResponse, string, error) {
+	return globals.API.GetOIDCStatus(shared.OIDCStatusRequest{
+		ID:    start.ID,
+		Token: start.Token,
+		Code:  code,
+	})
+}
+
+// FinishOIDCLogin decrypts the user's private key using their vault
+// passphrase, and stores the user's keys and session
+func FinishOIDCLogin(
+	status shared.OIDCStatusResponse,
+	session, passphrase string,
+	sessionKey, vaultKey []byte,
+) error {
+	kdf, err := shared.KDFResponse{
+		KDF:            status.KDF,
+		RecommendedKDF: status.RecommendedKDF,
+	}.Validated()
+	if err != nil {
+		return err
+	}
+
+	passphrase = strings.TrimSpace(passphrase)
+	userKey, loginKeyHash := crypto.GenerateUserKeys(
+		status.Identifier,
+		passphrase,
+		kdf.KDF)
+	privateKey, err := crypto.DecryptChunk(userKey, status.ProtectedKey)
+	if err != nil {
+		return IncorrectPassphraseError
+	}
+
+	kp, err := loadX25519Keys(
+		crypto.IngestKeys(privateKey, status.PublicKey),
+		userKey,
+		status.Keys)
+	if err != nil {
+		return err
+	}
+
+	upgradeKDF(status.Identifier, passphrase, userKey, loginKeyHash, kdf)
+	return storeLogin(kp, session, sessionKey, vaultKey)
+}
+
+// LinkOIDCAccount links the user's single sign-on identity to their existing
+// account using the account's password (and 2FA code, if enabled), and logs
+// them in
+func LinkOIDCAccount(
+	start shared.OIDCStartResponse,
+	identifier, password, code string,
+	sessionKey, vaultKey []byte,
+) error {
+	password = strings.TrimSpace(password)
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return err
+	}
+
+	_, loginKeyHash := crypto.GenerateUserKeys(identifier, password, kdf.KDF)
+	status, session, err := globals.API.LinkOIDCAccount(shared.OIDCLink{
+		ID:           start.ID,
+		Token:        start.Token,
+		LoginKeyHash: loginKeyHash,
+		Code:         strings.TrimSpace(code),
+	})
+	if err != nil {
+		return err
+	}
+
+	status.Identifier = identifier
+	return FinishOIDCLogin(status, session, password, sessionKey, vaultKey)
+}
+
+// SignupOIDCAccount creates a new account for the user's single sign-on
+// identity, with keys derived from their new vault passphrase. The server
+// password may also be an invite code or invite link code.
+func SignupOIDCAccount(
+	start shared.OIDCStartResponse,
+	identifier, passphrase, serverPassword string,
+	sessionKey, vaultKey []byte,
+) error {
+	passphrase = strings.TrimSpace(passphrase)
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		return err
+	}
+
+	keys, err := crypto.GenerateSignupKeys(identifier, passphrase, kdf.RecommendedKDF)
+	if err != nil {
+		return err
+	}
+
+	session, err := globals.API.SignupOIDCAccount(shared.OIDCSignup{
+		ID:                      start.ID,
+		Token:                   start.Token,
+		LoginKeyHash:            keys.LoginKeyHash,
+		PublicKey:               keys.PublicKey,
+		ProtectedPrivateKey:     keys.ProtectedPrivateKey,
+		ProtectedVaultFolderKey: keys.ProtectedRootFolderKey,
+		KDF:                     kdf.RecommendedKDF,
+		ServerPassword:          serverPassword,
+	})
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := crypto.DecryptChunk(keys.UserKey, keys.ProtectedPrivateKey)
+	if err != nil {
+		return err
+	}
+
+	kp, err := loadX25519Keys(
+		crypto.IngestKeys(privateKey, keys.PublicKey),
+		keys.UserKey,
+		nil)
+	if err != nil {
+		return err
+	}
+
+	return storeLogin(kp, session, sessionKey, vaultKey)
+}
@@ -0,0 +1,230 @@
+package login
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+	"yeetfile/cli/api"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+var oidcLoginLabel = "Log in with single sign-on (SSO)"
+var passwordLoginLabel = "Log in with email/account ID and password"
+
+var oidcURLMessage = `Open the following URL in your browser to log in:
+
+%s
+
+When prompted, enter this confirmation code in your browser: %s
+`
+
+var oidcLoginDesc = `Enter the vault passphrase for %s
+to unlock your vault.`
+
+var oidcLinkDesc = `An account already exists for %s.
+Enter its password to link it to your single sign-on login.`
+
+var oidcSignupDesc = `Choose a vault passphrase for your new account (%s).
+This is separate from your single sign-on password, and is
+needed to decrypt your files and passwords.`
+
+// showLoginMethodModel asks the user how they want to log in, returning true
+// if they chose single sign-on
+func showLoginMethodModel() bool {
+	useOIDC := true
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewNote().Title(utils.GenerateTitle("Login")),
+		huh.NewSelect[bool]().Options(
+			huh.NewOption(oidcLoginLabel, true),
+			huh.NewOption(passwordLoginLabel, false),
+		).Value(&useOIDC),
+	)).WithTheme(styles.Theme).Run()
+	utils.HandleCLIError("", err)
+
+	return useOIDC
+}
+
+// showOIDCLoginModel logs the user in with the server's identity provider. The
+// user finishes the provider's login in their browser, and then enters their
+// vault passphrase here to unlock (or create) their vault keys.
+func showOIDCLoginModel() {
+	var start shared.OIDCStartResponse
+	var startErr error
+	err := spinner.New().Title("Starting login...").Action(
+		func() {
+			start, startErr = globals.API.StartOIDCLogin()
+		}).Run()
+	utils.HandleCLIError("", err)
+	utils.HandleCLIError("error starting login", startErr)
+
+	fmt.Printf(oidcURLMessage, start.AuthURL, start.Code)
+
+	var status shared.OIDCStatusResponse
+	var session string
+	var waitErr error
+	err = spinner.New().Title("Waiting for login to complete in your browser...").Action(
+		func() {
+			status, session, waitErr = WaitForOIDCLogin(start)
+		}).Run()
+	utils.HandleCLIError("", err)
+	utils.HandleCLIError("error logging in", waitErr)
+
+	if status.Status == constants.OIDCStatusLogin && status.TwoFactorRequired {
+		// An incorrect code ends the login, so it isn't retried here
+		code := showTwoFactorPrompt()
+		status, session, err = VerifyOIDCLogin2FA(start, code)
+		utils.HandleCLIError("error logging in", err)
+	}
+
+	sessionKey, err := crypto.GenerateCLISessionKey()
+	utils.HandleCLIError("error generating session key", err)
+
+	var title, desc, extraField string
+	var finishFn func(passphrase, extra string, vaultKey []byte) error
+	switch status.Status {
+	case constants.OIDCStatusLogin:
+		title = "Login > Unlock Vault"
+		desc = fmt.Sprintf(oidcLoginDesc, status.Identifier)
+		finishFn = func(passphrase, _ string, vaultKey []byte) error {
+			return FinishOIDCLogin(status, session, passphrase, sessionKey, vaultKey)
+		}
+	case constants.OIDCStatusLink:
+		title = "Login > Link Account"
+		desc = fmt.Sprintf(oidcLinkDesc, status.Identifier)
+		if status.TwoFactorRequired {
+			extraField = "2FA Code"
+		}
+
+		finishFn = func(password, code string, vaultKey []byte) error {
+			return LinkOIDCAccount(start, status.Identifier, password, code, sessionKey, vaultKey)
+		}
+	case constants.OIDCStatusSignup:
+		title = "Login > Create Account"
+		desc = fmt.Sprintf(oidcSignupDesc, status.Identifier)
+		if status.ServerPasswordRequired {
+			extraField = "Server Password or Invite Code"
+		}
+
+		finishFn = func(passphrase, serverPassword string, vaultKey []byte) error {
+			return SignupOIDCAccount(start, status.Identifier, passphrase, serverPassword, sessionKey, vaultKey)
+		}
+	default:
+		utils.HandleCLIError("error logging in", errors.New("unexpected login status"))
+	}
+
+	confirm := status.Status == constants.OIDCStatusSignup
+	var errMsg string
+	for {
+		passphrase, extra, option := showOIDCPassphraseModel(title, desc, confirm, extraField, errMsg)
+
+		// Vault key is by default the same as the session key, unless
+		// the user provides a unique vault password
+		vaultKey := sessionKey
+		if option == userVaultPwOpt {
+			vaultKeyPassword := promptVaultPassword()
+			vaultKey = crypto.DerivePBKDFKey(
+				[]byte(vaultKeyPassword),
+				sessionKey,
+			)
+		}
+
+		err = finishFn(passphrase, extra, vaultKey)
+		if err == api.PendingApprovalError {
+			showPendingApprovalModel(status.Identifier)
+			return
+		} else if err == IncorrectPassphraseError ||
+			err == api.ServerPasswordError ||
+			(err != nil && status.Status == constants.OIDCStatusLink) {
+			// The passphrase can be retried as long as the login
+			// request is still valid
+			errMsg = err.Error()
+			continue
+		}
+
+		utils.HandleCLIError("error logging in", err)
+		break
+	}
+
+	showCLISessionNote(string(sessionKey))
+}
+
+// showOIDCPassphraseModel prompts for the user's vault passphrase, along with
+// an extra field (if a title is provided for it) such as a 2FA code
+func showOIDCPassphraseModel(
+	title, desc string,
+	confirm bool,
+	extraField, errMsg string,
+) (string, string, int) {
+	var passphrase, extra string
+	var option int
+
+	if len(errMsg) > 0 {
+		desc = styles.ErrStyle.Render("Error: "+errMsg) + "\n\n" + desc
+	}
+
+	fields := []huh.Field{
+		huh.NewNote().Title(utils.GenerateTitle(title)).Description(desc),
+		huh.NewInput().Title("Passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase).
+			Validate(func(s string) error {
+				if len(s) == 0 {
+					return errors.New("passphrase cannot be blank")
+				} else if confirm && len(s) < 8 {
+					return errors.New("passphrase must be at least 8 characters")
+				}
+
+				return nil
+			}),
+	}
+
+	if confirm {
+		fields = append(fields, huh.NewInput().Title("Confirm Passphrase").
+			EchoMode(huh.EchoModePassword).
+			Validate(func(s string) error {
+				if s != passphrase {
+					return errors.New("passphrases do not match")
+				}
+
+				return nil
+			}))
+	}
+
+	if len(extraField) > 0 {
+		fields = append(fields, huh.NewInput().Title(extraField).
+			EchoMode(huh.EchoModePassword).
+			Value(&extra))
+	}
+
+	fields = append(fields,
+		huh.NewSelect[int]().Options(
+			huh.NewOption(randomVaultPwLabel, randomVaultPwOpt),
+			huh.NewOption(userVaultPwLabel, userVaultPwOpt),
+		).Value(&option),
+		huh.NewConfirm().Affirmative("Continue").Negative(""))
+
+	err := huh.NewForm(huh.NewGroup(fields...)).
+		WithTheme(styles.Theme).
+		WithShowHelp(true).
+		Run()
+	utils.HandleCLIError("", err)
+
+	return passphrase, extra, option
+}
+
+func showPendingApprovalModel(identifier string) {
+	_ = huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title(utils.GenerateTitle("Account Created")).
+			Description(fmt.Sprintf("Your account (%s) has been created, "+
+				"but must be approved\nby an admin before you can log in.",
+				identifier)),
+		huh.NewConfirm().Affirmative("OK").Negative(""),
+	)).WithTheme(styles.Theme).Run()
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
 	"strings"
 	"yeetfile/cli/api"
 	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
 	"yeetfile/cli/styles"
 	"yeetfile/cli/utils"
 	"yeetfile/shared"
@@ -43,7 +45,19 @@ var sessionCLIKeyMsg = " export %s"
 var prefixCLIKeyTitle = "Prefix all commands with the env var"
 var prefixCLIKeyMsg = " %s yeetfile vault"
 
+var agentCLIKeyMessage = `Your CLI session key has been stored in the
+YeetFile agent, and doesn't need to be set in
+your environment.
+
+The agent will forget the key when it's stopped%s,
+after which you'll need to log in again.`
+
 func ShowLoginModel() {
+	if globals.ServerInfo.OIDCEnabled && showLoginMethodModel() {
+		showOIDCLoginModel()
+		return
+	}
+
 	var identifier string
 	var password string
 	var option int
@@ -93,11 +107,7 @@ func ShowLoginModel() {
 
 		if !loginSelected {
 			// User selected "forgot password"
-			email := ""
-			if strings.Contains(identifier, "@") {
-				email = identifier
-			}
-			err = showForgotPasswordModel(email, "")
+			err = showForgotPasswordModel(identifier)
 			if err == nil {
 				return runFunc("")
 			}
@@ -158,6 +168,11 @@ func showTwoFactorPrompt() string {
 }
 
 func showCLISessionNote(sessionKey string) {
+	if globals.Agent.SetCLIKey([]byte(sessionKey)) == nil {
+		showAgentSessionNote()
+		return
+	}
+
 	formattedVar := fmt.Sprintf(
 		cliKeyFormat,
 		shared.EscapeString(crypto.CLIKeyEnvVar),
@@ -221,7 +236,138 @@ func showCLISessionNote(sessionKey string) {
 	utils.HandleCLIError("error showing session note", err)
 }
 
-func showForgotPasswordModel(email string, errMsg string) error {
+// showAgentSessionNote lets the user know that their session key is being
+// held by the YeetFile agent
+func showAgentSessionNote() {
+	var idleTimeout string
+	if status, err := globals.Agent.Status(); err == nil && status.IdleTimeout > 0 {
+		idleTimeout = fmt.Sprintf(" or has\nbeen idle for %s", status.IdleTimeout)
+	}
+
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().Title(
+				utils.GenerateTitle("Vault Session Key")).
+				Description(fmt.Sprintf(agentCLIKeyMessage, idleTimeout)),
+			huh.NewConfirm().Affirmative("OK").Negative(""),
+		),
+	).WithTheme(styles.Theme).Run()
+
+	utils.HandleCLIError("error showing session note", err)
+}
+
+func showForgotPasswordModel(identifier string) error {
+	const (
+		passwordHintOpt = iota
+		recoveryKeyOpt
+	)
+
+	var option int
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Forgot Password")),
+			huh.NewSelect[int]().Options(
+				huh.NewOption("Email my password hint", passwordHintOpt),
+				huh.NewOption("Reset password with recovery key", recoveryKeyOpt),
+			).Value(&option),
+		)).WithTheme(styles.Theme).Run()
+
+	if err == huh.ErrUserAborted {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if option == recoveryKeyOpt {
+		return showRecoveryKeyModel(identifier, "")
+	}
+
+	email := ""
+	if strings.Contains(identifier, "@") {
+		email = identifier
+	}
+
+	return showPasswordHintModel(email, "")
+}
+
+func showRecoveryKeyModel(identifier string, errMsg string) error {
+	var (
+		recoveryKey string
+		password    string
+		submitted   bool
+		desc        string
+	)
+
+	if len(errMsg) > 0 {
+		desc = styles.ErrStyle.Render(errMsg)
+	}
+
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Forgot Password")).
+				Description("Enter your account's recovery key below "+
+					"to set a new password.\n\n"+
+					"You will be logged out of all of your devices."),
+			huh.NewInput().Title("Identifier").
+				Description("Email or Account ID").
+				Value(&identifier),
+			huh.NewInput().Title("Recovery Key").
+				EchoMode(huh.EchoModePassword).
+				Value(&recoveryKey),
+			huh.NewInput().Title("New Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password).
+				Validate(func(s string) error {
+					if len(s) < 8 {
+						return errors.New("password must be at least 8 characters")
+					}
+
+					return nil
+				}),
+			huh.NewInput().Title("Confirm New Password").
+				EchoMode(huh.EchoModePassword).
+				Validate(func(s string) error {
+					if s != password {
+						return errors.New("passwords do not match")
+					}
+
+					return nil
+				}),
+			huh.NewConfirm().
+				Affirmative("Reset Password").
+				Negative("Cancel").
+				Description(desc).
+				Value(&submitted),
+		)).WithTheme(styles.Theme).Run()
+
+	if err == huh.ErrUserAborted || !submitted {
+		return nil
+	}
+
+	_ = spinner.New().Title("Resetting password...").Action(func() {
+		err = RecoverAccount(identifier, recoveryKey, password)
+	}).Run()
+	if err != nil {
+		return showRecoveryKeyModel(identifier, err.Error())
+	}
+
+	_ = huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title(utils.GenerateTitle("Forgot Password")).
+				Description("Your password has been reset!\n"+
+					"You can now log in with your new password."),
+			huh.NewConfirm().
+				Affirmative("OK").
+				Negative("")),
+	).WithTheme(styles.Theme).Run()
+
+	return nil
+}
+
+func showPasswordHintModel(email string, errMsg string) error {
 	var submitted bool
 	var desc string
 	if len(errMsg) > 0 {
@@ -250,7 +396,7 @@ func showForgotPasswordModel(email string, errMsg string) error {
 
 	err = RequestPasswordHint(email)
 	if err != nil {
-		return showForgotPasswordModel(email, err.Error())
+		return showPasswordHintModel(email, err.Error())
 	}
 
 	_ = huh.NewForm(
@@ -10,6 +10,9 @@ func LogOut() error {
 		return err
 	}
 
+	// The agent is only holding keys for the session that just ended
+	_ = globals.Agent.Forget()
+
 	err = globals.Config.Reset()
 	return err
 }
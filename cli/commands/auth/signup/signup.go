@@ -2,6 +2,7 @@ package signup
 
 import (
 	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
 	"yeetfile/cli/utils"
 	"yeetfile/shared"
 )
@@ -9,17 +10,42 @@ import (
 // CreateSignupRequest generates all necessary keys, hashes, etc. for initial
 // signup. Note that for signup requests without email, an empty signup struct
 // is valid since the request has to be generated after the server provides
-// an account ID for the user.
-func CreateSignupRequest(identifier, password, hint, serverPw string) shared.Signup {
+// an account ID for the user. If a recovery key is provided, a copy of the
+// user's private key is encrypted with it.
+func CreateSignupRequest(
+	identifier, password, hint, serverPw string,
+	recoveryKey []byte,
+) shared.Signup {
 	if len(identifier) == 0 {
 		return shared.Signup{}
 	}
 
-	signupKeys, err := crypto.GenerateSignupKeys(identifier, password)
+	kdf, err := globals.API.GetKDFParams(identifier)
+	if err != nil {
+		utils.HandleCLIError("error fetching key derivation params", err)
+	}
+
+	signupKeys, err := crypto.GenerateSignupKeys(
+		identifier,
+		password,
+		kdf.RecommendedKDF)
 	if err != nil {
 		utils.HandleCLIError("error generating signup keys", err)
 	}
 
+	var recovery shared.RecoveryKey
+	if len(recoveryKey) > 0 {
+		recovery, err = crypto.CreateRecoveryKey(
+			recoveryKey,
+			signupKeys.UserKey,
+			shared.ProtectedKeyResponse{
+				ProtectedKey: signupKeys.ProtectedPrivateKey,
+			})
+		if err != nil {
+			utils.HandleCLIError("error generating recovery key", err)
+		}
+	}
+
 	return shared.Signup{
 		Identifier:              identifier,
 		LoginKeyHash:            signupKeys.LoginKeyHash,
@@ -28,11 +54,16 @@ func CreateSignupRequest(identifier, password, hint, serverPw string) shared.Sig
 		ProtectedVaultFolderKey: signupKeys.ProtectedRootFolderKey,
 		ServerPassword:          serverPw,
 		PasswordHint:            hint,
+		KDF:                     kdf.RecommendedKDF,
+		RecoveryKey:             recovery,
 	}
 }
 
-func CreateVerificationRequest(identifier, password, code string) shared.VerifyAccount {
-	signup := CreateSignupRequest(identifier, password, "", "")
+func CreateVerificationRequest(
+	identifier, password, code string,
+	recoveryKey []byte,
+) shared.VerifyAccount {
+	signup := CreateSignupRequest(identifier, password, "", "", recoveryKey)
 	return shared.VerifyAccount{
 		ID:                      signup.Identifier,
 		Code:                    code,
@@ -40,5 +71,7 @@ func CreateVerificationRequest(identifier, password, code string) shared.VerifyA
 		PublicKey:               signup.PublicKey,
 		ProtectedPrivateKey:     signup.ProtectedPrivateKey,
 		ProtectedVaultFolderKey: signup.ProtectedVaultFolderKey,
+		KDF:                     signup.KDF,
+		RecoveryKey:             signup.RecoveryKey,
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"log"
 	"strings"
 	"yeetfile/cli/api"
+	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/styles"
 	"yeetfile/cli/utils"
@@ -27,12 +28,25 @@ your account if you ever lose your account number.`
 const showIDMessage = `Your account ID is: %s -- write this down!
 This is what you will use to log in, and will not be shown again.`
 
+const recoveryKeyDesc = "A recovery key lets you reset your password if you " +
+	"forget it. It can also be set up later from your account settings."
+const showRecoveryKeyMessage = `Your recovery key is:
+
+%s
+
+Write this down and store it somewhere safe. Anyone with your recovery key
+can reset your password. It will not be shown again.`
+
+const pendingApprovalMessage = "Your account must be approved by an admin " +
+	"before you can log in."
+
 // ShowSignupModel is the main entrypoint to the YeetFile signup process
 func ShowSignupModel() {
 	var email string
 	var password string
 	var passwordHint string
 	var signupType string
+	var withRecoveryKey bool
 
 	var options []huh.Option[string]
 	if globals.ServerInfo.EmailConfigured {
@@ -78,6 +92,9 @@ func ShowSignupModel() {
 					"Setting a password hint is recommended.").
 				Lines(2).
 				Value(&passwordHint),
+			huh.NewConfirm().Title("Generate Recovery Key").
+				Description(recoveryKeyDesc).
+				Value(&withRecoveryKey),
 			huh.NewConfirm().Affirmative("Submit").Negative(""),
 		).WithHideFunc(func() bool {
 			return signupType != signupEmail
@@ -97,6 +114,9 @@ func ShowSignupModel() {
 
 					return errors.New("passwords do not match")
 				}),
+			huh.NewConfirm().Title("Generate Recovery Key").
+				Description(recoveryKeyDesc).
+				Value(&withRecoveryKey),
 			huh.NewConfirm().Affirmative("Submit").Negative(""),
 		).WithHideFunc(func() bool {
 			return signupType != signupIDOnly
@@ -104,27 +124,46 @@ func ShowSignupModel() {
 	).WithTheme(styles.Theme).WithShowHelp(true).Run()
 	utils.HandleCLIError("", err)
 
+	var recoveryKey []byte
+	if withRecoveryKey {
+		recoveryKey, err = crypto.GenerateRecoveryKey()
+		utils.HandleCLIError("error generating recovery key", err)
+	}
+
 	if signupType == signupIDOnly {
-		showIDOnlySignupModel(password, "")
+		showIDOnlySignupModel(password, "", recoveryKey)
 	} else if signupType == signupEmail {
-		showEmailSignupModel(email, password, passwordHint, "")
+		showEmailSignupModel(email, password, passwordHint, "", recoveryKey)
 	}
 }
 
 // showEmailSignupModel shows a spinner while the user's account is created
 // and finalized.
-func showEmailSignupModel(email, password, hint, serverPw string) {
+func showEmailSignupModel(
+	email, password, hint, serverPw string,
+	recoveryKey []byte,
+) {
 	var signupErr error
 	err := spinner.New().Title("Creating account...").Action(
 		func() {
-			signup := CreateSignupRequest(email, password, hint, serverPw)
+			signup := CreateSignupRequest(
+				email,
+				password,
+				hint,
+				serverPw,
+				recoveryKey)
 			_, signupErr = globals.API.SubmitSignup(signup)
 		}).Run()
 	utils.HandleCLIError("", err)
 
 	if signupErr == api.ServerPasswordError {
 		serverPassword := showServerPasswordPrompt()
-		showEmailSignupModel(email, password, hint, serverPassword)
+		showEmailSignupModel(
+			email,
+			password,
+			hint,
+			serverPassword,
+			recoveryKey)
 		return
 	}
 
@@ -172,10 +211,16 @@ func showEmailSignupModel(email, password, hint, serverPw string) {
 	}
 
 	runFunc()
+	showRecoveryKeyModel(recoveryKey)
+
+	completeMsg := "You may now log in!"
+	if globals.ServerInfo.ApprovalRequired {
+		completeMsg = pendingApprovalMessage
+	}
 
 	err = huh.NewForm(huh.NewGroup(
 		huh.NewNote().Title(utils.GenerateTitle("Signup Complete")).
-			Description("You may now log in!"),
+			Description(completeMsg),
 		huh.NewConfirm().Affirmative("Log In").Negative(""))).
 		WithTheme(styles.Theme).Run()
 	utils.HandleCLIError("", err)
@@ -183,7 +228,7 @@ func showEmailSignupModel(email, password, hint, serverPw string) {
 
 // showIDOnlySignupModel shows a spinner while the user's ID-only account is
 // created and finalized.
-func showIDOnlySignupModel(password, serverPw string) {
+func showIDOnlySignupModel(password, serverPw string, recoveryKey []byte) {
 	var response shared.SignupResponse
 	var signupErr error
 	err := spinner.New().Title("Creating account...").Action(
@@ -205,7 +250,7 @@ func showIDOnlySignupModel(password, serverPw string) {
 
 	if signupErr == api.ServerPasswordError {
 		serverPassword := showServerPasswordPrompt()
-		showIDOnlySignupModel(password, serverPassword)
+		showIDOnlySignupModel(password, serverPassword, recoveryKey)
 		return
 	}
 
@@ -223,7 +268,8 @@ func showIDOnlySignupModel(password, serverPw string) {
 					verify := CreateVerificationRequest(
 						response.Identifier,
 						password,
-						verificationCode)
+						verificationCode,
+						recoveryKey)
 					verifyErr = globals.API.VerifyAccount(verify)
 				}).Run()
 			utils.HandleCLIError("", err)
@@ -231,6 +277,7 @@ func showIDOnlySignupModel(password, serverPw string) {
 				runFunc(verifyErr.Error())
 			}
 
+			showRecoveryKeyModel(recoveryKey)
 			showAccountConfirmationModel(response.Identifier)
 		}
 
@@ -285,6 +332,9 @@ func showCaptchaModel(captchaStr string, errorMessages ...string) string {
 // showAccountConfirmationModel displays the user's new account ID to the user
 func showAccountConfirmationModel(id string) {
 	msg := fmt.Sprintf(showIDMessage, id)
+	if globals.ServerInfo.ApprovalRequired {
+		msg += "\n\n" + pendingApprovalMessage
+	}
 	err := huh.NewForm(
 		huh.NewGroup(
 			huh.NewNote().Title(utils.GenerateTitle("Your Account ID")).
@@ -300,6 +350,26 @@ func showAccountConfirmationModel(id string) {
 	utils.HandleCLIError("error showing confirmation", err)
 }
 
+// showRecoveryKeyModel displays the user's new recovery key to the user, if
+// they chose to generate one
+func showRecoveryKeyModel(recoveryKey []byte) {
+	if len(recoveryKey) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf(
+		showRecoveryKeyMessage,
+		crypto.FormatRecoveryKey(recoveryKey))
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().Title(utils.GenerateTitle("Your Recovery Key")).
+				Description(msg),
+			huh.NewConfirm().Affirmative("Continue").Negative(""),
+		),
+	).WithTheme(styles.Theme).WithShowHelp(true).Run()
+	utils.HandleCLIError("error showing recovery key", err)
+}
+
 func showServerPasswordPrompt() string {
 	var serverPw string
 	msg := fmt.Sprintf("This server (%s) is password protected.\nPlease enter"+
@@ -99,7 +99,10 @@ func (d DownloadResource) decryptResponse(
 	if len(password) == 0 {
 		key = d.Secret
 	} else {
-		key, _, err = crypto.DeriveSendingKey([]byte(password), d.Secret)
+		key, _, err = crypto.DeriveSendingKey(
+			[]byte(password),
+			d.Secret,
+			response.KDF.OrDefault(shared.LegacySendKDFParams()))
 	}
 
 	if err != nil {
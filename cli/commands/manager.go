@@ -9,12 +9,15 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"yeetfile/cli/agent"
 	"yeetfile/cli/commands/account"
+	agentcmd "yeetfile/cli/commands/agent"
 	"yeetfile/cli/commands/auth"
 	"yeetfile/cli/commands/auth/login"
 	"yeetfile/cli/commands/auth/logout"
 	"yeetfile/cli/commands/auth/signup"
 	"yeetfile/cli/commands/download"
+	"yeetfile/cli/commands/profile"
 	"yeetfile/cli/commands/send"
 	"yeetfile/cli/commands/vault"
 	"yeetfile/cli/crypto"
@@ -35,6 +38,8 @@ const (
 	Send     Command = "send"
 	Download Command = "download"
 	Account  Command = "account"
+	Agent    Command = "agent"
+	Profile  Command = "profile"
 	Help     Command = "help"
 )
 
@@ -48,13 +53,22 @@ var CommandMap = map[Command][]func(){
 	Send:     {send.ShowSendModel},
 	Download: {download.ShowDownloadModel},
 	Account:  {account.ShowAccountModel},
+	Agent:    {agentcmd.ShowAgentModel},
+	Profile:  {profile.ShowProfileModel},
 	Help:     {printHelp},
 }
 
 var AuthHelp = []string{
-	fmt.Sprintf("%s | Create a new YeetFile account", Signup),
-	fmt.Sprintf("%s  | Log into your YeetFile account", Login),
-	fmt.Sprintf("%s | Log out of your YeetFile account", Logout),
+	fmt.Sprintf("%s  | Create a new YeetFile account", Signup),
+	fmt.Sprintf("%s   | Log into your YeetFile account", Login),
+	fmt.Sprintf("%s  | Log out of your YeetFile account", Logout),
+	fmt.Sprintf("%s   | Keep your session keys in memory instead of %s\n"+
+		"             - Example: yeetfile agent\n"+
+		"             - Example: yeetfile agent lock|unlock|status|stop",
+		Agent, crypto.CLIKeyEnvVar),
+	fmt.Sprintf("%s | Manage profiles for multiple YeetFile servers\n"+
+		"             - Example: yeetfile profile add work https://yeetfile.example.com\n"+
+		"             - Example: yeetfile profile list|use", Profile),
 }
 
 var ActionHelp = []string{
@@ -74,7 +88,7 @@ var ActionHelp = []string{
 }
 
 var HelpMsg = `
-Usage: yeetfile <command> [args]
+Usage: yeetfile [--profile name] <command> [args]
 `
 
 var CommandHelpStr = `
@@ -145,14 +159,17 @@ func Entrypoint(args []string) {
 		if _, ok := authErr.(*net.OpError); ok {
 			utils.HandleCLIError("Unable to connect to the server", authErr)
 			return
-		} else if !isAuthCommand(command) && command != Download && authErr != nil {
+		} else if !isAuthCommand(command) &&
+			!isLocalCommand(command) &&
+			command != Download &&
+			authErr != nil {
 			styles.PrintErrStr("You are not logged in. " +
 				"Use the 'login' or 'signup' commands to continue.")
 			return
 		}
 	}
 
-	if !isAuthCommand(command) {
+	if !isAuthCommand(command) && !isLocalCommand(command) {
 		sessionErr := validateCurrentSession()
 		if sessionErr != nil {
 			errStr := fmt.Sprintf("Error validating session: %v", sessionErr)
@@ -195,20 +212,36 @@ func validateAuth() error {
 }
 
 func validateCurrentSession() error {
-	cliKey := crypto.ReadCLIKey()
-	if cliKey == nil || len(cliKey) == 0 {
-		errMsg := fmt.Sprintf(`Missing '%[1]s' environment variable.
-You must include the value returned for this variable either in your shell
-config file (.bashrc, .zshrc, etc), run 'export %[1]s=xxxx' in your current 
-session, or prefix commands with the variable (i.e. %[1]s=xxxx yeetfile vault)`,
-			crypto.CLIKeyEnvVar)
-		return errors.New(errMsg)
+	if len(globals.CLIKey) > 0 || len(crypto.ReadCLIKey()) > 0 {
+		return nil
 	}
 
-	return nil
+	if status, err := globals.Agent.Status(); err == nil && status.Locked {
+		return errors.New("the YeetFile agent is locked. " +
+			"Run 'yeetfile agent unlock' to continue.")
+	} else if err == nil {
+		return errors.New("the YeetFile agent isn't holding a session key. " +
+			"Log in again to store your session key in the agent.")
+	} else if !errors.Is(err, agent.NotRunningErr) {
+		return err
+	}
+
+	errMsg := fmt.Sprintf(`Missing '%[1]s' environment variable.
+You can either start the YeetFile agent ('yeetfile agent') and log in again to
+keep the key in memory, or include the value returned for this variable in your
+shell config file (.bashrc, .zshrc, etc), run 'export %[1]s=xxxx' in your current
+session, or prefix commands with the variable (i.e. %[1]s=xxxx yeetfile vault)`,
+		crypto.CLIKeyEnvVar)
+	return errors.New(errMsg)
 }
 
 // isAuthCommand checks if the provided command is related to authentication
 func isAuthCommand(cmd Command) bool {
 	return cmd == Login || cmd == Signup || cmd == Logout || cmd == Auth
 }
+
+// isLocalCommand checks if the provided command only manages the local CLI
+// setup, and can be used without being logged in
+func isLocalCommand(cmd Command) bool {
+	return cmd == Agent || cmd == Profile
+}
@@ -0,0 +1,161 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"net/url"
+	"os"
+	"yeetfile/cli/config"
+	"yeetfile/cli/globals"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+)
+
+type subcommand string
+
+const (
+	listCmd subcommand = "list"
+	addCmd  subcommand = "add"
+	useCmd  subcommand = "use"
+)
+
+var profileHelp = `Usage: yeetfile profile [command]
+
+Commands:
+  list                | List configured profiles (default)
+  add [name] [server] | Add a profile for a YeetFile server
+  use [name]          | Use a profile when --profile and YEETFILE_PROFILE aren't set`
+
+func ShowProfileModel() {
+	cmd := listCmd
+	var args []string
+	if len(os.Args) > 2 {
+		cmd = subcommand(os.Args[2])
+		args = os.Args[3:]
+	}
+
+	var err error
+	switch cmd {
+	case listCmd:
+		listProfiles()
+	case addCmd:
+		err = addProfile(args)
+	case useCmd:
+		err = useProfile(args)
+	default:
+		fmt.Println(profileHelp)
+		return
+	}
+
+	utils.HandleCLIError("profile error", err)
+}
+
+func listProfiles() {
+	currentProfile := globals.Config.CurrentProfile
+	if len(currentProfile) == 0 {
+		currentProfile = config.DefaultProfile
+	}
+
+	for _, name := range globals.Config.ProfileNames() {
+		profile, err := globals.Config.GetProfile(name)
+		if err != nil {
+			continue
+		}
+
+		marker := " "
+		if name == globals.Config.ProfileName {
+			marker = "*"
+		}
+
+		line := fmt.Sprintf("%s %s (%s)", marker, name, profile.Server)
+		if name == currentProfile {
+			line += " [current]"
+		}
+
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	fmt.Println("* = active profile")
+}
+
+func addProfile(args []string) error {
+	var name, server string
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	if len(args) > 1 {
+		server = args[1]
+	}
+
+	if len(name) == 0 || len(server) == 0 {
+		err := huh.NewForm(huh.NewGroup(
+			utils.CreateHeader(
+				"Add Profile",
+				"Each profile has its own login and keys"),
+			huh.NewInput().Title("Name").
+				Placeholder("work").
+				Value(&name),
+			huh.NewInput().Title("Server").
+				Placeholder("https://yeetfile.example.com").
+				Value(&server).
+				Validate(validateServer),
+			huh.NewConfirm().Affirmative("Add").Negative(""),
+		)).WithTheme(styles.Theme).Run()
+		if err != nil {
+			return err
+		}
+	} else if err := validateServer(server); err != nil {
+		return err
+	}
+
+	err := globals.Config.AddProfile(name, config.Profile{Server: server})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added profile '%s'\n\n"+
+		"Use 'yeetfile --profile %[1]s login' to log in, or\n"+
+		"'yeetfile profile use %[1]s' to use it by default.\n", name)
+	return nil
+}
+
+func useProfile(args []string) error {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	} else {
+		var options []huh.Option[string]
+		for _, profileName := range globals.Config.ProfileNames() {
+			options = append(options, huh.NewOption(profileName, profileName))
+		}
+
+		err := huh.NewForm(huh.NewGroup(
+			huh.NewNote().Title(utils.GenerateTitle("Use Profile")),
+			huh.NewSelect[string]().Options(options...).Value(&name),
+		)).WithTheme(styles.Theme).Run()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := globals.Config.SetCurrentProfile(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Now using profile '%s'\n", name)
+	return nil
+}
+
+func validateServer(server string) error {
+	parsed, err := url.Parse(server)
+	if err != nil || len(parsed.Host) == 0 ||
+		(parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.New("server must be a URL beginning with http:// or https://")
+	}
+
+	return nil
+}
@@ -56,8 +56,9 @@ func getExpString(value int64, units string) string {
 }
 
 func createTextLink(upload textUpload) (string, string, error) {
+	kdf := shared.SendKDFParams()
 	key, salt, err := crypto.DeriveSendingKey(
-		[]byte(upload.Password), nil)
+		[]byte(upload.Password), nil, kdf)
 	if err != nil {
 		return "", "", err
 	}
@@ -78,6 +79,7 @@ func createTextLink(upload textUpload) (string, string, error) {
 		Downloads:  upload.MaxDownloads,
 		Expiration: createExpString(upload.ExpValue, upload.ExpUnits),
 		Text:       encText,
+		KDF:        kdf,
 	}
 
 	id, err := globals.API.UploadText(encTextUpload)
@@ -93,8 +95,9 @@ func createTextLink(upload textUpload) (string, string, error) {
 }
 
 func createFileLink(upload fileUpload, progress func(int, int)) (string, string, error) {
+	kdf := shared.SendKDFParams()
 	key, salt, err := crypto.DeriveSendingKey(
-		[]byte(upload.Password), nil)
+		[]byte(upload.Password), nil, kdf)
 	if err != nil {
 		return "", "", err
 	}
@@ -112,6 +115,7 @@ func createFileLink(upload fileUpload, progress func(int, int)) (string, string,
 		Size:       size,
 		Downloads:  upload.MaxDownloads,
 		Expiration: createExpString(upload.ExpValue, upload.ExpUnits),
+		KDF:        kdf,
 	}
 
 	pending, err := transfer.InitSendFile(file, metadata, key)
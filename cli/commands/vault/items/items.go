@@ -28,6 +28,12 @@ type VaultContext struct {
 
 var keyPair crypto.KeyPair
 
+// VaultKeyPair returns the user's key pair, once it has been unlocked by
+// opening the vault
+func VaultKeyPair() crypto.KeyPair {
+	return keyPair
+}
+
 func FetchVaultContext(folderID string, isPassVault bool) (*VaultContext, error) {
 	if context, ok := folderContexts[folderID]; ok {
 		return context, nil
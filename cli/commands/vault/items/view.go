@@ -10,12 +10,10 @@ import (
 	"github.com/charmbracelet/huh"
 	huhSpinner "github.com/charmbracelet/huh/spinner"
 	"github.com/charmbracelet/lipgloss"
-	"log"
 	"os"
 	"strings"
 	"unicode"
 	"yeetfile/cli/commands/vault/internal"
-	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/models"
 	"yeetfile/cli/styles"
@@ -698,7 +696,7 @@ func ShowVaultPasswordPromptModel(errorMsgs ...string) ([]byte, error) {
 func RunVaultModel(m Model, event internal.Event) (Model, error) {
 	if keyPair.PublicKey == nil || keyPair.PrivateKey == nil {
 		var keyErr error
-		keyPair, keyErr = unlockVaultKeys()
+		keyPair, keyErr = globals.UnlockKeyPair(ShowVaultPasswordPromptModel)
 		if keyErr != nil {
 			errMsg := fmt.Sprintf(
 				"Error decrypting vault keys: %v\n",
@@ -732,41 +730,3 @@ func RunVaultModel(m Model, event internal.Event) (Model, error) {
 	model, err := p.Run()
 	return model.(Model), err
 }
-
-func unlockVaultKeys() (crypto.KeyPair, error) {
-	var kp crypto.KeyPair
-
-	cliKey := crypto.ReadCLIKey()
-	encPrivateKey, publicKey, err := globals.Config.GetKeys()
-	if err != nil {
-		errMsg := fmt.Sprintf("Error reading key files: %v\n", err)
-		styles.PrintErrStr(errMsg)
-	}
-
-	if privateKey, err := crypto.DecryptChunk(cliKey, encPrivateKey); err == nil {
-		kp = crypto.IngestKeys(privateKey, publicKey)
-	} else {
-		var cliKeyFunc func(errMsgs ...string) error
-		cliKeyFunc = func(errMsgs ...string) error {
-			cliPassword, err := ShowVaultPasswordPromptModel(errMsgs...)
-			if err != nil {
-				return err
-			}
-			key := crypto.DerivePBKDFKey(cliPassword, cliKey)
-			if privateKey, err := crypto.DecryptChunk(key, encPrivateKey); err == nil {
-				kp = crypto.IngestKeys(privateKey, publicKey)
-				return nil
-			} else {
-				return cliKeyFunc("Incorrect password")
-			}
-		}
-
-		err = cliKeyFunc()
-		if err != nil {
-			log.Printf("Error loading vault keys: %v\n", err)
-			return crypto.KeyPair{}, err
-		}
-	}
-
-	return kp, nil
-}
@@ -1,6 +1,8 @@
 package share
 
 import (
+	"errors"
+	"yeetfile/cli/contacts"
 	"yeetfile/cli/crypto"
 	"yeetfile/cli/globals"
 	"yeetfile/cli/models"
@@ -10,6 +12,8 @@ import (
 const ReadPerm = "Read Only"
 const WritePerm = "Read + Write"
 
+var cancelledErr = errors.New("sharing cancelled")
+
 type Action int
 
 const (
@@ -60,6 +64,7 @@ func shareItem(
 	item models.VaultItem,
 	decryptFunc crypto.CryptFunc,
 	decryptKey []byte,
+	keyPair crypto.KeyPair,
 	recipient string,
 	perm Perm,
 ) (shared.ShareInfo, error) {
@@ -68,7 +73,7 @@ func shareItem(
 		return shared.ShareInfo{}, err
 	}
 
-	userKey, err := generateUserProtectedKey(recipient, itemKey)
+	userKey, err := generateUserProtectedKey(keyPair, recipient, itemKey)
 	if err != nil {
 		return shared.ShareInfo{}, err
 	}
@@ -86,7 +91,11 @@ func shareItem(
 	}
 }
 
+// generateUserProtectedKey wraps an item's key with the recipient's public
+// key, once the user has confirmed that the server returned the recipient's
+// actual keys
 func generateUserProtectedKey(
+	keyPair crypto.KeyPair,
 	recipient string,
 	key []byte,
 ) ([]byte, error) {
@@ -95,7 +104,16 @@ func generateUserProtectedKey(
 		return nil, err
 	}
 
-	userItemKey, err := crypto.EncryptRSA(pubKeyResponse.PublicKey, key)
+	confirmed, err := contacts.ConfirmKeys(keyPair, recipient, pubKeyResponse)
+	if err != nil {
+		return nil, err
+	} else if !confirmed {
+		return nil, cancelledErr
+	}
+
+	// Recipients only have an X25519 key if their client supports it
+	publicKey := crypto.SelectPublicKey(pubKeyResponse)
+	userItemKey, err := crypto.WrapKey(publicKey, key)
 	if err != nil {
 		return nil, err
 	}
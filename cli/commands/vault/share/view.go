@@ -20,6 +20,7 @@ type model struct {
 	errMsg      string
 	decryptFunc crypto.CryptFunc
 	decryptKey  []byte
+	keyPair     crypto.KeyPair
 }
 
 var actionMap map[Action]func(model) (internal.Event, error)
@@ -60,9 +61,12 @@ func (m model) add() (internal.Event, error) {
 			m.item,
 			m.decryptFunc,
 			m.decryptKey,
+			m.keyPair,
 			recipient,
 			perm)
-		if err != nil {
+		if err == cancelledErr {
+			return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey, m.keyPair)
+		} else if err != nil {
 			m.errMsg = err.Error()
 			return m.add()
 		}
@@ -70,7 +74,7 @@ func (m model) add() (internal.Event, error) {
 		m.users = append(m.users, addedUser)
 	}
 
-	return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey)
+	return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey, m.keyPair)
 }
 
 func (m model) edit() (internal.Event, error) {
@@ -129,7 +133,7 @@ func (m model) edit() (internal.Event, error) {
 		m.users = updated
 	}
 
-	return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey)
+	return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey, m.keyPair)
 }
 
 func (m model) remove() (internal.Event, error) {
@@ -170,7 +174,7 @@ func (m model) remove() (internal.Event, error) {
 		}
 	}
 
-	return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey)
+	return RunModel(m.item, m.users, m.decryptFunc, m.decryptKey, m.keyPair)
 }
 
 func (m model) cancel() (internal.Event, error) {
@@ -241,6 +245,7 @@ func RunModel(
 	users []shared.ShareInfo,
 	decryptFunc crypto.CryptFunc,
 	decryptKey []byte,
+	keyPair crypto.KeyPair,
 ) (internal.Event, error) {
 	var sharedItemUsers []shared.ShareInfo
 	if users == nil {
@@ -258,6 +263,7 @@ func RunModel(
 		users:       sharedItemUsers,
 		decryptFunc: decryptFunc,
 		decryptKey:  decryptKey,
+		keyPair:     keyPair,
 	}
 
 	var title string
@@ -62,7 +62,8 @@ func showVaultModel(m items.Model) {
 				m.ViewRequest.Item,
 				nil,
 				m.Context.Crypto.DecryptFunc,
-				m.Context.Crypto.DecryptionKey)
+				m.Context.Crypto.DecryptionKey,
+				items.VaultKeyPair())
 		case internal.FileViewerView:
 			event, subviewErr = viewer.RunViewerModel(
 				m.ViewRequest.Item,
@@ -137,7 +137,11 @@ func downloadFile(id string, key []byte) ([]byte, error) {
 			return nil, err
 		}
 
-		decData, err := crypto.DecryptChunk(key, chunkData)
+		decData, err := crypto.DecryptFileChunk(
+			key,
+			chunk-1,
+			chunk == metadata.Chunks,
+			chunkData)
 		if err != nil {
 			return nil, err
 		}
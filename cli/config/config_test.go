@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const session = "test_session"
+
+func TestReadConfig(t *testing.T) {
+	paths, err := setupTempConfigDir()
+	if err != nil {
+		t.Fatal("Failed to set up temporary config directories")
+	}
+
+	config, err := ReadConfig(paths)
+	if err != nil {
+		t.Fatal("Failed to read config")
+	}
+
+	if !strings.Contains(config.Server, "http") {
+		t.Fatal("Invalid config server")
+	}
+}
+
+func TestReadSession(t *testing.T) {
+	paths, err := setupTempConfigDir()
+	if err != nil {
+		t.Fatal("Failed to set up temporary config directories")
+	}
+
+	config, _ := ReadConfig(paths)
+	err = config.SetSession(session)
+	if err != nil {
+		t.Fatal("Failed to set user session")
+	}
+
+	readSession := config.ReadSession()
+	if len(readSession) == 0 {
+		t.Fatal("Failed to read user session")
+	} else if string(readSession) != session {
+		t.Fatalf("Unexpected session value\n"+
+			"(expected %s, got %s)", session, string(readSession))
+	}
+}
+
+func TestLatestContactPin(t *testing.T) {
+	paths, err := setupTempConfigDir()
+	if err != nil {
+		t.Fatal("Failed to set up temporary config directories")
+	}
+
+	config, _ := ReadConfig(paths)
+	pinned := time.Now().UTC()
+	err = config.SetLatestContactPin(pinned)
+	if err != nil {
+		t.Fatal("Failed to set latest contact pin")
+	} else if !config.GetLatestContactPin().Equal(pinned) {
+		t.Fatal("Latest contact pin doesn't match the stored time")
+	}
+
+	err = config.Reset()
+	if err != nil {
+		t.Fatal("Failed to reset config")
+	} else if !config.GetLatestContactPin().IsZero() {
+		t.Fatal("Expected latest contact pin to be removed on reset")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	paths := newPaths(t.TempDir())
+	config, err := ReadConfig(paths)
+	if err != nil {
+		t.Fatal("Failed to read config")
+	}
+
+	defaultServer := config.Server
+	err = config.AddProfile("work", Profile{Server: "https://yeetfile.example.com/"})
+	if err != nil {
+		t.Fatalf("Failed to add profile: %v", err)
+	} else if err = config.AddProfile("work", Profile{}); !errors.Is(err, ProfileExistsErr) {
+		t.Fatalf("Expected duplicate profile error, got %v", err)
+	} else if err = config.AddProfile("../work", Profile{}); err != InvalidProfileNameErr {
+		t.Fatalf("Expected invalid profile name error, got %v", err)
+	}
+
+	err = config.SetCurrentProfile("work")
+	if err != nil {
+		t.Fatalf("Failed to set current profile: %v", err)
+	}
+
+	// Profiles are persisted without removing the default config comments
+	data, err := os.ReadFile(paths.config)
+	if err != nil {
+		t.Fatal("Failed to read config file")
+	} else if !strings.Contains(string(data), "# Configure default values") {
+		t.Fatal("Config file comments were removed")
+	}
+
+	config, err = ReadConfig(paths)
+	if err != nil {
+		t.Fatal("Failed to re-read config")
+	} else if config.CurrentProfile != "work" {
+		t.Fatalf("Unexpected current profile %s", config.CurrentProfile)
+	}
+
+	err = config.selectProfile(config.CurrentProfile)
+	if err != nil {
+		t.Fatalf("Failed to select profile: %v", err)
+	}
+
+	if config.Server != "https://yeetfile.example.com" {
+		t.Fatalf("Unexpected profile server %s", config.Server)
+	} else if config.DefaultView != "vault" || config.Send.Downloads != 1 {
+		t.Fatal("Profile didn't inherit default profile values")
+	} else if filepath.Dir(config.Paths.session) != filepath.Join(paths.directory, "profiles", "work") {
+		t.Fatalf("Unexpected profile session path %s", config.Paths.session)
+	}
+
+	defaultProfile, err := config.GetProfile(DefaultProfile)
+	if err != nil || defaultProfile.Server != defaultServer {
+		t.Fatal("Default profile was modified")
+	}
+
+	if _, err = config.GetProfile("missing"); !errors.Is(err, ProfileNotFoundErr) {
+		t.Fatalf("Expected missing profile error, got %v", err)
+	}
+}
+
+func TestParseProfileArg(t *testing.T) {
+	profile, args := ParseProfileArg([]string{"yeetfile", "--profile", "work", "vault"})
+	if profile != "work" || strings.Join(args, " ") != "yeetfile vault" {
+		t.Fatalf("Unexpected result: %s, %v", profile, args)
+	}
+
+	profile, args = ParseProfileArg([]string{"yeetfile", "--profile=work", "send", "file.txt"})
+	if profile != "work" || strings.Join(args, " ") != "yeetfile send file.txt" {
+		t.Fatalf("Unexpected result: %s, %v", profile, args)
+	}
+
+	// Flags after the command belong to the command
+	profile, args = ParseProfileArg([]string{"yeetfile", "send", "--profile", "work"})
+	if profile != "" || strings.Join(args, " ") != "yeetfile send --profile work" {
+		t.Fatalf("Unexpected result: %s, %v", profile, args)
+	}
+
+	profile, args = ParseProfileArg([]string{"yeetfile", "vault"})
+	if profile != "" || len(args) != 2 {
+		t.Fatalf("Unexpected result: %s, %v", profile, args)
+	}
+}
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"yeetfile/cli/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the name of the profile configured by the top level
+// values in config.yml. Named profiles inherit any values they don't set from
+// the default profile, and store their session and keys in their own
+// directory ($config_path/profiles/<name>).
+const DefaultProfile = "default"
+
+const profilesDirName = "profiles"
+
+var ProfileEnvVar = "YEETFILE_PROFILE"
+
+var profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+var (
+	ProfileNotFoundErr    = errors.New("profile does not exist")
+	ProfileExistsErr      = errors.New("profile already exists")
+	InvalidProfileNameErr = errors.New("profile names can only contain " +
+		"letters, numbers, '-', and '_'")
+	InvalidConfigFileErr = errors.New("config file is not a valid yaml mapping")
+)
+
+type Profile struct {
+	Server      string     `yaml:"server,omitempty"`
+	DefaultView string     `yaml:"default_view,omitempty"`
+	Send        SendConfig `yaml:"send,omitempty"`
+}
+
+// ParseProfileArg removes the --profile flag from the CLI args, returning the
+// name of the profile and the remaining args. The flag is only read from the
+// args before the command, so that flags belonging to commands are left
+// untouched. The profile name is empty if the flag wasn't provided.
+func ParseProfileArg(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", args
+	}
+
+	var profile string
+	remaining := []string{args[0]}
+	i := 1
+	for ; i < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+		if args[i] == "--profile" && i+1 < len(args) {
+			profile = args[i+1]
+			i++
+		} else if strings.HasPrefix(args[i], "--profile=") {
+			profile = strings.TrimPrefix(args[i], "--profile=")
+		} else {
+			break
+		}
+	}
+
+	return profile, append(remaining, args[i:]...)
+}
+
+// ProfileNames returns the names of every configured profile, starting with
+// the default profile
+func (c Config) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+
+	slices.Sort(names[1:])
+	return names
+}
+
+// GetProfile returns a profile by name, with any values it doesn't set
+// inherited from the default profile
+func (c Config) GetProfile(name string) (Profile, error) {
+	defaultProfile := c.defaultProfile
+	if name == DefaultProfile {
+		return defaultProfile, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %s", ProfileNotFoundErr, name)
+	}
+
+	if len(profile.Server) == 0 {
+		profile.Server = defaultProfile.Server
+	}
+
+	if len(profile.DefaultView) == 0 {
+		profile.DefaultView = defaultProfile.DefaultView
+	}
+
+	if profile.Send.Downloads == 0 {
+		profile.Send.Downloads = defaultProfile.Send.Downloads
+	}
+
+	if profile.Send.ExpirationAmount == 0 {
+		profile.Send.ExpirationAmount = defaultProfile.Send.ExpirationAmount
+		profile.Send.ExpirationUnits = defaultProfile.Send.ExpirationUnits
+	}
+
+	profile.Server = strings.TrimSuffix(profile.Server, "/")
+	return profile, nil
+}
+
+// selectProfile applies a profile's values to the config, and switches the
+// session and key paths to the profile's directory
+func (c *Config) selectProfile(name string) error {
+	profile, err := c.GetProfile(name)
+	if err != nil {
+		return err
+	}
+
+	c.Server = profile.Server
+	c.DefaultView = profile.DefaultView
+	c.Send = profile.Send
+	c.ProfileName = name
+
+	if name == DefaultProfile {
+		return nil
+	}
+
+	profileDir, err := makeConfigDirectories(
+		c.Paths.directory,
+		filepath.Join(profilesDirName, name))
+	if err != nil {
+		return err
+	}
+
+	c.Paths.session = filepath.Join(profileDir, sessionName)
+	c.Paths.encPrivateKey = filepath.Join(profileDir, encPrivateKeyName)
+	c.Paths.publicKey = filepath.Join(profileDir, publicKeyName)
+	c.Paths.encX25519Key = filepath.Join(profileDir, encX25519KeyName)
+	c.Paths.x25519PubKey = filepath.Join(profileDir, x25519PubKeyName)
+	c.Paths.agentSocket = filepath.Join(profileDir, agentSocketName)
+	return nil
+}
+
+// AddProfile adds a new named profile to the config file
+func (c *Config) AddProfile(name string, profile Profile) error {
+	if !profileNamePattern.MatchString(name) {
+		return InvalidProfileNameErr
+	} else if _, exists := c.Profiles[name]; exists || name == DefaultProfile {
+		return fmt.Errorf("%w: %s", ProfileExistsErr, name)
+	}
+
+	profile.Server = strings.TrimSuffix(profile.Server, "/")
+
+	var profileNode yaml.Node
+	err := profileNode.Encode(profile)
+	if err != nil {
+		return err
+	}
+
+	err = c.updateConfigFile(func(root *yaml.Node) {
+		profiles := getMappingValue(root, "profiles")
+		if profiles == nil || profiles.Kind != yaml.MappingNode {
+			profiles = &yaml.Node{Kind: yaml.MappingNode}
+			setMappingValue(root, "profiles", profiles)
+		}
+
+		setMappingValue(profiles, name, &profileNode)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+
+	c.Profiles[name] = profile
+	return nil
+}
+
+// SetCurrentProfile sets the profile to use when a profile isn't specified
+// with --profile or YEETFILE_PROFILE
+func (c *Config) SetCurrentProfile(name string) error {
+	if _, err := c.GetProfile(name); err != nil {
+		return err
+	}
+
+	err := c.updateConfigFile(func(root *yaml.Node) {
+		if name == DefaultProfile {
+			removeMappingValue(root, "profile")
+		} else {
+			setMappingValue(root, "profile", &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Value: name,
+			})
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.CurrentProfile = name
+	return nil
+}
+
+// updateConfigFile modifies the top level mapping in the config file, keeping
+// the rest of the file (including comments) intact
+func (c Config) updateConfigFile(update func(root *yaml.Node)) error {
+	data, err := os.ReadFile(c.Paths.config)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return err
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode}},
+		}
+	} else if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return InvalidConfigFileErr
+	}
+
+	update(doc.Content[0])
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err = encoder.Encode(&doc)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return err
+	}
+
+	return utils.CopyBytesToFile(buf.Bytes(), c.Paths.config)
+}
+
+func getMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+
+	mapping.Content = append(
+		mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		value)
+}
+
+func removeMappingValue(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
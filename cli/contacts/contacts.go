@@ -0,0 +1,120 @@
+package contacts
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
+	"yeetfile/shared"
+)
+
+type Status int
+
+const (
+	Unverified Status = iota
+	Verified
+	Changed
+)
+
+// Load fetches and decrypts the user's pinned contacts. Since contacts are only
+// ever added or replaced with a newer pin, the most recent pin acts as a
+// version for the list. Returns true if the server's copy is missing or older
+// than the latest copy seen on this device, which means the server may be
+// hiding contacts that the user has verified.
+func Load(kp crypto.KeyPair) ([]shared.PinnedContact, bool, error) {
+	contactList, err := globals.API.GetContacts()
+	if err != nil {
+		return nil, false, err
+	}
+
+	pinned, err := crypto.OpenContactList(kp, contactList)
+	if err != nil {
+		return nil, false, err
+	}
+
+	latest := latestPin(pinned)
+	seen := globals.Config.GetLatestContactPin()
+	if latest.Before(seen) {
+		return pinned, true, nil
+	} else if latest.After(seen) {
+		_ = globals.Config.SetLatestContactPin(latest)
+	}
+
+	return pinned, false, nil
+}
+
+// Pin marks a contact's keys as verified, replacing any fingerprint that was
+// previously pinned for the contact
+func Pin(
+	kp crypto.KeyPair,
+	pinned []shared.PinnedContact,
+	identifier string,
+	fingerprint []byte,
+) error {
+	identifier = normalizeIdentifier(identifier)
+	contact := shared.PinnedContact{
+		Identifier:  identifier,
+		Fingerprint: fingerprint,
+		Verified:    time.Now().UTC(),
+	}
+
+	updated := []shared.PinnedContact{contact}
+	for _, existing := range pinned {
+		if existing.Identifier != identifier {
+			updated = append(updated, existing)
+		}
+	}
+
+	contactList, err := crypto.CreateContactList(kp, updated)
+	if err != nil {
+		return err
+	}
+
+	err = globals.API.SetContacts(contactList)
+	if err != nil {
+		return err
+	}
+
+	return globals.Config.SetLatestContactPin(contact.Verified)
+}
+
+// Check compares a fingerprint against the one pinned for the contact, if the
+// user has verified the contact before
+func Check(
+	pinned []shared.PinnedContact,
+	identifier string,
+	fingerprint []byte,
+) (Status, shared.PinnedContact) {
+	identifier = normalizeIdentifier(identifier)
+	for _, contact := range pinned {
+		if contact.Identifier != identifier {
+			continue
+		} else if bytes.Equal(contact.Fingerprint, fingerprint) {
+			return Verified, contact
+		}
+
+		return Changed, contact
+	}
+
+	return Unverified, shared.PinnedContact{}
+}
+
+// latestPin returns the time of the most recently pinned contact, or a zero
+// time if no contacts have been pinned
+func latestPin(pinned []shared.PinnedContact) time.Time {
+	var latest time.Time
+	for _, contact := range pinned {
+		if contact.Verified.After(latest) {
+			latest = contact.Verified
+		}
+	}
+
+	return latest
+}
+
+// normalizeIdentifier ensures that a contact is pinned under the same
+// identifier regardless of how the email was capitalized
+func normalizeIdentifier(identifier string) string {
+	return strings.ToLower(strings.TrimSpace(identifier))
+}
@@ -0,0 +1,171 @@
+package contacts
+
+import (
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
+	"strings"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/styles"
+	"yeetfile/cli/utils"
+	"yeetfile/shared"
+)
+
+type confirmAction int
+
+const (
+	cancel confirmAction = iota
+	proceed
+	pinAndProceed
+)
+
+// fingerprintsPerLine keeps formatted fingerprints narrow enough to compare
+// side by side in most terminals
+const fingerprintsPerLine = 4
+
+const staleContactsWarning = "Your verified contacts on the server are " +
+	"missing or older than the copy last seen on this device, so the " +
+	"server may be hiding contacts that you've verified. Compare " +
+	"fingerprints again before continuing."
+
+// ConfirmKeys checks the public keys the server returned for a user against
+// the keys the current user has verified for them. Keys that were verified
+// before are accepted without prompting. Otherwise, the user is shown both
+// users' fingerprints and can pin the keys as verified, and is warned if the
+// keys don't match the ones they verified or if the server's copy of their
+// verified contacts is out of date. Returns true if the keys can be used.
+func ConfirmKeys(
+	kp crypto.KeyPair,
+	identifier string,
+	keys shared.PubKeyResponse,
+) (bool, error) {
+	var pinned []shared.PinnedContact
+	var stale bool
+	var err error
+	_ = spinner.New().Title("Checking verified contacts...").Action(func() {
+		pinned, stale, err = Load(kp)
+	}).Run()
+	if err != nil {
+		return false, fmt.Errorf("unable to load verified contacts: %w", err)
+	}
+
+	fingerprint := crypto.Fingerprint(keys)
+	status, contact := Check(pinned, identifier, fingerprint)
+	if status == Verified && !stale {
+		return true, nil
+	}
+
+	action, err := showConfirmKeysModel(
+		kp,
+		identifier,
+		fingerprint,
+		status,
+		contact,
+		stale)
+	if err != nil || action == cancel {
+		return false, err
+	} else if action == pinAndProceed {
+		_ = spinner.New().Title("Saving verified contact...").Action(func() {
+			err = Pin(kp, pinned, identifier, fingerprint)
+		}).Run()
+		if err != nil {
+			return false, fmt.Errorf("unable to save verified contact: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// FormatFingerprint splits a formatted fingerprint across multiple lines
+func FormatFingerprint(fingerprint []byte) string {
+	symbols := strings.Split(crypto.FormatFingerprint(fingerprint), "  ")
+	var lines []string
+	for i := 0; i < len(symbols); i += fingerprintsPerLine {
+		end := min(i+fingerprintsPerLine, len(symbols))
+		lines = append(lines, strings.Join(symbols[i:end], "  "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func showConfirmKeysModel(
+	kp crypto.KeyPair,
+	identifier string,
+	fingerprint []byte,
+	status Status,
+	contact shared.PinnedContact,
+	stale bool,
+) (confirmAction, error) {
+	var fields []huh.Field
+	var options []huh.Option[confirmAction]
+	if stale {
+		fields = append(fields,
+			huh.NewNote().
+				Title(styles.ErrStyle.Render("WARNING: VERIFIED CONTACTS ARE OUT OF DATE")).
+				Description(styles.ErrStyle.Render(staleContactsWarning)))
+	}
+
+	if status == Changed {
+		warning := fmt.Sprintf(
+			"The keys for %s do NOT match the keys you verified on %s.\n\n"+
+				"This can happen if they rotated their account keys or "+
+				"added new keys, but it can also mean that the server is "+
+				"trying to intercept what you share with them.\n\n"+
+				"Do not continue unless you have compared the new "+
+				"fingerprint with %s directly.",
+			identifier,
+			utils.LocalTimeFromUTC(contact.Verified).Format("2006-01-02"),
+			identifier)
+		fields = append(fields,
+			huh.NewNote().
+				Title(styles.ErrStyle.Render("WARNING: CONTACT KEYS HAVE CHANGED")).
+				Description(styles.ErrStyle.Render(warning)),
+			huh.NewNote().
+				Title("Verified Fingerprint").
+				Description(FormatFingerprint(contact.Fingerprint)))
+		options = []huh.Option[confirmAction]{
+			huh.NewOption("Cancel", cancel),
+			huh.NewOption("I've verified the new fingerprint", pinAndProceed),
+		}
+	} else if status == Verified {
+		desc := fmt.Sprintf(
+			"The keys for %s match the ones you verified on %s.",
+			identifier,
+			utils.LocalTimeFromUTC(contact.Verified).Format("2006-01-02"))
+		fields = append(fields, utils.CreateHeader("Verify Contact", desc))
+		options = []huh.Option[confirmAction]{
+			huh.NewOption("Continue", proceed),
+			huh.NewOption("Cancel", cancel),
+		}
+	} else {
+		desc := fmt.Sprintf(
+			"You haven't verified the keys for %s yet. Compare both of the "+
+				"fingerprints below with %s in person or over another "+
+				"channel to make sure that the server isn't intercepting "+
+				"what you share.",
+			identifier,
+			identifier)
+		fields = append(fields, utils.CreateHeader("Verify Contact", desc))
+		options = []huh.Option[confirmAction]{
+			huh.NewOption("Continue without verifying", proceed),
+			huh.NewOption("Mark as verified and continue", pinAndProceed),
+			huh.NewOption("Cancel", cancel),
+		}
+	}
+
+	var action confirmAction
+	fields = append(fields,
+		huh.NewNote().
+			Title(fmt.Sprintf("Fingerprint for %s", identifier)).
+			Description(FormatFingerprint(fingerprint)),
+		huh.NewNote().
+			Title("Your Fingerprint").
+			Description(FormatFingerprint(crypto.OwnFingerprint(kp))),
+		huh.NewSelect[confirmAction]().
+			Title("Select an action").
+			Options(options...).
+			Value(&action))
+
+	err := huh.NewForm(huh.NewGroup(fields...)).WithTheme(styles.Theme).Run()
+	return action, err
+}
@@ -30,12 +30,18 @@ type SignupKeys struct {
 	ProtectedRootFolderKey []byte
 }
 
-// DeriveSendingKey uses PBKDF2 to derive a key for sending a file. The salt
-// can be left nil in order to randomly generate the value.
+// DeriveSendingKey derives a key for sending a file using the provided key
+// derivation parameters. The salt can be left nil in order to randomly
+// generate the value.
 func DeriveSendingKey(
 	password []byte,
 	salt []byte,
+	params shared.KDFParams,
 ) ([]byte, []byte, error) {
+	if err := params.Validate(); err != nil {
+		return []byte{}, nil, err
+	}
+
 	if salt == nil {
 		salt = make([]byte, constants.KeySize)
 		if _, err := rand.Read(salt); err != nil {
@@ -43,7 +49,7 @@ func DeriveSendingKey(
 		}
 	}
 
-	key := DerivePBKDFKey(password, salt)
+	key := DeriveKey(password, salt, params)
 	return key, salt, nil
 }
 
@@ -96,37 +102,52 @@ func GenerateRandomNumber(size int) (int, error) {
 // DerivePBKDFKey uses PBKDF2 to derive a key from a known password and salt.
 // Used for files and text sent with YeetFile Send.
 func DerivePBKDFKey(password []byte, salt []byte) []byte {
-	key := pbkdf2.Key(password, salt, 600000, constants.KeySize, sha256.New)
-	return key
+	return DeriveKey(password, salt, shared.LegacySendKDFParams())
 }
 
-// DeriveArgon2Key uses Argon2 to derive a key from a known password and salt.
-// Used for the User Key, and subsequently the Login Key.
-func DeriveArgon2Key(password, salt []byte) []byte {
-	key := argon2.IDKey(
+// DeriveKey derives a key from a known password and salt using the algorithm
+// and cost parameters in params. The params should be validated before use.
+func DeriveKey(password, salt []byte, params shared.KDFParams) []byte {
+	if params.Algorithm == constants.KDFPBKDF2 {
+		return pbkdf2.Key(
+			password,
+			salt,
+			int(params.Iterations),
+			constants.KeySize,
+			sha256.New)
+	}
+
+	return argon2.IDKey(
 		password,
 		salt,
-		constants.Argon2Iter,
-		constants.Argon2Mem*1024,
+		params.Iterations,
+		params.Memory*1024,
 		1,
 		uint32(constants.KeySize))
-	return key
 }
 
 // GenerateUserKey generates the key used for encrypting and decrypting
-// files that are stored in YeetFile, using their identifier (email or acct ID)
-// and their password.
-func GenerateUserKey(identifier []byte, password []byte) []byte {
+// files that are stored in YeetFile, using their identifier (email or acct ID),
+// their password, and their account's key derivation parameters.
+func GenerateUserKey(
+	identifier []byte,
+	password []byte,
+	params shared.KDFParams,
+) []byte {
 	identifierHash := blake2b.Sum256(identifier)
-	return DeriveArgon2Key(password, identifierHash[:16])
+	return DeriveKey(password, identifierHash[:16], params)
 }
 
 // GenerateLoginKeyHash generates a login key using the user's user key and
 // their password, and returns a hex encoded hash of the resulting key.
-func GenerateLoginKeyHash(userKey []byte, password []byte) []byte {
+func GenerateLoginKeyHash(
+	userKey []byte,
+	password []byte,
+	params shared.KDFParams,
+) []byte {
 	hexUserKey := hex.EncodeToString(userKey)
 	pwHash := blake2b.Sum256(password)
-	loginKey := DeriveArgon2Key([]byte(hexUserKey), pwHash[:16])
+	loginKey := DeriveKey([]byte(hexUserKey), pwHash[:16], params)
 
 	h := sha256.New()
 	h.Write(loginKey)
@@ -276,17 +297,25 @@ func DecryptString(key []byte, byteStr []byte) (string, error) {
 
 // GenerateUserKeys generates the main user key as well as the login key hash,
 // which is generated from the user key. Returns the user key and login key hash.
-func GenerateUserKeys(identifier, password string) ([]byte, []byte) {
-	userKey := GenerateUserKey([]byte(identifier), []byte(password))
-	loginKeyHash := GenerateLoginKeyHash(userKey, []byte(password))
+func GenerateUserKeys(
+	identifier string,
+	password string,
+	params shared.KDFParams,
+) ([]byte, []byte) {
+	userKey := GenerateUserKey([]byte(identifier), []byte(password), params)
+	loginKeyHash := GenerateLoginKeyHash(userKey, []byte(password), params)
 
 	return userKey, loginKeyHash
 }
 
 // GenerateSignupKeys generates the main user key, the login key hash, the
 // private/public key pair, and the encrypted root folder key
-func GenerateSignupKeys(identifier, password string) (SignupKeys, error) {
-	userKey, loginKeyHash := GenerateUserKeys(identifier, password)
+func GenerateSignupKeys(
+	identifier string,
+	password string,
+	params shared.KDFParams,
+) (SignupKeys, error) {
+	userKey, loginKeyHash := GenerateUserKeys(identifier, password, params)
 	privateKey, publicKey, err := GenerateRSAKeyPair()
 	if err != nil {
 		return SignupKeys{}, err
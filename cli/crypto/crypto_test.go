@@ -2,15 +2,19 @@ package crypto
 
 import (
 	"bytes"
+	"encoding/hex"
+	"strings"
 	"testing"
+	"yeetfile/shared"
 	"yeetfile/shared/constants"
 )
 
 var data = []byte("data")
 var password = []byte("topsecret")
+var sendKDF = shared.LegacySendKDFParams()
 
 func TestDeriveKey(t *testing.T) {
-	key, salt, err := DeriveSendingKey(password, nil)
+	key, salt, err := DeriveSendingKey(password, nil, sendKDF)
 	if err != nil {
 		t.Fatalf("Error generating key: %v\n", err)
 	}
@@ -34,7 +38,7 @@ func TestDeriveKey(t *testing.T) {
 
 func TestEncryptChunk(t *testing.T) {
 	plainData := make([]byte, constants.ChunkSize)
-	key, _, _ := DeriveSendingKey(password, nil)
+	key, _, _ := DeriveSendingKey(password, nil, sendKDF)
 	encrypted, _ := EncryptChunk(key, plainData)
 
 	if len(encrypted) != len(plainData)+constants.TotalOverhead {
@@ -68,10 +72,10 @@ func TestEncryptChunk(t *testing.T) {
 }
 
 func TestDecryptChunk(t *testing.T) {
-	key, salt, _ := DeriveSendingKey(password, nil)
+	key, salt, _ := DeriveSendingKey(password, nil, sendKDF)
 	encrypted, _ := EncryptChunk(key, data)
 
-	decryptKey, _, _ := DeriveSendingKey(password, salt)
+	decryptKey, _, _ := DeriveSendingKey(password, salt, sendKDF)
 	decrypted, err := DecryptChunk(decryptKey, encrypted)
 
 	if err != nil {
@@ -85,12 +89,12 @@ func TestLoginKey(t *testing.T) {
 	myPassword := []byte("my-password")
 	myEmail := []byte("myemail@domain.com")
 
-	storageKey := GenerateUserKey(myEmail, myPassword)
-	loginKey := GenerateLoginKeyHash(storageKey, myPassword)
+	storageKey := GenerateUserKey(myEmail, myPassword, shared.LegacyKDFParams())
+	loginKey := GenerateLoginKeyHash(storageKey, myPassword, shared.LegacyKDFParams())
 
 	// Simulates login at a later time
-	newStorageKey := GenerateUserKey(myEmail, myPassword)
-	newLoginKey := GenerateLoginKeyHash(newStorageKey, myPassword)
+	newStorageKey := GenerateUserKey(myEmail, myPassword, shared.LegacyKDFParams())
+	newLoginKey := GenerateLoginKeyHash(newStorageKey, myPassword, shared.LegacyKDFParams())
 
 	if len(loginKey) != len(newLoginKey) {
 		t.Fatalf("Login key hash lengths do not match")
@@ -102,3 +106,410 @@ func TestLoginKey(t *testing.T) {
 		}
 	}
 }
+
+func TestKDFParams(t *testing.T) {
+	myPassword := []byte("my-password")
+	myEmail := []byte("myemail@domain.com")
+
+	legacyKey := GenerateUserKey(myEmail, myPassword, shared.LegacyKDFParams())
+	strongerKey := GenerateUserKey(myEmail, myPassword, shared.KDFParams{
+		Algorithm:  constants.KDFArgon2id,
+		Iterations: constants.Argon2Iter + 1,
+		Memory:     constants.Argon2Mem,
+	})
+
+	if bytes.Equal(legacyKey, strongerKey) {
+		t.Fatalf("Keys derived with different params should not match")
+	}
+
+	_, _, err := DeriveSendingKey(password, nil, shared.KDFParams{
+		Algorithm:  constants.KDFPBKDF2,
+		Iterations: 1000,
+	})
+	if err == nil {
+		t.Fatalf("Expected weak KDF params to be rejected")
+	}
+}
+
+func TestFileEncryption(t *testing.T) {
+	key, _ := GenerateRandomKey()
+	encryptor, err := NewFileEncryptor(key, constants.FileEncryptionV2)
+	if err != nil {
+		t.Fatalf("Error creating file encryptor: %v\n", err)
+	}
+
+	chunks := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+	var encrypted [][]byte
+	for i, chunk := range chunks {
+		encChunk, err := encryptor.EncryptChunk(i, i == len(chunks)-1, chunk)
+		if err != nil {
+			t.Fatalf("Error encrypting chunk: %v\n", err)
+		} else if len(encChunk) != len(chunk)+constants.TotalOverhead {
+			t.Fatalf("Unexpected encrypted chunk size\n"+
+				"expected: %d, actual: %d",
+				len(chunk)+constants.TotalOverhead,
+				len(encChunk))
+		}
+
+		encrypted = append(encrypted, encChunk)
+	}
+
+	for i, encChunk := range encrypted {
+		final := i == len(encrypted)-1
+		decrypted, err := DecryptFileChunk(key, i, final, encChunk)
+		if err != nil {
+			t.Fatalf("Error decrypting chunk: %v\n", err)
+		} else if !bytes.Equal(decrypted, chunks[i]) {
+			t.Fatalf("Decrypted chunk doesn't match source data")
+		}
+	}
+
+	// Reordered chunks
+	_, err = DecryptFileChunk(key, 0, false, encrypted[1])
+	if err != ChunkOrderErr {
+		t.Fatalf("Expected reordered chunk to fail, got: %v\n", err)
+	}
+
+	// Truncated file, where a non-final chunk is presented as the last one
+	_, err = DecryptFileChunk(key, 1, true, encrypted[1])
+	if err != ChunkOrderErr {
+		t.Fatalf("Expected truncated file to fail, got: %v\n", err)
+	}
+
+	// Tampered nonce prefix
+	tampered := bytes.Clone(encrypted[1])
+	tampered[0] ^= 1
+	_, err = DecryptFileChunk(key, 1, false, tampered)
+	if err == nil {
+		t.Fatalf("Expected tampered chunk to fail decryption")
+	}
+}
+
+func TestFileEncryptionV1(t *testing.T) {
+	key, _ := GenerateRandomKey()
+	encryptor, _ := NewFileEncryptor(key, constants.FileEncryptionV1)
+	encrypted, _ := encryptor.EncryptChunk(0, true, data)
+
+	// Version 1 chunks don't carry their position in the file
+	decrypted, err := DecryptFileChunk(key, 5, false, encrypted)
+	if err != nil {
+		t.Fatalf("Error decrypting v1 chunk: %v\n", err)
+	} else if !bytes.Equal(decrypted, data) {
+		t.Fatalf("Decrypted data doesn't match source data")
+	}
+
+	_, err = NewFileEncryptor(key, 99)
+	if err != UnsupportedVersionErr {
+		t.Fatalf("Expected unsupported version error, got: %v\n", err)
+	}
+}
+
+func TestX25519KeyWrapping(t *testing.T) {
+	privateKey, publicKey, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("Error generating x25519 key pair: %v\n", err)
+	}
+
+	key, _ := GenerateRandomKey()
+	wrapped, err := EncryptX25519(publicKey, key)
+	if err != nil {
+		t.Fatalf("Error wrapping key: %v\n", err)
+	} else if !IsX25519Wrapped(wrapped) {
+		t.Fatalf("Wrapped key is missing the x25519 header")
+	}
+
+	unwrapped, err := DecryptX25519(privateKey, wrapped)
+	if err != nil {
+		t.Fatalf("Error unwrapping key: %v\n", err)
+	} else if !bytes.Equal(unwrapped, key) {
+		t.Fatalf("Unwrapped key doesn't match source key")
+	}
+
+	otherPrivateKey, _, _ := GenerateX25519KeyPair()
+	_, err = DecryptX25519(otherPrivateKey, wrapped)
+	if err == nil {
+		t.Fatalf("Expected unwrapping with the wrong key to fail")
+	}
+
+	// The header is authenticated along with the key
+	tampered := bytes.Clone(wrapped)
+	tampered[len(tampered)-1] ^= 1
+	_, err = DecryptX25519(privateKey, tampered)
+	if err == nil {
+		t.Fatalf("Expected tampered key to fail unwrapping")
+	}
+}
+
+func TestKeyPairWrapKey(t *testing.T) {
+	rsaPrivateKey, rsaPublicKey, _ := GenerateRSAKeyPair()
+	x25519PrivateKey, x25519PublicKey, _ := GenerateX25519KeyPair()
+	key, _ := GenerateRandomKey()
+
+	// Keys are only wrapped with RSA if the user has no X25519 key pair
+	rsaOnly := IngestKeys(rsaPrivateKey, rsaPublicKey)
+	wrapped, _ := rsaOnly.WrapKey(key)
+	if IsX25519Wrapped(wrapped) {
+		t.Fatalf("Expected key to be wrapped with RSA")
+	}
+
+	kp := rsaOnly
+	kp.X25519PrivateKey = x25519PrivateKey
+	kp.X25519PublicKey = x25519PublicKey
+
+	// Keys wrapped with RSA before the X25519 key pair was added are
+	// still readable
+	unwrapped, err := kp.UnwrapKey(wrapped)
+	if err != nil || !bytes.Equal(unwrapped, key) {
+		t.Fatalf("Error unwrapping RSA key: %v\n", err)
+	}
+
+	wrapped, _ = kp.WrapKey(key)
+	if !IsX25519Wrapped(wrapped) {
+		t.Fatalf("Expected key to be wrapped with x25519")
+	}
+
+	unwrapped, err = kp.UnwrapKey(wrapped)
+	if err != nil || !bytes.Equal(unwrapped, key) {
+		t.Fatalf("Error unwrapping x25519 key: %v\n", err)
+	}
+
+	_, err = rsaOnly.UnwrapKey(wrapped)
+	if err != MissingPrivateKeyErr {
+		t.Fatalf("Expected missing private key error, got: %v\n", err)
+	}
+}
+
+func TestSelectPublicKey(t *testing.T) {
+	response := shared.PubKeyResponse{PublicKey: []byte("rsa")}
+	if key := SelectPublicKey(response); key.Type != constants.KeyTypeRSA {
+		t.Fatalf("Expected RSA key, got: %s\n", key.Type)
+	}
+
+	response.Keys = []shared.UserPublicKey{
+		{Type: "unknown", PublicKey: []byte("unknown")},
+		{Type: constants.KeyTypeX25519, PublicKey: []byte("x25519")},
+	}
+	if key := SelectPublicKey(response); key.Type != constants.KeyTypeX25519 {
+		t.Fatalf("Expected x25519 key, got: %s\n", key.Type)
+	}
+
+	_, err := WrapKey(shared.UserPublicKey{Type: "unknown"}, []byte("key"))
+	if err != UnsupportedKeyTypeErr {
+		t.Fatalf("Expected unsupported key type error, got: %v\n", err)
+	}
+}
+
+func TestRecoveryKey(t *testing.T) {
+	recoveryKey, err := GenerateRecoveryKey()
+	if err != nil {
+		t.Fatalf("Error generating recovery key: %v\n", err)
+	}
+
+	formatted := FormatRecoveryKey(recoveryKey)
+	for _, text := range []string{
+		formatted,
+		strings.ToLower(strings.ReplaceAll(formatted, "-", " ")),
+	} {
+		parsed, err := ParseRecoveryKey(text)
+		if err != nil || !bytes.Equal(parsed, recoveryKey) {
+			t.Fatalf("Failed to parse recovery key %s: %v\n", text, err)
+		}
+	}
+
+	if _, err = ParseRecoveryKey(formatted[5:]); err != InvalidRecoveryKeyErr {
+		t.Fatalf("Expected invalid recovery key error, got: %v\n", err)
+	}
+
+	userKey, _ := GenerateRandomKey()
+	privateKey, _ := GenerateRandomKey()
+	x25519Key, x25519PublicKey, _ := GenerateX25519KeyPair()
+	protectedKey, _ := EncryptChunk(userKey, privateKey)
+	protectedX25519Key, _ := EncryptChunk(userKey, x25519Key)
+
+	recovery, err := CreateRecoveryKey(recoveryKey, userKey, shared.ProtectedKeyResponse{
+		ProtectedKey: protectedKey,
+		Keys: []shared.UserKey{{
+			Type:         constants.KeyTypeX25519,
+			PublicKey:    x25519PublicKey,
+			ProtectedKey: protectedX25519Key,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Error creating recovery key: %v\n", err)
+	} else if !bytes.Equal(recovery.KeyHash, RecoveryKeyHash(recoveryKey)) {
+		t.Fatalf("Recovery key hash mismatch\n")
+	}
+
+	newUserKey, _ := GenerateRandomKey()
+	newProtectedKey, newProtectedKeys, err := RecoverProtectedKeys(
+		recoveryKey,
+		newUserKey,
+		shared.RecoveryKeysResponse{
+			ProtectedKey: recovery.ProtectedKey,
+			Keys:         recovery.Keys,
+		})
+	if err != nil {
+		t.Fatalf("Error recovering protected keys: %v\n", err)
+	}
+
+	decrypted, err := DecryptChunk(newUserKey, newProtectedKey)
+	if err != nil || !bytes.Equal(decrypted, privateKey) {
+		t.Fatalf("Failed to recover private key: %v\n", err)
+	}
+
+	decrypted, err = DecryptChunk(newUserKey, newProtectedKeys[0].ProtectedKey)
+	if err != nil || !bytes.Equal(decrypted, x25519Key) {
+		t.Fatalf("Failed to recover x25519 key: %v\n", err)
+	}
+
+	otherKey, _ := GenerateRecoveryKey()
+	_, _, err = RecoverProtectedKeys(otherKey, newUserKey, shared.RecoveryKeysResponse{
+		ProtectedKey: recovery.ProtectedKey,
+	})
+	if err != InvalidRecoveryKeyErr {
+		t.Fatalf("Expected invalid recovery key error, got: %v\n", err)
+	}
+}
+
+func TestEmergencyKeys(t *testing.T) {
+	ownerPrivateKey, ownerPublicKey, err := GenerateRSAKeyPair()
+	if err != nil {
+		t.Fatalf("Error generating owner key pair: %v\n", err)
+	}
+
+	contactPrivateKey, contactPublicKey, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatalf("Error generating contact key pair: %v\n", err)
+	}
+
+	owner := IngestKeys(ownerPrivateKey, ownerPublicKey)
+	rootKey, _ := GenerateRandomKey()
+	folderKey, _ := GenerateRandomKey()
+
+	protectedRootKey, err := owner.WrapKey(rootKey)
+	if err != nil {
+		t.Fatalf("Error wrapping root folder key: %v\n", err)
+	}
+
+	protectedFolderKey, err := EncryptChunk(rootKey, folderKey)
+	if err != nil {
+		t.Fatalf("Error encrypting folder key: %v\n", err)
+	}
+
+	contact, err := CreateEmergencyKey(owner, protectedRootKey, shared.UserPublicKey{
+		Type:      constants.KeyTypeX25519,
+		PublicKey: contactPublicKey,
+	})
+	if err != nil {
+		t.Fatalf("Error creating emergency key: %v\n", err)
+	}
+
+	keys := shared.EmergencyKeyResponse{ProtectedKey: contact.ProtectedKey}
+	contactKP := KeyPair{
+		X25519PrivateKey: contactPrivateKey,
+		X25519PublicKey:  contactPublicKey,
+	}
+
+	opened, err := OpenEmergencyKey(contactKP, keys)
+	if err != nil {
+		t.Fatalf("Error opening emergency key: %v\n", err)
+	} else if !bytes.Equal(opened, rootKey) {
+		t.Fatalf("Emergency key doesn't match the owner's root folder key")
+	}
+
+	// The contact's copy of the root folder key replaces the owner's copy at
+	// the start of a folder's key sequence
+	keySequence := [][]byte{protectedRootKey, protectedFolderKey}
+	unwound, err := UnwindEmergencyKeySequence(opened, keySequence)
+	if err != nil || !bytes.Equal(unwound, folderKey) {
+		t.Fatalf("Emergency key failed to unwind owner's key sequence: %v\n", err)
+	}
+
+	_, err = OpenEmergencyKey(owner, keys)
+	if err == nil {
+		t.Fatalf("Expected error opening emergency key as the owner")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	keys := shared.PubKeyResponse{
+		PublicKey: []byte("rsa"),
+		Keys: []shared.UserPublicKey{
+			{Type: constants.KeyTypeX25519, PublicKey: []byte("x25519")},
+			{Type: "other", PublicKey: []byte("other")},
+		},
+	}
+
+	// Fingerprints don't depend on the order of the additional keys
+	reordered := keys
+	reordered.Keys = []shared.UserPublicKey{keys.Keys[1], keys.Keys[0]}
+	fingerprint := Fingerprint(keys)
+	if !bytes.Equal(fingerprint, Fingerprint(reordered)) {
+		t.Fatalf("Expected fingerprints to match regardless of key order")
+	}
+
+	// Changing or adding any key changes the fingerprint
+	changed := shared.PubKeyResponse{PublicKey: []byte("rsa2"), Keys: keys.Keys}
+	rsaOnly := shared.PubKeyResponse{PublicKey: keys.PublicKey}
+	if bytes.Equal(fingerprint, Fingerprint(changed)) ||
+		bytes.Equal(fingerprint, Fingerprint(rsaOnly)) {
+		t.Fatalf("Expected fingerprint to change with the user's keys")
+	}
+
+	// The web client generates the same fingerprint for the same keys
+	expected := "5b8182f71c27fdcd809794b783943b1251e9ce93da82e1fbed93bde46bc59f9c"
+	rsaX25519 := shared.PubKeyResponse{
+		PublicKey: []byte("rsa"),
+		Keys:      keys.Keys[:1],
+	}
+	if hex.EncodeToString(Fingerprint(rsaX25519)) != expected {
+		t.Fatalf("Fingerprint doesn't match the expected value")
+	}
+
+	formatted := FormatFingerprint(fingerprint)
+	if len(strings.Split(formatted, "  ")) != constants.FingerprintLength {
+		t.Fatalf("Unexpected formatted fingerprint: %s\n", formatted)
+	}
+}
+
+func TestContactList(t *testing.T) {
+	privateKey, publicKey, _ := GenerateRSAKeyPair()
+	kp := IngestKeys(privateKey, publicKey)
+
+	contacts := []shared.PinnedContact{{
+		Identifier:  "user@example.com",
+		Fingerprint: Fingerprint(shared.PubKeyResponse{PublicKey: data}),
+	}}
+
+	contactList, err := CreateContactList(kp, contacts)
+	if err != nil {
+		t.Fatalf("Error creating contact list: %v\n", err)
+	}
+
+	opened, err := OpenContactList(kp, contactList)
+	if err != nil {
+		t.Fatalf("Error opening contact list: %v\n", err)
+	} else if len(opened) != 1 ||
+		opened[0].Identifier != contacts[0].Identifier ||
+		!bytes.Equal(opened[0].Fingerprint, contacts[0].Fingerprint) {
+		t.Fatalf("Opened contacts don't match the original contacts")
+	}
+
+	otherPrivateKey, otherPublicKey, _ := GenerateRSAKeyPair()
+	other := IngestKeys(otherPrivateKey, otherPublicKey)
+	_, err = OpenContactList(other, contactList)
+	if err == nil {
+		t.Fatalf("Expected error opening another user's contact list")
+	}
+
+	// A list with a key wrapped to the user's public key could have been
+	// created by anyone, including the server
+	contactsKey, _ := GenerateRandomKey()
+	forged := contactList
+	forged.ProtectedKey, _ = kp.WrapKey(contactsKey)
+	forged.Contacts, _ = EncryptChunk(contactsKey, []byte("[]"))
+	_, err = OpenContactList(kp, forged)
+	if err == nil {
+		t.Fatalf("Expected error opening a contact list the user didn't create")
+	}
+}
@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"yeetfile/shared"
+)
+
+// CreateEmergencyKey decrypts the user's vault root folder key and wraps it
+// with the emergency contact's public key. Only the root folder key is shared
+// with the contact, which gives them access to the user's vault without
+// exposing the user's private keys.
+func CreateEmergencyKey(
+	kp KeyPair,
+	protectedRootKey []byte,
+	contactKey shared.UserPublicKey,
+) (shared.NewEmergencyContact, error) {
+	rootKey, err := kp.UnwrapKey(protectedRootKey)
+	if err != nil {
+		return shared.NewEmergencyContact{}, err
+	}
+
+	var contact shared.NewEmergencyContact
+	contact.ProtectedKey, err = WrapKey(contactKey, rootKey)
+	if err != nil {
+		return shared.NewEmergencyContact{}, err
+	}
+
+	return contact, nil
+}
+
+// OpenEmergencyKey decrypts the vault root folder key of a user who granted the
+// current user emergency access, using the current user's key pair
+func OpenEmergencyKey(kp KeyPair, keys shared.EmergencyKeyResponse) ([]byte, error) {
+	return kp.UnwrapKey(keys.ProtectedKey)
+}
+
+// UnwindEmergencyKeySequence decrypts the key sequence for a folder in another
+// user's vault using their root folder key, which takes the place of the first
+// key in the sequence (the root folder key wrapped for the vault's owner)
+func UnwindEmergencyKeySequence(rootKey []byte, keySequence [][]byte) ([]byte, error) {
+	parentKey := rootKey
+	for i := 1; i < len(keySequence); i++ {
+		var err error
+		parentKey, err = DecryptChunk(parentKey, keySequence[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parentKey, nil
+}
@@ -0,0 +1,128 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"yeetfile/shared/constants"
+)
+
+// Version 2 file chunks use a nonce made up of a random per-file prefix, the
+// index of the chunk within the file, and a flag marking the final chunk:
+//
+//	prefix (7 bytes) || chunk index (4 bytes, big endian) || final flag (1 byte)
+//
+// The nonce is stored in front of each chunk in the same position as the v1
+// IV, so the encrypted size of a file is the same in both versions.
+const (
+	noncePrefixSize = 7
+	nonceIndexSize  = 4
+)
+
+var (
+	UnsupportedVersionErr = errors.New("unsupported file encryption version")
+	ChunkOrderErr         = errors.New("file chunk is out of order or the file is truncated")
+)
+
+// FileEncryptor encrypts the chunks of a single file using a specific file
+// encryption version
+type FileEncryptor struct {
+	Version int
+	key     []byte
+	prefix  []byte
+}
+
+// NewFileEncryptor returns a FileEncryptor for a new file. Each file should
+// use its own FileEncryptor, since the nonce prefix is generated per file.
+func NewFileEncryptor(key []byte, version int) (*FileEncryptor, error) {
+	if version != constants.FileEncryptionV1 && version != constants.FileEncryptionV2 {
+		return nil, UnsupportedVersionErr
+	}
+
+	prefix, err := GenerateRandomArray(noncePrefixSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileEncryptor{Version: version, key: key, prefix: prefix}, nil
+}
+
+// EncryptChunk encrypts a chunk of file data. The index is the zero-based
+// position of the chunk within the file, and final indicates that this is the
+// last chunk of the file.
+func (e *FileEncryptor) EncryptChunk(index int, final bool, data []byte) ([]byte, error) {
+	if e.Version == constants.FileEncryptionV1 {
+		return EncryptChunk(e.key, data)
+	}
+
+	aesgcm, err := newGCM(e.key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := chunkNonce(e.prefix, index, final)
+	aad := []byte{byte(e.Version)}
+	return aesgcm.Seal(nonce, nonce, data, aad), nil
+}
+
+// DecryptFileChunk decrypts a chunk of file data. The encryption version is
+// read from the chunk itself rather than trusted from the file's metadata:
+// version 2 chunks begin with a nonce header that encodes the chunk's index
+// and final flag, and are authenticated with the version as associated data.
+// Chunks without a valid version 2 header are decrypted as version 1, which
+// is only possible for files that were actually uploaded as version 1, since
+// version 2 ciphertext can't be opened without its associated data. If the
+// chunk can't be decrypted at the provided index and final flag, ChunkOrderErr
+// is returned.
+func DecryptFileChunk(key []byte, index int, final bool, chunk []byte) ([]byte, error) {
+	if len(chunk) <= constants.IVSize {
+		return nil, errors.New("invalid chunk size")
+	}
+
+	nonce := chunk[:constants.IVSize]
+	expected := chunkNonce(nonce[:noncePrefixSize], index, final)
+	if bytes.Equal(nonce, expected) {
+		aesgcm, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+
+		aad := []byte{byte(constants.FileEncryptionV2)}
+		data, err := aesgcm.Open(nil, nonce, chunk[constants.IVSize:], aad)
+		if err == nil {
+			return data, nil
+		}
+	}
+
+	// Files uploaded before versioning are always version 1
+	data, err := DecryptChunk(key, chunk)
+	if err != nil {
+		return nil, ChunkOrderErr
+	}
+
+	return data, nil
+}
+
+func chunkNonce(prefix []byte, index int, final bool) []byte {
+	nonce := make([]byte, constants.IVSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(
+		nonce[noncePrefixSize:noncePrefixSize+nonceIndexSize],
+		uint32(index))
+	if final {
+		nonce[constants.IVSize-1] = 1
+	}
+
+	return nonce
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
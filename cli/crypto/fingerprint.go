@@ -0,0 +1,165 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const contactsInfo = "yeetfile-contacts-key-wrap"
+
+var fingerprintPrefix = []byte("yeetfile-fingerprint")
+
+// Fingerprint returns a SHA-256 hash of all of a user's public keys. Each key
+// is hashed along with its type, and additional keys are sorted by type so
+// that the fingerprint doesn't depend on the order the server returns them in.
+func Fingerprint(keys shared.PubKeyResponse) []byte {
+	additionalKeys := make([]shared.UserPublicKey, len(keys.Keys))
+	copy(additionalKeys, keys.Keys)
+	sort.Slice(additionalKeys, func(i, j int) bool {
+		return additionalKeys[i].Type < additionalKeys[j].Type
+	})
+
+	hash := sha256.New()
+	hash.Write(fingerprintPrefix)
+	writeFingerprintValue := func(value []byte) {
+		hash.Write(binary.BigEndian.AppendUint32(nil, uint32(len(value))))
+		hash.Write(value)
+	}
+
+	writeFingerprintValue([]byte(constants.KeyTypeRSA))
+	writeFingerprintValue(keys.PublicKey)
+	for _, key := range additionalKeys {
+		writeFingerprintValue([]byte(key.Type))
+		writeFingerprintValue(key.PublicKey)
+	}
+
+	return hash.Sum(nil)
+}
+
+// OwnFingerprint returns the fingerprint of the user's own public keys, which
+// their contacts should see when they share with the user
+func OwnFingerprint(kp KeyPair) []byte {
+	keys := shared.PubKeyResponse{PublicKey: kp.PublicKey}
+	if len(kp.X25519PublicKey) > 0 {
+		keys.Keys = append(keys.Keys, shared.UserPublicKey{
+			Type:      constants.KeyTypeX25519,
+			PublicKey: kp.X25519PublicKey,
+		})
+	}
+
+	return Fingerprint(keys)
+}
+
+// FormatFingerprint returns the human-comparable form of a fingerprint, made
+// up of an emoji and word for each of the first FingerprintLength bytes
+func FormatFingerprint(fingerprint []byte) string {
+	var symbols []string
+	for i := 0; i < constants.FingerprintLength && i < len(fingerprint); i++ {
+		symbol := constants.FingerprintSymbols[fingerprint[i]&0x3f]
+		symbols = append(symbols, symbol[0]+" "+symbol[1])
+	}
+
+	return strings.Join(symbols, "  ")
+}
+
+// WrapContactsKey encrypts the key for the user's pinned contacts with a key
+// derived from their private key. Unlike wrapping it with the user's public
+// key, this means that the server can't replace the list with one of its own.
+func WrapContactsKey(kp KeyPair, contactsKey []byte) ([]byte, error) {
+	wrapKey, err := deriveContactsWrapKey(kp)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncryptChunk(wrapKey, contactsKey)
+}
+
+// UnwrapContactsKey decrypts the key for the user's pinned contacts, which
+// fails if the key wasn't wrapped by the user
+func UnwrapContactsKey(kp KeyPair, protectedKey []byte) ([]byte, error) {
+	wrapKey, err := deriveContactsWrapKey(kp)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptChunk(wrapKey, protectedKey)
+}
+
+// CreateContactList encrypts the user's pinned contacts with a new random key,
+// which is wrapped with a key derived from the user's private key
+func CreateContactList(
+	kp KeyPair,
+	contacts []shared.PinnedContact,
+) (shared.ContactList, error) {
+	contactsKey, err := GenerateRandomKey()
+	if err != nil {
+		return shared.ContactList{}, err
+	}
+
+	protectedKey, err := WrapContactsKey(kp, contactsKey)
+	if err != nil {
+		return shared.ContactList{}, err
+	}
+
+	contactsJSON, err := json.Marshal(contacts)
+	if err != nil {
+		return shared.ContactList{}, err
+	}
+
+	encContacts, err := EncryptChunk(contactsKey, contactsJSON)
+	if err != nil {
+		return shared.ContactList{}, err
+	}
+
+	return shared.ContactList{
+		ProtectedKey: protectedKey,
+		Contacts:     encContacts,
+	}, nil
+}
+
+// OpenContactList decrypts the user's pinned contacts using their key pair
+func OpenContactList(
+	kp KeyPair,
+	contactList shared.ContactList,
+) ([]shared.PinnedContact, error) {
+	if len(contactList.Contacts) == 0 {
+		return []shared.PinnedContact{}, nil
+	}
+
+	contactsKey, err := UnwrapContactsKey(kp, contactList.ProtectedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	contactsJSON, err := DecryptChunk(contactsKey, contactList.Contacts)
+	if err != nil {
+		return nil, err
+	}
+
+	var contacts []shared.PinnedContact
+	err = json.Unmarshal(contactsJSON, &contacts)
+	if err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+func deriveContactsWrapKey(kp KeyPair) ([]byte, error) {
+	if len(kp.PrivateKey) == 0 {
+		return nil, KeysNotIngestedError
+	}
+
+	reader := hkdf.New(sha256.New, kp.PrivateKey, nil, []byte(contactsInfo))
+	key := make([]byte, constants.KeySize)
+	_, err := io.ReadFull(reader, key)
+	return key, err
+}
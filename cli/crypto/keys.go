@@ -0,0 +1,208 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// Keys wrapped with anything other than the original RSA-OAEP key pair start
+// with a header identifying the scheme that was used:
+//
+//	"yfk" || version (1 byte) || scheme data
+//
+// For X25519 (version 2), the scheme data is the sender's ephemeral public key,
+// followed by the IV and the AES-GCM encrypted key. RSA-OAEP wrapped keys have
+// no header, so that keys wrapped before versioning remain readable.
+const (
+	wrappedKeyX25519 byte = 2
+
+	x25519Info = "yeetfile-x25519-key-wrap"
+)
+
+var (
+	wrappedKeyMagic = []byte("yfk")
+
+	UnsupportedKeyTypeErr = errors.New("unsupported public key type")
+	MissingPrivateKeyErr  = errors.New("missing private key for wrapped key")
+)
+
+// GenerateX25519KeyPair generates a new X25519 key pair, returning the raw
+// private and public keys
+func GenerateX25519KeyPair() ([]byte, []byte, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKey.Bytes(), privateKey.PublicKey().Bytes(), nil
+}
+
+// EncryptX25519 wraps a key for the owner of an X25519 public key, using an
+// ephemeral key pair to derive a one-time AES-GCM key
+func EncryptX25519(publicKey []byte, data []byte) ([]byte, error) {
+	recipientKey, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ephemeralKey.ECDH(recipientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPublicKey := ephemeralKey.PublicKey().Bytes()
+	wrapKey, err := deriveX25519WrapKey(secret, ephemeralPublicKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(wrapKey)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, err := GenerateRandomArray(constants.IVSize)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append(bytes.Clone(wrappedKeyMagic), wrappedKeyX25519)
+	result := append(bytes.Clone(header), ephemeralPublicKey...)
+	result = append(result, iv...)
+	return aesgcm.Seal(result, iv, data, header), nil
+}
+
+// DecryptX25519 unwraps a key that was wrapped with EncryptX25519, using the
+// recipient's X25519 private key
+func DecryptX25519(privateKey []byte, data []byte) ([]byte, error) {
+	headerSize := len(wrappedKeyMagic) + 1
+	if !IsX25519Wrapped(data) ||
+		len(data) <= headerSize+constants.X25519KeySize+constants.IVSize {
+		return nil, errors.New("invalid wrapped key")
+	}
+
+	key, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := data[:headerSize]
+	ephemeralPublicKey := data[headerSize : headerSize+constants.X25519KeySize]
+	iv := data[headerSize+constants.X25519KeySize : headerSize+constants.X25519KeySize+constants.IVSize]
+	ciphertext := data[headerSize+constants.X25519KeySize+constants.IVSize:]
+
+	ephemeralKey, err := ecdh.X25519().NewPublicKey(ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := key.ECDH(ephemeralKey)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapKey, err := deriveX25519WrapKey(
+		secret,
+		ephemeralPublicKey,
+		key.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(wrapKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return aesgcm.Open(nil, iv, ciphertext, header)
+}
+
+// IsX25519Wrapped returns true if the wrapped key was created by EncryptX25519
+func IsX25519Wrapped(data []byte) bool {
+	return len(data) > len(wrappedKeyMagic) &&
+		bytes.HasPrefix(data, wrappedKeyMagic) &&
+		data[len(wrappedKeyMagic)] == wrappedKeyX25519
+}
+
+// SelectPublicKey returns the strongest of a user's public keys that the CLI
+// supports. The legacy RSA key is used if the user has no other keys.
+func SelectPublicKey(response shared.PubKeyResponse) shared.UserPublicKey {
+	for _, key := range response.Keys {
+		if key.Type == constants.KeyTypeX25519 {
+			return key
+		}
+	}
+
+	return shared.UserPublicKey{
+		Type:      constants.KeyTypeRSA,
+		PublicKey: response.PublicKey,
+	}
+}
+
+// WrapKey encrypts a key using the provided public key, using the encryption
+// scheme that matches the public key's type
+func WrapKey(publicKey shared.UserPublicKey, data []byte) ([]byte, error) {
+	switch publicKey.Type {
+	case constants.KeyTypeX25519:
+		return EncryptX25519(publicKey.PublicKey, data)
+	case constants.KeyTypeRSA, "":
+		return EncryptRSA(publicKey.PublicKey, data)
+	}
+
+	return nil, UnsupportedKeyTypeErr
+}
+
+func deriveX25519WrapKey(secret, ephemeralPublicKey, publicKey []byte) ([]byte, error) {
+	salt := append(bytes.Clone(ephemeralPublicKey), publicKey...)
+	reader := hkdf.New(sha256.New, secret, salt, []byte(x25519Info))
+
+	key := make([]byte, constants.KeySize)
+	_, err := io.ReadFull(reader, key)
+	return key, err
+}
+
+// ReencryptProtectedKeys decrypts each of the user's private keys with their
+// current user key and encrypts them with their new user key
+func ReencryptProtectedKeys(
+	userKey, newUserKey []byte,
+	protectedKeys shared.ProtectedKeyResponse,
+) ([]byte, []shared.UserKey, error) {
+	privateKey, err := DecryptChunk(userKey, protectedKeys.ProtectedKey)
+	if err != nil {
+		return nil, nil, errors.New("error decrypting protected key")
+	}
+
+	newProtectedKey, err := EncryptChunk(newUserKey, privateKey)
+	if err != nil {
+		return nil, nil, errors.New("error encrypting private key")
+	}
+
+	var newProtectedKeys []shared.UserKey
+	for _, key := range protectedKeys.Keys {
+		privateKey, err = DecryptChunk(userKey, key.ProtectedKey)
+		if err != nil {
+			return nil, nil, errors.New("error decrypting protected key")
+		}
+
+		key.ProtectedKey, err = EncryptChunk(newUserKey, privateKey)
+		if err != nil {
+			return nil, nil, errors.New("error encrypting private key")
+		}
+
+		newProtectedKeys = append(newProtectedKeys, key)
+	}
+
+	return newProtectedKey, newProtectedKeys, nil
+}
@@ -0,0 +1,165 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"yeetfile/shared"
+	"yeetfile/shared/constants"
+)
+
+// Recovery keys are raw X25519 private keys, displayed to the user as unpadded
+// base32 in groups of 4 characters
+const (
+	recoveryKeyHashPrefix = "yeetfile-recovery"
+	recoveryKeyGroupSize  = 4
+)
+
+var (
+	recoveryKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	InvalidRecoveryKeyErr = errors.New("invalid recovery key")
+)
+
+// GenerateRecoveryKey generates a new account recovery key
+func GenerateRecoveryKey() ([]byte, error) {
+	privateKey, _, err := GenerateX25519KeyPair()
+	return privateKey, err
+}
+
+// RecoveryPublicKey returns the public key that the user's private keys are
+// encrypted with for recovery
+func RecoveryPublicKey(recoveryKey []byte) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(recoveryKey)
+	if err != nil {
+		return nil, InvalidRecoveryKeyErr
+	}
+
+	return key.PublicKey().Bytes(), nil
+}
+
+// RecoveryKeyHash returns the hash used to prove possession of a recovery key
+// to the server, without revealing the key itself
+func RecoveryKeyHash(recoveryKey []byte) []byte {
+	hash := sha256.Sum256(append([]byte(recoveryKeyHashPrefix), recoveryKey...))
+	return hash[:]
+}
+
+// CreateRecoveryKey decrypts each of the user's private keys with their user
+// key, and encrypts a copy of each with the recovery key so that they can be
+// decrypted if the user forgets their password
+func CreateRecoveryKey(
+	recoveryKey, userKey []byte,
+	protectedKeys shared.ProtectedKeyResponse,
+) (shared.RecoveryKey, error) {
+	publicKey, err := RecoveryPublicKey(recoveryKey)
+	if err != nil {
+		return shared.RecoveryKey{}, err
+	}
+
+	privateKey, err := DecryptChunk(userKey, protectedKeys.ProtectedKey)
+	if err != nil {
+		return shared.RecoveryKey{}, errors.New("error decrypting protected key")
+	}
+
+	recovery := shared.RecoveryKey{
+		KeyHash:   RecoveryKeyHash(recoveryKey),
+		PublicKey: publicKey,
+	}
+
+	recovery.ProtectedKey, err = EncryptX25519(publicKey, privateKey)
+	if err != nil {
+		return shared.RecoveryKey{}, err
+	}
+
+	for _, key := range protectedKeys.Keys {
+		privateKey, err = DecryptChunk(userKey, key.ProtectedKey)
+		if err != nil {
+			return shared.RecoveryKey{}, errors.New("error decrypting protected key")
+		}
+
+		recoveryProtectedKey, err := EncryptX25519(publicKey, privateKey)
+		if err != nil {
+			return shared.RecoveryKey{}, err
+		}
+
+		recovery.Keys = append(recovery.Keys, shared.UserKey{
+			Type:                 key.Type,
+			PublicKey:            key.PublicKey,
+			RecoveryProtectedKey: recoveryProtectedKey,
+		})
+	}
+
+	return recovery, nil
+}
+
+// RecoverProtectedKeys decrypts each of the user's private keys with their
+// recovery key and encrypts them with their new user key
+func RecoverProtectedKeys(
+	recoveryKey, newUserKey []byte,
+	recoveryKeys shared.RecoveryKeysResponse,
+) ([]byte, []shared.UserKey, error) {
+	privateKey, err := DecryptX25519(recoveryKey, recoveryKeys.ProtectedKey)
+	if err != nil {
+		return nil, nil, InvalidRecoveryKeyErr
+	}
+
+	newProtectedKey, err := EncryptChunk(newUserKey, privateKey)
+	if err != nil {
+		return nil, nil, errors.New("error encrypting private key")
+	}
+
+	var newProtectedKeys []shared.UserKey
+	for _, key := range recoveryKeys.Keys {
+		privateKey, err = DecryptX25519(recoveryKey, key.RecoveryProtectedKey)
+		if err != nil {
+			return nil, nil, InvalidRecoveryKeyErr
+		}
+
+		protectedKey, err := EncryptChunk(newUserKey, privateKey)
+		if err != nil {
+			return nil, nil, errors.New("error encrypting private key")
+		}
+
+		newProtectedKeys = append(newProtectedKeys, shared.UserKey{
+			Type:         key.Type,
+			PublicKey:    key.PublicKey,
+			ProtectedKey: protectedKey,
+		})
+	}
+
+	return newProtectedKey, newProtectedKeys, nil
+}
+
+// FormatRecoveryKey converts a recovery key into the text shown to the user
+func FormatRecoveryKey(recoveryKey []byte) string {
+	encoded := recoveryKeyEncoding.EncodeToString(recoveryKey)
+
+	var groups []string
+	for i := 0; i < len(encoded); i += recoveryKeyGroupSize {
+		groups = append(groups, encoded[i:min(i+recoveryKeyGroupSize, len(encoded))])
+	}
+
+	return strings.Join(groups, "-")
+}
+
+// ParseRecoveryKey converts the text of a recovery key back into the key,
+// ignoring case, spaces, and dashes
+func ParseRecoveryKey(text string) ([]byte, error) {
+	encoded := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' || r == '\n' {
+			return -1
+		}
+
+		return r
+	}, strings.ToUpper(text))
+
+	recoveryKey, err := recoveryKeyEncoding.DecodeString(encoded)
+	if err != nil || len(recoveryKey) != constants.X25519KeySize {
+		return nil, InvalidRecoveryKeyErr
+	}
+
+	return recoveryKey, nil
+}
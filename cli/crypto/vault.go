@@ -17,8 +17,10 @@ type CryptoCtx struct {
 }
 
 type KeyPair struct {
-	PrivateKey []byte
-	PublicKey  []byte
+	PrivateKey       []byte
+	PublicKey        []byte
+	X25519PrivateKey []byte
+	X25519PublicKey  []byte
 }
 
 func ReadCLIKey() []byte {
@@ -58,10 +60,17 @@ func (kp KeyPair) DeriveVaultCryptoContext(keySequence [][]byte) (CryptoCtx, err
 		decryptFunc = DecryptChunk
 		encryptFunc = EncryptChunk
 	} else {
+		// Root level keys are wrapped with the user's own key pair, which
+		// determines the scheme from the key pairs the user holds (when
+		// wrapping) or the wrapped key's header (when unwrapping)
 		decryptedFolderKey = kp.PrivateKey
 		encryptKey = kp.PublicKey
-		decryptFunc = DecryptRSA
-		encryptFunc = EncryptRSA
+		decryptFunc = func(_ []byte, data []byte) ([]byte, error) {
+			return kp.UnwrapKey(data)
+		}
+		encryptFunc = func(_ []byte, data []byte) ([]byte, error) {
+			return kp.WrapKey(data)
+		}
 	}
 
 	return CryptoCtx{
@@ -80,7 +89,7 @@ func (kp KeyPair) UnwindKeySequence(keySequence [][]byte) ([]byte, error) {
 	var err error
 	for _, key := range keySequence {
 		if parentKey == nil {
-			parentKey, err = DecryptRSA(kp.PrivateKey, key)
+			parentKey, err = kp.UnwrapKey(key)
 			if err != nil {
 				log.Println("Error decrypting root folder key")
 				return nil, err
@@ -98,3 +107,27 @@ func (kp KeyPair) UnwindKeySequence(keySequence [][]byte) ([]byte, error) {
 
 	return parentKey, nil
 }
+
+// WrapKey encrypts a key with the user's own public key, preferring the
+// X25519 key pair over the RSA key pair if the user has one
+func (kp KeyPair) WrapKey(data []byte) ([]byte, error) {
+	if len(kp.X25519PublicKey) > 0 {
+		return EncryptX25519(kp.X25519PublicKey, data)
+	}
+
+	return EncryptRSA(kp.PublicKey, data)
+}
+
+// UnwrapKey decrypts a key that was wrapped for the user, using whichever of
+// the user's private keys matches the scheme the key was wrapped with
+func (kp KeyPair) UnwrapKey(data []byte) ([]byte, error) {
+	if IsX25519Wrapped(data) {
+		if len(kp.X25519PrivateKey) == 0 {
+			return nil, MissingPrivateKeyErr
+		}
+
+		return DecryptX25519(kp.X25519PrivateKey, data)
+	}
+
+	return DecryptRSA(kp.PrivateKey, data)
+}
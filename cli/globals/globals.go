@@ -1,8 +1,9 @@
 package globals
 
 import (
-	"fmt"
 	"log"
+	"os"
+	"yeetfile/cli/agent"
 	"yeetfile/cli/api"
 	"yeetfile/cli/config"
 	"yeetfile/cli/crypto"
@@ -11,42 +12,31 @@ import (
 
 var API *api.Context
 var Config *config.Config
+var Agent *agent.Client
 var ServerInfo shared.ServerInfo
 
+// CLIKey is the key used to decrypt the user's session (and their private keys,
+// if they haven't set a vault password). It's read from the YeetFile agent if
+// it's running, otherwise from YEETFILE_CLI_KEY.
+var CLIKey []byte
+
 var LongWordlist []string
 var ShortWordlist []string
 
 func init() {
-	Config = config.LoadConfig()
+	// The --profile flag is removed from the args before they're handled by
+	// any commands
+	var profile string
+	profile, os.Args = config.ParseProfileArg(os.Args)
+	Config = config.LoadConfig(profile)
+
+	Agent = agent.NewClient(Config.AgentSocketPath())
 
 	session := Config.ReadSession()
 	if session == nil || len(session) == 0 {
 		API = api.InitContext(Config.Server, "")
 	} else {
-		cliKey := crypto.ReadCLIKey()
-		if cliKey == nil || len(cliKey) == 0 {
-			// Missing YEETFILE_CLI_KEY for decrypting session
-			API = api.InitContext(Config.Server, "")
-		} else {
-			sessionVal, err := crypto.DecryptChunk(cliKey, session)
-			if err != nil {
-				msg := fmt.Sprintf("Failed to decrypt session " +
-					"with YEETFILE_CLI_KEY value")
-				log.Println(msg)
-				if Config.DebugMode {
-					log.Printf("DEBUG: CLI_KEY -- len: %d, "+
-						"contents: %c%c...%c%c",
-						len(cliKey),
-						cliKey[0],
-						cliKey[1],
-						cliKey[len(cliKey)-2],
-						cliKey[len(cliKey)-1])
-				}
-				API = api.InitContext(Config.Server, "")
-			} else {
-				API = api.InitContext(Config.Server, string(sessionVal))
-			}
-		}
+		API = api.InitContext(Config.Server, decryptSession(session))
 	}
 
 	var err error
@@ -92,3 +82,38 @@ func init() {
 		}
 	}
 }
+
+// decryptSession decrypts the user's session using the CLI key from the agent
+// or the environment, and sets CLIKey to whichever key was able to decrypt it.
+// Returns an empty string if neither key is available or valid.
+func decryptSession(session []byte) string {
+	var cliKeys [][]byte
+	if agentKey, err := Agent.CLIKey(); err == nil {
+		cliKeys = append(cliKeys, agentKey)
+	}
+
+	if envKey := crypto.ReadCLIKey(); len(envKey) > 0 {
+		cliKeys = append(cliKeys, envKey)
+	}
+
+	for _, cliKey := range cliKeys {
+		sessionVal, err := crypto.DecryptChunk(cliKey, session)
+		if err == nil {
+			CLIKey = cliKey
+			return string(sessionVal)
+		}
+
+		log.Println("Failed to decrypt session with CLI key")
+		if Config.DebugMode {
+			log.Printf("DEBUG: CLI_KEY -- len: %d, "+
+				"contents: %c%c...%c%c",
+				len(cliKey),
+				cliKey[0],
+				cliKey[1],
+				cliKey[len(cliKey)-2],
+				cliKey[len(cliKey)-1])
+		}
+	}
+
+	return ""
+}
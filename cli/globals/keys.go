@@ -0,0 +1,80 @@
+package globals
+
+import (
+	"fmt"
+	"log"
+	"yeetfile/cli/crypto"
+)
+
+// VaultPasswordPrompt asks the user for their vault password, showing an error
+// message from a previous attempt if one is provided
+type VaultPasswordPrompt func(errMsgs ...string) ([]byte, error)
+
+// UnlockKeyPair returns the user's unlocked key pair. The key pair is read from
+// the YeetFile agent if it's running, otherwise it's decrypted from the config
+// directory using the CLI key (prompting for the user's vault password if
+// they've set one), and then stored in the agent for subsequent commands.
+func UnlockKeyPair(prompt VaultPasswordPrompt) (crypto.KeyPair, error) {
+	if kp, err := Agent.KeyPair(); err == nil {
+		return kp, nil
+	}
+
+	var kp crypto.KeyPair
+	var unlockKey []byte
+
+	encPrivateKey, publicKey, err := Config.GetKeys()
+	if err != nil {
+		return crypto.KeyPair{}, fmt.Errorf("error reading key files: %w", err)
+	}
+
+	if privateKey, err := crypto.DecryptChunk(CLIKey, encPrivateKey); err == nil {
+		kp = crypto.IngestKeys(privateKey, publicKey)
+		unlockKey = CLIKey
+	} else {
+		var unlockFunc func(errMsgs ...string) error
+		unlockFunc = func(errMsgs ...string) error {
+			vaultPassword, err := prompt(errMsgs...)
+			if err != nil {
+				return err
+			}
+
+			key := crypto.DerivePBKDFKey(vaultPassword, CLIKey)
+			privateKey, err := crypto.DecryptChunk(key, encPrivateKey)
+			if err != nil {
+				return unlockFunc("Incorrect password")
+			}
+
+			kp = crypto.IngestKeys(privateKey, publicKey)
+			unlockKey = key
+			return nil
+		}
+
+		err = unlockFunc()
+		if err != nil {
+			log.Printf("Error loading vault keys: %v\n", err)
+			return crypto.KeyPair{}, err
+		}
+	}
+
+	// The X25519 private key is stored using the same key as the RSA
+	// private key
+	encX25519Key, x25519PubKey, err := Config.GetX25519Keys()
+	if err != nil {
+		log.Printf("Error reading x25519 key files: %v\n", err)
+	} else if len(encX25519Key) > 0 {
+		x25519Key, err := crypto.DecryptChunk(unlockKey, encX25519Key)
+		if err != nil {
+			log.Printf("Error decrypting x25519 key: %v\n", err)
+			return crypto.KeyPair{}, err
+		}
+
+		kp.X25519PrivateKey = x25519Key
+		kp.X25519PublicKey = x25519PubKey
+	}
+
+	// Hold on to the unlocked keys in the agent (if it's running), so that
+	// the vault password isn't needed again until the agent is locked
+	_ = Agent.SetKeyPair(kp)
+
+	return kp, nil
+}
@@ -24,6 +24,7 @@ type PendingDownload struct {
 type DownloadChunk struct {
 	File     *os.File
 	ChunkNum int
+	Final    bool
 	Key      []byte
 	Endpoint string
 }
@@ -62,7 +63,11 @@ func fetchChunk(chunk DownloadChunk) ([]byte, error) {
 		return nil, err
 	}
 
-	decryptedData, err := crypto.DecryptChunk(chunk.Key, body)
+	decryptedData, err := crypto.DecryptFileChunk(
+		chunk.Key,
+		chunk.ChunkNum,
+		chunk.Final,
+		body)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +123,12 @@ func InitVaultDownload(
 		return PendingDownload{}, err
 	}
 
-	p := initDownload(metadata.ID, globals.Config.Server, key, file, metadata.Chunks)
+	p := initDownload(
+		metadata.ID,
+		globals.Config.Server,
+		key,
+		file,
+		metadata.Chunks)
 	p.UnformattedEndpoint = endpoints.DownloadVaultFileData
 	return p, nil
 }
@@ -159,6 +169,7 @@ func (p PendingDownload) DownloadData(progress func()) error {
 	finalChunk := DownloadChunk{
 		File:     p.File,
 		ChunkNum: p.NumChunks - 1,
+		Final:    true,
 		Key:      p.Key,
 		Endpoint: p.UnformattedEndpoint.Format(p.Server, p.ID, strconv.Itoa(p.NumChunks)),
 	}
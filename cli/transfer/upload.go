@@ -17,7 +17,7 @@ import (
 
 type PendingUpload struct {
 	ID                  string
-	Key                 []byte
+	Encryptor           *crypto.FileEncryptor
 	File                *os.File
 	NumChunks           int
 	UnformattedEndpoint endpoints.Endpoint
@@ -97,12 +97,18 @@ func InitVaultFile(
 	name := hex.EncodeToString(encName)
 	size := stat.Size()
 	numChunks := GetNumChunks(stat.Size())
+	encryptor, err := newFileEncryptor(key)
+	if err != nil {
+		return PendingUpload{}, err
+	}
+
 	upload := shared.VaultUpload{
-		Name:         name,
-		Length:       size,
-		Chunks:       numChunks,
-		FolderID:     folderID,
-		ProtectedKey: protectedKey,
+		Name:              name,
+		Length:            size,
+		Chunks:            numChunks,
+		FolderID:          folderID,
+		ProtectedKey:      protectedKey,
+		EncryptionVersion: encryptor.Version,
 	}
 
 	metaResponse, err := globals.API.InitVaultFile(upload)
@@ -112,7 +118,7 @@ func InitVaultFile(
 
 	return PendingUpload{
 		ID:                  metaResponse.ID,
-		Key:                 key,
+		Encryptor:           encryptor,
 		File:                file,
 		NumChunks:           numChunks,
 		UnformattedEndpoint: endpoints.UploadVaultFileData,
@@ -125,6 +131,12 @@ func InitSendFile(
 	meta shared.UploadMetadata,
 	key []byte,
 ) (PendingUpload, error) {
+	encryptor, err := newFileEncryptor(key)
+	if err != nil {
+		return PendingUpload{}, err
+	}
+
+	meta.EncryptionVersion = encryptor.Version
 	metaResponse, err := globals.API.InitSendFile(meta)
 	if err != nil {
 		return PendingUpload{}, err
@@ -132,7 +144,7 @@ func InitSendFile(
 
 	return PendingUpload{
 		ID:                  metaResponse.ID,
-		Key:                 key,
+		Encryptor:           encryptor,
 		File:                file,
 		NumChunks:           meta.Chunks,
 		UnformattedEndpoint: endpoints.UploadSendFileData,
@@ -207,7 +219,11 @@ func (p PendingUpload) prepareChunk(chunk int, size int64) (FileChunk, error) {
 		return FileChunk{}, err
 	}
 
-	encData, _ := crypto.EncryptChunk(p.Key, contents)
+	encData, err := p.Encryptor.EncryptChunk(chunk, chunk == p.NumChunks-1, contents)
+	if err != nil {
+		return FileChunk{}, err
+	}
+
 	return FileChunk{
 		Chunk:         chunk,
 		Endpoint:      endpoint,
@@ -1,11 +1,19 @@
 package transfer
 
 import (
+	"errors"
 	"math"
+	"slices"
+	"yeetfile/cli/crypto"
+	"yeetfile/cli/globals"
 	"yeetfile/shared/constants"
 	"yeetfile/shared/endpoints"
 )
 
+var UnsupportedEncryptionErr = errors.New(
+	"the server doesn't support this client's file encryption format, " +
+		"the server needs to be updated before uploading files")
+
 func GetNumChunks(size int64) int {
 	return int(math.Ceil(float64(size) / float64(constants.ChunkSize)))
 }
@@ -27,4 +35,24 @@ func GetModificationEndpoint(isFolder bool) endpoints.Endpoint {
 	}
 
 	return endpoints.VaultFile
-}
\ No newline at end of file
+}
+
+// newFileEncryptor returns an encryptor using the latest file encryption
+// format, as long as the server advertises support for it. Cached server info
+// is refreshed before giving up, in case the server was recently updated.
+func newFileEncryptor(key []byte) (*crypto.FileEncryptor, error) {
+	version := constants.LatestFileEncryption
+	if !slices.Contains(globals.ServerInfo.FileEncryption, version) {
+		serverInfo, err := globals.API.GetServerInfo()
+		if err != nil {
+			return nil, err
+		} else if !slices.Contains(serverInfo.FileEncryption, version) {
+			return nil, UnsupportedEncryptionErr
+		}
+
+		globals.ServerInfo = serverInfo
+		_ = globals.Config.SetServerInfo(serverInfo)
+	}
+
+	return crypto.NewFileEncryptor(key, version)
+}
@@ -30,4 +30,116 @@ const (
 	MaxTransferThreads              = 3
 	MaxPassNoteLen                  = 500
 	RecoveryCodeLen                 = 8
+
+	UsageTypeStorage   = "storage"
+	UsageTypeSend      = "send"
+	UsageTypeBandwidth = "bandwidth"
+)
+
+// File encryption formats. Version 1 seals each chunk with a random IV, and
+// version 2 binds each chunk to its position in the file and marks the final
+// chunk, so that chunks can't be reordered, duplicated, or dropped.
+const (
+	FileEncryptionV1 = 1
+	FileEncryptionV2 = 2
+
+	LatestFileEncryption = FileEncryptionV2
+)
+
+// Key derivation functions. Accounts and Send links store the parameters they
+// were created with, so that the recommended parameters can be strengthened
+// over time. Argon2Mem and Argon2Iter (and PBKDF2Iter for Send links) are the
+// parameters used before they were stored, and are the minimum that clients
+// will accept.
+const (
+	KDFArgon2id = "argon2id"
+	KDFPBKDF2   = "pbkdf2-sha256"
+
+	PBKDF2Iter    uint32 = 600000
+	MaxArgon2Mem  uint32 = 1024 // MB
+	MaxArgon2Iter uint32 = 16
+	MaxPBKDF2Iter uint32 = 10000000
+)
+
+// Account key types. Every account has an RSA key pair, and can also hold
+// newer key types that are preferred when sharing content.
+const (
+	KeyTypeRSA    = "rsa"
+	KeyTypeX25519 = "x25519"
+
+	X25519KeySize       = 32
+	MaxProtectedKeySize = 256
+)
+
+const (
+	AdminSortStorage   = "storage"
+	AdminSortLastLogin = "login"
+	AdminSortSignup    = "signup"
+
+	AdminUserListPageSize = 50
+
+	ActiveUserDays       = 30
+	DefaultAdminStatDays = 30
+	MaxAdminStatDays     = 365
 )
+
+const (
+	AdminBulkUpdateQuota = "quota"
+	AdminBulkDelete      = "delete"
+	AdminBulkLogout      = "logout"
+	AdminBulkDisable2FA  = "disable-2fa"
+)
+
+const (
+	ReportReasonMalware  = "malware"
+	ReportReasonIllegal  = "illegal"
+	ReportReasonPhishing = "phishing"
+	ReportReasonOther    = "other"
+	MaxReportDetailsLen  = 500
+)
+
+const (
+	OIDCStatusPending = "pending"
+	OIDCStatusLogin   = "login"
+	OIDCStatusLink    = "link"
+	OIDCStatusSignup  = "signup"
+	OIDCStatusError   = "error"
+)
+
+const (
+	EmergencyStatusIdle      = "idle"
+	EmergencyStatusRequested = "requested"
+	EmergencyStatusGranted   = "granted"
+
+	DefaultEmergencyWaitDays = 7
+	MaxEmergencyWaitDays     = 90
+	MaxEmergencyContacts     = 10
+)
+
+// Public key fingerprints are displayed as a sequence of symbols from the
+// FingerprintSymbols table, so that users can compare them out of band before
+// trusting a contact's keys. Each symbol is chosen using the low 6 bits of a
+// byte of the fingerprint.
+const (
+	FingerprintLength  = 12
+	MaxContactListSize = 128 * 1024
+)
+
+var FingerprintSymbols = [64][2]string{
+	{"🐶", "dog"}, {"🐱", "cat"}, {"🐭", "mouse"}, {"🐰", "rabbit"},
+	{"🦊", "fox"}, {"🐻", "bear"}, {"🐼", "panda"}, {"🐨", "koala"},
+	{"🐯", "tiger"}, {"🦁", "lion"}, {"🐮", "cow"}, {"🐷", "pig"},
+	{"🐸", "frog"}, {"🐵", "monkey"}, {"🐔", "chicken"}, {"🐧", "penguin"},
+	{"🐦", "bird"}, {"🦆", "duck"}, {"🦉", "owl"}, {"🐺", "wolf"},
+	{"🐴", "horse"}, {"🦄", "unicorn"}, {"🐝", "bee"}, {"🐛", "caterpillar"},
+	{"🦋", "butterfly"}, {"🐌", "snail"}, {"🐢", "turtle"}, {"🐍", "snake"},
+	{"🐙", "octopus"}, {"🦀", "crab"}, {"🐟", "fish"}, {"🐬", "dolphin"},
+	{"🐳", "whale"}, {"🦈", "shark"}, {"🐘", "elephant"}, {"🦒", "giraffe"},
+	{"🍎", "apple"}, {"🍌", "banana"}, {"🍇", "grapes"}, {"🍓", "strawberry"},
+	{"🍒", "cherries"}, {"🍑", "peach"}, {"🍍", "pineapple"}, {"🥕", "carrot"},
+	{"🌽", "corn"}, {"🍄", "mushroom"}, {"🌵", "cactus"}, {"🌲", "tree"},
+	{"🌻", "sunflower"}, {"🌙", "moon"}, {"🔥", "fire"}, {"🌈", "rainbow"},
+	{"🎈", "balloon"}, {"🎸", "guitar"}, {"🚀", "rocket"}, {"🚲", "bicycle"},
+	{"🔑", "key"}, {"🔔", "bell"}, {"🎁", "gift"}, {"📚", "books"},
+	{"🍕", "pizza"}, {"🍩", "donut"}, {"🎲", "dice"}, {"🏆", "trophy"},
+}
@@ -15,6 +15,8 @@ type HTMLEndpoints struct {
 	Login          string
 	Signup         string
 	Forgot         string
+	Recover        string
+	RecoveryKey    string
 	ChangeHint     string
 	ChangePassword string
 	VerifyEmail    string
@@ -28,6 +30,7 @@ type HTMLEndpoints struct {
 type BillingEndpoints struct {
 	BTCPayCheckout string
 	StripeCheckout string
+	ManualCheckout string
 }
 
 var HTMLPageEndpoints HTMLEndpoints
@@ -39,6 +42,11 @@ var (
 	Logout           = Endpoint("/api/logout")
 	Account          = Endpoint("/api/account")
 	AccountUsage     = Endpoint("/api/account/usage")
+	AccountBilling   = Endpoint("/api/account/billing")
+	AccountReceipt   = Endpoint("/api/account/billing/*")
+	RedeemVoucher    = Endpoint("/api/account/voucher")
+	AccountInvites   = Endpoint("/api/account/invites")
+	AccountInvite    = Endpoint("/api/account/invites/*")
 	RecyclePaymentID = Endpoint("/api/account/recycle/payment_id")
 	Forgot           = Endpoint("/api/forgot")
 	Session          = Endpoint("/api/session")
@@ -49,10 +57,51 @@ var (
 	ChangePassword   = Endpoint("/api/change/password")
 	ChangeHint       = Endpoint("/api/change/hint")
 	ServerInfo       = Endpoint("/api/info")
+	KDF              = Endpoint("/api/kdf")
+	Recovery         = Endpoint("/api/recovery")
+	RecoveryStart    = Endpoint("/api/recovery/start")
+	RecoveryFinish   = Endpoint("/api/recovery/finish")
 
+	OIDCStart    = Endpoint("/api/oidc/start")
+	OIDCCallback = Endpoint("/api/oidc/callback")
+	OIDCStatus   = Endpoint("/api/oidc/status")
+	OIDCConfirm  = Endpoint("/api/oidc/confirm")
+	OIDCLink     = Endpoint("/api/oidc/link")
+	OIDCSignup   = Endpoint("/api/oidc/signup")
+
+	Organization    = Endpoint("/api/org")
+	OrgInvites      = Endpoint("/api/org/invites")
+	OrgInviteAction = Endpoint("/api/org/invites/*")
+	OrgMember       = Endpoint("/api/org/members/*")
+
+	EmergencyAccess  = Endpoint("/api/emergency")
+	EmergencyContact = Endpoint("/api/emergency/contacts/*")
+	EmergencyDeny    = Endpoint("/api/emergency/deny/*")
+	EmergencyGrantor = Endpoint("/api/emergency/grantors/*")
+
+	AdminUsers         = Endpoint("/api/admin/users")
 	AdminUserActions   = Endpoint("/api/admin/user/*")
+	AdminSuspendUser   = Endpoint("/api/admin/suspend/*")
 	AdminFileActions   = Endpoint("/api/admin/files/*")
 	AdminInviteActions = Endpoint("/api/admin/invites")
+	AdminInviteLinks   = Endpoint("/api/admin/invites/links")
+	AdminInviteLink    = Endpoint("/api/admin/invites/links/*")
+	AdminSignups       = Endpoint("/api/admin/signups")
+	AdminSignupAction  = Endpoint("/api/admin/signups/*")
+	AdminEmailDomains  = Endpoint("/api/admin/domains")
+	AdminEmailDomain   = Endpoint("/api/admin/domains/*")
+	AdminStats         = Endpoint("/api/admin/stats")
+	AdminReports       = Endpoint("/api/admin/reports")
+	AdminReportActions = Endpoint("/api/admin/reports/*")
+	AdminInvoices      = Endpoint("/api/admin/invoices")
+	AdminInvoiceAction = Endpoint("/api/admin/invoices/*")
+	AdminVouchers      = Endpoint("/api/admin/vouchers")
+	AdminVoucherAction = Endpoint("/api/admin/vouchers/*")
+	AdminUpgrades      = Endpoint("/api/admin/upgrades")
+	AdminUpgradeAction = Endpoint("/api/admin/upgrades/*")
+	AdminRoles         = Endpoint("/api/admin/roles")
+	AdminRoleAction    = Endpoint("/api/admin/roles/*")
+	AdminSecrets       = Endpoint("/api/admin/secrets")
 
 	Up = Endpoint("/up")
 
@@ -75,14 +124,19 @@ var (
 	UploadSendText           = Endpoint("/api/send/text")
 	DownloadSendFileMetadata = Endpoint("/api/send/d/*")
 	DownloadSendFileData     = Endpoint("/api/send/d/*/*")
+	ReportSendFile           = Endpoint("/api/send/report/*")
 
 	ShareFile    = Endpoint("/api/share/file/*")
 	ShareFolder  = Endpoint("/api/share/folder/*")
 	PubKey       = Endpoint("/api/pubkey")
 	ProtectedKey = Endpoint("/api/protectedkey")
+	UserKeys     = Endpoint("/api/keys")
+	RotateKeys   = Endpoint("/api/keys/rotate")
+	Contacts     = Endpoint("/api/contacts")
 
 	StripeWebhook  = Endpoint("/stripe/webhook")
 	StripeCheckout = Endpoint("/stripe/checkout")
+	ManualCheckout = Endpoint("/manual/checkout")
 	BTCPayWebhook  = Endpoint("/btcpay/webhook")
 	BTCPayCheckout = Endpoint("/btcpay/checkout")
 
@@ -100,8 +154,11 @@ var (
 	HTMLVaultFolder      = Endpoint("/vault/*")
 	HTMLVaultFile        = Endpoint("/vault/*/file/*")
 	HTMLLogin            = Endpoint("/login")
+	HTMLOIDC             = Endpoint("/oidc")
 	HTMLSignup           = Endpoint("/signup")
 	HTMLForgot           = Endpoint("/forgot")
+	HTMLRecover          = Endpoint("/recover")
+	HTMLRecoveryKey      = Endpoint("/account/recovery")
 	HTMLChangeEmail      = Endpoint("/change/email/*")
 	HTMLChangePassword   = Endpoint("/change/password")
 	HTMLChangeHint       = Endpoint("/change/hint")
@@ -111,6 +168,7 @@ var (
 	HTMLCheckoutComplete = Endpoint("/checkout/complete")
 	HTMLUpgrade          = Endpoint("/upgrade")
 	HTMLAdmin            = Endpoint("/admin")
+	HTMLReceipt          = Endpoint("/account/receipt/*")
 )
 
 var JSVarNameMap = map[Endpoint]string{
@@ -121,6 +179,11 @@ var JSVarNameMap = map[Endpoint]string{
 	Session:          "Session",
 	Account:          "Account",
 	AccountUsage:     "AccountUsage",
+	AccountBilling:   "AccountBilling",
+	AccountReceipt:   "AccountReceipt",
+	RedeemVoucher:    "RedeemVoucher",
+	AccountInvites:   "AccountInvites",
+	AccountInvite:    "AccountInvite",
 	RecyclePaymentID: "RecyclePaymentID",
 	TwoFactor:        "TwoFactor",
 	VerifyAccount:    "VerifyAccount",
@@ -129,10 +192,50 @@ var JSVarNameMap = map[Endpoint]string{
 	ChangePassword:   "ChangePassword",
 	ChangeHint:       "ChangeHint",
 	ServerInfo:       "ServerInfo",
+	KDF:              "KDF",
+	Recovery:         "Recovery",
+	RecoveryStart:    "RecoveryStart",
+	RecoveryFinish:   "RecoveryFinish",
+
+	OIDCStart:   "OIDCStart",
+	OIDCStatus:  "OIDCStatus",
+	OIDCConfirm: "OIDCConfirm",
+	OIDCLink:    "OIDCLink",
+	OIDCSignup:  "OIDCSignup",
+
+	Organization:    "Organization",
+	OrgInvites:      "OrgInvites",
+	OrgInviteAction: "OrgInviteAction",
+	OrgMember:       "OrgMember",
+
+	EmergencyAccess:  "EmergencyAccess",
+	EmergencyContact: "EmergencyContact",
+	EmergencyDeny:    "EmergencyDeny",
+	EmergencyGrantor: "EmergencyGrantor",
 
+	AdminUsers:         "AdminUsers",
 	AdminUserActions:   "AdminUserActions",
+	AdminSuspendUser:   "AdminSuspendUser",
 	AdminFileActions:   "AdminFileActions",
 	AdminInviteActions: "AdminInviteActions",
+	AdminInviteLinks:   "AdminInviteLinks",
+	AdminInviteLink:    "AdminInviteLink",
+	AdminSignups:       "AdminSignups",
+	AdminSignupAction:  "AdminSignupAction",
+	AdminEmailDomains:  "AdminEmailDomains",
+	AdminEmailDomain:   "AdminEmailDomain",
+	AdminStats:         "AdminStats",
+	AdminReports:       "AdminReports",
+	AdminReportActions: "AdminReportActions",
+	AdminInvoices:      "AdminInvoices",
+	AdminInvoiceAction: "AdminInvoiceAction",
+	AdminVouchers:      "AdminVouchers",
+	AdminVoucherAction: "AdminVoucherAction",
+	AdminUpgrades:      "AdminUpgrades",
+	AdminUpgradeAction: "AdminUpgradeAction",
+	AdminRoles:         "AdminRoles",
+	AdminRoleAction:    "AdminRoleAction",
+	AdminSecrets:       "AdminSecrets",
 
 	PassRoot:     "PassRoot",
 	PassFolder:   "PassFolder",
@@ -153,15 +256,20 @@ var JSVarNameMap = map[Endpoint]string{
 	UploadSendText:           "UploadSendText",
 	DownloadSendFileMetadata: "DownloadSendFileMetadata",
 	DownloadSendFileData:     "DownloadSendFileData",
+	ReportSendFile:           "ReportSendFile",
 
 	ShareFile:    "ShareFile",
 	ShareFolder:  "ShareFolder",
 	PubKey:       "PubKey",
 	ProtectedKey: "ProtectedKey",
+	UserKeys:     "UserKeys",
+	RotateKeys:   "RotateKeys",
+	Contacts:     "Contacts",
 
 	StaticFile: "StaticFile",
 
 	StripeCheckout: "StripeCheckout",
+	ManualCheckout: "ManualCheckout",
 
 	HTMLHome:             "HTMLHome",
 	HTMLAccount:          "HTMLAccount",
@@ -174,7 +282,10 @@ var JSVarNameMap = map[Endpoint]string{
 	HTMLVaultFolder:      "HTMLVaultFolder",
 	HTMLVaultFile:        "HTMLVaultFile",
 	HTMLLogin:            "HTMLLogin",
+	HTMLOIDC:             "HTMLOIDC",
 	HTMLSignup:           "HTMLSignup",
+	HTMLRecover:          "HTMLRecover",
+	HTMLRecoveryKey:      "HTMLRecoveryKey",
 	HTMLChangeEmail:      "HTMLChangeEmail",
 	HTMLChangePassword:   "HTMLChangePassword",
 	HTMLChangeHint:       "HTMLChangeHint",
@@ -183,6 +294,7 @@ var JSVarNameMap = map[Endpoint]string{
 	HTMLServerInfo:       "HTMLServerInfo",
 	HTMLCheckoutComplete: "HTMLCheckoutComplete",
 	HTMLAdmin:            "HTMLAdmin",
+	HTMLReceipt:          "HTMLReceipt",
 }
 
 func (e Endpoint) Format(server string, args ...string) string {
@@ -210,6 +322,8 @@ func init() {
 		Login:          string(HTMLLogin),
 		Signup:         string(HTMLSignup),
 		Forgot:         string(HTMLForgot),
+		Recover:        string(HTMLRecover),
+		RecoveryKey:    string(HTMLRecoveryKey),
 		ChangePassword: string(HTMLChangePassword),
 		ChangeHint:     string(HTMLChangeHint),
 		VerifyEmail:    string(HTMLVerifyEmail),
@@ -222,5 +336,6 @@ func init() {
 	BillingPageEndpoints = BillingEndpoints{
 		BTCPayCheckout: string(BTCPayCheckout),
 		StripeCheckout: string(StripeCheckout),
+		ManualCheckout: string(ManualCheckout),
 	}
 }
@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"encoding/json"
 	"fmt"
 	"yeetfile/shared/constants"
 	"yeetfile/shared/endpoints"
@@ -23,7 +24,20 @@ export const VerificationCodeLength = %d;
 export const MaxHintLen = %d;
 export const MaxPassNoteLen = %d;
 export const Argon2Iter = %d;
-export const Argon2Mem = %d;`
+export const Argon2Mem = %d;
+export const PBKDF2Iter = %d;
+export const MaxArgon2Iter = %d;
+export const MaxArgon2Mem = %d;
+export const MaxPBKDF2Iter = %d;
+export const KDFArgon2id = "%s";
+export const KDFPBKDF2 = "%s";
+export const FileEncryptionV1 = %d;
+export const FileEncryptionV2 = %d;
+export const LatestFileEncryption = %d;
+export const KeyTypeRSA = "%s";
+export const KeyTypeX25519 = "%s";
+export const FingerprintLength = %d;
+export const FingerprintSymbols = %s;`
 
 const endpointsHeadJS = `
 // Auto-generated from shared/js.go. Don't edit this manually.
@@ -51,6 +65,7 @@ const endpointEntry = `
     static %s: Endpoint = {path: "%s"};`
 
 func GenerateSharedJS() (string, string) {
+	fingerprintSymbols, _ := json.Marshal(constants.FingerprintSymbols)
 	jsConsts := fmt.Sprintf(constsJS,
 		constants.JSSessionKey,
 		constants.IVSize,
@@ -64,7 +79,20 @@ func GenerateSharedJS() (string, string) {
 		constants.MaxHintLen,
 		constants.MaxPassNoteLen,
 		constants.Argon2Iter,
-		constants.Argon2Mem)
+		constants.Argon2Mem,
+		constants.PBKDF2Iter,
+		constants.MaxArgon2Iter,
+		constants.MaxArgon2Mem,
+		constants.MaxPBKDF2Iter,
+		constants.KDFArgon2id,
+		constants.KDFPBKDF2,
+		constants.FileEncryptionV1,
+		constants.FileEncryptionV2,
+		constants.LatestFileEncryption,
+		constants.KeyTypeRSA,
+		constants.KeyTypeX25519,
+		constants.FingerprintLength,
+		fingerprintSymbols)
 
 	jsEndpoints := endpointsHeadJS
 	for apiEndpoint, varName := range endpoints.JSVarNameMap {
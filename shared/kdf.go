@@ -0,0 +1,93 @@
+package shared
+
+import (
+	"errors"
+	"yeetfile/shared/constants"
+)
+
+var InvalidKDFParamsErr = errors.New("invalid key derivation parameters")
+
+// LegacyKDFParams returns the parameters used to derive account keys before
+// they were stored per account
+func LegacyKDFParams() KDFParams {
+	return KDFParams{
+		Algorithm:  constants.KDFArgon2id,
+		Iterations: constants.Argon2Iter,
+		Memory:     constants.Argon2Mem,
+	}
+}
+
+// LegacySendKDFParams returns the parameters used to derive Send link keys
+// before they were stored with each Send upload
+func LegacySendKDFParams() KDFParams {
+	return KDFParams{
+		Algorithm:  constants.KDFPBKDF2,
+		Iterations: constants.PBKDF2Iter,
+	}
+}
+
+// SendKDFParams returns the parameters used to derive keys for new Send
+// uploads. Downloads use the parameters stored with each upload instead, so
+// these can be strengthened without breaking existing Send links.
+func SendKDFParams() KDFParams {
+	return LegacySendKDFParams()
+}
+
+// OrDefault returns the default parameters if the parameters are unset, which
+// is the case for requests from clients that predate stored KDF parameters
+func (p KDFParams) OrDefault(defaultParams KDFParams) KDFParams {
+	if len(p.Algorithm) == 0 {
+		return defaultParams
+	}
+
+	return p
+}
+
+// Validate ensures that the parameters use a supported algorithm, and are at
+// least as strong as the legacy parameters for that algorithm without being
+// so expensive that deriving a key would exhaust the client's resources
+func (p KDFParams) Validate() error {
+	switch p.Algorithm {
+	case constants.KDFArgon2id:
+		if p.Iterations < constants.Argon2Iter ||
+			p.Iterations > constants.MaxArgon2Iter ||
+			p.Memory < constants.Argon2Mem ||
+			p.Memory > constants.MaxArgon2Mem {
+			return InvalidKDFParamsErr
+		}
+	case constants.KDFPBKDF2:
+		if p.Iterations < constants.PBKDF2Iter ||
+			p.Iterations > constants.MaxPBKDF2Iter ||
+			p.Memory != 0 {
+			return InvalidKDFParamsErr
+		}
+	default:
+		return InvalidKDFParamsErr
+	}
+
+	return nil
+}
+
+// Validated fills in the parameters for servers that predate stored KDF
+// parameters, and ensures that the resulting parameters are valid so that a
+// server can't weaken the keys derived by the client
+func (r KDFResponse) Validated() (KDFResponse, error) {
+	r.KDF = r.KDF.OrDefault(LegacyKDFParams())
+	r.RecommendedKDF = r.RecommendedKDF.OrDefault(r.KDF)
+	if r.KDF.Validate() != nil || r.RecommendedKDF.Validate() != nil {
+		return KDFResponse{}, InvalidKDFParamsErr
+	}
+
+	return r, nil
+}
+
+// IsWeakerThan returns true if keys derived with the other parameters would
+// be stronger, meaning that keys derived with these parameters should be
+// upgraded
+func (p KDFParams) IsWeakerThan(other KDFParams) bool {
+	if p.Algorithm != other.Algorithm {
+		return other.Algorithm == constants.KDFArgon2id
+	}
+
+	return p.Iterations < other.Iterations || p.Memory < other.Memory
+}
@@ -10,35 +10,159 @@ type AccountResponse struct {
 	PaymentID        string    `json:"paymentID"`
 	HasPasswordHint  bool      `json:"hasPasswordHint"`
 	Has2FA           bool      `json:"has2FA"`
+	HasRecoveryKey   bool      `json:"hasRecoveryKey"`
 	StorageAvailable int64     `json:"storageAvailable"`
 	StorageUsed      int64     `json:"storageUsed"`
 	SendAvailable    int64     `json:"sendAvailable"`
 	SendUsed         int64     `json:"sendUsed"`
 	UpgradeExp       time.Time `json:"upgradeExp" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+
+	UsageWarnings []UsageWarning `json:"usageWarnings"`
 }
 
 type UsageResponse struct {
-	StorageAvailable int64 `json:"storageAvailable"`
-	StorageUsed      int64 `json:"storageUsed"`
-	SendAvailable    int64 `json:"sendAvailable"`
-	SendUsed         int64 `json:"sendUsed"`
+	StorageAvailable   int64 `json:"storageAvailable"`
+	StorageUsed        int64 `json:"storageUsed"`
+	SendAvailable      int64 `json:"sendAvailable"`
+	SendUsed           int64 `json:"sendUsed"`
+	BandwidthAvailable int64 `json:"bandwidthAvailable"`
+	BandwidthUsed      int64 `json:"bandwidthUsed"`
+
+	UsageWarnings []UsageWarning `json:"usageWarnings"`
+}
+
+type UsageWarning struct {
+	Type      string `json:"type"`
+	Threshold int    `json:"threshold"`
+	Used      int64  `json:"used"`
+	Available int64  `json:"available"`
+}
+
+type BillingItem struct {
+	Tag      string `json:"tag"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+type BillingPurchase struct {
+	ID         string        `json:"id"`
+	Provider   string        `json:"provider"`
+	Items      []BillingItem `json:"items"`
+	Amount     int64         `json:"amount"`
+	Refunded   int64         `json:"refunded"`
+	Status     string        `json:"status"`
+	Date       time.Time     `json:"date" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Expiration time.Time     `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type RedeemVoucherRequest struct {
+	Code string `json:"code"`
+}
+
+type RedeemVoucherResponse struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+type BillingHistoryResponse struct {
+	Purchases []BillingPurchase `json:"purchases"`
+}
+
+type Organization struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Role             string    `json:"role"`
+	StorageAvailable int64     `json:"storageAvailable"`
+	StorageUsed      int64     `json:"storageUsed"`
+	SendAvailable    int64     `json:"sendAvailable"`
+	SendUsed         int64     `json:"sendUsed"`
+	UpgradeExp       time.Time `json:"upgradeExp" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type OrgMember struct {
+	UserID      string    `json:"userID"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	StorageUsed int64     `json:"storageUsed"`
+	StorageCap  int64     `json:"storageCap"`
+	SendUsed    int64     `json:"sendUsed"`
+	SendCap     int64     `json:"sendCap"`
+	Joined      time.Time `json:"joined" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type OrgInvite struct {
+	ID      string    `json:"id"`
+	OrgName string    `json:"orgName"`
+	Email   string    `json:"email"`
+	Role    string    `json:"role"`
+	Created time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type OrgResponse struct {
+	HasOrg         bool         `json:"hasOrg"`
+	Organization   Organization `json:"organization"`
+	Members        []OrgMember  `json:"members"`
+	SentInvites    []OrgInvite  `json:"sentInvites"`
+	PendingInvites []OrgInvite  `json:"pendingInvites"`
+}
+
+type CreateOrgRequest struct {
+	Name string `json:"name"`
+}
+
+type OrgInviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type OrgMemberUpdate struct {
+	Role       string `json:"role"`
+	StorageCap int64  `json:"storageCap"`
+	SendCap    int64  `json:"sendCap"`
+}
+
+type EmergencyContact struct {
+	UserID      string    `json:"userID"`
+	Email       string    `json:"email"`
+	WaitDays    int       `json:"waitDays"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requestedAt" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	GrantDate   time.Time `json:"grantDate" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type EmergencyAccessResponse struct {
+	Contacts []EmergencyContact `json:"contacts"`
+	Grantors []EmergencyContact `json:"grantors"`
+}
+
+type NewEmergencyContact struct {
+	Identifier   string `json:"identifier"`
+	WaitDays     int    `json:"waitDays"`
+	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type EmergencyKeyResponse struct {
+	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
 }
 
 type UploadMetadata struct {
-	Name       string `json:"name"`
-	Chunks     int    `json:"chunks"`
-	Size       int64  `json:"size"`
-	Downloads  int    `json:"downloads"`
-	Expiration string `json:"expiration"`
+	Name              string    `json:"name"`
+	Chunks            int       `json:"chunks"`
+	Size              int64     `json:"size"`
+	Downloads         int       `json:"downloads"`
+	Expiration        string    `json:"expiration"`
+	EncryptionVersion int       `json:"encryptionVersion"`
+	KDF               KDFParams `json:"kdf"`
 }
 
 type VaultUpload struct {
-	Name         string `json:"name"`
-	Length       int64  `json:"length"`
-	Chunks       int    `json:"chunks"`
-	FolderID     string `json:"folderID"`
-	ProtectedKey []byte `json:"protectedKey"`
-	PasswordData []byte `json:"passwordData"`
+	Name              string `json:"name"`
+	Length            int64  `json:"length"`
+	Chunks            int    `json:"chunks"`
+	FolderID          string `json:"folderID"`
+	ProtectedKey      []byte `json:"protectedKey"`
+	PasswordData      []byte `json:"passwordData"`
+	EncryptionVersion int    `json:"encryptionVersion"`
 }
 
 type ModifyVaultItem struct {
@@ -116,39 +240,56 @@ type VaultFolderResponse struct {
 }
 
 type VaultDownloadResponse struct {
-	Name         string `json:"name"`
-	ID           string `json:"id"`
-	Size         int64  `json:"size"`
-	Chunks       int    `json:"chunks"`
-	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	PasswordData []byte `json:"passwordData" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Name              string `json:"name"`
+	ID                string `json:"id"`
+	Size              int64  `json:"size"`
+	Chunks            int    `json:"chunks"`
+	ProtectedKey      []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PasswordData      []byte `json:"passwordData" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	EncryptionVersion int    `json:"encryptionVersion"`
 }
 
 type TextUpload struct {
-	Name       string `json:"name"`
-	Salt       []byte `json:"salt" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	Downloads  int    `json:"downloads"`
-	Expiration string `json:"expiration"`
-	Text       []byte `json:"text" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Name       string    `json:"name"`
+	Salt       []byte    `json:"salt" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Downloads  int       `json:"downloads"`
+	Expiration string    `json:"expiration"`
+	Text       []byte    `json:"text" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KDF        KDFParams `json:"kdf"`
 }
 
 type DownloadResponse struct {
-	Name       string    `json:"name"`
-	ID         string    `json:"id"`
-	Size       int64     `json:"size"`
-	Chunks     int       `json:"chunks"`
-	Downloads  int       `json:"downloads"`
-	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Name              string    `json:"name"`
+	ID                string    `json:"id"`
+	Size              int64     `json:"size"`
+	Chunks            int       `json:"chunks"`
+	Downloads         int       `json:"downloads"`
+	Expiration        time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	EncryptionVersion int       `json:"encryptionVersion"`
+	KDF               KDFParams `json:"kdf"`
+}
+
+type KDFParams struct {
+	Algorithm  string `json:"algorithm"`
+	Iterations uint32 `json:"iterations"`
+	Memory     uint32 `json:"memory"`
+}
+
+type KDFResponse struct {
+	KDF            KDFParams `json:"kdf"`
+	RecommendedKDF KDFParams `json:"recommendedKdf"`
 }
 
 type Signup struct {
-	Identifier              string `json:"identifier"`
-	LoginKeyHash            []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	PublicKey               []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedPrivateKey     []byte `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedVaultFolderKey []byte `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	PasswordHint            string `json:"passwordHint"`
-	ServerPassword          string `json:"serverPassword"`
+	Identifier              string      `json:"identifier"`
+	LoginKeyHash            []byte      `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey               []byte      `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedPrivateKey     []byte      `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedVaultFolderKey []byte      `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PasswordHint            string      `json:"passwordHint"`
+	ServerPassword          string      `json:"serverPassword"`
+	KDF                     KDFParams   `json:"kdf"`
+	RecoveryKey             RecoveryKey `json:"recoveryKey"`
 }
 
 type SignupResponse struct {
@@ -158,12 +299,14 @@ type SignupResponse struct {
 }
 
 type VerifyAccount struct {
-	ID                      string `json:"id"`
-	Code                    string `json:"code"`
-	LoginKeyHash            []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	PublicKey               []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedPrivateKey     []byte `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedVaultFolderKey []byte `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ID                      string      `json:"id"`
+	Code                    string      `json:"code"`
+	LoginKeyHash            []byte      `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey               []byte      `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedPrivateKey     []byte      `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedVaultFolderKey []byte      `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KDF                     KDFParams   `json:"kdf"`
+	RecoveryKey             RecoveryKey `json:"recoveryKey"`
 }
 
 type Login struct {
@@ -173,8 +316,48 @@ type Login struct {
 }
 
 type LoginResponse struct {
-	PublicKey    []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey    []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Keys         []UserKey `json:"keys"`
+}
+
+type UserKey struct {
+	Type                 string `json:"type"`
+	PublicKey            []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey         []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	RecoveryProtectedKey []byte `json:"recoveryProtectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type UserPublicKey struct {
+	Type      string `json:"type"`
+	PublicKey []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type WrappedKey struct {
+	ID           string `json:"id"`
+	IsFolder     bool   `json:"isFolder"`
 	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	SharedBy     string `json:"sharedBy"`
+}
+
+type WrappedKeysResponse struct {
+	Keys []WrappedKey `json:"keys"`
+}
+
+type KeyRotation struct {
+	LoginKeyHash []byte       `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey    []byte       `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey []byte       `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Keys         []UserKey    `json:"keys"`
+	WrappedKeys  []WrappedKey `json:"wrappedKeys"`
+	RemovedKeys  []WrappedKey `json:"removedKeys"`
+
+	RecoveryProtectedKey []byte `json:"recoveryProtectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ContactsProtectedKey []byte `json:"contactsProtectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type KeyRotationResponse struct {
+	Removed int `json:"removed"`
 }
 
 type SessionInfo struct {
@@ -185,6 +368,47 @@ type ForgotPassword struct {
 	Email string `json:"email"`
 }
 
+type RecoveryKey struct {
+	KeyHash      []byte    `json:"keyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey    []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Keys         []UserKey `json:"keys"`
+}
+
+type SetRecoveryKey struct {
+	LoginKeyHash []byte      `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	RecoveryKey  RecoveryKey `json:"recoveryKey"`
+}
+
+type RemoveRecoveryKey struct {
+	LoginKeyHash []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type RecoveryStatus struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type RecoveryKeysRequest struct {
+	Identifier string `json:"identifier"`
+	KeyHash    []byte `json:"keyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type RecoveryKeysResponse struct {
+	ProtectedKey []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Keys         []UserKey `json:"keys"`
+	KDF          KDFParams `json:"kdf"`
+}
+
+type RecoverAccount struct {
+	Identifier      string    `json:"identifier"`
+	KeyHash         []byte    `json:"keyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	NewLoginKeyHash []byte    `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey    []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKeys   []UserKey `json:"protectedKeys"`
+	KDF             KDFParams `json:"kdf"`
+}
+
 type VerifyEmail struct {
 	Email string `json:"email"`
 	Code  string `json:"code"`
@@ -202,11 +426,24 @@ type ChangePasswordHint struct {
 }
 
 type PubKeyResponse struct {
-	PublicKey []byte `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey []byte          `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Keys      []UserPublicKey `json:"keys"`
 }
 
-type ProtectedKeyResponse struct {
+type ContactList struct {
 	ProtectedKey []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Contacts     []byte `json:"contacts" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+}
+
+type PinnedContact struct {
+	Identifier  string    `json:"identifier"`
+	Fingerprint []byte    `json:"fingerprint" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Verified    time.Time `json:"verified" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type ProtectedKeyResponse struct {
+	ProtectedKey []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Keys         []UserKey `json:"keys"`
 }
 
 type ShareItemRequest struct {
@@ -260,16 +497,20 @@ type StartEmailChangeResponse struct {
 }
 
 type ChangeEmail struct {
-	NewEmail        string `json:"newEmail"`
-	OldLoginKeyHash []byte `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	NewLoginKeyHash []byte `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedKey    []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	NewEmail        string    `json:"newEmail"`
+	OldLoginKeyHash []byte    `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	NewLoginKeyHash []byte    `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey    []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKeys   []UserKey `json:"protectedKeys"`
+	KDF             KDFParams `json:"kdf"`
 }
 
 type ChangePassword struct {
-	OldLoginKeyHash []byte `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	NewLoginKeyHash []byte `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
-	ProtectedKey    []byte `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	OldLoginKeyHash []byte    `json:"oldLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	NewLoginKeyHash []byte    `json:"newLoginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey    []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKeys   []UserKey `json:"protectedKeys"`
+	KDF             KDFParams `json:"kdf"`
 }
 
 type NewTOTP struct {
@@ -297,8 +538,14 @@ type ServerInfo struct {
 	BillingEnabled     bool   `json:"billingEnabled"`
 	StripeEnabled      bool   `json:"stripeEnabled"`
 	BTCPayEnabled      bool   `json:"btcPayEnabled"`
+	ManualEnabled      bool   `json:"manualEnabled"`
+	InvitesAllowed     bool   `json:"invitesAllowed"`
+	ApprovalRequired   bool   `json:"approvalRequired"`
+	OIDCEnabled        bool   `json:"oidcEnabled"`
+	OIDCRequired       bool   `json:"oidcRequired"`
 	DefaultStorage     int64  `json:"defaultStorage"`
 	DefaultSend        int64  `json:"defaultSend"`
+	FileEncryption     []int  `json:"fileEncryption"`
 
 	Upgrades      Upgrades   `json:"upgrades"`
 	MonthUpgrades []*Upgrade `json:"monthUpgrades"`
@@ -328,6 +575,7 @@ type Upgrade struct {
 	Bytes       int64  `json:"bytes"`
 	Annual      bool   `json:"annual,omitempty"`
 	BTCPayLink  string `json:"btcpay_link"`
+	StripeID    string `json:"stripe_product_id,omitempty"`
 
 	ReadableBytes  string
 	IsVaultUpgrade bool
@@ -363,14 +611,129 @@ type AdminUserInfoResponse struct {
 	StorageAvailable int64  `json:"storageAvailable"`
 	SendUsed         int64  `json:"sendUsed"`
 	SendAvailable    int64  `json:"sendAvailable"`
+	InviteAllowance  int    `json:"inviteAllowance"`
+	Suspended        bool   `json:"suspended"`
+	SuspendedReason  string `json:"suspendedReason"`
 
 	Files []AdminFileInfoResponse `json:"files"`
 }
 
+type AdminSuspendAction struct {
+	Reason string `json:"reason"`
+}
+
 type AdminUserAction struct {
 	ID               string `json:"id"`
 	StorageAvailable int64  `json:"storageAvailable"`
 	SendAvailable    int64  `json:"sendAvailable"`
+	InviteAllowance  int    `json:"inviteAllowance"`
+}
+
+type AdminUserListItem struct {
+	ID               string    `json:"id"`
+	Email            string    `json:"email"`
+	StorageUsed      int64     `json:"storageUsed"`
+	StorageAvailable int64     `json:"storageAvailable"`
+	SendUsed         int64     `json:"sendUsed"`
+	SendAvailable    int64     `json:"sendAvailable"`
+	Has2FA           bool      `json:"has2FA"`
+	Created          time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	LastLogin        time.Time `json:"lastLogin" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Suspended        bool      `json:"suspended"`
+}
+
+type AdminUserListResponse struct {
+	Users    []AdminUserListItem `json:"users"`
+	Total    int                 `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+}
+
+type AdminBulkUserAction struct {
+	IDs              []string `json:"ids"`
+	Action           string   `json:"action"`
+	StorageAvailable int64    `json:"storageAvailable"`
+	SendAvailable    int64    `json:"sendAvailable"`
+}
+
+type AdminBulkUserActionResponse struct {
+	Failed []string `json:"failed"`
+}
+
+type AdminStatsResponse struct {
+	Days        []AdminStatsDay     `json:"days"`
+	SizeBuckets []AdminStatsBucket  `json:"sizeBuckets"`
+	Revenue     []AdminStatsRevenue `json:"revenue"`
+}
+
+type AdminStatsDay struct {
+	Date        time.Time `json:"date" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	TotalUsers  int       `json:"totalUsers"`
+	ActiveUsers int       `json:"activeUsers"`
+	VaultBytes  int64     `json:"vaultBytes"`
+	SendBytes   int64     `json:"sendBytes"`
+	Uploaded    int64     `json:"uploaded"`
+	Downloaded  int64     `json:"downloaded"`
+}
+
+type AdminStatsBucket struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type AdminStatsRevenue struct {
+	Tag       string `json:"tag"`
+	Purchases int    `json:"purchases"`
+	Revenue   int64  `json:"revenue"`
+}
+
+type SendReport struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+type AdminReportEntry struct {
+	Reason  string    `json:"reason"`
+	Details string    `json:"details"`
+	Date    time.Time `json:"date" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type AdminReportResponse struct {
+	MetadataID string             `json:"metadataID"`
+	OwnerID    string             `json:"ownerID"`
+	Size       int64              `json:"size"`
+	Exists     bool               `json:"exists"`
+	Reports    []AdminReportEntry `json:"reports"`
+}
+
+type AdminReportAction struct {
+	SuspendUploader bool   `json:"suspendUploader"`
+	Reason          string `json:"reason"`
+}
+
+type AdminInvoice struct {
+	ID       string        `json:"id"`
+	Email    string        `json:"email"`
+	Provider string        `json:"provider"`
+	Items    []BillingItem `json:"items"`
+	Amount   int64         `json:"amount"`
+	Refunded int64         `json:"refunded"`
+	Status   string        `json:"status"`
+	Date     time.Time     `json:"date" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type AdminRefundAction struct {
+	Amount int64 `json:"amount"`
+}
+
+type AdminVoucher struct {
+	Code       string    `json:"code"`
+	Tag        string    `json:"tag"`
+	Quantity   int       `json:"quantity"`
+	MaxUses    int       `json:"maxUses"`
+	Uses       int       `json:"uses"`
+	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Created    time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
 }
 
 type AdminFileInfoResponse struct {
@@ -386,3 +749,127 @@ type AdminFileInfoResponse struct {
 type AdminInviteAction struct {
 	Emails []string `json:"emails"`
 }
+
+type AdminRoleEntry struct {
+	UserID    string    `json:"userID"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	GrantedBy string    `json:"grantedBy"`
+	Granted   time.Time `json:"granted" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type AdminAuditEntry struct {
+	ID       int       `json:"id"`
+	ActorID  string    `json:"actorID"`
+	TargetID string    `json:"targetID"`
+	Action   string    `json:"action"`
+	Role     string    `json:"role"`
+	Created  time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type AdminRolesResponse struct {
+	Roles          []AdminRoleEntry  `json:"roles"`
+	Audit          []AdminAuditEntry `json:"audit"`
+	AvailableRoles []string          `json:"availableRoles"`
+}
+
+type AdminRoleAction struct {
+	User string `json:"user"`
+	Role string `json:"role"`
+}
+
+type ServerSecretUsage struct {
+	KeyID   string `json:"keyID"`
+	Current bool   `json:"current"`
+	Values  int    `json:"values"`
+}
+
+type AdminServerSecretsResponse struct {
+	Keys        []ServerSecretUsage `json:"keys"`
+	Legacy      int                 `json:"legacy"`
+	Unknown     int                 `json:"unknown"`
+	ReEncrypted int                 `json:"reEncrypted"`
+	Failed      int                 `json:"failed"`
+}
+
+type InviteLink struct {
+	ID         string    `json:"id"`
+	Code       string    `json:"code,omitempty"`
+	CreatedBy  string    `json:"createdBy"`
+	MaxUses    int       `json:"maxUses"`
+	Uses       int       `json:"uses"`
+	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+	Created    time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type CreateInviteLink struct {
+	MaxUses    int       `json:"maxUses"`
+	Expiration time.Time `json:"expiration" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type AccountInvitesResponse struct {
+	Allowance int          `json:"allowance"`
+	Used      int          `json:"used"`
+	Links     []InviteLink `json:"links"`
+}
+
+type PendingSignup struct {
+	ID      string    `json:"id"`
+	Email   string    `json:"email"`
+	Created time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type EmailDomainRule struct {
+	Domain  string    `json:"domain"`
+	Rule    string    `json:"rule"`
+	Created time.Time `json:"created" ts_type:"Date" ts_transform:"new Date(__VALUE__)"`
+}
+
+type OIDCStartResponse struct {
+	ID      string `json:"id"`
+	Token   string `json:"token"`
+	AuthURL string `json:"authURL"`
+	Code    string `json:"code"`
+}
+
+type OIDCStatusRequest struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+type OIDCConfirm struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+}
+
+type OIDCStatusResponse struct {
+	Status                 string    `json:"status"`
+	Identifier             string    `json:"identifier"`
+	Error                  string    `json:"error"`
+	TwoFactorRequired      bool      `json:"twoFactorRequired"`
+	ServerPasswordRequired bool      `json:"serverPasswordRequired"`
+	PublicKey              []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedKey           []byte    `json:"protectedKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Keys                   []UserKey `json:"keys"`
+	KDF                    KDFParams `json:"kdf"`
+	RecommendedKDF         KDFParams `json:"recommendedKdf"`
+}
+
+type OIDCLink struct {
+	ID           string `json:"id"`
+	Token        string `json:"token"`
+	LoginKeyHash []byte `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	Code         string `json:"code"`
+}
+
+type OIDCSignup struct {
+	ID                      string    `json:"id"`
+	Token                   string    `json:"token"`
+	LoginKeyHash            []byte    `json:"loginKeyHash" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	PublicKey               []byte    `json:"publicKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedPrivateKey     []byte    `json:"protectedPrivateKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	ProtectedVaultFolderKey []byte    `json:"protectedVaultFolderKey" ts_type:"Uint8Array" ts_transform:"__VALUE__ ? base64ToArray(__VALUE__) : new Uint8Array()"`
+	KDF                     KDFParams `json:"kdf"`
+	ServerPassword          string    `json:"serverPassword"`
+}
@@ -236,3 +236,54 @@ func TrimEmptyLines(s string) string {
 
 	return strings.Join(lines[start:end], "\n")
 }
+
+// GetUsageThreshold returns the highest threshold percentage that the used
+// amount has reached out of the available amount. Returns 0 if no thresholds
+// have been reached, or if the available amount is unlimited/unset.
+func GetUsageThreshold(used, available int64, thresholds []int) int {
+	if available <= 0 || used <= 0 {
+		return 0
+	}
+
+	reached := 0
+	for _, threshold := range thresholds {
+		if used*100 >= available*int64(threshold) && threshold > reached {
+			reached = threshold
+		}
+	}
+
+	return reached
+}
+
+// FormatUsageWarning returns a readable message for a usage warning, which can
+// be displayed to the user in the web interface or the CLI.
+func FormatUsageWarning(warning UsageWarning) string {
+	label, action := "Usage", "uploads"
+	switch warning.Type {
+	case constants.UsageTypeStorage:
+		label = "Vault storage"
+	case constants.UsageTypeSend:
+		label = "Send"
+	case constants.UsageTypeBandwidth:
+		label, action = "Weekly vault bandwidth", "downloads"
+	}
+
+	return fmt.Sprintf("%s is over %d%% used (%s / %s) -- %s will "+
+		"fail once the limit is reached.",
+		label,
+		warning.Threshold,
+		ReadableFileSize(warning.Used),
+		ReadableFileSize(warning.Available),
+		action)
+}
+
+// FormatCents converts an amount in cents to a readable dollar amount
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
@@ -61,6 +61,8 @@ func main() {
 		Add(shared.VaultFolder{}).
 		Add(shared.VaultFolderResponse{}).
 		Add(shared.VaultDownloadResponse{}).
+		Add(shared.KDFParams{}).
+		Add(shared.KDFResponse{}).
 		Add(shared.TextUpload{}).
 		Add(shared.DownloadResponse{}).
 		Add(shared.Signup{}).
@@ -68,10 +70,24 @@ func main() {
 		Add(shared.VerifyAccount{}).
 		Add(shared.Login{}).
 		Add(shared.LoginResponse{}).
+		Add(shared.UserKey{}).
+		Add(shared.UserPublicKey{}).
+		Add(shared.WrappedKey{}).
+		Add(shared.WrappedKeysResponse{}).
+		Add(shared.KeyRotation{}).
+		Add(shared.KeyRotationResponse{}).
 		Add(shared.SessionInfo{}).
 		Add(shared.ForgotPassword{}).
+		Add(shared.RecoveryKey{}).
+		Add(shared.SetRecoveryKey{}).
+		Add(shared.RecoveryStatus{}).
+		Add(shared.RecoveryKeysRequest{}).
+		Add(shared.RecoveryKeysResponse{}).
+		Add(shared.RecoverAccount{}).
 		Add(shared.ResetPassword{}).
 		Add(shared.PubKeyResponse{}).
+		Add(shared.ContactList{}).
+		Add(shared.PinnedContact{}).
 		Add(shared.ShareItemRequest{}).
 		Add(shared.NewSharedItem{}).
 		Add(shared.FileOwnershipInfo{}).
@@ -92,8 +108,54 @@ func main() {
 		Add(shared.ItemIndex{}).
 		Add(shared.AdminUserInfoResponse{}).
 		Add(shared.AdminUserAction{}).
+		Add(shared.AdminSuspendAction{}).
+		Add(shared.AdminUserListItem{}).
+		Add(shared.AdminUserListResponse{}).
+		Add(shared.AdminBulkUserAction{}).
+		Add(shared.AdminBulkUserActionResponse{}).
+		Add(shared.AdminStatsResponse{}).
+		Add(shared.AdminStatsDay{}).
+		Add(shared.AdminStatsBucket{}).
+		Add(shared.AdminStatsRevenue{}).
+		Add(shared.SendReport{}).
+		Add(shared.AdminReportEntry{}).
+		Add(shared.AdminReportResponse{}).
+		Add(shared.AdminReportAction{}).
+		Add(shared.AdminInvoice{}).
+		Add(shared.AdminRefundAction{}).
+		Add(shared.AdminVoucher{}).
+		Add(shared.RedeemVoucherRequest{}).
+		Add(shared.RedeemVoucherResponse{}).
+		Add(shared.Organization{}).
+		Add(shared.OrgMember{}).
+		Add(shared.OrgInvite{}).
+		Add(shared.OrgResponse{}).
+		Add(shared.CreateOrgRequest{}).
+		Add(shared.OrgInviteRequest{}).
+		Add(shared.OrgMemberUpdate{}).
+		Add(shared.EmergencyContact{}).
+		Add(shared.EmergencyAccessResponse{}).
+		Add(shared.NewEmergencyContact{}).
+		Add(shared.EmergencyKeyResponse{}).
 		Add(shared.AdminFileInfoResponse{}).
 		Add(shared.AdminInviteAction{}).
+		Add(shared.AdminRoleEntry{}).
+		Add(shared.AdminAuditEntry{}).
+		Add(shared.AdminRolesResponse{}).
+		Add(shared.AdminRoleAction{}).
+		Add(shared.ServerSecretUsage{}).
+		Add(shared.AdminServerSecretsResponse{}).
+		Add(shared.InviteLink{}).
+		Add(shared.CreateInviteLink{}).
+		Add(shared.AccountInvitesResponse{}).
+		Add(shared.PendingSignup{}).
+		Add(shared.EmailDomainRule{}).
+		Add(shared.OIDCStartResponse{}).
+		Add(shared.OIDCStatusRequest{}).
+		Add(shared.OIDCConfirm{}).
+		Add(shared.OIDCStatusResponse{}).
+		Add(shared.OIDCLink{}).
+		Add(shared.OIDCSignup{}).
 		Add(shared.ServerInfo{})
 
 	converter.WithBackupDir("")
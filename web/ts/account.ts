@@ -50,6 +50,200 @@ const init = () => {
 
     let saveSettingsBtn = document.getElementById("save-settings-btn") as HTMLButtonElement;
     saveSettingsBtn.addEventListener("click", saveSettings);
+
+    let emailReceiptLinks = document.getElementsByClassName("email-receipt");
+    for (let i = 0; i < emailReceiptLinks.length; i++) {
+        let link = emailReceiptLinks[i] as HTMLAnchorElement;
+        link.addEventListener("click", () => {
+            emailReceipt(link.dataset.id);
+        });
+    }
+
+    setupOrganization();
+    setupInviteLinks();
+}
+
+const setupOrganization = () => {
+    let createOrgBtn = document.getElementById("create-org-btn");
+    if (createOrgBtn) {
+        createOrgBtn.addEventListener("click", createOrganization);
+    }
+
+    let inviteBtn = document.getElementById("invite-org-member-btn") as HTMLButtonElement;
+    if (inviteBtn) {
+        inviteBtn.addEventListener("click", () => {
+            inviteOrgMember(inviteBtn.dataset.owner === "true");
+        });
+    }
+
+    let leaveBtn = document.getElementById("leave-org-btn") as HTMLButtonElement;
+    if (leaveBtn) {
+        leaveBtn.addEventListener("click", () => {
+            leaveOrganization(leaveBtn.dataset.owner === "true");
+        });
+    }
+
+    let addLinkListeners = (className: string, fn: (link: HTMLAnchorElement) => void) => {
+        let links = document.getElementsByClassName(className);
+        for (let i = 0; i < links.length; i++) {
+            let link = links[i] as HTMLAnchorElement;
+            link.addEventListener("click", () => {
+                fn(link);
+            });
+        }
+    }
+
+    addLinkListeners("accept-org-invite", link => {
+        orgRequest(Endpoints.format(Endpoints.OrgInviteAction, link.dataset.id), "PUT");
+    });
+
+    addLinkListeners("decline-org-invite", link => {
+        if (confirm("Decline this invite?")) {
+            orgRequest(Endpoints.format(Endpoints.OrgInviteAction, link.dataset.id), "DELETE");
+        }
+    });
+
+    addLinkListeners("cancel-org-invite", link => {
+        if (confirm("Cancel this invite?")) {
+            orgRequest(Endpoints.format(Endpoints.OrgInviteAction, link.dataset.id), "DELETE");
+        }
+    });
+
+    addLinkListeners("edit-org-member", link => {
+        editOrgMember(link, leaveBtn && leaveBtn.dataset.owner === "true");
+    });
+
+    addLinkListeners("remove-org-member", link => {
+        if (confirm("Remove this member from the organization?")) {
+            orgRequest(Endpoints.format(Endpoints.OrgMember, link.dataset.id), "DELETE");
+        }
+    });
+}
+
+const createOrganization = () => {
+    let name = prompt("Organization name:");
+    if (!name) {
+        return;
+    }
+
+    let request = new interfaces.CreateOrgRequest();
+    request.name = name;
+    orgRequest(Endpoints.Organization.path, "POST", request);
+}
+
+const inviteOrgMember = (isOwner: boolean) => {
+    let email = prompt("Email address of the new member:");
+    if (!email) {
+        return;
+    }
+
+    let request = new interfaces.OrgInviteRequest();
+    request.email = email;
+    request.role = isOwner && confirm("Should this member be an admin?") ?
+        "admin" : "member";
+    orgRequest(Endpoints.OrgInvites.path, "POST", request);
+}
+
+const editOrgMember = (link: HTMLAnchorElement, isOwner: boolean) => {
+    const gb = 1000 * 1000 * 1000;
+    let promptCap = (label: string, current: number): number => {
+        let value = prompt(`${label} cap in GB (0 for no cap):`, String(current / gb));
+        if (value === null) {
+            return -1;
+        }
+
+        let cap = parseFloat(value);
+        if (isNaN(cap) || cap < 0) {
+            alert("Invalid cap");
+            return -1;
+        }
+
+        return Math.round(cap * gb);
+    }
+
+    let storageCap = promptCap("Vault", parseInt(link.dataset.storageCap));
+    if (storageCap < 0) {
+        return;
+    }
+
+    let sendCap = promptCap("Send", parseInt(link.dataset.sendCap));
+    if (sendCap < 0) {
+        return;
+    }
+
+    let update = new interfaces.OrgMemberUpdate();
+    update.role = link.dataset.role;
+    update.storageCap = storageCap;
+    update.sendCap = sendCap;
+
+    if (isOwner) {
+        update.role = confirm("Should this member be an admin?") ?
+            "admin" : "member";
+    }
+
+    orgRequest(Endpoints.format(Endpoints.OrgMember, link.dataset.id), "PUT", update);
+}
+
+const leaveOrganization = (isOwner: boolean) => {
+    let confirmMsg = isOwner ?
+        "Delete this organization? All other members must be removed first." :
+        "Leave this organization?";
+    if (confirm(confirmMsg)) {
+        orgRequest(Endpoints.Organization.path, "DELETE");
+    }
+}
+
+const orgRequest = (url: string, method: string, body?: object) => {
+    fetch(url, {
+        method: method,
+        headers: {
+            "Content-Type": "application/json"
+        },
+        body: body ? JSON.stringify(body) : undefined
+    }).then(async response => {
+        if (response.ok) {
+            window.location.reload();
+        } else {
+            showMessage("Error: " + await response.text(), true);
+        }
+    }).catch(() => {
+        alert("Request failed");
+    });
+}
+
+const setupInviteLinks = () => {
+    let createLinkBtn = document.getElementById("create-invite-link-btn");
+    if (createLinkBtn) {
+        createLinkBtn.addEventListener("click", createInviteLink);
+    }
+
+    let deleteLinks = document.getElementsByClassName("delete-invite-link");
+    for (let i = 0; i < deleteLinks.length; i++) {
+        let link = deleteLinks[i] as HTMLAnchorElement;
+        link.addEventListener("click", () => {
+            if (confirm("Delete this invite link?")) {
+                orgRequest(Endpoints.format(Endpoints.AccountInvite, link.dataset.id), "DELETE");
+            }
+        });
+    }
+}
+
+const createInviteLink = () => {
+    fetch(Endpoints.AccountInvites.path, {
+        method: "POST"
+    }).then(async response => {
+        if (!response.ok) {
+            showMessage("Error: " + await response.text(), true);
+            return;
+        }
+
+        let link = new interfaces.InviteLink(await response.json());
+        let url = `${window.location.origin}${Endpoints.HTMLSignup.path}?code=${link.code}`;
+        prompt("Invite link created (this link won't be shown again):", url);
+        window.location.reload();
+    }).catch(() => {
+        alert("Request failed");
+    });
 }
 
 const loadStoredSettings = () => {
@@ -274,6 +468,20 @@ const recyclePaymentID = () => {
     }
 }
 
+const emailReceipt = (invoiceID: string) => {
+    fetch(Endpoints.format(Endpoints.AccountReceipt, invoiceID), {
+        method: "POST",
+    }).then(async response => {
+        if (response.ok) {
+            showMessage("Your receipt has been sent to your email.", false);
+        } else {
+            showMessage("Error: " + await response.text(), true);
+        }
+    }).catch(() => {
+        alert("Request failed");
+    });
+}
+
 const changePassword = () => {
     window.location.assign(Endpoints.HTMLChangePassword.path);
 }
@@ -1,79 +1,1162 @@
 import {Endpoints} from "./endpoints.js";
 import {
+    AdminBulkUserAction,
+    AdminBulkUserActionResponse,
     AdminFileInfoResponse,
     AdminInviteAction,
+    AdminInvoice,
+    AdminRefundAction,
+    AdminReportAction,
+    AdminReportResponse,
+    AdminRoleAction,
+    AdminRoleEntry,
+    AdminRolesResponse,
+    AdminServerSecretsResponse,
+    AdminStatsResponse,
+    AdminSuspendAction,
     AdminUserAction,
-    AdminUserInfoResponse
+    AdminUserInfoResponse,
+    AdminUserListItem,
+    AdminUserListResponse,
+    AdminVoucher,
+    CreateInviteLink,
+    EmailDomainRule,
+    InviteLink,
+    PendingSignup,
+    Upgrade,
+    Upgrades
 } from "./interfaces.js";
 
+let userListPage = 0;
+
 const init = () => {
+    setupStats();
+    loadReports();
+    loadInvoices();
+    setupRefunds();
+    loadUpgrades();
+    setupUpgradeEditing();
+    loadVouchers();
+    setupVoucherCreation();
+    setupUserList();
     setupUserSearch();
     setupFileSearch();
     setupInviteSending();
     setupInviteRevoking();
+    loadInviteLinks();
+    setupInviteLinkCreation();
+    loadPendingSignups();
+    loadEmailDomains();
+    setupEmailDomainAdding();
+    loadRoles();
+    setupRoleGranting();
+    loadServerSecrets();
+    setupReEncryption();
+}
+
+// =============================================================================
+// Invite actions
+// =============================================================================
+const setupInviteSending = () => {
+    let inviteList = document.getElementById("invite-emails") as HTMLTextAreaElement;
+    if (!inviteList) {
+        // Invites not enabled by the server admin
+        return;
+    }
+
+    let sendInvitesBtn = document.getElementById("send-invites");
+    sendInvitesBtn.addEventListener("click", () => {
+        let invitesText = inviteList.value.replace(" ", "");
+        let invitesList = invitesText.split(",");
+        let invites = new AdminInviteAction();
+        invites.emails = invitesList;
+
+        fetch(Endpoints.AdminInviteActions.path, {
+            method: "POST",
+            body: JSON.stringify(invites)
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Error sending invites: " + await response.text());
+                return;
+            }
+
+            alert("Invite(s) sent!");
+            window.location.reload();
+        });
+    });
+}
+
+const setupInviteRevoking = () => {
+    let pendingInvites = document.getElementById("pending-invites") as HTMLSelectElement;
+    if (!pendingInvites) {
+        // Invites not enabled by the server admin
+        return;
+    }
+
+    let revokeInvitesBtn = document.getElementById("revoke-invites");
+    revokeInvitesBtn.addEventListener("click", () => {
+        let selectedValues = Array.from(pendingInvites.selectedOptions)
+            .map(option => option.value);
+        let invites = new AdminInviteAction();
+        invites.emails = selectedValues;
+
+        fetch(Endpoints.AdminInviteActions.path, {
+            method: "DELETE",
+            body: JSON.stringify(invites)
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Failed to revoke pending invites: " + await response.text());
+                return;
+            }
+
+            alert("Invite(s) revoked!");
+            window.location.reload();
+        });
+    });
+}
+
+const loadInviteLinks = () => {
+    let linksDiv = document.getElementById("invite-links-list");
+    if (!linksDiv) {
+        // Invites not enabled by the server admin
+        return;
+    }
+
+    fetch(Endpoints.AdminInviteLinks.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching invite links: " + await response.text());
+            return;
+        }
+
+        linksDiv.innerHTML = "";
+
+        let links = await response.json();
+        if (links.length === 0) {
+            linksDiv.innerText = "No invite links.";
+            return;
+        }
+
+        for (let i = 0; i < links.length; i++) {
+            let link = new InviteLink(links[i]);
+            linksDiv.appendChild(generateInviteLinkHTML(link));
+        }
+    });
+}
+
+const generateInviteLinkHTML = (link: InviteLink): HTMLDivElement => {
+    let linkDiv = document.createElement("div") as HTMLDivElement;
+    linkDiv.className = "bordered-box visible";
+
+    let expiration = "Never";
+    if (link.expiration.getFullYear() > 1) {
+        expiration = link.expiration.toLocaleString();
+    }
+
+    let linkInfo = document.createElement("code");
+    linkInfo.innerText = `ID: ${link.id}
+Created By: ${link.createdBy}
+Uses: ${link.uses} / ${link.maxUses}
+Expires: ${expiration}`;
+
+    let deleteButton = document.createElement("button");
+    deleteButton.className = "red-button";
+    deleteButton.innerText = "Delete";
+    deleteButton.addEventListener("click", () => {
+        if (!confirm(`Delete invite link ${link.id}?`)) {
+            return;
+        }
+
+        fetch(Endpoints.format(Endpoints.AdminInviteLink, link.id), {
+            method: "DELETE"
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Failed to delete invite link: " + await response.text());
+                return;
+            }
+
+            loadInviteLinks();
+        });
+    });
+
+    linkDiv.appendChild(linkInfo);
+    linkDiv.appendChild(document.createElement("br"));
+    linkDiv.appendChild(deleteButton);
+    return linkDiv;
+}
+
+const setupInviteLinkCreation = () => {
+    let createBtn = document.getElementById("create-invite-link") as HTMLButtonElement;
+    if (!createBtn) {
+        // Invites not enabled by the server admin
+        return;
+    }
+
+    createBtn.addEventListener("click", () => {
+        let uses = document.getElementById("invite-link-uses") as HTMLInputElement;
+        let expiration = document.getElementById("invite-link-expiration") as HTMLInputElement;
+
+        let options = new CreateInviteLink();
+        options.maxUses = parseInt(uses.value);
+        if (expiration.value) {
+            options.expiration = new Date(expiration.value);
+        }
+
+        fetch(Endpoints.AdminInviteLinks.path, {
+            method: "POST",
+            body: JSON.stringify(options)
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Error creating invite link: " + await response.text());
+                return;
+            }
+
+            let created = new InviteLink(await response.json());
+            let url = `${window.location.origin}${Endpoints.HTMLSignup.path}?code=${created.code}`;
+            prompt("Invite link created (this link won't be shown again):", url);
+            loadInviteLinks();
+        });
+    });
+}
+
+// =============================================================================
+// Signup approval and email domains
+// =============================================================================
+
+const loadPendingSignups = () => {
+    let signupsDiv = document.getElementById("pending-signups-list");
+    if (!signupsDiv) {
+        // Signup approval not required, or not available for the admin's role
+        return;
+    }
+
+    fetch(Endpoints.AdminSignups.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching pending signups: " + await response.text());
+            return;
+        }
+
+        signupsDiv.innerHTML = "";
+
+        let signups = await response.json();
+        if (signups.length === 0) {
+            signupsDiv.innerText = "No pending signups.";
+            return;
+        }
+
+        for (let i = 0; i < signups.length; i++) {
+            let signup = new PendingSignup(signups[i]);
+            signupsDiv.appendChild(generatePendingSignupHTML(signup));
+        }
+    });
+}
+
+const generatePendingSignupHTML = (signup: PendingSignup): HTMLDivElement => {
+    let signupDiv = document.createElement("div") as HTMLDivElement;
+    signupDiv.className = "bordered-box visible";
+
+    let signupInfo = document.createElement("code");
+    signupInfo.innerText = `ID: ${signup.id}
+Email: ${signup.email || "None"}
+Signed Up: ${signup.created.toLocaleString()}`;
+
+    let approveButton = document.createElement("button");
+    approveButton.className = "accent-btn";
+    approveButton.style.marginRight = "5px";
+    approveButton.innerText = "Approve";
+    approveButton.addEventListener("click", () => {
+        updatePendingSignup(signup.id, "POST");
+    });
+
+    let rejectButton = document.createElement("button");
+    rejectButton.className = "red-button";
+    rejectButton.innerText = "Reject";
+    rejectButton.addEventListener("click", () => {
+        if (!confirm("Rejecting this signup will delete the account. Do you wish to proceed?")) {
+            return;
+        }
+
+        updatePendingSignup(signup.id, "DELETE");
+    });
+
+    signupDiv.appendChild(signupInfo);
+    signupDiv.appendChild(document.createElement("br"));
+    signupDiv.appendChild(approveButton);
+    signupDiv.appendChild(rejectButton);
+    return signupDiv;
+}
+
+const updatePendingSignup = (userID: string, method: string) => {
+    fetch(Endpoints.format(Endpoints.AdminSignupAction, userID), {
+        method: method
+    }).then(async response => {
+        if (!response.ok) {
+            alert("Failed to update signup: " + await response.text());
+            return;
+        }
+
+        loadPendingSignups();
+    });
+}
+
+const loadEmailDomains = () => {
+    let domainsDiv = document.getElementById("email-domains-list");
+    if (!domainsDiv) {
+        return;
+    }
+
+    fetch(Endpoints.AdminEmailDomains.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching email domain rules: " + await response.text());
+            return;
+        }
+
+        domainsDiv.innerHTML = "";
+
+        let rules = await response.json();
+        if (rules.length === 0) {
+            domainsDiv.innerText = "No domain rules. Signups from any email domain are allowed.";
+            return;
+        }
+
+        for (let i = 0; i < rules.length; i++) {
+            let rule = new EmailDomainRule(rules[i]);
+            domainsDiv.appendChild(generateEmailDomainHTML(rule));
+        }
+    });
+}
+
+const generateEmailDomainHTML = (rule: EmailDomainRule): HTMLDivElement => {
+    let ruleDiv = document.createElement("div") as HTMLDivElement;
+    ruleDiv.className = "bordered-box visible";
+
+    let ruleInfo = document.createElement("code");
+    ruleInfo.innerText = `${rule.rule === "allow" ? "Allow" : "Deny"}: ${rule.domain}`;
+
+    let deleteButton = document.createElement("button");
+    deleteButton.className = "red-button";
+    deleteButton.innerText = "Remove";
+    deleteButton.addEventListener("click", () => {
+        fetch(Endpoints.format(Endpoints.AdminEmailDomain, rule.domain), {
+            method: "DELETE"
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Failed to remove domain rule: " + await response.text());
+                return;
+            }
+
+            loadEmailDomains();
+        });
+    });
+
+    ruleDiv.appendChild(ruleInfo);
+    ruleDiv.appendChild(document.createElement("br"));
+    ruleDiv.appendChild(deleteButton);
+    return ruleDiv;
+}
+
+const setupEmailDomainAdding = () => {
+    let addBtn = document.getElementById("add-email-domain") as HTMLButtonElement;
+    if (!addBtn) {
+        return;
+    }
+
+    addBtn.addEventListener("click", () => {
+        let domain = document.getElementById("email-domain") as HTMLInputElement;
+        let ruleSelect = document.getElementById("email-domain-rule") as HTMLSelectElement;
+
+        let rule = new EmailDomainRule();
+        rule.domain = domain.value;
+        rule.rule = ruleSelect.value;
+
+        fetch(Endpoints.AdminEmailDomains.path, {
+            method: "POST",
+            body: JSON.stringify(rule)
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Error adding domain rule: " + await response.text());
+                return;
+            }
+
+            domain.value = "";
+            loadEmailDomains();
+        });
+    });
+}
+
+// =============================================================================
+// Stats
+// =============================================================================
+
+const setupStats = () => {
+    let daysSelect = document.getElementById("stats-days") as HTMLSelectElement;
+    if (!daysSelect) {
+        // Section not available for the admin's role
+        return;
+    }
+
+    daysSelect.addEventListener("change", () => {
+        loadStats(daysSelect.value);
+    });
+
+    loadStats(daysSelect.value);
+}
+
+const loadStats = (days: string) => {
+    let params = new URLSearchParams({days: days});
+    fetch(`${Endpoints.AdminStats.path}?${params.toString()}`).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching stats: " + await response.text());
+            return;
+        }
+
+        let stats = new AdminStatsResponse(await response.json());
+        renderStats(stats);
+    }).catch((error: Error) => {
+        console.error(error);
+    });
+}
+
+const renderStats = (stats: AdminStatsResponse) => {
+    let summary = document.getElementById("stats-summary");
+    if (stats.days.length === 0) {
+        summary.innerText = "No stats have been collected yet.";
+    } else {
+        let latest = stats.days[stats.days.length - 1];
+        summary.innerText = `Total Users: ${latest.totalUsers}
+Active Users: ${latest.activeUsers}
+Vault Storage: ${calcFileSize(latest.vaultBytes)}
+Send Storage: ${calcFileSize(latest.sendBytes)}`;
+    }
+
+    renderBarChart(
+        document.getElementById("stats-transfer-chart"),
+        stats.days.map(day =>
+            `${day.date.toLocaleDateString()}: ` +
+            `${calcFileSize(day.uploaded)} up, ${calcFileSize(day.downloaded)} down`),
+        stats.days.map(day => day.uploaded + day.downloaded));
+
+    renderBarChart(
+        document.getElementById("stats-size-chart"),
+        stats.sizeBuckets.map(bucket => `${bucket.label}: ${bucket.count} file(s)`),
+        stats.sizeBuckets.map(bucket => bucket.count));
+
+    let revenueBody = document.getElementById("stats-revenue-body");
+    revenueBody.innerHTML = "";
+    for (let i = 0; i < stats.revenue.length; i++) {
+        let row = document.createElement("tr");
+        let columns = [
+            stats.revenue[i].tag,
+            String(stats.revenue[i].purchases),
+            `$${(stats.revenue[i].revenue / 100).toFixed(2)}`,
+        ];
+
+        for (let j = 0; j < columns.length; j++) {
+            let cell = document.createElement("td");
+            cell.innerText = columns[j];
+            row.appendChild(cell);
+        }
+
+        revenueBody.appendChild(row);
+    }
+}
+
+const renderBarChart = (chart: HTMLElement, labels: string[], values: number[]) => {
+    chart.innerHTML = "";
+
+    let maxValue = Math.max(...values, 1);
+    for (let i = 0; i < values.length; i++) {
+        let bar = document.createElement("div");
+        bar.className = "bar-chart-bar";
+        bar.style.height = `${(values[i] / maxValue) * 100}%`;
+        bar.title = labels[i];
+        chart.appendChild(bar);
+    }
+}
+
+// =============================================================================
+// Reports
+// =============================================================================
+
+const loadReports = () => {
+    let reportsDiv = document.getElementById("reports-list");
+    if (!reportsDiv) {
+        // Section not available for the admin's role
+        return;
+    }
+
+    fetch(Endpoints.AdminReports.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching reports: " + await response.text());
+            return;
+        }
+
+        reportsDiv.innerHTML = "";
+
+        let reports = await response.json();
+        if (reports.length === 0) {
+            reportsDiv.innerText = "No reported links.";
+            return;
+        }
+
+        for (let i = 0; i < reports.length; i++) {
+            let report = new AdminReportResponse(reports[i]);
+            reportsDiv.appendChild(generateReportHTML(report));
+        }
+    }).catch((error: Error) => {
+        console.error(error);
+    });
+}
+
+const generateReportHTML = (report: AdminReportResponse): HTMLDivElement => {
+    let reportDiv = document.createElement("div") as HTMLDivElement;
+    reportDiv.className = "bordered-box visible";
+
+    let reportInfo = document.createElement("code");
+    reportInfo.innerText = `File ID: ${report.metadataID}
+Uploader ID: ${report.ownerID || "None (anonymous)"}
+Size: ${report.exists ? calcFileSize(report.size) : "N/A (file no longer exists)"}
+Reports: ${report.reports.length}`;
+
+    for (let i = 0; i < report.reports.length; i++) {
+        let entry = report.reports[i];
+        reportInfo.innerText += `\n- [${entry.date.toLocaleString()}] ${entry.reason}` +
+            (entry.details ? `: ${entry.details}` : "");
+    }
+
+    reportDiv.appendChild(reportInfo);
+    reportDiv.appendChild(document.createElement("br"));
+
+    let dismissButton = document.createElement("button");
+    dismissButton.className = "accent-btn";
+    dismissButton.style.marginRight = "5px";
+    dismissButton.innerText = "Dismiss";
+    dismissButton.addEventListener("click", () => {
+        fetch(Endpoints.format(Endpoints.AdminReportActions, report.metadataID), {
+            method: "DELETE"
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Failed to dismiss reports: " + await response.text());
+                return;
+            }
+
+            loadReports();
+        });
+    });
+
+    reportDiv.appendChild(dismissButton);
+
+    if (report.exists) {
+        let takedownButton = document.createElement("button");
+        takedownButton.className = "red-button";
+        takedownButton.style.marginRight = "5px";
+        takedownButton.innerText = "Take Down Link";
+        takedownButton.addEventListener("click", () => {
+            takeDownReportedFile(report.metadataID, false);
+        });
+
+        reportDiv.appendChild(takedownButton);
+
+        if (report.ownerID) {
+            let suspendButton = document.createElement("button");
+            suspendButton.className = "red-button";
+            suspendButton.innerText = "Take Down and Suspend Uploader";
+            suspendButton.addEventListener("click", () => {
+                takeDownReportedFile(report.metadataID, true);
+            });
+
+            reportDiv.appendChild(suspendButton);
+        }
+    }
+
+    return reportDiv;
+}
+
+const takeDownReportedFile = (metadataID: string, suspendUploader: boolean) => {
+    let action = new AdminReportAction();
+    action.suspendUploader = suspendUploader;
+
+    if (suspendUploader) {
+        let reason = prompt("Enter a reason for the suspension (sent to the user):");
+        if (reason === null) {
+            return;
+        }
+
+        action.reason = reason;
+    } else if (!confirm("Taking down this link will permanently delete the file. Proceed?")) {
+        return;
+    }
+
+    fetch(Endpoints.format(Endpoints.AdminReportActions, metadataID), {
+        method: "POST",
+        body: JSON.stringify(action)
+    }).then(async response => {
+        if (!response.ok) {
+            alert("Failed to take down link: " + await response.text());
+            return;
+        }
+
+        alert("Link has been taken down!");
+        loadReports();
+    }).catch(error => {
+        alert("Failed to take down link");
+        console.error(error);
+    });
+}
+
+// =============================================================================
+// Invoices
+// =============================================================================
+
+const loadInvoices = () => {
+    let invoicesDiv = document.getElementById("invoices-list");
+    if (!invoicesDiv) {
+        return;
+    }
+
+    fetch(Endpoints.AdminInvoices.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching invoices: " + await response.text());
+            return;
+        }
+
+        invoicesDiv.innerHTML = "";
+
+        let invoices = await response.json();
+        if (invoices.length === 0) {
+            invoicesDiv.innerText = "No pending invoices.";
+            return;
+        }
+
+        for (let i = 0; i < invoices.length; i++) {
+            let invoice = new AdminInvoice(invoices[i]);
+            invoicesDiv.appendChild(generateInvoiceHTML(invoice));
+        }
+    });
+}
+
+const generateInvoiceHTML = (invoice: AdminInvoice): HTMLDivElement => {
+    let invoiceDiv = document.createElement("div") as HTMLDivElement;
+    invoiceDiv.className = "bordered-box visible";
+
+    let invoiceInfo = document.createElement("code");
+    invoiceInfo.innerText = `Invoice ID: ${invoice.id}
+Email: ${invoice.email || "None"}
+Date: ${invoice.date.toLocaleString()}
+Total: $${(invoice.amount / 100).toFixed(2)}`;
+
+    for (let i = 0; i < invoice.items.length; i++) {
+        let item = invoice.items[i];
+        invoiceInfo.innerText += `\n- ${item.name} (x${item.quantity})`;
+    }
+
+    invoiceDiv.appendChild(invoiceInfo);
+    invoiceDiv.appendChild(document.createElement("br"));
+
+    let paidButton = document.createElement("button");
+    paidButton.className = "accent-btn";
+    paidButton.style.marginRight = "5px";
+    paidButton.innerText = "Mark Paid";
+    paidButton.addEventListener("click", () => {
+        if (!confirm("Mark this invoice as paid and apply the upgrades?")) {
+            return;
+        }
+
+        updateInvoice(invoice.id, "POST", "Invoice has been marked as paid!");
+    });
+
+    let cancelButton = document.createElement("button");
+    cancelButton.className = "red-button";
+    cancelButton.innerText = "Cancel";
+    cancelButton.addEventListener("click", () => {
+        if (!confirm("Cancel this invoice?")) {
+            return;
+        }
+
+        updateInvoice(invoice.id, "DELETE", "Invoice has been canceled");
+    });
+
+    invoiceDiv.appendChild(paidButton);
+    invoiceDiv.appendChild(cancelButton);
+    return invoiceDiv;
+}
+
+const updateInvoice = (invoiceID: string, method: string, msg: string, body?: string) => {
+    fetch(Endpoints.format(Endpoints.AdminInvoiceAction, invoiceID), {
+        method: method,
+        body: body
+    }).then(async response => {
+        if (!response.ok) {
+            alert("Failed to update invoice: " + await response.text());
+            return;
+        }
+
+        alert(msg);
+        loadInvoices();
+    }).catch(error => {
+        alert("Failed to update invoice");
+        console.error(error);
+    });
+}
+
+const setupRefunds = () => {
+    let refundBtn = document.getElementById("refund-invoice") as HTMLButtonElement;
+    if (!refundBtn) {
+        return;
+    }
+
+    refundBtn.addEventListener("click", () => {
+        let idInput = document.getElementById("refund-invoice-id") as HTMLInputElement;
+        let amountInput = document.getElementById("refund-amount") as HTMLInputElement;
+
+        let amount = Math.round(parseFloat(amountInput.value) * 100);
+        if (!idInput.value || isNaN(amount) || amount <= 0) {
+            alert("Enter an invoice ID and a refund amount");
+            return;
+        } else if (!confirm(`Refund $${(amount / 100).toFixed(2)} for ${idInput.value}?`)) {
+            return;
+        }
+
+        let action = new AdminRefundAction();
+        action.amount = amount;
+
+        updateInvoice(idInput.value, "PUT", "Refund has been issued!", JSON.stringify(action));
+    });
+}
+
+// =============================================================================
+// Upgrade catalog
+// =============================================================================
+
+const bytesPerGB = 1000 * 1000 * 1000;
+
+const loadUpgrades = () => {
+    let upgradesDiv = document.getElementById("upgrades-list");
+    if (!upgradesDiv) {
+        // Section not available for the admin's role
+        return;
+    }
+
+    fetch(Endpoints.AdminUpgrades.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching upgrades: " + await response.text());
+            return;
+        }
+
+        upgradesDiv.innerHTML = "";
+
+        let catalog = new Upgrades(await response.json());
+        let allUpgrades = catalog.send_upgrades.concat(catalog.vault_upgrades);
+        if (allUpgrades.length === 0) {
+            upgradesDiv.innerText = "No upgrades.";
+            return;
+        }
+
+        for (let i = 0; i < allUpgrades.length; i++) {
+            upgradesDiv.appendChild(generateUpgradeHTML(allUpgrades[i]));
+        }
+    });
+}
+
+const generateUpgradeHTML = (upgrade: Upgrade): HTMLDivElement => {
+    let upgradeDiv = document.createElement("div") as HTMLDivElement;
+    upgradeDiv.className = "bordered-box visible";
+
+    let upgradeType = "Send";
+    if (upgrade.IsVaultUpgrade) {
+        upgradeType = upgrade.annual ? "Vault (Yearly)" : "Vault (Monthly)";
+    }
+
+    let upgradeInfo = document.createElement("code");
+    upgradeInfo.innerText = `Tag: ${upgrade.tag}
+Name: ${upgrade.name}
+Type: ${upgradeType}
+Price: $${upgrade.price}
+Size: ${calcFileSize(upgrade.bytes)}
+BTCPay: ${upgrade.btcpay_link || "None"}
+Stripe Product: ${upgrade.stripe_product_id || "None"}`;
+
+    let editButton = document.createElement("button");
+    editButton.className = "accent-btn";
+    editButton.style.marginRight = "5px";
+    editButton.innerText = "Edit";
+    editButton.addEventListener("click", () => {
+        setUpgradeForm(upgrade);
+    });
+
+    let deleteButton = document.createElement("button");
+    deleteButton.className = "red-button";
+    deleteButton.innerText = "Delete";
+    deleteButton.addEventListener("click", () => {
+        if (!confirm(`Delete upgrade ${upgrade.tag}? Existing purchases are not affected.`)) {
+            return;
+        }
+
+        fetch(Endpoints.format(Endpoints.AdminUpgradeAction, upgrade.tag), {
+            method: "DELETE"
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Failed to delete upgrade: " + await response.text());
+                return;
+            }
+
+            loadUpgrades();
+        });
+    });
+
+    upgradeDiv.appendChild(upgradeInfo);
+    upgradeDiv.appendChild(document.createElement("br"));
+    upgradeDiv.appendChild(editButton);
+    upgradeDiv.appendChild(deleteButton);
+    return upgradeDiv;
+}
+
+const setUpgradeForm = (upgrade: Upgrade) => {
+    (document.getElementById("upgrade-tag") as HTMLInputElement).value = upgrade.tag;
+    (document.getElementById("upgrade-name") as HTMLInputElement).value = upgrade.name;
+    (document.getElementById("upgrade-description") as HTMLTextAreaElement).value = upgrade.description;
+    (document.getElementById("upgrade-price") as HTMLInputElement).value = String(upgrade.price);
+    (document.getElementById("upgrade-size") as HTMLInputElement).value = String(upgrade.bytes / bytesPerGB);
+    (document.getElementById("upgrade-btcpay") as HTMLInputElement).value = upgrade.btcpay_link || "";
+    (document.getElementById("upgrade-stripe") as HTMLInputElement).value = upgrade.stripe_product_id || "";
+
+    let upgradeType = document.getElementById("upgrade-type") as HTMLSelectElement;
+    if (!upgrade.IsVaultUpgrade) {
+        upgradeType.value = "send";
+    } else {
+        upgradeType.value = upgrade.annual ? "vault-annual" : "vault";
+    }
+}
+
+const setupUpgradeEditing = () => {
+    let saveBtn = document.getElementById("save-upgrade") as HTMLButtonElement;
+    if (!saveBtn) {
+        // Section not available for the admin's role
+        return;
+    }
+
+    saveBtn.addEventListener("click", () => {
+        let upgradeType = (document.getElementById("upgrade-type") as HTMLSelectElement).value;
+        let size = parseFloat((document.getElementById("upgrade-size") as HTMLInputElement).value);
+
+        let upgrade = new Upgrade();
+        upgrade.tag = (document.getElementById("upgrade-tag") as HTMLInputElement).value;
+        upgrade.name = (document.getElementById("upgrade-name") as HTMLInputElement).value;
+        upgrade.description = (document.getElementById("upgrade-description") as HTMLTextAreaElement).value;
+        upgrade.price = parseInt((document.getElementById("upgrade-price") as HTMLInputElement).value);
+        upgrade.bytes = Math.round(size * bytesPerGB);
+        upgrade.btcpay_link = (document.getElementById("upgrade-btcpay") as HTMLInputElement).value;
+        upgrade.stripe_product_id = (document.getElementById("upgrade-stripe") as HTMLInputElement).value;
+        upgrade.IsVaultUpgrade = upgradeType !== "send";
+        upgrade.annual = upgradeType === "vault-annual";
+
+        fetch(Endpoints.AdminUpgrades.path, {
+            method: "POST",
+            body: JSON.stringify(upgrade)
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Error saving upgrade: " + await response.text());
+                return;
+            }
+
+            alert("Upgrade saved!");
+            loadUpgrades();
+        });
+    });
+}
+
+// =============================================================================
+// Vouchers
+// =============================================================================
+
+const loadVouchers = () => {
+    let vouchersDiv = document.getElementById("vouchers-list");
+    if (!vouchersDiv) {
+        return;
+    }
+
+    fetch(Endpoints.AdminVouchers.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching vouchers: " + await response.text());
+            return;
+        }
+
+        vouchersDiv.innerHTML = "";
+
+        let vouchers = await response.json();
+        if (vouchers.length === 0) {
+            vouchersDiv.innerText = "No vouchers.";
+            return;
+        }
+
+        for (let i = 0; i < vouchers.length; i++) {
+            let voucher = new AdminVoucher(vouchers[i]);
+            vouchersDiv.appendChild(generateVoucherHTML(voucher));
+        }
+    });
 }
 
-// =============================================================================
-// Invite actions
-// =============================================================================
-const setupInviteSending = () => {
-    let inviteList = document.getElementById("invite-emails") as HTMLTextAreaElement;
-    if (!inviteList) {
-        // Invites not enabled by the server admin
-        return;
+const generateVoucherHTML = (voucher: AdminVoucher): HTMLDivElement => {
+    let voucherDiv = document.createElement("div") as HTMLDivElement;
+    voucherDiv.className = "bordered-box visible";
+
+    let expiration = "Never";
+    if (voucher.expiration.getFullYear() > 1) {
+        expiration = voucher.expiration.toLocaleString();
     }
 
-    let sendInvitesBtn = document.getElementById("send-invites");
-    sendInvitesBtn.addEventListener("click", () => {
-        let invitesText = inviteList.value.replace(" ", "");
-        let invitesList = invitesText.split(",");
-        let invites = new AdminInviteAction();
-        invites.emails = invitesList;
+    let voucherInfo = document.createElement("code");
+    voucherInfo.innerText = `Code: ${voucher.code}
+Upgrade: ${voucher.tag} (x${voucher.quantity})
+Uses: ${voucher.uses} / ${voucher.maxUses}
+Expires: ${expiration}`;
 
-        fetch(Endpoints.AdminInviteActions.path, {
-            method: "POST",
-            body: JSON.stringify(invites)
+    let deleteButton = document.createElement("button");
+    deleteButton.className = "red-button";
+    deleteButton.innerText = "Delete";
+    deleteButton.addEventListener("click", () => {
+        if (!confirm(`Delete voucher ${voucher.code}?`)) {
+            return;
+        }
+
+        fetch(Endpoints.format(Endpoints.AdminVoucherAction, voucher.code), {
+            method: "DELETE"
         }).then(async response => {
             if (!response.ok) {
-                alert("Error sending invites: " + await response.text());
+                alert("Failed to delete voucher: " + await response.text());
                 return;
             }
 
-            alert("Invite(s) sent!");
-            window.location.reload();
+            loadVouchers();
         });
     });
+
+    voucherDiv.appendChild(voucherInfo);
+    voucherDiv.appendChild(document.createElement("br"));
+    voucherDiv.appendChild(deleteButton);
+    return voucherDiv;
 }
 
-const setupInviteRevoking = () => {
-    let pendingInvites = document.getElementById("pending-invites") as HTMLSelectElement;
-    if (!pendingInvites) {
-        // Invites not enabled by the server admin
+const setupVoucherCreation = () => {
+    let createBtn = document.getElementById("create-voucher") as HTMLButtonElement;
+    if (!createBtn) {
         return;
     }
 
-    let revokeInvitesBtn = document.getElementById("revoke-invites");
-    revokeInvitesBtn.addEventListener("click", () => {
-        let selectedValues = Array.from(pendingInvites.selectedOptions)
-            .map(option => option.value);
-        let invites = new AdminInviteAction();
-        invites.emails = selectedValues;
+    createBtn.addEventListener("click", () => {
+        let tag = document.getElementById("voucher-tag") as HTMLSelectElement;
+        let quantity = document.getElementById("voucher-quantity") as HTMLInputElement;
+        let uses = document.getElementById("voucher-uses") as HTMLInputElement;
+        let expiration = document.getElementById("voucher-expiration") as HTMLInputElement;
+        let code = document.getElementById("voucher-code") as HTMLInputElement;
 
-        fetch(Endpoints.AdminInviteActions.path, {
-            method: "DELETE",
-            body: JSON.stringify(invites)
+        let voucher = new AdminVoucher();
+        voucher.code = code.value;
+        voucher.tag = tag.value;
+        voucher.quantity = parseInt(quantity.value);
+        voucher.maxUses = parseInt(uses.value);
+        if (expiration.value) {
+            voucher.expiration = new Date(expiration.value);
+        }
+
+        fetch(Endpoints.AdminVouchers.path, {
+            method: "POST",
+            body: JSON.stringify(voucher)
         }).then(async response => {
             if (!response.ok) {
-                alert("Failed to revoke pending invites: " + await response.text());
+                alert("Error creating voucher: " + await response.text());
                 return;
             }
 
-            alert("Invite(s) revoked!");
-            window.location.reload();
+            let created = new AdminVoucher(await response.json());
+            alert(`Voucher created: ${created.code}`);
+            code.value = "";
+            loadVouchers();
         });
     });
 }
 
+// =============================================================================
+// User list
+// =============================================================================
+
+const setupUserList = () => {
+    let filterBtn = document.getElementById("user-list-btn");
+    let prevBtn = document.getElementById("user-list-prev") as HTMLButtonElement;
+    let nextBtn = document.getElementById("user-list-next") as HTMLButtonElement;
+    let selectAll = document.getElementById("user-list-select-all") as HTMLInputElement;
+    let bulkActionBtn = document.getElementById("bulk-action-btn");
+    if (!filterBtn) {
+        // Section not available for the admin's role
+        return;
+    }
+
+
+    filterBtn.addEventListener("click", () => {
+        userListPage = 0;
+        loadUserList();
+    });
+
+    prevBtn.addEventListener("click", () => {
+        userListPage = Math.max(userListPage - 1, 0);
+        loadUserList();
+    });
+
+    nextBtn.addEventListener("click", () => {
+        userListPage += 1;
+        loadUserList();
+    });
+
+    selectAll.addEventListener("change", () => {
+        let checkboxes = document.querySelectorAll<HTMLInputElement>(".user-list-checkbox");
+        checkboxes.forEach(checkbox => checkbox.checked = selectAll.checked);
+    });
+
+    bulkActionBtn.addEventListener("click", () => {
+        performBulkAction();
+    });
+
+    loadUserList();
+}
+
+const loadUserList = () => {
+    let search = (document.getElementById("user-list-search") as HTMLInputElement).value;
+    let sort = (document.getElementById("user-list-sort") as HTMLSelectElement).value;
+    let order = (document.getElementById("user-list-order") as HTMLSelectElement).value;
+
+    let params = new URLSearchParams({
+        q: search,
+        sort: sort,
+        order: order,
+        page: String(userListPage),
+    });
+
+    fetch(`${Endpoints.AdminUsers.path}?${params.toString()}`).then(async response => {
+        if (!response.ok) {
+            alert("Error fetching users: " + await response.text());
+            return;
+        }
+
+        let userList = new AdminUserListResponse(await response.json());
+        renderUserList(userList);
+    }).catch((error: Error) => {
+        alert("Error fetching users");
+        console.error(error);
+    });
+}
+
+const renderUserList = (userList: AdminUserListResponse) => {
+    let tableBody = document.getElementById("user-list-body");
+    tableBody.innerHTML = "";
+
+    (document.getElementById("user-list-select-all") as HTMLInputElement).checked = false;
+
+    for (let i = 0; i < userList.users.length; i++) {
+        tableBody.appendChild(generateUserRow(userList.users[i]));
+    }
+
+    let totalPages = Math.max(Math.ceil(userList.total / userList.pageSize), 1);
+    let pageLabel = document.getElementById("user-list-page");
+    pageLabel.innerText = `Page ${userList.page + 1} of ${totalPages} (${userList.total} users)`;
+
+    let prevBtn = document.getElementById("user-list-prev") as HTMLButtonElement;
+    let nextBtn = document.getElementById("user-list-next") as HTMLButtonElement;
+    prevBtn.disabled = userList.page <= 0;
+    nextBtn.disabled = userList.page + 1 >= totalPages;
+}
+
+const generateUserRow = (user: AdminUserListItem): HTMLTableRowElement => {
+    let row = document.createElement("tr");
+
+    let checkbox = document.createElement("input");
+    checkbox.type = "checkbox";
+    checkbox.className = "user-list-checkbox";
+    checkbox.value = user.id;
+
+    let checkboxCell = document.createElement("td");
+    checkboxCell.appendChild(checkbox);
+    row.appendChild(checkboxCell);
+
+    let columns = [
+        user.id,
+        user.email,
+        `${calcFileSize(user.storageUsed)} / ${calcFileSize(user.storageAvailable)}`,
+        `${calcFileSize(user.sendUsed)} / ${calcFileSize(user.sendAvailable)}`,
+        user.has2FA ? "Yes" : "No",
+        user.suspended ? "Suspended" : "Active",
+        formatListDate(user.created),
+        formatListDate(user.lastLogin),
+    ];
+
+    for (let i = 0; i < columns.length; i++) {
+        let cell = document.createElement("td");
+        cell.innerText = columns[i];
+        row.appendChild(cell);
+    }
+
+    return row;
+}
+
+const formatListDate = (date: Date): string => {
+    if (date.getFullYear() <= 1) {
+        return "Never";
+    }
+
+    return date.toLocaleDateString();
+}
+
+const performBulkAction = () => {
+    let checkboxes = document.querySelectorAll<HTMLInputElement>(".user-list-checkbox:checked");
+    let userIDs = Array.from(checkboxes).map(checkbox => checkbox.value);
+    if (userIDs.length === 0) {
+        alert("No users selected");
+        return;
+    }
+
+    let actionSelect = document.getElementById("bulk-action") as HTMLSelectElement;
+    let action = new AdminBulkUserAction();
+    action.ids = userIDs;
+    action.action = actionSelect.value;
+
+    if (action.action === "quota") {
+        let storageInput = document.getElementById("bulk-storage") as HTMLInputElement;
+        let sendInput = document.getElementById("bulk-send") as HTMLInputElement;
+        if (isNaN(storageInput.valueAsNumber) || isNaN(sendInput.valueAsNumber)) {
+            alert("Storage and send values are required");
+            return;
+        }
+
+        action.storageAvailable = storageInput.valueAsNumber;
+        action.sendAvailable = sendInput.valueAsNumber;
+    }
+
+    let actionLabel = actionSelect.selectedOptions[0].innerText;
+    if (!confirm(`Apply "${actionLabel}" to ${userIDs.length} user(s)?`)) {
+        return;
+    }
+
+    fetch(Endpoints.AdminUsers.path, {
+        method: "POST",
+        body: JSON.stringify(action)
+    }).then(async response => {
+        if (!response.ok) {
+            alert("Error performing action: " + await response.text());
+            return;
+        }
+
+        let result = new AdminBulkUserActionResponse(await response.json());
+        if (result.failed.length > 0) {
+            alert("Action failed for user(s): " + result.failed.join(", "));
+        } else {
+            alert("Action complete!");
+        }
+
+        loadUserList();
+    }).catch((error: Error) => {
+        alert("Error performing action");
+        console.error(error);
+    });
+}
+
 // =============================================================================
 // User admin
 // =============================================================================
@@ -81,6 +1164,11 @@ const setupInviteRevoking = () => {
 const setupUserSearch = () => {
     let userSearchBtn = document.getElementById("user-search-btn") as HTMLButtonElement;
     let userIDInput = document.getElementById("user-id") as HTMLInputElement;
+    if (!userSearchBtn) {
+        // Section not available for the admin's role
+        return;
+    }
+
 
     userSearchBtn.addEventListener("click", () => {
         let userID = userIDInput.value;
@@ -111,7 +1199,16 @@ const generateUserActionsHTML = (userInfo: AdminUserInfoResponse): HTMLDivElemen
     userInfoElement.innerHTML = `<div>ID: ${userInfo.id}</div>
 <div>Email: ${userInfo.email}</div>
 <div>Storage Used (bytes): ${userInfo.storageUsed} / <input id="${userInfo.id}-storage" value="${userInfo.storageAvailable}" type="number"></div>
-<div>Send Used (bytes): ${userInfo.sendUsed} / <input id="${userInfo.id}-send" value="${userInfo.sendAvailable}" type="number"></div>`;
+<div>Send Used (bytes): ${userInfo.sendUsed} / <input id="${userInfo.id}-send" value="${userInfo.sendAvailable}" type="number"></div>
+<div>Invite Allowance: <input id="${userInfo.id}-invites" value="${userInfo.inviteAllowance}" type="number" min="0"></div>`;
+
+    if (userInfo.suspended) {
+        let suspendedElement = document.createElement("div");
+        suspendedElement.className = "red-text";
+        suspendedElement.innerText = "Suspended" +
+            (userInfo.suspendedReason ? `: ${userInfo.suspendedReason}` : "");
+        userInfoElement.appendChild(suspendedElement);
+    }
 
     userResponseDiv.appendChild(userInfoElement);
     userResponseDiv.appendChild(document.createElement("br"));
@@ -123,11 +1220,30 @@ const generateUserActionsHTML = (userInfo: AdminUserInfoResponse): HTMLDivElemen
     let updateButton = document.createElement("button");
     updateButton.className = "accent-btn";
     updateButton.style.marginRight = "5px";
-    updateButton.innerText = "Update User Storage";
+    updateButton.innerText = "Update User Limits";
+
+    let suspendButton = document.createElement("button");
+    suspendButton.className = "accent-btn";
+    suspendButton.style.marginRight = "5px";
+    suspendButton.innerText = userInfo.suspended ? "Lift Suspension" : "Suspend User";
 
     userResponseDiv.appendChild(updateButton);
+    userResponseDiv.appendChild(suspendButton);
     userResponseDiv.appendChild(deleteButton);
 
+    suspendButton.addEventListener("click", () => {
+        if (userInfo.suspended) {
+            unsuspendUser(userInfo.id);
+        } else {
+            let reason = prompt("Enter a reason for the suspension (sent to the user):");
+            if (reason === null) {
+                return;
+            }
+
+            suspendUser(userInfo.id, reason);
+        }
+    });
+
     updateButton.addEventListener("click", () => updateUser(userInfo.id));
     deleteButton.addEventListener("click", () => {
         if (!confirm("Deleting this user will also delete all files they have " +
@@ -157,13 +1273,16 @@ const generateUserActionsHTML = (userInfo: AdminUserInfoResponse): HTMLDivElemen
 const updateUser = (userID: string) => {
     let storageInput = document.getElementById(`${userID}-storage`) as HTMLInputElement;
     let sendInput = document.getElementById(`${userID}-send`) as HTMLInputElement;
+    let invitesInput = document.getElementById(`${userID}-invites`) as HTMLInputElement;
 
     let action = new AdminUserAction();
     action.storageAvailable = storageInput.valueAsNumber;
     action.sendAvailable = sendInput.valueAsNumber;
+    action.inviteAllowance = invitesInput.valueAsNumber;
 
-    if (action.storageAvailable === null || action.sendAvailable === null) {
-        console.error("Invalid storage/send value found");
+    if (action.storageAvailable === null || action.sendAvailable === null ||
+        isNaN(action.inviteAllowance)) {
+        console.error("Invalid storage/send/invite value found");
         return;
     }
 
@@ -172,9 +1291,39 @@ const updateUser = (userID: string) => {
         body: JSON.stringify(action)
     }).then(async response => {
         if (response.ok) {
-            alert("User storage/send updated!");
+            alert("User storage/send/invites updated!");
+        } else {
+            alert("Failed to update user storage/send/invites: " + await response.text());
+        }
+    });
+}
+
+const suspendUser = (userID: string, reason: string) => {
+    let action = new AdminSuspendAction();
+    action.reason = reason;
+
+    fetch(Endpoints.format(Endpoints.AdminSuspendUser, userID), {
+        method: "POST",
+        body: JSON.stringify(action)
+    }).then(async response => {
+        if (response.ok) {
+            alert("User has been suspended!");
+            (document.getElementById("user-search-btn") as HTMLButtonElement).click();
+        } else {
+            alert("Failed to suspend user: " + await response.text());
+        }
+    });
+}
+
+const unsuspendUser = (userID: string) => {
+    fetch(Endpoints.format(Endpoints.AdminSuspendUser, userID), {
+        method: "DELETE"
+    }).then(async response => {
+        if (response.ok) {
+            alert("User suspension has been lifted!");
+            (document.getElementById("user-search-btn") as HTMLButtonElement).click();
         } else {
-            alert("Failed to update user storage/send: " + await response.text());
+            alert("Failed to lift user suspension: " + await response.text());
         }
     });
 }
@@ -203,6 +1352,11 @@ const deleteUser = (userID: string, userDiv: HTMLDivElement) => {
 const setupFileSearch = () => {
     let fileSearchBtn = document.getElementById("file-search-btn") as HTMLButtonElement;
     let fileIDInput = document.getElementById("file-id") as HTMLInputElement;
+    if (!fileSearchBtn) {
+        // Section not available for the admin's role
+        return;
+    }
+
 
     fileSearchBtn.addEventListener("click", () => {
         let fileID = fileIDInput.value;
@@ -271,6 +1425,205 @@ Modified: ${fileInfo.modified}`;
     return fileResponseDiv;
 }
 
+// =============================================================================
+// Admin roles
+// =============================================================================
+
+const loadRoles = () => {
+    let rolesDiv = document.getElementById("roles-list");
+    if (!rolesDiv) {
+        // Section not available for the admin's role
+        return;
+    }
+
+    fetch(Endpoints.AdminRoles.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching admin roles: " + await response.text());
+            return;
+        }
+
+        let rolesResponse = new AdminRolesResponse(await response.json());
+
+        let roleSelect = document.getElementById("role-select") as HTMLSelectElement;
+        roleSelect.innerHTML = "";
+        for (let role of rolesResponse.availableRoles) {
+            let option = document.createElement("option");
+            option.value = role;
+            option.innerText = role;
+            roleSelect.appendChild(option);
+        }
+
+        rolesDiv.innerHTML = "";
+        if (rolesResponse.roles.length === 0) {
+            rolesDiv.innerText = "No admin roles granted.";
+        }
+
+        for (let role of rolesResponse.roles) {
+            rolesDiv.appendChild(generateRoleHTML(role));
+        }
+
+        let auditDiv = document.getElementById("roles-audit");
+        auditDiv.innerHTML = "";
+        for (let entry of rolesResponse.audit) {
+            let entryDiv = document.createElement("div");
+            entryDiv.className = "small-text";
+            entryDiv.innerText = `${entry.created.toLocaleString()}: ` +
+                `${entry.actorID} ${entry.action} ${entry.role} (${entry.targetID})`;
+            auditDiv.appendChild(entryDiv);
+        }
+    }).catch((error: Error) => {
+        console.error(error);
+    });
+}
+
+const generateRoleHTML = (role: AdminRoleEntry): HTMLDivElement => {
+    let roleDiv = document.createElement("div") as HTMLDivElement;
+    roleDiv.className = "bordered-box visible";
+
+    let roleInfo = document.createElement("code");
+    roleInfo.innerText = `User: ${role.email || role.userID}
+Role: ${role.role}
+Granted: ${role.granted.toLocaleString()} by ${role.grantedBy}`;
+
+    let revokeButton = document.createElement("button");
+    revokeButton.className = "red-button";
+    revokeButton.innerText = "Revoke";
+    revokeButton.addEventListener("click", () => {
+        if (!confirm(`Revoke the ${role.role} role from ${role.email || role.userID}?`)) {
+            return;
+        }
+
+        fetch(Endpoints.format(Endpoints.AdminRoleAction, role.userID), {
+            method: "DELETE"
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Failed to revoke role: " + await response.text());
+                return;
+            }
+
+            loadRoles();
+        });
+    });
+
+    roleDiv.appendChild(roleInfo);
+    roleDiv.appendChild(document.createElement("br"));
+    roleDiv.appendChild(revokeButton);
+    return roleDiv;
+}
+
+const setupRoleGranting = () => {
+    let grantBtn = document.getElementById("grant-role") as HTMLButtonElement;
+    if (!grantBtn) {
+        return;
+    }
+
+    grantBtn.addEventListener("click", () => {
+        let userInput = document.getElementById("role-user") as HTMLInputElement;
+        let roleSelect = document.getElementById("role-select") as HTMLSelectElement;
+
+        let action = new AdminRoleAction();
+        action.user = userInput.value;
+        action.role = roleSelect.value;
+
+        fetch(Endpoints.AdminRoles.path, {
+            method: "POST",
+            body: JSON.stringify(action)
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Failed to grant role: " + await response.text());
+                return;
+            }
+
+            userInput.value = "";
+            loadRoles();
+        });
+    });
+}
+
+// =============================================================================
+// Server secrets
+// =============================================================================
+
+const loadServerSecrets = () => {
+    let usageDiv = document.getElementById("secrets-usage");
+    if (!usageDiv) {
+        // Section not available for the admin's role
+        return;
+    }
+
+    fetch(Endpoints.AdminSecrets.path).then(async response => {
+        if (!response.ok) {
+            console.error("Error fetching server secrets: " + await response.text());
+            return;
+        }
+
+        showServerSecrets(new AdminServerSecretsResponse(await response.json()));
+    }).catch((error: Error) => {
+        console.error(error);
+    });
+}
+
+const showServerSecrets = (secrets: AdminServerSecretsResponse) => {
+    let usageDiv = document.getElementById("secrets-usage");
+    usageDiv.innerHTML = "";
+
+    let usage = document.createElement("code");
+    let lines = secrets.keys.map(key =>
+        `Key ${key.keyID}${key.current ? " (current)" : ""}: ${key.values} value(s)`);
+    if (secrets.legacy > 0) {
+        lines.push(`No key ID (legacy): ${secrets.legacy} value(s)`);
+    }
+
+    if (secrets.unknown > 0) {
+        lines.push(`Unknown key: ${secrets.unknown} value(s)`);
+    }
+
+    usage.innerText = lines.join("\n");
+
+    let usageBox = document.createElement("div");
+    usageBox.className = "bordered-box visible";
+    usageBox.appendChild(usage);
+    usageDiv.appendChild(usageBox);
+}
+
+const setupReEncryption = () => {
+    let reEncryptBtn = document.getElementById("re-encrypt-secrets") as HTMLButtonElement;
+    if (!reEncryptBtn) {
+        return;
+    }
+
+    reEncryptBtn.addEventListener("click", () => {
+        if (!confirm("Re-encrypt all stored values with the current server secret?")) {
+            return;
+        }
+
+        reEncryptBtn.disabled = true;
+        fetch(Endpoints.AdminSecrets.path, {
+            method: "POST"
+        }).then(async response => {
+            reEncryptBtn.disabled = false;
+            if (!response.ok) {
+                alert("Failed to re-encrypt values: " + await response.text());
+                return;
+            }
+
+            let secrets = new AdminServerSecretsResponse(await response.json());
+            showServerSecrets(secrets);
+
+            let message = `Re-encrypted ${secrets.reEncrypted} value(s).`;
+            if (secrets.failed > 0) {
+                message += ` ${secrets.failed} value(s) couldn't be decrypted ` +
+                    `with any configured server secret and were left as-is.`;
+            }
+
+            alert(message);
+        }).catch((error: Error) => {
+            reEncryptBtn.disabled = false;
+            console.error(error);
+        });
+    });
+}
+
 if (document.readyState !== "loading") {
     init();
 } else {
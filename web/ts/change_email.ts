@@ -1,6 +1,7 @@
 import * as crypto from "./crypto.js";
 import {Endpoints} from "./endpoints.js";
-import {ChangeEmail, ProtectedKeyResponse} from "./interfaces.js";
+import {fetchKDFParams, reencryptUserKeys} from "./keys.js";
+import {ChangeEmail, KDFResponse, ProtectedKeyResponse} from "./interfaces.js";
 
 let identifierInput: HTMLInputElement,
     passwordInput: HTMLInputElement,
@@ -43,21 +44,35 @@ const submitChangeEmail = async () => {
     let password = passwordInput.value;
     let newEmail = newEmailInput.value;
 
-    let oldUserKey = await crypto.generateUserKey(identifier, password);
-    let oldLoginKeyHash = await crypto.generateLoginKeyHash(oldUserKey, password);
+    let kdf: KDFResponse;
+    try {
+        kdf = await fetchKDFParams(identifier);
+    } catch (e) {
+        showMessage(`Error: ${e.message}`, true);
+        disableInputs(false);
+        return;
+    }
+
+    let oldUserKey = await crypto.generateUserKey(identifier, password, kdf.kdf);
+    let oldLoginKeyHash = await crypto.generateLoginKeyHash(
+        oldUserKey, password, kdf.kdf);
 
-    let newUserKey = await crypto.generateUserKey(newEmail, password);
-    let newLoginKeyHash = await crypto.generateLoginKeyHash(newUserKey, password);
+    let newUserKey = await crypto.generateUserKey(
+        newEmail, password, kdf.recommendedKdf);
+    let newLoginKeyHash = await crypto.generateLoginKeyHash(
+        newUserKey, password, kdf.recommendedKdf);
 
     let protectedKeyResponse = await fetch(Endpoints.ProtectedKey.path);
-    let protectedKey = new ProtectedKeyResponse(
+    let protectedKeyData = new ProtectedKeyResponse(
         await protectedKeyResponse.json()
-    ).protectedKey;
+    );
 
-    let newProtectedKey;
+    let newProtectedKey, newProtectedKeys;
     try {
-        let privateKey = await crypto.decryptChunk(oldUserKey, protectedKey);
+        let privateKey = await crypto.decryptChunk(oldUserKey, protectedKeyData.protectedKey);
         newProtectedKey = await crypto.encryptChunk(newUserKey, privateKey);
+        newProtectedKeys = await reencryptUserKeys(
+            oldUserKey, newUserKey, protectedKeyData.keys);
     } catch (e) {
         showMessage("Incorrect password", true);
         disableInputs(false);
@@ -68,7 +83,9 @@ const submitChangeEmail = async () => {
     changeEmail.oldLoginKeyHash = oldLoginKeyHash;
     changeEmail.newLoginKeyHash = newLoginKeyHash;
     changeEmail.protectedKey = newProtectedKey;
+    changeEmail.protectedKeys = newProtectedKeys;
     changeEmail.newEmail = newEmail;
+    changeEmail.kdf = kdf.recommendedKdf;
 
     let changeID = window.location.href.split("/").pop()
     fetch(Endpoints.format(Endpoints.ChangeEmail, changeID), {
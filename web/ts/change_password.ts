@@ -1,5 +1,6 @@
 import * as crypto from "./crypto.js";
 import {Endpoints} from "./endpoints.js";
+import {fetchKDFParams, reencryptUserKeys} from "./keys.js";
 import * as interfaces from "./interfaces.js";
 
 let submitBtn: HTMLButtonElement;
@@ -34,11 +35,17 @@ const submitPasswordChange = async () => {
     }
 
     inputsDisabled(true);
+    let kdf: interfaces.KDFResponse;
     let protectedKey: Uint8Array;
+    let protectedKeys: interfaces.UserKey[];
     try {
+        kdf = await fetchKDFParams(id.value);
+
         let protectedKeyResponse = await fetch(Endpoints.ProtectedKey.path);
         let responseData = await protectedKeyResponse.json();
-        protectedKey = new interfaces.ProtectedKeyResponse(responseData).protectedKey;
+        let protectedKeyData = new interfaces.ProtectedKeyResponse(responseData);
+        protectedKey = protectedKeyData.protectedKey;
+        protectedKeys = protectedKeyData.keys;
     } catch (error) {
         console.error(error);
         inputsDisabled(false);
@@ -46,17 +53,21 @@ const submitPasswordChange = async () => {
         return;
     }
 
-    let oldLoginKeyHash, newLoginKeyHash, newProtectedKey;
+    let oldLoginKeyHash, newLoginKeyHash, newProtectedKey, newProtectedKeys;
     try {
-        let oldUserKey = await crypto.generateUserKey(id.value, oldPw.value);
-        oldLoginKeyHash = await crypto.generateLoginKeyHash(oldUserKey, oldPw.value);
+        let oldUserKey = await crypto.generateUserKey(id.value, oldPw.value, kdf.kdf);
+        oldLoginKeyHash = await crypto.generateLoginKeyHash(
+            oldUserKey, oldPw.value, kdf.kdf);
 
         let privateKey = await crypto.decryptChunk(oldUserKey, protectedKey);
 
-        let newUserKey = await crypto.generateUserKey(id.value, newPw.value);
-        newLoginKeyHash = await crypto.generateLoginKeyHash(newUserKey, newPw.value);
+        let newUserKey = await crypto.generateUserKey(
+            id.value, newPw.value, kdf.recommendedKdf);
+        newLoginKeyHash = await crypto.generateLoginKeyHash(
+            newUserKey, newPw.value, kdf.recommendedKdf);
 
         newProtectedKey = await crypto.encryptChunk(newUserKey, privateKey);
+        newProtectedKeys = await reencryptUserKeys(oldUserKey, newUserKey, protectedKeys);
     } catch (error) {
         inputsDisabled(false);
         showMessage("Decryption error", true);
@@ -67,6 +78,8 @@ const submitPasswordChange = async () => {
     changePassword.oldLoginKeyHash = oldLoginKeyHash;
     changePassword.newLoginKeyHash = newLoginKeyHash;
     changePassword.protectedKey = newProtectedKey;
+    changePassword.protectedKeys = newProtectedKeys;
+    changePassword.kdf = kdf.recommendedKdf;
 
     fetch(Endpoints.ChangePassword.path, {
         method: "PUT",
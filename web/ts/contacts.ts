@@ -0,0 +1,188 @@
+import * as crypto from "./crypto.js";
+import * as constants from "./constants.js";
+import * as interfaces from "./interfaces.js";
+import * as localstorage from "./localstorage.js";
+import { Endpoints } from "./endpoints.js";
+
+export enum ContactStatus {
+    Unverified,
+    Verified,
+    Changed,
+}
+
+/**
+ * VaultKeys are the user's decrypted key pairs, along with the key derived
+ * from their private key that their pinned contacts are encrypted with
+ */
+export interface VaultKeys {
+    privateKey: CryptoKey;
+    publicKey: CryptoKey;
+    x25519Keys: crypto.X25519KeyPair | null;
+    contactsKey: CryptoKey;
+}
+
+/**
+ * ownFingerprint returns the fingerprint of the user's own public keys, which
+ * their contacts should see when they share with the user
+ * @param keys {VaultKeys}
+ * @returns {Promise<Uint8Array>}
+ */
+export const ownFingerprint = async (keys: VaultKeys): Promise<Uint8Array> => {
+    let publicKey = await crypto.exportKey(keys.publicKey, "spki");
+    let additionalKeys = [];
+    if (keys.x25519Keys) {
+        additionalKeys.push({
+            type: constants.KeyTypeX25519,
+            publicKey: keys.x25519Keys.publicKey,
+        });
+    }
+
+    return await crypto.fingerprint(publicKey, additionalKeys);
+}
+
+/**
+ * loadContacts fetches and decrypts the user's pinned contacts. Since contacts
+ * are only ever added or replaced with a newer pin, the most recent pin acts
+ * as a version for the list, and the list is reported as stale if the server's
+ * copy is missing or older than the latest copy seen in this browser.
+ * @param keys {VaultKeys}
+ * @returns {Promise<[interfaces.PinnedContact[], boolean]>} - the pinned
+ * contacts, and whether the server's copy is stale
+ */
+export const loadContacts = async (
+    keys: VaultKeys,
+): Promise<[interfaces.PinnedContact[], boolean]> => {
+    let response = await fetch(Endpoints.Contacts.path);
+    if (!response.ok) {
+        throw new Error(await response.text());
+    }
+
+    let pinned: interfaces.PinnedContact[] = [];
+    let contactList = new interfaces.ContactList(await response.text());
+    if (contactList.contacts.length > 0) {
+        let contactsKey = await crypto.importKey(new Uint8Array(
+            await crypto.decryptChunk(keys.contactsKey, contactList.protectedKey)));
+        let contactsJSON = await crypto.decryptString(contactsKey, contactList.contacts);
+        pinned = (JSON.parse(contactsJSON) || []).map(
+            (contact: any) => new interfaces.PinnedContact(contact));
+    }
+
+    let user = await contactsUser(keys);
+    let latest = latestPin(pinned);
+    let seen = localstorage.getLatestContactPin(user);
+    if (seen && (!latest || latest < seen)) {
+        return [pinned, true];
+    } else if (latest && (!seen || latest > seen)) {
+        localstorage.setLatestContactPin(user, latest);
+    }
+
+    return [pinned, false];
+}
+
+/**
+ * pinContact marks a contact's keys as verified, replacing any fingerprint
+ * that was previously pinned for the contact
+ * @param keys {VaultKeys}
+ * @param pinned {interfaces.PinnedContact[]} - the user's current pinned contacts
+ * @param identifier {string} - the contact's email or account ID
+ * @param fingerprint {Uint8Array} - the fingerprint of the contact's keys
+ */
+export const pinContact = async (
+    keys: VaultKeys,
+    pinned: interfaces.PinnedContact[],
+    identifier: string,
+    fingerprint: Uint8Array,
+): Promise<void> => {
+    identifier = normalizeIdentifier(identifier);
+    let updated = [{
+        identifier: identifier,
+        fingerprint: arrayToBase64(fingerprint),
+        verified: new Date().toISOString(),
+    }];
+
+    for (let contact of pinned) {
+        if (contact.identifier !== identifier) {
+            updated.push({
+                identifier: contact.identifier,
+                fingerprint: arrayToBase64(contact.fingerprint),
+                verified: contact.verified.toISOString(),
+            });
+        }
+    }
+
+    let rawKey = crypto.generateRandomKey();
+    let contactList = new interfaces.ContactList();
+    contactList.protectedKey = await crypto.encryptChunk(keys.contactsKey, rawKey);
+    contactList.contacts = await crypto.encryptString(
+        await crypto.importKey(rawKey),
+        JSON.stringify(updated));
+
+    let response = await fetch(Endpoints.Contacts.path, {
+        method: "PUT",
+        body: JSON.stringify(contactList, jsonReplacer),
+    });
+
+    if (!response.ok) {
+        throw new Error(await response.text());
+    }
+
+    localstorage.setLatestContactPin(
+        await contactsUser(keys),
+        new Date(updated[0].verified));
+}
+
+/**
+ * checkContact compares a fingerprint against the one pinned for the contact,
+ * if the user has verified the contact before
+ * @param pinned {interfaces.PinnedContact[]}
+ * @param identifier {string}
+ * @param fingerprint {Uint8Array}
+ * @returns {[ContactStatus, interfaces.PinnedContact|null]}
+ */
+export const checkContact = (
+    pinned: interfaces.PinnedContact[],
+    identifier: string,
+    fingerprint: Uint8Array,
+): [ContactStatus, interfaces.PinnedContact | null] => {
+    identifier = normalizeIdentifier(identifier);
+    for (let contact of pinned) {
+        if (contact.identifier !== identifier) {
+            continue;
+        }
+
+        let matches = contact.fingerprint.length === fingerprint.length &&
+            contact.fingerprint.every((value, i) => value === fingerprint[i]);
+        return [matches ? ContactStatus.Verified : ContactStatus.Changed, contact];
+    }
+
+    return [ContactStatus.Unverified, null];
+}
+
+/**
+ * latestPin returns the time of the most recently pinned contact, or null if
+ * no contacts have been pinned
+ * @param pinned {interfaces.PinnedContact[]}
+ */
+const latestPin = (pinned: interfaces.PinnedContact[]): Date | null => {
+    let latest: Date | null = null;
+    for (let contact of pinned) {
+        if (!latest || contact.verified > latest) {
+            latest = contact.verified;
+        }
+    }
+
+    return latest;
+}
+
+/**
+ * contactsUser returns the key that the user's latest contact pin is stored
+ * under, so that multiple users of the same browser are tracked separately
+ * @param keys {VaultKeys}
+ */
+const contactsUser = async (keys: VaultKeys): Promise<string> => {
+    return arrayToBase64(await ownFingerprint(keys));
+}
+
+const normalizeIdentifier = (identifier: string): string => {
+    return identifier.trim().toLowerCase();
+}
@@ -1,4 +1,5 @@
 import * as constants from "./constants.js";
+import { KDFParams } from "./interfaces.js";
 
 // @ts-ignore;
 export let webcrypto;
@@ -11,23 +12,141 @@ let utf8Decode = new TextDecoder();
 let indexedDB: IDBFactory;
 
 /**
- * deriveSendingKey creates a PBKDF2 key using a password as the payload, a
- * salt (or a randomly generated salt if not provided). Returns the derived key
- * and the salt.
+ * legacyKDFParams returns the parameters used to derive account keys before
+ * they were stored per account
+ * @returns {KDFParams}
+ */
+export const legacyKDFParams = (): KDFParams => {
+    return new KDFParams({
+        algorithm: constants.KDFArgon2id,
+        iterations: constants.Argon2Iter,
+        memory: constants.Argon2Mem,
+    });
+}
+
+/**
+ * legacySendKDFParams returns the parameters used to derive Send link keys
+ * before they were stored with each Send upload
+ * @returns {KDFParams}
+ */
+export const legacySendKDFParams = (): KDFParams => {
+    return new KDFParams({
+        algorithm: constants.KDFPBKDF2,
+        iterations: constants.PBKDF2Iter,
+        memory: 0,
+    });
+}
+
+/**
+ * sendKDFParams returns the parameters used to derive keys for new Send
+ * uploads. Downloads use the parameters stored with each upload instead.
+ * @returns {KDFParams}
+ */
+export const sendKDFParams = (): KDFParams => {
+    return legacySendKDFParams();
+}
+
+/**
+ * kdfOrDefault returns the default parameters if the parameters are unset,
+ * which is the case for servers that predate stored KDF parameters
+ * @param params {KDFParams}
+ * @param defaultParams {KDFParams}
+ * @returns {KDFParams}
+ */
+export const kdfOrDefault = (
+    params: KDFParams,
+    defaultParams: KDFParams,
+): KDFParams => {
+    if (!params || !params.algorithm) {
+        return defaultParams;
+    }
+
+    return params;
+}
+
+/**
+ * isValidKDF checks that the parameters use a supported algorithm, and are at
+ * least as strong as the legacy parameters without being so expensive that
+ * deriving a key would exhaust the browser's resources
+ * @param params {KDFParams}
+ * @returns {boolean}
+ */
+export const isValidKDF = (params: KDFParams): boolean => {
+    switch (params.algorithm) {
+        case constants.KDFArgon2id:
+            return params.iterations >= constants.Argon2Iter &&
+                params.iterations <= constants.MaxArgon2Iter &&
+                params.memory >= constants.Argon2Mem &&
+                params.memory <= constants.MaxArgon2Mem;
+        case constants.KDFPBKDF2:
+            return params.iterations >= constants.PBKDF2Iter &&
+                params.iterations <= constants.MaxPBKDF2Iter &&
+                !params.memory;
+        default:
+            return false;
+    }
+}
+
+/**
+ * isWeakerKDF returns true if keys derived with the other parameters would be
+ * stronger, meaning that keys derived with the first parameters should be
+ * upgraded
+ * @param params {KDFParams}
+ * @param other {KDFParams}
+ * @returns {boolean}
+ */
+export const isWeakerKDF = (params: KDFParams, other: KDFParams): boolean => {
+    if (params.algorithm !== other.algorithm) {
+        return other.algorithm === constants.KDFArgon2id;
+    }
+
+    return params.iterations < other.iterations || params.memory < other.memory;
+}
+
+/**
+ * deriveSendingKey derives a key for a Send upload using a password as the
+ * payload, a salt (or a randomly generated salt if not provided), and the
+ * upload's key derivation parameters. Returns the derived key and the salt.
  * @param password {string} - the password for generating the key
  * @param salt {Uint8Array} - the key salt (can be left undefined to randomly generate one)
+ * @param params {KDFParams} - the KDF params (defaults to the legacy params)
  * @returns {Promise<[CryptoKey,Uint8Array]>}
  */
 export const deriveSendingKey = async (
     password: string,
     salt: Uint8Array,
+    params?: KDFParams,
 ): Promise<[CryptoKey, Uint8Array]> => {
+    params = kdfOrDefault(params, legacySendKDFParams());
+    if (!isValidKDF(params)) {
+        throw new Error("Invalid key derivation parameters");
+    }
+
     if (!salt) {
         salt = webcrypto.getRandomValues(new Uint8Array(HashSize));
     }
 
-    let encodedPassword = utf8Encode.encode(password);
-    return [await deriveKey(encodedPassword, salt), salt];
+    return [await deriveKDFKey(password, salt, params), salt];
+}
+
+/**
+ * deriveKDFKey derives a key from a password and salt using the algorithm and
+ * cost parameters in params
+ * @param password {string} - the password for the key
+ * @param salt {Uint8Array} - the salt for the key
+ * @param params {KDFParams} - the (validated) KDF params
+ * @returns {Promise<CryptoKey>}
+ */
+const deriveKDFKey = async (
+    password: string,
+    salt: Uint8Array,
+    params: KDFParams,
+): Promise<CryptoKey> => {
+    if (params.algorithm === constants.KDFPBKDF2) {
+        return await deriveKey(utf8Encode.encode(password), salt, params.iterations);
+    }
+
+    return await generateArgon2Key(password, salt, params);
 }
 
 /**
@@ -49,11 +168,13 @@ export const importKey = async (keyData: Uint8Array): Promise<CryptoKey> => {
  * deriveKey derives a PBKDF2 key from a password and salt
  * @param password {Uint8Array} - a UTF-8 encoded password for the key
  * @param salt {Uint8Array} - the salt for the key
+ * @param iterations {number} - the number of PBKDF2 iterations
  * @returns {Promise<CryptoKey>}
  */
 export const deriveKey = async (
     password: Uint8Array,
     salt: Uint8Array,
+    iterations: number = constants.PBKDF2Iter,
 ): Promise<CryptoKey> => {
     let keyMaterial = await webcrypto.subtle.importKey(
         "raw",
@@ -67,7 +188,7 @@ export const deriveKey = async (
         {
             name: "PBKDF2",
             salt,
-            iterations: 600000,
+            iterations: iterations,
             hash: "SHA-256",
         },
         keyMaterial,
@@ -155,6 +276,364 @@ export const decryptRSA = async (
     return new Uint8Array(decrypted);
 }
 
+// Keys wrapped with anything other than RSA-OAEP start with a header
+// identifying the scheme: "yfk" || version (1 byte) || scheme data. For
+// X25519 (version 2), the scheme data is the sender's ephemeral public key,
+// followed by the IV and the AES-GCM encrypted key.
+const WrappedKeyMagic = utf8Encode.encode("yfk");
+const WrappedKeyX25519 = 2;
+const X25519KeySize = 32;
+const X25519Info = utf8Encode.encode("yeetfile-x25519-key-wrap");
+const ContactsInfo = utf8Encode.encode("yeetfile-contacts-key-wrap");
+
+export interface X25519KeyPair {
+    privateKey: Uint8Array;
+    publicKey: Uint8Array;
+}
+
+/**
+ * generateX25519KeyPair generates a new raw X25519 key pair
+ * @returns {Promise<X25519KeyPair>}
+ */
+export const generateX25519KeyPair = async (): Promise<X25519KeyPair> => {
+    await sodium.ready;
+    let keyPair = sodium.crypto_box_keypair();
+    return { privateKey: keyPair.privateKey, publicKey: keyPair.publicKey };
+}
+
+/**
+ * encryptX25519 wraps a key for the owner of an X25519 public key, using an
+ * ephemeral key pair to derive a one-time AES-GCM key
+ * @param publicKey {Uint8Array} - the recipient's X25519 public key
+ * @param data {Uint8Array} - the key to wrap
+ * @returns {Promise<Uint8Array>}
+ */
+export const encryptX25519 = async (
+    publicKey: Uint8Array,
+    data: Uint8Array,
+): Promise<Uint8Array> => {
+    let ephemeral = await generateX25519KeyPair();
+    let secret = sodium.crypto_scalarmult(ephemeral.privateKey, publicKey);
+    let wrapKey = await deriveX25519WrapKey(secret, ephemeral.publicKey, publicKey);
+
+    let header = x25519Header();
+    let iv = webcrypto.getRandomValues(new Uint8Array(IVSize));
+    let encrypted = await webcrypto.subtle.encrypt(
+        { name: "AES-GCM", iv, additionalData: header },
+        wrapKey,
+        data);
+
+    let merged = new Uint8Array(
+        header.length + X25519KeySize + IVSize + encrypted.byteLength);
+    merged.set(header);
+    merged.set(ephemeral.publicKey, header.length);
+    merged.set(iv, header.length + X25519KeySize);
+    merged.set(new Uint8Array(encrypted), header.length + X25519KeySize + IVSize);
+    return merged;
+}
+
+/**
+ * decryptX25519 unwraps a key that was wrapped with encryptX25519
+ * @param privateKey {Uint8Array} - the recipient's X25519 private key
+ * @param data {Uint8Array} - the wrapped key
+ * @returns {Promise<Uint8Array>}
+ */
+export const decryptX25519 = async (
+    privateKey: Uint8Array,
+    data: Uint8Array,
+): Promise<Uint8Array> => {
+    let headerSize = WrappedKeyMagic.length + 1;
+    if (!isX25519Wrapped(data) || data.length <= headerSize + X25519KeySize + IVSize) {
+        throw new Error("Invalid wrapped key");
+    }
+
+    await sodium.ready;
+    let header = data.slice(0, headerSize);
+    let ephemeralPublicKey = data.slice(headerSize, headerSize + X25519KeySize);
+    let iv = data.slice(headerSize + X25519KeySize, headerSize + X25519KeySize + IVSize);
+    let encrypted = data.slice(headerSize + X25519KeySize + IVSize);
+
+    let publicKey = sodium.crypto_scalarmult_base(privateKey);
+    let secret = sodium.crypto_scalarmult(privateKey, ephemeralPublicKey);
+    let wrapKey = await deriveX25519WrapKey(secret, ephemeralPublicKey, publicKey);
+
+    let decrypted = await webcrypto.subtle.decrypt(
+        { name: "AES-GCM", iv, additionalData: header },
+        wrapKey,
+        encrypted);
+    return new Uint8Array(decrypted);
+}
+
+/**
+ * isX25519Wrapped returns true if the key was wrapped with encryptX25519
+ * @param data {Uint8Array}
+ */
+export const isX25519Wrapped = (data: Uint8Array): boolean => {
+    return data.length > WrappedKeyMagic.length &&
+        WrappedKeyMagic.every((value, i) => value === data[i]) &&
+        data[WrappedKeyMagic.length] === WrappedKeyX25519;
+}
+
+/**
+ * wrapKeyForUser encrypts a key for another user, using the strongest of the
+ * user's public keys. Users only have an X25519 key if their client supports
+ * it, otherwise their RSA key is used.
+ * @param rsaPublicKey {Uint8Array} - the user's RSA-OAEP public key
+ * @param keys {Array} - the user's additional public keys
+ * @param data {Uint8Array} - the key to wrap
+ * @returns {Promise<Uint8Array>}
+ */
+export const wrapKeyForUser = async (
+    rsaPublicKey: Uint8Array,
+    keys: { type: string, publicKey: Uint8Array }[],
+    data: Uint8Array,
+): Promise<Uint8Array> => {
+    for (let key of keys || []) {
+        if (key.type === constants.KeyTypeX25519) {
+            return await encryptX25519(key.publicKey, data);
+        }
+    }
+
+    let publicKey = await new Promise<CryptoKey>(resolve => {
+        ingestPublicKey(rsaPublicKey, resolve);
+    });
+    return await encryptRSA(publicKey, data);
+}
+
+/**
+ * unwrapKey decrypts a key that was wrapped for the user, using whichever of
+ * the user's private keys matches the scheme the key was wrapped with
+ * @param privateKey {CryptoKey} - the user's RSA-OAEP private key
+ * @param x25519Keys {X25519KeyPair} - the user's X25519 keys, if they have any
+ * @param data {Uint8Array} - the wrapped key
+ * @returns {Promise<Uint8Array>}
+ */
+export const unwrapKey = async (
+    privateKey: CryptoKey,
+    x25519Keys: X25519KeyPair | null,
+    data: Uint8Array,
+): Promise<Uint8Array> => {
+    if (isX25519Wrapped(data)) {
+        if (!x25519Keys) {
+            throw new Error("Missing private key for wrapped key");
+        }
+
+        return await decryptX25519(x25519Keys.privateKey, data);
+    }
+
+    return await decryptRSA(privateKey, data);
+}
+
+// Recovery keys are raw X25519 private keys, displayed to the user as
+// unpadded base32 in groups of 4 characters
+const RecoveryKeyHashPrefix = utf8Encode.encode("yeetfile-recovery");
+const Base32Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567";
+const RecoveryKeyGroupSize = 4;
+
+/**
+ * generateRecoveryKey generates a new account recovery key
+ * @returns {Promise<Uint8Array>}
+ */
+export const generateRecoveryKey = async (): Promise<Uint8Array> => {
+    let keyPair = await generateX25519KeyPair();
+    return keyPair.privateKey;
+}
+
+/**
+ * recoveryPublicKey returns the public key that the user's private keys are
+ * encrypted with for recovery
+ * @param recoveryKey {Uint8Array}
+ * @returns {Promise<Uint8Array>}
+ */
+export const recoveryPublicKey = async (
+    recoveryKey: Uint8Array,
+): Promise<Uint8Array> => {
+    await sodium.ready;
+    return sodium.crypto_scalarmult_base(recoveryKey);
+}
+
+/**
+ * recoveryKeyHash returns the hash used to prove possession of a recovery key
+ * to the server, without revealing the key itself
+ * @param recoveryKey {Uint8Array}
+ * @returns {Promise<Uint8Array>}
+ */
+export const recoveryKeyHash = async (
+    recoveryKey: Uint8Array,
+): Promise<Uint8Array> => {
+    let data = new Uint8Array(RecoveryKeyHashPrefix.length + recoveryKey.length);
+    data.set(RecoveryKeyHashPrefix);
+    data.set(recoveryKey, RecoveryKeyHashPrefix.length);
+    return new Uint8Array(await webcrypto.subtle.digest("SHA-256", data));
+}
+
+/**
+ * formatRecoveryKey converts a recovery key into the text shown to the user
+ * @param recoveryKey {Uint8Array}
+ * @returns {string}
+ */
+export const formatRecoveryKey = (recoveryKey: Uint8Array): string => {
+    let encoded = "";
+    let buffer = 0;
+    let bits = 0;
+    for (let value of recoveryKey) {
+        buffer = (buffer << 8) | value;
+        bits += 8;
+        while (bits >= 5) {
+            encoded += Base32Alphabet[(buffer >>> (bits - 5)) & 31];
+            bits -= 5;
+        }
+    }
+
+    if (bits > 0) {
+        encoded += Base32Alphabet[(buffer << (5 - bits)) & 31];
+    }
+
+    let groups = [];
+    for (let i = 0; i < encoded.length; i += RecoveryKeyGroupSize) {
+        groups.push(encoded.slice(i, i + RecoveryKeyGroupSize));
+    }
+
+    return groups.join("-");
+}
+
+/**
+ * parseRecoveryKey converts the text of a recovery key back into the key,
+ * ignoring case, spaces, and dashes
+ * @param text {string}
+ * @returns {Uint8Array}
+ */
+export const parseRecoveryKey = (text: string): Uint8Array => {
+    let encoded = text.toUpperCase().replace(/[\s-]/g, "");
+    let key = [];
+    let buffer = 0;
+    let bits = 0;
+    for (let char of encoded) {
+        let value = Base32Alphabet.indexOf(char);
+        if (value < 0) {
+            throw new Error("Invalid recovery key");
+        }
+
+        buffer = ((buffer << 5) | value) & 0xfff;
+        bits += 5;
+        if (bits >= 8) {
+            key.push((buffer >>> (bits - 8)) & 0xff);
+            bits -= 8;
+        }
+    }
+
+    if (key.length !== X25519KeySize) {
+        throw new Error("Invalid recovery key");
+    }
+
+    return new Uint8Array(key);
+}
+
+// Fingerprints are a SHA-256 hash of all of a user's public keys, each
+// preceded by its type and prefixed with its length, and must match the
+// fingerprints generated by the CLI
+const FingerprintPrefix = utf8Encode.encode("yeetfile-fingerprint");
+
+/**
+ * fingerprint returns a hash of all of a user's public keys, which is used to
+ * verify that the server returned the user's actual keys
+ * @param rsaPublicKey {Uint8Array} - the user's RSA-OAEP public key
+ * @param keys {Array} - the user's additional public keys
+ * @returns {Promise<Uint8Array>}
+ */
+export const fingerprint = async (
+    rsaPublicKey: Uint8Array,
+    keys: { type: string, publicKey: Uint8Array }[],
+): Promise<Uint8Array> => {
+    let sorted = [...(keys || [])].sort((a, b) =>
+        a.type < b.type ? -1 : a.type > b.type ? 1 : 0);
+
+    let values = [utf8Encode.encode(constants.KeyTypeRSA), rsaPublicKey];
+    for (let key of sorted) {
+        values.push(utf8Encode.encode(key.type), key.publicKey);
+    }
+
+    let size = FingerprintPrefix.length;
+    for (let value of values) {
+        size += 4 + value.length;
+    }
+
+    let data = new Uint8Array(size);
+    let view = new DataView(data.buffer);
+    data.set(FingerprintPrefix);
+
+    let offset = FingerprintPrefix.length;
+    for (let value of values) {
+        view.setUint32(offset, value.length);
+        data.set(value, offset + 4);
+        offset += 4 + value.length;
+    }
+
+    return new Uint8Array(await webcrypto.subtle.digest("SHA-256", data));
+}
+
+/**
+ * formatFingerprint converts a fingerprint into the emoji and words shown to
+ * the user for comparison
+ * @param fingerprint {Uint8Array}
+ * @returns {string}
+ */
+export const formatFingerprint = (fingerprint: Uint8Array): string => {
+    let symbols = [];
+    for (let i = 0; i < constants.FingerprintLength && i < fingerprint.length; i++) {
+        let [emoji, word] = constants.FingerprintSymbols[fingerprint[i] & 0x3f];
+        symbols.push(`${emoji} ${word}`);
+    }
+
+    return symbols.join("  ");
+}
+
+const x25519Header = (): Uint8Array => {
+    let header = new Uint8Array(WrappedKeyMagic.length + 1);
+    header.set(WrappedKeyMagic);
+    header[WrappedKeyMagic.length] = WrappedKeyX25519;
+    return header;
+}
+
+const deriveX25519WrapKey = async (
+    secret: Uint8Array,
+    ephemeralPublicKey: Uint8Array,
+    publicKey: Uint8Array,
+): Promise<CryptoKey> => {
+    let salt = new Uint8Array(ephemeralPublicKey.length + publicKey.length);
+    salt.set(ephemeralPublicKey);
+    salt.set(publicKey, ephemeralPublicKey.length);
+
+    let baseKey = await webcrypto.subtle.importKey(
+        "raw", secret, "HKDF", false, ["deriveKey"]);
+    return await webcrypto.subtle.deriveKey(
+        { name: "HKDF", hash: "SHA-256", salt, info: X25519Info },
+        baseKey,
+        { name: "AES-GCM", length: 256 },
+        false,
+        ["encrypt", "decrypt"]);
+}
+
+/**
+ * deriveContactsKey derives the key that wraps the key for the user's pinned
+ * contacts from their private key. Unlike wrapping it with the user's public
+ * key, this means that the server can't replace the list with one of its own.
+ * @param privateKey {Uint8Array} - the user's raw private key
+ * @returns {Promise<CryptoKey>}
+ */
+export const deriveContactsKey = async (
+    privateKey: Uint8Array,
+): Promise<CryptoKey> => {
+    let baseKey = await webcrypto.subtle.importKey(
+        "raw", privateKey, "HKDF", false, ["deriveKey"]);
+    return await webcrypto.subtle.deriveKey(
+        { name: "HKDF", hash: "SHA-256", salt: new Uint8Array(), info: ContactsInfo },
+        baseKey,
+        { name: "AES-GCM", length: 256 },
+        false,
+        ["encrypt", "decrypt"]);
+}
+
 /**
  * decryptString decrypts an encrypted string using the provided key
  * @param key {CryptoKey} - the PBKDF2 key to use for decryption
@@ -186,14 +665,116 @@ export const decryptChunk = async (
     return await webcrypto.subtle.decrypt({ name: "AES-GCM", iv }, key, fileData);
 }
 
+// Version 2 file chunks use a nonce made up of a random per-file prefix, the
+// chunk's index within the file, and a flag marking the final chunk:
+// prefix (7 bytes) || chunk index (4 bytes, big endian) || final flag (1 byte)
+const NoncePrefixSize = 7;
+
+/**
+ * FileEncryptor encrypts the chunks of a single file using a specific file
+ * encryption version. Each file should use its own FileEncryptor.
+ */
+export class FileEncryptor {
+    version: number;
+    private readonly key: CryptoKey;
+    private readonly prefix: Uint8Array;
+
+    constructor(key: CryptoKey, version: number) {
+        this.key = key;
+        this.version = version;
+        this.prefix = webcrypto.getRandomValues(new Uint8Array(NoncePrefixSize));
+    }
+
+    /**
+     * encryptChunk encrypts a chunk of file data
+     * @param index {number} - the zero-based position of the chunk in the file
+     * @param final {boolean} - whether this is the last chunk of the file
+     * @param data {Uint8Array} - the data to encrypt
+     * @returns {Promise<Uint8Array>}
+     */
+    encryptChunk = async (
+        index: number,
+        final: boolean,
+        data: Uint8Array,
+    ): Promise<Uint8Array> => {
+        if (this.version === constants.FileEncryptionV1) {
+            return await encryptChunk(this.key, data);
+        }
+
+        let iv = chunkNonce(this.prefix, index, final);
+        let encrypted = await webcrypto.subtle.encrypt(
+            { name: "AES-GCM", iv, additionalData: new Uint8Array([this.version]) },
+            this.key,
+            data);
+        let merged = new Uint8Array(iv.length + encrypted.byteLength);
+        merged.set(iv);
+        merged.set(new Uint8Array(encrypted), iv.length);
+
+        return merged;
+    }
+}
+
+/**
+ * decryptFileChunk decrypts a chunk of file data that was encrypted with the
+ * provided file encryption version. Version 2 chunks must be decrypted at the
+ * same index and with the same final flag that they were encrypted with, so
+ * that reordered, duplicated, or missing chunks are detected.
+ * @param key {CryptoKey} - the file key
+ * @param version {number} - the file's encryption version
+ * @param index {number} - the zero-based position of the chunk in the file
+ * @param final {boolean} - whether this is the last chunk of the file
+ * @param data {Uint8Array} - the encrypted chunk
+ * @returns {Promise<Uint8Array>}
+ */
+export const decryptFileChunk = async (
+    key: CryptoKey,
+    version: number,
+    index: number,
+    final: boolean,
+    data: Uint8Array,
+): Promise<Uint8Array> => {
+    if (!version || version === constants.FileEncryptionV1) {
+        // Files uploaded before versioning are always version 1
+        return new Uint8Array(await decryptChunk(key, data));
+    } else if (version !== constants.FileEncryptionV2) {
+        throw new Error("Unsupported file encryption version");
+    }
+
+    let iv = data.slice(0, IVSize);
+    let expected = chunkNonce(iv.slice(0, NoncePrefixSize), index, final);
+    if (!expected.every((value, i) => value === iv[i])) {
+        throw new Error("File chunk is out of order or the file is truncated");
+    }
+
+    let decrypted = await webcrypto.subtle.decrypt(
+        { name: "AES-GCM", iv, additionalData: new Uint8Array([version]) },
+        key,
+        data.slice(IVSize, data.length));
+    return new Uint8Array(decrypted);
+}
+
+const chunkNonce = (
+    prefix: Uint8Array,
+    index: number,
+    final: boolean,
+): Uint8Array => {
+    let nonce = new Uint8Array(IVSize);
+    nonce.set(prefix);
+    new DataView(nonce.buffer).setUint32(NoncePrefixSize, index);
+    nonce[IVSize - 1] = final ? 1 : 0;
+    return nonce;
+}
+
 /**
  * Generate an argon2 hash from a provided payload/password and salt.
  * @param payload
  * @param salt
+ * @param params - the argon2id params (defaults to the legacy params)
  */
 export const generateArgon2Key = async (
     payload: string,
     salt: Uint8Array,
+    params: KDFParams = legacyKDFParams(),
 ): Promise<CryptoKey> => {
     await sodium.ready;
 
@@ -201,8 +782,8 @@ export const generateArgon2Key = async (
         constants.KeySize,
         sodium.from_string(payload),
         salt,
-        constants.Argon2Iter,
-        constants.Argon2Mem * 1024 * 1024,
+        params.iterations,
+        params.memory * 1024 * 1024,
         sodium.crypto_pwhash_ALG_ARGON2ID13
     );
 
@@ -214,14 +795,19 @@ export const generateArgon2Key = async (
  * their identifier (email or account ID) as the salt.
  * @param identifier {string} - the user's email or account ID
  * @param password {string} - the user's password
+ * @param params {KDFParams} - the account's KDF params (defaults to the legacy params)
  * @returns {Promise<CryptoKey>}
  */
 export const generateUserKey = async (
     identifier: string,
     password: string,
+    params?: KDFParams,
 ): Promise<CryptoKey> => {
     let emailHash = hashBlake2b(16, identifier);
-    return await generateArgon2Key(password, emailHash);
+    return await deriveKDFKey(
+        password,
+        emailHash,
+        kdfOrDefault(params, legacyKDFParams()));
 }
 
 /**
@@ -230,17 +816,22 @@ export const generateUserKey = async (
  * of that login key.
  * @param userKey {CryptoKey} - the user's user key from generateUserKey
  * @param password {string} - the user's password
+ * @param params {KDFParams} - the account's KDF params (defaults to the legacy params)
  * @returns {Promise<Uint8Array>}
  */
 export const generateLoginKeyHash = async (
     userKey: CryptoKey,
     password: string,
+    params?: KDFParams,
 ): Promise<Uint8Array> => {
     let userKeyExported = await exportKey(userKey, "raw");
     let userKeyHex = toHexString(userKeyExported);
     let pwHash = hashBlake2b(16, password);
 
-    let loginKey = await generateArgon2Key(userKeyHex, new Uint8Array(pwHash));
+    let loginKey = await deriveKDFKey(
+        userKeyHex,
+        new Uint8Array(pwHash),
+        kdfOrDefault(params, legacyKDFParams()));
     let loginKeyBytes = await exportKey(loginKey, "raw");
     let loginKeyHash = await webcrypto.subtle.digest("SHA-256", loginKeyBytes);
 
@@ -405,7 +996,8 @@ export const ingestPublicKey = (
             name: "RSA-OAEP",
             hash: { name: "SHA-256" }
         },
-        false,
+        // Public keys are exportable so that their fingerprint can be shown
+        true,
         ["encrypt"]
     ).catch((error: Error) => {
         console.error("Error re-importing vault key:", error);
@@ -470,13 +1062,18 @@ export const generateKeyPair = async (): Promise<CryptoKeyPair> => {
  * child's key.
  * @param privateKey {CryptoKey}
  * @param keySequence {Uint8Array[]}
+ * @param x25519Keys {X25519KeyPair}
  */
-export const unwindKeys = async (privateKey: CryptoKey, keySequence: Uint8Array[]) => {
+export const unwindKeys = async (
+    privateKey: CryptoKey,
+    keySequence: Uint8Array[],
+    x25519Keys: X25519KeyPair | null = null,
+) => {
     let parentKey;
     for (let i = 0; i < keySequence.length; i++) {
         if (!parentKey) {
             let protectedKey = keySequence[i];
-            parentKey = await decryptRSA(privateKey, protectedKey);
+            parentKey = await unwrapKey(privateKey, x25519Keys, protectedKey);
             continue;
         }
 
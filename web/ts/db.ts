@@ -25,6 +25,8 @@ export class YeetFileDB {
     private readonly privateKeyID: number;
     private readonly publicKeyID: number;
     private readonly passwordProtectedID: number;
+    private readonly x25519PrivateKeyID: number;
+    private readonly x25519PublicKeyID: number;
 
     private readonly longWordlistID: number;
     private readonly shortWordlistID: number;
@@ -49,11 +51,12 @@ export class YeetFileDB {
         publicKey: Uint8Array,
         password: string,
         callback: (success: boolean) => void,
+        x25519Keys?: crypto.X25519KeyPair | null,
     ) => void;
     getVaultKeyPair: (
         password: string,
         rawExport: boolean,
-    ) => Promise<[CryptoKey | Uint8Array, CryptoKey | Uint8Array]>;
+    ) => Promise<[CryptoKey | Uint8Array, CryptoKey | Uint8Array, crypto.X25519KeyPair | null, CryptoKey]>;
     removeKeys: (callback: (success: boolean) => void) => void;
     storeWordlists: (
         long: Array<string>,
@@ -77,6 +80,8 @@ export class YeetFileDB {
         this.privateKeyID = 1;
         this.publicKeyID = 2;
         this.passwordProtectedID = 3;
+        this.x25519PrivateKeyID = 4;
+        this.x25519PublicKeyID = 5;
 
         this.longWordlistID = 1;
         this.shortWordlistID = 2;
@@ -98,12 +103,14 @@ export class YeetFileDB {
          * @param publicKey {Uint8Array}
          * @param password {string}
          * @param callback {function(boolean)}
+         * @param x25519Keys {crypto.X25519KeyPair} - the user's X25519 keys, if any
          */
         this.insertVaultKeyPair = async (
             privateKey: Uint8Array,
             publicKey: Uint8Array,
             password: string,
             callback: (arg: boolean) => void,
+            x25519Keys: crypto.X25519KeyPair | null = null,
         ) => {
             this.removeKeys(() => { });
 
@@ -118,6 +125,9 @@ export class YeetFileDB {
 
             // Replaced w/ random value on each request (needs to be cached by browser)
             let encPrivKey = await crypto.encryptChunk(encKey, privateKey);
+            let encX25519Key = x25519Keys ?
+                await crypto.encryptChunk(encKey, x25519Keys.privateKey) :
+                null;
 
             let request = indexedDB.open(this.dbName, this.dbVersion);
             request.onsuccess = async (event: Event) => {
@@ -159,6 +169,17 @@ export class YeetFileDB {
                         console.error("Error storing pw protection flag:", error);
                         callback(false);
                     };
+
+                    if (x25519Keys) {
+                        objectStore.put({
+                            id: this.x25519PrivateKeyID,
+                            key: encX25519Key
+                        });
+                        objectStore.put({
+                            id: this.x25519PublicKeyID,
+                            key: x25519Keys.publicKey
+                        });
+                    }
                 } catch (error) {
                     console.error("Error during put operations:", error);
                 }
@@ -221,7 +242,7 @@ export class YeetFileDB {
         this.getVaultKeyPair = (
             password: string,
             rawExport: boolean,
-        ): Promise<[CryptoKey | Uint8Array, CryptoKey | Uint8Array]> => {
+        ): Promise<[CryptoKey | Uint8Array, CryptoKey | Uint8Array, crypto.X25519KeyPair | null, CryptoKey]> => {
             return new Promise((resolve, reject) => {
                 let request = indexedDB.open(this.dbName, this.dbVersion);
 
@@ -245,9 +266,16 @@ export class YeetFileDB {
                     let objectStore = transaction.objectStore(this.keysObjectStore);
 
                     try {
-                        const [privateKeyResult, publicKeyResult] = await Promise.all([
+                        const [
+                            privateKeyResult,
+                            publicKeyResult,
+                            x25519PrivateKeyResult,
+                            x25519PublicKeyResult,
+                        ] = await Promise.all([
                             this.requestToPromise<VaultKeyEntry>(objectStore.get(this.privateKeyID)),
-                            this.requestToPromise<VaultKeyEntry>(objectStore.get(this.publicKeyID))
+                            this.requestToPromise<VaultKeyEntry>(objectStore.get(this.publicKeyID)),
+                            this.requestToPromise<VaultKeyEntry>(objectStore.get(this.x25519PrivateKeyID)),
+                            this.requestToPromise<VaultKeyEntry>(objectStore.get(this.x25519PublicKeyID)),
                         ]);
 
                         if (!privateKeyResult || !publicKeyResult) {
@@ -274,12 +302,34 @@ export class YeetFileDB {
                             return;
                         }
 
+                        // X25519 keys are only present for users whose client
+                        // has added them to their account
+                        let x25519Keys: crypto.X25519KeyPair | null = null;
+                        if (x25519PrivateKeyResult && x25519PublicKeyResult) {
+                            try {
+                                x25519Keys = {
+                                    privateKey: new Uint8Array(await crypto.decryptChunk(
+                                        decKey, x25519PrivateKeyResult.key as Uint8Array)),
+                                    publicKey: x25519PublicKeyResult.key as Uint8Array,
+                                };
+                            } catch (error) {
+                                console.error("Unable to decrypt x25519 key:", error);
+                                reject("Unable to decrypt private key");
+                                return;
+                            }
+                        }
+
+                        // The contacts key is derived while the raw private
+                        // key is available, since the imported key can't be
+                        // exported again
+                        let contactsKey = await crypto.deriveContactsKey(privateKeyBytes);
+
                         if (rawExport) {
-                            resolve([privateKeyBytes, publicKeyBytes]);
+                            resolve([privateKeyBytes, publicKeyBytes, x25519Keys, contactsKey]);
                         } else {
                             crypto.ingestProtectedKey(privateKeyBytes, privateKey => {
                                 crypto.ingestPublicKey(publicKeyBytes, async publicKey => {
-                                    resolve([privateKey, publicKey]);
+                                    resolve([privateKey, publicKey, x25519Keys, contactsKey]);
                                 });
                             });
                         }
@@ -1,4 +1,5 @@
 import {YeetFileDB} from "../db";
+import {X25519KeyPair} from "../crypto";
 
 export class ProtectedVaultDialog {
     dialog: HTMLDialogElement;
@@ -18,12 +19,17 @@ export class ProtectedVaultDialog {
     /**
      * Display a dialog for the current vault password (if one was set when logging in)
      * @param yeetfileDB {YeetFileDB} - The yeetfile indexeddb instance
-     * @param callback {function(CryptoKey, CryptoKey)}
+     * @param callback {function(CryptoKey, CryptoKey, X25519KeyPair, CryptoKey)}
      * @param errorMsg {string|null}
      */
     show = (
         yeetfileDB: YeetFileDB,
-        callback: (privKey: CryptoKey, pubKey: CryptoKey) => void,
+        callback: (
+            privKey: CryptoKey,
+            pubKey: CryptoKey,
+            x25519Keys: X25519KeyPair | null,
+            contactsKey: CryptoKey,
+        ) => void,
         errorMsg: string|null,
     ) => {
         this.input.value = "";
@@ -40,8 +46,8 @@ export class ProtectedVaultDialog {
         this.submit.addEventListener("click", async () => {
             let password = this.input.value;
             this.dialog.close();
-            yeetfileDB.getVaultKeyPair(password, false).then(([privKey, pubKey]) => {
-                callback(privKey as CryptoKey, pubKey as CryptoKey);
+            yeetfileDB.getVaultKeyPair(password, false).then(([privKey, pubKey, x25519Keys, contactsKey]) => {
+                callback(privKey as CryptoKey, pubKey as CryptoKey, x25519Keys, contactsKey);
             }).catch(e => {
                 this.show(yeetfileDB, callback, e);
                 return;
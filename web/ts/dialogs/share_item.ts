@@ -1,3 +1,6 @@
+import * as contacts from "../contacts.js";
+import * as crypto from "../crypto.js";
+import * as interfaces from "../interfaces.js";
 import * as transfer from "../transfer.js";
 import {closeDialog, DialogSignal} from "./dialogs.js";
 
@@ -8,6 +11,15 @@ export class ShareContentDialog {
 
     submit: HTMLButtonElement;
     cancel: HTMLButtonElement;
+    check: HTMLButtonElement;
+
+    fingerprints: HTMLElement;
+    keyWarning: HTMLElement;
+    fingerprintUser: HTMLElement;
+    fingerprintTheirs: HTMLElement;
+    fingerprintYours: HTMLElement;
+    verified: HTMLElement;
+    verify: HTMLButtonElement;
 
     loading: HTMLElement;
     table: HTMLTableElement;
@@ -24,6 +36,15 @@ export class ShareContentDialog {
 
         this.submit = document.getElementById("submit-share") as HTMLButtonElement;
         this.cancel = document.getElementById("cancel-share") as HTMLButtonElement;
+        this.check = document.getElementById("check-share") as HTMLButtonElement;
+
+        this.fingerprints = document.getElementById("share-fingerprints");
+        this.keyWarning = document.getElementById("share-key-warning");
+        this.fingerprintUser = document.getElementById("share-fingerprint-user");
+        this.fingerprintTheirs = document.getElementById("share-fingerprint-theirs");
+        this.fingerprintYours = document.getElementById("share-fingerprint-yours");
+        this.verified = document.getElementById("share-verified");
+        this.verify = document.getElementById("verify-share") as HTMLButtonElement;
 
         this.loading = document.getElementById("share-loading");
         this.table = document.getElementById("share-table") as HTMLTableElement;
@@ -35,15 +56,18 @@ export class ShareContentDialog {
      * @param id {string} - The file or folder ID
      * @param rawKey {ArrayBuffer} - The unencrypted key for the item
      * @param isFolder {boolean} - True if the item is a folder
+     * @param keys {contacts.VaultKeys} - The user's keys, used for verifying contacts
      * @param callback {function(DialogSignal)} - Callback indicating the action performed
      */
     show = (
         id: string,
         rawKey: ArrayBuffer,
         isFolder: boolean,
+        keys: contacts.VaultKeys,
         callback: (s: DialogSignal) => void,
     ) => {
         this.init();
+        this.fingerprints.classList.add("hidden");
         this.loading.style.display = "inherit";
         this.table.style.display = "none";
         this.tableBody.innerHTML = "";
@@ -73,7 +97,35 @@ export class ShareContentDialog {
 
             let target = this.target.value;
             let canModify = this.modify.checked;
-            transfer.shareItem(target, rawKey, id, canModify, isFolder).then(response => {
+            let recipientKeys: interfaces.PubKeyResponse;
+            try {
+                let status: contacts.ContactStatus;
+                let stale: boolean;
+                [recipientKeys, status, stale] = await this.checkRecipient(keys, target);
+                if (status === contacts.ContactStatus.Changed && !confirm(
+                    `WARNING: The keys for '${target}' have changed since you ` +
+                    `verified them. This can mean that the server is trying ` +
+                    `to intercept what you share with them.\n\n` +
+                    `Only continue if you've compared the new fingerprint ` +
+                    `with them directly. Share anyway?`)) {
+                    updateButton(this.submit, false, "Share");
+                    return;
+                } else if (stale && !confirm(
+                    `WARNING: Your verified contacts on the server are ` +
+                    `missing or older than the copy last seen in this ` +
+                    `browser. This can mean that the server is hiding ` +
+                    `contacts you've verified.\n\n` +
+                    `Only continue if you've compared the fingerprint for ` +
+                    `'${target}' with them directly. Share anyway?`)) {
+                    updateButton(this.submit, false, "Share");
+                    return;
+                }
+            } catch {
+                updateButton(this.submit, false, "Share");
+                return;
+            }
+
+            transfer.shareItem(target, recipientKeys, rawKey, id, canModify, isFolder).then(response => {
                 let name = target;
                 if (!target.includes("@")) {
                     name = "*" + name.substring(name.length - 4, name.length);
@@ -91,6 +143,22 @@ export class ShareContentDialog {
             });
         });
 
+        this.check.addEventListener("click", async event => {
+            event.stopPropagation();
+
+            if (this.target.value.length === 0) {
+                alert("Must enter a YeetFile user's email or account ID to check");
+                return;
+            }
+
+            updateButton(this.check, true, "Checking...");
+            this.checkRecipient(keys, this.target.value).catch(() => {
+                // Errors have already been shown to the user
+            }).finally(() => {
+                updateButton(this.check, false, "Check Fingerprint");
+            });
+        });
+
         this.cancel.addEventListener("click", event => {
             event.stopPropagation();
             closeDialog(this.dialog);
@@ -99,6 +167,80 @@ export class ShareContentDialog {
 
         this.dialog.showModal();
     }
+
+    /**
+     * Fetch a recipient's public keys and check them against the user's
+     * pinned contacts, showing both users' fingerprints so that the user
+     * can verify the recipient's keys
+     * @param keys {contacts.VaultKeys} - The user's keys
+     * @param target {string} - The recipient's email or account ID
+     * @returns {Promise<[interfaces.PubKeyResponse, contacts.ContactStatus, boolean]>}
+     * - the recipient's keys, their contact status, and whether the user's
+     * verified contacts on the server are stale
+     */
+    checkRecipient = async (
+        keys: contacts.VaultKeys,
+        target: string,
+    ): Promise<[interfaces.PubKeyResponse, contacts.ContactStatus, boolean]> => {
+        let recipientKeys = await transfer.fetchPubKey(target);
+        let fingerprint = await crypto.fingerprint(
+            recipientKeys.publicKey,
+            recipientKeys.keys);
+
+        let pinned: interfaces.PinnedContact[];
+        let stale: boolean;
+        try {
+            [pinned, stale] = await contacts.loadContacts(keys);
+        } catch (error) {
+            alert("Unable to load verified contacts");
+            throw error;
+        }
+
+        let [status, contact] = contacts.checkContact(pinned, target, fingerprint);
+        this.fingerprintUser.innerText = target;
+        this.fingerprintTheirs.innerText = crypto.formatFingerprint(fingerprint);
+        this.fingerprintYours.innerText = crypto.formatFingerprint(
+            await contacts.ownFingerprint(keys));
+
+        if (status === contacts.ContactStatus.Changed) {
+            this.keyWarning.innerText = `WARNING: These keys don't match ` +
+                `the keys you verified for this user on ` +
+                `${contact.verified.toLocaleDateString()}. They may have ` +
+                `rotated their keys, or the server may be trying to ` +
+                `intercept what you share with them.`;
+            this.keyWarning.classList.remove("hidden");
+        } else if (stale) {
+            this.keyWarning.innerText = `WARNING: Your verified contacts ` +
+                `on the server are missing or older than the copy last ` +
+                `seen in this browser, so the server may be hiding ` +
+                `contacts that you've verified. Compare fingerprints ` +
+                `again before sharing.`;
+            this.keyWarning.classList.remove("hidden");
+        } else {
+            this.keyWarning.classList.add("hidden");
+        }
+
+        let isVerified = status === contacts.ContactStatus.Verified;
+        this.verified.classList.toggle("hidden", !isVerified);
+        this.verify.classList.toggle("hidden", isVerified);
+        this.verify.onclick = async event => {
+            event.stopPropagation();
+            updateButton(this.verify, true, "Saving...");
+            try {
+                await contacts.pinContact(keys, pinned, target, fingerprint);
+                this.keyWarning.classList.add("hidden");
+                this.verify.classList.add("hidden");
+                this.verified.classList.remove("hidden");
+            } catch {
+                alert("Unable to save verified contact");
+            }
+
+            updateButton(this.verify, false, "Mark as Verified");
+        };
+
+        this.fingerprints.classList.remove("hidden");
+        return [recipientKeys, status, stale];
+    }
 }
 
 const generateShareRow = (id, tableBody, recipient, isFolder, callback) => {
@@ -90,6 +90,8 @@ const showDownload = (name: string, download: interfaces.DownloadResponse, key:
     let downloadDiv = document.getElementById("download-prompt-div");
     downloadDiv.style.display = "inherit";
 
+    setupReportForm(download.id);
+
     downloadBtn.addEventListener("click", () => {
         downloadBtn.disabled = true;
         downloadBtn.innerText = "Downloading...";
@@ -120,6 +122,48 @@ const showDownload = (name: string, download: interfaces.DownloadResponse, key:
     })
 }
 
+const setupReportForm = (id: string) => {
+    let reportDiv = document.getElementById("report-div");
+    let reportLink = document.getElementById("report-link");
+    let reportForm = document.getElementById("report-form");
+    let reportSubmit = document.getElementById("report-submit") as HTMLButtonElement;
+
+    reportDiv.style.display = "inherit";
+    reportLink.addEventListener("click", event => {
+        event.preventDefault();
+        reportForm.style.display = "inherit";
+        reportLink.style.display = "none";
+    });
+
+    reportSubmit.addEventListener("click", () => {
+        let reason = document.getElementById("report-reason") as HTMLSelectElement;
+        let details = document.getElementById("report-details") as HTMLTextAreaElement;
+
+        let report = new interfaces.SendReport();
+        report.reason = reason.value;
+        report.details = details.value;
+
+        reportSubmit.disabled = true;
+        fetch(Endpoints.format(Endpoints.ReportSendFile, id), {
+            method: "POST",
+            body: JSON.stringify(report)
+        }).then(async response => {
+            if (!response.ok) {
+                alert("Error submitting report: " + await response.text());
+                reportSubmit.disabled = false;
+                return;
+            }
+
+            alert("Thank you, your report has been submitted for review.");
+            reportDiv.style.display = "none";
+        }).catch(error => {
+            alert("Error submitting report");
+            reportSubmit.disabled = false;
+            console.error(error);
+        });
+    });
+}
+
 const decryptName = async (key, name) => {
     let nameBytes = hexToBytes(name);
     return await crypto.decryptString(key, nameBytes);
@@ -193,9 +237,16 @@ const promptPassword = (download) => {
         setFormEnabled(false);
         updatePasswordBtn("Validating", true);
 
-        let [key, _] = await crypto.deriveSendingKey(password.value, secret);
-
-        setFormEnabled(true);
+        let key: CryptoKey;
+        try {
+            [key] = await crypto.deriveSendingKey(password.value, secret, download.kdf);
+        } catch (error) {
+            updatePasswordBtn("Submit", false);
+            alert(error.message);
+            return;
+        } finally {
+            setFormEnabled(true);
+        }
 
         decryptName(key, download.name).then(decryptedName => {
             showDownload(decryptedName, download, key);
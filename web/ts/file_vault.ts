@@ -1,13 +1,20 @@
 import { VaultView, VaultViewType, prep } from "./vault.js";
+import { X25519KeyPair } from "./crypto.js";
 
 const init = () => {
-    prep((privKey, pubKey) => {
-        loadVaultView(privKey, pubKey);
+    prep((privKey, pubKey, x25519Keys, contactsKey) => {
+        loadVaultView(privKey, pubKey, x25519Keys, contactsKey);
     });
 }
 
-const loadVaultView = (privKey: CryptoKey, pubKey: CryptoKey) => {
-    let vaultView = new VaultView(VaultViewType.FileVault, privKey, pubKey);
+const loadVaultView = (
+    privKey: CryptoKey,
+    pubKey: CryptoKey,
+    x25519Keys: X25519KeyPair | null,
+    contactsKey: CryptoKey,
+) => {
+    let vaultView = new VaultView(
+        VaultViewType.FileVault, privKey, pubKey, x25519Keys, contactsKey);
     vaultView.initialize();
 }
 
@@ -0,0 +1,228 @@
+import * as crypto from "./crypto.js";
+import * as constants from "./constants.js";
+import * as interfaces from "./interfaces.js";
+import { Endpoints } from "./endpoints.js";
+
+/**
+ * loadX25519Keys decrypts the user's X25519 key pair if they have one.
+ * Otherwise, a new key pair is generated and added to their account, along
+ * with a recovery copy of the private key if the user has a recovery key.
+ * Failing to add a new key pair doesn't prevent logging in, since the user's
+ * RSA key pair can still be used for everything.
+ * @param userKey {CryptoKey} - the key that the user's private keys are encrypted with
+ * @param keys {interfaces.UserKey[]} - the user's additional keys
+ * @returns {Promise<crypto.X25519KeyPair|null>}
+ */
+export const loadX25519Keys = async (
+    userKey: CryptoKey,
+    keys: interfaces.UserKey[],
+): Promise<crypto.X25519KeyPair | null> => {
+    for (let key of keys || []) {
+        if (key.type === constants.KeyTypeX25519) {
+            let privateKey = await crypto.decryptChunk(userKey, key.protectedKey);
+            return { privateKey: new Uint8Array(privateKey), publicKey: key.publicKey };
+        }
+    }
+
+    let keyPair = await crypto.generateX25519KeyPair();
+    let userKeyBody = new interfaces.UserKey();
+    userKeyBody.type = constants.KeyTypeX25519;
+    userKeyBody.publicKey = keyPair.publicKey;
+    userKeyBody.protectedKey = await crypto.encryptChunk(userKey, keyPair.privateKey);
+
+    try {
+        let recoveryStatus = await fetchRecoveryStatus();
+        if (recoveryStatus.enabled) {
+            userKeyBody.recoveryProtectedKey = await crypto.encryptX25519(
+                recoveryStatus.publicKey, keyPair.privateKey);
+        }
+    } catch (error) {
+        console.warn("Unable to fetch recovery key status:", error);
+        return null;
+    }
+
+    let response = await fetch(Endpoints.UserKeys.path, {
+        method: "PUT",
+        body: JSON.stringify(userKeyBody, jsonReplacer),
+    });
+
+    if (!response.ok) {
+        console.warn("Unable to add x25519 key pair:", await response.text());
+        return null;
+    }
+
+    return keyPair;
+}
+
+/**
+ * reencryptUserKeys decrypts each of the user's additional private keys with
+ * their current user key, and encrypts them with their new user key
+ * @param oldUserKey {CryptoKey}
+ * @param newUserKey {CryptoKey}
+ * @param keys {interfaces.UserKey[]}
+ * @returns {Promise<interfaces.UserKey[]>}
+ */
+export const reencryptUserKeys = async (
+    oldUserKey: CryptoKey,
+    newUserKey: CryptoKey,
+    keys: interfaces.UserKey[],
+): Promise<interfaces.UserKey[]> => {
+    let newKeys: interfaces.UserKey[] = [];
+    for (let key of keys || []) {
+        let privateKey = await crypto.decryptChunk(oldUserKey, key.protectedKey);
+        let newKey = new interfaces.UserKey();
+        newKey.type = key.type;
+        newKey.publicKey = key.publicKey;
+        newKey.protectedKey = await crypto.encryptChunk(newUserKey, new Uint8Array(privateKey));
+        newKeys.push(newKey);
+    }
+
+    return newKeys;
+}
+
+/**
+ * fetchRecoveryStatus returns whether the user has set up a recovery key
+ * @returns {Promise<interfaces.RecoveryStatus>}
+ */
+export const fetchRecoveryStatus = async (): Promise<interfaces.RecoveryStatus> => {
+    let response = await fetch(Endpoints.Recovery.path);
+    if (!response.ok) {
+        throw new Error(await response.text());
+    }
+
+    return new interfaces.RecoveryStatus(await response.json());
+}
+
+/**
+ * createRecoveryKey encrypts a copy of each of the user's private keys with a
+ * recovery key, so that they can be decrypted if the user forgets their
+ * password
+ * @param recoveryKey {Uint8Array} - the recovery key from generateRecoveryKey
+ * @param privateKey {Uint8Array} - the user's RSA private key
+ * @param keys {interfaces.UserKey[]} - the user's additional keys, with the
+ * private key in place of the protected key
+ * @returns {Promise<interfaces.RecoveryKey>}
+ */
+export const createRecoveryKey = async (
+    recoveryKey: Uint8Array,
+    privateKey: Uint8Array,
+    keys: interfaces.UserKey[],
+): Promise<interfaces.RecoveryKey> => {
+    let publicKey = await crypto.recoveryPublicKey(recoveryKey);
+
+    let recovery = new interfaces.RecoveryKey();
+    recovery.keyHash = await crypto.recoveryKeyHash(recoveryKey);
+    recovery.publicKey = publicKey;
+    recovery.protectedKey = await crypto.encryptX25519(publicKey, privateKey);
+    recovery.keys = [];
+    for (let key of keys || []) {
+        let recoveryUserKey = new interfaces.UserKey();
+        recoveryUserKey.type = key.type;
+        recoveryUserKey.publicKey = key.publicKey;
+        recoveryUserKey.recoveryProtectedKey = await crypto.encryptX25519(
+            publicKey, key.protectedKey);
+        recovery.keys.push(recoveryUserKey);
+    }
+
+    return recovery;
+}
+
+/**
+ * fetchKDFParams fetches the key derivation parameters for the account
+ * matching the identifier, along with the parameters recommended by the
+ * server. Servers that don't store per-account parameters only support the
+ * original ones.
+ * @param identifier {string} - the user's email or account ID
+ * @returns {Promise<interfaces.KDFResponse>}
+ */
+export const fetchKDFParams = async (
+    identifier: string,
+): Promise<interfaces.KDFResponse> => {
+    let url = Endpoints.KDF.path + "?identifier=" + encodeURIComponent(identifier);
+    let response = await fetch(url);
+
+    let kdf = new interfaces.KDFResponse();
+    if (response.ok) {
+        kdf = new interfaces.KDFResponse(await response.json());
+    } else if (response.status !== 404) {
+        throw new Error(await response.text());
+    }
+
+    return validateKDFResponse(kdf);
+}
+
+/**
+ * validateKDFResponse fills in the parameters for servers that predate stored
+ * KDF parameters, and ensures that the parameters are valid so that a server
+ * can't weaken the keys derived by the client
+ * @param kdf {interfaces.KDFResponse}
+ * @returns {interfaces.KDFResponse}
+ */
+export const validateKDFResponse = (
+    kdf: interfaces.KDFResponse,
+): interfaces.KDFResponse => {
+    kdf.kdf = crypto.kdfOrDefault(kdf.kdf, crypto.legacyKDFParams());
+    kdf.recommendedKdf = crypto.kdfOrDefault(kdf.recommendedKdf, kdf.kdf);
+    if (!crypto.isValidKDF(kdf.kdf) || !crypto.isValidKDF(kdf.recommendedKdf)) {
+        throw new Error("Invalid key derivation parameters");
+    }
+
+    return kdf;
+}
+
+/**
+ * upgradeKDF re-derives the user's keys using the server's recommended key
+ * derivation parameters if their account is still using weaker parameters.
+ * Failing to upgrade doesn't prevent logging in.
+ * @param identifier {string} - the user's email or account ID
+ * @param password {string} - the user's password
+ * @param userKey {CryptoKey} - the user key derived with the current params
+ * @param loginKeyHash {Uint8Array} - the login key hash for the current params
+ * @param kdf {interfaces.KDFResponse} - the current and recommended params
+ */
+export const upgradeKDF = async (
+    identifier: string,
+    password: string,
+    userKey: CryptoKey,
+    loginKeyHash: Uint8Array,
+    kdf: interfaces.KDFResponse,
+) => {
+    if (!crypto.isWeakerKDF(kdf.kdf, kdf.recommendedKdf)) {
+        return;
+    }
+
+    try {
+        let protectedKeyResponse = await fetch(Endpoints.ProtectedKey.path);
+        if (!protectedKeyResponse.ok) {
+            throw new Error(await protectedKeyResponse.text());
+        }
+
+        let protectedKeyData = new interfaces.ProtectedKeyResponse(
+            await protectedKeyResponse.json());
+        let privateKey = await crypto.decryptChunk(userKey, protectedKeyData.protectedKey);
+
+        let newUserKey = await crypto.generateUserKey(
+            identifier, password, kdf.recommendedKdf);
+
+        let changePassword = new interfaces.ChangePassword();
+        changePassword.oldLoginKeyHash = loginKeyHash;
+        changePassword.newLoginKeyHash = await crypto.generateLoginKeyHash(
+            newUserKey, password, kdf.recommendedKdf);
+        changePassword.protectedKey = await crypto.encryptChunk(
+            newUserKey, new Uint8Array(privateKey));
+        changePassword.protectedKeys = await reencryptUserKeys(
+            userKey, newUserKey, protectedKeyData.keys);
+        changePassword.kdf = kdf.recommendedKdf;
+
+        let response = await fetch(Endpoints.ChangePassword.path, {
+            method: "PUT",
+            body: JSON.stringify(changePassword, jsonReplacer),
+        });
+
+        if (!response.ok) {
+            console.warn("Unable to upgrade key derivation params:", await response.text());
+        }
+    } catch (error) {
+        console.warn("Unable to upgrade key derivation params:", error);
+    }
+}
@@ -88,4 +88,48 @@ export const setDefaultSendValues = (
     localStorage.setItem(defaultSendDownloadsKey, String(downloads));
     localStorage.setItem(defaultSendExpirationKey, String(expiration));
     localStorage.setItem(defaultSendExpirationUnitsKey, String(units.valueOf()));
-}
\ No newline at end of file
+}
+
+// =============================================================================
+// Pinned Contacts
+// =============================================================================
+
+const latestContactPinKey = "LatestContactPin";
+
+/**
+ * getLatestContactPin returns the time of the most recent pinned contact that
+ * this browser has seen for the user, or null if it hasn't seen any
+ * @param user {string} - an identifier for the user, such as their fingerprint
+ */
+export const getLatestContactPin = (user: string): Date | null => {
+    let value = fetchLocalStorageString(`${latestContactPinKey}-${user}`);
+    return value ? new Date(value) : null;
+}
+
+export const setLatestContactPin = (user: string, pinned: Date) => {
+    localStorage.setItem(`${latestContactPinKey}-${user}`, pinned.toISOString());
+}
+
+// =============================================================================
+// OIDC Login (session storage, since it only lasts for a single login)
+// =============================================================================
+
+const oidcRequestKey = "OIDCRequest";
+
+export type OIDCRequest = {
+    id: string,
+    token: string,
+}
+
+export const setOIDCRequest = (request: OIDCRequest) => {
+    sessionStorage.setItem(oidcRequestKey, JSON.stringify(request));
+}
+
+export const getOIDCRequest = (): OIDCRequest | null => {
+    let value = sessionStorage.getItem(oidcRequestKey);
+    return value ? JSON.parse(value) : null;
+}
+
+export const removeOIDCRequest = () => {
+    sessionStorage.removeItem(oidcRequestKey);
+}
@@ -1,7 +1,8 @@
 import * as crypto from "./crypto.js";
 import * as localstorage from "./localstorage.js";
 import { Endpoints } from "./endpoints.js";
-import { Login, LoginResponse } from "./interfaces.js";
+import { Login, LoginResponse, OIDCStartResponse } from "./interfaces.js";
+import { fetchKDFParams, loadX25519Keys, upgradeKDF } from "./keys.js";
 
 let vaultPasswordDialog;
 let twoFactorDialog;
@@ -30,6 +31,11 @@ const init = () => {
         }
     });
 
+    let oidcBtn = document.getElementById("oidc-login-btn") as HTMLButtonElement;
+    if (oidcBtn) {
+        oidcBtn.addEventListener("click", startOIDCLogin);
+    }
+
     vaultPasswordCB.checked = localstorage.getVaultPasswordSetting();
 
     // Enter key submits login form
@@ -74,8 +80,19 @@ const login = async (twoFactorCode: string) => {
         return;
     }
 
-    let userKey = await crypto.generateUserKey(identifier.value, password.value);
-    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password.value);
+    let kdf;
+    try {
+        kdf = await fetchKDFParams(identifier.value);
+    } catch (error) {
+        showMessage(`Error: ${error.message}`, true);
+        disableInputs(false);
+        return;
+    }
+
+    let userKey = await crypto.generateUserKey(
+        identifier.value, password.value, kdf.kdf);
+    let loginKeyHash = await crypto.generateLoginKeyHash(
+        userKey, password.value, kdf.kdf);
 
     let url = new URL(window.location.href);
     let params = new URLSearchParams(url.search);
@@ -106,9 +123,11 @@ const login = async (twoFactorCode: string) => {
             let privKey = new Uint8Array(await crypto.decryptChunk(
                 userKey, loginResponse.protectedKey));
             let pubKey = loginResponse.publicKey;
+            let x25519Keys = await loadX25519Keys(userKey, loginResponse.keys);
+            await upgradeKDF(identifier.value, password.value, userKey, loginKeyHash, kdf);
 
             if (vaultPasswordCB.checked) {
-                showVaultPassDialog(privKey, pubKey);
+                showVaultPassDialog(privKey, pubKey, x25519Keys);
             } else {
                 localstorage.disableVaultPasswordSetting()
                 const dbModule = await import('./db.js');
@@ -120,12 +139,35 @@ const login = async (twoFactorCode: string) => {
                         alert("Failed to insert vault keys into indexeddb");
                         window.location.assign(Endpoints.Logout.path);
                     }
-                });
+                }, x25519Keys);
             }
         }
     });
 }
 
+/**
+ * Starts logging in with the instance's identity provider. The request ID and
+ * token are kept for the rest of the login, which continues on the OIDC page
+ * once the provider redirects back to YeetFile.
+ */
+const startOIDCLogin = () => {
+    disableInputs(true);
+    fetch(Endpoints.OIDCStart.path, {
+        method: "POST",
+    }).then(async response => {
+        if (!response.ok) {
+            let errMsg = await response.text();
+            showMessage(`Error ${response.status}: ${errMsg}`, true);
+            disableInputs(false);
+            return;
+        }
+
+        let start = new OIDCStartResponse(await response.json());
+        localstorage.setOIDCRequest({id: start.id, token: start.token});
+        window.location.assign(start.authURL);
+    });
+}
+
 const isValidIdentifier = (identifier) => {
     if (identifier.includes("@")) {
         return true;
@@ -168,6 +210,7 @@ const showTwoFactorDialog = () => {
 const showVaultPassDialog = async (
     privKeyBytes: Uint8Array,
     pubKeyBytes: Uint8Array,
+    x25519Keys: crypto.X25519KeyPair | null,
 ) => {
     const dbModule = await import('./db.js');
     let db = new dbModule.YeetFileDB();
@@ -202,7 +245,8 @@ const showVaultPassDialog = async (
                 } else {
                     alert("Failed to insert keys into indexeddb");
                 }
-            });
+            },
+            x25519Keys);
     });
 
     vaultPasswordDialog.showModal();
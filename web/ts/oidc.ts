@@ -0,0 +1,382 @@
+import * as crypto from "./crypto.js";
+import * as interfaces from "./interfaces.js";
+import * as localstorage from "./localstorage.js";
+import { Endpoints } from "./endpoints.js";
+import {
+    fetchKDFParams,
+    loadX25519Keys,
+    upgradeKDF,
+    validateKDFResponse,
+} from "./keys.js";
+
+let statusText: HTMLParagraphElement;
+let fieldset: HTMLFieldSetElement;
+let promptText: HTMLParagraphElement;
+let passwordInput: HTMLInputElement;
+let confirmInput: HTMLInputElement;
+let serverPasswordInput: HTMLInputElement;
+let codeInput: HTMLInputElement;
+let submitBtn: HTMLButtonElement;
+
+const init = () => {
+    statusText = document.getElementById("oidc-status") as HTMLParagraphElement;
+    fieldset = document.getElementById("oidc-fieldset") as HTMLFieldSetElement;
+    promptText = document.getElementById("oidc-prompt") as HTMLParagraphElement;
+    passwordInput = document.getElementById("oidc-password") as HTMLInputElement;
+    confirmInput = document.getElementById("oidc-confirm-password") as HTMLInputElement;
+    serverPasswordInput = document.getElementById("oidc-server-password") as HTMLInputElement;
+    codeInput = document.getElementById("oidc-2fa-code") as HTMLInputElement;
+    submitBtn = document.getElementById("oidc-submit") as HTMLButtonElement;
+
+    let params = new URLSearchParams(window.location.search);
+    let request = localstorage.getOIDCRequest();
+    if (!request || request.id !== params.get("id")) {
+        // The login was started somewhere else, most likely the CLI, which
+        // finishes the login on its own once the user confirms its code
+        showCLIConfirm(params.get("id"));
+        return;
+    }
+
+    checkStatus(request);
+}
+
+/**
+ * Confirms a login started from the CLI using the code shown in the user's
+ * terminal, so that the CLI can finish logging in.
+ */
+const showCLIConfirm = (id: string) => {
+    statusText.innerText = "";
+    let cliFieldset = document.getElementById("oidc-cli-fieldset") as HTMLFieldSetElement;
+    let cliCodeInput = document.getElementById("oidc-cli-code") as HTMLInputElement;
+    let cliSubmitBtn = document.getElementById("oidc-cli-submit") as HTMLButtonElement;
+    cliFieldset.classList.remove("hidden");
+
+    cliSubmitBtn.addEventListener("click", async () => {
+        let confirm = new interfaces.OIDCConfirm();
+        confirm.id = id;
+        confirm.code = cliCodeInput.value.trim();
+
+        cliFieldset.disabled = true;
+        let response = await fetch(Endpoints.OIDCConfirm.path, {
+            method: "POST",
+            body: JSON.stringify(confirm),
+        });
+
+        cliFieldset.classList.add("hidden");
+        if (!response.ok) {
+            showMessage(`Error: ${await response.text()}`, true);
+            return;
+        }
+
+        statusText.innerText = "You've been authenticated. You can return " +
+            "to your terminal to finish logging in to the YeetFile CLI.";
+    });
+
+    registerEnterKeySubmit(cliSubmitBtn);
+    cliCodeInput.focus();
+}
+
+const fetchStatus = (request: localstorage.OIDCRequest, code: string) => {
+    let statusReq = new interfaces.OIDCStatusRequest();
+    statusReq.id = request.id;
+    statusReq.token = request.token;
+    statusReq.code = code;
+
+    return fetch(Endpoints.OIDCStatus.path, {
+        method: "POST",
+        body: JSON.stringify(statusReq),
+    });
+}
+
+const checkStatus = (request: localstorage.OIDCRequest) => {
+    fetchStatus(request, "").then(async response => {
+        if (!response.ok) {
+            localstorage.removeOIDCRequest();
+            statusText.innerText = "";
+            showMessage(`Error: ${await response.text()}`, true);
+            return;
+        }
+
+        let status = new interfaces.OIDCStatusResponse(await response.json());
+        switch (status.status) {
+            case "login":
+                if (!status.twoFactorRequired) {
+                    localstorage.removeOIDCRequest();
+                }
+
+                showPrompt(
+                    `Enter the vault passphrase for ${status.identifier} to unlock your vault.`,
+                    false,
+                    status.twoFactorRequired,
+                    false,
+                    async password => login(request, status, password));
+                break;
+            case "link":
+                showPrompt(
+                    `An account already exists for ${status.identifier}. Enter ` +
+                    `its password to link it to your single sign-on login.`,
+                    false,
+                    status.twoFactorRequired,
+                    false,
+                    async password => linkAccount(request, status.identifier, password));
+                break;
+            case "signup":
+                showPrompt(
+                    "Choose a vault passphrase for your new account. This is " +
+                    "separate from your single sign-on password, and is " +
+                    "needed to decrypt your files and passwords.",
+                    true,
+                    false,
+                    status.serverPasswordRequired,
+                    async password => signup(request, status.identifier, password));
+                break;
+            case "error":
+                localstorage.removeOIDCRequest();
+                statusText.innerText = "";
+                showMessage(`Error: ${status.error}`, true);
+                break;
+            default:
+                statusText.innerText = "";
+                showMessage("Login hasn't been completed yet", true);
+        }
+    });
+}
+
+const showPrompt = (
+    prompt: string,
+    confirm: boolean,
+    twoFactor: boolean,
+    serverPassword: boolean,
+    callback: (password: string) => Promise<void>,
+) => {
+    statusText.innerText = "";
+    promptText.innerText = prompt;
+    fieldset.classList.remove("hidden");
+    if (confirm) {
+        confirmInput.classList.remove("hidden");
+    }
+
+    if (twoFactor) {
+        codeInput.classList.remove("hidden");
+    }
+
+    if (serverPassword) {
+        serverPasswordInput.classList.remove("hidden");
+    }
+
+    submitBtn.addEventListener("click", async () => {
+        let password = passwordInput.value;
+        if (!password) {
+            showMessage("Missing passphrase", true);
+            return;
+        } else if (confirm && password !== confirmInput.value) {
+            showMessage("Passphrases do not match", true);
+            return;
+        } else if (confirm && password.length < 8) {
+            showMessage("Passphrase must be at least 8 characters long", true);
+            return;
+        } else if (twoFactor && !codeInput.value) {
+            showMessage("Missing two-factor code", true);
+            return;
+        }
+
+        setLoading(true);
+        await callback(password);
+    });
+
+    registerEnterKeySubmit(submitBtn);
+    passwordInput.focus();
+}
+
+const setLoading = (loading: boolean) => {
+    fieldset.disabled = loading;
+    let spinner = document.getElementById("oidc-spinner");
+    spinner.style.display = loading ? "inline" : "none";
+}
+
+/**
+ * Finishes logging in to an account that's already linked to the single
+ * sign-on identity. Accounts with two-factor authentication enabled need to
+ * provide their code before the account's keys are returned.
+ */
+const login = async (
+    request: localstorage.OIDCRequest,
+    status: interfaces.OIDCStatusResponse,
+    password: string,
+) => {
+    if (status.twoFactorRequired) {
+        let response = await fetchStatus(request, codeInput.value.trim());
+        localstorage.removeOIDCRequest();
+        if (!response.ok) {
+            fieldset.classList.add("hidden");
+            showMessage(`Error: ${await response.text()}`, true);
+            return;
+        }
+
+        status = new interfaces.OIDCStatusResponse(await response.json());
+    }
+
+    await unlockVault(status.identifier, password, status);
+}
+
+/**
+ * Decrypts the user's private key with their vault passphrase and stores
+ * their keys for the session.
+ */
+const unlockVault = async (
+    identifier: string,
+    password: string,
+    keys: interfaces.OIDCStatusResponse,
+) => {
+    let kdf: interfaces.KDFResponse;
+    try {
+        let kdfResponse = new interfaces.KDFResponse();
+        kdfResponse.kdf = keys.kdf;
+        kdfResponse.recommendedKdf = keys.recommendedKdf;
+        kdf = validateKDFResponse(kdfResponse);
+    } catch (error) {
+        showMessage(`Error: ${error.message}`, true);
+        setLoading(false);
+        return;
+    }
+
+    let userKey = await crypto.generateUserKey(identifier, password, kdf.kdf);
+    let privKey: Uint8Array;
+    try {
+        privKey = new Uint8Array(await crypto.decryptChunk(userKey, keys.protectedKey));
+    } catch {
+        showMessage("Incorrect vault passphrase", true);
+        setLoading(false);
+        return;
+    }
+
+    let x25519Keys = await loadX25519Keys(userKey, keys.keys);
+    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password, kdf.kdf);
+    await upgradeKDF(identifier, password, userKey, loginKeyHash, kdf);
+    await storeKeys(privKey, keys.publicKey, x25519Keys);
+}
+
+/**
+ * Links the single sign-on identity to an existing account after the user
+ * confirms their account password.
+ */
+const linkAccount = async (
+    request: localstorage.OIDCRequest,
+    identifier: string,
+    password: string,
+) => {
+    let kdf: interfaces.KDFResponse;
+    try {
+        kdf = await fetchKDFParams(identifier);
+    } catch (error) {
+        showMessage(`Error: ${error.message}`, true);
+        setLoading(false);
+        return;
+    }
+
+    let userKey = await crypto.generateUserKey(identifier, password, kdf.kdf);
+    let link = new interfaces.OIDCLink();
+    link.id = request.id;
+    link.token = request.token;
+    link.loginKeyHash = await crypto.generateLoginKeyHash(userKey, password, kdf.kdf);
+    link.code = codeInput.value.trim();
+
+    let response = await fetch(Endpoints.OIDCLink.path, {
+        method: "POST",
+        body: JSON.stringify(link, jsonReplacer),
+    });
+
+    if (!response.ok) {
+        showMessage(`Error: ${await response.text()}`, true);
+        setLoading(false);
+        return;
+    }
+
+    localstorage.removeOIDCRequest();
+    let keys = new interfaces.OIDCStatusResponse(await response.json());
+    await unlockVault(identifier, password, keys);
+}
+
+/**
+ * Creates a new account for the single sign-on identity, using keys derived
+ * from the user's new vault passphrase.
+ */
+const signup = async (
+    request: localstorage.OIDCRequest,
+    identifier: string,
+    password: string,
+) => {
+    let kdf: interfaces.KDFParams;
+    try {
+        kdf = (await fetchKDFParams(identifier)).recommendedKdf;
+    } catch (error) {
+        showMessage(`Error: ${error.message}`, true);
+        setLoading(false);
+        return;
+    }
+
+    let userKey = await crypto.generateUserKey(identifier, password, kdf);
+    let keyPair = await crypto.generateKeyPair();
+    let publicKey = await crypto.exportKey(keyPair.publicKey, "spki");
+    let privateKey = await crypto.exportKey(keyPair.privateKey, "pkcs8");
+    let vaultFolderKey = await crypto.generateRandomKey();
+
+    let oidcSignup = new interfaces.OIDCSignup();
+    oidcSignup.id = request.id;
+    oidcSignup.token = request.token;
+    oidcSignup.loginKeyHash = await crypto.generateLoginKeyHash(userKey, password, kdf);
+    oidcSignup.publicKey = publicKey;
+    oidcSignup.protectedPrivateKey = await crypto.encryptChunk(userKey, privateKey);
+    oidcSignup.protectedVaultFolderKey = await crypto.encryptRSA(
+        keyPair.publicKey, vaultFolderKey);
+    oidcSignup.kdf = kdf;
+    oidcSignup.serverPassword = serverPasswordInput.value;
+
+    let response = await fetch(Endpoints.OIDCSignup.path, {
+        method: "POST",
+        body: JSON.stringify(oidcSignup, jsonReplacer),
+    });
+
+    if (!response.ok) {
+        showMessage(`Error: ${await response.text()}`, true);
+        setLoading(false);
+        return;
+    }
+
+    localstorage.removeOIDCRequest();
+    if (response.status === 202) {
+        fieldset.classList.add("hidden");
+        showMessage(`Your account (${identifier}) has been created, but must ` +
+            "be approved by an admin before you can log in.", false);
+        return;
+    }
+
+    let x25519Keys = await loadX25519Keys(userKey, []);
+    await storeKeys(privateKey, publicKey, x25519Keys);
+}
+
+const storeKeys = async (
+    privKey: Uint8Array,
+    pubKey: Uint8Array,
+    x25519Keys: crypto.X25519KeyPair | null,
+) => {
+    localstorage.disableVaultPasswordSetting();
+    const dbModule = await import("./db.js");
+    let db = new dbModule.YeetFileDB();
+    db.insertVaultKeyPair(privKey, pubKey, "", success => {
+        if (success) {
+            window.location.assign(Endpoints.HTMLAccount.path);
+        } else {
+            alert("Failed to insert vault keys into indexeddb");
+            window.location.assign(Endpoints.Logout.path);
+        }
+    }, x25519Keys);
+}
+
+if (document.readyState !== "loading") {
+    init();
+} else {
+    document.addEventListener("DOMContentLoaded", () => {
+        init();
+    });
+}
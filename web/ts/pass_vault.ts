@@ -1,4 +1,5 @@
 import {VaultView, VaultViewType, prep} from "./vault.js";
+import {X25519KeyPair} from "./crypto.js";
 import {YeetFileDB} from "./db.js";
 import {Endpoints} from "./endpoints.js";
 
@@ -16,8 +17,8 @@ const shortWordlistURL = Endpoints.format(
 const db = new YeetFileDB();
 
 const init = () => {
-    prep((privKey, pubKey) => {
-        loadVaultView(privKey, pubKey);
+    prep((privKey, pubKey, x25519Keys, contactsKey) => {
+        loadVaultView(privKey, pubKey, x25519Keys, contactsKey);
     });
 
     loadWordLists();
@@ -53,8 +54,14 @@ const fetchWordlists = () => {
     });
 }
 
-const loadVaultView = (privKey: CryptoKey, pubKey: CryptoKey) => {
-    let vaultView = new VaultView(VaultViewType.PassVault, privKey, pubKey);
+const loadVaultView = (
+    privKey: CryptoKey,
+    pubKey: CryptoKey,
+    x25519Keys: X25519KeyPair | null,
+    contactsKey: CryptoKey,
+) => {
+    let vaultView = new VaultView(
+        VaultViewType.PassVault, privKey, pubKey, x25519Keys, contactsKey);
     vaultView.initialize();
 }
 
@@ -0,0 +1,136 @@
+import * as crypto from "./crypto.js";
+import {Endpoints} from "./endpoints.js";
+import * as interfaces from "./interfaces.js";
+
+let submitBtn: HTMLButtonElement;
+let inputFields: HTMLFieldSetElement;
+
+const init = () => {
+    inputFields = document.getElementById("input-fields") as HTMLFieldSetElement;
+    submitBtn = document.getElementById("recover-btn") as HTMLButtonElement;
+    submitBtn.addEventListener("click", submitRecovery);
+
+    document.addEventListener("keydown", (event: KeyboardEvent) => {
+        if (event.key === "Enter") {
+            submitBtn.click();
+        }
+    });
+}
+
+const inputsDisabled = (disabled: boolean) => {
+    submitBtn.disabled = disabled;
+    inputFields.disabled = disabled;
+}
+
+const submitRecovery = async () => {
+    let id = document.getElementById("identifier") as HTMLInputElement;
+    let recoveryKeyInput = document.getElementById("recovery-key") as HTMLInputElement;
+    let newPw = document.getElementById("new-password") as HTMLInputElement;
+    let newPwConfirm = document.getElementById("new-password-confirm") as HTMLInputElement;
+
+    if (!id.value || !recoveryKeyInput.value) {
+        showMessage("You must fill out all available fields", true);
+        return;
+    } else if (newPw.value !== newPwConfirm.value) {
+        showMessage("Passwords don't match", true);
+        return;
+    } else if (newPw.value.length < 8) {
+        showMessage("Password must be at least 8 characters long", true);
+        return;
+    }
+
+    let recoveryKey: Uint8Array;
+    try {
+        recoveryKey = crypto.parseRecoveryKey(recoveryKeyInput.value);
+    } catch (error) {
+        showMessage("Invalid recovery key", true);
+        return;
+    }
+
+    inputsDisabled(true);
+    let keyHash = await crypto.recoveryKeyHash(recoveryKey);
+    let recoveryKeys: interfaces.RecoveryKeysResponse;
+    try {
+        let request = new interfaces.RecoveryKeysRequest();
+        request.identifier = id.value;
+        request.keyHash = keyHash;
+
+        let response = await fetch(Endpoints.RecoveryStart.path, {
+            method: "POST",
+            body: JSON.stringify(request, jsonReplacer),
+        });
+
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+
+        recoveryKeys = new interfaces.RecoveryKeysResponse(await response.json());
+    } catch (error) {
+        inputsDisabled(false);
+        showMessage("Error recovering account: " + error.message, true);
+        return;
+    }
+
+    if (!crypto.isValidKDF(recoveryKeys.kdf)) {
+        inputsDisabled(false);
+        showMessage("Invalid key derivation parameters", true);
+        return;
+    }
+
+    let recoverAccount = new interfaces.RecoverAccount();
+    try {
+        let privateKey = await crypto.decryptX25519(
+            recoveryKey, recoveryKeys.protectedKey);
+
+        let newUserKey = await crypto.generateUserKey(
+            id.value, newPw.value, recoveryKeys.kdf);
+
+        recoverAccount.identifier = id.value;
+        recoverAccount.keyHash = keyHash;
+        recoverAccount.newLoginKeyHash = await crypto.generateLoginKeyHash(
+            newUserKey, newPw.value, recoveryKeys.kdf);
+        recoverAccount.protectedKey = await crypto.encryptChunk(newUserKey, privateKey);
+        recoverAccount.protectedKeys = [];
+        recoverAccount.kdf = recoveryKeys.kdf;
+
+        for (let key of recoveryKeys.keys || []) {
+            let keyPrivateKey = await crypto.decryptX25519(
+                recoveryKey, key.recoveryProtectedKey);
+            let newKey = new interfaces.UserKey();
+            newKey.type = key.type;
+            newKey.publicKey = key.publicKey;
+            newKey.protectedKey = await crypto.encryptChunk(newUserKey, keyPrivateKey);
+            recoverAccount.protectedKeys.push(newKey);
+        }
+    } catch (error) {
+        console.error(error);
+        inputsDisabled(false);
+        showMessage("Decryption error", true);
+        return;
+    }
+
+    fetch(Endpoints.RecoveryFinish.path, {
+        method: "POST",
+        body: JSON.stringify(recoverAccount, jsonReplacer),
+    }).then(async response => {
+        if (response.ok) {
+            alert("Your password has been reset! You can now log in with your new password.");
+            window.location.assign(Endpoints.HTMLLogin.path);
+        } else {
+            inputsDisabled(false);
+            showMessage("Error resetting password: " + await response.text(), true);
+        }
+    }).catch(error => {
+        inputsDisabled(false);
+        console.error(error);
+        alert("Error resetting password!");
+    });
+}
+
+if (document.readyState !== "loading") {
+    init();
+} else {
+    document.addEventListener("DOMContentLoaded", () => {
+        init();
+    });
+}
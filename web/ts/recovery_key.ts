@@ -0,0 +1,161 @@
+import * as crypto from "./crypto.js";
+import {Endpoints} from "./endpoints.js";
+import {createRecoveryKey, fetchKDFParams, fetchRecoveryStatus} from "./keys.js";
+import * as interfaces from "./interfaces.js";
+
+let generateBtn: HTMLButtonElement;
+let disableBtn: HTMLButtonElement;
+let inputFields: HTMLFieldSetElement;
+
+const init = () => {
+    inputFields = document.getElementById("input-fields") as HTMLFieldSetElement;
+    generateBtn = document.getElementById("generate-recovery-btn") as HTMLButtonElement;
+    disableBtn = document.getElementById("disable-recovery-btn") as HTMLButtonElement;
+    generateBtn.addEventListener("click", generateRecoveryKey);
+    disableBtn.addEventListener("click", disableRecoveryKey);
+
+    loadStatus();
+}
+
+const inputsDisabled = (disabled: boolean) => {
+    generateBtn.disabled = disabled;
+    disableBtn.disabled = disabled;
+    inputFields.disabled = disabled;
+}
+
+const loadStatus = () => {
+    let status = document.getElementById("recovery-status");
+    fetchRecoveryStatus().then(recoveryStatus => {
+        if (recoveryStatus.enabled) {
+            status.innerText = "Enabled";
+            status.className = "green-text";
+            disableBtn.classList.remove("hidden");
+        } else {
+            status.innerText = "Not Set";
+            status.className = "red-text";
+            disableBtn.classList.add("hidden");
+        }
+    }).catch(error => {
+        console.error(error);
+        status.innerText = "Unknown";
+    });
+}
+
+const generateRecoveryKey = async () => {
+    let id = document.getElementById("identifier") as HTMLInputElement;
+    let password = document.getElementById("password") as HTMLInputElement;
+    if (!id.value || !password.value) {
+        showMessage("You must enter your email or account ID and password", true);
+        return;
+    }
+
+    inputsDisabled(true);
+    let setRecovery = new interfaces.SetRecoveryKey();
+    let recoveryKey: Uint8Array;
+    try {
+        let kdf = await fetchKDFParams(id.value);
+        let userKey = await crypto.generateUserKey(id.value, password.value, kdf.kdf);
+        setRecovery.loginKeyHash = await crypto.generateLoginKeyHash(
+            userKey, password.value, kdf.kdf);
+
+        let protectedKeyResponse = await fetch(Endpoints.ProtectedKey.path);
+        if (!protectedKeyResponse.ok) {
+            throw new Error(await protectedKeyResponse.text());
+        }
+
+        let protectedKeyData = new interfaces.ProtectedKeyResponse(
+            await protectedKeyResponse.json());
+        let privateKey = await crypto.decryptChunk(userKey, protectedKeyData.protectedKey);
+
+        let keys: interfaces.UserKey[] = [];
+        for (let key of protectedKeyData.keys || []) {
+            let privateUserKey = new interfaces.UserKey();
+            privateUserKey.type = key.type;
+            privateUserKey.publicKey = key.publicKey;
+            privateUserKey.protectedKey = await crypto.decryptChunk(userKey, key.protectedKey);
+            keys.push(privateUserKey);
+        }
+
+        recoveryKey = await crypto.generateRecoveryKey();
+        setRecovery.recoveryKey = await createRecoveryKey(recoveryKey, privateKey, keys);
+    } catch (error) {
+        console.error(error);
+        inputsDisabled(false);
+        showMessage("Error decrypting keys, check your password and try again", true);
+        return;
+    }
+
+    fetch(Endpoints.Recovery.path, {
+        method: "PUT",
+        body: JSON.stringify(setRecovery, jsonReplacer),
+    }).then(async response => {
+        inputsDisabled(false);
+        if (response.ok) {
+            document.getElementById("recovery-key").innerText =
+                crypto.formatRecoveryKey(recoveryKey);
+            document.getElementById("recovery-key-div").classList.remove("hidden");
+            showMessage("Recovery key generated!", false);
+            loadStatus();
+        } else {
+            showMessage("Error setting recovery key: " + await response.text(), true);
+        }
+    }).catch(error => {
+        inputsDisabled(false);
+        console.error(error);
+        alert("Error setting recovery key!");
+    });
+}
+
+const disableRecoveryKey = async () => {
+    if (!confirm("Are you sure you want to disable your recovery key? You " +
+        "will be unable to reset your password if you forget it.")) {
+        return;
+    }
+
+    let id = document.getElementById("identifier") as HTMLInputElement;
+    let password = document.getElementById("password") as HTMLInputElement;
+    if (!id.value || !password.value) {
+        showMessage("You must enter your email or account ID and password", true);
+        return;
+    }
+
+    inputsDisabled(true);
+    let removeRecovery = new interfaces.RemoveRecoveryKey();
+    try {
+        let kdf = await fetchKDFParams(id.value);
+        let userKey = await crypto.generateUserKey(id.value, password.value, kdf.kdf);
+        removeRecovery.loginKeyHash = await crypto.generateLoginKeyHash(
+            userKey, password.value, kdf.kdf);
+    } catch (error) {
+        console.error(error);
+        inputsDisabled(false);
+        showMessage("Error generating login key, check your password and try again", true);
+        return;
+    }
+
+    fetch(Endpoints.Recovery.path, {
+        method: "DELETE",
+        body: JSON.stringify(removeRecovery, jsonReplacer),
+    }).then(async response => {
+        inputsDisabled(false);
+        if (response.ok) {
+            document.getElementById("recovery-key-div").classList.add("hidden");
+            showMessage("Recovery key disabled", false);
+            loadStatus();
+        } else {
+            showMessage("Error disabling recovery key: " + await response.text(), true);
+        }
+    }).catch(error => {
+        inputsDisabled(false);
+        console.error(error);
+        alert("Error disabling recovery key!");
+    });
+}
+
+if (document.readyState !== "loading") {
+    init();
+} else {
+    document.addEventListener("DOMContentLoaded", () => {
+        init();
+    });
+}
@@ -88,7 +88,8 @@ const init = () => {
             updateProgress("Initializing...");
             let [key, salt] = await crypto.deriveSendingKey(
                 formValues.password,
-                undefined);
+                undefined,
+                crypto.sendKDFParams());
 
             let rawKey = await crypto.exportKey(key, "raw");
             let keyHex = toURLSafeBase64(rawKey);
@@ -261,6 +262,14 @@ const submitFormMulti = async (
     }
 
     let encryptedName = await crypto.encryptString(key, name);
+    let encryptor: crypto.FileEncryptor;
+    try {
+        encryptor = await transfer.newFileEncryptor(key);
+    } catch (err) {
+        resetForm();
+        alert(err.message);
+        return;
+    }
 
     let hexName = toHexString(encryptedName);
     let chunks = getNumChunks(size);
@@ -273,9 +282,11 @@ const submitFormMulti = async (
         salt: Array.from(salt),
         downloads: form.downloads,
         size: size,
-        expiration: expString
+        expiration: expString,
+        encryptionVersion: encryptor.version,
+        kdf: crypto.sendKDFParams(),
     }), (id) => {
-        uploadZip(id, key, zip, chunks).then(() => {
+        uploadZip(id, encryptor, zip, chunks).then(() => {
             callback();
         });
     }, () => {
@@ -298,6 +309,14 @@ const submitFormSingle = async (
 ) => {
     let file = form.files[0];
     let encryptedName = await crypto.encryptString(key, file.name);
+    let encryptor: crypto.FileEncryptor;
+    try {
+        encryptor = await transfer.newFileEncryptor(key);
+    } catch (err) {
+        resetForm();
+        alert(err.message);
+        return;
+    }
 
     let hexName = toHexString(encryptedName);
     let chunks = getNumChunks(file.size);
@@ -309,11 +328,13 @@ const submitFormSingle = async (
         salt: [],
         downloads: form.downloads,
         size: file.size,
-        expiration: expString
+        expiration: expString,
+        encryptionVersion: encryptor.version,
+        kdf: crypto.sendKDFParams(),
     }), (id) => {
         let chunk = 1;
         let percent = (chunk / chunks) * 100;
-        transfer.uploadSendChunks(id, file, key, (done: boolean) => {
+        transfer.uploadSendChunks(id, file, encryptor, (done: boolean) => {
             if (done) {
                 showFileTag(id, secret);
                 updateProgressBar(file.size);
@@ -362,34 +383,38 @@ const submitFormText = async (
     });
 }
 
-const uploadZip = async (id, key, zip, chunks) => {
+const uploadZip = async (id, encryptor: crypto.FileEncryptor, zip, chunks) => {
     let i = 0;
     let zipData = new Uint8Array(0);
 
     zip.generateInternalStream({type:"uint8array"}).on("data", async (data: Uint8Array) => {
         zipData = concatTypedArrays(zipData, data);
-        if (zipData.length >= chunkSize) {
+
+        // Always hold back some data, so that the final chunk is only sent
+        // (and marked as final) once the zip stream has ended
+        if (zipData.length > chunkSize) {
+            let index = i;
             let slice = zipData.subarray(0, chunkSize);
-            let blob = await crypto.encryptChunk(key, slice);
+            zipData = zipData.subarray(chunkSize, zipData.length);
+            i += 1;
 
-            updateProgress(`Uploading file... ${i + 1}/${chunks}`)
+            let blob = await encryptor.encryptChunk(index, false, slice);
+            updateProgress(`Uploading file... ${index + 1}/${chunks}`)
             transfer.sendChunk(
                 Endpoints.UploadSendFileData,
                 blob,
                 id,
-                i + 1,
+                index + 1,
                 () => {
                     //
                 },
                 () => {
                     alert("Error uploading file!");
                 });
-            zipData = zipData.subarray(chunkSize, zipData.length);
-            i += 1;
         }
     }).on("end", async () => {
         if (zipData.length > 0) {
-            let blob = await crypto.encryptChunk(key, zipData);
+            let blob = await encryptor.encryptChunk(i, true, zipData);
             updateProgress(`Uploading file... ${i + 1}/${chunks}`);
             transfer.sendChunk(Endpoints.UploadSendFileData, blob, id, i + 1, (tag) => {
                 showFileTag(tag, "");
@@ -437,6 +462,7 @@ const uploadTextOnly = (
         expiration: exp,
         text: Array.from(text),
         size: text.length,
+        kdf: crypto.sendKDFParams(),
     }));
 }
 
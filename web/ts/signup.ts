@@ -2,6 +2,7 @@ import {MaxHintLen} from "./constants.js";
 import * as crypto from "./crypto.js";
 import {Endpoints} from "./endpoints.js";
 import * as interfaces from "./interfaces.js";
+import {createRecoveryKey, fetchKDFParams} from "./keys.js";
 
 let emailToggle;
 let idToggle;
@@ -67,20 +68,31 @@ const setupToggles = () => {
  * generateKeys generates the necessary keys for using YeetFile
  * @param identifier {string} - either email or account ID
  * @param password {string} - the user's password
+ * @param withRecovery {boolean} - whether to generate a recovery key
  * @param keyCallback {(Uint8Array, Uint8Array)} - the user's new private and public keys
  * @returns {Promise<interfaces.Signup>}
  */
 const generateKeys = async (
     identifier: string,
     password: string,
+    withRecovery: boolean,
     keyCallback: (
         signup: interfaces.Signup,
         privKey: Uint8Array,
         pubKey: Uint8Array,
     ) => void,
 ) => {
-    let userKey = await crypto.generateUserKey(identifier, password);
-    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password);
+    let kdf: interfaces.KDFParams;
+    try {
+        kdf = (await fetchKDFParams(identifier)).recommendedKdf;
+    } catch (error) {
+        showMessage(`Error: ${error.message}`, true);
+        inputsDisabled(false);
+        return;
+    }
+
+    let userKey = await crypto.generateUserKey(identifier, password, kdf);
+    let loginKeyHash = await crypto.generateLoginKeyHash(userKey, password, kdf);
     let keyPair = await crypto.generateKeyPair();
     let publicKey = await crypto.exportKey(keyPair.publicKey, "spki");
     let privateKey = await crypto.exportKey(keyPair.privateKey, "pkcs8");
@@ -94,6 +106,22 @@ const generateKeys = async (
     signup.publicKey = publicKey;
     signup.protectedPrivateKey = protectedPrivateKey;
     signup.protectedVaultFolderKey = protectedVaultFolderKey;
+    signup.kdf = kdf;
+
+    if (withRecovery) {
+        let recoveryKey = await crypto.generateRecoveryKey();
+        let saved = window.prompt(
+            "This is your recovery key, which can be used to reset your " +
+            "password if you forget it. Copy it somewhere safe, it will not " +
+            "be shown again:",
+            crypto.formatRecoveryKey(recoveryKey));
+        if (saved === null) {
+            inputsDisabled(false);
+            return;
+        }
+
+        signup.recoveryKey = await createRecoveryKey(recoveryKey, privateKey, []);
+    }
 
     keyCallback(signup, privateKey, publicKey);
 }
@@ -113,6 +141,7 @@ const emailSignup = async () => {
     let emailInput = document.getElementById("email") as HTMLInputElement;
     let passwordInput = document.getElementById("password") as HTMLInputElement;
     let confirmPasswordInput = document.getElementById("confirm-password") as HTMLInputElement;
+    let recoveryInput = document.getElementById("email-recovery-key") as HTMLInputElement;
 
     let password = passwordInput.value;
     let confirmPassword = confirmPasswordInput.value;
@@ -121,6 +150,7 @@ const emailSignup = async () => {
         await generateKeys(
             emailInput.value,
             passwordInput.value,
+            recoveryInput.checked,
             async (signup, privKey, pubKey) => {
                 const dbModule = await import("./db.js");
                 let db = new dbModule.YeetFileDB();
@@ -224,15 +254,19 @@ const generateAccountIDSignupHTML = (id: string, img: string) => {
  * them their one opportunity to copy the ID down.
  * @param id {string} - the user's new account ID
  */
-const generateSuccessHTML = (id: string) => {
+const generateSuccessHTML = (id: string, pending: boolean) => {
     document.addEventListener("click", (event) => {
         if ((event.target as HTMLElement).id === "goto-send") {
             window.location.assign(Endpoints.HTMLSend.path);
         }
     });
 
+    let pendingMsg = pending ?
+        `<p>Your account must be approved by an admin before you can log in.</p>` : "";
+
     return `<p>Your account ID is: <b data-testid="final-account-id">${id}</b> -- write this down!<br>
     This is what you will use to log in, and <b>will not be shown again.</b></p>
+    ${pendingMsg}
     <button data-testid="goto-send" id="goto-send">Start Yeeting</button>`
 }
 
@@ -244,6 +278,7 @@ const verifyAccountID = async (id: string) => {
     let button = document.getElementById(verifyButtonID) as HTMLButtonElement;
     let codeInput = document.getElementById("account-code") as HTMLInputElement;
     let passwordInput = document.getElementById("account-password") as HTMLInputElement;
+    let recoveryInput = document.getElementById("account-recovery-key") as HTMLInputElement;
 
     let password = passwordInput.value;
     button.disabled = true;
@@ -251,6 +286,7 @@ const verifyAccountID = async (id: string) => {
     await generateKeys(
         id,
         password,
+        recoveryInput.checked,
         async (userKeys, privKey, pubKey) => {
             let body = new interfaces.VerifyAccount();
             body.id = id;
@@ -259,6 +295,8 @@ const verifyAccountID = async (id: string) => {
             body.publicKey = userKeys.publicKey;
             body.protectedPrivateKey = userKeys.protectedPrivateKey;
             body.protectedVaultFolderKey = userKeys.protectedVaultFolderKey;
+            body.kdf = userKeys.kdf;
+            body.recoveryKey = userKeys.recoveryKey;
 
             fetch(Endpoints.VerifyAccount.path, {
                 method: "POST", body: JSON.stringify(body, jsonReplacer)
@@ -266,9 +304,10 @@ const verifyAccountID = async (id: string) => {
                 if (response.ok) {
                     const dbModule = await import('./db.js');
                     let db = new dbModule.YeetFileDB();
+                    let pending = response.status === 202;
                     await db.insertVaultKeyPair(privKey, pubKey, "", success => {
                         if (success) {
-                            let html = generateSuccessHTML(id);
+                            let html = generateSuccessHTML(id, pending);
                             addVerifyHTML(html);
                         } else {
                             alert("Error inserting keys into indexed db!");
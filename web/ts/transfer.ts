@@ -1,11 +1,16 @@
+import * as constants from "./constants.js";
 import * as crypto from "./crypto.js";
 import {Endpoint, Endpoints} from "./endpoints.js";
 import * as interfaces from "./interfaces.js";
 
+const unsupportedEncryptionMsg = "The server doesn't support this page's " +
+    "file encryption format, and needs to be updated before uploading files";
+
 type PendingDownload = {
     id: string,
     chunks: number,
     size: number,
+    encryptionVersion: number,
 }
 
 /**
@@ -67,7 +72,7 @@ const readChunk = (file, start, end): Promise<ArrayBuffer> => {
  * @param endpoint {string} - The string endpoint to use for uploading chunks
  * @param id {string} - The file ID returned from uploading metadata
  * @param file {File} - The file object being uploaded
- * @param key {CryptoKey} - The key to use for encrypting each file chunk
+ * @param encryptor {crypto.FileEncryptor} - The encryptor for the file's chunks
  * @param callback {function(boolean)} - A callback indicating successful upload
  * @param errorCallback {function(string)} - A callback with an error message
  */
@@ -75,7 +80,7 @@ const uploadChunks = async (
     endpoint: Endpoint,
     id: string,
     file: File,
-    key: CryptoKey,
+    encryptor: crypto.FileEncryptor,
     callback: (boolean) => void,
     errorCallback: (string) => void,
 ) => {
@@ -108,7 +113,10 @@ const uploadChunks = async (
             }
 
             let data = await readChunk(file, start, end);
-            let blob = await crypto.encryptChunk(key, new Uint8Array(data));
+            let blob = await encryptor.encryptChunk(
+                chunk,
+                chunk === chunks - 1,
+                new Uint8Array(data));
 
             sendChunk(endpoint, blob, id, chunk + 1, (response) => {
                 resolve("");
@@ -219,8 +227,14 @@ const downloadFile = (
         xhr.onreadystatechange = async () => {
             if (xhr.readyState === 4 && xhr.status === 200) {
                 let data = new Uint8Array(await xhr.response.arrayBuffer());
-                crypto.decryptChunk(key, data).then(decryptedChunk => {
-                    writer.write(new Uint8Array(decryptedChunk)).then(() => {
+                crypto.decryptFileChunk(
+                    key,
+                    download.encryptionVersion,
+                    chunkNum - 1,
+                    chunkNum === download.chunks,
+                    data,
+                ).then(decryptedChunk => {
+                    writer.write(decryptedChunk).then(() => {
                         if (chunkNum === download.chunks) {
                             writer.close().then(r => console.log(r));
                             callback(true);
@@ -253,12 +267,18 @@ const downloadFile = (
  * Fetches a single file chunk from the given URL
  * @param url
  * @param key
+ * @param version - The file's encryption version
+ * @param index - The zero-based position of the chunk in the file
+ * @param final - Whether this is the last chunk of the file
  * @param successCallback
  * @param errorCallback
  */
 export const fetchSingleChunk = (
     url: string,
     key: CryptoKey,
+    version: number,
+    index: number,
+    final: boolean,
     successCallback: (Uint8Array) => void,
     errorCallback: () => void,
 ) => {
@@ -270,7 +290,7 @@ export const fetchSingleChunk = (
 
         response.arrayBuffer().then(buf => {
             let data = new Uint8Array(buf);
-            crypto.decryptChunk(key, data).then(decryptedChunk => {
+            crypto.decryptFileChunk(key, version, index, final, data).then(decryptedChunk => {
                 successCallback(decryptedChunk);
             }).catch(err => {
                 console.error(err);
@@ -329,57 +349,80 @@ export const removeUserFromShared = (id, shareID, isFolder) => {
 
 }
 
+/**
+ * fetchPubKey fetches the public keys of another YeetFile user, which should be
+ * checked against the user's pinned contacts before they're used for sharing
+ * @param recipient {string} - The recipient's email or account ID
+ */
+export const fetchPubKey = (recipient: string): Promise<interfaces.PubKeyResponse> => {
+    return new Promise((resolve, reject) => {
+        fetch(`${Endpoints.PubKey.path}?user=${recipient}`).then(async response => {
+            if (!response.ok) {
+                alert("Error sharing: " + await response.text());
+                reject();
+                return;
+            }
+
+            resolve(new interfaces.PubKeyResponse(await response.text()));
+        }).catch(() => {
+            alert("Error fetching user's public key");
+            reject();
+        });
+    });
+}
+
 /**
  * shareItem shares a file or folder with another YeetFile user using that recipient's
  * email or account ID.
  * @param recipient {string} - The recipient's email or account ID
+ * @param recipientKeys {interfaces.PubKeyResponse} - The recipient's public keys
  * @param rawKey {ArrayBuffer} - The decrypted file/folder key
  * @param itemID {string} - The ID of the file or folder
  * @param canModify {boolean} - Whether the recipient can modify/delete the file/folder
  * @param isFolder {boolean} - An indicator of what type of content is being shared
  */
-export const shareItem = (recipient, rawKey, itemID, canModify, isFolder): Promise<interfaces.ShareInfo> => {
+export const shareItem = (
+    recipient,
+    recipientKeys: interfaces.PubKeyResponse,
+    rawKey,
+    itemID,
+    canModify,
+    isFolder,
+): Promise<interfaces.ShareInfo> => {
     let endpoint = isFolder ?
         Endpoints.format(Endpoints.ShareFolder, itemID) :
         Endpoints.format(Endpoints.ShareFile, itemID);
 
-    return new Promise((resolve, reject) => {
-        fetch(`${Endpoints.PubKey.path}?user=${recipient}`).then(async response => {
+    return new Promise(async (resolve, reject) => {
+        let userEncItemKey: Uint8Array;
+        try {
+            userEncItemKey = await crypto.wrapKeyForUser(
+                recipientKeys.publicKey,
+                recipientKeys.keys,
+                new Uint8Array(rawKey));
+        } catch {
+            alert("Error reading user's public key");
+            reject();
+            return;
+        }
+
+        fetch(endpoint, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json",
+            },
+            body: JSON.stringify({
+                user: recipient,
+                protectedKey: Array.from(userEncItemKey),
+                canModify: canModify,
+            })
+        }).then(response => {
             if (!response.ok) {
-                alert("Error sharing: " + await response.text());
+                alert("Error sharing content with user");
                 reject();
-                return;
+            } else {
+                resolve(new interfaces.ShareInfo(response));
             }
-
-            let recipientPublicKey = new interfaces.PubKeyResponse(
-                await response.text()).publicKey;
-            crypto.ingestPublicKey(recipientPublicKey, async userPubKey => {
-                if (!userPubKey) {
-                    alert("Error reading user's public key");
-                    reject();
-                    return;
-                }
-
-                let userEncItemKey = await crypto.encryptRSA(userPubKey, new Uint8Array(rawKey));
-                fetch(endpoint, {
-                    method: "POST",
-                    headers: {
-                        "Content-Type": "application/json",
-                    },
-                    body: JSON.stringify({
-                        user: recipient,
-                        protectedKey: Array.from(userEncItemKey),
-                        canModify: canModify,
-                    })
-                }).then(response => {
-                    if (!response.ok) {
-                        alert("Error sharing content with user");
-                        reject();
-                    } else {
-                        resolve(new interfaces.ShareInfo(response));
-                    }
-                });
-            });
         });
     });
 }
@@ -426,6 +469,26 @@ const getFileWriter = (name, length) => {
     return fileStream.getWriter();
 }
 
+/**
+ * newFileEncryptor returns an encryptor using the latest file encryption
+ * format, as long as the server advertises support for it. Uploads are refused
+ * instead of falling back to an older format.
+ * @param key {CryptoKey} - The file's key
+ */
+export const newFileEncryptor = async (key: CryptoKey): Promise<crypto.FileEncryptor> => {
+    let response = await fetch(Endpoints.ServerInfo.path);
+    if (!response.ok) {
+        throw new Error("Failed to fetch server settings");
+    }
+
+    let info = new interfaces.ServerInfo(await response.json());
+    if (!info.fileEncryption || !info.fileEncryption.includes(constants.LatestFileEncryption)) {
+        throw new Error(unsupportedEncryptionMsg);
+    }
+
+    return new crypto.FileEncryptor(key, constants.LatestFileEncryption);
+}
+
 export const uploadSendMetadata = (metadata: interfaces.UploadMetadata, callback, errorCallback) => {
     uploadMetadata(metadata, Endpoints.UploadSendFileMetadata, callback, errorCallback);
 }
@@ -438,12 +501,12 @@ export const uploadVaultMetadata = (
     uploadMetadata(metadata, Endpoints.UploadVaultFileMetadata, callback, errorCallback);
 }
 
-export const uploadSendChunks = async (id, file, key, callback, errorCallback) => {
-    await uploadChunks(Endpoints.UploadSendFileData, id, file, key, callback, errorCallback);
+export const uploadSendChunks = async (id, file, encryptor, callback, errorCallback) => {
+    await uploadChunks(Endpoints.UploadSendFileData, id, file, encryptor, callback, errorCallback);
 }
 
-export const uploadVaultChunks = async (id, file, key, callback, errorCallback) => {
-    await uploadChunks(Endpoints.UploadVaultFileData, id, file, key, callback, errorCallback);
+export const uploadVaultChunks = async (id, file, encryptor, callback, errorCallback) => {
+    await uploadChunks(Endpoints.UploadVaultFileData, id, file, encryptor, callback, errorCallback);
 }
 
 export const downloadVaultFile = (
@@ -456,7 +519,8 @@ export const downloadVaultFile = (
     let pendingDownload: PendingDownload = {
         id: download.id,
         chunks: download.chunks,
-        size: download.size
+        size: download.size,
+        encryptionVersion: download.encryptionVersion,
     }
 
     downloadFile(
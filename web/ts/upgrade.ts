@@ -1,4 +1,5 @@
 import {Endpoints} from "./endpoints.js";
+import {RedeemVoucherRequest, RedeemVoucherResponse} from "./interfaces.js";
 
 let selectedSendUpgrade: string;
 let selectedVaultUpgrade: string;
@@ -8,11 +9,14 @@ const init = () => {
     setupUpgradeButtons();
     setupBTCPayToggle();
     setupCheckoutButton();
+    setupVoucherRedemption();
 }
 
 const updateCheckoutButton = () => {
-    let checkoutBtn = document.getElementById("checkout-btn") as HTMLButtonElement;
-    checkoutBtn.disabled = !(selectedSendUpgrade || selectedVaultUpgrade);
+    document.querySelectorAll(".checkout-btn").forEach(btn => {
+        let checkoutBtn = btn as HTMLButtonElement;
+        checkoutBtn.disabled = !(selectedSendUpgrade || selectedVaultUpgrade);
+    });
 }
 
 const setupQuantityListeners = () => {
@@ -82,43 +86,93 @@ const setupUpgradeButtons = () => {
 }
 
 const setupCheckoutButton = () => {
-    let checkoutBtn = document.getElementById("checkout-btn") as HTMLButtonElement;
-    checkoutBtn.addEventListener("click", () => {
-        if (!selectedSendUpgrade && !selectedVaultUpgrade) {
-            return;
-        }
+    document.querySelectorAll(".checkout-btn").forEach(btn => {
+        let checkoutBtn = btn as HTMLButtonElement;
+        checkoutBtn.addEventListener("click", () => {
+            startCheckout(checkoutBtn);
+        });
+    });
+}
 
-        let vaultQuantity = "1";
-        if (selectedVaultUpgrade) {
-            let quantityID = `${selectedVaultUpgrade}-quantity`;
-            let quantityEl = document.getElementById(quantityID) as HTMLInputElement;
-            vaultQuantity = quantityEl.value;
+const startCheckout = (checkoutBtn: HTMLButtonElement) => {
+    if (!selectedSendUpgrade && !selectedVaultUpgrade) {
+        return;
+    }
 
-            let quantityInt = parseInt(vaultQuantity);
-            if (isNaN(quantityInt) || quantityInt < 0 || quantityInt > 12) {
-                vaultQuantity = "1";
-            }
-        }
+    let vaultQuantity = "1";
+    if (selectedVaultUpgrade) {
+        let quantityID = `${selectedVaultUpgrade}-quantity`;
+        let quantityEl = document.getElementById(quantityID) as HTMLInputElement;
+        vaultQuantity = quantityEl.value;
 
-        let link = new URL(window.location.origin + Endpoints.StripeCheckout.path);
-        if (selectedSendUpgrade) {
-            link.searchParams.set("send-upgrade", selectedSendUpgrade);
+        let quantityInt = parseInt(vaultQuantity);
+        if (isNaN(quantityInt) || quantityInt < 0 || quantityInt > 12) {
+            vaultQuantity = "1";
         }
+    }
+
+    let link = new URL(window.location.origin + checkoutBtn.dataset.endpoint);
+    if (selectedSendUpgrade) {
+        link.searchParams.set("send-upgrade", selectedSendUpgrade);
+    }
+
+    if (selectedVaultUpgrade) {
+        link.searchParams.set("vault-upgrade", selectedVaultUpgrade);
+        link.searchParams.set("vault-quantity", vaultQuantity);
+    }
+
+    let orgCheckout = document.getElementById("org-checkout") as HTMLInputElement;
+    if (orgCheckout && orgCheckout.checked) {
+        link.searchParams.set("org", "1");
+    }
+
+    checkoutBtn.innerText = "Processing...";
+    document.querySelectorAll(".checkout-btn").forEach(btn => {
+        (btn as HTMLButtonElement).disabled = true;
+    });
+
+    window.location.assign(link);
+}
+
+const setupVoucherRedemption = () => {
+    let redeemBtn = document.getElementById("redeem-voucher-btn") as HTMLButtonElement;
+    let codeInput = document.getElementById("voucher-code") as HTMLInputElement;
 
-        if (selectedVaultUpgrade) {
-            link.searchParams.set("vault-upgrade", selectedVaultUpgrade);
-            link.searchParams.set("vault-quantity", vaultQuantity);
+    redeemBtn.addEventListener("click", () => {
+        if (!codeInput.value) {
+            return;
         }
 
-        checkoutBtn.innerText = "Processing...";
-        checkoutBtn.disabled = true;
+        let request = new RedeemVoucherRequest();
+        request.code = codeInput.value;
+
+        redeemBtn.disabled = true;
+        fetch(Endpoints.RedeemVoucher.path, {
+            method: "POST",
+            body: JSON.stringify(request)
+        }).then(async response => {
+            redeemBtn.disabled = false;
+            if (!response.ok) {
+                showMessage("Error: " + await response.text(), true);
+                return;
+            }
 
-        window.location.assign(link);
+            let redeemed = new RedeemVoucherResponse(await response.json());
+            codeInput.value = "";
+            showMessage(`Voucher redeemed: ${redeemed.name} (x${redeemed.quantity})`, false);
+        }).catch(() => {
+            redeemBtn.disabled = false;
+            showMessage("Error redeeming voucher", true);
+        });
     });
 }
 
 const setupBTCPayToggle = () => {
     let cb = document.getElementById("btcpay-cb") as HTMLInputElement;
+    if (!cb) {
+        return;
+    }
+
     let url = new URL(window.location.href);
 
     cb.addEventListener("click", () => {
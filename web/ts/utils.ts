@@ -150,6 +150,20 @@ const base64ToArray = (base64: string): Uint8Array => {
     return bytes;
 }
 
+/**
+ * Converts a Uint8Array into a base64 string
+ * @param bytes {Uint8Array} - The bytes to convert
+ * @returns {string} The base64 representation of the array
+ */
+const arrayToBase64 = (bytes: Uint8Array): string => {
+    let binaryString = "";
+    for (let i = 0; i < bytes.length; i++) {
+        binaryString += String.fromCharCode(bytes[i]);
+    }
+
+    return btoa(binaryString);
+}
+
 /**
  * Formats a date in the local timezone with a language-sensitive representation
  * @param date {string} - The string date
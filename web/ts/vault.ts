@@ -103,14 +103,24 @@ export class VaultView {
     folderKey: CryptoKey;
     privateKey: CryptoKey;
     publicKey: CryptoKey;
+    x25519Keys: crypto.X25519KeyPair | null;
+    contactsKey: CryptoKey;
 
     folderEndpoint: Endpoint;
     webEndpoint: Endpoint;
 
-    constructor(viewType: VaultViewType, privateKey: CryptoKey, publicKey: CryptoKey) {
+    constructor(
+        viewType: VaultViewType,
+        privateKey: CryptoKey,
+        publicKey: CryptoKey,
+        x25519Keys: crypto.X25519KeyPair | null,
+        contactsKey: CryptoKey,
+    ) {
         this.folderID = this.getFolderID();
         this.privateKey = privateKey;
         this.publicKey = publicKey;
+        this.x25519Keys = x25519Keys;
+        this.contactsKey = contactsKey;
 
         this.viewType = viewType;
         if (viewType === VaultViewType.FileVault) {
@@ -181,7 +191,7 @@ export class VaultView {
             this.passwordDialog.create(
                 this.folderID,
                 this.folderKey || this.publicKey,
-                this.folderKey ? crypto.encryptChunk : crypto.encryptRSA,
+                this.folderKey ? crypto.encryptChunk : (_, data) => this.encryptData(data),
                 this.uploadPassword);
         });
 
@@ -246,7 +256,13 @@ export class VaultView {
                     this.currentFolders[id].key :
                     this.currentItems[id].key;
                 let itemKeyRaw = await crypto.exportKey(itemKey, "raw");
-                this.shareDialog.show(id, itemKeyRaw, isFolder, signal => {
+                let keys = {
+                    privateKey: this.privateKey,
+                    publicKey: this.publicKey,
+                    x25519Keys: this.x25519Keys,
+                    contactsKey: this.contactsKey,
+                };
+                this.shareDialog.show(id, itemKeyRaw, isFolder, keys, signal => {
                     if (signal === dialogs.DialogSignal.Cancel) {
                         return;
                     }
@@ -380,7 +396,10 @@ export class VaultView {
                 await this.loadVault(data);
             } else {
                 // In sub folder, need to iterate through key sequence
-                this.folderKey = await crypto.unwindKeys(this.privateKey, data.keySequence);
+                this.folderKey = await crypto.unwindKeys(
+                    this.privateKey,
+                    data.keySequence,
+                    this.x25519Keys);
                 await this.loadVault(data);
             }
         }
@@ -551,6 +570,17 @@ export class VaultView {
         let protectedKey = await this.encryptData(key);
         let importedKey = await crypto.importKey(key);
 
+        let encryptor: crypto.FileEncryptor;
+        try {
+            encryptor = await transfer.newFileEncryptor(importedKey);
+        } catch (err) {
+            this.paused = false;
+            callback(false, undefined, undefined);
+            alert(err.message);
+            this.showStorageBar("", 0);
+            return;
+        }
+
         let encryptedName = await crypto.encryptString(importedKey, file.name);
         let hexName = toHexString(encryptedName);
         let metadata = new interfaces.VaultUpload({
@@ -559,10 +589,11 @@ export class VaultView {
             chunks: getNumChunks(file.size),
             folderID: this.folderID,
             protectedKey: Array.from(protectedKey),
+            encryptionVersion: encryptor.version,
         });
 
         transfer.uploadVaultMetadata(metadata, id => {
-            transfer.uploadVaultChunks(id, file, importedKey, finished => {
+            transfer.uploadVaultChunks(id, file, encryptor, finished => {
                 this.paused = !finished;
                 if (finished) {
                     let size = this.cache.get(this.folderID).folder.isOwner ? file.size : 0;
@@ -606,26 +637,30 @@ export class VaultView {
     }
 
     /**
-     * Decrypt encrypted file/folder data using either RSA (root folder) or AES
-     * (any subfolder)
+     * Decrypt encrypted file/folder data using either the user's private keys
+     * (root folder) or AES (any subfolder)
      * @param data {Uint8Array} - The data to decrypt
      * @returns {Promise<Uint8Array>} - The decrypted chunk of data
      */
     decryptData = async (data: Uint8Array): Promise<Uint8Array> => {
         if (!this.folderKey) {
-            return await crypto.decryptRSA(this.privateKey, data);
+            return await crypto.unwrapKey(this.privateKey, this.x25519Keys, data);
         } else {
             return await crypto.decryptChunk(this.folderKey, data);
         }
     }
 
     /**
-     * Encrypt file/folder data using either RSA (root folder only) or AES (any
-     * subfolder)
+     * Encrypt file/folder data using either the user's public key (root folder
+     * only, preferring X25519 over RSA) or AES (any subfolder)
      * @param data {Uint8Array} - The data to encrypt
      */
     encryptData = async (data: Uint8Array): Promise<Uint8Array> => {
         if (!this.folderKey) {
+            if (this.x25519Keys) {
+                return await crypto.encryptX25519(this.x25519Keys.publicKey, data);
+            }
+
             return await crypto.encryptRSA(this.publicKey, data);
         } else {
             return await crypto.encryptChunk(this.folderKey, data);
@@ -1042,7 +1077,13 @@ export class VaultView {
                 const fetchChunk = (chunkNum: number) => {
                     let endpoint = Endpoints.DownloadVaultFileData;
                     let chunkURL = Endpoints.format(endpoint, metadata.id, `${chunkNum}`);
-                    transfer.fetchSingleChunk(chunkURL, file.key, chunk => {
+                    transfer.fetchSingleChunk(
+                        chunkURL,
+                        file.key,
+                        metadata.encryptionVersion,
+                        chunkNum - 1,
+                        chunkNum === metadata.chunks,
+                        chunk => {
                         if (!bytes) {
                             bytes = chunk;
                         } else {
@@ -1486,18 +1527,25 @@ export class VaultView {
  * Prepares the vault for usage by grabbing the user's vault key pair
  * @param callback
  */
-export const prep = (callback: (privKey: CryptoKey, pubKey: CryptoKey) => void) => {
+export const prep = (
+    callback: (
+        privKey: CryptoKey,
+        pubKey: CryptoKey,
+        x25519Keys: crypto.X25519KeyPair | null,
+        contactsKey: CryptoKey,
+    ) => void,
+) => {
     let vaultPassDialog = new ProtectedVaultDialog();
     let yeetfileDB = new YeetFileDB();
     yeetfileDB.isPasswordProtected(isProtected => {
         if (isProtected) {
-            vaultPassDialog.show(yeetfileDB, (privKey, pubKey) => {
-                callback(privKey, pubKey);
+            vaultPassDialog.show(yeetfileDB, (privKey, pubKey, x25519Keys, contactsKey) => {
+                callback(privKey, pubKey, x25519Keys, contactsKey);
             }, null);
         } else {
             yeetfileDB.getVaultKeyPair("", false)
-                .then(async ([privKey, pubKey]) => {
-                    callback(privKey as CryptoKey, pubKey as CryptoKey);
+                .then(async ([privKey, pubKey, x25519Keys, contactsKey]) => {
+                    callback(privKey as CryptoKey, pubKey as CryptoKey, x25519Keys, contactsKey);
                 })
                 .catch(e => {
                     console.error(e);
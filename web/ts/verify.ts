@@ -32,7 +32,11 @@ const submitVerificationCode = () => {
         method: "POST",
         body: JSON.stringify(emailVerify, jsonReplacer)
     }).then(async response => {
-        if (response.ok) {
+        if (response.status === 202) {
+            await resetKeys();
+            showMessage("Your email has been verified! Your account must be " +
+                "approved by an admin before you can log in.", false);
+        } else if (response.ok) {
             await resetKeys();
             showMessage("Your email has been verified! Redirecting...", false);
             setTimeout(() => {
@@ -47,7 +51,7 @@ const submitVerificationCode = () => {
 
 const resetKeys = async () => {
     let db = new YeetFileDB();
-    db.getVaultKeyPair("", true).then(async ([privKey, pubKey]) => {
+    db.getVaultKeyPair("", true).then(async ([privKey, pubKey, x25519Keys]) => {
         await db.removeKeys(async success => {
             if (!success) {
                 alert("Error resetting vault keys!");
@@ -65,7 +69,7 @@ const resetKeys = async () => {
                     alert("Error setting vault keys!");
                     return;
                 }
-            });
+            }, x25519Keys);
         });
     }).catch(e => {
         console.error(e);